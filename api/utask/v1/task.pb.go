@@ -0,0 +1,1136 @@
+// TaskService gives programs that can't use NATS KV directly the same task
+// operations as the CLI and the REST API. `ut serve --grpc :9090` serves it.
+//
+// Regenerate the Go code in this directory with `buf generate` from api/.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: utask/v1/task.proto
+
+package utaskv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Task struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// id is the task's 128-hex content id.
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// number is the task's short reference, shown as T-<n>.
+	Number uint64 `protobuf:"varint,2,opt,name=number,proto3" json:"number,omitempty"`
+	// text is the title line, an optional body and trailers.
+	Text string `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`
+	// status is open, in-progress, blocked, done or cancelled.
+	Status string `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	// done is true for done and cancelled tasks.
+	Done bool     `protobuf:"varint,5,opt,name=done,proto3" json:"done,omitempty"`
+	Tags []string `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty"`
+	// created is an RFC 3339 timestamp.
+	Created         string                 `protobuf:"bytes,7,opt,name=created,proto3" json:"created,omitempty"`
+	Priority        int32                  `protobuf:"varint,8,opt,name=priority,proto3" json:"priority,omitempty"`
+	EstimateMinutes int32                  `protobuf:"varint,9,opt,name=estimate_minutes,json=estimateMinutes,proto3" json:"estimate_minutes,omitempty"`
+	CreatedBy       string                 `protobuf:"bytes,10,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	UpdatedBy       string                 `protobuf:"bytes,11,opt,name=updated_by,json=updatedBy,proto3" json:"updated_by,omitempty"`
+	Assignee        string                 `protobuf:"bytes,12,opt,name=assignee,proto3" json:"assignee,omitempty"`
+	Private         bool                   `protobuf:"varint,13,opt,name=private,proto3" json:"private,omitempty"`
+	Contexts        []string               `protobuf:"bytes,14,rep,name=contexts,proto3" json:"contexts,omitempty"`
+	DependsOn       []string               `protobuf:"bytes,15,rep,name=depends_on,json=dependsOn,proto3" json:"depends_on,omitempty"`
+	Recur           string                 `protobuf:"bytes,16,opt,name=recur,proto3" json:"recur,omitempty"`
+	Scheduled       *timestamppb.Timestamp `protobuf:"bytes,17,opt,name=scheduled,proto3" json:"scheduled,omitempty"`
+	SnoozedUntil    *timestamppb.Timestamp `protobuf:"bytes,18,opt,name=snoozed_until,json=snoozedUntil,proto3" json:"snoozed_until,omitempty"`
+	// revision is the task's store revision: pass it as if_revision to
+	// change the task only if nobody else has since.
+	Revision      uint64 `protobuf:"varint,19,opt,name=revision,proto3" json:"revision,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Task) Reset() {
+	*x = Task{}
+	mi := &file_utask_v1_task_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Task) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Task) ProtoMessage() {}
+
+func (x *Task) ProtoReflect() protoreflect.Message {
+	mi := &file_utask_v1_task_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Task.ProtoReflect.Descriptor instead.
+func (*Task) Descriptor() ([]byte, []int) {
+	return file_utask_v1_task_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Task) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Task) GetNumber() uint64 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *Task) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Task) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Task) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+func (x *Task) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Task) GetCreated() string {
+	if x != nil {
+		return x.Created
+	}
+	return ""
+}
+
+func (x *Task) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *Task) GetEstimateMinutes() int32 {
+	if x != nil {
+		return x.EstimateMinutes
+	}
+	return 0
+}
+
+func (x *Task) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *Task) GetUpdatedBy() string {
+	if x != nil {
+		return x.UpdatedBy
+	}
+	return ""
+}
+
+func (x *Task) GetAssignee() string {
+	if x != nil {
+		return x.Assignee
+	}
+	return ""
+}
+
+func (x *Task) GetPrivate() bool {
+	if x != nil {
+		return x.Private
+	}
+	return false
+}
+
+func (x *Task) GetContexts() []string {
+	if x != nil {
+		return x.Contexts
+	}
+	return nil
+}
+
+func (x *Task) GetDependsOn() []string {
+	if x != nil {
+		return x.DependsOn
+	}
+	return nil
+}
+
+func (x *Task) GetRecur() string {
+	if x != nil {
+		return x.Recur
+	}
+	return ""
+}
+
+func (x *Task) GetScheduled() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Scheduled
+	}
+	return nil
+}
+
+func (x *Task) GetSnoozedUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SnoozedUntil
+	}
+	return nil
+}
+
+func (x *Task) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+type CreateRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Text            string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	Tags            []string               `protobuf:"bytes,2,rep,name=tags,proto3" json:"tags,omitempty"`
+	Priority        int32                  `protobuf:"varint,3,opt,name=priority,proto3" json:"priority,omitempty"`
+	EstimateMinutes int32                  `protobuf:"varint,4,opt,name=estimate_minutes,json=estimateMinutes,proto3" json:"estimate_minutes,omitempty"`
+	Assignee        string                 `protobuf:"bytes,5,opt,name=assignee,proto3" json:"assignee,omitempty"`
+	Private         bool                   `protobuf:"varint,6,opt,name=private,proto3" json:"private,omitempty"`
+	Contexts        []string               `protobuf:"bytes,7,rep,name=contexts,proto3" json:"contexts,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CreateRequest) Reset() {
+	*x = CreateRequest{}
+	mi := &file_utask_v1_task_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateRequest) ProtoMessage() {}
+
+func (x *CreateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_utask_v1_task_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateRequest.ProtoReflect.Descriptor instead.
+func (*CreateRequest) Descriptor() ([]byte, []int) {
+	return file_utask_v1_task_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *CreateRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *CreateRequest) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *CreateRequest) GetEstimateMinutes() int32 {
+	if x != nil {
+		return x.EstimateMinutes
+	}
+	return 0
+}
+
+func (x *CreateRequest) GetAssignee() string {
+	if x != nil {
+		return x.Assignee
+	}
+	return ""
+}
+
+func (x *CreateRequest) GetPrivate() bool {
+	if x != nil {
+		return x.Private
+	}
+	return false
+}
+
+func (x *CreateRequest) GetContexts() []string {
+	if x != nil {
+		return x.Contexts
+	}
+	return nil
+}
+
+type CreateResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Task  *Task                  `protobuf:"bytes,1,opt,name=task,proto3" json:"task,omitempty"`
+	// existed is set when an identical task was already there.
+	Existed       bool `protobuf:"varint,2,opt,name=existed,proto3" json:"existed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateResponse) Reset() {
+	*x = CreateResponse{}
+	mi := &file_utask_v1_task_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateResponse) ProtoMessage() {}
+
+func (x *CreateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_utask_v1_task_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateResponse.ProtoReflect.Descriptor instead.
+func (*CreateResponse) Descriptor() ([]byte, []int) {
+	return file_utask_v1_task_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateResponse) GetTask() *Task {
+	if x != nil {
+		return x.Task
+	}
+	return nil
+}
+
+func (x *CreateResponse) GetExisted() bool {
+	if x != nil {
+		return x.Existed
+	}
+	return false
+}
+
+type GetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRequest) Reset() {
+	*x = GetRequest{}
+	mi := &file_utask_v1_task_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRequest) ProtoMessage() {}
+
+func (x *GetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_utask_v1_task_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return file_utask_v1_task_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// Tags is a tag list whose presence can be told apart from an empty one.
+type Tags struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tags          []string               `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tags) Reset() {
+	*x = Tags{}
+	mi := &file_utask_v1_task_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tags) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tags) ProtoMessage() {}
+
+func (x *Tags) ProtoReflect() protoreflect.Message {
+	mi := &file_utask_v1_task_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tags.ProtoReflect.Descriptor instead.
+func (*Tags) Descriptor() ([]byte, []int) {
+	return file_utask_v1_task_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Tags) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type UpdateRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Text  *string                `protobuf:"bytes,2,opt,name=text,proto3,oneof" json:"text,omitempty"`
+	Done  *bool                  `protobuf:"varint,3,opt,name=done,proto3,oneof" json:"done,omitempty"`
+	// tags replaces the task's tags when set.
+	Tags     *Tags  `protobuf:"bytes,4,opt,name=tags,proto3" json:"tags,omitempty"`
+	Priority *int32 `protobuf:"varint,5,opt,name=priority,proto3,oneof" json:"priority,omitempty"`
+	// assignee reassigns the task; an empty string unassigns it.
+	Assignee *string `protobuf:"bytes,6,opt,name=assignee,proto3,oneof" json:"assignee,omitempty"`
+	Private  *bool   `protobuf:"varint,7,opt,name=private,proto3,oneof" json:"private,omitempty"`
+	// if_revision, when non-zero, fails the update with ABORTED unless the
+	// task is still at this revision.
+	IfRevision    uint64 `protobuf:"varint,8,opt,name=if_revision,json=ifRevision,proto3" json:"if_revision,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRequest) Reset() {
+	*x = UpdateRequest{}
+	mi := &file_utask_v1_task_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRequest) ProtoMessage() {}
+
+func (x *UpdateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_utask_v1_task_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRequest.ProtoReflect.Descriptor instead.
+func (*UpdateRequest) Descriptor() ([]byte, []int) {
+	return file_utask_v1_task_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UpdateRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateRequest) GetText() string {
+	if x != nil && x.Text != nil {
+		return *x.Text
+	}
+	return ""
+}
+
+func (x *UpdateRequest) GetDone() bool {
+	if x != nil && x.Done != nil {
+		return *x.Done
+	}
+	return false
+}
+
+func (x *UpdateRequest) GetTags() *Tags {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *UpdateRequest) GetPriority() int32 {
+	if x != nil && x.Priority != nil {
+		return *x.Priority
+	}
+	return 0
+}
+
+func (x *UpdateRequest) GetAssignee() string {
+	if x != nil && x.Assignee != nil {
+		return *x.Assignee
+	}
+	return ""
+}
+
+func (x *UpdateRequest) GetPrivate() bool {
+	if x != nil && x.Private != nil {
+		return *x.Private
+	}
+	return false
+}
+
+func (x *UpdateRequest) GetIfRevision() uint64 {
+	if x != nil {
+		return x.IfRevision
+	}
+	return 0
+}
+
+type TaskRef struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// if_revision, when non-zero, fails the call with ABORTED unless the task
+	// is still at this revision.
+	IfRevision    uint64 `protobuf:"varint,2,opt,name=if_revision,json=ifRevision,proto3" json:"if_revision,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskRef) Reset() {
+	*x = TaskRef{}
+	mi := &file_utask_v1_task_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskRef) ProtoMessage() {}
+
+func (x *TaskRef) ProtoReflect() protoreflect.Message {
+	mi := &file_utask_v1_task_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskRef.ProtoReflect.Descriptor instead.
+func (*TaskRef) Descriptor() ([]byte, []int) {
+	return file_utask_v1_task_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *TaskRef) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *TaskRef) GetIfRevision() uint64 {
+	if x != nil {
+		return x.IfRevision
+	}
+	return 0
+}
+
+type DeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteResponse) Reset() {
+	*x = DeleteResponse{}
+	mi := &file_utask_v1_task_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteResponse) ProtoMessage() {}
+
+func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_utask_v1_task_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
+func (*DeleteResponse) Descriptor() ([]byte, []int) {
+	return file_utask_v1_task_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeleteResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Tag   string                 `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	// any_tags keeps tasks with ANY of these tags, all_tags those with ALL.
+	AnyTags []string `protobuf:"bytes,2,rep,name=any_tags,json=anyTags,proto3" json:"any_tags,omitempty"`
+	AllTags []string `protobuf:"bytes,3,rep,name=all_tags,json=allTags,proto3" json:"all_tags,omitempty"`
+	// status is open (any open state), in-progress, blocked, done,
+	// cancelled, closed or review.
+	Status string `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	// assignee keeps tasks assigned to this identity, or "none" for
+	// unassigned ones.
+	Assignee string `protobuf:"bytes,5,opt,name=assignee,proto3" json:"assignee,omitempty"`
+	// context keeps tasks carrying this context, e.g. "@home".
+	Context string `protobuf:"bytes,6,opt,name=context,proto3" json:"context,omitempty"`
+	// limit caps the number of tasks (0 = all).
+	Limit         int32 `protobuf:"varint,7,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_utask_v1_task_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_utask_v1_task_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_utask_v1_task_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *ListRequest) GetAnyTags() []string {
+	if x != nil {
+		return x.AnyTags
+	}
+	return nil
+}
+
+func (x *ListRequest) GetAllTags() []string {
+	if x != nil {
+		return x.AllTags
+	}
+	return nil
+}
+
+func (x *ListRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ListRequest) GetAssignee() string {
+	if x != nil {
+		return x.Assignee
+	}
+	return ""
+}
+
+func (x *ListRequest) GetContext() string {
+	if x != nil {
+		return x.Context
+	}
+	return ""
+}
+
+func (x *ListRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type QueryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AnyTags       []string               `protobuf:"bytes,1,rep,name=any_tags,json=anyTags,proto3" json:"any_tags,omitempty"`
+	AllTags       []string               `protobuf:"bytes,2,rep,name=all_tags,json=allTags,proto3" json:"all_tags,omitempty"`
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryRequest) Reset() {
+	*x = QueryRequest{}
+	mi := &file_utask_v1_task_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryRequest) ProtoMessage() {}
+
+func (x *QueryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_utask_v1_task_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryRequest.ProtoReflect.Descriptor instead.
+func (*QueryRequest) Descriptor() ([]byte, []int) {
+	return file_utask_v1_task_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *QueryRequest) GetAnyTags() []string {
+	if x != nil {
+		return x.AnyTags
+	}
+	return nil
+}
+
+func (x *QueryRequest) GetAllTags() []string {
+	if x != nil {
+		return x.AllTags
+	}
+	return nil
+}
+
+func (x *QueryRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type WatchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// tag passes only changes to tasks carrying it, the change that removes
+	// it and deletes of tasks that carried it.
+	Tag           string `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_utask_v1_task_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_utask_v1_task_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_utask_v1_task_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *WatchRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+type Event struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// op is put or delete.
+	Op string `protobuf:"bytes,1,opt,name=op,proto3" json:"op,omitempty"`
+	// kind is created, updated, closed, cancelled, reopened or deleted.
+	Kind     string                 `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	Id       string                 `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`
+	Revision uint64                 `protobuf:"varint,4,opt,name=revision,proto3" json:"revision,omitempty"`
+	Time     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=time,proto3" json:"time,omitempty"`
+	// task is the new revision, or for a delete the last one seen, if any.
+	Task          *Task `protobuf:"bytes,6,opt,name=task,proto3" json:"task,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_utask_v1_task_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_utask_v1_task_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_utask_v1_task_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Event) GetOp() string {
+	if x != nil {
+		return x.Op
+	}
+	return ""
+}
+
+func (x *Event) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Event) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Event) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *Event) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Event) GetTask() *Task {
+	if x != nil {
+		return x.Task
+	}
+	return nil
+}
+
+var File_utask_v1_task_proto protoreflect.FileDescriptor
+
+const file_utask_v1_task_proto_rawDesc = "" +
+	"\n" +
+	"\x13utask/v1/task.proto\x12\butask.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xbf\x04\n" +
+	"\x04Task\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06number\x18\x02 \x01(\x04R\x06number\x12\x12\n" +
+	"\x04text\x18\x03 \x01(\tR\x04text\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12\x12\n" +
+	"\x04done\x18\x05 \x01(\bR\x04done\x12\x12\n" +
+	"\x04tags\x18\x06 \x03(\tR\x04tags\x12\x18\n" +
+	"\acreated\x18\a \x01(\tR\acreated\x12\x1a\n" +
+	"\bpriority\x18\b \x01(\x05R\bpriority\x12)\n" +
+	"\x10estimate_minutes\x18\t \x01(\x05R\x0festimateMinutes\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\n" +
+	" \x01(\tR\tcreatedBy\x12\x1d\n" +
+	"\n" +
+	"updated_by\x18\v \x01(\tR\tupdatedBy\x12\x1a\n" +
+	"\bassignee\x18\f \x01(\tR\bassignee\x12\x18\n" +
+	"\aprivate\x18\r \x01(\bR\aprivate\x12\x1a\n" +
+	"\bcontexts\x18\x0e \x03(\tR\bcontexts\x12\x1d\n" +
+	"\n" +
+	"depends_on\x18\x0f \x03(\tR\tdependsOn\x12\x14\n" +
+	"\x05recur\x18\x10 \x01(\tR\x05recur\x128\n" +
+	"\tscheduled\x18\x11 \x01(\v2\x1a.google.protobuf.TimestampR\tscheduled\x12?\n" +
+	"\rsnoozed_until\x18\x12 \x01(\v2\x1a.google.protobuf.TimestampR\fsnoozedUntil\x12\x1a\n" +
+	"\brevision\x18\x13 \x01(\x04R\brevision\"\xd0\x01\n" +
+	"\rCreateRequest\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\x12\x12\n" +
+	"\x04tags\x18\x02 \x03(\tR\x04tags\x12\x1a\n" +
+	"\bpriority\x18\x03 \x01(\x05R\bpriority\x12)\n" +
+	"\x10estimate_minutes\x18\x04 \x01(\x05R\x0festimateMinutes\x12\x1a\n" +
+	"\bassignee\x18\x05 \x01(\tR\bassignee\x12\x18\n" +
+	"\aprivate\x18\x06 \x01(\bR\aprivate\x12\x1a\n" +
+	"\bcontexts\x18\a \x03(\tR\bcontexts\"N\n" +
+	"\x0eCreateResponse\x12\"\n" +
+	"\x04task\x18\x01 \x01(\v2\x0e.utask.v1.TaskR\x04task\x12\x18\n" +
+	"\aexisted\x18\x02 \x01(\bR\aexisted\"\x1c\n" +
+	"\n" +
+	"GetRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x1a\n" +
+	"\x04Tags\x12\x12\n" +
+	"\x04tags\x18\x01 \x03(\tR\x04tags\"\xaf\x02\n" +
+	"\rUpdateRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\x04text\x18\x02 \x01(\tH\x00R\x04text\x88\x01\x01\x12\x17\n" +
+	"\x04done\x18\x03 \x01(\bH\x01R\x04done\x88\x01\x01\x12\"\n" +
+	"\x04tags\x18\x04 \x01(\v2\x0e.utask.v1.TagsR\x04tags\x12\x1f\n" +
+	"\bpriority\x18\x05 \x01(\x05H\x02R\bpriority\x88\x01\x01\x12\x1f\n" +
+	"\bassignee\x18\x06 \x01(\tH\x03R\bassignee\x88\x01\x01\x12\x1d\n" +
+	"\aprivate\x18\a \x01(\bH\x04R\aprivate\x88\x01\x01\x12\x1f\n" +
+	"\vif_revision\x18\b \x01(\x04R\n" +
+	"ifRevisionB\a\n" +
+	"\x05_textB\a\n" +
+	"\x05_doneB\v\n" +
+	"\t_priorityB\v\n" +
+	"\t_assigneeB\n" +
+	"\n" +
+	"\b_private\":\n" +
+	"\aTaskRef\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1f\n" +
+	"\vif_revision\x18\x02 \x01(\x04R\n" +
+	"ifRevision\" \n" +
+	"\x0eDeleteResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xb9\x01\n" +
+	"\vListRequest\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\x12\x19\n" +
+	"\bany_tags\x18\x02 \x03(\tR\aanyTags\x12\x19\n" +
+	"\ball_tags\x18\x03 \x03(\tR\aallTags\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12\x1a\n" +
+	"\bassignee\x18\x05 \x01(\tR\bassignee\x12\x18\n" +
+	"\acontext\x18\x06 \x01(\tR\acontext\x12\x14\n" +
+	"\x05limit\x18\a \x01(\x05R\x05limit\"Z\n" +
+	"\fQueryRequest\x12\x19\n" +
+	"\bany_tags\x18\x01 \x03(\tR\aanyTags\x12\x19\n" +
+	"\ball_tags\x18\x02 \x03(\tR\aallTags\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\" \n" +
+	"\fWatchRequest\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\"\xab\x01\n" +
+	"\x05Event\x12\x0e\n" +
+	"\x02op\x18\x01 \x01(\tR\x02op\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x12\x0e\n" +
+	"\x02id\x18\x03 \x01(\tR\x02id\x12\x1a\n" +
+	"\brevision\x18\x04 \x01(\x04R\brevision\x12.\n" +
+	"\x04time\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\"\n" +
+	"\x04task\x18\x06 \x01(\v2\x0e.utask.v1.TaskR\x04task2\xd2\x03\n" +
+	"\vTaskService\x12;\n" +
+	"\x06Create\x12\x17.utask.v1.CreateRequest\x1a\x18.utask.v1.CreateResponse\x12+\n" +
+	"\x03Get\x12\x14.utask.v1.GetRequest\x1a\x0e.utask.v1.Task\x121\n" +
+	"\x06Update\x12\x17.utask.v1.UpdateRequest\x1a\x0e.utask.v1.Task\x12*\n" +
+	"\x05Close\x12\x11.utask.v1.TaskRef\x1a\x0e.utask.v1.Task\x12+\n" +
+	"\x06Reopen\x12\x11.utask.v1.TaskRef\x1a\x0e.utask.v1.Task\x125\n" +
+	"\x06Delete\x12\x11.utask.v1.TaskRef\x1a\x18.utask.v1.DeleteResponse\x12/\n" +
+	"\x04List\x12\x15.utask.v1.ListRequest\x1a\x0e.utask.v1.Task0\x01\x121\n" +
+	"\x05Query\x12\x16.utask.v1.QueryRequest\x1a\x0e.utask.v1.Task0\x01\x122\n" +
+	"\x05Watch\x12\x16.utask.v1.WatchRequest\x1a\x0f.utask.v1.Event0\x01B0Z.github.com/iainlowe/utask/api/utask/v1;utaskv1b\x06proto3"
+
+var (
+	file_utask_v1_task_proto_rawDescOnce sync.Once
+	file_utask_v1_task_proto_rawDescData []byte
+)
+
+func file_utask_v1_task_proto_rawDescGZIP() []byte {
+	file_utask_v1_task_proto_rawDescOnce.Do(func() {
+		file_utask_v1_task_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_utask_v1_task_proto_rawDesc), len(file_utask_v1_task_proto_rawDesc)))
+	})
+	return file_utask_v1_task_proto_rawDescData
+}
+
+var file_utask_v1_task_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_utask_v1_task_proto_goTypes = []any{
+	(*Task)(nil),                  // 0: utask.v1.Task
+	(*CreateRequest)(nil),         // 1: utask.v1.CreateRequest
+	(*CreateResponse)(nil),        // 2: utask.v1.CreateResponse
+	(*GetRequest)(nil),            // 3: utask.v1.GetRequest
+	(*Tags)(nil),                  // 4: utask.v1.Tags
+	(*UpdateRequest)(nil),         // 5: utask.v1.UpdateRequest
+	(*TaskRef)(nil),               // 6: utask.v1.TaskRef
+	(*DeleteResponse)(nil),        // 7: utask.v1.DeleteResponse
+	(*ListRequest)(nil),           // 8: utask.v1.ListRequest
+	(*QueryRequest)(nil),          // 9: utask.v1.QueryRequest
+	(*WatchRequest)(nil),          // 10: utask.v1.WatchRequest
+	(*Event)(nil),                 // 11: utask.v1.Event
+	(*timestamppb.Timestamp)(nil), // 12: google.protobuf.Timestamp
+}
+var file_utask_v1_task_proto_depIdxs = []int32{
+	12, // 0: utask.v1.Task.scheduled:type_name -> google.protobuf.Timestamp
+	12, // 1: utask.v1.Task.snoozed_until:type_name -> google.protobuf.Timestamp
+	0,  // 2: utask.v1.CreateResponse.task:type_name -> utask.v1.Task
+	4,  // 3: utask.v1.UpdateRequest.tags:type_name -> utask.v1.Tags
+	12, // 4: utask.v1.Event.time:type_name -> google.protobuf.Timestamp
+	0,  // 5: utask.v1.Event.task:type_name -> utask.v1.Task
+	1,  // 6: utask.v1.TaskService.Create:input_type -> utask.v1.CreateRequest
+	3,  // 7: utask.v1.TaskService.Get:input_type -> utask.v1.GetRequest
+	5,  // 8: utask.v1.TaskService.Update:input_type -> utask.v1.UpdateRequest
+	6,  // 9: utask.v1.TaskService.Close:input_type -> utask.v1.TaskRef
+	6,  // 10: utask.v1.TaskService.Reopen:input_type -> utask.v1.TaskRef
+	6,  // 11: utask.v1.TaskService.Delete:input_type -> utask.v1.TaskRef
+	8,  // 12: utask.v1.TaskService.List:input_type -> utask.v1.ListRequest
+	9,  // 13: utask.v1.TaskService.Query:input_type -> utask.v1.QueryRequest
+	10, // 14: utask.v1.TaskService.Watch:input_type -> utask.v1.WatchRequest
+	2,  // 15: utask.v1.TaskService.Create:output_type -> utask.v1.CreateResponse
+	0,  // 16: utask.v1.TaskService.Get:output_type -> utask.v1.Task
+	0,  // 17: utask.v1.TaskService.Update:output_type -> utask.v1.Task
+	0,  // 18: utask.v1.TaskService.Close:output_type -> utask.v1.Task
+	0,  // 19: utask.v1.TaskService.Reopen:output_type -> utask.v1.Task
+	7,  // 20: utask.v1.TaskService.Delete:output_type -> utask.v1.DeleteResponse
+	0,  // 21: utask.v1.TaskService.List:output_type -> utask.v1.Task
+	0,  // 22: utask.v1.TaskService.Query:output_type -> utask.v1.Task
+	11, // 23: utask.v1.TaskService.Watch:output_type -> utask.v1.Event
+	15, // [15:24] is the sub-list for method output_type
+	6,  // [6:15] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_utask_v1_task_proto_init() }
+func file_utask_v1_task_proto_init() {
+	if File_utask_v1_task_proto != nil {
+		return
+	}
+	file_utask_v1_task_proto_msgTypes[5].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_utask_v1_task_proto_rawDesc), len(file_utask_v1_task_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_utask_v1_task_proto_goTypes,
+		DependencyIndexes: file_utask_v1_task_proto_depIdxs,
+		MessageInfos:      file_utask_v1_task_proto_msgTypes,
+	}.Build()
+	File_utask_v1_task_proto = out.File
+	file_utask_v1_task_proto_goTypes = nil
+	file_utask_v1_task_proto_depIdxs = nil
+}
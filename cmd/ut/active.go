@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdActive shows the caller's running timers: each task started with
+// `ut start` and not yet stopped, how long it has run, and the time on
+// the task counting it.
+func cmdActive(c *cli.Context) error {
+	cfg := getConfig(c)
+	ctx := utask.WithWorker(c.Context, c.String("worker"))
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	timers, err := store.Timers(ctx)
+	if err != nil {
+		return err
+	}
+	type timer struct {
+		utask.Claim
+		Ref     string `json:"ref"`
+		Title   string `json:"title"`
+		Elapsed string `json:"elapsed"`
+		Total   string `json:"total"`
+	}
+	now := time.Now()
+	out := []timer{}
+	for _, cl := range timers {
+		t, _, err := store.GetTask(ctx, cl.Task)
+		if err != nil {
+			continue
+		}
+		elapsed := cl.Elapsed(now)
+		out = append(out, timer{Claim: cl, Ref: t.Ref(), Title: t.Short(), Elapsed: utask.FormatDuration(elapsed), Total: utask.FormatDuration(t.TimeSpent() + elapsed)})
+	}
+	if c.Bool("verbose") {
+		b, _ := json.MarshalIndent(out, "", "  ")
+		fmt.Println(string(b))
+		return nil
+	}
+	if len(out) == 0 {
+		fmt.Println(tr(c).Sprintf("no timer running"))
+		return nil
+	}
+	for _, p := range out {
+		fmt.Printf("%s\t%s\t%s\t%s\n", p.Ref, p.Elapsed, tr(c).Sprintf("%s in total", p.Total), p.Title)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdAnnotate appends a note to a task.
+func cmdAnnotate(c *cli.Context) error {
+	if c.NArg() < 2 {
+		return fmt.Errorf("usage: ut annotate <id> <text>")
+	}
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	rid, _, err := store.Resolve(ctx, c.Args().First())
+	if err != nil {
+		return err
+	}
+	t, err := store.AnnotateTask(ctx, rid, strings.Join(c.Args().Tail(), " "))
+	if err != nil {
+		return err
+	}
+	if c.Bool("verbose") {
+		b, _ := json.MarshalIndent(t, "", "  ")
+		fmt.Println(string(b))
+		return nil
+	}
+	fmt.Println(tr(c).Sprintf("%s annotated", t.ID))
+	return nil
+}
+
+// printTaskNotes renders a task for `ut get --annotations`: its text, then
+// its annotations oldest first.
+func printTaskNotes(c *cli.Context, t utask.Task) {
+	fmt.Printf("%s\t%s\n", shortID(t.ID), t.Short())
+	if d := t.Details(); d != "" {
+		fmt.Println(indent(d))
+	}
+	if len(t.Annotations) == 0 {
+		fmt.Println(tr(c).Sprintf("no annotations"))
+		return
+	}
+	for _, a := range t.Annotations {
+		who := a.By
+		if who == "" {
+			who = "-"
+		}
+		fmt.Printf("\n%s  %s\n%s\n", tr(c).DateTime(a.When), who, indent(a.Text))
+	}
+}
+
+func indent(s string) string {
+	return "  " + strings.ReplaceAll(s, "\n", "\n  ")
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdAssign sets or clears a task's assignee.
+func cmdAssign(c *cli.Context) error {
+	if c.NArg() < 2 {
+		return fmt.Errorf("usage: ut assign <id> <who|none>")
+	}
+	cfg := getConfig(c)
+	who, err := resolvePerson(cfg, c.Args().Get(1))
+	if err != nil {
+		return err
+	}
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	rid, _, err := store.Resolve(ctx, c.Args().First())
+	if err != nil {
+		return err
+	}
+	t, err := store.UpdateTask(ctx, rid, utask.UpdateSet{Assignee: &who, IfRevision: c.Uint64("if-revision")})
+	if err != nil {
+		return err
+	}
+	switch {
+	case c.Bool("verbose"):
+		b, _ := json.MarshalIndent(t, "", "  ")
+		fmt.Println(string(b))
+	case who == "":
+		fmt.Println(tr(c).Sprintf("%s unassigned", t.ID))
+	default:
+		fmt.Println(tr(c).Sprintf("%s assigned to %s", t.ID, who))
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	conf "github.com/iainlowe/utask/internal/config"
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+func TestResolvePerson(t *testing.T) {
+	cfg := &conf.Config{
+		Identity: conf.IdentityConfig{Name: "Ada", Email: "ada@example.org"},
+		People:   map[string]conf.Person{"bob": {Name: "Bob Smith", Email: "bob@example.org"}},
+	}
+	cases := map[string]string{
+		"none":         "",
+		"bob":          "Bob Smith <bob@example.org>",
+		"Bob":          "Bob Smith <bob@example.org>",
+		"me":           "Ada <ada@example.org>",
+		"carol":        "carol",
+		" Dana Lee ":   "Dana Lee",
+		"d@example.io": "d@example.io",
+	}
+	for who, want := range cases {
+		if got, err := resolvePerson(cfg, who); err != nil || got != want {
+			t.Errorf("resolvePerson(%q) = %q, %v, want %q", who, got, err, want)
+		}
+	}
+}
+
+// runUT runs ut with args against a sqlite profile in dir, configured
+// with identity Ada and the people: handle bob.
+func runUT(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cfgPath := filepath.Join(dir, "config.yaml")
+	if _, err := os.Stat(cfgPath); err != nil {
+		cfg := "storage:\n  driver: sqlite\n  path: " + filepath.Join(dir, "tasks.db") + "\n" +
+			"cache:\n  disabled: true\n" +
+			"identity:\n  name: Ada\n  email: ada@example.org\n" +
+			"people:\n  bob:\n    name: Bob Smith\n    email: bob@example.org\n"
+		if err := os.WriteFile(cfgPath, []byte(cfg), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := newApp().Run(append([]string{"ut", "--config", cfgPath}, args...)); err != nil {
+		t.Fatalf("ut %v: %v", args, err)
+	}
+}
+
+func TestCmdAssign(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("UTASK_BACKEND", "sqlite")
+	runUT(t, dir, "create", "--title", "water the plants", "--assign", "bob")
+	assignee := func() string {
+		t.Helper()
+		s, err := utask.OpenSQLite(context.Background(), filepath.Join(dir, "tasks.db"), "default", utask.Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+		task, _, err := s.GetTask(context.Background(), mustResolve(t, s, "T-1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return task.Assignee
+	}
+	if got := assignee(); got != "Bob Smith <bob@example.org>" {
+		t.Fatalf("create --assign bob: assignee %q", got)
+	}
+	steps := []struct{ who, want string }{
+		{"me", "Ada <ada@example.org>"},
+		{"none", ""},
+		{"carol", "carol"},
+	}
+	for _, st := range steps {
+		runUT(t, dir, "assign", "T-1", st.who)
+		if got := assignee(); got != st.want {
+			t.Fatalf("assign %s: assignee %q, want %q", st.who, got, st.want)
+		}
+	}
+	if err := newApp().Run([]string{"ut", "--config", filepath.Join(dir, "config.yaml"), "assign", "T-1"}); err == nil {
+		t.Fatal("assign without a person succeeded")
+	}
+}
+
+func mustResolve(t *testing.T, s *utask.Store, ref string) string {
+	t.Helper()
+	id, _, err := s.Resolve(context.Background(), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
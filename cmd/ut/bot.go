@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/iainlowe/utask/internal/bot"
+	conf "github.com/iainlowe/utask/internal/config"
+	cli "github.com/urfave/cli/v2"
+)
+
+// botTransport builds the chat transport selected in config.
+func botTransport(cfg conf.BotConfig) (bot.Transport, error) {
+	client := &http.Client{}
+	switch {
+	case cfg.Matrix.Room != "":
+		if cfg.Matrix.Homeserver == "" || cfg.Matrix.Token == "" {
+			return nil, fmt.Errorf("bot.matrix needs homeserver, token and room")
+		}
+		return &bot.Matrix{Homeserver: cfg.Matrix.Homeserver, Token: cfg.Matrix.Token, Room: cfg.Matrix.Room, Client: client}, nil
+	case cfg.Discord.Channel != "":
+		if cfg.Discord.Token == "" {
+			return nil, fmt.Errorf("bot.discord needs token and channel")
+		}
+		return &bot.Discord{Token: cfg.Discord.Token, Channel: cfg.Discord.Channel, Client: client}, nil
+	}
+	return nil, fmt.Errorf("no bot transport configured (bot.matrix or bot.discord)")
+}
+
+func cmdBot(c *cli.Context) error {
+	cfg := getConfig(c)
+	tr, err := botTransport(cfg.Bot)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	return bot.New(store, tr, cfg.Bot.Prefix, cfg.Bot.Notify).Run(ctx)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// bulkFlags select the tasks a bulk command changes, like ut list's.
+func bulkFlags(extra ...cli.Flag) []cli.Flag {
+	return append([]cli.Flag{
+		&cli.StringFlag{Name: "tag", Usage: "tasks with this tag"},
+		&cli.StringFlag{Name: "tags", Usage: "tasks with ANY of these comma-separated tags"},
+		&cli.StringFlag{Name: "all-tags", Usage: "tasks with ALL of these comma-separated tags"},
+		&cli.StringFlag{Name: "status", Usage: "tasks in this status: open (any open state)|in-progress|blocked|done|cancelled|closed|review"},
+		&cli.StringFlag{Name: "assignee", Usage: "tasks assigned to me, a name/email, or none for unassigned"},
+		&cli.BoolFlag{Name: "atomic", Usage: "change every matching task or, if any fails, none of them"},
+	}, extra...)
+}
+
+// bulkMatches returns the ids of the tasks the filter flags select. At
+// least one filter is required so a bare command can't touch every task.
+func bulkMatches(c *cli.Context, store *utask.Store) ([]string, error) {
+	if c.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q; select tasks with --tag, --tags, --all-tags, --status or --assignee", c.Args().First())
+	}
+	f, err := listFilter(c)
+	if err != nil {
+		return nil, err
+	}
+	if f.Tag == "" && len(f.Any) == 0 && len(f.All) == 0 && f.Status == "" && f.Assignee == "" && !f.Unassigned {
+		return nil, errors.New("select tasks with --tag, --tags, --all-tags, --status or --assignee")
+	}
+	it, err := store.ListIter(c.Context, f)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Task().ID)
+	}
+	return ids, it.Err()
+}
+
+// runBulk applies op to the selected tasks and reports the outcome: a
+// summary line, or the result as JSON with --verbose. It fails if any task
+// could not be changed.
+func runBulk(c *cli.Context, op func(*utask.Store, []string) (utask.BulkResult, error), summary func(utask.BulkResult) string) error {
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	ids, err := bulkMatches(c, store)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		fmt.Println(tr(c).Sprintf("no matching tasks"))
+		return nil
+	}
+	res, err := op(store, ids)
+	if err != nil && res.Changed == 0 && len(res.Failed) == 0 {
+		return err
+	}
+	if c.Bool("verbose") {
+		b, _ := json.MarshalIndent(res, "", "  ")
+		fmt.Println(string(b))
+	} else {
+		for _, f := range res.Failed {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", f.ID, f.Error)
+		}
+		fmt.Println(summary(res))
+	}
+	if err != nil {
+		return err
+	}
+	if len(res.Failed) > 0 {
+		return fmt.Errorf("%d of %d tasks failed", len(res.Failed), len(ids))
+	}
+	return nil
+}
+
+// runAtomic applies change to each of ids in one Store.Txn, for --atomic:
+// either every task changes or none does.
+func runAtomic(c *cli.Context, store *utask.Store, ids []string, change func(*utask.Tx, string) (bool, error)) (utask.BulkResult, error) {
+	var res utask.BulkResult
+	err := store.Txn(c.Context, func(tx *utask.Tx) error {
+		res = utask.BulkResult{}
+		for _, id := range ids {
+			changed, err := change(tx, id)
+			if err != nil {
+				return fmt.Errorf("%s: %w", id, err)
+			}
+			if changed {
+				res.Changed++
+			} else {
+				res.Unchanged++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return utask.BulkResult{}, err
+	}
+	return res, nil
+}
+
+// cmdBulkClose closes every task the filter flags select.
+func cmdBulkClose(c *cli.Context) error {
+	return runBulk(c, func(store *utask.Store, ids []string) (utask.BulkResult, error) {
+		if c.Bool("atomic") {
+			return runAtomic(c, store, ids, (*utask.Tx).Close)
+		}
+		return store.CloseTasks(c.Context, ids)
+	}, func(res utask.BulkResult) string {
+		return tr(c).Sprintf("closed %d tasks, %d already closed", res.Changed, res.Unchanged)
+	})
+}
+
+// cmdBulkTag adds and removes tags on every task the filter flags select.
+func cmdBulkTag(c *cli.Context) error {
+	add, remove := parseCSVTags(c.String("add")), parseCSVTags(c.String("remove"))
+	if len(add) == 0 && len(remove) == 0 {
+		return errors.New("give tags to --add or --remove")
+	}
+	return runBulk(c, func(store *utask.Store, ids []string) (utask.BulkResult, error) {
+		if c.Bool("atomic") {
+			return runAtomic(c, store, ids, func(tx *utask.Tx, id string) (bool, error) {
+				return tx.Tag(id, add, remove)
+			})
+		}
+		return store.TagTasks(c.Context, ids, add, remove)
+	}, func(res utask.BulkResult) string {
+		return tr(c).Sprintf("retagged %d tasks, %d unchanged", res.Changed, res.Unchanged)
+	})
+}
+
+// cmdBulkDelete deletes every task the filter flags select, after asking
+// unless --yes is given.
+func cmdBulkDelete(c *cli.Context) error {
+	return runBulk(c, func(store *utask.Store, ids []string) (utask.BulkResult, error) {
+		if !c.Bool("yes") {
+			if !canPrompt() {
+				return utask.BulkResult{}, errors.New("pass --yes to delete without a terminal to confirm on")
+			}
+			fmt.Print(tr(c).Sprintf("delete %d tasks? [y/N] ", len(ids)))
+			line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			if answer := strings.ToLower(strings.TrimSpace(line)); answer != "y" && answer != "yes" {
+				return utask.BulkResult{}, errors.New("delete cancelled")
+			}
+		}
+		if c.Bool("atomic") {
+			return runAtomic(c, store, ids, func(tx *utask.Tx, id string) (bool, error) {
+				return true, tx.Delete(id)
+			})
+		}
+		return store.DeleteTasks(c.Context, ids)
+	}, func(res utask.BulkResult) string {
+		return tr(c).Sprintf("deleted %d tasks", res.Changed)
+	})
+}
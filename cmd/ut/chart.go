@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/iainlowe/utask/internal/report"
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdChart draws a report chart over --since for the named kind.
+func cmdChart(kind string) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		cfg := getConfig(c)
+		now := time.Now()
+		since := c.String("since")
+		if since == "" {
+			// A burndown reads best over the current sprint.
+			since = "14d"
+			if cfg.Report.SprintStart != "" && cfg.Report.SprintLength != "" {
+				since = "sprint-start"
+			}
+		}
+		from, err := reportSince(cfg, since, now)
+		if err != nil {
+			return err
+		}
+		ctx := c.Context
+		store, err := openStore(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		defer closeStore(store)
+		tasks, events, err := reportData(ctx, store, from)
+		if err != nil {
+			return err
+		}
+		var ch report.Chart
+		if kind == "burndown" {
+			ch = report.Burndown(tasks, events, c.String("tag"), from, now)
+		} else {
+			ch = report.CreatedVsClosed(tasks, events, from, now)
+		}
+		if c.Bool("verbose") {
+			b, _ := json.MarshalIndent(ch, "", "  ")
+			fmt.Println(string(b))
+			return nil
+		}
+		return ch.Render(os.Stdout, c.Int("width"))
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// workerFlag names the queue worker that ut claim, complete, start and
+// stop act for, so several workers can share one identity.
+var workerFlag = &cli.StringFlag{Name: "worker", EnvVars: []string{"UTASK_WORKER"}, Usage: "worker id holding the claim (default: claims belong to your identity)"}
+
+// cmdClaim takes the oldest unclaimed open task matching the filters for
+// the worker and prints it, for queue workers.
+func cmdClaim(c *cli.Context) error {
+	if c.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q; select tasks with --tag, --tags, --all-tags or --assignee", c.Args().First())
+	}
+	lease, err := utask.ParseDuration(c.String("lease"))
+	if err != nil {
+		return err
+	}
+	f, err := listFilter(c)
+	if err != nil {
+		return err
+	}
+	cfg := getConfig(c)
+	ctx := utask.WithWorker(c.Context, c.String("worker"))
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	t, cl, err := store.ClaimNext(ctx, f, lease)
+	if errors.Is(err, utask.ErrNotFound) {
+		return errors.New(tr(c).Sprintf("no unclaimed open tasks"))
+	}
+	if err != nil {
+		return err
+	}
+	if c.Bool("verbose") && !c.IsSet("output") {
+		b, _ := json.MarshalIndent(map[string]any{"task": t, "claim": cl}, "", "  ")
+		fmt.Println(string(b))
+		return nil
+	}
+	if err := printTasks(c, []utask.Task{t}); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, tr(c).Sprintf("claimed until %s", cl.Expires.Local().Format("15:04")))
+	return nil
+}
+
+// cmdComplete closes a task the worker claimed.
+func cmdComplete(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return fmt.Errorf("usage: ut complete <id>")
+	}
+	cfg := getConfig(c)
+	ctx := utask.WithWorker(c.Context, c.String("worker"))
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	rid, _, err := store.Resolve(ctx, c.Args().First())
+	if err != nil {
+		return err
+	}
+	t, err := store.CompleteClaim(ctx, rid)
+	if err != nil {
+		return err
+	}
+	fmt.Println(tr(c).Sprintf("%s completed", t.Ref()))
+	return nil
+}
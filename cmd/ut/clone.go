@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdClone creates a copy of a task linked back to it with a Cloned-from
+// trailer, applying --title, --tag and --assignee overrides.
+func cmdClone(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("usage: ut clone <id> [--title s] [--tag t ...]")
+	}
+	cfg := getConfig(c)
+	ctx := c.Context
+	assignee, err := resolveAssignee(cfg, c.String("assignee"))
+	if err != nil {
+		return err
+	}
+	var keep func(string) bool
+	if c.IsSet("trailers") {
+		keys := map[string]bool{}
+		for _, k := range strings.Split(c.String("trailers"), ",") {
+			keys[strings.ToLower(strings.TrimSpace(k))] = true
+		}
+		keep = func(key string) bool { return keys[strings.ToLower(key)] }
+	}
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	rid, _, err := store.Resolve(ctx, c.Args().First())
+	if err != nil {
+		return err
+	}
+	src, _, err := store.GetTask(ctx, rid)
+	if err != nil {
+		return err
+	}
+	in := utask.CloneInput(src, c.String("title"), keep)
+	if tags := c.StringSlice("tag"); len(tags) > 0 {
+		in.Tags = tags
+	}
+	in.Assignee = assignee
+	t, existed, err := store.CreateTask(ctx, in)
+	if err != nil {
+		return err
+	}
+	if c.Bool("verbose") {
+		b, _ := json.MarshalIndent(t, "", "  ")
+		fmt.Println(string(b))
+	} else if existed {
+		fmt.Println(tr(c).Sprintf("%s (exists)", t.ID))
+	} else {
+		fmt.Println(t.ID)
+	}
+	return nil
+}
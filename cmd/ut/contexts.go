@@ -0,0 +1,277 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	conf "github.com/iainlowe/utask/internal/config"
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// activeContext is the context listings are narrowed to: --context when
+// the command has it (all turns the filter off), else the one `ut context
+// set` recorded for the profile, unless --no-context.
+func activeContext(c *cli.Context) (string, error) {
+	if c.IsSet("context") {
+		if v := c.String("context"); v != "all" {
+			return utask.ParseContext(v)
+		}
+		return "", nil
+	}
+	if c.Bool("no-context") {
+		return "", nil
+	}
+	path, err := utask.ActiveContextPath(getConfig(c).UI.Profile)
+	if err != nil {
+		return "", err
+	}
+	return utask.LoadActiveContext(path)
+}
+
+// applyFilterContext adds the filters of the context in use (ui.context)
+// to f, unless --no-context is given.
+func applyFilterContext(c *cli.Context, f *utask.ListFilter) error {
+	if c.Bool("no-context") {
+		return nil
+	}
+	name, fc, ok, err := getConfig(c).ActiveContext()
+	if err != nil || !ok {
+		return err
+	}
+	if err := filterContext(c, fc, f); err != nil {
+		return fmt.Errorf("context %s: %w", name, err)
+	}
+	return nil
+}
+
+// filterContext sets each filter of fc on f that the command's own flags
+// left unset.
+func filterContext(c *cli.Context, fc conf.FilterContext, f *utask.ListFilter) error {
+	flagged := func(names ...string) bool {
+		return slices.ContainsFunc(names, c.IsSet)
+	}
+	if fc.Tag != "" && !flagged("tag") {
+		f.Tag = fc.Tag
+	}
+	if fc.Tags != "" && !flagged("tags", "any") {
+		f.Any = parseCSVTags(fc.Tags)
+	}
+	if fc.AllTags != "" && !flagged("all-tags", "all") {
+		f.All = parseCSVTags(fc.AllTags)
+	}
+	if fc.Status != "" && !flagged("status") {
+		st, err := utask.ParseStatus(fc.Status)
+		if err != nil {
+			return err
+		}
+		f.Status = st
+	}
+	if fc.Assignee != "" && !flagged("assignee") {
+		if fc.Assignee == "none" {
+			f.Unassigned = true
+		} else {
+			who, err := resolveAssignee(getConfig(c), fc.Assignee)
+			if err != nil {
+				return err
+			}
+			f.Assignee = who
+		}
+	}
+	if fc.Context != "" && !flagged("context") {
+		name, err := utask.ParseContext(fc.Context)
+		if err != nil {
+			return err
+		}
+		f.Context = name
+	}
+	return nil
+}
+
+// parseContextFlag reads a repeatable --context flag; "none" clears.
+func parseContextFlag(c *cli.Context) ([]string, error) {
+	vals := c.StringSlice("context")
+	if len(vals) == 1 && vals[0] == "none" {
+		return []string{}, nil
+	}
+	return utask.NormalizeContexts(vals)
+}
+
+// cmdContext prints the filter context in use and the active context.
+func cmdContext(c *cli.Context) error {
+	name, fc, ok, err := getConfig(c).ActiveContext()
+	if err != nil {
+		return err
+	}
+	cur, err := activeContext(c)
+	if err != nil {
+		return err
+	}
+	if ok {
+		fmt.Printf("%s\t%s\n", name, fc)
+	}
+	if cur != "" {
+		fmt.Println(cur)
+	}
+	if !ok && cur == "" {
+		fmt.Println(tr(c).Sprintf("no active context"))
+	}
+	return nil
+}
+
+// cmdContextSet makes a context active for later listings, or clears it.
+// Given filters, it instead defines a named filter context.
+func cmdContextSet(c *cli.Context) error {
+	if c.NArg() > 1 {
+		return defineContext(c)
+	}
+	if c.NArg() != 1 {
+		return fmt.Errorf("usage: ut context set <@context|none> | <name> key=value...")
+	}
+	if c.Args().First() == "none" {
+		return cmdContextClear(c)
+	}
+	name, err := utask.ParseContext(c.Args().First())
+	if err != nil {
+		return err
+	}
+	path, err := utask.ActiveContextPath(getConfig(c).UI.Profile)
+	if err != nil {
+		return err
+	}
+	if err := utask.SaveActiveContext(path, name); err != nil {
+		return err
+	}
+	fmt.Println(tr(c).Sprintf("now in %s", name))
+	return nil
+}
+
+// cmdContextList shows the contexts open tasks use, with counts.
+func cmdContextList(c *cli.Context) error {
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	it, err := store.ListIter(ctx, utask.ListFilter{Status: utask.StatusOpen})
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	tasks := []utask.Task{}
+	for it.Next() {
+		tasks = append(tasks, it.Task())
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	counts := utask.CountContexts(tasks)
+	if c.Bool("verbose") {
+		b, _ := json.MarshalIndent(counts, "", "  ")
+		fmt.Println(string(b))
+		return nil
+	}
+	for _, cc := range counts {
+		fmt.Printf("%s\t%d\n", cc.Context, cc.Open)
+	}
+	return nil
+}
+
+// cmdContextClear turns the active context off.
+func cmdContextClear(c *cli.Context) error {
+	path, err := utask.ActiveContextPath(getConfig(c).UI.Profile)
+	if err != nil {
+		return err
+	}
+	if err := utask.SaveActiveContext(path, ""); err != nil {
+		return err
+	}
+	fmt.Println(tr(c).Sprintf("context cleared"))
+	return nil
+}
+
+// defineContext saves a named filter context to the config file.
+func defineContext(c *cli.Context) error {
+	name := c.Args().First()
+	fc, err := conf.ParseFilterContext(c.Args().Tail())
+	if err != nil {
+		return err
+	}
+	// Catch bad values now rather than on every listing.
+	if err := filterContext(c, fc, &utask.ListFilter{}); err != nil {
+		return err
+	}
+	if err := conf.SaveContext(configPath(c), name, fc); err != nil {
+		return err
+	}
+	fmt.Println(tr(c).Sprintf("context %s saved: %s", name, fc))
+	return nil
+}
+
+// cmdContextUse makes a named filter context the one listings apply, or
+// with none stops applying one.
+func cmdContextUse(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("usage: ut context use <name|none>")
+	}
+	name := c.Args().First()
+	if name == "none" {
+		if err := conf.UseContext(configPath(c), ""); err != nil {
+			return err
+		}
+		fmt.Println(tr(c).Sprintf("no filter context in use"))
+		return nil
+	}
+	fc, ok := getConfig(c).Contexts[name]
+	if !ok {
+		return fmt.Errorf("no context %q; define it with ut context set %s key=value...", name, name)
+	}
+	if err := conf.UseContext(configPath(c), name); err != nil {
+		return err
+	}
+	fmt.Println(tr(c).Sprintf("now using context %s: %s", name, fc))
+	return nil
+}
+
+// cmdContextFilters prints the named filter contexts, marking the one in
+// use.
+func cmdContextFilters(c *cli.Context) error {
+	cfg := getConfig(c)
+	if c.Bool("verbose") {
+		b, _ := json.MarshalIndent(map[string]any{"active": cfg.UI.Context, "contexts": cfg.Contexts}, "", "  ")
+		fmt.Println(string(b))
+		return nil
+	}
+	names := make([]string, 0, len(cfg.Contexts))
+	for name := range cfg.Contexts {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		mark := " "
+		if name == cfg.UI.Context {
+			mark = "*"
+		}
+		fmt.Printf("%s %s\t%s\n", mark, name, cfg.Contexts[name])
+	}
+	return nil
+}
+
+// cmdContextDelete removes a named filter context from the config file.
+func cmdContextDelete(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("usage: ut context delete <name>")
+	}
+	name := c.Args().First()
+	if name == getConfig(c).UI.Context {
+		return fmt.Errorf("context %s is in use; run ut context use none first", name)
+	}
+	if err := conf.DeleteContext(configPath(c), name); err != nil {
+		return err
+	}
+	fmt.Println(tr(c).Sprintf("context %s deleted", name))
+	return nil
+}
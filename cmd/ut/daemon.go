@@ -0,0 +1,518 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	conf "github.com/iainlowe/utask/internal/config"
+	"github.com/iainlowe/utask/internal/daemon"
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// forwardedCommands may run inside `ut daemon`. Commands that read stdin or
+// run their own long-lived loops always run directly.
+var forwardedCommands = map[string]bool{
+	"create": true, "list": true, "mine": true, "get": true, "close": true, "reopen": true, "approve": true, "start": true, "stop": true, "active": true, "claim": true, "complete": true, "release": true, "assign": true, "delegate": true, "waiting": true, "report": true, "random": true, "snooze": true, "clone": true, "diff": true, "history": true, "undo": true, "trash": true, "restore": true, "chart": true, "plan": true, "block": true, "unblock": true, "annotate": true, "trailer": true, "cancel": true,
+	"update": true, "delete": true, "rm": true, "tags": true, "count": true, "dedupe": true, "summary": true, "query": true, "check": true,
+	"maintain": true, "export": true, "rebuild-index": true, "ping": true, "activity": true,
+}
+
+// globalValueFlags are the global flags that consume the next argument.
+var globalValueFlags = map[string]bool{
+	"--config": true, "-c": true, "--nats-url": true, "--backend": true, "--timeout": true, "--openai-api-key": true,
+	"--openai-model": true, "--profile": true, "--log-level": true, "--log-format": true,
+	"--cpuprofile": true, "--memprofile": true, "--trace": true, "--output": true, "-o": true,
+}
+
+// profilingFlags must profile the client process, so they disable
+// forwarding.
+var profilingFlags = []string{"--cpuprofile", "--memprofile", "--trace"}
+
+func wantsProfile(args []string) bool {
+	for _, a := range args {
+		for _, f := range profilingFlags {
+			if a == f || strings.HasPrefix(a, f+"=") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wantsOffline reports whether args or the environment ask for --offline.
+func wantsOffline(args []string) bool {
+	if v := os.Getenv("UTASK_OFFLINE"); v != "" && v != "0" && v != "false" {
+		return true
+	}
+	for _, a := range args {
+		if a == "--offline" || a == "--offline=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// commandName returns the first non-flag argument after the program name.
+func commandName(args []string) string {
+	for i := 1; i < len(args); i++ {
+		a := args[i]
+		if !strings.HasPrefix(a, "-") {
+			return a
+		}
+		if globalValueFlags[a] {
+			i++
+		}
+	}
+	return ""
+}
+
+func forwardedEnv(key string) bool {
+	switch key {
+	case "LANG", "LC_ALL", "LC_MESSAGES":
+		// The client's locale picks the language of its output.
+		return true
+	}
+	return strings.HasPrefix(key, "UTASK_") || strings.HasPrefix(key, "OPENAI_")
+}
+
+// forwardToDaemon runs args inside a running daemon. ok is false when the
+// command isn't forwardable or no daemon is listening, in which case the
+// caller runs it directly. UTASK_NO_DAEMON=1 disables forwarding, offline
+// runs never need the daemon's connection, --suggest-tags has to prompt
+// on the client's terminal and --details-file - reads the client's stdin.
+func forwardToDaemon(args []string) (code int, ok bool) {
+	if os.Getenv("UTASK_NO_DAEMON") != "" || !forwardedCommands[commandName(args)] || wantsProfile(args) || wantsOffline(args) || wantsSuggest(args) || wantsStdin(args) {
+		return 0, false
+	}
+	path, err := daemon.SocketPath()
+	if err != nil {
+		return 0, false
+	}
+	req := daemon.Request{Args: args, Env: map[string]string{}}
+	req.Dir, _ = os.Getwd()
+	for _, kv := range os.Environ() {
+		if k, v, _ := strings.Cut(kv, "="); forwardedEnv(k) {
+			req.Env[k] = v
+		}
+	}
+	code, err = daemon.Call(path, req, os.Stdout, os.Stderr)
+	if errors.Is(err, daemon.ErrUnavailable) {
+		return 0, false
+	}
+	if err != nil {
+		slog.Error("daemon call failed", "op", commandName(args), "err", err)
+		return 1, true
+	}
+	return code, true
+}
+
+// warm is set inside `ut daemon`; openStore then hands out its long-lived
+// stores instead of dialing NATS per command.
+var warm *daemonState
+
+// openStore connects to the configured profile, or reuses the daemon's
+// connection when running inside `ut daemon`.
+func openStore(ctx context.Context, cfg *conf.Config) (*utask.Store, error) {
+	var (
+		s   *utask.Store
+		err error
+	)
+	if warm != nil {
+		s, err = warm.store(cfg)
+	} else {
+		var opts utask.Options
+		if opts, err = storeOptions(cfg); err != nil {
+			return nil, err
+		}
+		s, err = openDriver(ctx, cfg, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	replayJournal(ctx, cfg, s)
+	return s, nil
+}
+
+// openDriver opens the profile with the configured storage driver.
+func openDriver(ctx context.Context, cfg *conf.Config, opts utask.Options) (*utask.Store, error) {
+	switch cfg.Storage.Driver {
+	case "", "nats":
+		return utask.OpenWithOptions(ctx, cfg.NATS.URL, cfg.UI.Profile, opts)
+	case "sqlite":
+		path := cfg.Storage.Path
+		if path == "" {
+			var err error
+			if path, err = utask.SQLitePath(); err != nil {
+				return nil, err
+			}
+		}
+		return utask.OpenSQLite(ctx, path, cfg.UI.Profile, opts)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q (want nats or sqlite)", cfg.Storage.Driver)
+	}
+}
+
+// storeOptions maps the storage:, limits:, identity: and nats: connection config
+// and the profile's encryption key onto utask.Options.
+func storeOptions(cfg *conf.Config) (utask.Options, error) {
+	enc, err := utask.ParseEncoding(cfg.Storage.Encoding)
+	if err != nil {
+		return utask.Options{}, err
+	}
+	comp, err := utask.ParseCompression(cfg.Storage.Compression)
+	if err != nil {
+		return utask.Options{}, err
+	}
+	ks, err := utask.ParseKeyspace(cfg.Storage.Keyspace)
+	if err != nil {
+		return utask.Options{}, err
+	}
+	ids, err := utask.ParseIDStrategy(cfg.Storage.IDStrategy)
+	if err != nil {
+		return utask.Options{}, err
+	}
+	tagRe, err := utask.ParseTagPattern(cfg.Limits.TagPattern)
+	if err != nil {
+		return utask.Options{}, err
+	}
+	role, err := utask.ParseRole(cfg.Access.RoleFor(conf.NATSUser(cfg.NATS.URL)))
+	if err != nil {
+		return utask.Options{}, fmt.Errorf("access: %w", err)
+	}
+	limits := utask.Limits{
+		MaxTextLen: cfg.Limits.MaxTextLen,
+		MaxTags:    cfg.Limits.MaxTags,
+		MaxTagLen:  cfg.Limits.MaxTagLen,
+		TagPattern: tagRe,
+	}
+	key, err := profileKey(cfg)
+	if err != nil {
+		return utask.Options{}, err
+	}
+	return utask.Options{Key: key, Encoding: enc, CompressAbove: cfg.Storage.CompressAbove, Compression: comp, Keyspace: ks, IDStrategy: ids, Timeout: cfg.NATS.Timeout, ReconnectWait: cfg.NATS.ReconnectWait, MaxReconnects: cfg.NATS.MaxReconnects, Retries: cfg.NATS.Retries, FailFast: cfg.NATS.FailFast, Limits: limits, HealOrphans: cfg.Storage.HealOrphans, AuditRetention: cfg.Storage.AuditRetention, TaskHistory: cfg.Storage.TaskHistory, UndoDepth: cfg.Storage.UndoDepth, Identity: conf.ResolveIdentity(cfg.Identity).String(), Role: role, ReviewTags: cfg.Review.Tags}, nil
+}
+
+// closeStore releases a store from openStore; daemon stores stay open. A
+// store that wrote tasks first brings the local snapshot up to date, so the
+// next `ut list` shows the user's own changes.
+func closeStore(s *utask.Store) {
+	if warm == nil {
+		if s.Writes() > 0 {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			refreshCache(ctx, s)
+			cancel()
+		}
+		s.Close()
+	}
+}
+
+// refreshCache replays newer revisions into the snapshot of s's profile,
+// if it has one.
+func refreshCache(ctx context.Context, s *utask.Store) {
+	path, err := utask.SnapshotPath(s.Profile())
+	if err != nil {
+		return
+	}
+	snap, err := utask.LoadSnapshot(path, s.Profile())
+	if err != nil || snap.Empty() {
+		return
+	}
+	n, err := s.SyncSnapshot(ctx, snap)
+	if err != nil {
+		slog.Debug("cache not refreshed", "profile", s.Profile(), "err", err)
+		return
+	}
+	if n > 0 {
+		if err := snap.Save(path); err != nil {
+			slog.Debug("cache not saved", "profile", s.Profile(), "err", err)
+		}
+	}
+}
+
+type warmStore struct {
+	store   *utask.Store
+	profile string
+}
+
+// daemonState owns the daemon's stores. mu serializes forwarded commands
+// and background jobs: commands write to the process-wide os.Stdout, which
+// is swapped per invocation.
+type daemonState struct {
+	ctx      context.Context
+	cache    bool
+	mu       sync.Mutex
+	storesMu sync.Mutex
+	stores   map[string]*warmStore
+	dirtyMu  sync.Mutex
+	dirty    map[string]bool
+}
+
+func (d *daemonState) store(cfg *conf.Config) (*utask.Store, error) {
+	key := cfg.Storage.Driver + "|" + cfg.NATS.URL + "|" + cfg.Storage.Path + "|" + cfg.UI.Profile
+	d.storesMu.Lock()
+	defer d.storesMu.Unlock()
+	if ws, ok := d.stores[key]; ok {
+		return ws.store, nil
+	}
+	opts, err := storeOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s, err := openDriver(d.ctx, cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+	d.stores[key] = &warmStore{store: s, profile: cfg.UI.Profile}
+	if d.cache {
+		// Mark the snapshot dirty on every change so the background loop
+		// keeps `ut list` output current.
+		if events, err := s.Watch(d.ctx, utask.WatchFilter{}); err == nil {
+			go func() {
+				for range events {
+					d.dirtyMu.Lock()
+					d.dirty[key] = true
+					d.dirtyMu.Unlock()
+				}
+			}()
+		}
+	}
+	return s, nil
+}
+
+func (d *daemonState) closeAll() {
+	d.storesMu.Lock()
+	defer d.storesMu.Unlock()
+	for _, ws := range d.stores {
+		ws.store.Close()
+	}
+}
+
+// handle runs one forwarded invocation with the client's environment and
+// working directory, capturing its output.
+func (d *daemonState) handle(ctx context.Context, req daemon.Request, stdout, stderr io.Writer) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// Refresh the snapshots a command wrote to before the next one runs,
+	// rather than waiting for the background loop.
+	defer d.refreshWritten(d.writes())
+	// Forwarded flags reconfigure the default logger for this command only.
+	defer slog.SetDefault(slog.Default())
+	defer swapEnv(req.Env)()
+	if req.Dir != "" {
+		if wd, err := os.Getwd(); err == nil && os.Chdir(req.Dir) == nil {
+			defer os.Chdir(wd)
+		}
+	}
+	return runCaptured(stdout, stderr, func() error {
+		app := newApp()
+		app.ExitErrHandler = func(*cli.Context, error) {} // never os.Exit the daemon
+		return app.RunContext(ctx, req.Args)
+	})
+}
+
+// swapEnv replaces the forwarded environment variables with env and returns
+// a func restoring the daemon's own values.
+func swapEnv(env map[string]string) func() {
+	saved := map[string]string{}
+	for _, kv := range os.Environ() {
+		if k, v, _ := strings.Cut(kv, "="); forwardedEnv(k) {
+			saved[k] = v
+			os.Unsetenv(k)
+		}
+	}
+	for k, v := range env {
+		if forwardedEnv(k) {
+			os.Setenv(k, v)
+		}
+	}
+	return func() {
+		for k := range env {
+			os.Unsetenv(k)
+		}
+		for k, v := range saved {
+			os.Setenv(k, v)
+		}
+	}
+}
+
+// runCaptured runs fn with os.Stdout/os.Stderr redirected to the given
+// writers and returns the exit code the CLI would have used.
+func runCaptured(stdout, stderr io.Writer, fn func() error) (code int) {
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		outR.Close()
+		outW.Close()
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); _, _ = io.Copy(stdout, outR) }()
+	go func() { defer wg.Done(); _, _ = io.Copy(stderr, errR) }()
+	oldOut, oldErr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = outW, errW
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("command panicked", "panic", r)
+			code = 1
+		}
+		os.Stdout, os.Stderr = oldOut, oldErr
+		outW.Close()
+		errW.Close()
+		wg.Wait()
+		outR.Close()
+		errR.Close()
+	}()
+	if err := fn(); err != nil {
+		fmt.Fprintln(os.Stderr, explainError(err))
+		return 1
+	}
+	return 0
+}
+
+// background refreshes dirty snapshots and periodically compacts the tag
+// index of every warm store.
+func (d *daemonState) background(compactEvery time.Duration) {
+	refresh := time.NewTicker(2 * time.Second)
+	defer refresh.Stop()
+	var compact <-chan time.Time
+	if compactEvery > 0 {
+		t := time.NewTicker(compactEvery)
+		defer t.Stop()
+		compact = t.C
+	}
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-refresh.C:
+			d.dirtyMu.Lock()
+			keys := d.dirty
+			d.dirty = map[string]bool{}
+			d.dirtyMu.Unlock()
+			for key := range keys {
+				d.refreshSnapshot(key)
+			}
+		case <-compact:
+			d.compactAll()
+		}
+	}
+}
+
+func (d *daemonState) lookup(key string) *warmStore {
+	d.storesMu.Lock()
+	defer d.storesMu.Unlock()
+	return d.stores[key]
+}
+
+func (d *daemonState) refreshSnapshot(key string) {
+	ws := d.lookup(key)
+	if ws == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	refreshCache(d.ctx, ws.store)
+}
+
+// writes returns each warm store's write count.
+func (d *daemonState) writes() map[string]uint64 {
+	d.storesMu.Lock()
+	defer d.storesMu.Unlock()
+	out := make(map[string]uint64, len(d.stores))
+	for key, ws := range d.stores {
+		out[key] = ws.store.Writes()
+	}
+	return out
+}
+
+// refreshWritten refreshes the snapshot of every store that has written
+// since before was taken. The caller holds d.mu.
+func (d *daemonState) refreshWritten(before map[string]uint64) {
+	if !d.cache {
+		return
+	}
+	for key, n := range d.writes() {
+		if n != before[key] {
+			refreshCache(d.ctx, d.lookup(key).store)
+		}
+	}
+}
+
+func (d *daemonState) compactAll() {
+	d.storesMu.Lock()
+	stores := make([]*warmStore, 0, len(d.stores))
+	for _, ws := range d.stores {
+		stores = append(stores, ws)
+	}
+	d.storesMu.Unlock()
+	for _, ws := range stores {
+		d.compact(ws)
+	}
+}
+
+// compact runs one compaction pass while holding mu, so its log lines go to
+// the daemon's stderr rather than a client's captured stream.
+func (d *daemonState) compact(ws *warmStore) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	rep, err := ws.store.CompactTagIndex(d.ctx)
+	if err != nil {
+		slog.Error("compaction failed", "op", "compact", "profile", ws.profile, "err", err)
+		return
+	}
+	if rep.Migrated > 0 {
+		slog.Info("migrated tag index", "op", "compact", "profile", ws.profile, "tags", rep.Migrated, "entries", rep.Entries)
+	}
+}
+
+func cmdDaemon(c *cli.Context) error {
+	cfg := getConfig(c)
+	path := c.String("socket")
+	if path == "" {
+		var err error
+		if path, err = daemon.SocketPath(); err != nil {
+			return err
+		}
+	}
+	ln, err := daemon.Listen(path)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	d := &daemonState{ctx: ctx, cache: !cfg.Cache.Disabled, stores: map[string]*warmStore{}, dirty: map[string]bool{}}
+	// Connect the default profile up front so the first command is fast.
+	if _, err := d.store(cfg); err != nil {
+		ln.Close()
+		return err
+	}
+	warm = d
+	defer d.closeAll()
+	reports, err := scheduledReports(cfg)
+	if err != nil {
+		ln.Close()
+		return err
+	}
+	go d.background(c.Duration("compact-every"))
+	for _, r := range reports {
+		go d.runSchedule(cfg, r)
+	}
+	slog.Info("ut daemon listening", "socket", path, "profile", cfg.UI.Profile)
+	return daemon.Serve(ctx, ln, d.handle)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// dateFlags are the --due, --scheduled and --wait flags of create and
+// update.
+func dateFlags(clear string) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{Name: "due", Usage: "due date, e.g. tomorrow, \"fri 5pm\", 2026-03-01 or 3d" + clear},
+		&cli.StringFlag{Name: "scheduled", Usage: "when work is planned to start" + clear},
+		&cli.StringFlag{Name: "wait", Usage: "hide the task from ut list until then" + clear},
+	}
+}
+
+// whenFlag parses a date flag with utask.ParseWhen. A flag that isn't set
+// yields nil; "none" yields the zero time, which clears the field.
+func whenFlag(c *cli.Context, name string) (*time.Time, error) {
+	if !c.IsSet(name) {
+		return nil, nil
+	}
+	v := c.String(name)
+	if v == "none" {
+		return &time.Time{}, nil
+	}
+	when, _, err := utask.ParseWhen(v, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("--%s: %w", name, err)
+	}
+	return &when, nil
+}
+
+// inputDates copies the date flags onto a new task.
+func inputDates(c *cli.Context, in *utask.TaskInput) error {
+	for name, dst := range map[string]*time.Time{"due": &in.Due, "scheduled": &in.Scheduled, "wait": &in.Wait} {
+		when, err := whenFlag(c, name)
+		if err != nil {
+			return err
+		}
+		if when != nil {
+			*dst = *when
+		}
+	}
+	return nil
+}
+
+// updateDates copies the date flags onto an update.
+func updateDates(c *cli.Context, set *utask.UpdateSet) error {
+	var err error
+	if set.Due, err = whenFlag(c, "due"); err != nil {
+		return err
+	}
+	if set.Scheduled, err = whenFlag(c, "scheduled"); err != nil {
+		return err
+	}
+	set.Wait, err = whenFlag(c, "wait")
+	return err
+}
+
+// dueFilter sets --due-before and --overdue on a listing. A bare date
+// includes tasks due that day.
+func dueFilter(c *cli.Context, f *utask.ListFilter) error {
+	f.Overdue = c.Bool("overdue")
+	if !c.IsSet("due-before") {
+		return nil
+	}
+	when, dateOnly, err := utask.ParseWhen(c.String("due-before"), time.Now())
+	if err != nil {
+		return fmt.Errorf("--due-before: %w", err)
+	}
+	if dateOnly {
+		when = when.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	f.DueBefore = when
+	return nil
+}
+
+// changedFilter sets --since and --until on a listing: tasks last changed
+// (closed tasks: closed) in that range. Each takes a duration back from
+// now (7d), a date or a time; a bare --until date includes that day.
+func changedFilter(c *cli.Context, f *utask.ListFilter) error {
+	now := time.Now()
+	parse := func(name string) (time.Time, error) {
+		s := c.String(name)
+		if d, err := utask.ParseDuration(s); err == nil {
+			return now.Add(-d), nil
+		}
+		when, dateOnly, err := utask.ParseWhen(s, now)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("--%s: %w", name, err)
+		}
+		if dateOnly && name == "until" {
+			when = when.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		return when, nil
+	}
+	var err error
+	if c.String("since") != "" {
+		if f.Since, err = parse("since"); err != nil {
+			return err
+		}
+	}
+	if c.String("until") != "" {
+		if f.Until, err = parse("until"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
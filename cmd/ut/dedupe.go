@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdDedupe prints each group of content-identical tasks, oldest first,
+// with a blank line between groups.
+func cmdDedupe(c *cli.Context) error {
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	groups, err := store.Duplicates(ctx)
+	if err != nil {
+		return err
+	}
+	if c.Bool("verbose") {
+		b, _ := json.MarshalIndent(groups, "", "  ")
+		fmt.Println(string(b))
+		return nil
+	}
+	if len(groups) == 0 {
+		fmt.Println(tr(c).Sprintf("no duplicates"))
+		return nil
+	}
+	for i, group := range groups {
+		if i > 0 {
+			fmt.Println()
+		}
+		for _, t := range group {
+			fmt.Printf("%s\t%s\t%s\t%s\t%s\n", t.Ref(), shortID(t.ID), t.State(), t.Created, t.Short())
+		}
+	}
+	return nil
+}
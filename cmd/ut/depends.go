@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdBlock makes the first task depend on the second: it isn't ready
+// until the second is closed. Given one task, it marks it blocked on
+// something outside the tracker.
+func cmdBlock(c *cli.Context) error {
+	switch c.NArg() {
+	case 0:
+		return fmt.Errorf("usage: ut block <id> [<blocking-id>]")
+	case 1:
+		return setStatus(c, utask.StatusBlocked)
+	}
+	return setDependency(c, c.Args().Get(1))
+}
+
+// cmdUnblock drops one dependency of a task, or all of them when no
+// second id is given; then a blocked task is open again.
+func cmdUnblock(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return fmt.Errorf("usage: ut unblock <id> [<blocking-id>]")
+	}
+	return setDependency(c, c.Args().Get(1))
+}
+
+func setDependency(c *cli.Context, onRef string) error {
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	id, _, err := store.Resolve(ctx, c.Args().First())
+	if err != nil {
+		return err
+	}
+	var on string
+	if onRef != "" {
+		if on, _, err = store.Resolve(ctx, onRef); err != nil {
+			return err
+		}
+	}
+	block := c.Command.Name == "block"
+	var t utask.Task
+	if block {
+		t, err = store.BlockTask(ctx, id, on, c.Uint64("if-revision"))
+	} else {
+		t, err = store.UnblockTask(ctx, id, on, c.Uint64("if-revision"))
+		if err == nil && on == "" && t.State() == utask.StatusBlocked {
+			t, _, err = store.SetStatus(ctx, id, utask.StatusOpen, 0)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	switch {
+	case c.Bool("verbose"):
+		b, _ := json.MarshalIndent(t, "", "  ")
+		fmt.Println(string(b))
+	case block:
+		fmt.Println(tr(c).Sprintf("%s blocked by %s", t.ID, shortID(on)))
+	case on == "":
+		fmt.Println(tr(c).Sprintf("%s unblocked", t.ID))
+	default:
+		fmt.Println(tr(c).Sprintf("%s no longer blocked by %s", t.ID, shortID(on)))
+	}
+	return nil
+}
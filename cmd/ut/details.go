@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// detailsFlag reads a task's details from --details or --details-file
+// (- for stdin). It returns nil when neither is given.
+func detailsFlag(c *cli.Context) (*string, error) {
+	if c.IsSet("details") && c.IsSet("details-file") {
+		return nil, fmt.Errorf("--details and --details-file are mutually exclusive")
+	}
+	if c.IsSet("details") {
+		s := c.String("details")
+		return &s, nil
+	}
+	if !c.IsSet("details-file") {
+		return nil, nil
+	}
+	r, err := openInput(c.String("details-file"))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("--details-file: %w", err)
+	}
+	s := string(b)
+	return &s, nil
+}
+
+// wantsStdin reports whether args read stdin with --details-file -, which
+// the daemon can't see and so has to run in the client.
+func wantsStdin(args []string) bool {
+	for i, a := range args {
+		if a == "--details-file=-" || a == "--details-file" && i+1 < len(args) && args[i+1] == "-" {
+			return true
+		}
+	}
+	return false
+}
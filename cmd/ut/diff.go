@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/iainlowe/utask/internal/textdiff"
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdDiff shows what changed between two revisions of a task, by default
+// its last change. One revision is compared with the task as it is now.
+func cmdDiff(c *cli.Context) error {
+	if c.NArg() < 1 || c.NArg() > 3 {
+		return fmt.Errorf("usage: ut diff <id> [revA [revB]]")
+	}
+	var revs []uint64
+	for _, arg := range c.Args().Slice()[1:] {
+		r, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid revision %q", arg)
+		}
+		revs = append(revs, r)
+	}
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	rid, _, err := store.Resolve(ctx, c.Args().First())
+	if err != nil {
+		return err
+	}
+	history, err := store.TaskHistory(ctx, rid)
+	if err != nil {
+		return err
+	}
+	if c.Bool("list") {
+		if c.Bool("verbose") {
+			b, _ := json.MarshalIndent(history, "", "  ")
+			fmt.Println(string(b))
+			return nil
+		}
+		for _, a := range history {
+			fmt.Printf("%d\t%s\t%s\t%s\n", a.Task.Revision, a.When.Local().Format(time.DateTime), orDash(a.By), a.Kind)
+		}
+		return nil
+	}
+	var (
+		from, to         utask.Task
+		fromName, toName string
+	)
+	switch len(revs) {
+	case 0:
+		if len(history) == 0 {
+			return fmt.Errorf("no recorded changes to %s", shortID(rid))
+		}
+		last := history[len(history)-1]
+		to, toName = last.Task, versionName(last)
+		fromName = "/dev/null"
+		if len(history) > 1 {
+			prev := history[len(history)-2]
+			from, fromName = prev.Task, versionName(prev)
+		}
+	default:
+		if from, err = utask.FindRevision(history, revs[0]); err != nil {
+			return err
+		}
+		fromName = fmt.Sprintf("revision %d", revs[0])
+		if len(revs) == 2 {
+			if to, err = utask.FindRevision(history, revs[1]); err != nil {
+				return err
+			}
+			toName = fmt.Sprintf("revision %d", revs[1])
+		} else {
+			var rev uint64
+			if to, rev, err = store.GetTask(ctx, rid); err != nil {
+				return err
+			}
+			toName = fmt.Sprintf("revision %d (current)", rev)
+		}
+	}
+	var a []string
+	if fromName != "/dev/null" {
+		a = utask.RecordLines(from)
+	}
+	fmt.Print(textdiff.Unified(fromName, toName, a, utask.RecordLines(to), 3))
+	return nil
+}
+
+// versionName labels a history entry for a diff header.
+func versionName(a utask.Activity) string {
+	return fmt.Sprintf("revision %d (%s by %s, %s)", a.Task.Revision, a.Kind, orDash(a.By), a.When.Local().Format(time.DateTime))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdEdit opens a task in the user's editor as front matter (tags and
+// trailers) followed by its text, and saves the result only if nobody
+// changed the task meanwhile. When the save fails the edited file is kept
+// so the edit isn't lost.
+func cmdEdit(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("usage: ut edit <id>")
+	}
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	rid, _, err := store.Resolve(ctx, c.Args().First())
+	if err != nil {
+		return err
+	}
+	t, rev, err := store.GetTask(ctx, rid)
+	if err != nil {
+		return err
+	}
+	orig := utask.EditBuffer(t)
+	f, err := os.CreateTemp("", "ut-edit-*.md")
+	if err != nil {
+		return err
+	}
+	path := f.Name()
+	_, err = f.WriteString(orig)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+	if err := runEditor(path); err != nil {
+		os.Remove(path)
+		return err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if string(b) == orig {
+		os.Remove(path)
+		fmt.Println(tr(c).Sprintf("no changes"))
+		return nil
+	}
+	text, tags, err := utask.ParseEditBuffer(string(b))
+	if err == nil {
+		set := utask.UpdateSet{IfRevision: rev}
+		if text != t.Text {
+			set.Text = &text
+		}
+		if tags != nil && !slices.Equal(tags, t.Tags) {
+			set.Tags = &tags
+		}
+		if set.Text == nil && set.Tags == nil {
+			os.Remove(path)
+			fmt.Println(tr(c).Sprintf("no changes"))
+			return nil
+		}
+		t, err = store.UpdateTask(ctx, rid, set)
+	}
+	if err != nil {
+		return fmt.Errorf("%w\n%s", err, tr(c).Sprintf("your edit is saved in %s", path))
+	}
+	os.Remove(path)
+	if c.Bool("verbose") {
+		b, _ := json.MarshalIndent(t, "", "  ")
+		fmt.Println(string(b))
+	} else {
+		fmt.Println(tr(c).Sprintf("%s updated", t.ID))
+	}
+	return nil
+}
+
+// runEditor opens path in $VISUAL, else $EDITOR, else vi, attached to the
+// terminal. The variable may carry arguments, e.g. "code --wait".
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		args = []string{"vi"}
+	}
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			return fmt.Errorf("editor %s exited with status %d; task not changed", args[0], exit.ExitCode())
+		}
+		return fmt.Errorf("run editor: %w", err)
+	}
+	return nil
+}
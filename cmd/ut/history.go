@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iainlowe/utask/internal/textdiff"
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdHistory lists a task's stored revisions, oldest first, each with when
+// and by whom it was written and the fields it changed. Deleted tasks are
+// found by their full id.
+func cmdHistory(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("usage: ut history <id>")
+	}
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	ref := c.Args().First()
+	rid, _, err := store.Resolve(ctx, ref)
+	if errors.Is(err, utask.ErrNotFound) {
+		rid, err = ref, nil
+	}
+	if err != nil {
+		return err
+	}
+	revs, err := store.History(ctx, rid)
+	if err != nil {
+		return err
+	}
+	if c.Bool("verbose") {
+		b, _ := json.MarshalIndent(revs, "", "  ")
+		fmt.Println(string(b))
+		return nil
+	}
+	var prev utask.Task
+	for _, r := range revs {
+		who := r.Task.UpdatedBy
+		if who == "" {
+			who = r.Task.CreatedBy
+		}
+		line := fmt.Sprintf("revision %d\t%s\t%s", r.Revision, r.When.Local().Format(time.DateTime), orDash(who))
+		if r.Deleted {
+			fmt.Println(line + "\t" + tr(c).Sprintf("deleted"))
+			continue
+		}
+		fmt.Println(line)
+		for _, ch := range utask.Changes(prev, r.Task) {
+			if ch.Field == "text" {
+				var a []string
+				if ch.From != "" {
+					a = strings.Split(strings.TrimRight(ch.From, "\n"), "\n")
+				}
+				b := strings.Split(strings.TrimRight(ch.To, "\n"), "\n")
+				for _, l := range strings.Split(strings.TrimRight(textdiff.Unified("text", "text", a, b, 1), "\n"), "\n") {
+					fmt.Println("    " + l)
+				}
+				continue
+			}
+			fmt.Printf("    %s: %s -> %s\n", ch.Field, orDash(oneLine(ch.From)), orDash(oneLine(ch.To)))
+		}
+		prev = r.Task
+	}
+	return nil
+}
+
+// oneLine folds a multi-line field value (repeated annotations) onto one
+// line.
+func oneLine(s string) string {
+	return strings.ReplaceAll(s, "\n", "; ")
+}
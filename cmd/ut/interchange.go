@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/iainlowe/utask/internal/feed"
+	"github.com/iainlowe/utask/internal/ical"
+	"github.com/iainlowe/utask/internal/importer"
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// openInput returns stdin for "" or "-", otherwise the named file.
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "" || path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(path)
+}
+
+func cmdExport(c *cli.Context) error {
+	// The format may be given positionally: `ut export atom`.
+	format := c.String("format")
+	if c.NArg() > 0 {
+		format = c.Args().First()
+	}
+	switch format {
+	case "jsonl", "atom", "ics":
+	default:
+		return fmt.Errorf("invalid --format: %s", format)
+	}
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	if format == "atom" {
+		window, err := utask.ParseDuration(c.String("since"))
+		if err != nil {
+			return err
+		}
+		acts, err := store.RecentActivity(ctx, time.Now().Add(-window), c.Int("limit"))
+		if err != nil {
+			return err
+		}
+		b, err := feed.Atom(cfg.UI.Profile, "", acts)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+	if format == "ics" {
+		tasks := []utask.Task{}
+		if err := store.ForEach(ctx, func(t utask.Task) error {
+			tasks = append(tasks, t)
+			return nil
+		}); err != nil {
+			return err
+		}
+		return ical.WriteTodos(os.Stdout, tasks)
+	}
+	w := bufio.NewWriter(os.Stdout)
+	if _, err := store.ExportJSONL(ctx, w, c.Bool("revisions")); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func cmdImportJSONL(c *cli.Context) error {
+	in, err := openInput(c.Args().First())
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	res, err := store.ImportJSONL(ctx, in, func(t utask.Task) {
+		if c.Bool("verbose") {
+			fmt.Println(t.ID)
+		}
+	})
+	fmt.Println(tr(c).Sprintf("imported %d (created %d, updated %d, unchanged %d)", res.Created+res.Updated+res.Unchanged, res.Created, res.Updated, res.Unchanged))
+	return err
+}
+
+func cmdImportCSV(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return fmt.Errorf("usage: ut import csv --map title=Col[,tags=Col,...] <file|->")
+	}
+	m, err := importer.ParseMapping(c.String("map"))
+	if err != nil {
+		return err
+	}
+	opts := importer.CSVOptions{Map: m, Encoding: c.String("encoding"), TagSep: c.String("tag-sep")}
+	if d := c.String("delimiter"); d != "" {
+		if d == `\t` || d == "tab" {
+			d = "\t"
+		}
+		r, size := utf8.DecodeRuneInString(d)
+		if size != len(d) {
+			return fmt.Errorf("invalid --delimiter: %q", d)
+		}
+		opts.Delimiter = r
+	}
+	in, err := openInput(c.Args().First())
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if c.Bool("dry-run") {
+		n := 0
+		err := importer.ReadCSV(in, opts, func(row int, t utask.Task) error {
+			n++
+			printPreview(fmt.Sprintf("row %d", row), t)
+			return nil
+		})
+		fmt.Printf("would import %d\n", n)
+		return err
+	}
+
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	created, updated := 0, 0
+	err = importer.ReadCSV(in, opts, func(row int, t utask.Task) error {
+		isNew, err := store.PutTask(ctx, t)
+		if err != nil {
+			return err
+		}
+		if isNew {
+			created++
+		} else {
+			updated++
+		}
+		return nil
+	})
+	fmt.Printf("imported %d (created %d, updated %d)\n", created+updated, created, updated)
+	return err
+}
+
+// printPreview prints a dry-run line for a task about to be imported.
+func printPreview(label string, t utask.Task) {
+	st := "open"
+	if t.Done {
+		st = "closed"
+	}
+	fmt.Printf("%s\t%s\t%s\t[%s]\n", label, t.ID[:12], st, strings.Join(t.Tags, ","))
+	fmt.Println("  ", t.Short())
+}
+
+// importTasks upserts already-mapped tasks, or previews them with --dry-run.
+func importTasks(c *cli.Context, tasks []utask.Task) error {
+	if c.Bool("dry-run") {
+		for i, t := range tasks {
+			printPreview(fmt.Sprintf("#%d", i+1), t)
+		}
+		fmt.Printf("would import %d\n", len(tasks))
+		return nil
+	}
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	created, updated := 0, 0
+	for _, t := range tasks {
+		isNew, err := store.PutTask(ctx, t)
+		if err != nil {
+			fmt.Printf("imported %d (created %d, updated %d)\n", created+updated, created, updated)
+			return err
+		}
+		if isNew {
+			created++
+		} else {
+			updated++
+		}
+	}
+	fmt.Printf("imported %d (created %d, updated %d)\n", created+updated, created, updated)
+	return nil
+}
+
+func cmdImportTodoist(c *cli.Context) error {
+	var (
+		tasks []utask.Task
+		err   error
+	)
+	if p := c.String("backup"); p != "" {
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		st, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if tasks, err = importer.ReadTodoistBackup(p, f, st.Size()); err != nil {
+			return err
+		}
+	} else {
+		token := c.String("token")
+		if token == "" {
+			return fmt.Errorf("--token (or TODOIST_API_TOKEN) or --backup is required")
+		}
+		client := &http.Client{Timeout: 30 * time.Second}
+		if tasks, err = importer.FetchTodoist(c.Context, client, importer.TodoistAPI, token); err != nil {
+			return err
+		}
+	}
+	return importTasks(c, tasks)
+}
+
+func cmdImportICS(c *cli.Context) error {
+	in, err := openInput(c.Args().First())
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tasks, err := ical.ReadTodos(in)
+	if err != nil {
+		return err
+	}
+	return importTasks(c, tasks)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	conf "github.com/iainlowe/utask/internal/config"
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// keyPath is where the profile's encryption key lives: storage.
+// encryption_key_file, or ~/.utask/keys/<profile>.key.
+func keyPath(cfg *conf.Config) (string, error) {
+	if p := cfg.Storage.EncryptionKeyFile; p != "" {
+		return p, nil
+	}
+	return utask.KeyPath(cfg.UI.Profile)
+}
+
+// profileKey returns the key from $UTASK_ENCRYPTION_KEY or the key file,
+// or nil when the profile isn't encrypted.
+func profileKey(cfg *conf.Config) (*utask.Key, error) {
+	if v := os.Getenv("UTASK_ENCRYPTION_KEY"); v != "" {
+		k, err := utask.ParseKey(v)
+		if err != nil {
+			return nil, fmt.Errorf("UTASK_ENCRYPTION_KEY: %w", err)
+		}
+		return k, nil
+	}
+	path, err := keyPath(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return utask.LoadKey(path)
+}
+
+// cmdKeygen creates the profile's encryption key, or prints a new one.
+func cmdKeygen(c *cli.Context) error {
+	k, err := utask.GenerateKey()
+	if err != nil {
+		return err
+	}
+	if c.Bool("print") {
+		fmt.Println(k.String())
+		return nil
+	}
+	cfg := getConfig(c)
+	path, err := keyPath(cfg)
+	if err != nil {
+		return err
+	}
+	if err := utask.SaveKey(path, k); err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%s already exists; tasks written with it could no longer be read", path)
+		}
+		return err
+	}
+	fmt.Println(tr(c).Sprintf("wrote %s; copy it to every machine that uses profile %s", path, cfg.UI.Profile))
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iainlowe/utask/internal/output"
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// kanbanColumns are the board's columns, in order; the ones after blocked
+// are left out when empty.
+var kanbanColumns = []string{"open", "in-progress", "blocked", "review", "done", "cancelled"}
+
+// printView draws tasks in the --format view, reporting false when the
+// listing is a plain table.
+func printView(c *cli.Context, tasks []utask.Task) (bool, error) {
+	view := strings.ToLower(strings.TrimSpace(c.String("format")))
+	if view == "" || view == "table" {
+		return false, nil
+	}
+	if view != "kanban" && view != "tree" {
+		return true, fmt.Errorf("unknown --format %q (want kanban or tree)", view)
+	}
+	if f, err := outputFormat(c, output.Table); err != nil || f != output.Table {
+		return true, fmt.Errorf("--format %s draws for the terminal; it doesn't combine with --output or --verbose", view)
+	}
+	ref := func(t utask.Task) string {
+		if c.Bool("full-id") {
+			return t.ID
+		}
+		return t.Ref()
+	}
+	now := time.Now()
+	if view == "kanban" {
+		return true, output.Board(os.Stdout, kanban(tasks, ref, now), boardWidth(c))
+	}
+	var roots []output.Node
+	switch by := c.String("group-by"); by {
+	case "tag":
+		roots = tagTree(tasks, ref, now)
+	case "parent":
+		roots = parentTree(tasks, ref, now)
+	default:
+		return true, fmt.Errorf("unknown --group-by %q (want tag or parent)", by)
+	}
+	return true, output.Tree(os.Stdout, roots)
+}
+
+func boardWidth(c *cli.Context) int {
+	if w := c.Int("width"); w > 0 {
+		return w
+	}
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+	return 100
+}
+
+// kanban sorts tasks into a column per status, keeping their order within
+// each. Tasks awaiting review get a column of their own.
+func kanban(tasks []utask.Task, ref func(utask.Task) string, now time.Time) []output.Column {
+	cards := map[string][][]string{}
+	for _, t := range tasks {
+		col := string(t.State())
+		if t.ReviewRequestedBy != "" && !t.Done {
+			col = "review"
+		}
+		card := []string{ref(t) + " " + t.Short()}
+		if badges := taskBadges(t, now); len(badges) > 0 {
+			card = append(card, strings.Join(badges, " · "))
+		}
+		cards[col] = append(cards[col], card)
+	}
+	var cols []output.Column
+	for i, name := range kanbanColumns {
+		if i > 2 && len(cards[name]) == 0 {
+			continue
+		}
+		cols = append(cols, output.Column{Title: name, Cards: cards[name]})
+	}
+	return cols
+}
+
+// tagTree puts each task under every tag it carries, tags in order and
+// untagged tasks last.
+func tagTree(tasks []utask.Task, ref func(utask.Task) string, now time.Time) []output.Node {
+	byTag := map[string][]output.Node{}
+	var untagged []output.Node
+	for _, t := range tasks {
+		n := output.Node{Text: treeLine(t, ref, now)}
+		if len(t.Tags) == 0 {
+			untagged = append(untagged, n)
+		}
+		for _, tag := range t.Tags {
+			byTag[tag] = append(byTag[tag], n)
+		}
+	}
+	tags := make([]string, 0, len(byTag))
+	for tag := range byTag {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	var roots []output.Node
+	for _, tag := range tags {
+		roots = append(roots, output.Node{Text: fmt.Sprintf("%s (%d)", tag, len(byTag[tag])), Children: byTag[tag]})
+	}
+	if len(untagged) > 0 {
+		roots = append(roots, output.Node{Text: fmt.Sprintf("(no tag) (%d)", len(untagged)), Children: untagged})
+	}
+	return roots
+}
+
+// parentTree nests each task under the listed tasks that depend on it, so
+// a task waiting on others has them as its children. Tasks no listed task
+// depends on are the roots.
+func parentTree(tasks []utask.Task, ref func(utask.Task) string, now time.Time) []output.Node {
+	byID := map[string]utask.Task{}
+	for _, t := range tasks {
+		byID[t.ID] = t
+	}
+	child := map[string]bool{}
+	for _, t := range tasks {
+		for _, dep := range t.DependsOn {
+			if _, ok := byID[dep]; ok {
+				child[dep] = true
+			}
+		}
+	}
+	var node func(t utask.Task, path map[string]bool) output.Node
+	node = func(t utask.Task, path map[string]bool) output.Node {
+		n := output.Node{Text: treeLine(t, ref, now)}
+		path[t.ID] = true
+		defer delete(path, t.ID)
+		for _, dep := range t.DependsOn {
+			if d, ok := byID[dep]; ok && !path[dep] {
+				n.Children = append(n.Children, node(d, path))
+			}
+		}
+		return n
+	}
+	var roots []output.Node
+	for _, t := range tasks {
+		if !child[t.ID] {
+			roots = append(roots, node(t, map[string]bool{}))
+		}
+	}
+	return roots
+}
+
+func treeLine(t utask.Task, ref func(utask.Task) string, now time.Time) string {
+	badges := append([]string{string(t.State())}, taskBadges(t, now)...)
+	return fmt.Sprintf("%s %s (%s)", ref(t), t.Short(), strings.Join(badges, " · "))
+}
+
+// taskBadges are the short notes a board card or tree line carries: the
+// priority (1 is highest), the due date, the assignee and the tags.
+func taskBadges(t utask.Task, now time.Time) []string {
+	var out []string
+	if t.Priority != 0 {
+		out = append(out, "p"+strconv.Itoa(t.Priority))
+	}
+	if due, ok := t.DueAt(now.Location()); ok {
+		if t.Overdue(now) {
+			out = append(out, "overdue "+due.Format("01-02"))
+		} else {
+			out = append(out, "due "+due.Format("01-02"))
+		}
+	}
+	if t.Assignee != "" {
+		out = append(out, "@"+t.Assignee)
+	}
+	for _, tag := range t.Tags {
+		out = append(out, "#"+tag)
+	}
+	return out
+}
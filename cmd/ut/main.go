@@ -3,13 +3,23 @@ package main
 import (
     "context"
     "encoding/json"
+    "errors"
     "fmt"
-    "log"
+    "log/slog"
+    "net/http"
     "os"
+    "os/signal"
+    "sort"
+    "strconv"
     "strings"
+    "syscall"
+    "time"
 
     conf "github.com/iainlowe/utask/internal/config"
     buildinfo "github.com/iainlowe/utask/internal/build"
+    "github.com/iainlowe/utask/internal/ghsync"
+    "github.com/iainlowe/utask/internal/i18n"
+    "github.com/iainlowe/utask/internal/output"
     "github.com/iainlowe/utask/internal/utask"
     cli "github.com/urfave/cli/v2"
 )
@@ -17,7 +27,52 @@ import (
 // appMetaKey is used to stash config into cli.App metadata
 const appMetaKey = "config"
 
+// configPathMetaKey stashes the path the config was read from, for
+// commands that edit it (see ut profile).
+const configPathMetaKey = "config_path"
+
+// i18nMetaKey stashes the run's message printer (see tr).
+const i18nMetaKey = "i18n"
+
+// exitInterrupted is the exit code for a command cut short by SIGINT or
+// SIGTERM, matching the shell convention for SIGINT.
+const exitInterrupted = 130
+
 func main() {
+	if code, ok := forwardToDaemon(os.Args); ok {
+		os.Exit(code)
+	}
+	// The first signal cancels the command's context so stores drain and
+	// partial progress is reported; a second one kills the process.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+	err := newApp().RunContext(ctx, os.Args)
+	// Check before stop, which cancels ctx itself.
+	interrupted := ctx.Err() != nil
+	stop()
+	if err != nil {
+		if interrupted {
+			fmt.Fprintf(os.Stderr, "interrupted: %v (changes made before the signal were kept)\n", err)
+			os.Exit(exitInterrupted)
+		}
+		// Print to stderr and exit non-zero
+		fmt.Fprintln(os.Stderr, explainError(err))
+		os.Exit(1)
+	}
+}
+
+// explainError renders a command's error for the terminal, adding what to
+// do next where the error alone doesn't say.
+func explainError(err error) string {
+	if errors.Is(err, utask.ErrConflict) {
+		return err.Error() + "\nthe task changed since it was read; `ut history <id>` shows what changed and `ut get <id>` its current revision, to retry with (and pass to --if-revision, if you used it)"
+	}
+	return err.Error()
+}
+
+// newApp builds the CLI. The daemon builds a fresh one per forwarded
+// invocation so per-run metadata never leaks between commands.
+func newApp() *cli.App {
     // Customize version flag to avoid -v alias conflict with verbose
     cli.VersionFlag = &cli.BoolFlag{Name: "version", Usage: "print version and exit"}
     cli.VersionPrinter = func(c *cli.Context) {
@@ -36,12 +91,25 @@ func main() {
         Flags: []cli.Flag{
             &cli.StringFlag{Name: "config", Aliases: []string{"c"}, Usage: "path to config file", EnvVars: []string{"UTASK_CONFIG"}},
             &cli.StringFlag{Name: "nats-url", Usage: "NATS server URL", EnvVars: []string{"UTASK_NATS_URL"}},
+			&cli.StringFlag{Name: "backend", Usage: "storage driver: nats|sqlite (a local file, no server)", EnvVars: []string{"UTASK_BACKEND"}},
+			&cli.DurationFlag{Name: "timeout", Usage: "per-operation NATS timeout (e.g. 5s)", EnvVars: []string{"UTASK_NATS_TIMEOUT"}},
             &cli.StringFlag{Name: "openai-api-key", Usage: "OpenAI API key", EnvVars: []string{"OPENAI_API_KEY"}},
             &cli.StringFlag{Name: "openai-model", Usage: "OpenAI model name", EnvVars: []string{"UTASK_OPENAI_MODEL"}},
 			&cli.StringFlag{Name: "profile", Usage: "profile/namespace", EnvVars: []string{"UTASK_PROFILE"}},
-			&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "increase verbosity"},
+			&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "increase verbosity (implies --log-level debug)"},
+			&cli.BoolFlag{Name: "offline", Usage: "read from the local cache and queue changes until the next connection", EnvVars: []string{"UTASK_OFFLINE"}},
+			&cli.StringFlag{Name: "log-level", Usage: "log level: debug|info|warn|error", EnvVars: []string{"UTASK_LOG_LEVEL"}},
+			&cli.StringFlag{Name: "log-format", Usage: "log format: text|json", EnvVars: []string{"UTASK_LOG_FORMAT"}},
+			&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Usage: "output format of list, get, tags and query: table|json|csv|tsv", EnvVars: []string{"UTASK_OUTPUT"}},
+			&cli.StringFlag{Name: "cpuprofile", Usage: "write a CPU profile to this file"},
+			&cli.StringFlag{Name: "memprofile", Usage: "write a heap profile to this file on exit"},
+			&cli.StringFlag{Name: "trace", Usage: "write an execution trace to this file"},
 		},
+		After: func(c *cli.Context) error { return stopProfiling() },
 		Before: func(c *cli.Context) error {
+			if err := startProfiling(c); err != nil {
+				return err
+			}
 			// Determine config file path
 			cfgPath := c.String("config")
 			if cfgPath == "" {
@@ -62,6 +130,10 @@ func main() {
 				return err
 			}
 
+			// Pick the profile first so its settings sit between the
+			// file and the environment.
+			cfg.UseProfile(c.String("profile"))
+
 			// Overlay env
 			conf.OverlayEnv(cfg)
 
@@ -69,6 +141,12 @@ func main() {
 			if c.IsSet("nats-url") {
 				cfg.NATS.URL = c.String("nats-url")
 			}
+			if c.IsSet("backend") {
+				cfg.Storage.Driver = c.String("backend")
+			}
+			if c.IsSet("timeout") {
+				cfg.NATS.Timeout = c.Duration("timeout")
+			}
 			if c.IsSet("openai-api-key") {
 				cfg.OpenAI.APIKey = c.String("openai-api-key")
 			}
@@ -78,6 +156,14 @@ func main() {
 			if c.IsSet("profile") {
 				cfg.UI.Profile = c.String("profile")
 			}
+			if c.IsSet("log-level") {
+				cfg.Log.Level = c.String("log-level")
+			} else if c.Bool("verbose") {
+				cfg.Log.Level = "debug"
+			}
+			if c.IsSet("log-format") {
+				cfg.Log.Format = c.String("log-format")
+			}
 
 			// Defaults if still empty
 			if cfg.NATS.URL == "" {
@@ -87,11 +173,19 @@ func main() {
 				cfg.UI.Profile = "default"
 			}
 
+			logger, err := newLogger(stderrWriter{}, cfg.Log.Level, cfg.Log.Format)
+			if err != nil {
+				return err
+			}
+			slog.SetDefault(logger)
+
 			// Stash in metadata for commands
 			if c.App.Metadata == nil {
 				c.App.Metadata = map[string]interface{}{}
 			}
 			c.App.Metadata[appMetaKey] = cfg
+			c.App.Metadata[configPathMetaKey] = cfgPath
+			c.App.Metadata[i18nMetaKey] = i18n.New(i18n.Detect(cfg.UI.Locale, os.Getenv))
 			return nil
 		},
 		Commands: []*cli.Command{
@@ -100,54 +194,345 @@ func main() {
 				Usage: "Run MCP server",
 				Flags: []cli.Flag{
 					&cli.BoolFlag{Name: "stdio", Usage: "run MCP over stdio"},
+					&cli.StringFlag{Name: "http", Usage: "serve MCP over HTTP (Streamable HTTP at /mcp, HTTP+SSE at /sse) on this address, e.g. :8386"},
+					&cli.IntFlag{Name: "max-in-flight", Usage: "requests to run at once; more wait for a slot (default mcp.max_in_flight, else 8)"},
 				},
 				Action: func(c *cli.Context) error {
 					if c.Bool("stdio") {
 						return runMCPStdio(c)
 					}
+					if c.IsSet("http") {
+						return runMCPHTTP(c)
+					}
 					return cli.ShowSubcommandHelp(c)
 				},
 			},
-			{Name: "create", Usage: "Create a task", Flags: []cli.Flag{
+			{Name: "create", Usage: "Create a task", Flags: append([]cli.Flag{
 				&cli.StringFlag{Name: "title", Usage: "task text/title"},
 				&cli.StringSliceFlag{Name: "tag", Usage: "task tag (repeatable)"},
-				// Single text field; no separate extended/description
+				&cli.StringFlag{Name: "details", Usage: "longer description, kept apart from the title"},
+				&cli.StringFlag{Name: "details-file", Usage: "read the details from a file, or - for stdin"},
 				&cli.IntFlag{Name: "priority", Value: 1, Usage: "priority (1=highest)"},
 				&cli.IntFlag{Name: "estimate-min", Usage: "estimate in minutes"},
-			}, Action: cmdCreate},
+				&cli.StringFlag{Name: "assignee", Aliases: []string{"assign"}, Usage: "assign to: me, a people: handle or a name/email"},
+				&cli.BoolFlag{Name: "private", Usage: "visible only to you (and admins)"},
+				&cli.StringSliceFlag{Name: "context", Usage: "where it can be done, e.g. @home (repeatable)"},
+				&cli.BoolFlag{Name: "dates", Usage: "set a due date from phrases like \"by friday\" in the title (default: config dates.capture)"},
+				&cli.StringSliceFlag{Name: "depends-on", Usage: "task that must be closed first (repeatable)"},
+				&cli.StringFlag{Name: "recur", Usage: "repeat on close: every:7d, weekly, weekdays or an RRULE like FREQ=MONTHLY;COUNT=6"},
+				&cli.BoolFlag{Name: "force-new", Usage: "create a new task with a random id even if one with the same content exists"},
+				suggestTagsFlag,
+			}, dateFlags("")...), Action: cmdCreate},
 			{Name: "list", Usage: "List tasks", Flags: []cli.Flag{
 				&cli.StringFlag{Name: "tag", Usage: "filter by single tag"},
 				&cli.StringFlag{Name: "tags", Usage: "ANY match: comma-separated tags"},
 				&cli.StringFlag{Name: "all-tags", Usage: "ALL match: comma-separated tags"},
-				&cli.StringFlag{Name: "status", Usage: "filter by status: open|closed"},
+				&cli.StringFlag{Name: "status", Usage: "filter by status: open (any open state)|in-progress|blocked|done|cancelled|closed|review"},
+				&cli.StringFlag{Name: "assignee", Usage: "filter by assignee: me, a name/email, or none for unassigned"},
+				&cli.StringSliceFlag{Name: "trailer", Usage: "only tasks with this trailer: Key=Value, or Key for any value (repeatable)"},
+				&cli.BoolFlag{Name: "fresh", Usage: "read from the server instead of the local cache"},
+				&cli.BoolFlag{Name: "in-progress", Usage: "only tasks someone has started, with who and for how long"},
+				&cli.BoolFlag{Name: "waiting", Usage: "only snoozed tasks, with when they return (hidden otherwise)"},
+				&cli.StringFlag{Name: "context", Usage: "only tasks in this context, or all (default: the active context, see ut context)"},
+				&cli.StringFlag{Name: "due-before", Usage: "only tasks due by then, e.g. fri or 2026-03-01"},
+				&cli.BoolFlag{Name: "overdue", Usage: "only open tasks past their due date"},
+				&cli.StringFlag{Name: "since", Usage: "only tasks changed (closed tasks: closed) since then, e.g. 7d or 2026-03-01"},
+				&cli.StringFlag{Name: "until", Usage: "only tasks changed (closed tasks: closed) by then, e.g. 2026-03-31"},
+				&cli.StringFlag{Name: "sort", Usage: "order by: priority (highest, i.e. 1, first and unset last, then oldest; default)|created|due (soonest first, undated last)|text"},
+				&cli.BoolFlag{Name: "reverse", Usage: "reverse the order"},
+				&cli.IntFlag{Name: "limit", Usage: "print at most this many tasks, then the --cursor for the rest"},
+				&cli.StringFlag{Name: "cursor", Usage: "continue a listing from the cursor a limited one printed; pass the same filters"},
+				&cli.BoolFlag{Name: "ready", Usage: "only open tasks whose dependencies are all closed"},
+				&cli.BoolFlag{Name: "recurring", Usage: "only open tasks that repeat, with their rule"},
+				&cli.StringFlag{Name: "format", Usage: "draw the listing as kanban (a column per status) or tree (see --group-by) instead of a table"},
+				&cli.StringFlag{Name: "group-by", Value: "tag", Usage: "tree branches: tag, or parent (each task under the tasks that depend on it)"},
+				&cli.IntFlag{Name: "width", Usage: "terminal columns for --format kanban (default $COLUMNS, else 100)"},
+				noContextFlag,
+				fullIDFlag,
 			}, Action: cmdList},
-			{Name: "get", Usage: "Get a task", Action: cmdGet},
-			{Name: "close", Usage: "Close a task", Action: cmdClose},
-			{Name: "reopen", Usage: "Reopen a task", Action: cmdReopen},
-			{Name: "update", Usage: "Update a task text/tags", Flags: []cli.Flag{
+			{Name: "mine", Usage: "List open tasks assigned to your identity", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "tag", Usage: "filter by single tag"},
+				&cli.StringFlag{Name: "context", Usage: "only tasks in this context, or all (default: the active context, see ut context)"},
+				&cli.BoolFlag{Name: "fresh", Usage: "read from the server instead of the local cache"},
+				fullIDFlag,
+			}, Action: cmdMine},
+			{Name: "waiting", Usage: "List open tasks you delegated, with who has them and for how long", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "tag", Usage: "filter by single tag"},
+				&cli.StringFlag{Name: "context", Usage: "only tasks in this context, or all (default: the active context, see ut context)"},
+			}, Action: cmdWaiting},
+			{Name: "context", Usage: "Show the filter context in use and the active context; listings only show tasks in them", Action: cmdContext, Subcommands: []*cli.Command{
+				{Name: "set", Usage: "Narrow list, mine, waiting and random to a context, e.g. @home (none clears), or define a named filter context", ArgsUsage: "<@context|none> | <name> key=value...", Action: cmdContextSet},
+				{Name: "use", Usage: "Apply a named filter context to list, query and count (none stops)", ArgsUsage: "<name|none>", Action: cmdContextUse},
+				{Name: "filters", Usage: "Named filter contexts, * marking the one in use", Action: cmdContextFilters},
+				{Name: "delete", Usage: "Remove a named filter context from the config file", ArgsUsage: "<name>", Action: cmdContextDelete},
+				{Name: "clear", Usage: "Show tasks from every context again", Action: cmdContextClear},
+				{Name: "list", Usage: "Contexts of open tasks, with counts", Action: cmdContextList},
+			}},
+			{Name: "random", Usage: "Pick a random open task to work on", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "tag", Usage: "pick within this tag"},
+				&cli.StringFlag{Name: "context", Usage: "pick within this context, or all (default: the active context)"},
+				&cli.BoolFlag{Name: "weighted", Usage: "favour urgent tasks: high priority, due soon or overdue"},
+				&cli.BoolFlag{Name: "start", Usage: "claim the picked task, as ut start does"},
+			}, Action: cmdRandom},
+			{Name: "assign", Usage: "Give a task to someone (me, a people: handle, a name/email), or none to unassign it", ArgsUsage: "<id> <who|none>", Flags: []cli.Flag{
+				&cli.Uint64Flag{Name: "if-revision", Usage: "fail unless the task is still at this revision"},
+			}, Action: cmdAssign},
+			{Name: "delegate", Usage: "Hand a task to someone (me, a people: handle, a name/email) and wait on them; none stops waiting", Flags: []cli.Flag{
+				&cli.Uint64Flag{Name: "if-revision", Usage: "fail unless the task is still at this revision"},
+			}, Action: cmdDelegate},
+			{Name: "block", Usage: "Make a task wait until another is closed, or mark it blocked", ArgsUsage: "<id> [<blocking-id>]", Flags: []cli.Flag{
+				&cli.Uint64Flag{Name: "if-revision", Usage: "fail unless the task is still at this revision"},
+			}, Action: cmdBlock},
+			{Name: "unblock", Usage: "Drop a dependency of a task, or all of them and its blocked status", ArgsUsage: "<id> [<blocking-id>]", Flags: []cli.Flag{
+				&cli.Uint64Flag{Name: "if-revision", Usage: "fail unless the task is still at this revision"},
+			}, Action: cmdUnblock},
+			{Name: "cancel", Usage: "Close a task without doing it", Flags: []cli.Flag{
+				&cli.Uint64Flag{Name: "if-revision", Usage: "fail unless the task is still at this revision"},
+			}, Action: cmdCancel},
+			{Name: "activity", Usage: "Show who created, changed, closed, reassigned or deleted tasks, oldest first", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "user", Usage: "only events by this identity (me, a name or an email)"},
+				&cli.StringFlag{Name: "since", Value: "7d", Usage: "how far back to look (e.g. 7d, 2w)"},
+				&cli.IntFlag{Name: "limit", Usage: "show only the most recent N events (0 = all)"},
+			}, Action: cmdActivity},
+			{Name: "watch", Usage: "Stream task changes as they happen until interrupted", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "tag", Usage: "only changes to tasks with this tag"},
+				&cli.BoolFlag{Name: "json", Usage: "print each event as a JSON line"},
+			}, Action: cmdWatch},
+			{Name: "clone", Usage: "Copy a task into a new one linked back with a Cloned-from trailer", ArgsUsage: "<id>", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "title", Usage: "new first line (default: the original's)"},
+				&cli.StringSliceFlag{Name: "tag", Usage: "replace the copied tags (repeatable)"},
+				&cli.StringFlag{Name: "assignee", Usage: "assign the clone to: me or a name/email"},
+				&cli.StringFlag{Name: "trailers", Usage: "comma-separated trailer keys to copy (default: all but Approved-by, Delegated-to, Cloned-from and Due)"},
+			}, Action: cmdClone},
+			{Name: "diff", Usage: "Show what changed between two revisions of a task (default: its last change)", ArgsUsage: "<id> [revA [revB]]", Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "list", Usage: "list the recorded revisions instead"},
+			}, Action: cmdDiff},
+			{Name: "history", Usage: "Show a task's revisions with when, who and what changed", ArgsUsage: "<id>", Action: cmdHistory},
+			{Name: "undo", Usage: "Revert the last create, update, close or delete", Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "list", Usage: "list the mutations that can be undone, newest first"},
+			}, Action: cmdUndo},
+			{Name: "get", Usage: "Get a task", Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "annotations", Usage: "print the text and annotation history instead of JSON"},
+			}, Action: cmdGet},
+			{Name: "annotate", Usage: "Add a note to a task's history", ArgsUsage: "<id> <text>", Action: cmdAnnotate},
+			{Name: "close", Usage: "Close a task", Flags: []cli.Flag{
+				&cli.Uint64Flag{Name: "if-revision", Usage: "fail unless the task is still at this revision"},
+			}, Action: cmdClose},
+			{Name: "reopen", Usage: "Reopen a task, withdrawing any pending review", Action: cmdReopen},
+			{Name: "start", Usage: "Claim a task you are working on so others can see it and mark it in-progress (renews your claim)", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "lease", Value: "2h", Usage: "how long the claim lasts unless renewed (e.g. 2h, 1d)"},
+				workerFlag,
+			}, Action: cmdStart},
+			{Name: "pomo", Usage: "Work on a task in pomodoro sessions, recording each one on the task", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "length", Value: "25m", Usage: "session length"},
+				&cli.StringFlag{Name: "break", Value: "5m", Usage: "break length"},
+			}, Action: cmdPomo},
+			{Name: "snooze", Usage: "Hide tasks from ut list until later; given ids, or all open tasks matching --tag/--tags/--assignee", ArgsUsage: "[<id>...]", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "until", Required: true, Usage: "tomorrow|next-week|next-month, a duration (4h, 2d, 1w, 1m = one month), YYYY-MM-DD, or none to wake"},
+				&cli.StringFlag{Name: "tag", Usage: "snooze open tasks with this tag"},
+				&cli.StringFlag{Name: "tags", Usage: "snooze open tasks with ANY of these comma-separated tags"},
+				&cli.StringFlag{Name: "assignee", Usage: "snooze open tasks assigned to: me, a name/email, or none"},
+			}, Action: cmdSnooze},
+			{Name: "stop", Aliases: []string{"release"}, Usage: "Release your claim on a task, recording the time since ut start as worked, and set it back to open for others to claim", ArgsUsage: "[<id>]", Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "discard", Usage: "release without recording the time"},
+				workerFlag,
+			}, Action: cmdStop},
+			{Name: "active", Usage: "Show your running timers: the tasks you have started and for how long", Flags: []cli.Flag{workerFlag}, Action: cmdActive},
+			{Name: "claim", Usage: "Claim the oldest unclaimed open task matching a filter for a worker, as a task queue", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "tag", Usage: "tasks with this tag"},
+				&cli.StringFlag{Name: "tags", Usage: "tasks with ANY of these comma-separated tags"},
+				&cli.StringFlag{Name: "all-tags", Usage: "tasks with ALL of these comma-separated tags"},
+				&cli.StringFlag{Name: "assignee", Usage: "tasks assigned to me, a name/email, or none for unassigned"},
+				&cli.StringFlag{Name: "lease", Value: "10m", Usage: "how long the claim lasts unless completed, released or renewed with ut start"},
+				workerFlag,
+				fullIDFlag,
+			}, Action: cmdClaim},
+			{Name: "complete", Usage: "Close a task the worker claimed", ArgsUsage: "<id>", Flags: []cli.Flag{workerFlag}, Action: cmdComplete},
+			{Name: "approve", Usage: "Approve and close a task awaiting review", Flags: []cli.Flag{
+				&cli.Uint64Flag{Name: "if-revision", Usage: "fail unless the task is still at this revision"},
+			}, Action: cmdApprove},
+			{Name: "trailer", Usage: "List a task's trailers, or add or remove one", ArgsUsage: "<id> [add \"Key: Value\" | rm Key]", Action: cmdTrailer},
+			{Name: "edit", Usage: "Edit a task's text, tags and trailers in $VISUAL or $EDITOR", ArgsUsage: "<id>", Action: cmdEdit},
+			{Name: "update", Usage: "Update a task text/tags", Flags: append([]cli.Flag{
 				&cli.StringFlag{Name: "text", Usage: "new task text"},
 				&cli.StringFlag{Name: "title", Usage: "new title/text"},
-				// Single text field; no separate extended/description
+				&cli.StringFlag{Name: "details", Usage: "replace the details; empty clears them"},
+				&cli.StringFlag{Name: "details-file", Usage: "read the new details from a file, or - for stdin"},
 				&cli.StringSliceFlag{Name: "tag", Usage: "replace tags (repeatable)"},
 				&cli.StringFlag{Name: "tags", Usage: "replace tags (comma-separated)"},
 				&cli.BoolFlag{Name: "done", Usage: "set done true/false"},
 				&cli.IntFlag{Name: "priority", Usage: "update priority"},
-			}, Action: cmdUpdate},
-			{Name: "delete", Usage: "Delete a task", Aliases: []string{"rm"}, Action: cmdDelete},
+				&cli.StringFlag{Name: "assignee", Usage: "reassign to: me, a name/email, or none"},
+				&cli.BoolFlag{Name: "private", Usage: "set visibility: --private or --private=false to share"},
+				&cli.StringSliceFlag{Name: "context", Usage: "replace contexts, e.g. @home (repeatable; none clears)"},
+				&cli.StringFlag{Name: "recur", Usage: "change the recurrence rule; none stops the series"},
+				&cli.Uint64Flag{Name: "if-revision", Usage: "fail unless the task is still at this revision"},
+				suggestTagsFlag,
+			}, dateFlags("; none clears")...), Action: cmdUpdate},
+			{Name: "delete", Usage: "Move a task to the trash", Aliases: []string{"rm"}, Flags: []cli.Flag{
+				&cli.Uint64Flag{Name: "if-revision", Usage: "fail unless the task is still at this revision"},
+			}, Action: cmdDelete},
+			{Name: "bulk", Usage: "Close, retag or delete every task matching a filter", Subcommands: []*cli.Command{
+				{Name: "close", Usage: "Close the matching tasks", Flags: bulkFlags(), Action: cmdBulkClose},
+				{Name: "tag", Usage: "Add and remove tags on the matching tasks", Flags: bulkFlags(
+					&cli.StringFlag{Name: "add", Usage: "comma-separated tags to add"},
+					&cli.StringFlag{Name: "remove", Usage: "comma-separated tags to remove"},
+				), Action: cmdBulkTag},
+				{Name: "delete", Usage: "Delete the matching tasks", Flags: bulkFlags(
+					&cli.BoolFlag{Name: "yes", Usage: "don't ask for confirmation"},
+				), Action: cmdBulkDelete},
+			}},
 			{Name: "tags", Usage: "List tags", Action: cmdTags},
-            {Name: "rebuild-index", Usage: "Rebuild tag index", Action: cmdRebuildIndex},
+			{Name: "dedupe", Usage: "List groups of tasks with identical content", Action: cmdDedupe},
+			{Name: "count", Usage: "Print how many tasks ut list would show, for prompts and status bars", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "tag", Usage: "filter by single tag"},
+				&cli.StringFlag{Name: "tags", Usage: "ANY match: comma-separated tags"},
+				&cli.StringFlag{Name: "all-tags", Usage: "ALL match: comma-separated tags"},
+				&cli.StringFlag{Name: "status", Usage: "filter by status: open (any open state)|in-progress|blocked|done|cancelled|closed|review"},
+				&cli.StringFlag{Name: "assignee", Usage: "filter by assignee: me, a name/email, or none for unassigned"},
+				&cli.StringSliceFlag{Name: "trailer", Usage: "only tasks with this trailer: Key=Value, or Key for any value (repeatable)"},
+				&cli.StringFlag{Name: "context", Usage: "only tasks in this context, or all (default: the active context, see ut context)"},
+				&cli.StringFlag{Name: "due-before", Usage: "only tasks due by then, e.g. fri or 2026-03-01"},
+				&cli.BoolFlag{Name: "overdue", Usage: "only open tasks past their due date"},
+				&cli.StringFlag{Name: "since", Usage: "only tasks changed (closed tasks: closed) since then, e.g. 7d or 2026-03-01"},
+				&cli.StringFlag{Name: "until", Usage: "only tasks changed (closed tasks: closed) by then, e.g. 2026-03-31"},
+				noContextFlag,
+			}, Action: cmdCount},
+			{Name: "summary", Usage: "Show open and closed totals, per tag, overdue, and tasks created and closed lately", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "since", Value: "7d", Usage: "window for created and closed: a duration (7d), a date (YYYY-MM-DD) or sprint-start"},
+			}, Action: cmdSummary},
+			{Name: "query", Usage: "List tasks with any of some tags and all of others", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "any", Usage: "tasks with ANY of these comma-separated tags"},
+				&cli.StringFlag{Name: "all", Usage: "tasks with ALL of these comma-separated tags"},
+				&cli.IntFlag{Name: "limit", Usage: "list at most this many tasks (0 = all), then the --cursor for the rest"},
+				&cli.StringFlag{Name: "cursor", Usage: "continue a query from the cursor a limited one printed; pass the same tags"},
+				noContextFlag,
+				fullIDFlag,
+			}, Action: cmdQuery},
+			{Name: "alias", Usage: "Name a task, list aliases, or remove one", ArgsUsage: "[<name> <id>]", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "rm", Usage: "remove this alias"},
+			}, Action: cmdAlias},
+            {Name: "rebuild-index", Usage: "Rebuild the tag and status indexes", Action: cmdRebuildIndex},
+			{Name: "maintain", Usage: "Move the tag index to per-task keys, clear its delete markers and number tasks that have no T-<n> yet", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "keyspace", Usage: "move tasks to this key layout first: flat|sharded"},
+			}, Action: cmdMaintain},
+			{Name: "ping", Usage: "Check NATS, buckets and watchers (or a `ut serve` instance with --url); exits non-zero when unhealthy", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "url", Usage: "check this server's /readyz instead, e.g. http://localhost:8385"},
+			}, Action: cmdPing},
+			{Name: "plan", Usage: "Forecast open-task estimates per week against your capacity", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "until", Value: "4w", Usage: "horizon: a date (YYYY-MM-DD) or a duration from now (6w)"},
+				&cli.StringFlag{Name: "capacity", Usage: "work per week, e.g. 30h (default: plan.weekly_capacity)"},
+				&cli.StringFlag{Name: "assignee", Usage: "only tasks assigned to: me or a name/email"},
+				&cli.StringFlag{Name: "tag", Usage: "only tasks with this tag"},
+				&cli.StringFlag{Name: "format", Value: "text", Usage: "output format: text|json"},
+			}, Action: cmdPlan},
+			{Name: "chart", Usage: "Draw terminal charts from the audit trail", Subcommands: []*cli.Command{
+				{Name: "burndown", Usage: "Open tasks at the end of each day (or week)", Flags: []cli.Flag{
+					&cli.StringFlag{Name: "tag", Usage: "only tasks with this tag, e.g. sprint-12"},
+					&cli.StringFlag{Name: "since", Usage: "window: a duration (14d), a date (YYYY-MM-DD) or sprint-start (default: sprint-start if configured, else 14d)"},
+					&cli.IntFlag{Name: "width", Value: 50, Usage: "columns for the longest bar"},
+				}, Action: cmdChart("burndown")},
+				{Name: "created-vs-closed", Usage: "Tasks created and closed per day (or week)", Flags: []cli.Flag{
+					&cli.StringFlag{Name: "since", Value: "30d", Usage: "window: a duration (90d), a date (YYYY-MM-DD) or sprint-start"},
+					&cli.IntFlag{Name: "width", Value: 50, Usage: "columns for the longest bar"},
+				}, Action: cmdChart("created-vs-closed")},
+			}},
+			{Name: "report", Usage: "Summarize tasks for sharing; without a subcommand, created vs closed per day, average cycle time and per-tag throughput", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "tag", Usage: "only tasks with this tag"},
+				&cli.StringFlag{Name: "window", Value: "30d", Usage: "window: a duration (30d), a date (YYYY-MM-DD) or sprint-start"},
+			}, Action: cmdReportFlow, Subcommands: []*cli.Command{
+				{Name: "team", Usage: "Per-assignee open, closed, estimate load and overdue counts", Flags: []cli.Flag{
+					&cli.StringFlag{Name: "since", Value: "7d", Usage: "closed-count window: a duration (7d), a date (YYYY-MM-DD) or sprint-start"},
+					&cli.StringFlag{Name: "format", Value: "text", Usage: "output format: text|csv|json"},
+				}, Action: cmdReportTeam},
+				{Name: "digest", Usage: "Backlog summary: open, created, closed, overdue and due-soon tasks, by assignee", Flags: []cli.Flag{
+					&cli.StringFlag{Name: "since", Value: "7d", Usage: "window: a duration (7d), a date (YYYY-MM-DD) or sprint-start"},
+					&cli.StringFlag{Name: "format", Value: "text", Usage: "output format: text|json"},
+					&cli.BoolFlag{Name: "send", Usage: "deliver to every report.schedule destination now instead of printing"},
+				}, Action: cmdReportDigest},
+			}},
+			{Name: "migrate", Usage: "Rewrite tasks stored at older schema versions in the current one", Action: cmdMigrate},
+			{Name: "purge", Usage: "Empty the trash, or with --profile delete a profile's buckets, history and local cache for good", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "older-than", Usage: "only purge tasks deleted at least this long ago, e.g. 30d"},
+				&cli.StringFlag{Name: "profile", Usage: "profile to delete"},
+				&cli.StringFlag{Name: "export", Usage: "with --profile: write the tasks to this JSONL file first"},
+				&cli.BoolFlag{Name: "yes", Usage: "with --profile: don't prompt (exports only with --export)"},
+			}, Action: cmdPurge},
+			{Name: "trash", Usage: "List deleted tasks kept in the trash", Action: cmdTrashList, Subcommands: []*cli.Command{
+				{Name: "list", Usage: "Deleted tasks, most recently deleted first", Action: cmdTrashList},
+			}},
+			{Name: "restore", Usage: "Bring a deleted task back from the trash", ArgsUsage: "<id>", Action: cmdRestore},
+			{Name: "profile", Usage: "List profiles; each names its own buckets, server and create defaults", Action: cmdProfileList, Subcommands: []*cli.Command{
+				{Name: "list", Usage: "Configured profiles, * marking the one in use", Action: cmdProfileList},
+				{Name: "create", Usage: "Add a profile to the config file", ArgsUsage: "<name>", Flags: []cli.Flag{
+					&cli.StringFlag{Name: "url", Usage: "NATS server URL for the profile (default: nats.url)"},
+					&cli.StringSliceFlag{Name: "tag", Usage: "tag ut create adds when given no --tag (repeatable)"},
+					&cli.IntFlag{Name: "priority", Usage: "priority ut create uses when given no --priority"},
+					&cli.BoolFlag{Name: "use", Usage: "also make it the default profile"},
+				}, Action: cmdProfileCreate},
+				{Name: "use", Usage: "Make a profile the default (ui.profile); --profile and UTASK_PROFILE still override it", ArgsUsage: "<name>", Action: cmdProfileUse},
+				{Name: "delete", Usage: "Remove a profile from the config file; its tasks stay until ut purge", ArgsUsage: "<name>", Action: cmdProfileDelete},
+			}},
+			{Name: "keygen", Usage: "Create the profile's encryption key (see storage.encryption_key_file)", Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "print", Usage: "print a new key instead of writing the key file"},
+			}, Action: cmdKeygen},
             {Name: "check", Usage: "Check tasks for trailer issues", Flags: []cli.Flag{
                 &cli.StringFlag{Name: "tag", Usage: "filter by tag"},
                 &cli.StringFlag{Name: "status", Usage: "filter by status: open|closed"},
             }, Action: cmdCheck},
+			{Name: "export", Usage: "Export all tasks", ArgsUsage: "[jsonl|atom|ics]", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "format", Value: "jsonl", Usage: "output format: jsonl|atom|ics"},
+				&cli.StringFlag{Name: "since", Value: "30d", Usage: "atom: activity window (e.g. 7d, 2w)"},
+				&cli.IntFlag{Name: "limit", Value: 50, Usage: "atom: maximum entries (0 = all)"},
+				&cli.BoolFlag{Name: "revisions", Usage: "jsonl: every kept revision of each task, oldest first, for ut import to replay"},
+			}, Action: cmdExport},
+			{Name: "import", Usage: "Import tasks from a ut export JSONL file, or another format", ArgsUsage: "<file.jsonl|->", Action: func(c *cli.Context) error {
+				if c.NArg() == 0 {
+					return cli.ShowSubcommandHelp(c)
+				}
+				return cmdImportJSONL(c)
+			}, Subcommands: []*cli.Command{
+				{Name: "jsonl", Usage: "Import JSONL (one task per line) from a file or stdin", ArgsUsage: "[file|-]", Action: cmdImportJSONL},
+				{Name: "csv", Usage: "Import CSV rows mapped onto task fields", ArgsUsage: "<file|->", Flags: []cli.Flag{
+					&cli.StringFlag{Name: "map", Required: true, Usage: "field=Column pairs, e.g. title=Summary,tags=Labels,due=DueDate"},
+					&cli.StringFlag{Name: "delimiter", Value: ",", Usage: "field delimiter (use \\t or tab for TSV)"},
+					&cli.StringFlag{Name: "encoding", Value: "utf-8", Usage: "input encoding: utf-8|latin1|utf-16le|utf-16be"},
+					&cli.StringFlag{Name: "tag-sep", Value: ",", Usage: "separator inside the tags column"},
+					&cli.BoolFlag{Name: "dry-run", Usage: "preview mapped tasks without writing"},
+				}, Action: cmdImportCSV},
+				{Name: "todoist", Usage: "Import from the Todoist REST API or a backup zip/CSV", Flags: []cli.Flag{
+					&cli.StringFlag{Name: "token", Usage: "Todoist API token", EnvVars: []string{"TODOIST_API_TOKEN"}},
+					&cli.StringFlag{Name: "backup", Usage: "path to a Todoist backup .zip or project .csv"},
+					&cli.BoolFlag{Name: "dry-run", Usage: "preview mapped tasks without writing"},
+				}, Action: cmdImportTodoist},
+				{Name: "ics", Usage: "Import iCalendar VTODOs (Apple Reminders, CalDAV)", ArgsUsage: "[file|-]", Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "dry-run", Usage: "preview mapped tasks without writing"},
+				}, Action: cmdImportICS},
+			}},
+			{Name: "sync", Usage: "Sync tasks with another system", Subcommands: []*cli.Command{
+				{Name: "github", Usage: "Sync GitHub issues with a label and tasks with the same tag, both ways", Flags: []cli.Flag{
+					&cli.StringFlag{Name: "repo", Required: true, Usage: "repository as owner/name"},
+					&cli.StringFlag{Name: "label", Value: "utask", Usage: "issue label and task tag to sync"},
+					&cli.StringFlag{Name: "token", Usage: "GitHub token with issues read/write access", EnvVars: []string{"GITHUB_TOKEN"}},
+					&cli.StringFlag{Name: "api", Value: ghsync.API, Usage: "GitHub API URL, e.g. https://ghe.example.org/api/v3"},
+				}, Action: cmdSyncGithub},
+			}},
+			{Name: "serve", Usage: "Serve the REST API and web dashboard", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "addr", Value: "127.0.0.1:8385", Usage: "listen address; use :8385 to accept connections from other hosts (set access.tokens first)"},
+				&cli.StringSliceFlag{Name: "allow-host", Usage: "also answer requests addressed to this host name, e.g. behind a proxy (repeatable; * = any)"},
+				&cli.StringFlag{Name: "grpc", Usage: "also serve the utask.v1.TaskService gRPC API on this address, e.g. :9090"},
+			}, Action: cmdServe},
+			{Name: "bot", Usage: "Run the Matrix/Discord chat bridge configured under bot:", Action: cmdBot},
+			{Name: "notify", Usage: "Notify people: when tasks @mention them or are assigned to them", Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "dry-run", Usage: "print notices instead of sending them"},
+			}, Action: cmdNotify},
+			{Name: "daemon", Usage: "Keep connections and caches warm and serve CLI commands over a Unix socket", Flags: []cli.Flag{
+				&cli.StringFlag{Name: "socket", Usage: "socket path (default ~/.utask/run/ut.sock)", EnvVars: []string{"UTASK_DAEMON_SOCKET"}},
+				&cli.DurationFlag{Name: "compact-every", Value: 6 * time.Hour, Usage: "tag index compaction interval (0 = never)"},
+			}, Action: cmdDaemon},
         },
     }
-
-	if err := app.Run(os.Args); err != nil {
-		// Print to stderr and exit non-zero
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
-	}
+	return app
 }
 
 func getConfig(c *cli.Context) *conf.Config {
@@ -160,6 +545,16 @@ func getConfig(c *cli.Context) *conf.Config {
 	return &conf.Config{}
 }
 
+// tr returns the printer for the run's locale (ui.locale or $LANG).
+func tr(c *cli.Context) *i18n.Printer {
+	if c.App != nil {
+		if p, ok := c.App.Metadata[i18nMetaKey].(*i18n.Printer); ok {
+			return p
+		}
+	}
+	return i18n.New(i18n.English)
+}
+
 // --- Command stubs ---
 
 func cmdCreate(c *cli.Context) error {
@@ -167,18 +562,65 @@ func cmdCreate(c *cli.Context) error {
 	if strings.TrimSpace(c.String("title")) == "" {
 		return fmt.Errorf("--title is required")
 	}
-	ctx := context.Background()
-	store, err := utask.Open(ctx, cfg.NATS.URL, cfg.UI.Profile)
+	ctx := c.Context
+	assignee, err := resolvePerson(cfg, c.String("assignee"))
+	if err != nil {
+		return err
+	}
+	text := c.String("title")
+	var due time.Time
+	if c.Bool("dates") || cfg.Dates.Capture && !c.IsSet("dates") {
+		text, due, _ = utask.CaptureDue(text, time.Now(), cfg.Dates.KeepPhrase)
+	}
+	contexts, err := utask.NormalizeContexts(c.StringSlice("context"))
 	if err != nil {
 		return err
 	}
-	defer store.Close()
+	prof := cfg.ActiveProfile()
 	in := utask.TaskInput{
-		Text:            c.String("title"),
+		Text:            text,
 		Tags:            c.StringSlice("tag"),
 		Priority:        c.Int("priority"),
 		EstimateMinutes: c.Int("estimate-min"),
+		Assignee:        assignee,
+		Private:         c.Bool("private"),
+		Contexts:        contexts,
+		DependsOn:       c.StringSlice("depends-on"),
+		Recur:           c.String("recur"),
+		Due:             due,
+		// Under storage.id_strategy random the store picks a random id
+		// anyway; setting ForceNew here also covers creates queued offline.
+		ForceNew: c.Bool("force-new") || utask.IDStrategy(cfg.Storage.IDStrategy) == utask.IDRandom,
+	}
+	details, err := detailsFlag(c)
+	if err != nil {
+		return err
 	}
+	if details != nil {
+		in.Details = *details
+	}
+	if !c.IsSet("tag") {
+		in.Tags = append([]string(nil), prof.Tags...)
+	}
+	if !c.IsSet("priority") && prof.Priority != 0 {
+		in.Priority = prof.Priority
+	}
+	if err := inputDates(c, &in); err != nil {
+		return err
+	}
+	store, offline, err := openOrQueue(c, cfg)
+	if err != nil {
+		return err
+	}
+	if offline {
+		return queueOffline(c, cfg, utask.JournalEntry{Op: utask.JournalCreate, Input: &in}, "")
+	}
+	defer closeStore(store)
+	extra, err := suggestTags(c, cfg, store, in.Text, in.Tags)
+	if err != nil {
+		return err
+	}
+	in.Tags = append(in.Tags, extra...)
 	t, existed, err := store.CreateTask(ctx, in)
 	if err != nil {
 		return err
@@ -188,7 +630,7 @@ func cmdCreate(c *cli.Context) error {
 		fmt.Println(string(b))
 	} else {
 		if existed {
-			fmt.Println(t.ID, "(exists)")
+			fmt.Println(tr(c).Sprintf("%s (exists)", t.ID))
 		} else {
 			fmt.Println(t.ID)
 		}
@@ -197,307 +639,527 @@ func cmdCreate(c *cli.Context) error {
 }
 
 func cmdList(c *cli.Context) error {
-	cfg := getConfig(c)
-	ctx := context.Background()
-	store, err := utask.Open(ctx, cfg.NATS.URL, cfg.UI.Profile)
+	f, err := listFilter(c)
+	if err != nil {
+		return err
+	}
+	ctxName, err := activeContext(c)
 	if err != nil {
 		return err
 	}
-	defer store.Close()
+	f.Context = ctxName
+	if err := applyFilterContext(c, &f); err != nil {
+		return err
+	}
+	f.Ready, f.Recurring = c.Bool("ready"), c.Bool("recurring")
+	if err := dueFilter(c, &f); err != nil {
+		return err
+	}
+	if err := changedFilter(c, &f); err != nil {
+		return err
+	}
+	if f.Sort, err = utask.ParseSortKey(c.String("sort")); err != nil {
+		return err
+	}
+	f.Reverse = c.Bool("reverse")
+	f.Limit = c.Int("limit")
+	if c.Bool("in-progress") {
+		return listInProgress(c, f)
+	}
+	if c.Bool("waiting") {
+		return listSnoozed(c, f)
+	}
+	f.HideSnoozed = true
+	return listTasks(c, f)
+}
+
+// listFilter reads the --tag, --tags, --all-tags, --status and --assignee
+// flags into a filter.
+func listFilter(c *cli.Context) (utask.ListFilter, error) {
 	var sf utask.Status
 	if s := c.String("status"); s != "" {
-		switch s {
-		case string(utask.StatusOpen):
-			sf = utask.StatusOpen
-		case string(utask.StatusClosed):
-			sf = utask.StatusClosed
-		default:
-			return fmt.Errorf("invalid --status: %s", s)
+		var err error
+		if sf, err = utask.ParseStatus(s); err != nil {
+			return utask.ListFilter{}, err
 		}
 	}
-	var tasks []utask.Task
-	anyTags := parseCSVTags(c.String("tags"))
-	allTags := parseCSVTags(c.String("all-tags"))
-	if len(anyTags) > 0 || len(allTags) > 0 {
-		tasks, err = store.Query(ctx, anyTags, allTags, 0)
+	f := utask.ListFilter{Tag: c.String("tag"), Any: parseCSVTags(c.String("tags")), All: parseCSVTags(c.String("all-tags")), Status: sf}
+	switch a := c.String("assignee"); a {
+	case "":
+	case "none":
+		f.Unassigned = true
+	default:
+		who, err := resolveAssignee(getConfig(c), a)
 		if err != nil {
-			return err
+			return utask.ListFilter{}, err
 		}
-		if sf != "" {
-			filtered := make([]utask.Task, 0, len(tasks))
-			for _, t := range tasks {
-				if sf == utask.StatusOpen && t.Done {
-					continue
-				}
-				if sf == utask.StatusClosed && !t.Done {
-					continue
+		f.Assignee = who
+	}
+	for _, v := range c.StringSlice("trailer") {
+		tr, err := utask.ParseTrailerFilter(v)
+		if err != nil {
+			return utask.ListFilter{}, err
+		}
+		f.Trailers = append(f.Trailers, tr)
+	}
+	return f, nil
+}
+
+// listInProgress lists tasks someone has claimed with `ut start`, with who
+// and for how long, filtered like any other listing.
+func listInProgress(c *cli.Context, f utask.ListFilter) error {
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	claims, err := store.Claims(ctx)
+	if err != nil {
+		return err
+	}
+	snap := &utask.Snapshot{Tasks: map[string]utask.Task{}}
+	for _, cl := range claims {
+		if t, _, err := store.GetTask(ctx, cl.Task); err == nil {
+			snap.Tasks[t.ID] = t
+		}
+	}
+	if f.Ready {
+		// Ready needs the dependencies; only claimed tasks are printed.
+		for _, cl := range claims {
+			for _, dep := range snap.Tasks[cl.Task].DependsOn {
+				if d, _, err := store.GetTask(ctx, dep); err == nil {
+					snap.Tasks[dep] = d
 				}
-				filtered = append(filtered, t)
 			}
-			tasks = filtered
 		}
-	} else {
-		tasks, err = store.List(ctx, c.String("tag"), sf)
-		if err != nil {
-			return err
+	}
+	type inProgress struct {
+		utask.Claim
+		Elapsed string `json:"elapsed"`
+		Title   string `json:"title"`
+	}
+	now := time.Now()
+	out := []inProgress{}
+	for _, t := range snap.Select(f) {
+		for _, cl := range claims {
+			if cl.Task == t.ID {
+				out = append(out, inProgress{Claim: cl, Elapsed: utask.FormatDuration(cl.Elapsed(now)), Title: t.Short()})
+			}
 		}
 	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Since.Before(out[j].Since) })
 	if c.Bool("verbose") {
-		b, _ := json.MarshalIndent(tasks, "", "  ")
+		b, _ := json.MarshalIndent(out, "", "  ")
 		fmt.Println(string(b))
 		return nil
 	}
-	for _, t := range tasks {
-		st := "open"
-		if t.Done {
-			st = "closed"
-		}
-		created := t.Created
-		fmt.Printf("%s\t%s\t%s\t[%s]\n", t.ID, st, created, strings.Join(t.Tags, ","))
-		fmt.Println("  ", t.Text)
+	for _, p := range out {
+		fmt.Printf("%s\t%s\t%s\t%s\n", shortID(p.Task), p.By, p.Elapsed, p.Title)
 	}
 	return nil
 }
 
-func parseCSVTags(in string) []string {
-	if strings.TrimSpace(in) == "" {
-		return nil
+// cmdMine lists open tasks assigned to the configured identity.
+func cmdMine(c *cli.Context) error {
+	me, err := resolveAssignee(getConfig(c), "me")
+	if err != nil {
+		return err
 	}
-	parts := strings.Split(in, ",")
-	out := make([]string, 0, len(parts))
-	seen := map[string]struct{}{}
-	for _, p := range parts {
-		p = strings.ToLower(strings.TrimSpace(p))
-		if p == "" {
-			continue
-		}
-		if _, ok := seen[p]; ok {
-			continue
-		}
-		seen[p] = struct{}{}
-		out = append(out, p)
+	ctxName, err := activeContext(c)
+	if err != nil {
+		return err
 	}
-	return out
+	return listTasks(c, utask.ListFilter{Tag: c.String("tag"), Status: utask.StatusOpen, Assignee: me, HideSnoozed: true, Context: ctxName, Sort: utask.DefaultSort})
 }
 
-func cmdGet(c *cli.Context) error {
-	if c.NArg() < 1 {
-		return fmt.Errorf("usage: ut get <id>")
-	}
-	id := c.Args().First()
+// cmdWaiting lists open tasks the configured identity delegated, oldest
+// delegation first.
+func cmdWaiting(c *cli.Context) error {
 	cfg := getConfig(c)
-	ctx := context.Background()
-	store, err := utask.Open(ctx, cfg.NATS.URL, cfg.UI.Profile)
+	me, err := resolveAssignee(cfg, "me")
 	if err != nil {
 		return err
 	}
-	defer store.Close()
-	rid, candidates, err := store.Resolve(id)
+	ctxName, err := activeContext(c)
 	if err != nil {
-		if len(candidates) > 1 {
-			return fmt.Errorf("ambiguous prefix; candidates: %s", strings.Join(candidates, ", "))
-		}
 		return err
 	}
-	t, _, err := store.GetTask(ctx, rid)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	it, err := store.ListIter(ctx, utask.ListFilter{Tag: c.String("tag"), DelegatedBy: me, Context: ctxName})
 	if err != nil {
 		return err
 	}
-	b, _ := json.MarshalIndent(t, "", "  ")
-	fmt.Println(string(b))
+	defer it.Close()
+	tasks := []utask.Task{}
+	for it.Next() {
+		tasks = append(tasks, it.Task())
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Delegation.Since.Before(tasks[j].Delegation.Since) })
+	if c.Bool("verbose") {
+		return printTasks(c, tasks)
+	}
+	now := time.Now()
+	for _, t := range tasks {
+		fmt.Printf("%s\t%s\t%s\t%s\n", shortID(t.ID), t.Delegation.To, utask.FormatDuration(now.Sub(t.Delegation.Since)), t.Short())
+	}
 	return nil
 }
 
-func cmdClose(c *cli.Context) error {
-	if c.NArg() < 1 {
-		return fmt.Errorf("usage: ut close <id>")
+func cmdDelegate(c *cli.Context) error {
+	if c.NArg() < 2 {
+		return fmt.Errorf("usage: ut delegate <id> <person|none>")
 	}
-	id := c.Args().First()
 	cfg := getConfig(c)
-	ctx := context.Background()
-	store, err := utask.Open(ctx, cfg.NATS.URL, cfg.UI.Profile)
+	to, err := resolvePerson(cfg, c.Args().Get(1))
 	if err != nil {
 		return err
 	}
-	defer store.Close()
-	rid, candidates, err := store.Resolve(id)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
 	if err != nil {
-		if len(candidates) > 1 {
-			return fmt.Errorf("ambiguous prefix; candidates: %s", strings.Join(candidates, ", "))
-		}
 		return err
 	}
-	t, changed, err := store.CloseTask(ctx, rid)
+	defer closeStore(store)
+	rid, _, err := store.Resolve(ctx, c.Args().First())
 	if err != nil {
 		return err
 	}
-	if c.Bool("verbose") {
+	t, err := store.DelegateTask(ctx, rid, to, c.Uint64("if-revision"))
+	if err != nil {
+		return err
+	}
+	switch {
+	case c.Bool("verbose"):
 		b, _ := json.MarshalIndent(t, "", "  ")
 		fmt.Println(string(b))
-	} else {
-		if changed {
-			fmt.Println(t.ID, "closed")
-		} else {
-			fmt.Println(t.ID, "already closed")
-		}
+	case to == "":
+		fmt.Println(tr(c).Sprintf("%s no longer waiting", t.ID))
+	default:
+		fmt.Println(tr(c).Sprintf("%s delegated to %s", t.ID, to))
 	}
 	return nil
 }
 
-func cmdReopen(c *cli.Context) error {
-	if c.NArg() < 1 {
-		return fmt.Errorf("usage: ut reopen <id>")
-	}
-	id := c.Args().First()
+func cmdActivity(c *cli.Context) error {
 	cfg := getConfig(c)
-	ctx := context.Background()
-	store, err := utask.Open(ctx, cfg.NATS.URL, cfg.UI.Profile)
+	window, err := utask.ParseDuration(c.String("since"))
 	if err != nil {
 		return err
 	}
-	defer store.Close()
-	rid, candidates, err := store.Resolve(id)
+	user, err := resolveAssignee(cfg, c.String("user"))
 	if err != nil {
-		if len(candidates) > 1 {
-			return fmt.Errorf("ambiguous prefix; candidates: %s", strings.Join(candidates, ", "))
-		}
 		return err
 	}
-	t, changed, err := store.ReopenTask(ctx, rid)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	acts, err := store.Audit(ctx, utask.AuditFilter{Since: time.Now().Add(-window), User: user, Limit: c.Int("limit")})
 	if err != nil {
 		return err
 	}
 	if c.Bool("verbose") {
-		b, _ := json.MarshalIndent(t, "", "  ")
+		b, _ := json.MarshalIndent(acts, "", "  ")
 		fmt.Println(string(b))
-	} else {
-		if changed {
-			fmt.Println(t.ID, "reopened")
-		} else {
-			fmt.Println(t.ID, "already open")
+		return nil
+	}
+	for _, a := range acts {
+		by := a.By
+		if by == "" {
+			by = "-"
+		}
+		fmt.Printf("%s\t%s\t%s\t%s\t%s", a.When.Local().Format(time.DateTime), by, a.Kind, shortID(a.Task.ID), a.Task.Short())
+		if a.Detail != "" {
+			fmt.Printf(" (%s)", a.Detail)
 		}
+		fmt.Println()
 	}
 	return nil
 }
 
-// events command removed
+// shortID abbreviates a task id for tabular output.
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
 
-func cmdTags(c *cli.Context) error {
+// resolveAssignee maps "me" to the configured identity; other values pass
+// through trimmed.
+func resolveAssignee(cfg *conf.Config, who string) (string, error) {
+	who = strings.TrimSpace(who)
+	if who != "me" {
+		return who, nil
+	}
+	me := conf.ResolveIdentity(cfg.Identity).String()
+	if me == "" {
+		return "", fmt.Errorf("no identity: set identity.name in the config or git user.name")
+	}
+	return me, nil
+}
+
+// resolvePerson is resolveAssignee that also takes a people: handle, and
+// none for nobody.
+func resolvePerson(cfg *conf.Config, who string) (string, error) {
+	if who == "none" {
+		return "", nil
+	}
+	if p, ok := cfg.People[strings.ToLower(who)]; ok {
+		return p.Identity().String(), nil
+	}
+	return resolveAssignee(cfg, who)
+}
+
+// listTasks prints the page of tasks matching f after --cursor, from the
+// local cache unless --fresh is set or the cache is disabled.
+func listTasks(c *cli.Context, f utask.ListFilter) error {
 	cfg := getConfig(c)
-	ctx := context.Background()
-	store, err := utask.Open(ctx, cfg.NATS.URL, cfg.UI.Profile)
+	if c.Bool("offline") || !c.Bool("fresh") && !cfg.Cache.Disabled {
+		return listCached(c, f)
+	}
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
 	if err != nil {
 		return err
 	}
-	defer store.Close()
-	counts, err := store.ListTags()
+	defer closeStore(store)
+	limit := f.Limit
+	f.Limit = 0
+	it, err := store.ListIter(ctx, f)
 	if err != nil {
 		return err
 	}
-	for k, v := range counts {
-		fmt.Printf("%s\t%d\n", k, v)
+	defer it.Close()
+	tasks := []utask.Task{}
+	for it.Next() {
+		tasks = append(tasks, it.Task())
 	}
-	return nil
+	warnOrphans(it)
+	if err := it.Err(); err != nil {
+		return err
+	}
+	f.Limit = limit
+	return printPage(c, tasks, f)
 }
 
-func cmdRebuildIndex(c *cli.Context) error {
-	cfg := getConfig(c)
-	ctx := context.Background()
-	store, err := utask.Open(ctx, cfg.NATS.URL, cfg.UI.Profile)
+// printPage prints the page of tasks, already in f's order, that --cursor
+// and f.Limit select, as a table or the --format view, then the cursor for
+// the next one on stderr.
+func printPage(c *cli.Context, tasks []utask.Task, f utask.ListFilter) error {
+	page, err := utask.PageOf(tasks, f, c.String("cursor"))
 	if err != nil {
 		return err
 	}
-	defer store.Close()
-	if err := store.RebuildIndex(ctx); err != nil {
+	drawn, err := printView(c, page.Tasks)
+	if err != nil {
 		return err
 	}
-	fmt.Println("OK")
+	if !drawn {
+		if err := printTasks(c, page.Tasks); err != nil {
+			return err
+		}
+	}
+	if page.Next != "" {
+		fmt.Fprintln(os.Stderr, tr(c).Sprintf("next page: --cursor %s", page.Next))
+	}
 	return nil
 }
 
-func cmdCheck(c *cli.Context) error {
-    cfg := getConfig(c)
-    ctx := context.Background()
-    store, err := utask.Open(ctx, cfg.NATS.URL, cfg.UI.Profile)
-    if err != nil { return err }
-    defer store.Close()
-    var sf utask.Status
-    if s := c.String("status"); s != "" {
-        switch s {
-        case string(utask.StatusOpen): sf = utask.StatusOpen
-        case string(utask.StatusClosed): sf = utask.StatusClosed
-        default: return fmt.Errorf("invalid --status: %s", s)
-        }
-    }
-    tasks, err := store.List(ctx, c.String("tag"), sf)
-    if err != nil { return err }
-    issues := 0
-    for _, t := range tasks {
-        drops := t.TrailerDrops()
-        if len(drops) == 0 {
-            continue
-        }
-        issues++
-        fmt.Printf("%s\t%s\n", t.ID, t.Short())
-        fmt.Println("  Dropped lines from trailer block:")
-        for _, line := range drops {
-            fmt.Println("   -", line)
-        }
-    }
-    if issues == 0 {
-        fmt.Println("OK")
-    }
-    return nil
+// warnOrphans reports stale tag index entries a listing came across.
+func warnOrphans(it utask.TaskIterator) {
+	ids, healed := it.Orphans()
+	if msg := utask.OrphanWarning(ids, healed); msg != "" {
+		slog.Warn(msg, "op", "list", "orphans", len(ids), "removed", healed)
+	}
 }
 
-func cmdUpdate(c *cli.Context) error {
-	if c.NArg() < 1 {
-		return fmt.Errorf("usage: ut update <id> [--title s] [--tag t ...] [--tags a,b]")
+// printTasks prints tasks in the --output format.
+func printTasks(c *cli.Context, tasks []utask.Task) error {
+	w, err := newTaskWriter(c, output.Table)
+	if err != nil {
+		return err
 	}
-	id := c.Args().First()
+	for _, t := range tasks {
+		if err := w.WriteTask(t); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// fullIDFlag makes listings print task ids instead of T-<n> numbers.
+var fullIDFlag = &cli.BoolFlag{Name: "full-id", Usage: "print full task ids instead of T-<n> numbers"}
+
+// noContextFlag makes a listing ignore the filter context in use and the
+// active context.
+var noContextFlag = &cli.BoolFlag{Name: "no-context", Usage: "ignore the filter context in use and the active context (see ut context)"}
+
+// listCached prints from the local snapshot before connecting, then replays
+// newer revisions into it so the next listing has other people's changes.
+// The user's own writes are already in it: closeStore refreshes the
+// snapshot after every command that wrote. With no snapshot yet, it syncs
+// first and prints the result.
+func listCached(c *cli.Context, f utask.ListFilter) error {
 	cfg := getConfig(c)
-	ctx := context.Background()
-	store, err := utask.Open(ctx, cfg.NATS.URL, cfg.UI.Profile)
+	path, err := utask.SnapshotPath(cfg.UI.Profile)
 	if err != nil {
 		return err
 	}
-	defer store.Close()
-
-	rid, cands, err := store.Resolve(id)
+	snap, err := utask.LoadSnapshot(path, cfg.UI.Profile)
 	if err != nil {
-		if len(cands) > 1 {
-			return fmt.Errorf("ambiguous prefix; candidates: %s", strings.Join(cands, ", "))
-		}
 		return err
 	}
-
-	var set utask.UpdateSet
-	// Prefer --text; fallback to --title for compatibility
-	if s := strings.TrimSpace(c.String("text")); s != "" {
-		set.Text = &s
-	} else if s := strings.TrimSpace(c.String("title")); s != "" {
-		set.Text = &s
+	printed := false
+	if !snap.Empty() {
+		if err := printPage(c, snap.Select(f), f); err != nil {
+			return err
+		}
+		printed = true
 	}
-	// No separate extended/description field; only text, tags, done, priority
-	if c.IsSet("priority") {
-		p := c.Int("priority")
-		set.Priority = &p
+	if c.Bool("offline") {
+		if !printed {
+			return fmt.Errorf("no local cache to list offline; run ut list while connected first")
+		}
+		return nil
 	}
-	if c.IsSet("done") {
-		b := c.Bool("done")
-		set.Done = &b
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		if printed {
+			slog.Warn("showing cached tasks", "op", "list", "err", err)
+			return nil
+		}
+		return err
 	}
-	tags := []string{}
-	tags = append(tags, parseCSVTags(c.String("tags"))...)
-	tags = append(tags, c.StringSlice("tag")...)
-	if len(tags) > 0 {
-		// normalize via parseCSVTags logic for repeatable flags
-		joined := strings.Join(tags, ",")
-		n := parseCSVTags(joined)
-		set.Tags = &n
+	defer closeStore(store)
+	n, err := store.SyncSnapshot(ctx, snap)
+	if err != nil {
+		return fmt.Errorf("sync cache: %w", err)
 	}
+	if n > 0 {
+		if err := snap.Save(path); err != nil {
+			return fmt.Errorf("save cache: %w", err)
+		}
+		if printed {
+			slog.Debug("cache refreshed", "op", "list", "changes", n)
+		}
+	}
+	if !printed {
+		return printPage(c, snap.Select(f), f)
+	}
+	return nil
+}
 
-	t, err := store.UpdateTask(ctx, rid, set)
+func parseCSVTags(in string) []string {
+	if strings.TrimSpace(in) == "" {
+		return nil
+	}
+	parts := strings.Split(in, ",")
+	out := make([]string, 0, len(parts))
+	seen := map[string]struct{}{}
+	for _, p := range parts {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" {
+			continue
+		}
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		seen[p] = struct{}{}
+		out = append(out, p)
+	}
+	return out
+}
+
+func cmdGet(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return fmt.Errorf("usage: ut get <id>")
+	}
+	id := c.Args().First()
+	cfg := getConfig(c)
+	if c.Bool("offline") {
+		return getCached(c, cfg, id)
+	}
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	rid, _, err := store.Resolve(ctx, id)
+	if err != nil {
+		return err
+	}
+	t, _, err := store.GetTask(ctx, rid)
+	if err != nil {
+		return err
+	}
+	if c.Bool("annotations") {
+		printTaskNotes(c, t)
+		return nil
+	}
+	return printTaskRecord(c, t)
+}
+
+// printTaskRecord prints the task ut get shows: indented JSON, unless
+// --output asks for a format (JSON then being a single line). The JSON
+// adds time_spent, the sum of the task's worked intervals, when it has
+// any.
+func printTaskRecord(c *cli.Context, t utask.Task) error {
+	w, err := newTaskWriter(c, output.JSON)
+	if err != nil {
+		return err
+	}
+	if !c.IsSet("output") {
+		w.Indent("  ")
+	}
+	var v any = t
+	if spent := t.TimeSpent(); spent > 0 {
+		v = struct {
+			utask.Task
+			TimeSpent        string `json:"time_spent"`
+			TimeSpentMinutes int    `json:"time_spent_minutes"`
+		}{t, utask.FormatDuration(spent), int(spent / time.Minute)}
+	}
+	if err := w.writeTask(v, t); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func cmdClose(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return fmt.Errorf("usage: ut close <id>")
+	}
+	id := c.Args().First()
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, offline, err := openOrQueue(c, cfg)
+	if err != nil {
+		return err
+	}
+	if offline {
+		return queueOffline(c, cfg, utask.JournalEntry{Op: utask.JournalClose, BaseRevision: c.Uint64("if-revision")}, id)
+	}
+	defer closeStore(store)
+	rid, _, err := store.Resolve(ctx, id)
+	if err != nil {
+		return err
+	}
+	t, changed, err := store.CloseTaskIf(ctx, rid, c.Uint64("if-revision"))
 	if err != nil {
 		return err
 	}
@@ -505,167 +1167,577 @@ func cmdUpdate(c *cli.Context) error {
 		b, _ := json.MarshalIndent(t, "", "  ")
 		fmt.Println(string(b))
 	} else {
-		fmt.Println(t.ID, "updated")
+		switch {
+		case !t.Done && changed:
+			fmt.Println(tr(c).Sprintf("%s awaiting review; someone else must run: ut approve %s", t.ID, t.ID))
+		case !t.Done:
+			fmt.Println(tr(c).Sprintf("%s already awaiting review", t.ID))
+		case changed:
+			fmt.Println(tr(c).Sprintf("%s closed", t.ID))
+		default:
+			fmt.Println(tr(c).Sprintf("%s already closed", t.ID))
+		}
 	}
 	return nil
 }
 
-func cmdDelete(c *cli.Context) error {
+func cmdReopen(c *cli.Context) error {
 	if c.NArg() < 1 {
-		return fmt.Errorf("usage: ut delete <id>")
+		return fmt.Errorf("usage: ut reopen <id>")
 	}
 	id := c.Args().First()
 	cfg := getConfig(c)
-	ctx := context.Background()
-	store, err := utask.Open(ctx, cfg.NATS.URL, cfg.UI.Profile)
+	ctx := c.Context
+	store, offline, err := openOrQueue(c, cfg)
+	if err != nil {
+		return err
+	}
+	if offline {
+		return queueOffline(c, cfg, utask.JournalEntry{Op: utask.JournalReopen}, id)
+	}
+	defer closeStore(store)
+	rid, _, err := store.Resolve(ctx, id)
 	if err != nil {
 		return err
 	}
-	defer store.Close()
-	rid, cands, err := store.Resolve(id)
+	t, changed, err := store.ReopenTask(ctx, rid)
 	if err != nil {
-		if len(cands) > 1 {
-			return fmt.Errorf("ambiguous prefix; candidates: %s", strings.Join(cands, ", "))
+		return err
+	}
+	if c.Bool("verbose") {
+		b, _ := json.MarshalIndent(t, "", "  ")
+		fmt.Println(string(b))
+	} else {
+		if changed {
+			fmt.Println(tr(c).Sprintf("%s reopened", t.ID))
+		} else {
+			fmt.Println(tr(c).Sprintf("%s already open", t.ID))
 		}
+	}
+	return nil
+}
+
+func cmdStart(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return fmt.Errorf("usage: ut start <id>")
+	}
+	lease, err := utask.ParseDuration(c.String("lease"))
+	if err != nil {
+		return err
+	}
+	cfg := getConfig(c)
+	ctx := utask.WithWorker(c.Context, c.String("worker"))
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	rid, _, err := store.Resolve(ctx, c.Args().First())
+	if err != nil {
+		return err
+	}
+	cl, err := store.ClaimTask(ctx, rid, lease)
+	if err != nil {
+		return err
+	}
+	if c.Bool("verbose") {
+		b, _ := json.MarshalIndent(cl, "", "  ")
+		fmt.Println(string(b))
+	} else {
+		fmt.Println(tr(c).Sprintf("%s started %s ago, claimed until %s", rid, utask.FormatDuration(cl.Elapsed(time.Now())), cl.Expires.Local().Format("15:04")))
+	}
+	return nil
+}
+
+func cmdStop(c *cli.Context) error {
+	cfg := getConfig(c)
+	ctx := utask.WithWorker(c.Context, c.String("worker"))
+	store, err := openStore(ctx, cfg)
+	if err != nil {
 		return err
 	}
-	delID, err := store.DeleteTask(ctx, rid)
+	defer closeStore(store)
+	var rid string
+	if c.NArg() > 0 {
+		if rid, _, err = store.Resolve(ctx, c.Args().First()); err != nil {
+			return err
+		}
+	} else if rid, err = runningTimer(ctx, store); err != nil {
+		return err
+	}
+	if c.Bool("discard") {
+		if err := store.ReleaseClaim(ctx, rid); err != nil {
+			return err
+		}
+		fmt.Println(tr(c).Sprintf("%s released", rid))
+		return nil
+	}
+	t, w, err := store.StopWork(ctx, rid)
 	if err != nil {
 		return err
 	}
-	fmt.Println(delID, "deleted")
+	switch {
+	case c.Bool("verbose"):
+		b, _ := json.MarshalIndent(t, "", "  ")
+		fmt.Println(string(b))
+	case w.End.IsZero():
+		fmt.Println(tr(c).Sprintf("%s released", rid))
+	default:
+		fmt.Println(tr(c).Sprintf("recorded %s on %s (%s in total)", utask.FormatDuration(w.Duration()), shortID(rid), utask.FormatDuration(t.TimeSpent())))
+	}
 	return nil
 }
 
-func runMCPStdio(c *cli.Context) error {
-	// Basic MCP-style JSON-RPC loop with tools/list and tools/call
-	log.SetOutput(os.Stderr)
-	dec := json.NewDecoder(os.Stdin)
-	enc := json.NewEncoder(os.Stdout)
-	type msg struct {
-		ID      any             `json:"id"`
-		Method  string          `json:"method"`
-		JSONRPC string          `json:"jsonrpc"`
-		Params  json.RawMessage `json:"params"`
+// runningTimer returns the task of the caller's only running timer, for
+// `ut stop` without an id.
+func runningTimer(ctx context.Context, store *utask.Store) (string, error) {
+	timers, err := store.Timers(ctx)
+	if err != nil {
+		return "", err
+	}
+	switch len(timers) {
+	case 0:
+		return "", errors.New("no timer running; usage: ut stop <id>")
+	case 1:
+		return timers[0].Task, nil
 	}
-	type resp struct {
-		ID      any         `json:"id"`
-		JSONRPC string      `json:"jsonrpc"`
-		Result  interface{} `json:"result,omitempty"`
-		Error   interface{} `json:"error,omitempty"`
+	ids := make([]string, len(timers))
+	for i, cl := range timers {
+		ids[i] = shortID(cl.Task)
 	}
-	tools := []string{"create", "list", "get", "close", "reopen"}
+	return "", fmt.Errorf("%d timers running (%s); name one: ut stop <id>", len(timers), strings.Join(ids, ", "))
+}
 
+func cmdApprove(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return fmt.Errorf("usage: ut approve <id>")
+	}
 	cfg := getConfig(c)
-	ctx := context.Background()
-	store, err := utask.Open(ctx, cfg.NATS.URL, cfg.UI.Profile)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
 	if err != nil {
 		return err
 	}
-	defer store.Close()
+	defer closeStore(store)
+	rid, _, err := store.Resolve(ctx, c.Args().First())
+	if err != nil {
+		return err
+	}
+	t, err := store.ApproveTask(ctx, rid, c.Uint64("if-revision"))
+	if err != nil {
+		return err
+	}
+	if c.Bool("verbose") {
+		b, _ := json.MarshalIndent(t, "", "  ")
+		fmt.Println(string(b))
+	} else {
+		fmt.Println(tr(c).Sprintf("%s approved and closed", t.ID))
+	}
+	return nil
+}
 
-	for {
-		var m msg
-		if err := dec.Decode(&m); err != nil {
-			return nil // graceful exit on EOF
+// events command removed
+
+func cmdTags(c *cli.Context) error {
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	counts, err := store.ListTags(ctx)
+	if err != nil {
+		return err
+	}
+	f, err := outputFormat(c, output.Table)
+	if err != nil {
+		return err
+	}
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	w := output.New(os.Stdout, f, "tag", "count")
+	for _, tag := range tags {
+		row := struct {
+			Tag   string `json:"tag"`
+			Count int    `json:"count"`
+		}{tag, counts[tag]}
+		if err := w.Write(row, tag, strconv.Itoa(counts[tag])); err != nil {
+			return err
 		}
-		r := resp{ID: m.ID, JSONRPC: "2.0"}
-		switch m.Method {
-		case "initialize":
-			r.Result = map[string]any{"capabilities": map[string]any{"tools": tools}}
-		case "tools/list":
-			r.Result = map[string]any{"tools": tools}
-		case "tools/call":
-			var p struct {
-				Name string                 `json:"name"`
-				Args map[string]interface{} `json:"arguments"`
-			}
-			if err := json.Unmarshal(m.Params, &p); err != nil {
-				r.Error = err.Error()
-				break
-			}
-			switch p.Name {
-			case "create":
-				title, _ := p.Args["title"].(string)
-				var tags []string
-				if v, ok := p.Args["tags"].([]interface{}); ok {
-					for _, it := range v {
-						if s, ok := it.(string); ok {
-							tags = append(tags, s)
-						}
-					}
-				}
-				in := utask.TaskInput{Text: title, Tags: tags}
-				t, _, err := store.CreateTask(ctx, in)
-				if err != nil {
-					r.Error = err.Error()
-					break
-				}
-				r.Result = t
-			case "list":
-				tag, _ := p.Args["tag"].(string)
-				var sf utask.Status
-				if s, ok := p.Args["status"].(string); ok {
-					switch s {
-					case string(utask.StatusOpen):
-						sf = utask.StatusOpen
-					case string(utask.StatusClosed):
-						sf = utask.StatusClosed
-					}
-				}
-				ts, err := store.List(ctx, tag, sf)
-				if err != nil {
-					r.Error = err.Error()
-					break
-				}
-				r.Result = ts
-			case "get":
-				id, _ := p.Args["id"].(string)
-				rid, _, err := store.Resolve(id)
-				if err != nil {
-					r.Error = err.Error()
-					break
-				}
-				t, _, err := store.GetTask(ctx, rid)
-				if err != nil {
-					r.Error = err.Error()
-					break
-				}
-				r.Result = t
-			case "close":
-				id, _ := p.Args["id"].(string)
-				rid, _, err := store.Resolve(id)
-				if err != nil {
-					r.Error = err.Error()
-					break
-				}
-				t, _, err := store.CloseTask(ctx, rid)
-				if err != nil {
-					r.Error = err.Error()
-					break
-				}
-				r.Result = t
-			case "reopen":
-				id, _ := p.Args["id"].(string)
-				rid, _, err := store.Resolve(id)
-				if err != nil {
-					r.Error = err.Error()
-					break
-				}
-				t, _, err := store.ReopenTask(ctx, rid)
-				if err != nil {
-					r.Error = err.Error()
-					break
-				}
-				r.Result = t
-			default:
-				r.Error = fmt.Sprintf("unknown tool: %s", p.Name)
+	}
+	return w.Flush()
+}
+
+// cmdQuery lists a page of the tasks with any of --any's tags and all of
+// --all's, in the --output format.
+func cmdQuery(c *cli.Context) error {
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	f := utask.ListFilter{Any: parseCSVTags(c.String("any")), All: parseCSVTags(c.String("all")), Limit: c.Int("limit"), Sort: utask.SortCreated}
+	if err := applyFilterContext(c, &f); err != nil {
+		return err
+	}
+	page, err := store.List(ctx, f, c.String("cursor"))
+	if err != nil {
+		return err
+	}
+	if err := printTasks(c, page.Tasks); err != nil {
+		return err
+	}
+	if page.Next != "" {
+		fmt.Fprintln(os.Stderr, tr(c).Sprintf("next page: --cursor %s", page.Next))
+	}
+	return nil
+}
+
+func cmdAlias(c *cli.Context) error {
+	if n := c.NArg(); n != 0 && n != 2 {
+		return fmt.Errorf("usage: ut alias [<name> <id>] [--rm name]")
+	}
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	if name := c.String("rm"); name != "" {
+		return store.DeleteAlias(ctx, name)
+	}
+	if c.NArg() == 2 {
+		rid, _, err := store.Resolve(ctx, c.Args().Get(1))
+		if err != nil {
+			return err
+		}
+		return store.SetAlias(ctx, c.Args().First(), rid)
+	}
+	aliases, err := store.Aliases(ctx)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("%s\t%s\n", name, aliases[name])
+	}
+	return nil
+}
+
+func cmdRebuildIndex(c *cli.Context) error {
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	if err := store.RebuildIndex(ctx); err != nil {
+		return err
+	}
+	fmt.Println("OK")
+	return nil
+}
+
+func cmdMaintain(c *cli.Context) error {
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	if c.IsSet("keyspace") {
+		ks, err := utask.ParseKeyspace(c.String("keyspace"))
+		if err != nil {
+			return err
+		}
+		moved, err := store.Reshard(ctx, ks)
+		if err != nil {
+			return fmt.Errorf("reshard: %w", err)
+		}
+		fmt.Printf("moved %d tasks to the %s keyspace\n", moved, ks)
+	}
+	numbered, err := store.NumberTasks(ctx)
+	if err != nil {
+		return fmt.Errorf("number tasks: %w", err)
+	}
+	if numbered > 0 {
+		fmt.Printf("numbered %d tasks\n", numbered)
+	}
+	rep, err := store.CompactTagIndex(ctx)
+	if err != nil {
+		return err
+	}
+	if c.Bool("verbose") {
+		b, _ := json.MarshalIndent(rep, "", "  ")
+		fmt.Println(string(b))
+		return nil
+	}
+	if rep.Migrated > 0 {
+		fmt.Printf("moved %d tags (%d entries) to per-task index keys\n", rep.Migrated, rep.Entries)
+	}
+	return nil
+}
+
+func cmdMigrate(c *cli.Context) error {
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	rep, err := store.Migrate(ctx)
+	if c.Bool("verbose") {
+		b, _ := json.MarshalIndent(rep, "", "  ")
+		fmt.Println(string(b))
+	} else {
+		fmt.Printf("migrated %d of %d tasks to schema %d (skipped %d changed mid-run)\n", rep.Migrated, rep.Tasks, utask.SchemaVersion, rep.Skipped)
+	}
+	return err
+}
+
+// pingTimeout bounds `ut ping` when no --timeout is given.
+const pingTimeout = 10 * time.Second
+
+func cmdPing(c *cli.Context) error {
+	ctx := c.Context
+	if c.Duration("timeout") == 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, pingTimeout)
+		defer cancel()
+	}
+	var h utask.Health
+	if u := c.String("url"); u != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(u, "/")+"/readyz", nil)
+		if err != nil {
+			return err
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if err := json.NewDecoder(res.Body).Decode(&h); err != nil {
+			return fmt.Errorf("%s: %s", req.URL, res.Status)
+		}
+	} else {
+		store, err := openStore(ctx, getConfig(c))
+		if err != nil {
+			return err
+		}
+		defer closeStore(store)
+		h = store.Health(ctx)
+	}
+	if c.Bool("verbose") {
+		b, _ := json.MarshalIndent(h, "", "  ")
+		fmt.Println(string(b))
+	} else {
+		for _, chk := range h.Checks {
+			state, info := "ok", chk.Detail
+			if !chk.OK {
+				state, info = "FAIL", chk.Error
 			}
-		default:
-			r.Error = fmt.Sprintf("unknown method: %s", m.Method)
+			fmt.Printf("%-4s  %-24s %7.1fms  %s\n", state, chk.Name, chk.LatencyMS, info)
 		}
-		if err := enc.Encode(&r); err != nil {
-			return nil
+	}
+	if !h.OK {
+		return fmt.Errorf("unhealthy")
+	}
+	return nil
+}
+
+func cmdCheck(c *cli.Context) error {
+    cfg := getConfig(c)
+    ctx := c.Context
+    store, err := openStore(ctx, cfg)
+    if err != nil { return err }
+    defer closeStore(store)
+    var sf utask.Status
+    if s := c.String("status"); s != "" {
+        switch s {
+        case string(utask.StatusOpen): sf = utask.StatusOpen
+        case string(utask.StatusClosed): sf = utask.StatusClosed
+        default: return fmt.Errorf("invalid --status: %s", s)
+        }
+    }
+    it, err := store.ListIter(ctx, utask.ListFilter{Tag: c.String("tag"), Status: sf})
+    if err != nil { return err }
+    defer it.Close()
+    issues := 0
+    for it.Next() {
+        t := it.Task()
+        drops := t.TrailerDrops()
+        if len(drops) == 0 {
+            continue
+        }
+        issues++
+        fmt.Printf("%s\t%s\n", t.ID, t.Short())
+        fmt.Println("  Dropped lines from trailer block:")
+        for _, line := range drops {
+            fmt.Println("   -", line)
+        }
+    }
+    if err := it.Err(); err != nil {
+        return err
+    }
+    warnOrphans(it)
+    if issues == 0 {
+        fmt.Println("OK")
+    }
+    return nil
+}
+
+func cmdUpdate(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return fmt.Errorf("usage: ut update <id> [--title s] [--tag t ...] [--tags a,b]")
+	}
+	id := c.Args().First()
+	cfg := getConfig(c)
+	ctx := c.Context
+	set := utask.UpdateSet{IfRevision: c.Uint64("if-revision")}
+	// Prefer --text; fallback to --title for compatibility
+	if s := strings.TrimSpace(c.String("text")); s != "" {
+		set.Text = &s
+	} else if s := strings.TrimSpace(c.String("title")); s != "" {
+		set.Text = &s
+	}
+	details, err := detailsFlag(c)
+	if err != nil {
+		return err
+	}
+	set.Details = details
+	if c.IsSet("priority") {
+		p := c.Int("priority")
+		set.Priority = &p
+	}
+	if c.IsSet("done") {
+		b := c.Bool("done")
+		set.Done = &b
+	}
+	if c.IsSet("assignee") {
+		who := c.String("assignee")
+		if who == "none" {
+			who = ""
+		}
+		who, err := resolveAssignee(cfg, who)
+		if err != nil {
+			return err
+		}
+		set.Assignee = &who
+	}
+	if c.IsSet("private") {
+		b := c.Bool("private")
+		set.Private = &b
+	}
+	if c.IsSet("context") {
+		contexts, err := parseContextFlag(c)
+		if err != nil {
+			return err
+		}
+		set.Contexts = &contexts
+	}
+	if err := updateDates(c, &set); err != nil {
+		return err
+	}
+	if c.IsSet("recur") {
+		rule := c.String("recur")
+		if rule == "none" {
+			rule = ""
+		}
+		set.Recur = &rule
+	}
+	tags := []string{}
+	tags = append(tags, parseCSVTags(c.String("tags"))...)
+	tags = append(tags, c.StringSlice("tag")...)
+	if len(tags) > 0 {
+		// normalize via parseCSVTags logic for repeatable flags
+		joined := strings.Join(tags, ",")
+		n := parseCSVTags(joined)
+		set.Tags = &n
+	}
+
+	store, offline, err := openOrQueue(c, cfg)
+	if err != nil {
+		return err
+	}
+	if offline {
+		return queueOffline(c, cfg, utask.JournalEntry{Op: utask.JournalUpdate, Set: &set, BaseRevision: set.IfRevision}, id)
+	}
+	defer closeStore(store)
+	rid, _, err := store.Resolve(ctx, id)
+	if err != nil {
+		return err
+	}
+	if suggestEnabled(c, cfg) {
+		cur, _, err := store.GetTask(ctx, rid)
+		if err != nil {
+			return err
 		}
+		text, tags := cur.Text, cur.Tags
+		if set.Text != nil {
+			text = *set.Text
+		}
+		if set.Tags != nil {
+			tags = *set.Tags
+		}
+		extra, err := suggestTags(c, cfg, store, text, tags)
+		if err != nil {
+			return err
+		}
+		if len(extra) > 0 {
+			all := append(append([]string{}, tags...), extra...)
+			set.Tags = &all
+		}
+	}
+	t, err := store.UpdateTask(ctx, rid, set)
+	if err != nil {
+		return err
+	}
+	if c.Bool("verbose") {
+		b, _ := json.MarshalIndent(t, "", "  ")
+		fmt.Println(string(b))
+	} else {
+		fmt.Println(tr(c).Sprintf("%s updated", t.ID))
+	}
+	return nil
+}
+
+func cmdDelete(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return fmt.Errorf("usage: ut delete <id>")
 	}
+	id := c.Args().First()
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, offline, err := openOrQueue(c, cfg)
+	if err != nil {
+		return err
+	}
+	if offline {
+		return queueOffline(c, cfg, utask.JournalEntry{Op: utask.JournalDelete, BaseRevision: c.Uint64("if-revision")}, id)
+	}
+	defer closeStore(store)
+	rid, _, err := store.Resolve(ctx, id)
+	if err != nil {
+		return err
+	}
+	delID, err := store.DeleteTaskIf(ctx, rid, c.Uint64("if-revision"))
+	if err != nil {
+		return err
+	}
+	fmt.Println(tr(c).Sprintf("%s deleted", delID))
+	return nil
 }
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+func TestListCacheHasOwnWrites(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("UTASK_BACKEND", "sqlite")
+	t.Setenv("UTASK_NO_DAEMON", "1")
+	cfg := "storage:\n  driver: sqlite\n  path: " + filepath.Join(dir, "tasks.db") + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	runUT(t, dir, "create", "--title", "first")
+	runUT(t, dir, "list")
+	runUT(t, dir, "create", "--title", "brand new")
+
+	path, err := utask.SnapshotPath("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap, err := utask.LoadSnapshot(path, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, task := range snap.Select(utask.ListFilter{}) {
+		if task.Short() == "brand new" {
+			return
+		}
+		titles = append(titles, task.Short())
+	}
+	t.Fatalf("cached tasks after create = %q, want the new task too", titles)
+}
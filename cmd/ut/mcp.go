@@ -0,0 +1,625 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	buildinfo "github.com/iainlowe/utask/internal/build"
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// mcpProtocolVersions are the MCP revisions the server speaks, newest
+// first. A client asking for one of them gets it; any other request is
+// answered with the newest, and the client decides whether to go on.
+var mcpProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// rpcError is a JSON-RPC 2.0 error object.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// JSON-RPC error codes; -32000..-32099 are reserved for server errors.
+const (
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternal       = -32603
+	rpcNotFound       = -32001
+	rpcConflict       = -32002
+	rpcForbidden      = -32003
+	// rpcCancelled answers a request cancelled by the client, as in LSP.
+	rpcCancelled = -32800
+)
+
+// defaultMCPInFlight is how many requests the server runs at once unless
+// mcp.max_in_flight or --max-in-flight say otherwise.
+const defaultMCPInFlight = 8
+
+// mcpError maps store errors onto JSON-RPC error codes.
+func mcpError(err error) rpcError {
+	code := rpcInternal
+	switch {
+	case errors.Is(err, utask.ErrNotFound):
+		code = rpcNotFound
+	case errors.Is(err, utask.ErrConflict):
+		code = rpcConflict
+	case errors.Is(err, utask.ErrForbidden):
+		code = rpcForbidden
+	case errors.Is(err, utask.ErrAmbiguousPrefix), errors.Is(err, utask.ErrValidation):
+		code = rpcInvalidParams
+	}
+	e := rpcError{Code: code, Message: err.Error()}
+	var amb *utask.AmbiguousError
+	if errors.As(err, &amb) {
+		e.Data = map[string]any{"candidates": amb.Candidates}
+	}
+	return e
+}
+
+// rpcRequest is a JSON-RPC 2.0 request, or a notification when ID is nil.
+type rpcRequest struct {
+	ID      any             `json:"id"`
+	Method  string          `json:"method"`
+	JSONRPC string          `json:"jsonrpc"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	ID      any    `json:"id"`
+	JSONRPC string `json:"jsonrpc"`
+	Result  any    `json:"result,omitempty"`
+	Error   any    `json:"error,omitempty"`
+}
+
+// mcpTool is a tool as tools/list describes it, plus the function that
+// runs it. call gets arguments already checked against the schema's
+// required keys.
+type mcpTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+	call        func(ctx context.Context, store *utask.Store, args json.RawMessage) (any, error)
+}
+
+// objectSchema is the JSON Schema of a tool's arguments.
+func objectSchema(props map[string]any, required ...string) map[string]any {
+	s := map[string]any{"type": "object", "properties": props, "additionalProperties": false}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func stringProp(desc string) map[string]any {
+	return map[string]any{"type": "string", "description": desc}
+}
+
+var idSchema = objectSchema(map[string]any{
+	"id": stringProp("task id, unique id prefix or alias"),
+}, "id")
+
+// idTool builds a tool that acts on one task named by its "id" argument.
+func idTool(name, desc string, run func(ctx context.Context, store *utask.Store, id string) (utask.Task, error)) mcpTool {
+	return mcpTool{Name: name, Description: desc, InputSchema: idSchema,
+		call: func(ctx context.Context, store *utask.Store, args json.RawMessage) (any, error) {
+			var a struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal(args, &a); err != nil {
+				return nil, err
+			}
+			rid, _, err := store.Resolve(ctx, a.ID)
+			if err != nil {
+				return nil, err
+			}
+			return run(ctx, store, rid)
+		}}
+}
+
+// mcpTools are the tools the MCP server offers.
+var mcpTools = []mcpTool{
+	{
+		Name:        "create",
+		Description: "Create a task. Creating the same task twice returns the existing one.",
+		InputSchema: objectSchema(map[string]any{
+			"title":   stringProp("task title; put a longer description in details"),
+			"details": stringProp("optional longer description, kept apart from the title"),
+			"tags":    map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "task tags"},
+			"private": map[string]any{"type": "boolean", "description": "only show the task to its author and admins"},
+		}, "title"),
+		call: func(ctx context.Context, store *utask.Store, args json.RawMessage) (any, error) {
+			var a struct {
+				Title   string   `json:"title"`
+				Details string   `json:"details"`
+				Tags    []string `json:"tags"`
+				Private bool     `json:"private"`
+			}
+			if err := json.Unmarshal(args, &a); err != nil {
+				return nil, err
+			}
+			t, _, err := store.CreateTask(ctx, utask.TaskInput{Text: a.Title, Details: a.Details, Tags: a.Tags, Private: a.Private})
+			return t, err
+		},
+	},
+	{
+		Name:        "list",
+		Description: "List tasks, optionally only those with a tag or in a status, highest priority first unless sorted otherwise.",
+		InputSchema: objectSchema(map[string]any{
+			"tag": stringProp("only tasks with this tag"),
+			"status": map[string]any{
+				"type":        "string",
+				"enum":        []string{"open", "in-progress", "blocked", "done", "cancelled", "closed", "review"},
+				"description": "only tasks in this status; open covers in-progress and blocked, closed covers done and cancelled",
+			},
+			"sort": map[string]any{
+				"type":        "string",
+				"enum":        []string{"priority", "created", "due", "text"},
+				"description": "order: priority (highest, i.e. 1, first and unset last, then oldest; default), created (oldest first), due (soonest first, undated last) or text",
+			},
+			"reverse": map[string]any{"type": "boolean", "description": "reverse the order"},
+			"limit":   map[string]any{"type": "integer", "minimum": 0, "description": "return at most this many tasks (0 for no limit) and a next cursor for the rest"},
+			"cursor":  stringProp("the next cursor of the previous page; pass the same filters"),
+		}),
+		call: func(ctx context.Context, store *utask.Store, args json.RawMessage) (any, error) {
+			var a struct {
+				Tag     string `json:"tag"`
+				Status  string `json:"status"`
+				Sort    string `json:"sort"`
+				Reverse bool   `json:"reverse"`
+				Limit   int    `json:"limit"`
+				Cursor  string `json:"cursor"`
+			}
+			if err := json.Unmarshal(args, &a); err != nil {
+				return nil, err
+			}
+			var sf utask.Status
+			if a.Status != "" {
+				var err error
+				if sf, err = utask.ParseStatus(a.Status); err != nil {
+					return nil, err
+				}
+			}
+			key, err := utask.ParseSortKey(a.Sort)
+			if err != nil {
+				return nil, err
+			}
+			return store.List(ctx, utask.ListFilter{Tag: a.Tag, Status: sf, Sort: key, Reverse: a.Reverse, Limit: a.Limit}, a.Cursor)
+		},
+	},
+	idTool("get", "Show a task.", func(ctx context.Context, store *utask.Store, id string) (utask.Task, error) {
+		t, _, err := store.GetTask(ctx, id)
+		return t, err
+	}),
+	idTool("close", "Close a task as done. A task that needs review stays open until someone else approves it.", func(ctx context.Context, store *utask.Store, id string) (utask.Task, error) {
+		t, _, err := store.CloseTask(ctx, id)
+		return t, err
+	}),
+	idTool("reopen", "Reopen a done or cancelled task, or withdraw a review request.", func(ctx context.Context, store *utask.Store, id string) (utask.Task, error) {
+		t, _, err := store.ReopenTask(ctx, id)
+		return t, err
+	}),
+	idTool("approve", "Approve a pending review, closing the task.", func(ctx context.Context, store *utask.Store, id string) (utask.Task, error) {
+		return store.ApproveTask(ctx, id, 0)
+	}),
+	{
+		Name:        "update",
+		Description: "Change a task. Only the arguments given are changed.",
+		InputSchema: objectSchema(map[string]any{
+			"id":          stringProp("task id, unique id prefix or alias"),
+			"text":        stringProp("new task text"),
+			"details":     stringProp("replace the task's details; empty clears them"),
+			"tags":        map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "replace the task's tags"},
+			"done":        map[string]any{"type": "boolean", "description": "close (true) or reopen (false) the task"},
+			"priority":    map[string]any{"type": "integer", "description": "task priority"},
+			"assignee":    stringProp("reassign to this name or email; empty unassigns"),
+			"private":     map[string]any{"type": "boolean", "description": "only show the task to its author and admins"},
+			"recur":       stringProp("recurrence rule, e.g. every:7d, weekly or FREQ=MONTHLY;COUNT=6; empty stops the series"),
+			"if_revision": revisionProp,
+		}, "id"),
+		call: func(ctx context.Context, store *utask.Store, args json.RawMessage) (any, error) {
+			var a struct {
+				ID         string    `json:"id"`
+				Text       *string   `json:"text"`
+				Details    *string   `json:"details"`
+				Tags       *[]string `json:"tags"`
+				Done       *bool     `json:"done"`
+				Priority   *int      `json:"priority"`
+				Assignee   *string   `json:"assignee"`
+				Private    *bool     `json:"private"`
+				Recur      *string   `json:"recur"`
+				IfRevision uint64    `json:"if_revision"`
+			}
+			if err := json.Unmarshal(args, &a); err != nil {
+				return nil, err
+			}
+			rid, _, err := store.Resolve(ctx, a.ID)
+			if err != nil {
+				return nil, err
+			}
+			return store.UpdateTask(ctx, rid, utask.UpdateSet{
+				Text:       a.Text,
+				Details:    a.Details,
+				Tags:       a.Tags,
+				Done:       a.Done,
+				Priority:   a.Priority,
+				Assignee:   a.Assignee,
+				Private:    a.Private,
+				Recur:      a.Recur,
+				IfRevision: a.IfRevision,
+			})
+		},
+	},
+	{
+		Name:        "delete",
+		Description: "Delete a task.",
+		InputSchema: objectSchema(map[string]any{
+			"id":          stringProp("task id, unique id prefix or alias"),
+			"if_revision": revisionProp,
+		}, "id"),
+		call: func(ctx context.Context, store *utask.Store, args json.RawMessage) (any, error) {
+			var a struct {
+				ID         string `json:"id"`
+				IfRevision uint64 `json:"if_revision"`
+			}
+			if err := json.Unmarshal(args, &a); err != nil {
+				return nil, err
+			}
+			rid, _, err := store.Resolve(ctx, a.ID)
+			if err != nil {
+				return nil, err
+			}
+			delID, err := store.DeleteTaskIf(ctx, rid, a.IfRevision)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{"id": delID}, nil
+		},
+	},
+	{
+		Name:        "tags",
+		Description: "List tags with how many tasks carry each.",
+		InputSchema: objectSchema(map[string]any{}),
+		call: func(ctx context.Context, store *utask.Store, _ json.RawMessage) (any, error) {
+			counts, err := store.ListTags(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{"tags": counts}, nil
+		},
+	},
+	{
+		Name:        "query",
+		Description: "Find tasks by tag: with any of one set of tags and all of another.",
+		InputSchema: objectSchema(map[string]any{
+			"any":    map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "tasks with at least one of these tags"},
+			"all":    map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "tasks with every one of these tags"},
+			"limit":  map[string]any{"type": "integer", "minimum": 0, "description": "return at most this many tasks (0 for no limit) and a next cursor for the rest"},
+			"cursor": stringProp("the next cursor of the previous page; pass the same tags"),
+		}),
+		call: func(ctx context.Context, store *utask.Store, args json.RawMessage) (any, error) {
+			var a struct {
+				Any    []string `json:"any"`
+				All    []string `json:"all"`
+				Limit  int      `json:"limit"`
+				Cursor string   `json:"cursor"`
+			}
+			if err := json.Unmarshal(args, &a); err != nil {
+				return nil, err
+			}
+			f := utask.ListFilter{Any: parseCSVTags(strings.Join(a.Any, ",")), All: parseCSVTags(strings.Join(a.All, ",")), Limit: a.Limit, Sort: utask.SortCreated}
+			return store.List(ctx, f, a.Cursor)
+		},
+	},
+	{
+		Name:        "rebuild-index",
+		Description: "Rebuild the tag and status indexes from the tasks. Needs the admin role.",
+		InputSchema: objectSchema(map[string]any{}),
+		call: func(ctx context.Context, store *utask.Store, _ json.RawMessage) (any, error) {
+			if err := store.RebuildIndex(ctx); err != nil {
+				return nil, err
+			}
+			return map[string]any{"ok": true}, nil
+		},
+	},
+}
+
+var revisionProp = map[string]any{"type": "integer", "minimum": 1, "description": "fail unless the task is still at this revision"}
+
+// mcpServer answers MCP requests against one store.
+type mcpServer struct {
+	store   *utask.Store
+	tools   []mcpTool
+	prompts []mcpPrompt
+	// slots holds a token for each request running, across connections.
+	slots chan struct{}
+	// profile is the store's profile. openProfile, when set, opens another
+	// one for a prompt's profile argument; the caller closes it.
+	profile     string
+	openProfile func(ctx context.Context, name string) (*utask.Store, error)
+}
+
+func newMCPServer(c *cli.Context, store *utask.Store) *mcpServer {
+	cfg := getConfig(c)
+	n := cfg.MCP.MaxInFlight
+	if c.IsSet("max-in-flight") {
+		n = c.Int("max-in-flight")
+	}
+	if n <= 0 {
+		n = defaultMCPInFlight
+	}
+	return &mcpServer{store: store, tools: mcpTools, prompts: mcpPrompts, slots: make(chan struct{}, n), profile: cfg.UI.Profile, openProfile: profileOpener(c)}
+}
+
+// serve answers one message from conn: a request, a notification, or a
+// batch of them, whose members run concurrently and whose responses come
+// back together as an array. It reports false when there is nothing to
+// send back.
+func (s *mcpServer) serve(ctx context.Context, conn *mcpConn, msg json.RawMessage) (any, bool) {
+	if b := bytes.TrimSpace(msg); len(b) == 0 || b[0] != '[' {
+		return s.call(ctx, conn, msg)
+	}
+	var batch []json.RawMessage
+	if err := json.Unmarshal(msg, &batch); err != nil || len(batch) == 0 {
+		return rpcResponse{JSONRPC: "2.0", Error: rpcError{Code: rpcInvalidRequest, Message: "empty or malformed batch"}}, true
+	}
+	resps := make([]any, len(batch))
+	answered := make([]bool, len(batch))
+	var wg sync.WaitGroup
+	for i, m := range batch {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resps[i], answered[i] = s.call(ctx, conn, m)
+		}()
+	}
+	wg.Wait()
+	out := []any{}
+	for i, r := range resps {
+		if answered[i] {
+			out = append(out, r)
+		}
+	}
+	return out, len(out) > 0
+}
+
+// call runs one request once a slot is free, under a context of its own
+// that a cancellation from the client ends. A request cancelled before it
+// starts is answered with rpcCancelled; one cancelled while running gets
+// its real answer, since a write it made may already have landed and a
+// client told otherwise would retry it. Notifications are acted on at
+// once.
+func (s *mcpServer) call(ctx context.Context, conn *mcpConn, msg json.RawMessage) (any, bool) {
+	var m rpcRequest
+	if err := json.Unmarshal(msg, &m); err != nil {
+		return rpcResponse{JSONRPC: "2.0", Error: rpcError{Code: rpcInvalidRequest, Message: err.Error()}}, true
+	}
+	if m.ID == nil {
+		s.notification(conn, m)
+		return nil, false
+	}
+	ctx, end, ok := conn.begin(ctx, m.ID)
+	if !ok {
+		return rpcResponse{ID: m.ID, JSONRPC: "2.0", Error: rpcError{Code: rpcInvalidRequest, Message: "a request with this id is still in flight"}}, true
+	}
+	defer end()
+	cancelled := rpcResponse{ID: m.ID, JSONRPC: "2.0", Error: rpcError{Code: rpcCancelled, Message: "request cancelled"}}
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-ctx.Done():
+		return cancelled, true
+	}
+	if ctx.Err() != nil {
+		return cancelled, true
+	}
+	r, _ := s.handle(ctx, conn, m)
+	return r, true
+}
+
+// notification acts on a notification from the client: MCP's
+// notifications/cancelled and LSP's $/cancelRequest stop a request in
+// flight; the rest need nothing.
+func (s *mcpServer) notification(conn *mcpConn, m rpcRequest) {
+	var p struct {
+		RequestID any `json:"requestId"`
+		ID        any `json:"id"`
+	}
+	switch m.Method {
+	case "notifications/cancelled", "$/cancelRequest":
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return
+		}
+		if p.RequestID == nil {
+			p.RequestID = p.ID
+		}
+		conn.cancel(p.RequestID)
+	}
+}
+
+// handle answers one request from conn. It reports false for
+// notifications, which get no response.
+func (s *mcpServer) handle(ctx context.Context, conn *mcpConn, m rpcRequest) (rpcResponse, bool) {
+	if m.ID == nil {
+		return rpcResponse{}, false
+	}
+	r := rpcResponse{ID: m.ID, JSONRPC: "2.0"}
+	switch m.Method {
+	case "initialize":
+		var p struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			r.Error = rpcError{Code: rpcInvalidParams, Message: err.Error()}
+			break
+		}
+		version := mcpProtocolVersions[0]
+		if slices.Contains(mcpProtocolVersions, p.ProtocolVersion) {
+			version = p.ProtocolVersion
+		}
+		r.Result = map[string]any{
+			"protocolVersion": version,
+			"capabilities": map[string]any{
+				"tools":     map[string]any{"listChanged": false},
+				"prompts":   map[string]any{"listChanged": false},
+				"resources": map[string]any{"subscribe": true, "listChanged": true},
+			},
+			"serverInfo": map[string]any{"name": "utask", "version": buildinfo.Version},
+		}
+		conn.watch(s.store)
+	case "ping":
+		if h := s.store.Health(ctx); !h.OK {
+			r.Error = rpcError{Code: rpcInternal, Message: "unhealthy", Data: h}
+		} else {
+			r.Result = map[string]any{}
+		}
+	case "tools/list":
+		r.Result = map[string]any{"tools": s.tools}
+	case "tools/call":
+		var p struct {
+			Name string          `json:"name"`
+			Args json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			r.Error = rpcError{Code: rpcInvalidParams, Message: err.Error()}
+			break
+		}
+		i := slices.IndexFunc(s.tools, func(t mcpTool) bool { return t.Name == p.Name })
+		if i < 0 {
+			r.Error = rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("unknown tool: %s", p.Name)}
+			break
+		}
+		tool := s.tools[i]
+		if err := checkArgs(tool.InputSchema, &p.Args); err != nil {
+			r.Error = rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("%s: %v", tool.Name, err)}
+			break
+		}
+		v, err := tool.call(ctx, s.store, p.Args)
+		r.Result = toolResult(v, err)
+	case "resources/list", "resources/read", "resources/subscribe", "resources/unsubscribe":
+		if v, err := s.handleResource(ctx, conn, m.Method, m.Params); err != nil {
+			r.Error = mcpError(err)
+		} else {
+			r.Result = v
+		}
+	case "prompts/list":
+		r.Result = map[string]any{"prompts": s.prompts}
+	case "prompts/get":
+		var p struct {
+			Name string            `json:"name"`
+			Args map[string]string `json:"arguments"`
+		}
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			r.Error = rpcError{Code: rpcInvalidParams, Message: err.Error()}
+			break
+		}
+		if v, err := s.getPrompt(ctx, p.Name, p.Args); err != nil {
+			r.Error = mcpError(err)
+		} else {
+			r.Result = v
+		}
+	default:
+		r.Error = rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("unknown method: %s", m.Method)}
+	}
+	return r, true
+}
+
+// checkArgs makes sure args is an object holding every key schema
+// requires, defaulting missing arguments to {}.
+func checkArgs(schema map[string]any, args *json.RawMessage) error {
+	if len(*args) == 0 || string(*args) == "null" {
+		*args = json.RawMessage("{}")
+	}
+	var got map[string]json.RawMessage
+	if err := json.Unmarshal(*args, &got); err != nil {
+		return fmt.Errorf("arguments must be an object")
+	}
+	required, _ := schema["required"].([]string)
+	for _, k := range required {
+		if v, ok := got[k]; !ok || string(v) == "null" {
+			return fmt.Errorf("missing argument %q", k)
+		}
+	}
+	return nil
+}
+
+// toolResult wraps a tool's outcome as an MCP tool result: the value as
+// JSON text and as structured content. A failed call is a result with
+// isError set, so the model sees what went wrong; structuredContent.error
+// carries the same code and candidates a JSON-RPC error would.
+func toolResult(v any, err error) map[string]any {
+	if err != nil {
+		var e rpcError
+		var syntax *json.SyntaxError
+		var typ *json.UnmarshalTypeError
+		if errors.As(err, &syntax) || errors.As(err, &typ) {
+			e = rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		} else {
+			e = mcpError(err)
+		}
+		return map[string]any{
+			"content":           []map[string]any{{"type": "text", "text": e.Message}},
+			"structuredContent": map[string]any{"error": e},
+			"isError":           true,
+		}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return toolResult(nil, err)
+	}
+	return map[string]any{
+		"content":           []map[string]any{{"type": "text", "text": string(b)}},
+		"structuredContent": v,
+	}
+}
+
+func runMCPStdio(c *cli.Context) error {
+	dec := json.NewDecoder(os.Stdin)
+	enc := json.NewEncoder(os.Stdout)
+
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+
+	srv := newMCPServer(c, store)
+	// Responses are written as requests finish, and notifications from
+	// the task watcher share stdout with them.
+	var mu sync.Mutex
+	write := func(v any) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return enc.Encode(v)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	conn := newMCPConn(ctx, func(n rpcNotification) { _ = write(&n) })
+	var wg sync.WaitGroup
+	// Graceful exit on EOF, once the requests in flight are answered.
+	defer wg.Wait()
+	for {
+		var msg json.RawMessage
+		if err := dec.Decode(&msg); err != nil {
+			return nil
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if out, ok := srv.serve(ctx, conn, msg); ok {
+				_ = write(out)
+			}
+		}()
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+// testMCPServer serves a fresh sqlite store, running at most inFlight
+// requests at once.
+func testMCPServer(t *testing.T, inFlight int) (*mcpServer, *mcpConn) {
+	t.Helper()
+	ctx := context.Background()
+	store, err := utask.OpenSQLite(ctx, filepath.Join(t.TempDir(), "tasks.db"), "test", utask.Options{Identity: "Ada <ada@example.org>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(store.Close)
+	s := &mcpServer{store: store, tools: mcpTools, prompts: mcpPrompts, slots: make(chan struct{}, inFlight)}
+	return s, newMCPConn(ctx, func(rpcNotification) {})
+}
+
+type testResponse struct {
+	ID     any             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+// decodeResponses reads what serve or call returned as JSON, one response
+// or a batch of them.
+func decodeResponses(t *testing.T, v any) []testResponse {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []testResponse
+	if len(b) > 0 && b[0] == '[' {
+		err = json.Unmarshal(b, &out)
+	} else {
+		out = make([]testResponse, 1)
+		err = json.Unmarshal(b, &out[0])
+	}
+	if err != nil {
+		t.Fatalf("%s: %v", b, err)
+	}
+	return out
+}
+
+// callAsync runs msg through call and delivers its response.
+func callAsync(s *mcpServer, conn *mcpConn, msg string) <-chan any {
+	done := make(chan any, 1)
+	go func() {
+		r, _ := s.call(context.Background(), conn, json.RawMessage(msg))
+		done <- r
+	}()
+	return done
+}
+
+// waitInFlight waits until n requests have begun on conn.
+func waitInFlight(t *testing.T, conn *mcpConn, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		conn.mu.Lock()
+		got := len(conn.inflight)
+		conn.mu.Unlock()
+		if got == n {
+			return
+		}
+	}
+	t.Fatalf("requests in flight never reached %d", n)
+}
+
+func TestMCPBatch(t *testing.T) {
+	s, conn := testMCPServer(t, 2)
+	ctx := context.Background()
+	out, ok := s.serve(ctx, conn, json.RawMessage(`[
+		{"jsonrpc":"2.0","id":1,"method":"tools/list"},
+		{"jsonrpc":"2.0","method":"notifications/initialized"},
+		{"jsonrpc":"2.0","id":"two","method":"prompts/list"},
+		{"jsonrpc":"2.0","id":3,"method":"nope"}
+	]`))
+	if !ok {
+		t.Fatal("batch got no response")
+	}
+	resps := decodeResponses(t, out)
+	if len(resps) != 3 || resps[0].ID != float64(1) || resps[1].ID != "two" || resps[2].ID != float64(3) {
+		t.Fatalf("batch responses = %+v", resps)
+	}
+	if resps[0].Error != nil || resps[1].Error != nil || resps[2].Error == nil || resps[2].Error.Code != rpcMethodNotFound {
+		t.Fatalf("batch responses = %+v", resps)
+	}
+
+	out, ok = s.serve(ctx, conn, json.RawMessage(`[]`))
+	if resps := decodeResponses(t, out); !ok || resps[0].Error == nil || resps[0].Error.Code != rpcInvalidRequest {
+		t.Fatalf("empty batch = %+v", resps)
+	}
+	if out, ok := s.serve(ctx, conn, json.RawMessage(`[{"jsonrpc":"2.0","method":"notifications/initialized"}]`)); ok {
+		t.Fatalf("batch of notifications answered with %v", out)
+	}
+}
+
+func TestMCPInFlightLimit(t *testing.T) {
+	s, conn := testMCPServer(t, 1)
+	s.slots <- struct{}{} // a request already running
+	done := callAsync(s, conn, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	waitInFlight(t, conn, 1)
+	select {
+	case r := <-done:
+		t.Fatalf("request ran past the limit: %v", r)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	r, _ := s.call(context.Background(), conn, json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	if resps := decodeResponses(t, r); resps[0].Error == nil || resps[0].Error.Code != rpcInvalidRequest {
+		t.Fatalf("duplicate id = %+v", resps)
+	}
+
+	<-s.slots
+	if resps := decodeResponses(t, <-done); resps[0].Error != nil || resps[0].ID != float64(1) {
+		t.Fatalf("request after a slot freed = %+v", resps)
+	}
+}
+
+func TestMCPCancel(t *testing.T) {
+	for _, cancel := range []string{
+		`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"r1","reason":"user"}}`,
+		`{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":"r1"}}`,
+	} {
+		s, conn := testMCPServer(t, 1)
+		s.slots <- struct{}{}
+		done := callAsync(s, conn, `{"jsonrpc":"2.0","id":"r1","method":"tools/list"}`)
+		waitInFlight(t, conn, 1)
+		if out, ok := s.serve(context.Background(), conn, json.RawMessage(cancel)); ok {
+			t.Fatalf("cancellation answered with %v", out)
+		}
+		if resps := decodeResponses(t, <-done); resps[0].Error == nil || resps[0].Error.Code != rpcCancelled || resps[0].ID != "r1" {
+			t.Fatalf("%s: waiting request = %+v", cancel, resps)
+		}
+		waitInFlight(t, conn, 0)
+	}
+}
+
+func TestMCPCancelAfterStart(t *testing.T) {
+	s, conn := testMCPServer(t, 1)
+	s.tools = append(s.tools, mcpTool{
+		Name:        "commit",
+		InputSchema: objectSchema(map[string]any{}),
+		call: func(ctx context.Context, _ *utask.Store, _ json.RawMessage) (any, error) {
+			// The write has landed when the client's cancellation arrives.
+			conn.cancel(7)
+			<-ctx.Done()
+			return map[string]any{"committed": true}, nil
+		},
+	})
+	r, _ := s.call(context.Background(), conn, json.RawMessage(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"commit","arguments":{}}}`))
+	resps := decodeResponses(t, r)
+	if resps[0].Error != nil {
+		t.Fatalf("a request cancelled after it ran got %+v", resps[0].Error)
+	}
+	var res struct {
+		Structured map[string]any `json:"structuredContent"`
+	}
+	if err := json.Unmarshal(resps[0].Result, &res); err != nil || res.Structured["committed"] != true {
+		t.Fatalf("result = %s, %v", resps[0].Result, err)
+	}
+}
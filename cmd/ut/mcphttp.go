@@ -0,0 +1,375 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/iainlowe/utask/internal/httpapi"
+	cli "github.com/urfave/cli/v2"
+)
+
+const (
+	// mcpSessionHeader carries the session id of the Streamable HTTP
+	// transport.
+	mcpSessionHeader = "Mcp-Session-Id"
+	// mcpSessionIdle is how long a session without an open stream lasts
+	// after its last request.
+	mcpSessionIdle = time.Hour
+	// mcpKeepAlive is how often an idle event stream gets a comment, so
+	// proxies don't close it.
+	mcpKeepAlive = 30 * time.Second
+)
+
+// rpcParseError is the JSON-RPC code for a body that isn't JSON.
+const rpcParseError = -32700
+
+func runMCPHTTP(c *cli.Context) error {
+	cfg := getConfig(c)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	tokens, err := tokenGrants(cfg.Access.Tokens)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              c.String("http"),
+		Handler:           newMCPHTTP(newMCPServer(c, store), tokens),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	slog.Info("serving mcp", "addr", srv.Addr, "profile", cfg.UI.Profile, "role", store.Role(ctx), "tokens", len(tokens))
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("mcp: %w", err)
+	case <-ctx.Done():
+	}
+	// Event streams end with ctx, so Shutdown only waits for calls in
+	// flight.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// mcpHTTP serves MCP over HTTP in both transports clients use: Streamable
+// HTTP at /mcp (POST a message, get the response back; GET opens an event
+// stream; DELETE ends the session) and the older HTTP+SSE transport (GET
+// /sse opens the stream and names a /messages URL to POST to, and
+// responses arrive on the stream). Every request is checked against the
+// access tokens and runs as its token's role and identity.
+type mcpHTTP struct {
+	srv    *mcpServer
+	tokens map[string]httpapi.Grant
+	mux    *http.ServeMux
+
+	mu       sync.Mutex
+	sessions map[string]*mcpSession
+}
+
+// mcpSession is one client's connection state.
+type mcpSession struct {
+	// out carries responses to an HTTP+SSE client's stream; Streamable
+	// HTTP sessions answer in the POST response and leave it nil.
+	out chan any
+	// notes carries server notifications to whichever event stream is
+	// open; they are dropped while none is.
+	notes chan rpcNotification
+	conn  *mcpConn
+	// done is closed when the session ends, which ends its streams and
+	// its task watcher.
+	done     chan struct{}
+	cancel   context.CancelFunc
+	lastSeen time.Time
+	streams  int
+}
+
+func newMCPHTTP(srv *mcpServer, tokens map[string]httpapi.Grant) *mcpHTTP {
+	h := &mcpHTTP{srv: srv, tokens: tokens, mux: http.NewServeMux(), sessions: map[string]*mcpSession{}}
+	h.mux.HandleFunc("POST /mcp", h.handlePost)
+	h.mux.HandleFunc("GET /mcp", h.handleStream)
+	h.mux.HandleFunc("DELETE /mcp", h.handleDelete)
+	h.mux.HandleFunc("GET /sse", h.handleSSE)
+	h.mux.HandleFunc("POST /messages", h.handleMessage)
+	return h
+}
+
+func (h *mcpHTTP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Browsers send Origin; refusing foreign ones stops a web page from
+	// driving a server on localhost (DNS rebinding).
+	if o := r.Header.Get("Origin"); o != "" {
+		if u, err := url.Parse(o); err != nil || u.Host != r.Host {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+	}
+	if v := r.Header.Get("Mcp-Protocol-Version"); v != "" && !slices.Contains(mcpProtocolVersions, v) {
+		http.Error(w, fmt.Sprintf("unsupported MCP protocol version %q", v), http.StatusBadRequest)
+		return
+	}
+	r, ok := httpapi.Authenticate(r, h.tokens)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="utask"`)
+		http.Error(w, "missing or unknown access token", http.StatusUnauthorized)
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// newSession starts a session for the caller of r, dropping ones that have
+// sat idle past mcpSessionIdle.
+func (h *mcpHTTP) newSession(r *http.Request, out chan any) (string, *mcpSession) {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	id := hex.EncodeToString(b[:])
+	// The session outlives r but keeps its caller's role and identity.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+	sess := &mcpSession{out: out, notes: make(chan rpcNotification, 64), done: make(chan struct{}), cancel: cancel, lastSeen: time.Now()}
+	sess.conn = newMCPConn(ctx, func(n rpcNotification) {
+		select {
+		case sess.notes <- n:
+		default:
+		}
+	})
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sid, s := range h.sessions {
+		if s.streams == 0 && time.Since(s.lastSeen) > mcpSessionIdle {
+			h.endLocked(sid)
+		}
+	}
+	h.sessions[id] = sess
+	return id, sess
+}
+
+// session looks up a live session and marks it used.
+func (h *mcpHTTP) session(id string) (*mcpSession, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sess, ok := h.sessions[id]
+	if ok {
+		sess.lastSeen = time.Now()
+	}
+	return sess, ok
+}
+
+func (h *mcpHTTP) end(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.endLocked(id)
+}
+
+func (h *mcpHTTP) endLocked(id string) {
+	if sess, ok := h.sessions[id]; ok {
+		close(sess.done)
+		sess.cancel()
+		delete(h.sessions, id)
+	}
+}
+
+// streamOpened and streamClosed count a session's open event streams, which
+// keep it from expiring.
+func (h *mcpHTTP) streamOpened(sess *mcpSession) {
+	h.mu.Lock()
+	sess.streams++
+	h.mu.Unlock()
+}
+
+func (h *mcpHTTP) streamClosed(sess *mcpSession) {
+	h.mu.Lock()
+	sess.streams--
+	sess.lastSeen = time.Now()
+	h.mu.Unlock()
+}
+
+// readMessage reads a POSTed JSON-RPC message or batch, answering one that
+// isn't JSON itself.
+func readMessage(w http.ResponseWriter, r *http.Request) (json.RawMessage, bool) {
+	var m json.RawMessage
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&m); err != nil {
+		writeRPC(w, http.StatusBadRequest, rpcResponse{JSONRPC: "2.0", Error: rpcError{Code: rpcParseError, Message: err.Error()}})
+		return m, false
+	}
+	return m, true
+}
+
+func writeRPC(w http.ResponseWriter, code int, r any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(r)
+}
+
+// handlePost answers a Streamable HTTP message, or a batch of them with an
+// array. initialize starts a session and returns its id in the
+// Mcp-Session-Id header; every later message must carry it. Notifications
+// are acknowledged with 202 and no body.
+func (h *mcpHTTP) handlePost(w http.ResponseWriter, r *http.Request) {
+	msg, ok := readMessage(w, r)
+	if !ok {
+		return
+	}
+	var (
+		m    rpcRequest
+		sess *mcpSession
+	)
+	if json.Unmarshal(msg, &m) == nil && m.Method == "initialize" {
+		var id string
+		id, sess = h.newSession(r, nil)
+		w.Header().Set(mcpSessionHeader, id)
+	} else if sess, ok = h.requireSession(w, r.Header.Get(mcpSessionHeader)); !ok {
+		return
+	}
+	resp, ok := h.srv.serve(r.Context(), sess.conn, msg)
+	if !ok {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	writeRPC(w, http.StatusOK, resp)
+}
+
+// requireSession looks up a session, answering 400 when the request names
+// none and 404 when it has ended, which tells the client to initialize
+// again.
+func (h *mcpHTTP) requireSession(w http.ResponseWriter, id string) (*mcpSession, bool) {
+	if id == "" {
+		http.Error(w, "missing "+mcpSessionHeader+" header", http.StatusBadRequest)
+		return nil, false
+	}
+	sess, ok := h.session(id)
+	if !ok {
+		http.Error(w, "unknown or expired session", http.StatusNotFound)
+		return nil, false
+	}
+	return sess, true
+}
+
+// handleStream opens a Streamable HTTP session's event stream, which
+// carries the server's notifications, and keep-alives, until the client,
+// the session or the server goes away.
+func (h *mcpHTTP) handleStream(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "GET /mcp needs Accept: text/event-stream", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := h.requireSession(w, r.Header.Get(mcpSessionHeader))
+	if !ok {
+		return
+	}
+	h.stream(w, r, sess, nil)
+}
+
+func (h *mcpHTTP) handleDelete(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireSession(w, r.Header.Get(mcpSessionHeader)); !ok {
+		return
+	}
+	h.end(r.Header.Get(mcpSessionHeader))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSSE opens an HTTP+SSE session: the first event names the URL to
+// POST messages to, and responses follow as message events. The session
+// ends with the stream.
+func (h *mcpHTTP) handleSSE(w http.ResponseWriter, r *http.Request) {
+	id, sess := h.newSession(r, make(chan any, 16))
+	defer h.end(id)
+	h.stream(w, r, sess, func(f http.Flusher) {
+		fmt.Fprintf(w, "event: endpoint\ndata: /messages?sessionId=%s\n\n", id)
+		f.Flush()
+	})
+}
+
+// handleMessage takes a message for an HTTP+SSE session and queues its
+// response on the session's stream.
+func (h *mcpHTTP) handleMessage(w http.ResponseWriter, r *http.Request) {
+	sess, ok := h.requireSession(w, r.URL.Query().Get("sessionId"))
+	if !ok {
+		return
+	}
+	if sess.out == nil {
+		http.Error(w, "session has no event stream; POST to /mcp", http.StatusBadRequest)
+		return
+	}
+	msg, ok := readMessage(w, r)
+	if !ok {
+		return
+	}
+	resp, ok := h.srv.serve(r.Context(), sess.conn, msg)
+	if ok {
+		select {
+		case sess.out <- resp:
+		case <-sess.done:
+			http.Error(w, "session ended", http.StatusNotFound)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// stream writes sess's event stream until the client disconnects, the
+// session ends or the server shuts down. start, if set, writes the first
+// events.
+func (h *mcpHTTP) stream(w http.ResponseWriter, r *http.Request, sess *mcpSession, start func(http.Flusher)) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	h.streamOpened(sess)
+	defer h.streamClosed(sess)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if start != nil {
+		start(flusher)
+	} else {
+		fmt.Fprint(w, ": connected\n\n")
+		flusher.Flush()
+	}
+	tick := time.NewTicker(mcpKeepAlive)
+	defer tick.Stop()
+	for {
+		select {
+		case resp := <-sess.out:
+			b, _ := json.Marshal(resp)
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", b)
+		case n := <-sess.notes:
+			b, _ := json.Marshal(&n)
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", b)
+		case <-tick.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-sess.done:
+			return
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+// taskURIPrefix starts the URI of a task resource; the task id follows.
+const taskURIPrefix = "utask://tasks/"
+
+// rpcNotification is a JSON-RPC 2.0 notification from the server.
+type rpcNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// mcpConn is one client's connection: where its notifications go, the
+// task resources it subscribed to and the watcher that feeds them, and
+// its requests in flight.
+type mcpConn struct {
+	// ctx lasts as long as the connection and carries its caller's role
+	// and identity, so the watcher only reports tasks they may see.
+	ctx    context.Context
+	notify func(rpcNotification)
+
+	mu       sync.Mutex
+	subs     map[string]bool
+	watching bool
+	// inflight cancels each running request, by its id (see requestKey).
+	inflight map[string]context.CancelFunc
+}
+
+func newMCPConn(ctx context.Context, notify func(rpcNotification)) *mcpConn {
+	return &mcpConn{ctx: ctx, notify: notify, subs: map[string]bool{}, inflight: map[string]context.CancelFunc{}}
+}
+
+// requestKey is a request id as a map key. JSON numbers decode as
+// float64 and strings keep their quotes, so 1 and "1" stay apart.
+func requestKey(id any) string {
+	b, _ := json.Marshal(id)
+	return string(b)
+}
+
+// begin gives request id a context of its own, which cancel ends; end
+// forgets it once the request is answered. It reports false when a
+// request with the same id is still in flight, as a cancellation couldn't
+// tell the two apart.
+func (c *mcpConn) begin(ctx context.Context, id any) (context.Context, func(), bool) {
+	key := requestKey(id)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, dup := c.inflight[key]; dup {
+		return nil, nil, false
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	c.inflight[key] = cancel
+	return ctx, func() {
+		c.mu.Lock()
+		delete(c.inflight, key)
+		c.mu.Unlock()
+		cancel()
+	}, true
+}
+
+// cancel stops request id if it is still running.
+func (c *mcpConn) cancel(id any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cancel, ok := c.inflight[requestKey(id)]; ok {
+		cancel()
+	}
+}
+
+func (c *mcpConn) subscribe(uri string, on bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if on {
+		c.subs[uri] = true
+	} else {
+		delete(c.subs, uri)
+	}
+}
+
+func (c *mcpConn) subscribed(uri string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.subs[uri]
+}
+
+// watch starts, once per connection, a watcher on store that sends every
+// task change as a utask/taskChanged notification,
+// notifications/resources/updated for subscribed tasks, and
+// notifications/resources/list_changed when a task comes or goes. It runs
+// until the connection's context ends.
+func (c *mcpConn) watch(store *utask.Store) {
+	c.mu.Lock()
+	started := c.watching
+	c.watching = true
+	c.mu.Unlock()
+	if started {
+		return
+	}
+	events, err := store.Watch(c.ctx, utask.WatchFilter{})
+	if err != nil {
+		slog.Warn("mcp: no task change notifications", "err", err)
+		return
+	}
+	go func() {
+		for ev := range events {
+			c.notify(rpcNotification{JSONRPC: "2.0", Method: "utask/taskChanged", Params: ev})
+			if uri := taskURIPrefix + ev.ID; c.subscribed(uri) {
+				c.notify(rpcNotification{JSONRPC: "2.0", Method: "notifications/resources/updated", Params: map[string]any{"uri": uri}})
+			}
+			if ev.Kind == utask.ActivityCreated || ev.Kind == utask.ActivityDeleted {
+				c.notify(rpcNotification{JSONRPC: "2.0", Method: "notifications/resources/list_changed"})
+			}
+		}
+	}()
+}
+
+// taskResource describes a task as a resources/list entry.
+func taskResource(t utask.Task) map[string]any {
+	return map[string]any{
+		"uri":      taskURIPrefix + t.ID,
+		"name":     t.Ref(),
+		"title":    t.Short(),
+		"mimeType": "application/json",
+	}
+}
+
+// resourceTask reads the task id out of a task resource URI.
+func resourceTask(uri string) (string, error) {
+	id, ok := strings.CutPrefix(uri, taskURIPrefix)
+	if !ok || id == "" || strings.Contains(id, "/") {
+		return "", fmt.Errorf("not a task resource: %q: %w", uri, utask.ErrValidation)
+	}
+	return id, nil
+}
+
+// handleResource answers resources/list, read, subscribe and unsubscribe
+// for conn.
+func (s *mcpServer) handleResource(ctx context.Context, conn *mcpConn, method string, params json.RawMessage) (any, error) {
+	var p struct {
+		URI    string `json:"uri"`
+		Cursor string `json:"cursor"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("%v: %w", err, utask.ErrValidation)
+		}
+	}
+	if method == "resources/list" {
+		page, err := s.store.List(ctx, utask.ListFilter{Sort: utask.SortCreated, Limit: mcpPromptTasks}, p.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		resources := []map[string]any{}
+		for _, t := range page.Tasks {
+			resources = append(resources, taskResource(t))
+		}
+		out := map[string]any{"resources": resources}
+		if page.Next != "" {
+			out["nextCursor"] = page.Next
+		}
+		return out, nil
+	}
+	id, err := resourceTask(p.URI)
+	if err != nil {
+		return nil, err
+	}
+	if method == "resources/unsubscribe" {
+		conn.subscribe(p.URI, false)
+		return map[string]any{}, nil
+	}
+	t, _, err := s.store.GetTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if method == "resources/subscribe" {
+		conn.subscribe(p.URI, true)
+		return map[string]any{}, nil
+	}
+	b, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"contents": []map[string]any{{"uri": p.URI, "mimeType": "application/json", "text": string(b)}}}, nil
+}
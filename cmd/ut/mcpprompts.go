@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// mcpPromptTasks caps how many tasks a prompt lists; the rest are
+// counted.
+const mcpPromptTasks = 100
+
+// mcpPrompt is a prompt as prompts/list describes it, plus the function
+// that renders it from live store data. render gets arguments already
+// checked for the required ones.
+type mcpPrompt struct {
+	Name        string         `json:"name"`
+	Title       string         `json:"title,omitempty"`
+	Description string         `json:"description"`
+	Arguments   []mcpPromptArg `json:"arguments,omitempty"`
+	render      func(ctx context.Context, store *utask.Store, args map[string]string) (string, error)
+}
+
+// mcpPromptArg is one argument of a prompt; prompt arguments are strings.
+type mcpPromptArg struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+var (
+	tagArg     = mcpPromptArg{Name: "tag", Description: "only tasks with this tag"}
+	profileArg = mcpPromptArg{Name: "profile", Description: "read another profile listed under profiles: in the server's config"}
+)
+
+// mcpPrompts are the prompts the MCP server offers.
+var mcpPrompts = []mcpPrompt{
+	{
+		Name:        "open-tasks",
+		Title:       "Summarize my open tasks",
+		Description: "Summarize the open tasks, highest priority first: what they are about, what matters most and what looks stuck.",
+		Arguments:   []mcpPromptArg{tagArg, profileArg},
+		render: func(ctx context.Context, store *utask.Store, args map[string]string) (string, error) {
+			page, err := store.List(ctx, utask.ListFilter{Tag: args["tag"], Status: utask.StatusOpen, HideSnoozed: true, Sort: utask.DefaultSort}, "")
+			if err != nil {
+				return "", err
+			}
+			var b strings.Builder
+			fmt.Fprintf(&b, "Summarize my open tasks%s: what they are about, which matter most, and any that look stale, blocked or overdue. Refer to tasks by their T- number.\n\n", tagged(args["tag"]))
+			writeTaskLines(&b, page.Tasks, time.Now())
+			return b.String(), nil
+		},
+	},
+	{
+		Name:        "plan-day",
+		Title:       "Plan my day",
+		Description: "Plan today from the tasks due today or overdue and the ones already in progress.",
+		Arguments:   []mcpPromptArg{tagArg, profileArg},
+		render: func(ctx context.Context, store *utask.Store, args map[string]string) (string, error) {
+			now := time.Now()
+			y, m, d := now.Date()
+			endOfDay := time.Date(y, m, d+1, 0, 0, 0, 0, now.Location()).Add(-time.Nanosecond)
+			due, err := store.List(ctx, utask.ListFilter{Tag: args["tag"], Status: utask.StatusOpen, DueBefore: endOfDay, Sort: utask.SortDue}, "")
+			if err != nil {
+				return "", err
+			}
+			started, err := store.List(ctx, utask.ListFilter{Tag: args["tag"], Status: utask.StatusInProgress, Sort: utask.DefaultSort}, "")
+			if err != nil {
+				return "", err
+			}
+			var b strings.Builder
+			fmt.Fprintf(&b, "Plan my day for %s from my tasks%s. Put them in a realistic order, using the estimates where given, deal with overdue ones first, and say what should move to another day if it doesn't all fit.\n\n", now.Format("Monday 2 January 2006"), tagged(args["tag"]))
+			b.WriteString("Due today or overdue:\n")
+			writeTaskLines(&b, due.Tasks, now)
+			b.WriteString("\nAlready in progress:\n")
+			writeTaskLines(&b, started.Tasks, now)
+			return b.String(), nil
+		},
+	},
+	{
+		Name:        "task",
+		Title:       "Work on a task",
+		Description: "Suggest next steps for one task, with its details, notes and dependencies.",
+		Arguments: []mcpPromptArg{
+			{Name: "id", Description: "task id, number, unique id prefix or alias", Required: true},
+			profileArg,
+		},
+		render: func(ctx context.Context, store *utask.Store, args map[string]string) (string, error) {
+			rid, _, err := store.Resolve(ctx, args["id"])
+			if err != nil {
+				return "", err
+			}
+			t, _, err := store.GetTask(ctx, rid)
+			if err != nil {
+				return "", err
+			}
+			now := time.Now()
+			var b strings.Builder
+			b.WriteString("Help me with this task: break it into next steps, point out anything unclear or missing, and say whether it can be done as it stands.\n\n")
+			writeTaskLines(&b, []utask.Task{t}, now)
+			if d := t.Details(); d != "" {
+				fmt.Fprintf(&b, "\nDetails:\n%s\n", d)
+			}
+			if len(t.Annotations) > 0 {
+				b.WriteString("\nNotes, oldest first:\n")
+				for _, a := range t.Annotations {
+					fmt.Fprintf(&b, "- %s %s: %s\n", a.When.Format("2006-01-02"), a.By, a.Text)
+				}
+			}
+			if len(t.DependsOn) > 0 {
+				b.WriteString("\nWaits on:\n")
+				var deps []utask.Task
+				for _, id := range t.DependsOn {
+					if dep, _, err := store.GetTask(ctx, id); err == nil {
+						deps = append(deps, dep)
+					}
+				}
+				writeTaskLines(&b, deps, now)
+			}
+			return b.String(), nil
+		},
+	},
+}
+
+func tagged(tag string) string {
+	if tag == "" {
+		return ""
+	}
+	return " tagged " + tag
+}
+
+// writeTaskLines lists tasks one per line, with what a reader needs to
+// weigh them: status, priority, due date, estimate, assignee and tags.
+func writeTaskLines(b *strings.Builder, tasks []utask.Task, now time.Time) {
+	if len(tasks) == 0 {
+		b.WriteString("(none)\n")
+		return
+	}
+	for i, t := range tasks {
+		if i == mcpPromptTasks {
+			fmt.Fprintf(b, "…and %d more\n", len(tasks)-i)
+			break
+		}
+		attrs := []string{string(t.State())}
+		if t.Priority != 0 {
+			attrs = append(attrs, fmt.Sprintf("priority %d", t.Priority))
+		}
+		if due, ok := t.DueAt(now.Location()); ok {
+			if t.Overdue(now) {
+				attrs = append(attrs, "overdue since "+due.Format("2006-01-02"))
+			} else {
+				attrs = append(attrs, "due "+due.Format("2006-01-02"))
+			}
+		}
+		if t.EstimateMinutes > 0 {
+			attrs = append(attrs, "estimate "+utask.FormatDuration(time.Duration(t.EstimateMinutes)*time.Minute))
+		}
+		if t.Assignee != "" {
+			attrs = append(attrs, "assigned to "+t.Assignee)
+		}
+		if len(t.Tags) > 0 {
+			attrs = append(attrs, "tags "+strings.Join(t.Tags, ", "))
+		}
+		fmt.Fprintf(b, "- %s %s (%s)\n", t.Ref(), t.Short(), strings.Join(attrs, "; "))
+	}
+}
+
+// getPrompt answers prompts/get: it renders the named prompt against the
+// store of the profile the arguments name, or the server's own.
+func (s *mcpServer) getPrompt(ctx context.Context, name string, args map[string]string) (any, error) {
+	i := slices.IndexFunc(s.prompts, func(p mcpPrompt) bool { return p.Name == name })
+	if i < 0 {
+		return nil, fmt.Errorf("unknown prompt: %s: %w", name, utask.ErrValidation)
+	}
+	p := s.prompts[i]
+	for _, a := range p.Arguments {
+		if a.Required && strings.TrimSpace(args[a.Name]) == "" {
+			return nil, fmt.Errorf("%s: missing argument %q: %w", p.Name, a.Name, utask.ErrValidation)
+		}
+	}
+	store := s.store
+	if prof := args["profile"]; prof != "" && prof != s.profile {
+		if s.openProfile == nil {
+			return nil, fmt.Errorf("%s: this server only serves profile %s: %w", p.Name, s.profile, utask.ErrValidation)
+		}
+		other, err := s.openProfile(ctx, prof)
+		if err != nil {
+			return nil, err
+		}
+		defer other.Close()
+		store = other
+	}
+	text, err := p.render(ctx, store, args)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"description": p.Description,
+		"messages":    []map[string]any{{"role": "user", "content": map[string]any{"type": "text", "text": text}}},
+	}, nil
+}
+
+// profileOpener opens the profiles listed under profiles: for the prompts'
+// profile argument, connecting directly as ut purge does.
+func profileOpener(c *cli.Context) func(ctx context.Context, name string) (*utask.Store, error) {
+	return func(ctx context.Context, name string) (*utask.Store, error) {
+		cfg := *getConfig(c)
+		if _, ok := cfg.Profiles[name]; !ok {
+			return nil, fmt.Errorf("no profile %q under profiles: in the server's config: %w", name, utask.ErrValidation)
+		}
+		cfg.UseProfile(name)
+		if c.IsSet("nats-url") {
+			cfg.NATS.URL = c.String("nats-url")
+		}
+		opts, err := storeOptions(&cfg)
+		if err != nil {
+			return nil, err
+		}
+		return openDriver(ctx, &cfg, opts)
+	}
+}
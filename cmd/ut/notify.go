@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	conf "github.com/iainlowe/utask/internal/config"
+	"github.com/iainlowe/utask/internal/notify"
+	cli "github.com/urfave/cli/v2"
+)
+
+// printSender writes notices to stdout for `ut notify --dry-run`.
+type printSender struct{}
+
+func (printSender) Send(_ context.Context, n notify.Notice) error {
+	fmt.Printf("%s\t%s\t%s\n", n.To, n.Kind, n.Text())
+	return nil
+}
+
+// notifyRecipients builds the people map from config, keyed by lowercase
+// handle. With dryRun every recipient prints instead.
+func notifyRecipients(cfg *conf.Config, dryRun bool) (map[string]notify.Recipient, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	smtpCfg, auth := cfg.Notify.SMTP, smtpAuth(cfg)
+	out := make(map[string]notify.Recipient, len(cfg.People))
+	for handle, p := range cfg.People {
+		r := notify.Recipient{Identity: p.Identity().String()}
+		switch {
+		case dryRun:
+			r.Senders = []notify.Sender{printSender{}}
+		default:
+			if p.Webhook != "" {
+				r.Senders = append(r.Senders, &notify.Webhook{URL: p.Webhook, Client: client})
+			}
+			if p.Slack != "" {
+				r.Senders = append(r.Senders, &notify.Slack{URL: p.Slack, Client: client})
+			}
+			if p.Mail {
+				if smtpCfg.Addr == "" || p.Email == "" {
+					return nil, fmt.Errorf("people.%s: mail needs an email and notify.smtp.addr", handle)
+				}
+				r.Senders = append(r.Senders, &notify.Mail{Addr: smtpCfg.Addr, From: smtpCfg.From, To: p.Email, Auth: auth})
+			}
+		}
+		out[strings.ToLower(handle)] = r
+	}
+	return out, nil
+}
+
+func cmdNotify(c *cli.Context) error {
+	cfg := getConfig(c)
+	people, err := notifyRecipients(cfg, c.Bool("dry-run"))
+	if err != nil {
+		return err
+	}
+	if len(people) == 0 {
+		return fmt.Errorf("no people configured to notify (people:)")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	return notify.New(store, people).Run(ctx)
+}
+
+// smtpAuth returns PLAIN auth for notify.smtp, or nil when it has no
+// username.
+func smtpAuth(cfg *conf.Config) smtp.Auth {
+	s := cfg.Notify.SMTP
+	if s.Username == "" {
+		return nil
+	}
+	host, _, _ := net.SplitHostPort(s.Addr)
+	return smtp.PlainAuth("", s.Username, s.Password, host)
+}
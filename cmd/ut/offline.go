@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	conf "github.com/iainlowe/utask/internal/config"
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// openOrQueue opens the store for a command that changes tasks. It reports
+// offline instead, with no store, under --offline or when NATS can't be
+// reached and the local snapshot can stand in for it; the command should
+// then queue its change with queueOffline.
+func openOrQueue(c *cli.Context, cfg *conf.Config) (*utask.Store, bool, error) {
+	if c.Bool("offline") {
+		return nil, true, nil
+	}
+	store, err := openStore(c.Context, cfg)
+	if err == nil {
+		return store, false, nil
+	}
+	if !errors.Is(err, utask.ErrUnavailable) || cfg.Cache.Disabled {
+		return nil, false, err
+	}
+	slog.Warn("NATS unreachable; queueing the change until the next connection", "err", err)
+	return nil, true, nil
+}
+
+// queueOffline makes e against the task ref (empty for create) in the
+// local snapshot and journals it for replayJournal, printing the result.
+func queueOffline(c *cli.Context, cfg *conf.Config, e utask.JournalEntry, ref string) error {
+	snapPath, err := utask.SnapshotPath(cfg.UI.Profile)
+	if err != nil {
+		return err
+	}
+	journal, err := utask.JournalPath(cfg.UI.Profile)
+	if err != nil {
+		return err
+	}
+	snap, err := utask.LoadSnapshot(snapPath, cfg.UI.Profile)
+	if err != nil {
+		return err
+	}
+	if snap.Empty() && e.Op != utask.JournalCreate {
+		return fmt.Errorf("no local cache to work offline from; run ut list while connected first")
+	}
+	if ref != "" {
+		id, err := snap.Resolve(ref)
+		if err != nil {
+			return err
+		}
+		e.ID = id
+		if e.BaseRevision == 0 {
+			e.BaseRevision = snap.Tasks[id].Revision
+		}
+	}
+	e.At = time.Now().UTC()
+	t, err := snap.Apply(e, conf.ResolveIdentity(cfg.Identity).String())
+	if err != nil {
+		return err
+	}
+	e.ID = t.ID
+	if err := utask.AppendJournal(journal, e); err != nil {
+		return fmt.Errorf("journal: %w", err)
+	}
+	if err := snap.Save(snapPath); err != nil {
+		return fmt.Errorf("save cache: %w", err)
+	}
+	fmt.Println(tr(c).Sprintf("%s queued (%s offline)", t.ID, e.Op))
+	return nil
+}
+
+// replayJournal applies changes queued offline now that store is
+// connected, warning about each one the server refused. Entries it could
+// not get to stay queued.
+func replayJournal(ctx context.Context, cfg *conf.Config, store *utask.Store) {
+	path, err := utask.JournalPath(cfg.UI.Profile)
+	if err != nil {
+		return
+	}
+	entries, err := utask.LoadJournal(path)
+	if err != nil {
+		slog.Warn("offline journal unreadable", "op", "replay", "err", err)
+		return
+	}
+	if len(entries) == 0 {
+		return
+	}
+	results := store.Replay(ctx, entries)
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			slog.Warn("offline change not applied", "op", string(r.Entry.Op), "task", r.Entry.ID, "queued", r.Entry.At.Local().Format(time.DateTime), "err", r.Err)
+		}
+	}
+	if err := utask.SaveJournal(path, entries[len(results):]); err != nil {
+		slog.Warn("offline journal not updated", "op", "replay", "err", err)
+	}
+	slog.Info("replayed offline changes", "op", "replay", "applied", len(results)-failed, "failed", failed, "pending", len(entries)-len(results))
+	// The cache holds the offline view; rebuild it from the server.
+	if snapPath, err := utask.SnapshotPath(cfg.UI.Profile); err == nil && len(results) > 0 {
+		_ = (&utask.Snapshot{Profile: cfg.UI.Profile, Tasks: map[string]utask.Task{}}).Save(snapPath)
+	}
+}
+
+// getCached prints a task from the local snapshot for `ut get --offline`.
+func getCached(c *cli.Context, cfg *conf.Config, ref string) error {
+	path, err := utask.SnapshotPath(cfg.UI.Profile)
+	if err != nil {
+		return err
+	}
+	snap, err := utask.LoadSnapshot(path, cfg.UI.Profile)
+	if err != nil {
+		return err
+	}
+	id, err := snap.Resolve(ref)
+	if err != nil {
+		return err
+	}
+	if c.Bool("annotations") {
+		printTaskNotes(c, snap.Tasks[id])
+		return nil
+	}
+	return printTaskRecord(c, snap.Tasks[id])
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/iainlowe/utask/internal/output"
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// outputFormat returns the --output format. Without the flag it is def,
+// or JSON with --verbose, which printed JSON before --output existed.
+func outputFormat(c *cli.Context, def output.Format) (output.Format, error) {
+	if c.IsSet("output") {
+		return output.ParseFormat(c.String("output"))
+	}
+	if c.Bool("verbose") {
+		return output.JSON, nil
+	}
+	return def, nil
+}
+
+// taskWriter writes tasks in the --output format. A table has one line per
+// task with its title and what else a glance needs; the other formats
+// carry the whole text, and JSON the whole task.
+type taskWriter struct {
+	*output.Writer
+	table, fullID bool
+}
+
+var (
+	taskTableColumns  = []string{"id", "status", "created", "tags", "assignee", "title", "details"}
+	taskRecordColumns = []string{"id", "number", "status", "created", "tags", "assignee", "priority", "text"}
+)
+
+func newTaskWriter(c *cli.Context, def output.Format) (*taskWriter, error) {
+	f, err := outputFormat(c, def)
+	if err != nil {
+		return nil, err
+	}
+	columns := taskRecordColumns
+	if f == output.Table {
+		columns = taskTableColumns
+	}
+	return &taskWriter{Writer: output.New(os.Stdout, f, columns...), table: f == output.Table, fullID: c.Bool("full-id")}, nil
+}
+
+// WriteTask writes one task.
+func (w *taskWriter) WriteTask(t utask.Task) error {
+	return w.writeTask(t, t)
+}
+
+// writeTask writes t with v as its JSON value.
+func (w *taskWriter) writeTask(v any, t utask.Task) error {
+	tags := strings.Join(t.Tags, ",")
+	if !w.table {
+		var number string
+		if t.Number != 0 {
+			number = strconv.FormatUint(t.Number, 10)
+		}
+		return w.Write(v, t.ID, number, string(t.State()), t.Created, tags, t.Assignee, strconv.Itoa(t.Priority), t.Text)
+	}
+	ref := t.Ref()
+	if w.fullID {
+		ref = t.ID
+	}
+	return w.Write(v, ref, string(t.State()), t.Created, tags, t.Assignee, t.Short(), taskDetails(t))
+}
+
+// taskDetails sums up what a listing shows of a task beyond its columns.
+func taskDetails(t utask.Task) string {
+	var out []string
+	if t.Private {
+		out = append(out, "private")
+	}
+	if len(t.Contexts) > 0 {
+		out = append(out, strings.Join(t.Contexts, " "))
+	}
+	if t.Due != nil {
+		out = append(out, "due "+utask.FormatDue(*t.Due))
+	}
+	if t.Scheduled != nil {
+		out = append(out, "scheduled "+t.Scheduled.Local().Format("2006-01-02 15:04"))
+	}
+	if len(t.DependsOn) > 0 {
+		deps := make([]string, len(t.DependsOn))
+		for i, id := range t.DependsOn {
+			deps[i] = shortID(id)
+		}
+		out = append(out, "after "+strings.Join(deps, " "))
+	}
+	if t.Recur != "" {
+		out = append(out, "repeats "+t.Recur)
+	}
+	if spent := t.TimeSpent(); spent > 0 {
+		out = append(out, "worked "+utask.FormatDuration(spent))
+	}
+	return strings.Join(out, "; ")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iainlowe/utask/internal/report"
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdPlan forecasts open-task estimates against the weekly capacity up to
+// --until, flagging overcommitted weeks and due dates at risk.
+func cmdPlan(c *cli.Context) error {
+	cfg := getConfig(c)
+	now := time.Now()
+	var until time.Time
+	if u := c.String("until"); strings.Count(u, "-") == 2 {
+		d, err := time.ParseInLocation("2006-01-02", u, now.Location())
+		if err != nil {
+			return fmt.Errorf("invalid --until: %w", err)
+		}
+		until = d
+	} else {
+		d, err := utask.ParseDuration(u)
+		if err != nil {
+			return fmt.Errorf("invalid --until: %w", err)
+		}
+		until = now.Add(d)
+	}
+	if !until.After(now) {
+		return fmt.Errorf("--until must be in the future")
+	}
+	capacity := c.String("capacity")
+	if capacity == "" {
+		capacity = cfg.Plan.WeeklyCapacity
+	}
+	if capacity == "" {
+		return fmt.Errorf("set --capacity or plan.weekly_capacity, e.g. 30h")
+	}
+	weekly, err := utask.ParseDuration(capacity)
+	if err != nil {
+		return fmt.Errorf("invalid capacity: %w", err)
+	}
+	f := utask.ListFilter{Tag: c.String("tag"), Status: utask.StatusOpen}
+	if a := c.String("assignee"); a != "" {
+		if f.Assignee, err = resolveAssignee(cfg, a); err != nil {
+			return err
+		}
+	}
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	it, err := store.ListIter(ctx, f)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	tasks := []utask.Task{}
+	for it.Next() {
+		tasks = append(tasks, it.Task())
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	p := report.BuildPlan(tasks, now, until, weekly)
+	if c.String("format") == "json" {
+		b, _ := json.MarshalIndent(p, "", "  ")
+		fmt.Println(string(b))
+		return nil
+	}
+	fmt.Print(p.Text())
+	return nil
+}
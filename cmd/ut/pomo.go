@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdPomo runs pomodoro sessions on a task: it claims the task, counts down
+// each session, records the time worked on the task (also when interrupted)
+// and asks what to do next.
+func cmdPomo(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return fmt.Errorf("usage: ut pomo <id> [--length 25m]")
+	}
+	length, err := utask.ParseDuration(c.String("length"))
+	if err != nil {
+		return err
+	}
+	pause, err := utask.ParseDuration(c.String("break"))
+	if err != nil {
+		return err
+	}
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	rid, _, err := store.Resolve(ctx, c.Args().First())
+	if err != nil {
+		return err
+	}
+	// Writes after an interrupt still need to land.
+	after := func() (context.Context, context.CancelFunc) {
+		return context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	}
+	in := bufio.NewReader(os.Stdin)
+	for {
+		if _, err := store.ClaimTask(ctx, rid, length+pause+10*time.Minute); err != nil {
+			return err
+		}
+		start := time.Now()
+		countdown(ctx, "work", length)
+		wctx, cancel := after()
+		t, err := store.LogWork(wctx, rid, start, time.Now())
+		cancel()
+		if err != nil {
+			return err
+		}
+		fmt.Println(tr(c).Sprintf("recorded %s on %s (%s in total)", utask.FormatDuration(time.Since(start)), shortID(rid), utask.FormatDuration(t.TimeSpent())))
+		if ctx.Err() != nil {
+			wctx, cancel := after()
+			defer cancel()
+			_ = store.ReleaseClaim(wctx, rid)
+			return ctx.Err()
+		}
+		fmt.Print(tr(c).Sprintf("[c]ontinue, [b]reak, [d]one (close the task) or [q]uit? "))
+		line, _ := in.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "c", "continue":
+		case "b", "break":
+			countdown(ctx, "break", pause)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+		case "d", "done":
+			t, _, err := store.CloseTaskIf(ctx, rid, 0)
+			if err != nil {
+				return err
+			}
+			if t.Done {
+				fmt.Println(tr(c).Sprintf("%s closed", t.ID))
+			} else {
+				fmt.Println(tr(c).Sprintf("%s awaiting review; someone else must run: ut approve %s", t.ID, t.ID))
+			}
+			return nil
+		default:
+			return store.ReleaseClaim(ctx, rid)
+		}
+	}
+}
+
+// countdown redraws the time left on one terminal line until d has passed
+// or ctx is done, then rings the bell.
+func countdown(ctx context.Context, label string, d time.Duration) {
+	end := time.Now().Add(d)
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	for {
+		left := time.Until(end)
+		if left <= 0 {
+			fmt.Printf("\r%s: 00:00 \a\n", label)
+			return
+		}
+		fmt.Printf("\r%s: %02d:%02d ", label, int(left/time.Minute), int(left%time.Minute/time.Second))
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			return
+		case <-tick.C:
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	conf "github.com/iainlowe/utask/internal/config"
+	cli "github.com/urfave/cli/v2"
+)
+
+// configPath is the file the run's config came from, which ut profile edits.
+func configPath(c *cli.Context) string {
+	p, _ := c.App.Metadata[configPathMetaKey].(string)
+	return p
+}
+
+// cmdProfileList prints the configured profiles, marking the one in use.
+func cmdProfileList(c *cli.Context) error {
+	cfg := getConfig(c)
+	if c.Bool("verbose") {
+		b, _ := json.MarshalIndent(map[string]any{"active": cfg.UI.Profile, "profiles": cfg.Profiles}, "", "  ")
+		fmt.Println(string(b))
+		return nil
+	}
+	names := make([]string, 0, len(cfg.Profiles)+1)
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	if _, ok := cfg.Profiles[cfg.UI.Profile]; !ok {
+		names = append(names, cfg.UI.Profile)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		p := cfg.Profiles[name]
+		mark := " "
+		if name == cfg.UI.Profile {
+			mark = "*"
+		}
+		url, tags, prio := p.NATSURL, strings.Join(p.Tags, ","), "-"
+		if url == "" {
+			url = "-"
+		}
+		if tags == "" {
+			tags = "-"
+		}
+		if p.Priority != 0 {
+			prio = strconv.Itoa(p.Priority)
+		}
+		fmt.Printf("%s %s\t%s\t%s\t%s\n", mark, name, url, tags, prio)
+	}
+	return nil
+}
+
+// cmdProfileCreate adds a named profile to the config file.
+func cmdProfileCreate(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("usage: ut profile create [--url nats://host:4222] [--tag t] [--priority n] [--use] <name>")
+	}
+	name := c.Args().First()
+	if _, ok := getConfig(c).Profiles[name]; ok {
+		return fmt.Errorf("profile %s already exists", name)
+	}
+	p := conf.Profile{NATSURL: c.String("url"), Tags: c.StringSlice("tag"), Priority: c.Int("priority")}
+	if err := conf.SaveProfile(configPath(c), name, p); err != nil {
+		return err
+	}
+	fmt.Println(tr(c).Sprintf("profile %s created", name))
+	if c.Bool("use") {
+		return useProfile(c, name)
+	}
+	return nil
+}
+
+// cmdProfileUse makes a profile the default for later commands.
+func cmdProfileUse(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("usage: ut profile use <name>")
+	}
+	name := c.Args().First()
+	if _, ok := getConfig(c).Profiles[name]; !ok && name != "default" {
+		return fmt.Errorf("no profile %s; add it with ut profile create %s", name, name)
+	}
+	return useProfile(c, name)
+}
+
+func useProfile(c *cli.Context, name string) error {
+	if err := conf.SetDefaultProfile(configPath(c), name); err != nil {
+		return err
+	}
+	fmt.Println(tr(c).Sprintf("now using profile %s", name))
+	return nil
+}
+
+// cmdProfileDelete removes a profile from the config file. Its tasks stay
+// on the server; ut purge removes those.
+func cmdProfileDelete(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("usage: ut profile delete <name>")
+	}
+	name := c.Args().First()
+	if name == getConfig(c).UI.Profile {
+		return fmt.Errorf("profile %s is in use; switch with ut profile use first", name)
+	}
+	if err := conf.DeleteProfile(configPath(c), name); err != nil {
+		return err
+	}
+	fmt.Println(tr(c).Sprintf("profile %s deleted; its tasks remain until ut purge --profile %s", name, name))
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// profiling holds the outputs opened by startProfiling.
+var profiling struct {
+	cpu, trace *os.File
+	memPath    string
+}
+
+// startProfiling honours --cpuprofile, --memprofile and --trace for the
+// duration of the command. stopProfiling flushes them.
+func startProfiling(c *cli.Context) error {
+	if p := c.String("cpuprofile"); p != "" {
+		f, err := os.Create(p)
+		if err != nil {
+			return fmt.Errorf("cpuprofile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return fmt.Errorf("cpuprofile: %w", err)
+		}
+		profiling.cpu = f
+	}
+	if p := c.String("trace"); p != "" {
+		f, err := os.Create(p)
+		if err != nil {
+			return fmt.Errorf("trace: %w", err)
+		}
+		if err := trace.Start(f); err != nil {
+			f.Close()
+			return fmt.Errorf("trace: %w", err)
+		}
+		profiling.trace = f
+	}
+	profiling.memPath = c.String("memprofile")
+	return nil
+}
+
+func stopProfiling() error {
+	if profiling.cpu != nil {
+		pprof.StopCPUProfile()
+		profiling.cpu.Close()
+		profiling.cpu = nil
+	}
+	if profiling.trace != nil {
+		trace.Stop()
+		profiling.trace.Close()
+		profiling.trace = nil
+	}
+	if profiling.memPath != "" {
+		f, err := os.Create(profiling.memPath)
+		if err != nil {
+			return fmt.Errorf("memprofile: %w", err)
+		}
+		defer f.Close()
+		runtime.GC() // report live heap, not garbage
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			return fmt.Errorf("memprofile: %w", err)
+		}
+		profiling.memPath = ""
+	}
+	return nil
+}
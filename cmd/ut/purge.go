@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdPurge deletes a profile from the server and this machine. Unless
+// --yes is given it offers to export the tasks first and asks for the
+// profile name as confirmation. Without --profile it empties the trash.
+func cmdPurge(c *cli.Context) error {
+	if !c.IsSet("profile") {
+		return cmdPurgeTrash(c)
+	}
+	if c.IsSet("older-than") {
+		return fmt.Errorf("--older-than purges the trash; it doesn't apply with --profile")
+	}
+	cfg := *getConfig(c)
+	// The profile may live on its own server.
+	cfg.UseProfile(c.String("profile"))
+	if c.IsSet("nats-url") {
+		cfg.NATS.URL = c.String("nats-url")
+	}
+	ctx := c.Context
+	// Connect directly: the daemon's store and the offline journal belong
+	// to the profile being removed.
+	opts, err := storeOptions(&cfg)
+	if err != nil {
+		return err
+	}
+	store, err := openDriver(ctx, &cfg, opts)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	export := c.String("export")
+	if !c.Bool("yes") {
+		in := bufio.NewReader(os.Stdin)
+		if export == "" {
+			def := fmt.Sprintf("%s-%s.jsonl", cfg.UI.Profile, time.Now().Format("20060102"))
+			fmt.Print(tr(c).Sprintf("export tasks to %s first? [Y/n/other path] ", def))
+			line, _ := in.ReadString('\n')
+			answer := strings.TrimSpace(line)
+			switch yes, ok := tr(c).YesNo(answer); {
+			case answer == "" || yes:
+				export = def
+			case !ok:
+				export = answer
+			}
+		}
+		if err := exportTo(c, store, export); err != nil {
+			return err
+		}
+		fmt.Print(tr(c).Sprintf("this permanently deletes profile %s, its history and its local cache; type the profile name to confirm: ", cfg.UI.Profile))
+		line, _ := in.ReadString('\n')
+		if strings.TrimSpace(line) != cfg.UI.Profile {
+			return errors.New("purge cancelled")
+		}
+	} else if err := exportTo(c, store, export); err != nil {
+		return err
+	}
+	removed, err := store.Purge(ctx)
+	if c.Bool("verbose") {
+		for _, name := range removed {
+			fmt.Println(name)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	for _, pathFor := range []func(string) (string, error){utask.SnapshotPath, utask.JournalPath} {
+		p, err := pathFor(cfg.UI.Profile)
+		if err != nil {
+			continue
+		}
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	fmt.Println(tr(c).Sprintf("profile %s purged", cfg.UI.Profile))
+	return nil
+}
+
+// exportTo writes every task to a new JSONL file at path; an empty path
+// skips the export. It won't overwrite an existing file.
+func exportTo(c *cli.Context, store *utask.Store, path string) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	n, err := store.ExportJSONL(c.Context, w, false)
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	fmt.Println(tr(c).Sprintf("exported %d tasks to %s", n, path))
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdRandom suggests one open task for "just pick something" moments,
+// skipping blocked and snoozed tasks, tasks waiting on open dependencies,
+// tasks awaiting review and tasks someone else has started, within the
+// active context.
+func cmdRandom(c *cli.Context) error {
+	cfg := getConfig(c)
+	ctxName, err := activeContext(c)
+	if err != nil {
+		return err
+	}
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	me, _ := resolveAssignee(cfg, "me")
+	claims, err := store.Claims(ctx)
+	if err != nil {
+		return err
+	}
+	taken := map[string]bool{}
+	for _, cl := range claims {
+		if !utask.SameIdentity(cl.By, me) {
+			taken[cl.Task] = true
+		}
+	}
+	it, err := store.ListIter(ctx, utask.ListFilter{Tag: c.String("tag"), Status: utask.StatusOpen, Ready: true, Context: ctxName})
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	tasks := []utask.Task{}
+	for it.Next() {
+		if t := it.Task(); !taken[t.ID] {
+			tasks = append(tasks, t)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	t, ok := utask.PickRandom(tasks, c.Bool("weighted"), time.Now(), rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())))
+	if !ok {
+		return errors.New(tr(c).Sprintf("no open tasks to pick from"))
+	}
+	if err := printTasks(c, []utask.Task{t}); err != nil {
+		return err
+	}
+	if !c.Bool("start") {
+		return nil
+	}
+	cl, err := store.ClaimTask(ctx, t.ID, 0)
+	if err != nil {
+		return err
+	}
+	fmt.Println(tr(c).Sprintf("started, claimed until %s", cl.Expires.Local().Format("15:04")))
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureUT is runUT returning what the command printed.
+func captureUT(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	out := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- string(b)
+	}()
+	runUT(t, dir, args...)
+	w.Close()
+	return <-out
+}
+
+func TestCmdRandomSkipsBlocked(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("UTASK_BACKEND", "sqlite")
+	runUT(t, dir, "create", "--title", "pour the foundation")
+	runUT(t, dir, "create", "--title", "build the walls", "--depends-on", "T-1")
+	runUT(t, dir, "create", "--title", "paint the fence")
+	runUT(t, dir, "block", "T-3")
+	for range 20 {
+		if got := captureUT(t, dir, "random"); !strings.Contains(got, "pour the foundation") {
+			t.Fatalf("ut random picked a blocked task:\n%s", got)
+		}
+	}
+}
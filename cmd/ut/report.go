@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	conf "github.com/iainlowe/utask/internal/config"
+	"github.com/iainlowe/utask/internal/notify"
+	"github.com/iainlowe/utask/internal/output"
+	"github.com/iainlowe/utask/internal/report"
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// reportSince resolves --since: a duration back from now, a date, or
+// "sprint-start" using the report: config.
+func reportSince(cfg *conf.Config, since string, now time.Time) (time.Time, error) {
+	switch {
+	case since == "sprint-start":
+		if cfg.Report.SprintStart == "" || cfg.Report.SprintLength == "" {
+			return time.Time{}, fmt.Errorf("--since sprint-start needs report.sprint_start and report.sprint_length")
+		}
+		anchor, err := time.ParseInLocation("2006-01-02", cfg.Report.SprintStart, now.Location())
+		if err != nil {
+			return time.Time{}, fmt.Errorf("report.sprint_start: %w", err)
+		}
+		length, err := utask.ParseDuration(cfg.Report.SprintLength)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("report.sprint_length: %w", err)
+		}
+		return report.SprintStart(anchor, length, now)
+	case strings.Count(since, "-") == 2:
+		return time.ParseInLocation("2006-01-02", since, now.Location())
+	}
+	d, err := utask.ParseDuration(since)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return now.Add(-d), nil
+}
+
+// reportData loads every task and the events since the window start.
+func reportData(ctx context.Context, store *utask.Store, since time.Time) ([]utask.Task, []utask.Activity, error) {
+	tasks := []utask.Task{}
+	if err := store.ForEach(ctx, func(t utask.Task) error {
+		tasks = append(tasks, t)
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+	events, err := store.Audit(ctx, utask.AuditFilter{Since: since})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(events) == 0 {
+		// Profiles without an audit trail yet: fall back to close times
+		// derived from each task's last write.
+		if events, err = store.RecentActivity(ctx, since, 0); err != nil {
+			return nil, nil, err
+		}
+	}
+	return tasks, events, nil
+}
+
+func cmdReportTeam(c *cli.Context) error {
+	format := c.String("format")
+	switch format {
+	case "text", "csv", "json":
+	default:
+		return fmt.Errorf("invalid --format: %s", format)
+	}
+	cfg := getConfig(c)
+	now := time.Now()
+	since, err := reportSince(cfg, c.String("since"), now)
+	if err != nil {
+		return err
+	}
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	tasks, events, err := reportData(ctx, store, since)
+	if err != nil {
+		return err
+	}
+	rows := report.Team(tasks, events, since, now)
+	switch format {
+	case "csv":
+		return report.WriteTeamCSV(os.Stdout, rows)
+	case "json":
+		b, _ := json.MarshalIndent(map[string]any{"since": since.UTC(), "rows": rows}, "", "  ")
+		fmt.Println(string(b))
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "ASSIGNEE\tOPEN\tCLOSED\tESTIMATE\tOVERDUE\tWORKED\n")
+	for _, r := range rows {
+		est := "-"
+		if r.EstimateMinutes > 0 {
+			est = utask.FormatDuration(time.Duration(r.EstimateMinutes) * time.Minute)
+		}
+		worked := "-"
+		if r.WorkedMinutes > 0 {
+			worked = utask.FormatDuration(time.Duration(r.WorkedMinutes) * time.Minute)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%d\t%s\n", r.Assignee, r.Open, r.Closed, est, r.Overdue, worked)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("closed and worked since %s\n", since.Format("2006-01-02 15:04"))
+	return nil
+}
+
+// cmdReportFlow prints created-vs-closed counts per day, the average
+// cycle time and per-tag throughput for the --window. --output json gives
+// the whole report as one value, csv and tsv the daily counts.
+func cmdReportFlow(c *cli.Context) error {
+	if c.NArg() > 0 {
+		return fmt.Errorf("unknown report %q (want team, digest, or flags for the throughput report)", c.Args().First())
+	}
+	f, err := outputFormat(c, output.Table)
+	if err != nil {
+		return err
+	}
+	cfg := getConfig(c)
+	now := time.Now()
+	since, err := reportSince(cfg, c.String("window"), now)
+	if err != nil {
+		return err
+	}
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	tasks, events, err := reportData(ctx, store, since)
+	if err != nil {
+		return err
+	}
+	flow := report.BuildFlow(tasks, events, strings.ToLower(c.String("tag")), since, now)
+	switch f {
+	case output.JSON:
+		w := output.New(os.Stdout, f)
+		if err := w.Write(flow); err != nil {
+			return err
+		}
+		return w.Flush()
+	case output.CSV, output.TSV:
+		w := output.New(os.Stdout, f, "date", "created", "closed")
+		for _, d := range flow.Days {
+			if err := w.Write(d, d.Date, strconv.Itoa(d.Created), strconv.Itoa(d.Closed)); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	}
+	cycle := "-"
+	if flow.AvgCycleSeconds > 0 {
+		cycle = utask.FormatDuration(flow.AvgCycleTime())
+	}
+	fmt.Printf("since %s: %d created, %d closed, average cycle time %s\n", since.Format("2006-01-02 15:04"), flow.Created, flow.Closed, cycle)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "\nDATE\tCREATED\tCLOSED\n")
+	for _, d := range flow.Days {
+		fmt.Fprintf(tw, "%s\t%d\t%d\n", d.Date, d.Created, d.Closed)
+	}
+	if len(flow.Tags) > 0 {
+		fmt.Fprintf(tw, "\nTAG\tCREATED\tCLOSED\n")
+		for _, t := range flow.Tags {
+			fmt.Fprintf(tw, "%s\t%d\t%d\n", t.Tag, t.Created, t.Closed)
+		}
+	}
+	return tw.Flush()
+}
+
+// buildDigest renders the backlog digest for the window named by since.
+func buildDigest(ctx context.Context, cfg *conf.Config, store *utask.Store, since string) (report.Digest, error) {
+	now := time.Now()
+	if since == "" {
+		since = "7d"
+	}
+	from, err := reportSince(cfg, since, now)
+	if err != nil {
+		return report.Digest{}, err
+	}
+	tasks, events, err := reportData(ctx, store, from)
+	if err != nil {
+		return report.Digest{}, err
+	}
+	return report.BuildDigest(tasks, events, from, now), nil
+}
+
+func cmdReportDigest(c *cli.Context) error {
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	d, err := buildDigest(ctx, cfg, store, c.String("since"))
+	if err != nil {
+		return err
+	}
+	if c.Bool("send") {
+		return sendScheduledReports(ctx, cfg, notify.Report{Subject: d.Subject(), Text: d.Text()})
+	}
+	if c.String("format") == "json" {
+		b, _ := json.MarshalIndent(d, "", "  ")
+		fmt.Println(string(b))
+		return nil
+	}
+	fmt.Println(d.Subject())
+	fmt.Print(d.Text())
+	return nil
+}
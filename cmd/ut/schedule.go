@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	conf "github.com/iainlowe/utask/internal/config"
+	"github.com/iainlowe/utask/internal/notify"
+	"github.com/iainlowe/utask/internal/report"
+	"github.com/iainlowe/utask/internal/schedule"
+)
+
+// scheduledReport is a report.schedule entry ready to run.
+type scheduledReport struct {
+	conf.ReportSchedule
+	cron    *schedule.Cron
+	senders []notify.ReportSender
+}
+
+// scheduledReports validates report.schedule and builds each entry's
+// senders.
+func scheduledReports(cfg *conf.Config) ([]scheduledReport, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	out := make([]scheduledReport, 0, len(cfg.Report.Schedule))
+	for i, rs := range cfg.Report.Schedule {
+		c, err := schedule.Parse(rs.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("report.schedule[%d]: %w", i, err)
+		}
+		r := scheduledReport{ReportSchedule: rs, cron: c}
+		if rs.Webhook != "" {
+			r.senders = append(r.senders, &notify.Webhook{URL: rs.Webhook, Client: client})
+		}
+		if rs.Slack != "" {
+			r.senders = append(r.senders, &notify.Slack{URL: rs.Slack, Client: client})
+		}
+		if rs.Email != "" {
+			smtpCfg := cfg.Notify.SMTP
+			if smtpCfg.Addr == "" {
+				return nil, fmt.Errorf("report.schedule[%d]: email needs notify.smtp.addr", i)
+			}
+			r.senders = append(r.senders, &notify.Mail{Addr: smtpCfg.Addr, From: smtpCfg.From, To: rs.Email, Auth: smtpAuth(cfg)})
+		}
+		if len(r.senders) == 0 {
+			return nil, fmt.Errorf("report.schedule[%d]: set email, webhook or slack", i)
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func (r scheduledReport) send(ctx context.Context, rep notify.Report) error {
+	var errs []error
+	for _, s := range r.senders {
+		if err := s.SendReport(ctx, rep); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sendScheduledReports delivers rep to every report.schedule destination
+// now, for `ut report digest --send`.
+func sendScheduledReports(ctx context.Context, cfg *conf.Config, rep notify.Report) error {
+	reports, err := scheduledReports(cfg)
+	if err != nil {
+		return err
+	}
+	if len(reports) == 0 {
+		return fmt.Errorf("no report.schedule entries to send to")
+	}
+	var errs []error
+	for _, r := range reports {
+		errs = append(errs, r.send(ctx, rep))
+	}
+	return errors.Join(errs...)
+}
+
+// runSchedule sends r's digest each time its cron expression fires, until
+// the daemon stops.
+func (d *daemonState) runSchedule(cfg *conf.Config, r scheduledReport) {
+	for {
+		next := r.cron.Next(time.Now())
+		if next.IsZero() {
+			slog.Warn("report schedule never fires", "op", "report", "cron", r.Cron)
+			return
+		}
+		t := time.NewTimer(time.Until(next))
+		select {
+		case <-d.ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		store, err := d.store(cfg)
+		if err == nil {
+			var dg report.Digest
+			if dg, err = buildDigest(d.ctx, cfg, store, r.Since); err == nil {
+				err = r.send(d.ctx, notify.Report{Subject: dg.Subject(), Text: dg.Text()})
+			}
+		}
+		if err != nil {
+			slog.Error("scheduled report failed", "op", "report", "cron", r.Cron, "err", err)
+			continue
+		}
+		slog.Info("sent scheduled report", "op", "report", "cron", r.Cron)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	conf "github.com/iainlowe/utask/internal/config"
+	"github.com/iainlowe/utask/internal/grpcapi"
+	"github.com/iainlowe/utask/internal/httpapi"
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+func cmdServe(c *cli.Context) error {
+	cfg := getConfig(c)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	tokens, err := tokenGrants(cfg.Access.Tokens)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              c.String("addr"),
+		Handler:           httpapi.New(store, httpapi.Options{Profile: cfg.UI.Profile, Inbound: cfg.Inbound, Tokens: tokens, Hosts: serveHosts(c.String("addr"), c.StringSlice("allow-host"))}),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	errc := make(chan error, 2)
+	go func() { errc <- srv.ListenAndServe() }()
+	slog.Info("serving", "addr", srv.Addr, "profile", cfg.UI.Profile, "role", store.Role(ctx), "tokens", len(tokens))
+	if addr := c.String("grpc"); addr != "" {
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("serve grpc: %w", err)
+		}
+		gs := grpcapi.New(store, tokens)
+		go func() { errc <- gs.Serve(lis) }()
+		// Streams such as Watch only end with their caller, so give them
+		// the HTTP shutdown's grace period and then cut them off.
+		defer func() {
+			done := make(chan struct{})
+			go func() { gs.GracefulStop(); close(done) }()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				gs.Stop()
+			}
+		}()
+		slog.Info("serving grpc", "addr", lis.Addr().String())
+	}
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("serve: %w", err)
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// tokenGrants parses the access.tokens config.
+func tokenGrants(in map[string]conf.TokenGrant) (map[string]httpapi.Grant, error) {
+	out := make(map[string]httpapi.Grant, len(in))
+	for tok, g := range in {
+		role, err := utask.ParseRole(g.Role)
+		if err != nil {
+			return nil, fmt.Errorf("access.tokens: %w", err)
+		}
+		out[tok] = httpapi.Grant{Role: role, Identity: g.Identity}
+	}
+	return out, nil
+}
+
+// serveHosts lists the host names `ut serve` answers to besides localhost:
+// the address it binds, or for a wildcard address the machine's name and
+// interface addresses, plus extra.
+func serveHosts(addr string, extra []string) []string {
+	hosts := append([]string{}, extra...)
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	if ip := net.ParseIP(host); host != "" && (ip == nil || !ip.IsUnspecified()) {
+		return append(hosts, host)
+	}
+	if name, err := os.Hostname(); err == nil {
+		hosts = append(hosts, name)
+	}
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, a := range addrs {
+			if n, ok := a.(*net.IPNet); ok {
+				hosts = append(hosts, n.IP.String())
+			}
+		}
+	}
+	return hosts
+}
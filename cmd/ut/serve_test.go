@@ -0,0 +1,25 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestServeHosts(t *testing.T) {
+	if got := serveHosts("127.0.0.1:8385", nil); !slices.Equal(got, []string{"127.0.0.1"}) {
+		t.Fatalf("loopback bind = %q", got)
+	}
+	if got := serveHosts("tasks.lan:80", []string{"proxy.example"}); !slices.Equal(got, []string{"proxy.example", "tasks.lan"}) {
+		t.Fatalf("named bind = %q", got)
+	}
+	name, err := os.Hostname()
+	if err != nil {
+		t.Skip(err)
+	}
+	for _, addr := range []string{":8385", "0.0.0.0:8385", "[::]:8385"} {
+		if got := serveHosts(addr, nil); !slices.Contains(got, name) {
+			t.Errorf("wildcard bind %s = %q, want the host name %q", addr, got, name)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdSnooze snoozes the given tasks, or every open task matching --tag,
+// --tags or --assignee when no ids are given.
+func cmdSnooze(c *cli.Context) error {
+	var until time.Time
+	if u := c.String("until"); u != "none" {
+		var err error
+		if until, err = utask.ParseSnooze(u, time.Now()); err != nil {
+			return err
+		}
+	}
+	cfg := getConfig(c)
+	f := utask.ListFilter{Tag: c.String("tag"), Any: parseCSVTags(c.String("tags")), Status: utask.StatusOpen}
+	switch a := c.String("assignee"); a {
+	case "":
+	case "none":
+		f.Unassigned = true
+	default:
+		who, err := resolveAssignee(cfg, a)
+		if err != nil {
+			return err
+		}
+		f.Assignee = who
+	}
+	bulk := f.Tag != "" || len(f.Any) > 0 || f.Assignee != "" || f.Unassigned
+	if c.NArg() == 0 && !bulk {
+		return fmt.Errorf("usage: ut snooze <id...> --until <when>, or ut snooze --tag|--tags|--assignee ... --until <when>")
+	}
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	var ids []string
+	for _, arg := range c.Args().Slice() {
+		rid, _, err := store.Resolve(ctx, arg)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, rid)
+	}
+	if c.NArg() == 0 {
+		it, err := store.ListIter(ctx, f)
+		if err != nil {
+			return err
+		}
+		for it.Next() {
+			ids = append(ids, it.Task().ID)
+		}
+		it.Close()
+		if err := it.Err(); err != nil {
+			return err
+		}
+	}
+	for _, id := range ids {
+		t, err := store.SnoozeTask(ctx, id, until, 0)
+		if err != nil {
+			return fmt.Errorf("%s: %w", shortID(id), err)
+		}
+		switch {
+		case c.Bool("verbose"):
+			b, _ := json.MarshalIndent(t, "", "  ")
+			fmt.Println(string(b))
+		case until.IsZero():
+			fmt.Println(tr(c).Sprintf("%s awake", t.ID))
+		default:
+			fmt.Println(tr(c).Sprintf("%s snoozed until %s", t.ID, tr(c).DateTime(until)))
+		}
+	}
+	return nil
+}
+
+// listSnoozed prints snoozed tasks matching f, soonest to return first.
+func listSnoozed(c *cli.Context, f utask.ListFilter) error {
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	f.Snoozed, f.HideSnoozed = true, false
+	it, err := store.ListIter(ctx, f)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	tasks := []utask.Task{}
+	for it.Next() {
+		tasks = append(tasks, it.Task())
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].SnoozedUntil.Before(*tasks[j].SnoozedUntil) })
+	if c.Bool("verbose") {
+		return printTasks(c, tasks)
+	}
+	now := time.Now()
+	for _, t := range tasks {
+		when := tr(c).Sprintf("%s (in %s)", tr(c).DateTime(*t.SnoozedUntil), utask.FormatDuration(t.SnoozedUntil.Sub(now)))
+		fmt.Printf("%s\t%s\t%s\n", shortID(t.ID), when, t.Short())
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdCancel closes a task without doing it.
+func cmdCancel(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return fmt.Errorf("usage: ut cancel <id>")
+	}
+	return setStatus(c, utask.StatusCancelled)
+}
+
+// setStatus moves the task named by the first argument to st and prints
+// the result.
+func setStatus(c *cli.Context, st utask.Status) error {
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	rid, _, err := store.Resolve(ctx, c.Args().First())
+	if err != nil {
+		return err
+	}
+	t, changed, err := store.SetStatus(ctx, rid, st, c.Uint64("if-revision"))
+	if err != nil {
+		return err
+	}
+	switch {
+	case c.Bool("verbose"):
+		b, _ := json.MarshalIndent(t, "", "  ")
+		fmt.Println(string(b))
+	case changed:
+		fmt.Println(tr(c).Sprintf("%s is now %s", t.ID, t.State()))
+	default:
+		fmt.Println(tr(c).Sprintf("%s already %s", t.ID, t.State()))
+	}
+	return nil
+}
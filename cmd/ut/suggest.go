@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	conf "github.com/iainlowe/utask/internal/config"
+	"github.com/iainlowe/utask/internal/llm"
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// suggestTagsFlag is shared by create and update.
+var suggestTagsFlag = &cli.BoolFlag{Name: "suggest-tags", Usage: "ask the OpenAI model for tags and confirm them before saving (default: config openai.auto_tag)"}
+
+// wantsSuggest reports whether args ask for --suggest-tags, which prompts on
+// the client's terminal and so can't run inside the daemon.
+func wantsSuggest(args []string) bool {
+	for _, a := range args {
+		if a == "--suggest-tags" || a == "--suggest-tags=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// canPrompt reports whether the command can ask the user something: it
+// runs in the user's process, not the daemon, and stdin is a terminal.
+func canPrompt() bool {
+	if warm != nil {
+		return false
+	}
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// suggestEnabled reports whether --suggest-tags, or openai.auto_tag when
+// the flag isn't given, asks for tag suggestions.
+func suggestEnabled(c *cli.Context, cfg *conf.Config) bool {
+	if c.IsSet("suggest-tags") {
+		return c.Bool("suggest-tags")
+	}
+	return cfg.OpenAI.AutoTag
+}
+
+// suggestTags asks the configured model for tags that fit text and aren't
+// in have yet, and returns the ones the user accepts; see suggestEnabled.
+// A failed suggestion is logged rather than failing the write, and without
+// a terminal to confirm on the suggestions are only printed.
+func suggestTags(c *cli.Context, cfg *conf.Config, store *utask.Store, text string, have []string) ([]string, error) {
+	if !suggestEnabled(c, cfg) {
+		return nil, nil
+	}
+	if cfg.OpenAI.APIKey == "" {
+		if c.IsSet("suggest-tags") {
+			return nil, errors.New("--suggest-tags needs openai.api_key or $OPENAI_API_KEY")
+		}
+		slog.Warn("openai.auto_tag is set but no API key is configured; not suggesting tags")
+		return nil, nil
+	}
+	ctx := c.Context
+	counts, err := store.ListTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	vocab := make([]string, 0, len(counts))
+	for tag := range counts {
+		vocab = append(vocab, tag)
+	}
+	sort.Slice(vocab, func(i, j int) bool {
+		if counts[vocab[i]] != counts[vocab[j]] {
+			return counts[vocab[i]] > counts[vocab[j]]
+		}
+		return vocab[i] < vocab[j]
+	})
+	client := &llm.Client{APIKey: cfg.OpenAI.APIKey, Model: cfg.OpenAI.Model}
+	suggested, err := client.SuggestTags(ctx, text, vocab)
+	if err != nil {
+		slog.Warn("tag suggestion failed", "err", err)
+		return nil, nil
+	}
+	var fresh []string
+	for _, tag := range suggested {
+		if !containsTag(have, tag) {
+			fresh = append(fresh, tag)
+		}
+	}
+	if len(fresh) == 0 {
+		return nil, nil
+	}
+	if !canPrompt() {
+		fmt.Fprintln(os.Stderr, tr(c).Sprintf("suggested tags (not added without a terminal to confirm): %s", strings.Join(fresh, ", ")))
+		return nil, nil
+	}
+	fmt.Fprint(os.Stderr, tr(c).Sprintf("suggested tags: %s; add them? [Y/n/other tags] ", strings.Join(fresh, ", ")))
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer := strings.TrimSpace(line)
+	switch yes, ok := tr(c).YesNo(answer); {
+	case answer == "" || yes:
+		return fresh, nil
+	case ok:
+		return nil, nil
+	default:
+		return parseCSVTags(answer), nil
+	}
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(strings.TrimSpace(t), tag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdCount prints how many tasks ut list would show for the same filters.
+func cmdCount(c *cli.Context) error {
+	f, err := listFilter(c)
+	if err != nil {
+		return err
+	}
+	if f.Context, err = activeContext(c); err != nil {
+		return err
+	}
+	if err := applyFilterContext(c, &f); err != nil {
+		return err
+	}
+	if err := dueFilter(c, &f); err != nil {
+		return err
+	}
+	if err := changedFilter(c, &f); err != nil {
+		return err
+	}
+	f.HideSnoozed = true
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	n, err := store.Count(ctx, f)
+	if err != nil {
+		return err
+	}
+	fmt.Println(n)
+	return nil
+}
+
+// cmdSummary prints task totals, a per-tag breakdown and recent activity.
+func cmdSummary(c *cli.Context) error {
+	cfg := getConfig(c)
+	now := time.Now()
+	since, err := reportSince(cfg, c.String("since"), now)
+	if err != nil {
+		return err
+	}
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	sum, err := store.Summarize(ctx, since, now)
+	if err != nil {
+		return err
+	}
+	if c.Bool("verbose") {
+		b, _ := json.MarshalIndent(sum, "", "  ")
+		fmt.Println(string(b))
+		return nil
+	}
+	p := tr(c)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\t%d\n", p.Sprintf("open"), sum.Open)
+	fmt.Fprintf(w, "%s\t%d\n", p.Sprintf("closed"), sum.Closed)
+	fmt.Fprintf(w, "%s\t%d\n", p.Sprintf("overdue"), sum.Overdue)
+	fmt.Fprintf(w, "%s\t%d\n", p.Sprintf("created since %s", p.Date(since)), sum.CreatedSince)
+	fmt.Fprintf(w, "%s\t%d\n", p.Sprintf("closed since %s", p.Date(since)), sum.ClosedSince)
+	if len(sum.Tags) > 0 {
+		fmt.Fprintln(w, "\nTAG\tOPEN\tCLOSED")
+		for _, ts := range sum.Tags {
+			fmt.Fprintf(w, "%s\t%d\t%d\n", ts.Tag, ts.Open, ts.Closed)
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/iainlowe/utask/internal/ghsync"
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdSyncGithub syncs the repository's issues carrying --label with the
+// tasks tagged with it, both ways (see ghsync).
+func cmdSyncGithub(c *cli.Context) error {
+	repo := strings.Trim(c.String("repo"), "/")
+	if owner, name, ok := strings.Cut(repo, "/"); !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("--repo %q: want owner/name", c.String("repo"))
+	}
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	gh := &ghsync.Client{Base: c.String("api"), Token: c.String("token"), Repo: repo}
+	res, err := ghsync.Sync(ctx, store, gh, c.String("label"))
+	if c.Bool("verbose") {
+		b, _ := json.MarshalIndent(res, "", "  ")
+		fmt.Println(string(b))
+	} else if res.Pulled+res.Pushed > 0 || err == nil {
+		fmt.Println(tr(c).Sprintf("pulled %d, pushed %d, %d conflicts", res.Pulled, res.Pushed, res.Conflicts))
+	}
+	return err
+}
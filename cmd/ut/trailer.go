@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdTrailer lists a task's trailers, or adds or removes one:
+// `ut trailer <id> add "Refs: #123"`, `ut trailer <id> rm Refs`.
+func cmdTrailer(c *cli.Context) error {
+	const usage = `usage: ut trailer <id> [add "Key: Value" | rm Key]`
+	args := c.Args().Slice()
+	if len(args) != 1 && len(args) < 3 {
+		return fmt.Errorf(usage)
+	}
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	rid, _, err := store.Resolve(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	var (
+		t   utask.Task
+		msg string
+	)
+	switch {
+	case len(args) == 1:
+		if t, _, err = store.GetTask(ctx, rid); err != nil {
+			return err
+		}
+	case args[1] == "add":
+		line, err := utask.ParseTrailerLine(strings.Join(args[2:], " "))
+		if err != nil {
+			return err
+		}
+		if t, err = store.AddTrailer(ctx, rid, line); err != nil {
+			return err
+		}
+		msg = tr(c).Sprintf("%s updated", t.ID)
+	case args[1] == "rm" || args[1] == "remove":
+		if len(args) != 3 {
+			return fmt.Errorf(usage)
+		}
+		var changed bool
+		if t, changed, err = store.RemoveTrailer(ctx, rid, args[2]); err != nil {
+			return err
+		}
+		msg = tr(c).Sprintf("%s updated", t.ID)
+		if !changed {
+			msg = tr(c).Sprintf("%s has no %s trailer", t.Ref(), args[2])
+		}
+	default:
+		return fmt.Errorf(usage)
+	}
+	switch {
+	case c.Bool("verbose"):
+		b, _ := json.MarshalIndent(t, "", "  ")
+		fmt.Println(string(b))
+	case msg != "":
+		fmt.Println(msg)
+	default:
+		for _, line := range t.Trailers() {
+			fmt.Printf("%s: %s\n", line.Key, line.Value)
+		}
+	}
+	return nil
+}
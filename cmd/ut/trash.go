@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdTrashList prints the deleted tasks waiting in the trash, most
+// recently deleted first.
+func cmdTrashList(c *cli.Context) error {
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	trash, err := store.ListTrash(ctx)
+	if err != nil {
+		return err
+	}
+	if c.Bool("verbose") {
+		b, _ := json.MarshalIndent(trash, "", "  ")
+		fmt.Println(string(b))
+		return nil
+	}
+	for _, tt := range trash {
+		title, _, _ := strings.Cut(tt.Task.Text, "\n")
+		fmt.Printf("%s\t%s\t%s\t%s\n", tt.Task.Ref(), tt.DeletedAt.Local().Format(time.DateTime), orDash(tt.DeletedBy), title)
+	}
+	return nil
+}
+
+// cmdRestore brings a task back from the trash.
+func cmdRestore(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("usage: ut restore <id>")
+	}
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	t, err := store.RestoreTask(ctx, c.Args().First())
+	if err != nil {
+		return err
+	}
+	fmt.Println(tr(c).Sprintf("%s restored", t.Ref()))
+	return nil
+}
+
+// cmdPurgeTrash permanently deletes what has been in the trash for
+// --older-than, or everything in it.
+func cmdPurgeTrash(c *cli.Context) error {
+	if c.IsSet("export") || c.IsSet("yes") {
+		return fmt.Errorf("--export and --yes only apply with --profile")
+	}
+	var olderThan time.Duration
+	if s := c.String("older-than"); s != "" {
+		d, err := utask.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("--older-than: %w", err)
+		}
+		olderThan = d
+	}
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	n, err := store.PurgeTrash(ctx, olderThan)
+	if err != nil {
+		return err
+	}
+	fmt.Println(tr(c).Sprintf("purged %d tasks from the trash", n))
+	return nil
+}
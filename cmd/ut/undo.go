@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdUndo reverts the profile's latest mutation, or lists what can be
+// undone.
+func cmdUndo(c *cli.Context) error {
+	if c.NArg() > 0 {
+		return fmt.Errorf("usage: ut undo [--list]")
+	}
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	if c.Bool("list") {
+		entries, err := store.UndoHistory(ctx)
+		if err != nil {
+			return err
+		}
+		if c.Bool("verbose") {
+			b, _ := json.MarshalIndent(entries, "", "  ")
+			fmt.Println(string(b))
+			return nil
+		}
+		for _, e := range entries {
+			fmt.Printf("%s\t%s\t%s\t%s\n", e.At.Local().Format(time.DateTime), orDash(e.By), e.Op, undoTarget(c, e))
+		}
+		return nil
+	}
+	e, err := store.Undo(ctx)
+	if e.Seq != 0 {
+		fmt.Println(tr(c).Sprintf("undid %s of %s", e.Op, undoTarget(c, e)))
+	}
+	return err
+}
+
+// undoTarget names the tasks an undo entry covers.
+func undoTarget(c *cli.Context, e utask.UndoEntry) string {
+	if len(e.Changes) == 1 {
+		return e.Changes[0].Ref()
+	}
+	return tr(c).Sprintf("%d tasks", len(e.Changes))
+}
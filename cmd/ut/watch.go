@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+	cli "github.com/urfave/cli/v2"
+)
+
+// cmdWatch prints task changes as they happen until interrupted.
+func cmdWatch(c *cli.Context) error {
+	cfg := getConfig(c)
+	ctx := c.Context
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store)
+	events, err := store.Watch(ctx, utask.WatchFilter{Tag: c.String("tag")})
+	if err != nil {
+		return err
+	}
+	for ev := range events {
+		if c.Bool("json") {
+			b, _ := json.Marshal(ev)
+			fmt.Println(string(b))
+			continue
+		}
+		by, title := "-", "-"
+		if ev.Task != nil {
+			title = ev.Task.Short()
+			switch who := ev.Task.UpdatedBy; {
+			case ev.Kind == utask.ActivityCreated && ev.Task.CreatedBy != "":
+				by = ev.Task.CreatedBy
+			case ev.Op == utask.EventPut && who != "":
+				by = who
+			}
+		}
+		fmt.Printf("%s\t%s\t%s\t%s\t%s\n", ev.Time.Local().Format(time.DateTime), by, ev.Kind, shortID(ev.ID), title)
+	}
+	if ctx.Err() != nil {
+		// Interrupted: the normal way to stop watching.
+		return nil
+	}
+	return errors.New("watch stopped: lost the task watcher")
+}
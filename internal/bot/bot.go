@@ -0,0 +1,245 @@
+// Package bot bridges a chat channel to a Store: it answers "!ut" commands
+// and posts task change notifications back to the channel.
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+// Message is an inbound chat message.
+type Message struct {
+	Sender string
+	Text   string
+}
+
+// Transport connects the bot to one chat channel.
+type Transport interface {
+	// Receive streams new messages from other users until ctx is done.
+	Receive(ctx context.Context) (<-chan Message, error)
+	// Send posts text to the channel.
+	Send(ctx context.Context, text string) error
+}
+
+// Bot dispatches chat commands to a Store.
+type Bot struct {
+	store  *utask.Store
+	tr     Transport
+	prefix string
+	notify bool
+}
+
+// New returns a Bot answering messages that start with prefix (default "!ut").
+// When notify is set, task changes are announced in the channel.
+func New(store *utask.Store, tr Transport, prefix string, notify bool) *Bot {
+	if prefix == "" {
+		prefix = "!ut"
+	}
+	return &Bot{store: store, tr: tr, prefix: prefix, notify: notify}
+}
+
+// Run serves commands and notifications until ctx is cancelled.
+func (b *Bot) Run(ctx context.Context) error {
+	msgs, err := b.tr.Receive(ctx)
+	if err != nil {
+		return err
+	}
+	var events <-chan utask.Event
+	if b.notify {
+		if events, err = b.store.Watch(ctx, utask.WatchFilter{}); err != nil {
+			return err
+		}
+	}
+	seen := map[string]bool{}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case m, ok := <-msgs:
+			if !ok {
+				return nil
+			}
+			reply, ok := b.Handle(ctx, m.Text)
+			if !ok {
+				continue
+			}
+			if err := b.tr.Send(ctx, reply); err != nil {
+				slog.Error("bot send failed", "op", "reply", "err", err)
+			}
+		case ev, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if err := b.tr.Send(ctx, describe(ev, seen)); err != nil {
+				slog.Error("bot send failed", "op", "notify", "task", ev.ID, "err", err)
+			}
+		}
+	}
+}
+
+// describe renders an event, tracking done state to tell closes from edits.
+func describe(ev utask.Event, seen map[string]bool) string {
+	short := ev.ID
+	if len(short) > 8 {
+		short = short[:8]
+	}
+	if ev.Op == utask.EventDelete || ev.Task == nil {
+		delete(seen, ev.ID)
+		return fmt.Sprintf("deleted %s", short)
+	}
+	t := ev.Task
+	was, known := seen[ev.ID]
+	seen[ev.ID] = t.Done
+	verb := "updated"
+	switch {
+	case !known && !t.Done:
+		verb = "created"
+	case known && !was && t.Done:
+		verb = "closed"
+	case known && was && !t.Done:
+		verb = "reopened"
+	}
+	return fmt.Sprintf("%s %s %s%s", verb, short, t.Short(), fmtTags(t.Tags))
+}
+
+// Handle interprets one chat message. ok is false when the message is not
+// addressed to the bot.
+func (b *Bot) Handle(ctx context.Context, text string) (reply string, ok bool) {
+	text = strings.TrimSpace(text)
+	if text != b.prefix && !strings.HasPrefix(text, b.prefix+" ") {
+		return "", false
+	}
+	fields := strings.Fields(strings.TrimPrefix(text, b.prefix))
+	if len(fields) == 0 {
+		return usage(b.prefix), true
+	}
+	out, err := b.dispatch(ctx, fields[0], fields[1:])
+	if err != nil {
+		return "error: " + err.Error(), true
+	}
+	return out, true
+}
+
+func usage(prefix string) string {
+	return fmt.Sprintf("usage: %[1]s add <text> [#tag ...] | %[1]s list [#tag ...] [closed|all] | %[1]s close <id> | %[1]s reopen <id> | %[1]s get <id>", prefix)
+}
+
+func (b *Bot) dispatch(ctx context.Context, cmd string, args []string) (string, error) {
+	switch cmd {
+	case "add", "create":
+		words, tags := splitTags(args)
+		if len(words) == 0 {
+			return "", fmt.Errorf("empty text")
+		}
+		t, existed, err := b.store.CreateTask(ctx, utask.TaskInput{Text: strings.Join(words, " "), Tags: tags, Priority: 1})
+		if err != nil {
+			return "", err
+		}
+		if existed {
+			return fmt.Sprintf("exists %s %s", t.ID[:8], t.Short()), nil
+		}
+		return fmt.Sprintf("added %s %s%s", t.ID[:8], t.Short(), fmtTags(t.Tags)), nil
+	case "list", "ls":
+		return b.list(ctx, args)
+	case "close", "done", "reopen", "get":
+		if len(args) != 1 {
+			return "", fmt.Errorf("usage: %s %s <id>", b.prefix, cmd)
+		}
+		id, _, err := b.store.Resolve(ctx, args[0])
+		if err != nil {
+			return "", err
+		}
+		var t utask.Task
+		switch cmd {
+		case "close", "done":
+			t, _, err = b.store.CloseTask(ctx, id)
+		case "reopen":
+			t, _, err = b.store.ReopenTask(ctx, id)
+		default:
+			t, _, err = b.store.GetTask(ctx, id)
+		}
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s %s %s%s", t.ID[:8], status(t), t.Short(), fmtTags(t.Tags)), nil
+	case "help":
+		return usage(b.prefix), nil
+	}
+	return "", fmt.Errorf("unknown command %q; try %s help", cmd, b.prefix)
+}
+
+// maxListed keeps chat replies readable.
+const maxListed = 20
+
+func (b *Bot) list(ctx context.Context, args []string) (string, error) {
+	words, tags := splitTags(args)
+	sf := utask.StatusOpen
+	for _, w := range words {
+		switch w {
+		case "closed":
+			sf = utask.StatusClosed
+		case "all":
+			sf = ""
+		}
+	}
+	var (
+		tasks []utask.Task
+		err   error
+	)
+	if len(tags) > 0 {
+		tasks, err = b.store.Query(ctx, nil, tags, 0)
+	} else {
+		var page utask.Page
+		page, err = b.store.List(ctx, utask.ListFilter{Status: sf}, "")
+		tasks = page.Tasks
+	}
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	for _, t := range tasks {
+		if sf != "" && t.Done != (sf == utask.StatusClosed) {
+			continue
+		}
+		if len(lines) == maxListed {
+			lines = append(lines, "…")
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%s %s%s", t.ID[:8], t.Short(), fmtTags(t.Tags)))
+	}
+	if len(lines) == 0 {
+		return "no tasks", nil
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// splitTags separates "#tag" words from the rest.
+func splitTags(args []string) (words, tags []string) {
+	for _, a := range args {
+		if len(a) > 1 && a[0] == '#' {
+			tags = append(tags, a[1:])
+			continue
+		}
+		words = append(words, a)
+	}
+	return words, tags
+}
+
+func status(t utask.Task) string {
+	if t.Done {
+		return "closed"
+	}
+	return "open"
+}
+
+func fmtTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(tags, ",") + "]"
+}
@@ -0,0 +1,50 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+func TestHandleIgnoresOtherMessages(t *testing.T) {
+	b := New(nil, nil, "", false)
+	if _, ok := b.Handle(context.Background(), "hello there"); ok {
+		t.Fatalf("messages without prefix must be ignored")
+	}
+	if _, ok := b.Handle(context.Background(), "!utility"); ok {
+		t.Fatalf("prefix must be a whole word")
+	}
+	reply, ok := b.Handle(context.Background(), "!ut")
+	if !ok || !strings.HasPrefix(reply, "usage:") {
+		t.Fatalf("expected usage, got %q", reply)
+	}
+	reply, _ = b.Handle(context.Background(), "!ut add #only-tags")
+	if reply != "error: empty text" {
+		t.Fatalf("expected empty text error, got %q", reply)
+	}
+}
+
+func TestSplitTags(t *testing.T) {
+	words, tags := splitTags(strings.Fields("buy milk #errand #shopping"))
+	if strings.Join(words, " ") != "buy milk" || strings.Join(tags, ",") != "errand,shopping" {
+		t.Fatalf("unexpected split: %v %v", words, tags)
+	}
+}
+
+func TestDescribeTracksState(t *testing.T) {
+	seen := map[string]bool{}
+	task := utask.Task{ID: "0123456789", Text: "Ship it"}
+	if got := describe(utask.Event{Op: utask.EventPut, ID: task.ID, Task: &task}, seen); !strings.HasPrefix(got, "created 01234567") {
+		t.Fatalf("unexpected: %q", got)
+	}
+	closed := task
+	closed.Done = true
+	if got := describe(utask.Event{Op: utask.EventPut, ID: task.ID, Task: &closed}, seen); !strings.HasPrefix(got, "closed") {
+		t.Fatalf("unexpected: %q", got)
+	}
+	if got := describe(utask.Event{Op: utask.EventDelete, ID: task.ID}, seen); got != "deleted 01234567" {
+		t.Fatalf("unexpected: %q", got)
+	}
+}
@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// DiscordAPI is the base URL of the Discord REST API.
+const DiscordAPI = "https://discord.com/api/v10"
+
+// Discord is a Transport for one Discord channel. It polls the channel's
+// message history over REST, so no gateway connection is required.
+type Discord struct {
+	Token   string
+	Channel string
+	Client  *http.Client
+	// Base overrides DiscordAPI (for tests).
+	Base string
+	// Poll is the message polling interval (default 2s).
+	Poll time.Duration
+}
+
+type discordMessage struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+	Author  struct {
+		Username string `json:"username"`
+		Bot      bool   `json:"bot"`
+	} `json:"author"`
+}
+
+func (d *Discord) do(ctx context.Context, method, path string, body, out any) error {
+	base := d.Base
+	if base == "" {
+		base = DiscordAPI
+	}
+	var buf bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&buf).Encode(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, base+path, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bot "+d.Token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("discord %s: %s", path, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Send posts a message to the channel.
+func (d *Discord) Send(ctx context.Context, text string) error {
+	return d.do(ctx, http.MethodPost, "/channels/"+d.Channel+"/messages", map[string]string{"content": text}, nil)
+}
+
+// Receive polls for messages newer than the latest one at startup and
+// streams those not written by bots.
+func (d *Discord) Receive(ctx context.Context) (<-chan Message, error) {
+	var latest []discordMessage
+	if err := d.do(ctx, http.MethodGet, "/channels/"+d.Channel+"/messages?limit=1", nil, &latest); err != nil {
+		return nil, err
+	}
+	after := "0"
+	if len(latest) > 0 {
+		after = latest[0].ID
+	}
+	poll := d.Poll
+	if poll <= 0 {
+		poll = 2 * time.Second
+	}
+	out := make(chan Message)
+	go func() {
+		defer close(out)
+		tick := time.NewTicker(poll)
+		defer tick.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C:
+			}
+			var msgs []discordMessage
+			if err := d.do(ctx, http.MethodGet, "/channels/"+d.Channel+"/messages?limit=50&after="+after, nil, &msgs); err != nil {
+				if ctx.Err() == nil {
+					slog.Warn("discord poll failed", "op", "poll", "err", err)
+				}
+				continue
+			}
+			// Discord returns newest first.
+			for i := len(msgs) - 1; i >= 0; i-- {
+				m := msgs[i]
+				after = m.ID
+				if m.Author.Bot {
+					continue
+				}
+				select {
+				case out <- Message{Sender: m.Author.Username, Text: m.Content}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,121 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Matrix is a Transport for one Matrix room using the client-server API.
+type Matrix struct {
+	Homeserver string
+	Token      string
+	Room       string
+	Client     *http.Client
+
+	txn atomic.Int64
+}
+
+func (m *Matrix) do(ctx context.Context, method, path string, body, out any) error {
+	var rd *bytes.Reader
+	if body != nil {
+		b, _ := json.Marshal(body)
+		rd = bytes.NewReader(b)
+	} else {
+		rd = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(m.Homeserver, "/")+path, rd)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+m.Token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("matrix %s: %s", path, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Send posts a plain-text message to the room.
+func (m *Matrix) Send(ctx context.Context, text string) error {
+	txn := strconv.FormatInt(time.Now().UnixNano(), 36) + strconv.FormatInt(m.txn.Add(1), 36)
+	path := "/_matrix/client/v3/rooms/" + url.PathEscape(m.Room) + "/send/m.room.message/" + txn
+	return m.do(ctx, http.MethodPut, path, map[string]string{"msgtype": "m.text", "body": text}, nil)
+}
+
+type matrixSync struct {
+	NextBatch string `json:"next_batch"`
+	Rooms     struct {
+		Join map[string]struct {
+			Timeline struct {
+				Events []struct {
+					Type    string `json:"type"`
+					Sender  string `json:"sender"`
+					Content struct {
+						MsgType string `json:"msgtype"`
+						Body    string `json:"body"`
+					} `json:"content"`
+				} `json:"events"`
+			} `json:"timeline"`
+		} `json:"join"`
+	} `json:"rooms"`
+}
+
+// Receive long-polls /sync and streams text messages in the room that were
+// not sent by the bot's own user. History before the first sync is skipped.
+func (m *Matrix) Receive(ctx context.Context) (<-chan Message, error) {
+	var who struct {
+		UserID string `json:"user_id"`
+	}
+	if err := m.do(ctx, http.MethodGet, "/_matrix/client/v3/account/whoami", nil, &who); err != nil {
+		return nil, err
+	}
+	var first matrixSync
+	if err := m.do(ctx, http.MethodGet, "/_matrix/client/v3/sync?timeout=0", nil, &first); err != nil {
+		return nil, err
+	}
+	out := make(chan Message)
+	go func() {
+		defer close(out)
+		since := first.NextBatch
+		for ctx.Err() == nil {
+			var s matrixSync
+			path := "/_matrix/client/v3/sync?timeout=30000&since=" + url.QueryEscape(since)
+			if err := m.do(ctx, http.MethodGet, path, nil, &s); err != nil {
+				if ctx.Err() == nil {
+					slog.Warn("matrix sync failed", "op", "sync", "err", err)
+					time.Sleep(5 * time.Second)
+				}
+				continue
+			}
+			since = s.NextBatch
+			for _, ev := range s.Rooms.Join[m.Room].Timeline.Events {
+				if ev.Type != "m.room.message" || ev.Content.MsgType != "m.text" || ev.Sender == who.UserID {
+					continue
+				}
+				select {
+				case out <- Message{Sender: ev.Sender, Text: ev.Content.Body}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// AccessConfig maps callers to roles (read-only|contributor|admin).
+type AccessConfig struct {
+	// DefaultRole applies to NATS users not listed in Users (default admin).
+	DefaultRole string `yaml:"default_role"`
+	// Users maps NATS user names, taken from the server URL, to roles.
+	Users map[string]string `yaml:"users"`
+	// Tokens maps HTTP access tokens to grants. When set, `ut serve`
+	// rejects API requests without a listed token.
+	Tokens map[string]TokenGrant `yaml:"tokens"`
+}
+
+// TokenGrant is a role and, optionally, the identity a token acts as. In
+// YAML it is either a bare role or a mapping:
+//
+//	"view-token": read-only
+//	"ada-token": {role: contributor, identity: "Ada <ada@example.org>"}
+type TokenGrant struct {
+	Role     string `yaml:"role"`
+	Identity string `yaml:"identity"`
+}
+
+func (g *TokenGrant) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*g = TokenGrant{Role: node.Value}
+		return nil
+	}
+	type plain TokenGrant
+	return node.Decode((*plain)(g))
+}
+
+// RoleFor returns the role name configured for a NATS user.
+func (a AccessConfig) RoleFor(user string) string {
+	if r, ok := a.Users[user]; ok && user != "" {
+		return r
+	}
+	if a.DefaultRole != "" {
+		return a.DefaultRole
+	}
+	return "admin"
+}
+
+// NATSUser returns the user name in a NATS server URL such as
+// "nats://alice:pw@host:4222", using the first of a comma-separated list.
+// It returns "" when the URL carries no user.
+func NATSUser(servers string) string {
+	first, _, _ := strings.Cut(servers, ",")
+	u, err := url.Parse(strings.TrimSpace(first))
+	if err != nil || u.User == nil {
+		return ""
+	}
+	return u.User.Username()
+}
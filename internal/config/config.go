@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	yaml "gopkg.in/yaml.v3"
 )
@@ -12,14 +13,179 @@ import (
 type Config struct {
 	NATS struct {
 		URL string `yaml:"url"`
+		// Timeout bounds each NATS operation, e.g. "5s" (0 = client default).
+		Timeout time.Duration `yaml:"timeout"`
+		// ReconnectWait and MaxReconnects control reconnecting after the
+		// connection drops (0 = client defaults of 2s and 60 attempts;
+		// max_reconnects -1 retries forever).
+		ReconnectWait time.Duration `yaml:"reconnect_wait"`
+		MaxReconnects int           `yaml:"max_reconnects"`
+		// Retries is how many more times a read is tried after a timeout
+		// (0 = 2, -1 = none).
+		Retries int `yaml:"retries"`
+		// FailFast fails operations at once, naming the server, profile
+		// and bucket, while the connection is down.
+		FailFast bool `yaml:"fail_fast"`
 	} `yaml:"nats"`
 	OpenAI struct {
 		APIKey string `yaml:"api_key"`
 		Model  string `yaml:"model"`
+		// AutoTag makes `ut create` and `ut update` suggest tags as if
+		// --suggest-tags were given.
+		AutoTag bool `yaml:"auto_tag"`
 	} `yaml:"openai"`
 	UI struct {
 		Profile string `yaml:"profile"`
+		// Context names the filter context listings apply (see
+		// Contexts); `ut context use` sets it.
+		Context string `yaml:"context"`
+		// Locale picks the language of CLI messages and dates (en, fr,
+		// de); empty follows $LC_ALL, $LC_MESSAGES or $LANG.
+		Locale string `yaml:"locale"`
 	} `yaml:"ui"`
+	// Profiles are named environments, each with its own server and
+	// defaults; ui.profile or --profile picks one (see UseProfile).
+	Profiles map[string]Profile `yaml:"profiles"`
+	// Contexts are named default filters for listings; ui.context
+	// picks the one in use.
+	Contexts map[string]FilterContext `yaml:"contexts"`
+	Inbound  []InboundHook            `yaml:"inbound"`
+	Bot      BotConfig                `yaml:"bot"`
+	Cache    struct {
+		// Disabled turns off the local snapshot used by `ut list`.
+		Disabled bool `yaml:"disabled"`
+	} `yaml:"cache"`
+	Storage StorageConfig `yaml:"storage"`
+	Limits  LimitsConfig  `yaml:"limits"`
+	// Identity is stamped on tasks as created_by/updated_by; unset parts
+	// come from git config or $USER (see ResolveIdentity).
+	Identity IdentityConfig `yaml:"identity"`
+	// Access maps NATS users and HTTP tokens to roles that limit what they
+	// may change.
+	Access AccessConfig `yaml:"access"`
+	// Review lists tags whose tasks need a second identity's approval
+	// (`ut approve`) before they close.
+	Review struct {
+		Tags []string `yaml:"tags"`
+	} `yaml:"review"`
+	// Report configures `ut report`. SprintStart (YYYY-MM-DD, any sprint's
+	// first day) and SprintLength (e.g. "2w") let --since sprint-start find
+	// the current sprint. Schedule lists digests `ut daemon` sends.
+	Report struct {
+		SprintStart  string           `yaml:"sprint_start"`
+		SprintLength string           `yaml:"sprint_length"`
+		Schedule     []ReportSchedule `yaml:"schedule"`
+	} `yaml:"report"`
+	// Plan configures `ut plan`: the hours of estimated work that fit in a
+	// week, e.g. "30h".
+	Plan struct {
+		WeeklyCapacity string `yaml:"weekly_capacity"`
+	} `yaml:"plan"`
+	// Dates makes `ut create` turn phrases like "by friday" or "next
+	// month" into the due date (Task.Due), removing the phrase unless
+	// KeepPhrase.
+	Dates struct {
+		Capture    bool `yaml:"capture"`
+		KeepPhrase bool `yaml:"keep_phrase"`
+	} `yaml:"dates"`
+	// MCP configures `ut mcp`: MaxInFlight is how many requests it runs
+	// at once (0 = default); the rest wait for a slot.
+	MCP struct {
+		MaxInFlight int `yaml:"max_in_flight"`
+	} `yaml:"mcp"`
+	// People maps @mention handles to identities and notification targets.
+	People map[string]Person `yaml:"people"`
+	Notify NotifyConfig      `yaml:"notify"`
+	// Log sets the default log level (debug|info|warn|error) and format
+	// (text|json) for stderr logging.
+	Log struct {
+		Level  string `yaml:"level"`
+		Format string `yaml:"format"`
+	} `yaml:"log"`
+}
+
+// ReportSchedule is a backlog digest sent on a cron schedule.
+type ReportSchedule struct {
+	// Cron is a five-field expression in local time, e.g. "0 8 * * mon".
+	Cron string `yaml:"cron"`
+	// Since is the digest window, as for `ut report --since` (default 7d).
+	Since string `yaml:"since"`
+	// Email sends through notify.smtp; Webhook receives a JSON POST of
+	// {subject, text}; Slack is an incoming-webhook URL.
+	Email   string `yaml:"email"`
+	Webhook string `yaml:"webhook"`
+	Slack   string `yaml:"slack"`
+}
+
+// LimitsConfig bounds task input; zero values use the built-in defaults.
+type LimitsConfig struct {
+	MaxTextLen int `yaml:"max_text_len"`
+	MaxTags    int `yaml:"max_tags"`
+	MaxTagLen  int `yaml:"max_tag_len"`
+	// TagPattern is a regexp normalized (lowercase) tags must match.
+	TagPattern string `yaml:"tag_pattern"`
+}
+
+// StorageConfig tunes how task values are written to KV.
+type StorageConfig struct {
+	// Driver is where profiles live: nats (default) or sqlite, a single
+	// local file at Path (default ~/.utask/tasks.db) needing no server.
+	Driver string `yaml:"driver"`
+	Path   string `yaml:"path"`
+	// Encoding is json (default) or msgpack. Reads accept both.
+	Encoding string `yaml:"encoding"`
+	// CompressAbove compresses task values larger than this many bytes
+	// (0 = never) with Compression: zstd (default) or gzip.
+	CompressAbove int    `yaml:"compress_above"`
+	Compression   string `yaml:"compression"`
+	// Keyspace is the task key layout for new profiles: flat (default) or
+	// sharded. Existing profiles move with `ut maintain --keyspace`.
+	Keyspace string `yaml:"keyspace"`
+	// IDStrategy is how new tasks get ids: content (default), derived
+	// from the task so re-creating it returns the original, or random.
+	IDStrategy string `yaml:"id_strategy"`
+	// HealOrphans drops tag index entries for deleted tasks when listings
+	// find them, instead of only warning about them.
+	HealOrphans bool `yaml:"heal_orphans"`
+	// AuditRetention is how long the audit trail behind `ut activity`
+	// keeps events, e.g. "2160h" (0 = 90 days).
+	AuditRetention time.Duration `yaml:"audit_retention"`
+	// TaskHistory is how many revisions of each task the NATS tasks
+	// bucket keeps for `ut history` (0 = 16, at most 64).
+	TaskHistory int `yaml:"task_history"`
+	// EncryptionKeyFile holds the key that encrypts task text on the
+	// server (default ~/.utask/keys/<profile>.key, used if it exists).
+	// $UTASK_ENCRYPTION_KEY takes precedence.
+	EncryptionKeyFile string `yaml:"encryption_key_file"`
+	// UndoDepth is how many recent mutations `ut undo` can revert
+	// (0 = 20, negative turns undo off).
+	UndoDepth int `yaml:"undo_depth"`
+}
+
+// BotConfig configures the chat bridge run by `ut bot`. Exactly one of
+// Matrix or Discord should be set.
+type BotConfig struct {
+	Prefix string `yaml:"prefix"`
+	Notify bool   `yaml:"notify"`
+	Matrix struct {
+		Homeserver string `yaml:"homeserver"`
+		Token      string `yaml:"token"`
+		Room       string `yaml:"room"`
+	} `yaml:"matrix"`
+	Discord struct {
+		Token   string `yaml:"token"`
+		Channel string `yaml:"channel"`
+	} `yaml:"discord"`
+}
+
+// InboundHook configures a /v1/inbound/<token> endpoint. Text, Tags and
+// Priority are Go text/template strings rendered against the posted fields,
+// e.g. text: "{{.title}}\n\n{{.body}}".
+type InboundHook struct {
+	Token    string   `yaml:"token"`
+	Text     string   `yaml:"text"`
+	Tags     []string `yaml:"tags"`
+	Priority string   `yaml:"priority"`
 }
 
 func DefaultPath() (string, error) {
@@ -50,13 +216,27 @@ func OverlayEnv(cfg *Config) {
 	if v := os.Getenv("UTASK_NATS_URL"); v != "" {
 		cfg.NATS.URL = v
 	}
+	if v := os.Getenv("UTASK_NATS_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.NATS.Timeout = d
+		}
+	}
 	if v := os.Getenv("OPENAI_API_KEY"); v != "" {
 		cfg.OpenAI.APIKey = v
 	}
 	if v := os.Getenv("UTASK_OPENAI_MODEL"); v != "" {
 		cfg.OpenAI.Model = v
 	}
+	if v := os.Getenv("UTASK_BACKEND"); v != "" {
+		cfg.Storage.Driver = v
+	}
 	if v := os.Getenv("UTASK_PROFILE"); v != "" {
 		cfg.UI.Profile = v
 	}
+	if v := os.Getenv("UTASK_LOG_LEVEL"); v != "" {
+		cfg.Log.Level = v
+	}
+	if v := os.Getenv("UTASK_LOG_FORMAT"); v != "" {
+		cfg.Log.Format = v
+	}
 }
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// FilterContext is a named set of default listing filters under
+// contexts:, like a taskwarrior context. While ui.context names it, `ut
+// list`, `ut query` and `ut count` apply each filter their own flags
+// leave unset. Values are as the flags of `ut list` take them.
+type FilterContext struct {
+	Tag string `yaml:"tag,omitempty"`
+	// Tags and AllTags are comma-separated: any of, and all of.
+	Tags     string `yaml:"tags,omitempty"`
+	AllTags  string `yaml:"all_tags,omitempty"`
+	Status   string `yaml:"status,omitempty"`
+	Assignee string `yaml:"assignee,omitempty"`
+	// Context is a GTD context such as @home; it replaces the one `ut
+	// context set @home` made active.
+	Context string `yaml:"context,omitempty"`
+}
+
+// filterKeys maps the keys of `ut context set` to the fields they set.
+var filterKeys = []struct {
+	key   string
+	field func(*FilterContext) *string
+}{
+	{"tag", func(fc *FilterContext) *string { return &fc.Tag }},
+	{"tags", func(fc *FilterContext) *string { return &fc.Tags }},
+	{"all-tags", func(fc *FilterContext) *string { return &fc.AllTags }},
+	{"status", func(fc *FilterContext) *string { return &fc.Status }},
+	{"assignee", func(fc *FilterContext) *string { return &fc.Assignee }},
+	{"context", func(fc *FilterContext) *string { return &fc.Context }},
+}
+
+// ParseFilterContext reads key=value filters, e.g. tag=work status=open.
+// Keys are tag, tags, all-tags, status, assignee and context.
+func ParseFilterContext(args []string) (FilterContext, error) {
+	var fc FilterContext
+	for _, a := range args {
+		k, v, ok := strings.Cut(a, "=")
+		if !ok || strings.TrimSpace(v) == "" {
+			return FilterContext{}, fmt.Errorf("invalid filter %q (want key=value, e.g. tag=work)", a)
+		}
+		field := fc.field(strings.ToLower(strings.TrimSpace(k)))
+		if field == nil {
+			return FilterContext{}, fmt.Errorf("unknown filter %q (want tag, tags, all-tags, status, assignee or context)", k)
+		}
+		*field = strings.TrimSpace(v)
+	}
+	if fc == (FilterContext{}) {
+		return FilterContext{}, fmt.Errorf("no filters given (want key=value, e.g. tag=work)")
+	}
+	return fc, nil
+}
+
+func (fc *FilterContext) field(key string) *string {
+	for _, f := range filterKeys {
+		if f.key == key {
+			return f.field(fc)
+		}
+	}
+	return nil
+}
+
+// String formats fc as the key=value filters ParseFilterContext reads.
+func (fc FilterContext) String() string {
+	var parts []string
+	for _, f := range filterKeys {
+		if v := *f.field(&fc); v != "" {
+			parts = append(parts, f.key+"="+v)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// ActiveContext returns the name and filters of the context in use. ok is
+// false when there is none; a ui.context that isn't defined is an error.
+func (cfg *Config) ActiveContext() (name string, fc FilterContext, ok bool, err error) {
+	name = cfg.UI.Context
+	if name == "" {
+		return "", FilterContext{}, false, nil
+	}
+	fc, ok = cfg.Contexts[name]
+	if !ok {
+		return name, FilterContext{}, false, fmt.Errorf("context %q in use (ui.context) is not defined under contexts; `ut context use none` stops using it", name)
+	}
+	return name, fc, true, nil
+}
+
+// SaveContext adds or replaces contexts.<name> in the config file at path.
+func SaveContext(path, name string, fc FilterContext) error {
+	if !profileNameRe.MatchString(name) {
+		return fmt.Errorf("invalid context name %q (letters, digits, - and _ only)", name)
+	}
+	var val yaml.Node
+	if err := val.Encode(fc); err != nil {
+		return err
+	}
+	return editFile(path, func(root *yaml.Node) error {
+		setKey(mappingKey(root, "contexts"), name, &val)
+		return nil
+	})
+}
+
+// DeleteContext removes contexts.<name> from the config file at path.
+func DeleteContext(path, name string) error {
+	return editFile(path, func(root *yaml.Node) error {
+		if !deleteKey(mappingKey(root, "contexts"), name) {
+			return fmt.Errorf("no context %q in %s", name, path)
+		}
+		return nil
+	})
+}
+
+// UseContext records name as ui.context in the config file at path; ""
+// stops using one.
+func UseContext(path, name string) error {
+	return editFile(path, func(root *yaml.Node) error {
+		ui := mappingKey(root, "ui")
+		if name == "" {
+			deleteKey(ui, "context")
+			return nil
+		}
+		setKey(ui, "context", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name})
+		return nil
+	})
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// IdentityConfig names the author stamped on task writes.
+type IdentityConfig struct {
+	Name  string `yaml:"name"`
+	Email string `yaml:"email"`
+}
+
+// String renders the identity as "Name <email>", or whichever part is set.
+func (i IdentityConfig) String() string {
+	switch {
+	case i.Name != "" && i.Email != "":
+		return i.Name + " <" + i.Email + ">"
+	case i.Email != "":
+		return "<" + i.Email + ">"
+	}
+	return i.Name
+}
+
+// ResolveIdentity fills the parts of i left unset from git's user.name and
+// user.email, and falls back to $USER for the name.
+func ResolveIdentity(i IdentityConfig) IdentityConfig {
+	if i.Name == "" {
+		i.Name = gitConfig("user.name")
+	}
+	if i.Email == "" {
+		i.Email = gitConfig("user.email")
+	}
+	if i.Name == "" && i.Email == "" {
+		i.Name = os.Getenv("USER")
+	}
+	return i
+}
+
+// gitConfig returns a git config value, or "" when git or the key is missing.
+func gitConfig(key string) string {
+	out, err := exec.Command("git", "config", "--get", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
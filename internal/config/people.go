@@ -0,0 +1,28 @@
+package config
+
+// Person is an entry in the people map, keyed by the handle used in
+// @mentions: who they are and where to reach them.
+type Person struct {
+	Name  string `yaml:"name"`
+	Email string `yaml:"email"`
+	// Webhook receives each notice as a JSON POST.
+	Webhook string `yaml:"webhook"`
+	// Slack is an incoming-webhook URL.
+	Slack string `yaml:"slack"`
+	// Mail sends notices to Email through notify.smtp.
+	Mail bool `yaml:"mail"`
+}
+
+// Identity returns the person as an IdentityConfig.
+func (p Person) Identity() IdentityConfig { return IdentityConfig{Name: p.Name, Email: p.Email} }
+
+// NotifyConfig configures delivery for `ut notify`.
+type NotifyConfig struct {
+	SMTP struct {
+		// Addr is host:port of the mail server.
+		Addr     string `yaml:"addr"`
+		From     string `yaml:"from"`
+		Username string `yaml:"username"`
+		Password string `yaml:"password"`
+	} `yaml:"smtp"`
+}
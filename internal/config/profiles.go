@@ -0,0 +1,165 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Profile is a named environment under profiles:. Its name is also the
+// suffix of the profile's buckets, so each keeps its own tasks; NATSURL
+// points it at its own server as well.
+type Profile struct {
+	// NATSURL replaces nats.url while the profile is in use;
+	// $UTASK_NATS_URL and --nats-url still win.
+	NATSURL string `yaml:"nats_url,omitempty"`
+	// Tags and Priority are what `ut create` uses when --tag or
+	// --priority isn't given.
+	Tags     []string `yaml:"default_tags,omitempty"`
+	Priority int      `yaml:"default_priority,omitempty"`
+}
+
+var profileNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// CheckProfileName rejects names that can't be part of a bucket name.
+func CheckProfileName(name string) error {
+	if !profileNameRe.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q (letters, digits, - and _ only)", name)
+	}
+	return nil
+}
+
+// UseProfile makes name the profile in use, when set, and applies its
+// settings. Call it before OverlayEnv so environment variables and flags
+// still override them.
+func (cfg *Config) UseProfile(name string) {
+	if name != "" {
+		cfg.UI.Profile = name
+	}
+	if p, ok := cfg.Profiles[cfg.UI.Profile]; ok && p.NATSURL != "" {
+		cfg.NATS.URL = p.NATSURL
+	}
+}
+
+// ActiveProfile returns the settings of the profile in use; one that isn't
+// listed under profiles: has none.
+func (cfg *Config) ActiveProfile() Profile { return cfg.Profiles[cfg.UI.Profile] }
+
+// SaveProfile adds or replaces profiles.<name> in the config file at path,
+// keeping the rest of the file and its comments.
+func SaveProfile(path, name string, p Profile) error {
+	if err := CheckProfileName(name); err != nil {
+		return err
+	}
+	var val yaml.Node
+	if err := val.Encode(p); err != nil {
+		return err
+	}
+	return editFile(path, func(root *yaml.Node) error {
+		setKey(mappingKey(root, "profiles"), name, &val)
+		return nil
+	})
+}
+
+// DeleteProfile removes profiles.<name> from the config file at path.
+func DeleteProfile(path, name string) error {
+	return editFile(path, func(root *yaml.Node) error {
+		if !deleteKey(mappingKey(root, "profiles"), name) {
+			return fmt.Errorf("no profile %q in %s", name, path)
+		}
+		return nil
+	})
+}
+
+// SetDefaultProfile records name as ui.profile in the config file at path.
+func SetDefaultProfile(path, name string) error {
+	if err := CheckProfileName(name); err != nil {
+		return err
+	}
+	return editFile(path, func(root *yaml.Node) error {
+		setKey(mappingKey(root, "ui"), "profile", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name})
+		return nil
+	})
+}
+
+// editFile applies edit to the top-level mapping of the YAML file at path,
+// creating the file if needed, and writes it back atomically.
+func editFile(path string, edit func(root *yaml.Node) error) error {
+	var doc yaml.Node
+	b, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("read config: %w", err)
+	}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return fmt.Errorf("parse config yaml: %w", err)
+	}
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+	}
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("config %s: top level is not a mapping", path)
+	}
+	if err := edit(root); err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// mappingKey returns the mapping under key in m, adding an empty one when
+// key is missing or not a mapping.
+func mappingKey(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key && m.Content[i+1].Kind == yaml.MappingNode {
+			return m.Content[i+1]
+		}
+	}
+	v := &yaml.Node{Kind: yaml.MappingNode}
+	setKey(m, key, v)
+	return v
+}
+
+// setKey sets key in mapping m to v, keeping its position and comments
+// when it exists.
+func setKey(m *yaml.Node, key string, v *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			v.LineComment = m.Content[i+1].LineComment
+			m.Content[i+1] = v
+			return
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, v)
+}
+
+// deleteKey removes key from mapping m, reporting whether it was there.
+func deleteKey(m *yaml.Node, key string) bool {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProfileEdits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	orig := "# my settings\nnats:\n  url: nats://home:4222 # lan box\nui:\n  locale: fr\n"
+	if err := os.WriteFile(path, []byte(orig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveProfile(path, "work", Profile{NATSURL: "nats://work:4222", Tags: []string{"work"}, Priority: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveProfile(path, "side", Profile{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetDefaultProfile(path, "work"); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteProfile(path, "side"); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteProfile(path, "side"); err == nil {
+		t.Fatal("deleting a missing profile succeeded")
+	}
+	if err := SaveProfile(path, "a.b", Profile{}); err == nil {
+		t.Fatal("saved a profile name that can't be a bucket suffix")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# my settings", "# lan box", "locale: fr"} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("rewritten config lost %q:\n%s", want, b)
+		}
+	}
+
+	cfg, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Profiles) != 1 || cfg.UI.Profile != "work" {
+		t.Fatalf("profiles = %v, ui.profile = %q", cfg.Profiles, cfg.UI.Profile)
+	}
+	cfg.UseProfile("")
+	if cfg.NATS.URL != "nats://work:4222" || cfg.ActiveProfile().Priority != 2 {
+		t.Fatalf("work profile not applied: url %q, %+v", cfg.NATS.URL, cfg.ActiveProfile())
+	}
+	cfg, _ = LoadFromFile(path)
+	cfg.UseProfile("scratch")
+	if cfg.NATS.URL != "nats://home:4222" || cfg.UI.Profile != "scratch" {
+		t.Fatalf("unlisted profile: url %q, profile %q", cfg.NATS.URL, cfg.UI.Profile)
+	}
+}
+
+func TestContextEdits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	fc, err := ParseFilterContext([]string{"tag=work", "status=open"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fc.String(); got != "tag=work status=open" {
+		t.Fatalf("String = %q", got)
+	}
+	for _, bad := range [][]string{nil, {"tag"}, {"colour=red"}, {"tag="}} {
+		if _, err := ParseFilterContext(bad); err == nil {
+			t.Errorf("ParseFilterContext(%q) succeeded", bad)
+		}
+	}
+	if err := SaveContext(path, "work", fc); err != nil {
+		t.Fatal(err)
+	}
+	if err := UseContext(path, "work"); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, got, ok, err := cfg.ActiveContext(); err != nil || !ok || name != "work" || got != fc {
+		t.Fatalf("ActiveContext = %q, %+v, %v, %v", name, got, ok, err)
+	}
+	if err := DeleteContext(path, "work"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = LoadFromFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := cfg.ActiveContext(); err == nil {
+		t.Fatal("ActiveContext accepted a deleted context")
+	}
+	if err := UseContext(path, ""); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = LoadFromFile(path); err != nil || cfg.UI.Context != "" {
+		t.Fatalf("ui.context = %q after use none, %v", cfg.UI.Context, err)
+	}
+}
@@ -0,0 +1,202 @@
+// Package daemon carries CLI invocations over a Unix socket so `ut` commands
+// can run inside a long-lived `ut daemon` process that keeps NATS
+// connections and caches warm.
+//
+// The wire protocol is one JSON Request from the client followed by framed
+// output from the server: a 1-byte stream tag, a 4-byte big-endian length,
+// and the payload. The final frame is tagged 'x' and carries the decimal
+// exit code.
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// Stream tags used in frames.
+const (
+	tagStdout byte = 'o'
+	tagStderr byte = 'e'
+	tagExit   byte = 'x'
+)
+
+// maxFrame bounds a single frame so a corrupt length can't exhaust memory.
+const maxFrame = 16 << 20
+
+// Request is a single CLI invocation forwarded by a client.
+type Request struct {
+	Args []string          `json:"args"`
+	Env  map[string]string `json:"env,omitempty"`
+	// Dir is the client's working directory, for relative paths in flags.
+	Dir string `json:"dir,omitempty"`
+}
+
+// Handler runs req, writing its output to stdout/stderr, and returns the
+// process exit code.
+type Handler func(ctx context.Context, req Request, stdout, stderr io.Writer) int
+
+// SocketPath returns the default socket location, ~/.utask/run/ut.sock, or
+// $UTASK_DAEMON_SOCKET when set.
+func SocketPath() (string, error) {
+	if p := os.Getenv("UTASK_DAEMON_SOCKET"); p != "" {
+		return p, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".utask", "run", "ut.sock"), nil
+}
+
+// Listen creates the socket at path, replacing a stale socket file left by a
+// daemon that is no longer running.
+func Listen(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if c, err := net.Dial("unix", path); err == nil {
+		c.Close()
+		return nil, fmt.Errorf("daemon already running at %s", path)
+	}
+	_ = os.Remove(path)
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	_ = os.Chmod(path, 0o600)
+	return ln, nil
+}
+
+// Serve accepts connections on ln until ctx is cancelled, running each
+// request through h.
+func Serve(ctx context.Context, ln net.Listener, h Handler) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+			serveConn(ctx, conn, h)
+		}()
+	}
+}
+
+func serveConn(ctx context.Context, conn net.Conn, h Handler) {
+	var req Request
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		return
+	}
+	fw := &frameWriter{w: conn}
+	code := h(ctx, req, fw.stream(tagStdout), fw.stream(tagStderr))
+	_ = fw.write(tagExit, []byte(strconv.Itoa(code)))
+}
+
+// frameWriter serializes frames from concurrent stdout/stderr writers.
+type frameWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (f *frameWriter) write(tag byte, p []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return writeFrame(f.w, tag, p)
+}
+
+func (f *frameWriter) stream(tag byte) io.Writer {
+	return writerFunc(func(p []byte) (int, error) {
+		if err := f.write(tag, p); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	})
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (fn writerFunc) Write(p []byte) (int, error) { return fn(p) }
+
+func writeFrame(w io.Writer, tag byte, p []byte) error {
+	var hdr [5]byte
+	hdr[0] = tag
+	binary.BigEndian.PutUint32(hdr[1:], uint32(len(p)))
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(p)
+	return err
+}
+
+func readFrame(r io.Reader) (byte, []byte, error) {
+	var hdr [5]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0, nil, err
+	}
+	n := binary.BigEndian.Uint32(hdr[1:])
+	if n > maxFrame {
+		return 0, nil, fmt.Errorf("frame too large: %d bytes", n)
+	}
+	p := make([]byte, n)
+	if _, err := io.ReadFull(r, p); err != nil {
+		return 0, nil, err
+	}
+	return hdr[0], p, nil
+}
+
+// ErrUnavailable is returned by Call when no daemon is listening, telling the
+// caller to run the command directly.
+var ErrUnavailable = errors.New("daemon unavailable")
+
+// Call forwards req to the daemon at path, copying its output to stdout and
+// stderr, and returns the remote exit code.
+func Call(path string, req Request, stdout, stderr io.Writer) (int, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return 0, ErrUnavailable
+	}
+	defer conn.Close()
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return 0, err
+	}
+	br := bufio.NewReader(conn)
+	for {
+		tag, p, err := readFrame(br)
+		if err != nil {
+			return 1, fmt.Errorf("daemon: %w", err)
+		}
+		switch tag {
+		case tagStdout:
+			_, _ = stdout.Write(p)
+		case tagStderr:
+			_, _ = stderr.Write(p)
+		case tagExit:
+			code, err := strconv.Atoi(string(p))
+			if err != nil {
+				return 1, fmt.Errorf("daemon: bad exit frame %q", p)
+			}
+			return code, nil
+		}
+	}
+}
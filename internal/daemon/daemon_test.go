@@ -0,0 +1,48 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCallRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ut.sock")
+	ln, err := Listen(path)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(ctx, ln, func(ctx context.Context, req Request, stdout, stderr io.Writer) int {
+			fmt.Fprintln(stdout, strings.Join(req.Args, " "))
+			fmt.Fprintln(stderr, req.Env["UTASK_PROFILE"])
+			return 3
+		})
+	}()
+
+	var out, errOut bytes.Buffer
+	code, err := Call(path, Request{Args: []string{"ut", "list"}, Env: map[string]string{"UTASK_PROFILE": "work"}}, &out, &errOut)
+	if err != nil || code != 3 {
+		t.Fatalf("call: code=%d err=%v", code, err)
+	}
+	if out.String() != "ut list\n" || errOut.String() != "work\n" {
+		t.Fatalf("unexpected output: %q / %q", out.String(), errOut.String())
+	}
+	if _, err := Listen(path); err == nil {
+		t.Fatal("second Listen should refuse a live socket")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+	if _, err := Call(path, Request{}, &out, &errOut); err != ErrUnavailable {
+		t.Fatalf("expected ErrUnavailable after shutdown, got %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+// Package feed renders task activity as an Atom feed.
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+const atomNS = "http://www.w3.org/2005/Atom"
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	NS      string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link,omitempty"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Author     *atomAuthor    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category,omitempty"`
+	Content    atomContent    `xml:"content"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// Atom renders acts as an Atom 1.0 document. selfURL is optional and used as
+// the feed's self link when set; profile names the feed.
+func Atom(profile, selfURL string, acts []utask.Activity) ([]byte, error) {
+	updated := time.Unix(0, 0).UTC()
+	if len(acts) > 0 {
+		updated = acts[0].When
+	}
+	f := atomFeed{
+		NS:      atomNS,
+		ID:      "urn:utask:" + profile,
+		Title:   fmt.Sprintf("utask activity (%s)", profile),
+		Updated: updated.Format(time.RFC3339),
+		Author:  atomAuthor{Name: "utask"},
+	}
+	if selfURL != "" {
+		f.Links = append(f.Links, atomLink{Rel: "self", Href: selfURL})
+	}
+	for _, a := range acts {
+		e := atomEntry{
+			ID:      fmt.Sprintf("urn:utask:%s:%s:%s", profile, a.Task.ID, a.Kind),
+			Title:   fmt.Sprintf("[%s] %s", a.Kind, a.Task.Short()),
+			Updated: a.When.Format(time.RFC3339),
+			Content: atomContent{Type: "text", Body: entryBody(a)},
+		}
+		if a.By != "" {
+			e.Author = &atomAuthor{Name: a.By}
+		}
+		for _, tag := range a.Task.Tags {
+			e.Categories = append(e.Categories, atomCategory{Term: tag})
+		}
+		f.Entries = append(f.Entries, e)
+	}
+	b, err := xml.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(b, '\n')...), nil
+}
+
+func entryBody(a utask.Activity) string {
+	s := a.Task.Text + "\n\nid: " + a.Task.ID
+	if a.Task.Priority != 0 {
+		s += fmt.Sprintf("\npriority: %d", a.Task.Priority)
+	}
+	if a.Task.EstimateMinutes != 0 {
+		s += fmt.Sprintf("\nestimate: %dm", a.Task.EstimateMinutes)
+	}
+	return s
+}
@@ -0,0 +1,37 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+func TestAtomRendersEntries(t *testing.T) {
+	when := time.Date(2025, 8, 26, 13, 40, 0, 0, time.UTC)
+	acts := []utask.Activity{{
+		Kind: utask.ActivityClosed,
+		When: when,
+		By:   "Ada <ada@example.org>",
+		Task: utask.Task{ID: "abc", Text: "Ship <patch>\n\nDetails", Tags: []string{"work"}},
+	}}
+	b, err := Atom("default", "http://localhost/feed.atom", acts)
+	if err != nil {
+		t.Fatalf("atom: %v", err)
+	}
+	var f atomFeed
+	if err := xml.Unmarshal(b, &f); err != nil {
+		t.Fatalf("output is not valid xml: %v\n%s", err, b)
+	}
+	if len(f.Entries) != 1 || f.Entries[0].Title != "[closed] Ship <patch>" {
+		t.Fatalf("unexpected entries: %+v", f.Entries)
+	}
+	if a := f.Entries[0].Author; a == nil || a.Name != "Ada <ada@example.org>" {
+		t.Fatalf("entry author: %+v", a)
+	}
+	if f.Updated != "2025-08-26T13:40:00Z" || !strings.Contains(f.Entries[0].Content.Body, "Details") {
+		t.Fatalf("unexpected feed: %+v", f)
+	}
+}
@@ -0,0 +1,134 @@
+package ghsync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// API is the base URL of the GitHub REST API.
+const API = "https://api.github.com"
+
+// pageSize is how many issues one listing request asks for (GitHub's
+// maximum).
+const pageSize = 100
+
+// Issue is the subset of a GitHub issue the sync uses.
+type Issue struct {
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	State     string    `json:"state"`
+	Labels    []Label   `json:"labels"`
+	HTMLURL   string    `json:"html_url"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// PullRequest is set on pull requests, which the issues API lists too.
+	PullRequest json.RawMessage `json:"pull_request,omitempty"`
+}
+
+// Label is an issue label.
+type Label struct {
+	Name string `json:"name"`
+}
+
+// IssueEdit is the body of an issue create or update.
+type IssueEdit struct {
+	Title  string   `json:"title"`
+	Body   string   `json:"body"`
+	Labels []string `json:"labels"`
+	State  string   `json:"state,omitempty"`
+}
+
+// Client calls the issues API of one repository.
+type Client struct {
+	HTTP *http.Client
+	// Base is the API URL (default API), e.g. https://ghe.example.org/api/v3.
+	Base  string
+	Token string
+	// Repo is "owner/name".
+	Repo string
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	base := c.Base
+	if base == "" {
+		base = API
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(base, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	hc := c.HTTP
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("github %s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(msg))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Issues lists the repository's issues, open and closed, that carry
+// label. Pull requests are left out.
+func (c *Client) Issues(ctx context.Context, label string) ([]Issue, error) {
+	q := url.Values{"state": {"all"}, "labels": {label}, "sort": {"created"}, "direction": {"asc"}, "per_page": {strconv.Itoa(pageSize)}}
+	var out []Issue
+	for page := 1; ; page++ {
+		q.Set("page", strconv.Itoa(page))
+		var batch []Issue
+		if err := c.do(ctx, http.MethodGet, "/repos/"+c.Repo+"/issues?"+q.Encode(), nil, &batch); err != nil {
+			return nil, err
+		}
+		for _, is := range batch {
+			if len(is.PullRequest) == 0 {
+				out = append(out, is)
+			}
+		}
+		if len(batch) < pageSize {
+			return out, nil
+		}
+	}
+}
+
+// CreateIssue opens an issue.
+func (c *Client) CreateIssue(ctx context.Context, e IssueEdit) (Issue, error) {
+	var is Issue
+	err := c.do(ctx, http.MethodPost, "/repos/"+c.Repo+"/issues", e, &is)
+	return is, err
+}
+
+// UpdateIssue replaces an issue's title, body, labels and state.
+func (c *Client) UpdateIssue(ctx context.Context, number int, e IssueEdit) (Issue, error) {
+	var is Issue
+	err := c.do(ctx, http.MethodPatch, "/repos/"+c.Repo+"/issues/"+strconv.Itoa(number), e, &is)
+	return is, err
+}
@@ -0,0 +1,202 @@
+// Package ghsync keeps GitHub issues and tasks in step. Issues with a
+// label become tasks tagged with it and tasks with the tag become issues:
+// the issue title and body are the task text, labels are tags and a closed
+// issue is a done task. The task carries the issue URL in a GitHub-Issue
+// trailer, and the Store's sync bucket records, per issue, the task and
+// the issue's updated_at and task revision last synced (see
+// utask.SyncLink), so each run only moves what changed.
+package ghsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+// IssueTrailer holds the URL of a task's issue.
+const IssueTrailer = "GitHub-Issue"
+
+// Source names a repository's links in the Store's sync bucket.
+func Source(repo string) string { return "github." + repo }
+
+// Result counts what Sync did.
+type Result struct {
+	// Pulled counts tasks created or changed from issues.
+	Pulled int `json:"pulled"`
+	// Pushed counts issues opened or changed from tasks.
+	Pushed int `json:"pushed"`
+	// Conflicts counts tasks changed on both sides since the last sync;
+	// the issue wins, and the task's own change stays in its history.
+	Conflicts int `json:"conflicts"`
+}
+
+// Sync pulls the issues carrying label that changed since the last run
+// into tasks, then pushes the tasks tagged label that changed into issues,
+// opening issues for open tasks that have none.
+func Sync(ctx context.Context, store *utask.Store, c *Client, label string) (Result, error) {
+	var res Result
+	label = strings.ToLower(strings.TrimSpace(label))
+	if label == "" {
+		return res, errors.New("sync github: a label is required")
+	}
+	source := Source(c.Repo)
+	links, err := store.SyncLinks(ctx, source)
+	if err != nil {
+		return res, err
+	}
+	byTask := map[string]string{}
+	for item, l := range links {
+		byTask[l.Task] = item
+	}
+
+	// Every labelled issue is listed: a cursor on updated_at would skip
+	// edits made there while this run pushed other issues.
+	issues, err := c.Issues(ctx, label)
+	if err != nil {
+		return res, err
+	}
+	pulled := map[string]bool{}
+	for _, is := range issues {
+		item := strconv.Itoa(is.Number)
+		l, linked := links[item]
+		if linked && !is.UpdatedAt.After(l.Updated) {
+			continue
+		}
+		t, err := pullIssue(ctx, store, is, l, linked, &res)
+		if err != nil {
+			return res, fmt.Errorf("issue #%d: %w", is.Number, err)
+		}
+		l = utask.SyncLink{Task: t.ID, Updated: is.UpdatedAt, Revision: t.Revision}
+		if err := store.PutSyncLink(ctx, source, item, l); err != nil {
+			return res, err
+		}
+		links[item], byTask[t.ID], pulled[t.ID] = l, item, true
+		res.Pulled++
+	}
+
+	it, err := store.ListIter(ctx, utask.ListFilter{Tag: label})
+	if err != nil {
+		return res, err
+	}
+	var tasks []utask.Task
+	for it.Next() {
+		tasks = append(tasks, it.Task())
+	}
+	it.Close()
+	if err := it.Err(); err != nil {
+		return res, err
+	}
+	for _, t := range tasks {
+		if pulled[t.ID] {
+			continue
+		}
+		item, linked := byTask[t.ID]
+		if !linked {
+			if n := issueNumber(t, c.Repo); n != 0 {
+				item, linked = strconv.Itoa(n), true
+			}
+		}
+		switch {
+		case linked && links[item].Revision == t.Revision:
+			continue
+		case !linked && t.Done:
+			// Only open work becomes a new issue.
+			continue
+		}
+		edit := IssueFields(t)
+		var is Issue
+		if linked {
+			n, _ := strconv.Atoi(item)
+			is, err = c.UpdateIssue(ctx, n, edit)
+		} else {
+			edit.State = ""
+			if is, err = c.CreateIssue(ctx, edit); err == nil {
+				item = strconv.Itoa(is.Number)
+				text := utask.SetTrailer(t.Text, IssueTrailer, is.HTMLURL)
+				t, err = store.UpdateTask(ctx, t.ID, utask.UpdateSet{Text: &text, IfRevision: t.Revision})
+			}
+		}
+		if err != nil {
+			return res, fmt.Errorf("task %s: %w", t.Ref(), err)
+		}
+		l := utask.SyncLink{Task: t.ID, Updated: is.UpdatedAt, Revision: t.Revision}
+		if err := store.PutSyncLink(ctx, source, item, l); err != nil {
+			return res, err
+		}
+		res.Pushed++
+	}
+	return res, nil
+}
+
+// pullIssue writes an issue into its linked task, or a new one.
+func pullIssue(ctx context.Context, store *utask.Store, is Issue, l utask.SyncLink, linked bool, res *Result) (utask.Task, error) {
+	text, tags, done := TaskFields(is)
+	if linked {
+		_, rev, err := store.GetTask(ctx, l.Task)
+		switch {
+		case errors.Is(err, utask.ErrNotFound):
+			// Deleted here but changed there: bring it back.
+		case err != nil:
+			return utask.Task{}, err
+		default:
+			if rev != l.Revision {
+				res.Conflicts++
+				slog.Warn("task and issue both changed; taking the issue", "op", "sync", "task", l.Task, "issue", is.HTMLURL)
+			}
+			return store.UpdateTask(ctx, l.Task, utask.UpdateSet{Text: &text, Tags: &tags, Done: &done})
+		}
+	}
+	t, _, err := store.CreateTask(ctx, utask.TaskInput{Text: text, Tags: tags})
+	if err != nil || !done || t.Done {
+		return t, err
+	}
+	t, _, err = store.CloseTask(ctx, t.ID)
+	return t, err
+}
+
+// TaskFields maps an issue to task text (title, body and a GitHub-Issue
+// trailer), tags and done.
+func TaskFields(is Issue) (text string, tags []string, done bool) {
+	text = strings.TrimSpace(is.Title)
+	if body := strings.TrimSpace(strings.ReplaceAll(is.Body, "\r\n", "\n")); body != "" {
+		text += "\n\n" + body
+	}
+	text = utask.SetTrailer(text, IssueTrailer, is.HTMLURL)
+	tags = make([]string, 0, len(is.Labels))
+	for _, l := range is.Labels {
+		tags = append(tags, l.Name)
+	}
+	return text, tags, is.State == "closed"
+}
+
+// IssueFields maps a task to an issue: its first line is the title and the
+// rest, without the GitHub-Issue trailer, the body.
+func IssueFields(t utask.Task) IssueEdit {
+	title, body, _ := strings.Cut(utask.SetTrailer(t.Text, IssueTrailer, ""), "\n")
+	state := "open"
+	if t.Done {
+		state = "closed"
+	}
+	labels := append([]string{}, t.Tags...)
+	return IssueEdit{Title: strings.TrimSpace(title), Body: strings.TrimSpace(body), Labels: labels, State: state}
+}
+
+// issueNumber returns the number of the repo issue a task's GitHub-Issue
+// trailer names, or 0.
+func issueNumber(t utask.Task, repo string) int {
+	for _, tr := range t.Trailers() {
+		if !strings.EqualFold(tr.Key, IssueTrailer) {
+			continue
+		}
+		_, num, ok := strings.Cut(strings.TrimSpace(tr.Value), "/"+repo+"/issues/")
+		if n, err := strconv.Atoi(num); ok && err == nil && n > 0 {
+			return n
+		}
+	}
+	return 0
+}
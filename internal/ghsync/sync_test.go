@@ -0,0 +1,162 @@
+package ghsync
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+// fakeGitHub serves the issues API of one repository from memory.
+type fakeGitHub struct {
+	mu     sync.Mutex
+	url    string
+	issues []Issue
+	clock  time.Time
+}
+
+func (g *fakeGitHub) tick() time.Time {
+	g.clock = g.clock.Add(time.Second)
+	return g.clock
+}
+
+func (g *fakeGitHub) add(title, body, state string, labels ...string) int {
+	is := Issue{Number: len(g.issues) + 1, Title: title, Body: body, State: state, UpdatedAt: g.tick()}
+	for _, l := range labels {
+		is.Labels = append(is.Labels, Label{Name: l})
+	}
+	is.HTMLURL = g.url + "/o/r/issues/" + strconv.Itoa(is.Number)
+	g.issues = append(g.issues, is)
+	return is.Number
+}
+
+func (g *fakeGitHub) edit(n int, e IssueEdit) Issue {
+	is := &g.issues[n-1]
+	is.Title, is.Body, is.Labels = e.Title, e.Body, nil
+	for _, l := range e.Labels {
+		is.Labels = append(is.Labels, Label{Name: l})
+	}
+	if e.State != "" {
+		is.State = e.State
+	}
+	is.UpdatedAt = g.tick()
+	return *is
+}
+
+func (g *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/repos/o/r/issues")
+	var e IssueEdit
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&e)
+	}
+	switch {
+	case r.Method == http.MethodGet && path == "":
+		label := r.URL.Query().Get("labels")
+		out := []Issue{}
+		for _, is := range g.issues {
+			for _, l := range is.Labels {
+				if l.Name == label {
+					out = append(out, is)
+					break
+				}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	case r.Method == http.MethodPost && path == "":
+		_ = json.NewEncoder(w).Encode(g.edit(g.add("", "", "open"), e))
+	case r.Method == http.MethodPatch:
+		n, _ := strconv.Atoi(strings.TrimPrefix(path, "/"))
+		if n < 1 || n > len(g.issues) {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(g.edit(n, e))
+	default:
+		http.Error(w, "unexpected "+r.Method+" "+r.URL.Path, http.StatusBadRequest)
+	}
+}
+
+func TestSync(t *testing.T) {
+	ctx := context.Background()
+	store, err := utask.OpenSQLite(ctx, filepath.Join(t.TempDir(), "tasks.db"), "test", utask.Options{Identity: "Ada <ada@example.org>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(store.Close)
+
+	gh := &fakeGitHub{clock: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	srv := httptest.NewServer(gh)
+	t.Cleanup(srv.Close)
+	gh.url = srv.URL
+	c := &Client{HTTP: srv.Client(), Base: srv.URL, Repo: "o/r"}
+
+	open := gh.add("Fix the login page", "It 500s.", "open", "utask", "bug")
+	gh.add("Old report", "", "closed", "utask")
+	gh.add("Not ours", "", "open", "other")
+	local, _, err := store.CreateTask(ctx, utask.TaskInput{Text: "Write docs\n\nFor the API.", Tags: []string{"utask"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(want Result) {
+		t.Helper()
+		if got, err := Sync(ctx, store, c, "utask"); err != nil || got != want {
+			t.Fatalf("Sync = %+v, %v; want %+v", got, err, want)
+		}
+	}
+	run(Result{Pulled: 2, Pushed: 1})
+	run(Result{})
+
+	links, err := store.SyncLinks(ctx, Source("o/r"))
+	if err != nil || len(links) != 3 {
+		t.Fatalf("SyncLinks = %v, %v", links, err)
+	}
+	pulled, _, err := store.GetTask(ctx, links["1"].Task)
+	if err != nil || pulled.Done || !strings.HasPrefix(pulled.Text, "Fix the login page\n\nIt 500s.") || strings.Join(pulled.Tags, ",") != "bug,utask" {
+		t.Fatalf("task from issue #1 = %+v, %v", pulled, err)
+	}
+	if closed, _, err := store.GetTask(ctx, links["2"].Task); err != nil || !closed.Done {
+		t.Fatalf("task from closed issue #2 = %+v, %v", closed, err)
+	}
+	if links["4"].Task != local.ID || gh.issues[3].Title != "Write docs" || gh.issues[3].Body != "For the API." {
+		t.Fatalf("pushed issue = %+v, link %+v", gh.issues[3], links["4"])
+	}
+	if local, _, _ = store.GetTask(ctx, local.ID); issueNumber(local, "o/r") != 4 {
+		t.Fatalf("pushed task text = %q", local.Text)
+	}
+
+	// A change on either side travels to the other.
+	gh.edit(open, IssueEdit{Title: "Fix the login page", Body: "It 500s on submit.", Labels: []string{"utask"}, State: "closed"})
+	if _, _, err := store.CloseTask(ctx, local.ID); err != nil {
+		t.Fatal(err)
+	}
+	run(Result{Pulled: 1, Pushed: 1})
+	if pulled, _, _ = store.GetTask(ctx, pulled.ID); !pulled.Done || !strings.Contains(pulled.Text, "on submit") {
+		t.Fatalf("task after issue edit = %+v", pulled)
+	}
+	if gh.issues[3].State != "closed" {
+		t.Fatalf("issue after task close = %+v", gh.issues[3])
+	}
+	run(Result{})
+
+	// Both changed: the issue wins.
+	text := "Fix the login page\n\nlocal edit"
+	if _, err := store.UpdateTask(ctx, pulled.ID, utask.UpdateSet{Text: &text}); err != nil {
+		t.Fatal(err)
+	}
+	gh.edit(open, IssueEdit{Title: "Fix the login page", Body: "remote edit", Labels: []string{"utask"}})
+	run(Result{Pulled: 1, Conflicts: 1})
+	if pulled, _, _ = store.GetTask(ctx, pulled.ID); !strings.Contains(pulled.Text, "remote edit") {
+		t.Fatalf("task after conflict = %q", pulled.Text)
+	}
+}
@@ -0,0 +1,297 @@
+// Package grpcapi serves a Store as the utask.v1.TaskService gRPC service
+// (see api/utask/v1/task.proto) for `ut serve --grpc`.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	utaskv1 "github.com/iainlowe/utask/api/utask/v1"
+	"github.com/iainlowe/utask/internal/httpapi"
+	"github.com/iainlowe/utask/internal/utask"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server implements utaskv1.TaskServiceServer on a shared Store.
+type Server struct {
+	utaskv1.UnimplementedTaskServiceServer
+	store  *utask.Store
+	tokens map[string]httpapi.Grant
+}
+
+// New returns a gRPC server with the task service backed by store. When
+// tokens is non-empty, calls must send a listed token as "authorization:
+// Bearer <token>" metadata and run as its role and identity, as on the
+// REST API. The store must outlive the server.
+func New(store *utask.Store, tokens map[string]httpapi.Grant, opts ...grpc.ServerOption) *grpc.Server {
+	s := &Server{store: store, tokens: tokens}
+	opts = append(opts, grpc.UnaryInterceptor(s.unary), grpc.StreamInterceptor(s.stream))
+	gs := grpc.NewServer(opts...)
+	utaskv1.RegisterTaskServiceServer(gs, s)
+	return gs
+}
+
+func (s *Server) unary(ctx context.Context, req any, _ *grpc.UnaryServerInfo, h grpc.UnaryHandler) (any, error) {
+	ctx, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := h(ctx, req)
+	return resp, statusError(err)
+}
+
+func (s *Server) stream(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, h grpc.StreamHandler) error {
+	ctx, err := s.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return statusError(h(srv, &authedStream{ServerStream: ss, ctx: ctx}))
+}
+
+// authedStream carries the caller's role and identity into a stream.
+type authedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (a *authedStream) Context() context.Context { return a.ctx }
+
+// authenticate checks the call's bearer token and returns ctx with its
+// role and identity, like httpapi.Authenticate.
+func (s *Server) authenticate(ctx context.Context) (context.Context, error) {
+	if len(s.tokens) == 0 {
+		return ctx, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		tok, ok := strings.CutPrefix(v, "Bearer ")
+		if g, known := s.tokens[strings.TrimSpace(tok)]; ok && known {
+			return utask.WithIdentity(utask.WithRole(ctx, g.Role), g.Identity), nil
+		}
+	}
+	return nil, status.Error(codes.Unauthenticated, "missing or unknown access token")
+}
+
+// statusError maps Store errors to gRPC status codes.
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	code := codes.Internal
+	switch {
+	case errors.Is(err, utask.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, utask.ErrForbidden):
+		code = codes.PermissionDenied
+	case errors.Is(err, utask.ErrConflict):
+		code = codes.Aborted
+	case errors.Is(err, utask.ErrAmbiguousPrefix):
+		code = codes.FailedPrecondition
+	case errors.Is(err, utask.ErrValidation), strings.HasPrefix(err.Error(), "invalid"):
+		code = codes.InvalidArgument
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	}
+	return status.Error(code, err.Error())
+}
+
+func (s *Server) Create(ctx context.Context, req *utaskv1.CreateRequest) (*utaskv1.CreateResponse, error) {
+	if strings.TrimSpace(req.GetText()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "empty text")
+	}
+	t, existed, err := s.store.CreateTask(ctx, utask.TaskInput{
+		Text:            req.GetText(),
+		Tags:            req.GetTags(),
+		Priority:        int(req.GetPriority()),
+		EstimateMinutes: int(req.GetEstimateMinutes()),
+		Assignee:        req.GetAssignee(),
+		Private:         req.GetPrivate(),
+		Contexts:        req.GetContexts(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &utaskv1.CreateResponse{Task: toProto(t), Existed: existed}, nil
+}
+
+func (s *Server) Get(ctx context.Context, req *utaskv1.GetRequest) (*utaskv1.Task, error) {
+	id, _, err := s.store.Resolve(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	t, _, err := s.store.GetTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toProto(t), nil
+}
+
+func (s *Server) Update(ctx context.Context, req *utaskv1.UpdateRequest) (*utaskv1.Task, error) {
+	id, _, err := s.store.Resolve(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	set := utask.UpdateSet{
+		Text:       req.Text,
+		Done:       req.Done,
+		Assignee:   req.Assignee,
+		Private:    req.Private,
+		IfRevision: req.GetIfRevision(),
+	}
+	if req.Tags != nil {
+		tags := req.Tags.GetTags()
+		set.Tags = &tags
+	}
+	if req.Priority != nil {
+		p := int(req.GetPriority())
+		set.Priority = &p
+	}
+	t, err := s.store.UpdateTask(ctx, id, set)
+	if err != nil {
+		return nil, err
+	}
+	return toProto(t), nil
+}
+
+func (s *Server) Close(ctx context.Context, req *utaskv1.TaskRef) (*utaskv1.Task, error) {
+	id, _, err := s.store.Resolve(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	t, _, err := s.store.CloseTaskIf(ctx, id, req.GetIfRevision())
+	if err != nil {
+		return nil, err
+	}
+	return toProto(t), nil
+}
+
+func (s *Server) Reopen(ctx context.Context, req *utaskv1.TaskRef) (*utaskv1.Task, error) {
+	id, _, err := s.store.Resolve(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	t, _, err := s.store.ReopenTaskIf(ctx, id, req.GetIfRevision())
+	if err != nil {
+		return nil, err
+	}
+	return toProto(t), nil
+}
+
+func (s *Server) Delete(ctx context.Context, req *utaskv1.TaskRef) (*utaskv1.DeleteResponse, error) {
+	id, _, err := s.store.Resolve(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	id, err = s.store.DeleteTaskIf(ctx, id, req.GetIfRevision())
+	if err != nil {
+		return nil, err
+	}
+	return &utaskv1.DeleteResponse{Id: id}, nil
+}
+
+func (s *Server) List(req *utaskv1.ListRequest, stream utaskv1.TaskService_ListServer) error {
+	f := utask.ListFilter{
+		Tag:   req.GetTag(),
+		Any:   req.GetAnyTags(),
+		All:   req.GetAllTags(),
+		Limit: int(req.GetLimit()),
+	}
+	if st := req.GetStatus(); st != "" {
+		var err error
+		if f.Status, err = utask.ParseStatus(st); err != nil {
+			return err
+		}
+	}
+	if a := req.GetAssignee(); a == "none" {
+		f.Unassigned = true
+	} else {
+		f.Assignee = a
+	}
+	if c := req.GetContext(); c != "" {
+		name, err := utask.ParseContext(c)
+		if err != nil {
+			return err
+		}
+		f.Context = name
+	}
+	return s.send(stream, f)
+}
+
+func (s *Server) Query(req *utaskv1.QueryRequest, stream utaskv1.TaskService_QueryServer) error {
+	return s.send(stream, utask.ListFilter{Any: req.GetAnyTags(), All: req.GetAllTags(), Limit: int(req.GetLimit())})
+}
+
+// send streams the tasks f selects.
+func (s *Server) send(stream grpc.ServerStreamingServer[utaskv1.Task], f utask.ListFilter) error {
+	it, err := s.store.ListIter(stream.Context(), f)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	for it.Next() {
+		if err := stream.Send(toProto(it.Task())); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+func (s *Server) Watch(req *utaskv1.WatchRequest, stream utaskv1.TaskService_WatchServer) error {
+	ctx := stream.Context()
+	events, err := s.store.Watch(ctx, utask.WatchFilter{Tag: req.GetTag()})
+	if err != nil {
+		return err
+	}
+	for ev := range events {
+		pe := &utaskv1.Event{Op: string(ev.Op), Kind: string(ev.Kind), Id: ev.ID, Revision: ev.Revision, Time: timestamppb.New(ev.Time)}
+		if ev.Task != nil {
+			pe.Task = toProto(*ev.Task)
+		}
+		if err := stream.Send(pe); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+func toProto(t utask.Task) *utaskv1.Task {
+	return &utaskv1.Task{
+		Id:              t.ID,
+		Number:          t.Number,
+		Text:            t.Text,
+		Status:          string(t.State()),
+		Done:            t.Done,
+		Tags:            t.Tags,
+		Created:         t.Created,
+		Priority:        int32(t.Priority),
+		EstimateMinutes: int32(t.EstimateMinutes),
+		CreatedBy:       t.CreatedBy,
+		UpdatedBy:       t.UpdatedBy,
+		Assignee:        t.Assignee,
+		Private:         t.Private,
+		Contexts:        t.Contexts,
+		DependsOn:       t.DependsOn,
+		Recur:           t.Recur,
+		Scheduled:       timestamp(t.Scheduled),
+		SnoozedUntil:    timestamp(t.SnoozedUntil),
+		Revision:        t.Revision,
+	}
+}
+
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
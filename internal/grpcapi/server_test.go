@@ -0,0 +1,157 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+
+	utaskv1 "github.com/iainlowe/utask/api/utask/v1"
+	"github.com/iainlowe/utask/internal/httpapi"
+	"github.com/iainlowe/utask/internal/utask"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dial serves store over an in-memory listener and returns a client.
+func dial(t *testing.T, store *utask.Store, tokens map[string]httpapi.Grant) utaskv1.TaskServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := New(store, tokens)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return utaskv1.NewTaskServiceClient(conn)
+}
+
+func openStore(t *testing.T) *utask.Store {
+	t.Helper()
+	s, err := utask.OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "tasks.db"), "test", utask.Options{Identity: "Ada <ada@example.org>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+func collect(t *testing.T, recv func() (*utaskv1.Task, error)) []*utaskv1.Task {
+	t.Helper()
+	var out []*utaskv1.Task
+	for {
+		task, err := recv()
+		if errors.Is(err, io.EOF) {
+			return out
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, task)
+	}
+}
+
+func TestTaskService(t *testing.T) {
+	ctx := context.Background()
+	c := dial(t, openStore(t), nil)
+
+	created, err := c.Create(ctx, &utaskv1.CreateRequest{Text: "ship it", Tags: []string{"Release", "ops"}, Priority: 2})
+	if err != nil || created.Existed || created.Task.Number != 1 || created.Task.Status != "open" {
+		t.Fatalf("Create = %+v, %v", created, err)
+	}
+	task := created.Task
+	if _, err := c.Create(ctx, &utaskv1.CreateRequest{Text: "write notes", Tags: []string{"ops"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.Get(ctx, &utaskv1.GetRequest{Id: "T-1"}); err != nil || got.Id != task.Id {
+		t.Fatalf("Get T-1 = %+v, %v", got, err)
+	}
+
+	text := "ship it\n\nafter the freeze"
+	updated, err := c.Update(ctx, &utaskv1.UpdateRequest{Id: task.Id[:12], Text: &text, Tags: &utaskv1.Tags{Tags: []string{"release"}}, IfRevision: task.Revision})
+	if err != nil || updated.Text != text || len(updated.Tags) != 1 {
+		t.Fatalf("Update = %+v, %v", updated, err)
+	}
+	if _, err := c.Update(ctx, &utaskv1.UpdateRequest{Id: task.Id, Text: &text, IfRevision: task.Revision}); status.Code(err) != codes.Aborted {
+		t.Fatalf("stale Update: %v, want Aborted", err)
+	}
+
+	list, err := c.List(ctx, &utaskv1.ListRequest{Tag: "ops"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := collect(t, list.Recv); len(got) != 1 || got[0].Text != "write notes" {
+		t.Fatalf("List tag=ops = %v", got)
+	}
+	query, err := c.Query(ctx, &utaskv1.QueryRequest{AnyTags: []string{"ops", "release"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := collect(t, query.Recv); len(got) != 2 {
+		t.Fatalf("Query = %v", got)
+	}
+
+	if closed, err := c.Close(ctx, &utaskv1.TaskRef{Id: task.Id}); err != nil || !closed.Done || closed.Status != "done" {
+		t.Fatalf("Close = %+v, %v", closed, err)
+	}
+	if reopened, err := c.Reopen(ctx, &utaskv1.TaskRef{Id: task.Id}); err != nil || reopened.Done {
+		t.Fatalf("Reopen = %+v, %v", reopened, err)
+	}
+	if del, err := c.Delete(ctx, &utaskv1.TaskRef{Id: task.Id}); err != nil || del.Id != task.Id {
+		t.Fatalf("Delete = %+v, %v", del, err)
+	}
+	if _, err := c.Get(ctx, &utaskv1.GetRequest{Id: task.Id}); status.Code(err) != codes.NotFound {
+		t.Fatalf("Get after delete: %v, want NotFound", err)
+	}
+	if _, err := c.Create(ctx, &utaskv1.CreateRequest{Text: " "}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Create empty: %v, want InvalidArgument", err)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	ctx := context.Background()
+	c := dial(t, openStore(t), map[string]httpapi.Grant{"s3cret": {Role: utask.RoleReader}})
+	if _, err := c.Create(ctx, &utaskv1.CreateRequest{Text: "x"}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Create without token: %v, want Unauthenticated", err)
+	}
+	authed := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer s3cret")
+	if _, err := c.Create(authed, &utaskv1.CreateRequest{Text: "x"}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("Create as reader: %v, want PermissionDenied", err)
+	}
+	list, err := c.List(authed, &utaskv1.ListRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := collect(t, list.Recv); len(got) != 0 {
+		t.Fatalf("List = %v", got)
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	cases := map[error]codes.Code{
+		fmt.Errorf("task x: %w", utask.ErrNotFound):       codes.NotFound,
+		&utask.AmbiguousError{Prefix: "a"}:                codes.FailedPrecondition,
+		fmt.Errorf("update: %w", utask.ErrConflict):       codes.Aborted,
+		fmt.Errorf("delete: %w", utask.ErrForbidden):      codes.PermissionDenied,
+		fmt.Errorf("%w: empty text", utask.ErrValidation): codes.InvalidArgument,
+		errors.New("invalid status: x"):                   codes.InvalidArgument,
+		errors.New("boom"):                                codes.Internal,
+		status.Error(codes.Unavailable, "already mapped"): codes.Unavailable,
+	}
+	for err, want := range cases {
+		if got := status.Code(statusError(err)); got != want {
+			t.Fatalf("%q: got %s, want %s", err, got, want)
+		}
+	}
+}
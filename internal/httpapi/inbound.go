@@ -0,0 +1,147 @@
+package httpapi
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+
+	conf "github.com/iainlowe/utask/internal/config"
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+// maxInboundBody caps inbound webhook payloads.
+const maxInboundBody = 1 << 20
+
+// findHook returns the hook whose token matches, comparing in constant time.
+func (s *Server) findHook(token string) (conf.InboundHook, bool) {
+	for _, h := range s.opts.Inbound {
+		if h.Token != "" && subtle.ConstantTimeCompare([]byte(h.Token), []byte(token)) == 1 {
+			return h, true
+		}
+	}
+	return conf.InboundHook{}, false
+}
+
+// handleInbound creates a task from a JSON or form post using the templates of
+// the hook matching the path token.
+func (s *Server) handleInbound(w http.ResponseWriter, r *http.Request) {
+	hook, ok := s.findHook(r.PathValue("token"))
+	if !ok {
+		writeError(w, utask.ErrNotFound)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxInboundBody)
+	fields, err := inboundFields(r)
+	if err != nil {
+		writeError(w, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+	in, err := renderHook(hook, fields)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	t, existed, err := s.store.CreateTask(r.Context(), in)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	code := http.StatusCreated
+	if existed {
+		code = http.StatusOK
+	}
+	writeJSON(w, code, map[string]any{"id": t.ID, "existed": existed})
+}
+
+// inboundFields flattens a JSON object or form post into string fields.
+// Nested JSON values are re-encoded as JSON strings.
+func inboundFields(r *http.Request) (map[string]string, error) {
+	out := map[string]string{}
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch ct {
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		if err := r.ParseMultipartForm(maxInboundBody); err != nil && err != http.ErrNotMultipart {
+			return nil, err
+		}
+		for k, v := range r.Form {
+			out[k] = strings.Join(v, ",")
+		}
+	default:
+		var raw map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+			return nil, err
+		}
+		for k, v := range raw {
+			switch x := v.(type) {
+			case string:
+				out[k] = x
+			case nil:
+				out[k] = ""
+			case float64:
+				out[k] = strconv.FormatFloat(x, 'f', -1, 64)
+			case bool:
+				out[k] = strconv.FormatBool(x)
+			default:
+				b, _ := json.Marshal(x)
+				out[k] = string(b)
+			}
+		}
+	}
+	return out, nil
+}
+
+// renderHook applies the hook templates to fields. Without a text template
+// the "text" or "title" field is used as-is.
+func renderHook(h conf.InboundHook, fields map[string]string) (utask.TaskInput, error) {
+	render := func(name, tmpl string) (string, error) {
+		t, err := template.New(name).Option("missingkey=zero").Parse(tmpl)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s template: %w", name, err)
+		}
+		var b bytes.Buffer
+		if err := t.Execute(&b, fields); err != nil {
+			return "", fmt.Errorf("invalid %s template: %w", name, err)
+		}
+		return b.String(), nil
+	}
+	var in utask.TaskInput
+	var err error
+	switch {
+	case h.Text != "":
+		if in.Text, err = render("text", h.Text); err != nil {
+			return in, err
+		}
+	case fields["text"] != "":
+		in.Text = fields["text"]
+	default:
+		in.Text = fields["title"]
+	}
+	if strings.TrimSpace(in.Text) == "" {
+		return in, fmt.Errorf("%w: empty text", utask.ErrValidation)
+	}
+	for _, tt := range h.Tags {
+		v, err := render("tags", tt)
+		if err != nil {
+			return in, err
+		}
+		in.Tags = append(in.Tags, strings.Split(v, ",")...)
+	}
+	if h.Priority != "" {
+		v, err := render("priority", h.Priority)
+		if err != nil {
+			return in, err
+		}
+		if v = strings.TrimSpace(v); v != "" {
+			if in.Priority, err = strconv.Atoi(v); err != nil {
+				return in, fmt.Errorf("invalid priority: %q", v)
+			}
+		}
+	}
+	return in, nil
+}
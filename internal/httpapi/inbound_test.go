@@ -0,0 +1,48 @@
+package httpapi
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	conf "github.com/iainlowe/utask/internal/config"
+)
+
+func TestRenderHook(t *testing.T) {
+	h := conf.InboundHook{
+		Text:     "{{.title}}\n\n{{.body}}",
+		Tags:     []string{"inbox", "{{.labels}}"},
+		Priority: "{{.prio}}",
+	}
+	in, err := renderHook(h, map[string]string{"title": "Call Bob", "body": "re: invoice", "labels": "phone,work", "prio": "2"})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if in.Text != "Call Bob\n\nre: invoice" || in.Priority != 2 {
+		t.Fatalf("unexpected input: %+v", in)
+	}
+	if strings.Join(in.Tags, ",") != "inbox,phone,work" {
+		t.Fatalf("unexpected tags: %v", in.Tags)
+	}
+	if _, err := renderHook(conf.InboundHook{}, map[string]string{}); err == nil {
+		t.Fatalf("expected empty text error")
+	}
+}
+
+func TestInboundFieldsForm(t *testing.T) {
+	r := httptest.NewRequest("POST", "/v1/inbound/x", strings.NewReader("title=Buy+milk&tag=a&tag=b"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	f, err := inboundFields(r)
+	if err != nil || f["title"] != "Buy milk" || f["tag"] != "a,b" {
+		t.Fatalf("unexpected fields %v err=%v", f, err)
+	}
+}
+
+func TestInboundUnknownToken(t *testing.T) {
+	s := New(nil, Options{Inbound: []conf.InboundHook{{Token: "secret"}}})
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("POST", "/v1/inbound/nope", strings.NewReader("{}")))
+	if rec.Code != 404 {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
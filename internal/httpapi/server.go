@@ -0,0 +1,567 @@
+// Package httpapi exposes a Store over a small JSON REST API, an SSE event
+// stream, and the embedded web dashboard served by `ut serve`.
+package httpapi
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	conf "github.com/iainlowe/utask/internal/config"
+	"github.com/iainlowe/utask/internal/feed"
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+//go:embed web
+var webFS embed.FS
+
+// Options configures a Server.
+type Options struct {
+	// Profile names the store's namespace in feeds.
+	Profile string
+	// Inbound lists the webhook tokens accepted at /v1/inbound/<token>.
+	Inbound []conf.InboundHook
+	// Tokens maps access tokens to what their requests may do. When it is
+	// non-empty, API and feed requests must present a listed token as
+	// "Authorization: Bearer <token>" or an access_token query parameter.
+	Tokens map[string]Grant
+	// Hosts lists the names requests may address the server by, besides
+	// localhost, 127.0.0.1 and [::1]; a request whose Host header names
+	// any other host is refused. That stops a web page from reaching the
+	// server through a DNS name rebound to its address. Nil allows any host.
+	Hosts []string
+}
+
+// Grant is what requests presenting an access token run as.
+type Grant struct {
+	Role utask.Role
+	// Identity stamps the requests' writes and decides which private tasks
+	// they see; empty is anonymous.
+	Identity string
+}
+
+// Server routes HTTP requests to a shared Store.
+type Server struct {
+	store *utask.Store
+	opts  Options
+	mux   *http.ServeMux
+}
+
+// New returns a Server backed by store. The store must outlive the server.
+func New(store *utask.Store, opts Options) *Server {
+	s := &Server{store: store, opts: opts, mux: http.NewServeMux()}
+	s.routes()
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Hosts != nil && !AllowedHost(r.Host, s.opts.Hosts) {
+		writeJSON(w, http.StatusForbidden, map[string]any{"error": "unknown host " + strconv.Quote(r.Host)})
+		return
+	}
+	if !safeMethod(r.Method) && !sameOrigin(r) {
+		writeJSON(w, http.StatusForbidden, map[string]any{"error": "cross-origin request refused"})
+		return
+	}
+	if needsToken(r.URL.Path) {
+		var ok bool
+		if r, ok = Authenticate(r, s.opts.Tokens); !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="utask"`)
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "missing or unknown access token"})
+			return
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// Authenticate checks r's access token against tokens and returns r with
+// the token's role and identity in its context. It reports false when r
+// presents no listed token; with no tokens every request passes as is.
+func Authenticate(r *http.Request, tokens map[string]Grant) (*http.Request, bool) {
+	if len(tokens) == 0 {
+		return r, true
+	}
+	tok := requestToken(r)
+	g, ok := tokens[tok]
+	if tok == "" || !ok {
+		return r, false
+	}
+	return r.WithContext(utask.WithIdentity(utask.WithRole(r.Context(), g.Role), g.Identity)), true
+}
+
+// safeMethod reports whether method only reads.
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// AllowedHost reports whether host, a Host header, names localhost,
+// 127.0.0.1, [::1] or one of hosts; ports are ignored.
+func AllowedHost(host string, hosts []string) bool {
+	name := hostName(host)
+	switch name {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	for _, h := range hosts {
+		if h == "*" || hostName(h) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// hostName strips the port and brackets from a host[:port] and lowercases
+// what is left.
+func hostName(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.Trim(host, "[]"))
+}
+
+// sameOrigin reports whether r comes from a page this server served, or
+// from a client that isn't a browser and sends no Origin. Refusing other
+// writes stops any web page the user visits from changing tasks through a
+// server on their machine or network (CSRF); Options.Hosts covers pages
+// served from a name rebound to the server.
+func sameOrigin(r *http.Request) bool {
+	o := r.Header.Get("Origin")
+	if o == "" {
+		return true
+	}
+	u, err := url.Parse(o)
+	return err == nil && u.Host == r.Host
+}
+
+// needsToken reports whether path is guarded by Options.Tokens. The
+// dashboard's static files and the health probes are public, and inbound
+// hooks carry their own token in the path.
+func needsToken(path string) bool {
+	if strings.HasPrefix(path, "/v1/inbound/") {
+		return false
+	}
+	return strings.HasPrefix(path, "/v1/") || path == "/feed.atom"
+}
+
+// requestToken reads a bearer token, falling back to the access_token query
+// parameter for clients such as EventSource that cannot set headers.
+func requestToken(r *http.Request) string {
+	if tok, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(tok)
+	}
+	return r.URL.Query().Get("access_token")
+}
+
+func (s *Server) routes() {
+	ui, _ := fs.Sub(webFS, "web")
+	s.mux.Handle("GET /", http.FileServer(http.FS(ui)))
+	s.mux.HandleFunc("GET /v1/tasks", s.handleList)
+	s.mux.HandleFunc("POST /v1/tasks", s.handleCreate)
+	s.mux.HandleFunc("GET /v1/tasks/{id}", s.handleGet)
+	s.mux.HandleFunc("PATCH /v1/tasks/{id}", s.handleUpdate)
+	s.mux.HandleFunc("DELETE /v1/tasks/{id}", s.handleDelete)
+	s.mux.HandleFunc("POST /v1/tasks/{id}/close", s.handleClose)
+	s.mux.HandleFunc("POST /v1/tasks/{id}/reopen", s.handleReopen)
+	s.mux.HandleFunc("POST /v1/tasks/{id}/approve", s.handleApprove)
+	s.mux.HandleFunc("GET /v1/tags", s.handleTags)
+	s.mux.HandleFunc("GET /v1/events", s.handleEvents)
+	s.mux.HandleFunc("GET /feed.atom", s.handleAtom)
+	s.mux.HandleFunc("POST /v1/inbound/{token}", s.handleInbound)
+	s.mux.HandleFunc("GET /healthz", s.handleHealthz)
+	s.mux.HandleFunc("GET /readyz", s.handleReadyz)
+}
+
+// readyTimeout bounds the probes behind /readyz.
+const readyTimeout = 5 * time.Second
+
+// handleHealthz is a liveness probe: it fails only when the NATS connection
+// is down, so a slow server doesn't get the process restarted.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if err := s.store.Live(); err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
+// handleReadyz is a readiness probe: NATS, every bucket and a KV watcher
+// must all respond.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+	h := s.store.Health(ctx)
+	code := http.StatusOK
+	if !h.OK {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, h)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	switch msg := err.Error(); {
+	case errors.Is(err, utask.ErrNotFound):
+		code = http.StatusNotFound
+	case errors.Is(err, utask.ErrForbidden):
+		code = http.StatusForbidden
+	case errors.Is(err, utask.ErrAmbiguousPrefix), errors.Is(err, utask.ErrConflict):
+		code = http.StatusConflict
+	case errors.Is(err, utask.ErrValidation), strings.HasPrefix(msg, "invalid"):
+		// Handler-level parse errors ("invalid status: x") are plain strings.
+		code = http.StatusBadRequest
+	}
+	body := map[string]any{"error": err.Error()}
+	var amb *utask.AmbiguousError
+	if errors.As(err, &amb) {
+		body["candidates"] = amb.Candidates
+	}
+	writeJSON(w, code, body)
+}
+
+// resolve maps a path id (full or prefix) to a full task id.
+func (s *Server) resolve(r *http.Request) (string, error) {
+	rid, _, err := s.store.Resolve(r.Context(), r.PathValue("id"))
+	return rid, err
+}
+
+// ifMatch reads an expected task revision from the If-Match header, which
+// carries the revision from a task response, quoted like an ETag or bare.
+// A missing header means any revision.
+func ifMatch(r *http.Request) (uint64, error) {
+	v := strings.TrimSpace(r.Header.Get("If-Match"))
+	if v == "" || v == "*" {
+		return 0, nil
+	}
+	rev, err := strconv.ParseUint(strings.Trim(v, `"`), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid If-Match: %q", v)
+	}
+	return rev, nil
+}
+
+func splitTags(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var sf utask.Status
+	if st := q.Get("status"); st != "" {
+		var err error
+		if sf, err = utask.ParseStatus(st); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	key, err := utask.ParseSortKey(q.Get("sort"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	f := utask.ListFilter{
+		Tag:     q.Get("tag"),
+		Any:     splitTags(q.Get("tags")),
+		All:     splitTags(q.Get("all-tags")),
+		Status:  sf,
+		Sort:    key,
+		Reverse: q.Get("reverse") == "true",
+	}
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit < 0 {
+			writeError(w, fmt.Errorf("invalid limit: %s", v))
+			return
+		}
+	}
+	if a := q.Get("assignee"); a == "none" {
+		f.Unassigned = true
+	} else {
+		f.Assignee = a
+	}
+	if c := q.Get("context"); c != "" {
+		name, err := utask.ParseContext(c)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		f.Context = name
+	}
+	page, err := s.store.List(r.Context(), f, q.Get("cursor"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if page.Next != "" {
+		// The body stays a plain array; the next page is linked as on GitHub.
+		q.Set("cursor", page.Next)
+		q.Del("access_token")
+		w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.Path, q.Encode()))
+	}
+	writeJSON(w, http.StatusOK, page.Tasks)
+}
+
+type createRequest struct {
+	Text            string   `json:"text"`
+	Details         string   `json:"details"`
+	Tags            []string `json:"tags"`
+	Priority        int      `json:"priority"`
+	EstimateMinutes int      `json:"estimate_minutes"`
+	Assignee        string   `json:"assignee"`
+	Private         bool     `json:"private"`
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req createRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		writeError(w, fmt.Errorf("%w: empty text", utask.ErrValidation))
+		return
+	}
+	t, existed, err := s.store.CreateTask(r.Context(), utask.TaskInput{
+		Text:            req.Text,
+		Details:         req.Details,
+		Tags:            req.Tags,
+		Priority:        req.Priority,
+		EstimateMinutes: req.EstimateMinutes,
+		Assignee:        req.Assignee,
+		Private:         req.Private,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	code := http.StatusCreated
+	if existed {
+		code = http.StatusOK
+	}
+	writeJSON(w, code, t)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	id, err := s.resolve(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	t, rev, err := s.store.GetTask(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatUint(rev, 10)))
+	writeJSON(w, http.StatusOK, t)
+}
+
+type updateRequest struct {
+	Text     *string   `json:"text"`
+	Details  *string   `json:"details"`
+	Done     *bool     `json:"done"`
+	Tags     *[]string `json:"tags"`
+	Priority *int      `json:"priority"`
+	Assignee *string   `json:"assignee"`
+	Private  *bool     `json:"private"`
+}
+
+func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	id, err := s.resolve(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req updateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+	ifRev, err := ifMatch(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	t, err := s.store.UpdateTask(r.Context(), id, utask.UpdateSet{
+		Text:       req.Text,
+		Details:    req.Details,
+		Done:       req.Done,
+		Tags:       req.Tags,
+		Priority:   req.Priority,
+		Assignee:   req.Assignee,
+		Private:    req.Private,
+		IfRevision: ifRev,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	id, err := s.resolve(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	ifRev, err := ifMatch(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	delID, err := s.store.DeleteTaskIf(r.Context(), id, ifRev)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"id": delID})
+}
+
+func (s *Server) handleClose(w http.ResponseWriter, r *http.Request) {
+	id, err := s.resolve(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	ifRev, err := ifMatch(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	t, _, err := s.store.CloseTaskIf(r.Context(), id, ifRev)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) handleReopen(w http.ResponseWriter, r *http.Request) {
+	id, err := s.resolve(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	ifRev, err := ifMatch(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	t, _, err := s.store.ReopenTaskIf(r.Context(), id, ifRev)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) handleApprove(w http.ResponseWriter, r *http.Request) {
+	id, err := s.resolve(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	ifRev, err := ifMatch(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	t, err := s.store.ApproveTask(r.Context(), id, ifRev)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) handleTags(w http.ResponseWriter, r *http.Request) {
+	counts, err := s.store.ListTags(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, counts)
+}
+
+// handleEvents streams task changes as Server-Sent Events; ?tag= narrows
+// them to one tag.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, fmt.Errorf("streaming unsupported"))
+		return
+	}
+	events, err := s.store.Watch(r.Context(), utask.WatchFilter{Tag: r.URL.Query().Get("tag")})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+	for ev := range events {
+		b, _ := json.Marshal(ev)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Op, b)
+		flusher.Flush()
+	}
+}
+
+// handleAtom serves recent created/closed activity as an Atom feed. Query
+// parameters: since (e.g. 7d, default 30d) and limit (default 50).
+func (s *Server) handleAtom(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	window := 30 * 24 * time.Hour
+	if v := q.Get("since"); v != "" {
+		d, err := utask.ParseDuration(v)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		window = d
+	}
+	limit := 50
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, fmt.Errorf("invalid limit: %s", v))
+			return
+		}
+		limit = n
+	}
+	acts, err := s.store.RecentActivity(r.Context(), time.Now().Add(-window), limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	b, err := feed.Atom(s.opts.Profile, scheme+"://"+r.Host+r.URL.Path, acts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,145 @@
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+func TestServesDashboard(t *testing.T) {
+	s := New(nil, Options{Profile: "default"})
+	for _, path := range []string{"/", "/app.js"} {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d", path, rec.Code)
+		}
+	}
+}
+
+func TestWriteErrorStatus(t *testing.T) {
+	cases := map[error]int{
+		fmt.Errorf("task x: %w", utask.ErrNotFound):                                               http.StatusNotFound,
+		&utask.AmbiguousError{Prefix: "a", Candidates: []utask.Candidate{{ID: "a1"}, {ID: "a2"}}}: http.StatusConflict,
+		fmt.Errorf("update: %w", utask.ErrConflict):                                               http.StatusConflict,
+		fmt.Errorf("delete: %w", utask.ErrForbidden):                                              http.StatusForbidden,
+		fmt.Errorf("%w: empty text", utask.ErrValidation):                                         http.StatusBadRequest,
+		errors.New("invalid status: x"):                                                           http.StatusBadRequest,
+		errors.New("boom"):                                                                        http.StatusInternalServerError,
+	}
+	for err, want := range cases {
+		rec := httptest.NewRecorder()
+		writeError(rec, err)
+		if rec.Code != want || !strings.Contains(rec.Body.String(), "error") {
+			t.Fatalf("%q: got %d, want %d", err, rec.Code, want)
+		}
+	}
+}
+
+func TestTokenRequired(t *testing.T) {
+	s := New(nil, Options{Tokens: map[string]Grant{"s3cret": {Role: utask.RoleReader}}})
+	cases := map[string]int{
+		"/v1/tasks":                http.StatusUnauthorized,
+		"/v1/tasks?access_token=x": http.StatusUnauthorized,
+		"/feed.atom":               http.StatusUnauthorized,
+		"/":                        http.StatusOK,
+		"/v1/inbound/nope":         http.StatusNotFound,
+	}
+	for path, want := range cases {
+		rec := httptest.NewRecorder()
+		method := http.MethodGet
+		if strings.HasPrefix(path, "/v1/inbound/") {
+			method = http.MethodPost
+		}
+		s.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader("{}")))
+		if rec.Code != want {
+			t.Fatalf("%s %s: got %d, want %d", method, path, rec.Code, want)
+		}
+	}
+}
+
+func TestCrossOriginWrites(t *testing.T) {
+	s := New(nil, Options{})
+	cases := []struct {
+		method, origin string
+		want           int
+	}{
+		{http.MethodPost, "https://evil.example", http.StatusForbidden},
+		{http.MethodDelete, "null", http.StatusForbidden},
+		{http.MethodPost, "http://example.com", http.StatusNotFound},
+		{http.MethodPost, "", http.StatusNotFound},
+		{http.MethodGet, "https://evil.example", http.StatusOK},
+	}
+	for _, tc := range cases {
+		path := "/v1/inbound/nope"
+		if tc.method == http.MethodGet {
+			path = "/"
+		}
+		r := httptest.NewRequest(tc.method, path, strings.NewReader("{}"))
+		if tc.origin != "" {
+			r.Header.Set("Origin", tc.origin)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		if rec.Code != tc.want {
+			t.Errorf("%s %s from %q: got %d, want %d", tc.method, path, tc.origin, rec.Code, tc.want)
+		}
+	}
+}
+
+func TestAllowedHosts(t *testing.T) {
+	s := New(nil, Options{Hosts: []string{"tasks.lan", "192.168.1.5"}})
+	cases := map[string]int{
+		"localhost:8385":    http.StatusOK,
+		"127.0.0.1:8385":    http.StatusOK,
+		"[::1]:8385":        http.StatusOK,
+		"Tasks.LAN":         http.StatusOK,
+		"192.168.1.5:8385":  http.StatusOK,
+		"rebound.evil:8385": http.StatusForbidden,
+		"192.168.1.6:8385":  http.StatusForbidden,
+		"localhost.evil:80": http.StatusForbidden,
+	}
+	for host, want := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Host = host
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, r)
+		if rec.Code != want {
+			t.Errorf("GET / for Host %q: got %d, want %d", host, rec.Code, want)
+		}
+	}
+}
+
+func TestRequestToken(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/v1/tasks?access_token=q", nil)
+	if got := requestToken(r); got != "q" {
+		t.Fatalf("query token: got %q", got)
+	}
+	r.Header.Set("Authorization", "Bearer h")
+	if got := requestToken(r); got != "h" {
+		t.Fatalf("header token: got %q", got)
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	cases := map[string]uint64{"": 0, "*": 0, `"42"`: 42, "7": 7}
+	for hdr, want := range cases {
+		r := httptest.NewRequest(http.MethodPatch, "/v1/tasks/x", nil)
+		if hdr != "" {
+			r.Header.Set("If-Match", hdr)
+		}
+		if got, err := ifMatch(r); err != nil || got != want {
+			t.Fatalf("If-Match %q: got %d, %v; want %d", hdr, got, err, want)
+		}
+	}
+	r := httptest.NewRequest(http.MethodPatch, "/v1/tasks/x", nil)
+	r.Header.Set("If-Match", `W/"abc"`)
+	if _, err := ifMatch(r); err == nil {
+		t.Fatal("expected error for non-numeric If-Match")
+	}
+}
@@ -0,0 +1,117 @@
+"use strict";
+
+const $ = (sel) => document.querySelector(sel);
+const tbody = $("#tasks");
+
+// An access token, when the server requires one, comes from the page URL's
+// #token= fragment and is remembered for later visits.
+const token = (() => {
+  const m = location.hash.match(/token=([^&]+)/);
+  if (m) localStorage.setItem("utask-token", decodeURIComponent(m[1]));
+  return localStorage.getItem("utask-token") || "";
+})();
+
+async function api(method, path, body) {
+  const headers = body ? { "Content-Type": "application/json" } : {};
+  if (token) headers.Authorization = "Bearer " + token;
+  const res = await fetch(path, {
+    method,
+    headers,
+    body: body ? JSON.stringify(body) : undefined,
+  });
+  const data = await res.json().catch(() => ({}));
+  if (!res.ok) throw new Error(data.error || res.statusText);
+  return data;
+}
+
+function csv(s) {
+  return s.split(",").map((t) => t.trim()).filter(Boolean);
+}
+
+async function load() {
+  const params = new URLSearchParams();
+  const tags = $("#f-tags").value.trim();
+  const status = $("#f-status").value;
+  if (tags) params.set("tags", tags);
+  if (status) params.set("status", status);
+  const tasks = await api("GET", "/v1/tasks?" + params);
+  tasks.sort((a, b) => (a.priority || 0) - (b.priority || 0) || a.created.localeCompare(b.created));
+  tbody.replaceChildren(...tasks.map(row));
+}
+
+function row(t) {
+  const tr = document.createElement("tr");
+  if (t.done) tr.className = "done";
+  const id = document.createElement("td");
+  id.className = "id";
+  id.textContent = t.id.slice(0, 8);
+  const main = document.createElement("td");
+  const title = document.createElement("div");
+  title.className = "title";
+  title.textContent = t.text.split("\n")[0];
+  main.append(title);
+  for (const tag of t.tags || []) {
+    const span = document.createElement("span");
+    span.className = "tag";
+    span.textContent = tag;
+    span.onclick = () => { $("#f-tags").value = tag; load(); };
+    main.append(span);
+  }
+  const actions = document.createElement("td");
+  actions.className = "actions";
+  actions.append(
+    button(t.done ? "reopen" : "close", () => api("POST", `/v1/tasks/${t.id}/${t.done ? "reopen" : "close"}`)),
+    button("edit", () => edit(t)),
+    button("delete", () => confirm("Delete task?") && api("DELETE", `/v1/tasks/${t.id}`)),
+  );
+  tr.append(id, main, actions);
+  return tr;
+}
+
+function button(label, fn) {
+  const b = document.createElement("button");
+  b.textContent = label;
+  b.onclick = async () => {
+    try { await fn(); await load(); } catch (e) { alert(e.message); }
+  };
+  return b;
+}
+
+async function edit(t) {
+  const text = prompt("Text", t.text);
+  if (text === null) return;
+  const tags = prompt("Tags (comma-separated)", (t.tags || []).join(","));
+  if (tags === null) return;
+  await api("PATCH", `/v1/tasks/${t.id}`, { text, tags: csv(tags) });
+}
+
+$("#create").onsubmit = async (e) => {
+  e.preventDefault();
+  const f = e.target;
+  try {
+    await api("POST", "/v1/tasks", {
+      text: f.text.value,
+      tags: csv(f.tags.value),
+      priority: Number(f.priority.value) || 0,
+    });
+    f.text.value = "";
+    await load();
+  } catch (err) {
+    alert(err.message);
+  }
+};
+$("#f-tags").onchange = load;
+$("#f-status").onchange = load;
+
+function listen() {
+  const es = new EventSource("/v1/events" + (token ? "?access_token=" + encodeURIComponent(token) : ""));
+  es.onopen = () => { $("#status").textContent = "live"; };
+  es.onerror = () => { $("#status").textContent = "reconnecting…"; };
+  let pending;
+  const refresh = () => { clearTimeout(pending); pending = setTimeout(load, 150); };
+  es.addEventListener("put", refresh);
+  es.addEventListener("delete", refresh);
+}
+
+load().catch((e) => alert(e.message));
+listen();
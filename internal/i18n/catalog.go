@@ -0,0 +1,160 @@
+package i18n
+
+// catalogs maps each non-English language to translations of the English
+// format strings. Keep the verbs (%s, %d) in the same order as the key.
+var catalogs = map[string]map[string]string{
+	French: {
+		"%s (exists)":                    "%s (existe déjà)",
+		"%s closed":                      "%s fermée",
+		"%s already closed":              "%s déjà fermée",
+		"%s reopened":                    "%s rouverte",
+		"%s already open":                "%s déjà ouverte",
+		"%s updated":                     "%s mise à jour",
+		"no duplicates":                  "aucun doublon",
+		"%s has no %s trailer":           "%s n'a pas de trailer %s",
+		"%s deleted":                     "%s supprimée",
+		"open":                           "ouvertes",
+		"closed":                         "fermées",
+		"overdue":                        "en retard",
+		"created since %s":               "créées depuis le %s",
+		"closed since %s":                "fermées depuis le %s",
+		"%s restored":                    "%s restaurée",
+		"purged %d tasks from the trash": "%d tâches supprimées définitivement de la corbeille",
+		"%s released":                    "%s libérée",
+		"no timer running":               "aucun minuteur en cours",
+		"%s in total":                    "%s au total",
+		"%s completed":                   "%s terminée",
+		"no unclaimed open tasks":        "aucune tâche ouverte libre",
+		"claimed until %s":               "réservée jusqu'à %s",
+		"next page: --cursor %s":         "page suivante : --cursor %s",
+		"profile %s created":             "profil %s créé",
+		"now using profile %s":           "profil %s utilisé désormais",
+		"profile %s deleted; its tasks remain until ut purge --profile %s": "profil %s supprimé ; ses tâches restent jusqu'à ut purge --profile %s",
+		"%s approved and closed":                                   "%s approuvée et fermée",
+		"%s already awaiting review":                               "%s déjà en attente de relecture",
+		"%s awaiting review; someone else must run: ut approve %s": "%s en attente de relecture ; une autre personne doit lancer : ut approve %s",
+		"%s started %s ago, claimed until %s":                      "%s commencée il y a %s, réservée jusqu'à %s",
+		"%s delegated to %s":                                       "%s déléguée à %s",
+		"%s assigned to %s":                                        "%s assignée à %s",
+		"%s unassigned":                                            "%s n'est plus assignée",
+		"%s no longer waiting":                                     "%s n'est plus en attente",
+		"%s snoozed until %s":                                      "%s mise en veille jusqu'au %s",
+		"%s awake":                                                 "%s réveillée",
+		"%s blocked by %s":                                         "%s bloquée par %s",
+		"%s no longer blocked by %s":                               "%s n'est plus bloquée par %s",
+		"%s unblocked":                                             "%s débloquée",
+		"%s annotated":                                             "%s annotée",
+		"no annotations":                                           "aucune annotation",
+		"%s is now %s":                                             "%s est maintenant %s",
+		"%s already %s":                                            "%s déjà %s",
+		"started, claimed until %s":                                "commencée, réservée jusqu'à %s",
+		"recorded %s on %s (%s in total)":                          "%s enregistrées sur %s (%s au total)",
+		"[c]ontinue, [b]reak, [d]one (close the task) or [q]uit? ": "[c]ontinuer, [b] pause, [d] terminer (fermer la tâche) ou [q]uitter ? ",
+		"no open tasks to pick from":                               "aucune tâche ouverte à choisir",
+		"%s (in %s)":                                               "%s (dans %s)",
+		"%s queued (%s offline)":                                   "%s en attente (%s hors ligne)",
+		"wrote %s; copy it to every machine that uses profile %s":  "%s écrit ; copiez-le sur chaque machine qui utilise le profil %s",
+		"export tasks to %s first? [Y/n/other path] ":              "exporter les tâches vers %s d'abord ? [O/n/autre chemin] ",
+		"this permanently deletes profile %s, its history and its local cache; type the profile name to confirm: ": "ceci supprime définitivement le profil %s, son historique et son cache local ; tapez le nom du profil pour confirmer : ",
+		"profile %s purged": "profil %s supprimé",
+		"suggested tags: %s; add them? [Y/n/other tags] ":              "étiquettes suggérées : %s ; les ajouter ? [O/n/autres étiquettes] ",
+		"suggested tags (not added without a terminal to confirm): %s": "étiquettes suggérées (non ajoutées faute de terminal pour confirmer) : %s",
+		"exported %d tasks to %s":                                      "%d tâches exportées vers %s",
+		"deleted":                                                      "supprimée",
+		"undid %s of %s":                                               "%s de %s annulé",
+		"%d tasks":                                                     "%d tâches",
+		"no matching tasks":                                            "aucune tâche correspondante",
+		"closed %d tasks, %d already closed":                           "%d tâches fermées, %d déjà fermées",
+		"retagged %d tasks, %d unchanged":                              "étiquettes modifiées sur %d tâches, %d inchangées",
+		"deleted %d tasks":                                             "%d tâches supprimées",
+		"delete %d tasks? [y/N] ":                                      "supprimer %d tâches ? [y/N] ",
+		"imported %d (created %d, updated %d, unchanged %d)":           "%d importées (%d créées, %d mises à jour, %d inchangées)",
+		"pulled %d, pushed %d, %d conflicts":                           "%d récupérées, %d envoyées, %d conflits",
+		"no changes":                                                   "aucune modification",
+		"your edit is saved in %s":                                     "modification enregistrée dans %s",
+		"now in %s":                                                    "désormais dans %s",
+		"no active context":                                            "aucun contexte actif",
+		"context cleared":                                              "contexte effacé",
+		"context %s saved: %s":                                         "contexte %s enregistré : %s",
+		"no filter context in use":                                     "aucun contexte de filtre utilisé",
+		"now using context %s: %s":                                     "contexte %s utilisé désormais : %s",
+		"context %s deleted":                                           "contexte %s supprimé",
+	},
+	German: {
+		"%s (exists)":                    "%s (existiert bereits)",
+		"%s closed":                      "%s geschlossen",
+		"%s already closed":              "%s bereits geschlossen",
+		"%s reopened":                    "%s wieder geöffnet",
+		"%s already open":                "%s bereits offen",
+		"%s updated":                     "%s aktualisiert",
+		"no duplicates":                  "keine Duplikate",
+		"%s has no %s trailer":           "%s hat keinen %s-Trailer",
+		"%s deleted":                     "%s gelöscht",
+		"open":                           "offen",
+		"closed":                         "geschlossen",
+		"overdue":                        "überfällig",
+		"created since %s":               "erstellt seit %s",
+		"closed since %s":                "geschlossen seit %s",
+		"%s restored":                    "%s wiederhergestellt",
+		"purged %d tasks from the trash": "%d Aufgaben endgültig aus dem Papierkorb gelöscht",
+		"%s released":                    "%s freigegeben",
+		"no timer running":               "kein Timer läuft",
+		"%s in total":                    "%s insgesamt",
+		"%s completed":                   "%s erledigt",
+		"no unclaimed open tasks":        "keine freien offenen Aufgaben",
+		"claimed until %s":               "reserviert bis %s",
+		"next page: --cursor %s":         "nächste Seite: --cursor %s",
+		"profile %s created":             "Profil %s angelegt",
+		"now using profile %s":           "Profil %s wird jetzt verwendet",
+		"profile %s deleted; its tasks remain until ut purge --profile %s": "Profil %s gelöscht; seine Aufgaben bleiben bis ut purge --profile %s",
+		"%s approved and closed":                                   "%s genehmigt und geschlossen",
+		"%s already awaiting review":                               "%s wartet bereits auf Prüfung",
+		"%s awaiting review; someone else must run: ut approve %s": "%s wartet auf Prüfung; eine andere Person muss ausführen: ut approve %s",
+		"%s started %s ago, claimed until %s":                      "%s vor %s begonnen, reserviert bis %s",
+		"%s delegated to %s":                                       "%s an %s delegiert",
+		"%s assigned to %s":                                        "%s %s zugewiesen",
+		"%s unassigned":                                            "%s niemandem mehr zugewiesen",
+		"%s no longer waiting":                                     "%s wartet nicht mehr",
+		"%s snoozed until %s":                                      "%s zurückgestellt bis %s",
+		"%s awake":                                                 "%s wieder aktiv",
+		"%s blocked by %s":                                         "%s blockiert durch %s",
+		"%s no longer blocked by %s":                               "%s nicht mehr blockiert durch %s",
+		"%s unblocked":                                             "%s nicht mehr blockiert",
+		"%s annotated":                                             "%s kommentiert",
+		"no annotations":                                           "keine Anmerkungen",
+		"%s is now %s":                                             "%s ist jetzt %s",
+		"%s already %s":                                            "%s bereits %s",
+		"started, claimed until %s":                                "begonnen, reserviert bis %s",
+		"recorded %s on %s (%s in total)":                          "%s auf %s erfasst (%s insgesamt)",
+		"[c]ontinue, [b]reak, [d]one (close the task) or [q]uit? ": "[c] weiter, [b] Pause, [d] fertig (Aufgabe schließen) oder [q] beenden? ",
+		"no open tasks to pick from":                               "keine offenen Aufgaben zur Auswahl",
+		"%s (in %s)":                                               "%s (in %s)",
+		"%s queued (%s offline)":                                   "%s vorgemerkt (%s offline)",
+		"wrote %s; copy it to every machine that uses profile %s":  "%s geschrieben; auf jeden Rechner kopieren, der Profil %s nutzt",
+		"export tasks to %s first? [Y/n/other path] ":              "Aufgaben zuerst nach %s exportieren? [Y/n/anderer Pfad] ",
+		"this permanently deletes profile %s, its history and its local cache; type the profile name to confirm: ": "Dies löscht Profil %s, seinen Verlauf und seinen lokalen Cache endgültig; zur Bestätigung den Profilnamen eingeben: ",
+		"profile %s purged": "Profil %s gelöscht",
+		"suggested tags: %s; add them? [Y/n/other tags] ":              "vorgeschlagene Tags: %s; hinzufügen? [Y/n/andere Tags] ",
+		"suggested tags (not added without a terminal to confirm): %s": "vorgeschlagene Tags (ohne Terminal zur Bestätigung nicht hinzugefügt): %s",
+		"exported %d tasks to %s":                                      "%d Aufgaben nach %s exportiert",
+		"deleted":                                                      "gelöscht",
+		"undid %s of %s":                                               "%s von %s rückgängig gemacht",
+		"%d tasks":                                                     "%d Aufgaben",
+		"no matching tasks":                                            "keine passenden Aufgaben",
+		"closed %d tasks, %d already closed":                           "%d Aufgaben geschlossen, %d bereits geschlossen",
+		"retagged %d tasks, %d unchanged":                              "Tags von %d Aufgaben geändert, %d unverändert",
+		"deleted %d tasks":                                             "%d Aufgaben gelöscht",
+		"delete %d tasks? [y/N] ":                                      "%d Aufgaben löschen? [y/N] ",
+		"imported %d (created %d, updated %d, unchanged %d)":           "%d importiert (%d erstellt, %d aktualisiert, %d unverändert)",
+		"pulled %d, pushed %d, %d conflicts":                           "%d geholt, %d übertragen, %d Konflikte",
+		"no changes":                                                   "keine Änderungen",
+		"your edit is saved in %s":                                     "Bearbeitung in %s gespeichert",
+		"now in %s":                                                    "jetzt in %s",
+		"no active context":                                            "kein aktiver Kontext",
+		"context cleared":                                              "Kontext aufgehoben",
+		"context %s saved: %s":                                         "Kontext %s gespeichert: %s",
+		"no filter context in use":                                     "kein Filterkontext aktiv",
+		"now using context %s: %s":                                     "jetzt mit Kontext %s: %s",
+		"context %s deleted":                                           "Kontext %s gelöscht",
+	},
+}
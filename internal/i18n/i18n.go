@@ -0,0 +1,104 @@
+// Package i18n translates user-facing CLI messages and formats dates for
+// the user's locale. Messages are keyed by their English format string, so
+// untranslated messages fall back to English as written.
+package i18n
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Supported languages.
+const (
+	English = "en"
+	French  = "fr"
+	German  = "de"
+)
+
+// Detect picks the language from the configured locale, then $LC_ALL,
+// $LC_MESSAGES and $LANG, e.g. "fr_FR.UTF-8" is French. Unsupported or
+// unset locales fall back to English.
+func Detect(configured string, getenv func(string) string) string {
+	for _, v := range []string{configured, getenv("LC_ALL"), getenv("LC_MESSAGES"), getenv("LANG")} {
+		if v == "" {
+			continue
+		}
+		lang := strings.ToLower(v)
+		if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+			lang = lang[:i]
+		}
+		if _, ok := catalogs[lang]; ok || lang == English {
+			return lang
+		}
+		// The first locale set wins, as in setlocale(3).
+		return English
+	}
+	return English
+}
+
+// Printer renders messages and dates in one language.
+type Printer struct {
+	lang    string
+	catalog map[string]string
+	date    string
+}
+
+// dateLayouts are the short date layouts per language.
+var dateLayouts = map[string]string{
+	English: "2006-01-02",
+	French:  "02/01/2006",
+	German:  "02.01.2006",
+}
+
+// New returns a Printer for lang (see Detect).
+func New(lang string) *Printer {
+	date, ok := dateLayouts[lang]
+	if !ok {
+		lang, date = English, dateLayouts[English]
+	}
+	return &Printer{lang: lang, catalog: catalogs[lang], date: date}
+}
+
+// Lang is the printer's language code.
+func (p *Printer) Lang() string { return p.lang }
+
+// Sprintf formats the translation of format, or format itself when the
+// catalog has none.
+func (p *Printer) Sprintf(format string, args ...any) string {
+	if tr, ok := p.catalog[format]; ok {
+		format = tr
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// answers are the words for yes and no per language, besides English's,
+// which every language accepts.
+var answers = map[string]struct{ yes, no []string }{
+	English: {yes: []string{"y", "yes"}, no: []string{"n", "no"}},
+	French:  {yes: []string{"o", "oui"}, no: []string{"n", "non"}},
+	German:  {yes: []string{"j", "ja"}, no: []string{"n", "nein"}},
+}
+
+// YesNo reads a prompt's answer: yes reports a yes word, and ok whether
+// answer was a yes or no word at all, in English or the printer's language.
+// Case and surrounding space don't matter.
+func (p *Printer) YesNo(answer string) (yes, ok bool) {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	for _, lang := range []string{English, p.lang} {
+		if slices.Contains(answers[lang].yes, answer) {
+			return true, true
+		}
+		if slices.Contains(answers[lang].no, answer) {
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// Date formats t's calendar day in local time.
+func (p *Printer) Date(t time.Time) string { return t.Local().Format(p.date) }
+
+// DateTime formats t's day and 24-hour time in local time.
+func (p *Printer) DateTime(t time.Time) string { return t.Local().Format(p.date + " 15:04") }
@@ -0,0 +1,150 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetect(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+	cases := []struct {
+		configured string
+		env        map[string]string
+		want       string
+	}{
+		{"", nil, English},
+		{"", map[string]string{"LANG": "fr_FR.UTF-8"}, French},
+		{"", map[string]string{"LANG": "fr_FR.UTF-8", "LC_ALL": "de_DE"}, German},
+		{"en", map[string]string{"LANG": "de_DE.UTF-8"}, English},
+		{"de-AT", nil, German},
+		{"", map[string]string{"LC_MESSAGES": "ja_JP.UTF-8", "LANG": "fr_FR"}, English},
+		{"", map[string]string{"LANG": "C.UTF-8"}, English},
+	}
+	for _, c := range cases {
+		if got := Detect(c.configured, env(c.env)); got != c.want {
+			t.Errorf("Detect(%q, %v) = %q, want %q", c.configured, c.env, got, c.want)
+		}
+	}
+}
+
+func TestPrinter(t *testing.T) {
+	if got := New(French).Sprintf("%s closed", "abc"); got != "abc fermée" {
+		t.Fatalf("fr: %q", got)
+	}
+	if got := New("xx").Sprintf("%s closed", "abc"); got != "abc closed" {
+		t.Fatalf("fallback: %q", got)
+	}
+	if got := New(German).Sprintf("untranslated %d", 3); got != "untranslated 3" {
+		t.Fatalf("untranslated: %q", got)
+	}
+	day := time.Date(2026, 3, 5, 12, 0, 0, 0, time.Local)
+	for lang, want := range map[string]string{English: "2026-03-05", French: "05/03/2026", German: "05.03.2026"} {
+		if got := New(lang).Date(day); got != want {
+			t.Errorf("%s Date = %q, want %q", lang, got, want)
+		}
+	}
+}
+
+func TestYesNo(t *testing.T) {
+	cases := []struct {
+		lang, answer string
+		yes, ok      bool
+	}{
+		{English, "Y", true, true},
+		{English, " no ", false, true},
+		{English, "oui", false, false},
+		{French, "o", true, true},
+		{French, "Oui", true, true},
+		{French, "non", false, true},
+		{French, "yes", true, true},
+		{French, "other.jsonl", false, false},
+		{German, "j", true, true},
+		{German, "nein", false, true},
+	}
+	for _, c := range cases {
+		if yes, ok := New(c.lang).YesNo(c.answer); yes != c.yes || ok != c.ok {
+			t.Errorf("%s YesNo(%q) = %v, %v; want %v, %v", c.lang, c.answer, yes, ok, c.yes, c.ok)
+		}
+	}
+}
+
+// TestCatalogVerbs checks every translation keeps its key's verbs in order.
+func TestCatalogVerbs(t *testing.T) {
+	verbs := func(s string) string {
+		var out []string
+		for i := 0; i < len(s)-1; i++ {
+			if s[i] == '%' {
+				out = append(out, s[i:i+2])
+				i++
+			}
+		}
+		return strings.Join(out, "")
+	}
+	for lang, cat := range catalogs {
+		for key, tr := range cat {
+			if verbs(key) != verbs(tr) {
+				t.Errorf("%s: %q has verbs %q, want %q", lang, tr, verbs(tr), verbs(key))
+			}
+		}
+	}
+}
+
+// TestCatalogComplete checks every message the CLI translates has an entry
+// in every catalog: it collects the string literals passed to Sprintf on
+// anything but fmt in cmd/ut.
+func TestCatalogComplete(t *testing.T) {
+	fset := token.NewFileSet()
+	files, err := filepath.Glob("../../cmd/ut/*.go")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("cmd/ut sources: %v", err)
+	}
+	var keys []string
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || sel.Sel.Name != "Sprintf" {
+				return true
+			}
+			if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == "fmt" {
+				return true
+			}
+			if lit, ok := call.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				key, err := strconv.Unquote(lit.Value)
+				if err != nil {
+					t.Fatal(err)
+				}
+				keys = append(keys, key)
+			}
+			return true
+		})
+	}
+	if len(keys) == 0 {
+		t.Fatal("found no translated messages in cmd/ut")
+	}
+	for lang, cat := range catalogs {
+		for _, key := range keys {
+			if _, ok := cat[key]; !ok {
+				t.Errorf("%s: no translation for %q", lang, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,292 @@
+// Package ical reads and writes tasks as iCalendar VTODO components, the
+// subset Apple Reminders and most CalDAV clients exchange.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+const (
+	stampLayout = "20060102T150405Z"
+	dateLayout  = "20060102"
+)
+
+// WriteTodos writes tasks as a VCALENDAR of VTODOs. The task id is the UID so
+// an exported file re-imports onto the same tasks; Task.Due (or an older "Due:"
+// trailer) becomes DUE.
+func WriteTodos(w io.Writer, tasks []utask.Task) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) { writeFolded(bw, s) }
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//utask//ut//EN")
+	now := time.Now().UTC().Format(stampLayout)
+	for _, t := range tasks {
+		line("BEGIN:VTODO")
+		line("UID:" + t.ID)
+		line("DTSTAMP:" + now)
+		if ts, err := time.Parse(time.RFC3339, t.Created); err == nil {
+			line("CREATED:" + ts.UTC().Format(stampLayout))
+		}
+		line("SUMMARY:" + escape(t.Short()))
+		if body := body(t.Text); body != "" {
+			line("DESCRIPTION:" + escape(body))
+		}
+		if len(t.Tags) > 0 {
+			esc := make([]string, len(t.Tags))
+			for i, tag := range t.Tags {
+				esc[i] = escape(tag)
+			}
+			line("CATEGORIES:" + strings.Join(esc, ","))
+		}
+		if p := toICalPriority(t.Priority); p > 0 {
+			line("PRIORITY:" + strconv.Itoa(p))
+		}
+		if due, ok := dueProp(t); ok {
+			line(due)
+		}
+		switch t.State() {
+		case utask.StatusDone:
+			line("STATUS:COMPLETED")
+		case utask.StatusCancelled:
+			line("STATUS:CANCELLED")
+		case utask.StatusInProgress:
+			line("STATUS:IN-PROCESS")
+		default:
+			line("STATUS:NEEDS-ACTION")
+		}
+		line("END:VTODO")
+	}
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+// ReadTodos parses every VTODO in r. UIDs that are utask ids are kept, other
+// records get the content id of their mapped text.
+func ReadTodos(r io.Reader) ([]utask.Task, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	out := []utask.Task{}
+	var cur map[string]string
+	for _, l := range lines {
+		name, value := splitProp(l)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VTODO"):
+			cur = map[string]string{}
+		case name == "END" && strings.EqualFold(value, "VTODO"):
+			if cur == nil {
+				continue
+			}
+			t, err := todoTask(cur)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, t)
+			cur = nil
+		case cur != nil:
+			cur[name] = value
+		}
+	}
+	return out, nil
+}
+
+func todoTask(p map[string]string) (utask.Task, error) {
+	summary := unescape(p["SUMMARY"])
+	if strings.TrimSpace(summary) == "" {
+		return utask.Task{}, fmt.Errorf("vtodo %s: empty summary", p["UID"])
+	}
+	text := strings.TrimSpace(summary)
+	desc := strings.TrimSpace(unescape(p["DESCRIPTION"]))
+	if desc != "" {
+		text += "\n\n" + desc
+	}
+	t := utask.Task{Text: text, Created: time.Now().UTC().Format(time.RFC3339)}
+	if due, ok := parseDue(p["DUE"]); ok && !hasTrailer(text, utask.DueTrailer) {
+		t.Due = &due
+	}
+	if v := p["CATEGORIES"]; v != "" {
+		for _, c := range splitEscaped(v) {
+			t.Tags = append(t.Tags, unescape(c))
+		}
+	}
+	if v, err := strconv.Atoi(p["PRIORITY"]); err == nil {
+		t.Priority = fromICalPriority(v)
+	}
+	switch st := strings.ToUpper(p["STATUS"]); {
+	case st == "COMPLETED" || p["COMPLETED"] != "":
+		t.Status, t.Done = utask.StatusDone, true
+	case st == "CANCELLED":
+		t.Status, t.Done = utask.StatusCancelled, true
+	case st == "IN-PROCESS":
+		t.Status = utask.StatusInProgress
+	}
+	if ts, err := time.Parse(stampLayout, p["CREATED"]); err == nil {
+		t.Created = ts.Format(time.RFC3339)
+	}
+	t.ID = strings.ToLower(p["UID"])
+	if utask.ValidateTask(t) != nil {
+		t.ID = utask.ContentID(t)
+	}
+	return t, nil
+}
+
+func hasTrailer(text, key string) bool {
+	for _, tr := range (utask.Task{Text: text}).Trailers() {
+		if strings.EqualFold(tr.Key, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// body returns the task text after the title line, trailers included.
+func body(text string) string {
+	_, rest, ok := strings.Cut(text, "\n")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(rest)
+}
+
+// toICalPriority maps utask 1 (highest) .. 3+ onto the high/medium/low values
+// Reminders uses (1, 5, 9). Zero means unset.
+func toICalPriority(p int) int {
+	switch {
+	case p <= 0:
+		return 0
+	case p == 1:
+		return 1
+	case p == 2:
+		return 5
+	default:
+		return 9
+	}
+}
+
+func fromICalPriority(p int) int {
+	switch {
+	case p <= 0:
+		return 0
+	case p <= 4:
+		return 1
+	case p == 5:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// dueProp renders the task's due time as a DUE property: Task.Due, or the
+// Due trailer of older tasks.
+func dueProp(t utask.Task) (string, bool) {
+	if t.Due != nil {
+		return formatDue(utask.FormatDue(*t.Due))
+	}
+	for _, tr := range t.Trailers() {
+		if strings.EqualFold(tr.Key, utask.DueTrailer) {
+			return formatDue(tr.Value)
+		}
+	}
+	return "", false
+}
+
+func formatDue(v string) (string, bool) {
+	v = strings.TrimSpace(v)
+	if ts, err := time.Parse(time.RFC3339, v); err == nil {
+		return "DUE:" + ts.UTC().Format(stampLayout), true
+	}
+	if ts, err := time.Parse("2006-01-02", v); err == nil {
+		return "DUE;VALUE=DATE:" + ts.Format(dateLayout), true
+	}
+	return "", false
+}
+
+// parseDue reads a DUE value for Task.Due: floating times and dates are
+// local, and a date is kept at midnight so it means the whole day.
+func parseDue(v string) (time.Time, bool) {
+	if ts, err := time.Parse(stampLayout, v); err == nil {
+		return ts, true
+	}
+	for _, layout := range []string{"20060102T150405", dateLayout} {
+		if ts, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+			return ts, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// splitProp splits "NAME;PARAMS:VALUE", dropping the parameters.
+func splitProp(l string) (name, value string) {
+	head, value, _ := strings.Cut(l, ":")
+	name, _, _ = strings.Cut(head, ";")
+	return strings.ToUpper(name), value
+}
+
+func escape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+func unescape(s string) string {
+	r := strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+	return r.Replace(s)
+}
+
+// splitEscaped splits on commas not preceded by a backslash.
+func splitEscaped(s string) []string {
+	var out []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == ',' {
+			out = append(out, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, s[start:])
+}
+
+// writeFolded writes a content line folded at 75 octets per RFC 5545,
+// never splitting a UTF-8 sequence.
+func writeFolded(w *bufio.Writer, s string) {
+	for len(s) > 75 {
+		cut := 75
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+func unfold(r io.Reader) ([]string, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 4<<20)
+	var out []string
+	for sc.Scan() {
+		l := strings.TrimRight(sc.Text(), "\r")
+		if (strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")) && len(out) > 0 {
+			out[len(out)-1] += l[1:]
+			continue
+		}
+		if l != "" {
+			out = append(out, l)
+		}
+	}
+	return out, sc.Err()
+}
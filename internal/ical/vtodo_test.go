@@ -0,0 +1,54 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+func TestVTODORoundTrip(t *testing.T) {
+	long := strings.Repeat("très long, ", 12)
+	in := utask.Task{
+		Text:     "Renew passport\n\n" + long + "\n\nDue: 2025-10-01",
+		Tags:     []string{"admin", "travel"},
+		Priority: 2,
+		Created:  "2025-08-26T13:40:00Z",
+	}
+	in.ID = utask.ContentID(in)
+
+	var buf bytes.Buffer
+	if err := WriteTodos(&buf, []utask.Task{in}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if !strings.Contains(buf.String(), "DUE;VALUE=DATE:20251001") {
+		t.Fatalf("expected DUE line in:\n%s", buf.String())
+	}
+	got, err := ReadTodos(&buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected 1 task, got %d", len(got))
+	}
+	out := got[0]
+	if out.ID != in.ID || out.Text != in.Text || out.Priority != 2 || out.Created != in.Created {
+		t.Fatalf("round trip mismatch:\nwant %+v\ngot  %+v", in, out)
+	}
+	if strings.Join(out.Tags, ",") != "admin,travel" {
+		t.Fatalf("unexpected tags: %v", out.Tags)
+	}
+}
+
+func TestReadTodosForeignUID(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:ABC-123\r\nSUMMARY:Call mum\r\nDUE:20250901T090000Z\r\nSTATUS:COMPLETED\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+	got, err := ReadTodos(strings.NewReader(data))
+	if err != nil || len(got) != 1 {
+		t.Fatalf("read: %v %v", got, err)
+	}
+	if len(got[0].ID) != 128 || !got[0].Done || got[0].Text != "Call mum" || got[0].Due == nil || got[0].Due.UTC().Format(time.RFC3339) != "2025-09-01T09:00:00Z" {
+		t.Fatalf("unexpected task: %+v", got[0])
+	}
+}
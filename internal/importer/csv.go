@@ -0,0 +1,264 @@
+// Package importer converts foreign task formats into utask records.
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+// CSVOptions controls how a CSV file is decoded and mapped onto tasks.
+type CSVOptions struct {
+	// Map associates a utask field with a CSV column header, e.g.
+	// title=Summary. Known fields are title, notes, tags, priority,
+	// estimate, done, created and due (a date, optionally with a time,
+	// kept as Task.Due); any other field is kept as a trailer
+	// (ticket=Key becomes "Ticket: <value>").
+	Map       map[string]string
+	Delimiter rune
+	// Encoding is one of utf-8, latin1, utf-16le or utf-16be.
+	Encoding string
+	// TagSep splits the tags column; defaults to ",".
+	TagSep string
+}
+
+// ParseMapping parses "field=Column,field=Column" into a field->column map.
+func ParseMapping(s string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		field, col, ok := strings.Cut(part, "=")
+		field = strings.ToLower(strings.TrimSpace(field))
+		col = strings.TrimSpace(col)
+		if !ok || field == "" || col == "" {
+			return nil, fmt.Errorf("invalid mapping %q: want field=Column", part)
+		}
+		out[field] = col
+	}
+	if _, ok := out["title"]; !ok {
+		return nil, fmt.Errorf("mapping must include title=<column>")
+	}
+	return out, nil
+}
+
+// ReadCSV decodes r according to opts and passes one task per data row to fn
+// along with its 1-based row number (the header is row 1). Tasks carry the
+// deterministic id of their normalized content.
+func ReadCSV(r io.Reader, opts CSVOptions, fn func(row int, t utask.Task) error) error {
+	dr, err := decodeReader(r, opts.Encoding)
+	if err != nil {
+		return err
+	}
+	cr := csv.NewReader(dr)
+	if opts.Delimiter != 0 {
+		cr.Comma = opts.Delimiter
+	}
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("read header: %w", err)
+	}
+	cols := map[string]int{}
+	for i, h := range header {
+		cols[strings.TrimSpace(h)] = i
+	}
+	idx := map[string]int{}
+	for field, col := range opts.Map {
+		i, ok := cols[col]
+		if !ok {
+			return fmt.Errorf("column %q (for %s) not found in header", col, field)
+		}
+		idx[field] = i
+	}
+	tagSep := opts.TagSep
+	if tagSep == "" {
+		tagSep = ","
+	}
+	row := 1
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		row++
+		if err != nil {
+			return fmt.Errorf("row %d: %w", row, err)
+		}
+		t, err := rowToTask(rec, idx, tagSep)
+		if err != nil {
+			return fmt.Errorf("row %d: %w", row, err)
+		}
+		if err := fn(row, t); err != nil {
+			return fmt.Errorf("row %d: %w", row, err)
+		}
+	}
+}
+
+func rowToTask(rec []string, idx map[string]int, tagSep string) (utask.Task, error) {
+	get := func(field string) string {
+		i, ok := idx[field]
+		if !ok || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+	title := get("title")
+	if title == "" {
+		return utask.Task{}, fmt.Errorf("empty title")
+	}
+	var t utask.Task
+	if v := get("tags"); v != "" {
+		t.Tags = strings.Split(v, tagSep)
+	}
+	if v := get("priority"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil {
+			return utask.Task{}, fmt.Errorf("invalid priority %q", v)
+		}
+		t.Priority = p
+	}
+	if v := get("estimate"); v != "" {
+		m, err := strconv.Atoi(v)
+		if err != nil {
+			return utask.Task{}, fmt.Errorf("invalid estimate %q", v)
+		}
+		t.EstimateMinutes = m
+	}
+	t.Done = parseBool(get("done"))
+	created := time.Now().UTC()
+	if v := get("created"); v != "" {
+		ts, err := parseTime(v)
+		if err != nil {
+			return utask.Task{}, fmt.Errorf("invalid created %q", v)
+		}
+		created = ts
+	}
+	t.Created = created.UTC().Format(time.RFC3339)
+
+	var trailers []utask.Trailer
+	if v := get("due"); v != "" {
+		ts, err := parseTime(v)
+		if err != nil {
+			return utask.Task{}, fmt.Errorf("invalid due %q (want YYYY-MM-DD, optionally with a time)", v)
+		}
+		t.Due = &ts
+	}
+	for field := range idx {
+		switch field {
+		case "title", "notes", "tags", "priority", "estimate", "done", "created", "due":
+			continue
+		}
+		if v := get(field); v != "" {
+			trailers = append(trailers, utask.Trailer{Key: trailerKey(field), Value: v})
+		}
+	}
+	sortTrailers(trailers)
+	t.Text = composeText(title, trailers)
+	t.Description = strings.TrimSpace(get("notes"))
+	t.ID = utask.ContentID(t)
+	return t, nil
+}
+
+// composeText assembles title and trailers in the layout understood by
+// Task.Trailers; the body goes to Task.Description.
+func composeText(title string, trailers []utask.Trailer) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimSpace(title))
+	if len(trailers) > 0 {
+		b.WriteString("\n\n")
+		for i, tr := range trailers {
+			if i > 0 {
+				b.WriteByte('\n')
+			}
+			b.WriteString(tr.Key + ": " + tr.Value)
+		}
+	}
+	return b.String()
+}
+
+func sortTrailers(trs []utask.Trailer) {
+	sort.Slice(trs, func(i, j int) bool { return trs[i].Key < trs[j].Key })
+}
+
+// trailerKey turns a mapping field like "due_date" into "Due-Date".
+func trailerKey(field string) string {
+	parts := strings.FieldsFunc(field, func(r rune) bool { return r == '_' || r == '-' || r == ' ' })
+	for i, p := range parts {
+		parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	}
+	return strings.Join(parts, "-")
+}
+
+func parseBool(s string) bool {
+	switch strings.ToLower(s) {
+	case "1", "true", "yes", "y", "x", "done", "closed", "completed":
+		return true
+	}
+	return false
+}
+
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if ts, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return ts, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", s)
+}
+
+// decodeReader converts the input encoding to UTF-8 and strips a BOM.
+func decodeReader(r io.Reader, enc string) (io.Reader, error) {
+	switch strings.ToLower(strings.ReplaceAll(enc, "_", "-")) {
+	case "", "utf-8", "utf8":
+		br := bufio.NewReader(r)
+		if b, err := br.Peek(3); err == nil && bytes.Equal(b, []byte{0xEF, 0xBB, 0xBF}) {
+			_, _ = br.Discard(3)
+		}
+		return br, nil
+	case "latin1", "latin-1", "iso-8859-1":
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]byte, 0, len(b))
+		for _, c := range b {
+			out = utf8.AppendRune(out, rune(c))
+		}
+		return bytes.NewReader(out), nil
+	case "utf-16le", "utf-16be", "utf-16":
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		big := strings.HasSuffix(strings.ToLower(enc), "be")
+		if len(b) >= 2 && b[0] == 0xFF && b[1] == 0xFE {
+			big, b = false, b[2:]
+		} else if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+			big, b = true, b[2:]
+		}
+		u := make([]uint16, 0, len(b)/2)
+		for i := 0; i+1 < len(b); i += 2 {
+			if big {
+				u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+			} else {
+				u = append(u, uint16(b[i+1])<<8|uint16(b[i]))
+			}
+		}
+		return strings.NewReader(string(utf16.Decode(u))), nil
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %s", enc)
+	}
+}
@@ -0,0 +1,82 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+func TestReadCSVMapsColumns(t *testing.T) {
+	data := "Summary;Labels;DueDate;Notes\n" +
+		"Ship patch;work, urgent;2025-09-01;Needs review\n"
+	m, err := ParseMapping("title=Summary,tags=Labels,due=DueDate,notes=Notes")
+	if err != nil {
+		t.Fatalf("mapping: %v", err)
+	}
+	var got []utask.Task
+	err = ReadCSV(strings.NewReader(data), CSVOptions{Map: m, Delimiter: ';'}, func(row int, t utask.Task) error {
+		got = append(got, t)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected 1 task, got %d", len(got))
+	}
+	task := got[0]
+	if task.Short() != "Ship patch" || task.Details() != "Needs review" {
+		t.Fatalf("unexpected text: %q", task.Text)
+	}
+	if task.Due == nil || utask.FormatDue(*task.Due) != "2025-09-01" || len(task.Trailers()) != 0 {
+		t.Fatalf("expected due 2025-09-01, got %v and trailers %+v", task.Due, task.Trailers())
+	}
+	if len(task.Tags) != 2 || strings.TrimSpace(task.Tags[1]) != "urgent" {
+		t.Fatalf("unexpected tags: %#v", task.Tags)
+	}
+	if err := utask.ValidateTask(task); err != nil {
+		t.Fatalf("mapped task should validate: %v", err)
+	}
+}
+
+func TestReadCSVDue(t *testing.T) {
+	m := map[string]string{"title": "Title", "due": "Due"}
+	cases := map[string]string{
+		"2026-10-16":       "2026-10-16",
+		"2026-10-16 09:30": "2026-10-16T09:30:00",
+	}
+	for in, want := range cases {
+		var got utask.Task
+		err := ReadCSV(strings.NewReader("Title,Due\nPay rent,"+in+"\n"), CSVOptions{Map: m}, func(row int, t utask.Task) error {
+			got = t
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", in, err)
+		}
+		if got.Due == nil || !strings.HasPrefix(utask.FormatDue(*got.Due), want) {
+			t.Fatalf("%s: due %v, want %s", in, got.Due, want)
+		}
+		if _, ok := got.DueAt(time.Local); !ok {
+			t.Fatalf("%s: no due time", in)
+		}
+	}
+	err := ReadCSV(strings.NewReader("Title,Due\nPay rent,10/16/2026\n"), CSVOptions{Map: m}, func(int, utask.Task) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "row 2") || !strings.Contains(err.Error(), "invalid due") {
+		t.Fatalf("unparseable due: %v", err)
+	}
+}
+
+func TestReadCSVLatin1(t *testing.T) {
+	data := "Title\nCaf\xe9\n"
+	var got []utask.Task
+	err := ReadCSV(strings.NewReader(data), CSVOptions{Map: map[string]string{"title": "Title"}, Encoding: "latin1"}, func(row int, t utask.Task) error {
+		got = append(got, t)
+		return nil
+	})
+	if err != nil || len(got) != 1 || got[0].Text != "Café" {
+		t.Fatalf("expected decoded latin1 title, got %+v err=%v", got, err)
+	}
+}
@@ -0,0 +1,271 @@
+package importer
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+// TodoistAPI is the base URL of the Todoist REST API.
+const TodoistAPI = "https://api.todoist.com/rest/v2"
+
+// TodoistTask is the subset of a Todoist REST task used for import.
+type TodoistTask struct {
+	ID          string   `json:"id"`
+	Content     string   `json:"content"`
+	Description string   `json:"description"`
+	ProjectID   string   `json:"project_id"`
+	SectionID   string   `json:"section_id"`
+	Labels      []string `json:"labels"`
+	Priority    int      `json:"priority"`
+	IsCompleted bool     `json:"is_completed"`
+	CreatedAt   string   `json:"created_at"`
+	Due         *struct {
+		Date     string `json:"date"`
+		Datetime string `json:"datetime"`
+		String   string `json:"string"`
+	} `json:"due"`
+}
+
+type todoistNamed struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// FetchTodoist reads active tasks, projects and sections from the Todoist REST
+// API at base (normally TodoistAPI) and maps them to tasks.
+func FetchTodoist(ctx context.Context, client *http.Client, base, token string) ([]utask.Task, error) {
+	get := func(p string, v any) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(base, "/")+p, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("todoist %s: %w", p, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("todoist %s: %s", p, resp.Status)
+		}
+		return json.NewDecoder(resp.Body).Decode(v)
+	}
+	var (
+		tasks              []TodoistTask
+		projects, sections []todoistNamed
+	)
+	if err := get("/projects", &projects); err != nil {
+		return nil, err
+	}
+	if err := get("/sections", &sections); err != nil {
+		return nil, err
+	}
+	if err := get("/tasks", &tasks); err != nil {
+		return nil, err
+	}
+	names := func(in []todoistNamed) map[string]string {
+		m := make(map[string]string, len(in))
+		for _, n := range in {
+			m[n.ID] = n.Name
+		}
+		return m
+	}
+	return FromTodoist(tasks, names(projects), names(sections)), nil
+}
+
+// FromTodoist maps REST tasks to utask tasks. Projects and sections (looked up
+// by id) become tags alongside labels; due dates become Task.Due.
+func FromTodoist(in []TodoistTask, projects, sections map[string]string) []utask.Task {
+	out := make([]utask.Task, 0, len(in))
+	for _, tt := range in {
+		tags := append([]string{}, tt.Labels...)
+		if p := projects[tt.ProjectID]; p != "" {
+			tags = append(tags, tagSlug(p))
+		}
+		if s := sections[tt.SectionID]; s != "" {
+			tags = append(tags, tagSlug(s))
+		}
+		var due string
+		if tt.Due != nil {
+			due = tt.Due.Datetime
+			if due == "" {
+				due = tt.Due.Date
+			}
+		}
+		created := time.Now().UTC()
+		if ts, err := time.Parse(time.RFC3339Nano, tt.CreatedAt); err == nil {
+			created = ts.UTC()
+		}
+		out = append(out, todoistTask(tt.Content, tt.Description, due, tags, tt.Priority, tt.IsCompleted, created))
+	}
+	return out
+}
+
+// todoistPriority converts Todoist priority (4 = urgent, 1 = normal) to utask
+// priority (1 = highest).
+func todoistPriority(p int) int {
+	if p < 1 || p > 4 {
+		return 0
+	}
+	return 5 - p
+}
+
+func todoistTask(content, description, due string, tags []string, prio int, done bool, created time.Time) utask.Task {
+	var trailers []utask.Trailer
+	ts, ok := todoistDue(due)
+	if !ok && due != "" {
+		trailers = append(trailers, utask.Trailer{Key: utask.DueTrailer, Value: due})
+	}
+	t := utask.Task{
+		Text:        composeText(content, trailers),
+		Description: strings.TrimSpace(description),
+		Tags:        tags,
+		Priority:    todoistPriority(prio),
+		Done:        done,
+		Created:     created.Format(time.RFC3339),
+	}
+	if ok {
+		t.Due = &ts
+	}
+	t.ID = utask.ContentID(t)
+	return t
+}
+
+// todoistDue parses a Todoist due date or date-time; floating times and
+// dates are local. Anything else (a phrase like "tomorrow" in a CSV
+// export) is not ok and is kept as a Due trailer instead.
+func todoistDue(s string) (time.Time, bool) {
+	if ts, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return ts, true
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02"} {
+		if ts, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return ts, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// tagSlug turns a project or section name into a tag: "Home Stuff" -> "home-stuff".
+func tagSlug(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), "-")
+}
+
+// ReadTodoistBackup reads a Todoist backup: either a zip of per-project CSV
+// files or a single exported CSV (project taken from the file name).
+func ReadTodoistBackup(name string, r io.ReaderAt, size int64) ([]utask.Task, error) {
+	if strings.EqualFold(path.Ext(name), ".csv") {
+		return ReadTodoistCSV(projectFromFile(name), io.NewSectionReader(r, 0, size))
+	}
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("open backup: %w", err)
+	}
+	out := []utask.Task{}
+	for _, f := range zr.File {
+		if !strings.EqualFold(path.Ext(f.Name), ".csv") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		tasks, err := ReadTodoistCSV(projectFromFile(f.Name), rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		out = append(out, tasks...)
+	}
+	return out, nil
+}
+
+// projectFromFile strips directories, the .csv suffix and Todoist's
+// " [id]" suffix from a backup file name.
+func projectFromFile(name string) string {
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	if i := strings.LastIndex(base, " ["); i > 0 && strings.HasSuffix(base, "]") {
+		base = base[:i]
+	}
+	return base
+}
+
+// ReadTodoistCSV parses one Todoist project CSV (TYPE, CONTENT, DESCRIPTION,
+// PRIORITY, ..., DATE columns). Section rows tag the tasks that follow them;
+// inline @labels in CONTENT become tags.
+func ReadTodoistCSV(project string, r io.Reader) ([]utask.Task, error) {
+	br, err := decodeReader(r, "utf-8")
+	if err != nil {
+		return nil, err
+	}
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	col := map[string]int{}
+	for i, h := range header {
+		col[strings.ToUpper(strings.TrimSpace(h))] = i
+	}
+	for _, need := range []string{"TYPE", "CONTENT"} {
+		if _, ok := col[need]; !ok {
+			return nil, fmt.Errorf("missing %s column", need)
+		}
+	}
+	now := time.Now().UTC()
+	section := ""
+	out := []utask.Task{}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		get := func(name string) string {
+			i, ok := col[name]
+			if !ok || i >= len(rec) {
+				return ""
+			}
+			return strings.TrimSpace(rec[i])
+		}
+		switch strings.ToLower(get("TYPE")) {
+		case "section":
+			section = get("CONTENT")
+		case "task":
+			content, labels := splitLabels(get("CONTENT"))
+			tags := append(labels, tagSlug(project))
+			if section != "" {
+				tags = append(tags, tagSlug(section))
+			}
+			prio, _ := strconv.Atoi(get("PRIORITY"))
+			out = append(out, todoistTask(content, get("DESCRIPTION"), get("DATE"), tags, prio, false, now))
+		}
+	}
+}
+
+// splitLabels removes "@label" words from content and returns them as tags.
+func splitLabels(content string) (string, []string) {
+	var words, labels []string
+	for _, w := range strings.Fields(content) {
+		if len(w) > 1 && w[0] == '@' {
+			labels = append(labels, w[1:])
+			continue
+		}
+		words = append(words, w)
+	}
+	return strings.Join(words, " "), labels
+}
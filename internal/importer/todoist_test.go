@@ -0,0 +1,58 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+func TestFromTodoistMapsFields(t *testing.T) {
+	tasks := []TodoistTask{{
+		Content:   "Pay rent",
+		ProjectID: "p1",
+		SectionID: "s1",
+		Labels:    []string{"money"},
+		Priority:  4,
+		CreatedAt: "2025-08-01T10:00:00.000000Z",
+	}}
+	tasks[0].Due = &struct {
+		Date     string `json:"date"`
+		Datetime string `json:"datetime"`
+		String   string `json:"string"`
+	}{Date: "2025-09-01", String: "every month"}
+
+	got := FromTodoist(tasks, map[string]string{"p1": "Home Stuff"}, map[string]string{"s1": "Bills"})
+	if len(got) != 1 {
+		t.Fatalf("expected 1 task, got %d", len(got))
+	}
+	task := got[0]
+	if task.Priority != 1 {
+		t.Fatalf("todoist p4 should map to priority 1, got %d", task.Priority)
+	}
+	if strings.Join(task.Tags, ",") != "money,home-stuff,bills" {
+		t.Fatalf("unexpected tags: %v", task.Tags)
+	}
+	if task.Due == nil || utask.FormatDue(*task.Due) != "2025-09-01" {
+		t.Fatalf("expected due 2025-09-01, got %v", task.Due)
+	}
+	if task.Created != "2025-08-01T10:00:00Z" || len(task.ID) != 128 {
+		t.Fatalf("unexpected created/id: %+v", task)
+	}
+}
+
+func TestReadTodoistCSV(t *testing.T) {
+	data := "TYPE,CONTENT,DESCRIPTION,PRIORITY,INDENT,AUTHOR,RESPONSIBLE,DATE,DATE_LANG,TIMEZONE\n" +
+		"section,Errands,,,,,,,,\n" +
+		"task,Buy milk @shopping,,2,1,,,tomorrow,en,UTC\n"
+	got, err := ReadTodoistCSV(projectFromFile("Home [12345].csv"), strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(got) != 1 || got[0].Short() != "Buy milk" || got[0].Priority != 3 {
+		t.Fatalf("unexpected tasks: %+v", got)
+	}
+	if strings.Join(got[0].Tags, ",") != "shopping,home,errands" {
+		t.Fatalf("unexpected tags: %v", got[0].Tags)
+	}
+}
@@ -0,0 +1,144 @@
+// Package llm asks an OpenAI-compatible chat completions API for help with
+// tasks, such as tags that fit a task's text.
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// OpenAIAPI is the base URL of the OpenAI API.
+	OpenAIAPI = "https://api.openai.com/v1"
+	// DefaultModel answers when Client.Model is empty.
+	DefaultModel = "gpt-4.1-mini"
+	// MaxSuggestedTags caps how many tags SuggestTags returns.
+	MaxSuggestedTags = 5
+	// maxVocabulary caps how many existing tags go into a prompt.
+	maxVocabulary = 200
+)
+
+// Client calls the chat completions endpoint.
+type Client struct {
+	APIKey string
+	// Model defaults to DefaultModel.
+	Model string
+	// BaseURL defaults to OpenAIAPI.
+	BaseURL string
+	// HTTP defaults to http.DefaultClient.
+	HTTP *http.Client
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// complete sends one system and one user message and returns the reply,
+// which the model is asked to write as a JSON object.
+func (c *Client) complete(ctx context.Context, system, user string) (string, error) {
+	model, base, client := c.Model, c.BaseURL, c.HTTP
+	if model == "" {
+		model = DefaultModel
+	}
+	if base == "" {
+		base = OpenAIAPI
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	b, err := json.Marshal(map[string]any{
+		"model":           model,
+		"messages":        []chatMessage{{"system", system}, {"user", user}},
+		"response_format": map[string]string{"type": "json_object"},
+		"temperature":     0,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(base, "/")+"/chat/completions", bytes.NewReader(b))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("openai: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if json.Unmarshal(body, &e) == nil && e.Error.Message != "" {
+			return "", fmt.Errorf("openai: %s: %s", resp.Status, e.Error.Message)
+		}
+		return "", fmt.Errorf("openai: %s", resp.Status)
+	}
+	var out struct {
+		Choices []struct {
+			Message chatMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("openai: %w", err)
+	}
+	if len(out.Choices) == 0 {
+		return "", fmt.Errorf("openai: empty response")
+	}
+	return out.Choices[0].Message.Content, nil
+}
+
+const suggestPrompt = `You tag items in a task list. Reply with a JSON object {"tags": [...]} holding at most %d short lowercase tags (letters, digits and dashes) for the user's task. Prefer tags from the existing vocabulary when they fit, and only invent a tag when none does. Reply with an empty list when no tag fits.`
+
+// SuggestTags proposes up to MaxSuggestedTags tags for a task's text,
+// preferring ones from vocab (the tags in use, most used first). Tags come
+// back lowercased with spaces turned into dashes and duplicates dropped.
+func (c *Client) SuggestTags(ctx context.Context, text string, vocab []string) ([]string, error) {
+	if len(vocab) > maxVocabulary {
+		vocab = vocab[:maxVocabulary]
+	}
+	user := "Task:\n" + text
+	if len(vocab) > 0 {
+		user += "\n\nExisting tags: " + strings.Join(vocab, ", ")
+	}
+	reply, err := c.complete(ctx, fmt.Sprintf(suggestPrompt, MaxSuggestedTags), user)
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.Unmarshal([]byte(reply), &out); err != nil {
+		return nil, fmt.Errorf("openai: unexpected tag reply %q", reply)
+	}
+	tags := []string{}
+	for _, t := range out.Tags {
+		t = strings.Join(strings.Fields(strings.ToLower(strings.TrimPrefix(strings.TrimSpace(t), "#"))), "-")
+		if t == "" || contains(tags, t) {
+			continue
+		}
+		if tags = append(tags, t); len(tags) == MaxSuggestedTags {
+			break
+		}
+	}
+	return tags, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
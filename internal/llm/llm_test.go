@@ -0,0 +1,51 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSuggestTags(t *testing.T) {
+	var got struct {
+		Model    string        `json:"model"`
+		Messages []chatMessage `json:"messages"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Errorf("unexpected request %s %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		reply, _ := json.Marshal(map[string]any{"tags": []string{"#Home", "Yard Work", "home", "", "a", "b", "c", "d"}})
+		_ = json.NewEncoder(w).Encode(map[string]any{"choices": []any{map[string]any{"message": chatMessage{"assistant", string(reply)}}}})
+	}))
+	defer srv.Close()
+
+	c := &Client{APIKey: "sk-test", BaseURL: srv.URL}
+	tags, err := c.SuggestTags(context.Background(), "Mow the lawn", []string{"home", "work"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(tags, ",") != "home,yard-work,a,b,c" {
+		t.Fatalf("tags = %v", tags)
+	}
+	if got.Model != DefaultModel || len(got.Messages) != 2 || !strings.Contains(got.Messages[1].Content, "Existing tags: home, work") {
+		t.Fatalf("request = %+v", got)
+	}
+}
+
+func TestSuggestTagsAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":{"message":"Incorrect API key provided"}}`))
+	}))
+	defer srv.Close()
+
+	c := &Client{APIKey: "bad", BaseURL: srv.URL}
+	if _, err := c.SuggestTags(context.Background(), "x", nil); err == nil || !strings.Contains(err.Error(), "Incorrect API key") {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+// Package notify tells people when a task mentions them with @name or is
+// assigned to them, through webhooks, Slack or email.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+// Kind is why someone is being notified.
+type Kind string
+
+const (
+	Mentioned Kind = "mentioned"
+	Assigned  Kind = "assigned"
+)
+
+// Notice is one notification for one person.
+type Notice struct {
+	Kind Kind `json:"kind"`
+	// To is the recipient's handle.
+	To string `json:"to"`
+	// By is the identity that made the change, when known.
+	By   string     `json:"by,omitempty"`
+	Task utask.Task `json:"task"`
+}
+
+// Text renders the notice as one line.
+func (n Notice) Text() string {
+	short := n.Task.ID
+	if len(short) > 8 {
+		short = short[:8]
+	}
+	what := "mentioned you in"
+	if n.Kind == Assigned {
+		what = "assigned you"
+	}
+	by := n.By
+	if by == "" {
+		by = "someone"
+	}
+	return fmt.Sprintf("%s %s %s %s", by, what, short, n.Task.Short())
+}
+
+// Sender delivers notices to one destination.
+type Sender interface {
+	Send(ctx context.Context, n Notice) error
+}
+
+// Recipient is someone who can be notified.
+type Recipient struct {
+	// Identity is "Name <email>"; assignees and authors are matched
+	// against it with utask.SameIdentity.
+	Identity string
+	Senders  []Sender
+}
+
+// Notifier watches a Store and sends notices for new mentions and
+// assignments.
+type Notifier struct {
+	store  *utask.Store
+	people map[string]Recipient
+}
+
+// New returns a Notifier for the people map, keyed by lowercase handle.
+func New(store *utask.Store, people map[string]Recipient) *Notifier {
+	return &Notifier{store: store, people: people}
+}
+
+// tracked is what the Notifier remembers of a task to tell new mentions
+// and assignments from ones it has already seen.
+type tracked struct {
+	mentions []string
+	assignee string
+}
+
+func track(t utask.Task) tracked { return tracked{mentions: t.Mentions(), assignee: t.Assignee} }
+
+// Run sends notices for changes made after it starts until ctx is
+// cancelled. Existing mentions and assignments are not announced.
+func (n *Notifier) Run(ctx context.Context) error {
+	seen := map[string]tracked{}
+	events, err := n.store.Watch(ctx, utask.WatchFilter{})
+	if err != nil {
+		return err
+	}
+	if err := n.store.ForEach(ctx, func(t utask.Task) error {
+		seen[t.ID] = track(t)
+		return nil
+	}); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if ev.Op == utask.EventDelete || ev.Task == nil {
+				delete(seen, ev.ID)
+				continue
+			}
+			for _, no := range n.notices(seen[ev.ID], *ev.Task) {
+				n.send(ctx, no)
+			}
+			seen[ev.ID] = track(*ev.Task)
+		}
+	}
+}
+
+func (n *Notifier) send(ctx context.Context, no Notice) {
+	for _, s := range n.people[no.To].Senders {
+		if err := s.Send(ctx, no); err != nil {
+			slog.ErrorContext(ctx, "notify failed", "to", no.To, "kind", string(no.Kind), "task", no.Task.ID, "err", err)
+		}
+	}
+}
+
+// notices lists who to tell about t given what was last seen of it (zero
+// for a new task). The assignee hears once, as assigned, even if also
+// mentioned, and nobody is told about their own change.
+func (n *Notifier) notices(prev tracked, t utask.Task) []Notice {
+	by := t.UpdatedBy
+	if by == "" {
+		by = t.CreatedBy
+	}
+	var out []Notice
+	told := map[string]bool{}
+	add := func(kind Kind, who string) {
+		h, ok := n.lookup(who)
+		if !ok || told[h] || (by != "" && utask.SameIdentity(n.people[h].Identity, by)) {
+			return
+		}
+		told[h] = true
+		out = append(out, Notice{Kind: kind, To: h, By: by, Task: t})
+	}
+	if t.Assignee != "" && t.Assignee != prev.assignee {
+		add(Assigned, t.Assignee)
+	}
+	for _, m := range t.Mentions() {
+		if !slices.Contains(prev.mentions, m) {
+			add(Mentioned, m)
+		}
+	}
+	return out
+}
+
+// lookup finds the handle for who, which is a handle or an identity.
+func (n *Notifier) lookup(who string) (string, bool) {
+	if _, ok := n.people[strings.ToLower(who)]; ok {
+		return strings.ToLower(who), true
+	}
+	handles := make([]string, 0, len(n.people))
+	for h := range n.people {
+		handles = append(handles, h)
+	}
+	slices.Sort(handles)
+	for _, h := range handles {
+		if utask.SameIdentity(n.people[h].Identity, who) {
+			return h, true
+		}
+	}
+	return "", false
+}
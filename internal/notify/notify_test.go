@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+func testNotifier() *Notifier {
+	return New(nil, map[string]Recipient{
+		"ada": {Identity: "Ada Lovelace <ada@example.org>"},
+		"bob": {Identity: "Bob <bob@example.org>"},
+		"ops": {},
+	})
+}
+
+func kinds(ns []Notice) string {
+	var parts []string
+	for _, n := range ns {
+		parts = append(parts, n.To+":"+string(n.Kind))
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestNotices(t *testing.T) {
+	n := testNotifier()
+	cases := []struct {
+		name string
+		prev tracked
+		task utask.Task
+		want string
+	}{
+		{"new mentions", tracked{}, utask.Task{Text: "ask @ada and @ops, not @carol"}, "ada:mentioned,ops:mentioned"},
+		{"already mentioned", tracked{mentions: []string{"ada"}}, utask.Task{Text: "ask @ada and @bob"}, "bob:mentioned"},
+		{"assigned by identity", tracked{}, utask.Task{Text: "fix", Assignee: "bob@example.org"}, "bob:assigned"},
+		{"assigned and mentioned", tracked{}, utask.Task{Text: "@bob please", Assignee: "bob"}, "bob:assigned"},
+		{"same assignee", tracked{assignee: "bob"}, utask.Task{Text: "fix", Assignee: "bob"}, ""},
+		{"own change", tracked{}, utask.Task{Text: "note to @ada", UpdatedBy: "Ada Lovelace <ada@example.org>"}, ""},
+	}
+	for _, c := range cases {
+		if got := kinds(n.notices(c.prev, c.task)); got != c.want {
+			t.Fatalf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestSlackSend(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+	s := &Slack{URL: srv.URL, Client: srv.Client()}
+	no := Notice{Kind: Assigned, To: "bob", By: "Ada", Task: utask.Task{ID: "0123456789abcdef", Text: "Fix login\n\ndetails"}}
+	if err := s.Send(context.Background(), no); err != nil {
+		t.Fatal(err)
+	}
+	if got["text"] != "Ada assigned you 01234567 Fix login" {
+		t.Fatalf("unexpected text %q", got["text"])
+	}
+}
+
+func TestMailMessage(t *testing.T) {
+	no := Notice{Kind: Mentioned, To: "ada", Task: utask.Task{ID: "abc", Text: "Ping @ada\n\nbody"}}
+	msg := string(mailMessage("ut@example.org", "ada@example.org", no, time.Unix(0, 0).UTC()))
+	for _, want := range []string{"To: ada@example.org\r\n", "Subject: [ut] someone mentioned you in abc Ping @ada\r\n", "\r\n\r\nPing @ada\r\n\r\nbody"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("message missing %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestWebhookSendReport(t *testing.T) {
+	var got Report
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+	w := &Webhook{URL: srv.URL, Client: srv.Client()}
+	rep := Report{Subject: "ut summary", Text: "3 open\n"}
+	if err := w.SendReport(context.Background(), rep); err != nil {
+		t.Fatal(err)
+	}
+	if got != rep {
+		t.Fatalf("got %+v, want %+v", got, rep)
+	}
+	msg := string(rfc5322("ut@example.org", "ada@example.org", "[ut] "+rep.Subject, rep.Text, time.Unix(0, 0).UTC()))
+	if !strings.Contains(msg, "Subject: [ut] ut summary\r\n") || !strings.HasSuffix(msg, "\r\n\r\n3 open\r\n") {
+		t.Fatalf("unexpected message:\n%s", msg)
+	}
+}
@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Webhook POSTs each notice as JSON.
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+func (w *Webhook) Send(ctx context.Context, n Notice) error {
+	return postJSON(ctx, w.Client, w.URL, n)
+}
+
+// Slack posts notices to a Slack incoming webhook.
+type Slack struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s *Slack) Send(ctx context.Context, n Notice) error {
+	return postJSON(ctx, s.Client, s.URL, map[string]string{"text": n.Text()})
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, body any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("post %s: %s", req.URL.Redacted(), resp.Status)
+	}
+	return nil
+}
+
+// Mail emails notices through an SMTP server.
+type Mail struct {
+	// Addr is the server's host:port.
+	Addr string
+	From string
+	To   string
+	// Auth may be nil for servers that accept unauthenticated mail.
+	Auth smtp.Auth
+}
+
+func (m *Mail) Send(ctx context.Context, n Notice) error {
+	msg := mailMessage(m.From, m.To, n, time.Now())
+	return smtp.SendMail(m.Addr, m.Auth, m.From, []string{m.To}, msg)
+}
+
+// mailMessage renders n as an RFC 5322 message.
+func mailMessage(from, to string, n Notice, now time.Time) []byte {
+	body := fmt.Sprintf("%s\n\nTask %s\n", n.Task.Text, n.Task.ID)
+	return rfc5322(from, to, "[ut] "+n.Text(), body, now)
+}
+
+// rfc5322 renders a plain-text message with CRLF line endings.
+func rfc5322(from, to, subject, body string, now time.Time) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\nTo: %s\r\n", from, to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", strings.ReplaceAll(subject, "\n", " "))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// Report is a rendered summary, such as a scheduled digest.
+type Report struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+}
+
+// ReportSender delivers reports to one destination.
+type ReportSender interface {
+	SendReport(ctx context.Context, r Report) error
+}
+
+func (w *Webhook) SendReport(ctx context.Context, r Report) error {
+	return postJSON(ctx, w.Client, w.URL, r)
+}
+
+func (s *Slack) SendReport(ctx context.Context, r Report) error {
+	return postJSON(ctx, s.Client, s.URL, map[string]string{"text": "*" + r.Subject + "*\n```\n" + r.Text + "```"})
+}
+
+func (m *Mail) SendReport(ctx context.Context, r Report) error {
+	msg := rfc5322(m.From, m.To, "[ut] "+r.Subject, r.Text, time.Now())
+	return smtp.SendMail(m.Addr, m.Auth, m.From, []string{m.To}, msg)
+}
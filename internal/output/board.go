@@ -0,0 +1,118 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Column is one column of a Board: a title and its cards, each card one or
+// more lines.
+type Column struct {
+	Title string
+	Cards [][]string
+}
+
+// minColumnWidth is the narrowest a Board column gets, whatever the width.
+const minColumnWidth = 12
+
+// Board draws columns side by side in a box-drawing frame that fits width
+// terminal columns, each headed by its title and card count, with a blank
+// line between cards. Lines too long for their column are cut with "…".
+// Columns get at least minColumnWidth, so a board of many columns may be
+// wider than width.
+func Board(w io.Writer, cols []Column, width int) error {
+	if len(cols) == 0 {
+		return nil
+	}
+	// Each column has a border on its left and a space either side of its
+	// text; the last one also has a border on its right.
+	inner := (width-1)/len(cols) - 3
+	if inner < minColumnWidth {
+		inner = minColumnWidth
+	}
+	bodies := make([][]string, len(cols))
+	rows := 0
+	for i, col := range cols {
+		for j, card := range col.Cards {
+			if j > 0 {
+				bodies[i] = append(bodies[i], "")
+			}
+			bodies[i] = append(bodies[i], card...)
+		}
+		rows = max(rows, len(bodies[i]))
+	}
+
+	var b strings.Builder
+	rule := func(left, mid, right string) {
+		b.WriteString(left)
+		for i := range cols {
+			if i > 0 {
+				b.WriteString(mid)
+			}
+			b.WriteString(strings.Repeat("─", inner+2))
+		}
+		b.WriteString(right + "\n")
+	}
+	line := func(cell func(i int) string) {
+		for i := range cols {
+			fmt.Fprintf(&b, "│ %s ", fit(cell(i), inner))
+		}
+		b.WriteString("│\n")
+	}
+	rule("┌", "┬", "┐")
+	line(func(i int) string { return fmt.Sprintf("%s (%d)", strings.ToUpper(cols[i].Title), len(cols[i].Cards)) })
+	rule("├", "┼", "┤")
+	for r := 0; r < rows; r++ {
+		line(func(i int) string {
+			if r < len(bodies[i]) {
+				return bodies[i][r]
+			}
+			return ""
+		})
+	}
+	rule("└", "┴", "┘")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// fit pads s to width runes, or cuts it to width with a trailing "…".
+func fit(s string, width int) string {
+	s = tableCell(s)
+	n := utf8.RuneCountInString(s)
+	if n <= width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	r := []rune(s)
+	return string(r[:width-1]) + "…"
+}
+
+// Node is one entry of a Tree and the entries under it.
+type Node struct {
+	Text     string
+	Children []Node
+}
+
+// Tree draws nodes as an indented tree with box-drawing branches, each
+// root at the margin and its children below it.
+func Tree(w io.Writer, roots []Node) error {
+	var b strings.Builder
+	var walk func(nodes []Node, prefix string)
+	walk = func(nodes []Node, prefix string) {
+		for i, n := range nodes {
+			branch, next := "├── ", "│   "
+			if i == len(nodes)-1 {
+				branch, next = "└── ", "    "
+			}
+			b.WriteString(prefix + branch + tableCell(n.Text) + "\n")
+			walk(n.Children, prefix+next)
+		}
+	}
+	for _, r := range roots {
+		b.WriteString(tableCell(r.Text) + "\n")
+		walk(r.Children, "")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,56 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBoard(t *testing.T) {
+	var b bytes.Buffer
+	cols := []Column{
+		{Title: "open", Cards: [][]string{{"T-1 write the release notes", "p2"}, {"T-2 tag"}}},
+		{Title: "done", Cards: nil},
+	}
+	if err := Board(&b, cols, 31); err != nil {
+		t.Fatal(err)
+	}
+	want := "" +
+		"┌──────────────┬──────────────┐\n" +
+		"│ OPEN (2)     │ DONE (0)     │\n" +
+		"├──────────────┼──────────────┤\n" +
+		"│ T-1 write t… │              │\n" +
+		"│ p2           │              │\n" +
+		"│              │              │\n" +
+		"│ T-2 tag      │              │\n" +
+		"└──────────────┴──────────────┘\n"
+	if got := b.String(); got != want {
+		t.Errorf("Board:\n%s\nwant:\n%s", got, want)
+	}
+	b.Reset()
+	if err := Board(&b, nil, 80); err != nil || b.Len() != 0 {
+		t.Errorf("empty board = %q, %v", b.String(), err)
+	}
+}
+
+func TestTree(t *testing.T) {
+	var b bytes.Buffer
+	roots := []Node{
+		{Text: "release", Children: []Node{
+			{Text: "T-1 ship", Children: []Node{{Text: "T-2 notes"}, {Text: "T-3 tag"}}},
+			{Text: "T-4 announce"},
+		}},
+		{Text: "untagged"},
+	}
+	if err := Tree(&b, roots); err != nil {
+		t.Fatal(err)
+	}
+	want := "release\n" +
+		"├── T-1 ship\n" +
+		"│   ├── T-2 notes\n" +
+		"│   └── T-3 tag\n" +
+		"└── T-4 announce\n" +
+		"untagged\n"
+	if got := b.String(); got != want {
+		t.Errorf("Tree:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,167 @@
+// Package output renders command results as an aligned table, JSON Lines,
+// CSV or TSV. A command describes each record once, as the value to encode
+// as JSON and its cells for the column formats, and the Writer does the
+// rest.
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Format is an output format.
+type Format string
+
+const (
+	Table Format = "table"
+	JSON  Format = "json"
+	CSV   Format = "csv"
+	TSV   Format = "tsv"
+)
+
+// Formats lists the formats ParseFormat accepts.
+var Formats = []Format{Table, JSON, CSV, TSV}
+
+// ParseFormat reads a format name, in any case. Empty means Table.
+func ParseFormat(s string) (Format, error) {
+	if s = strings.ToLower(strings.TrimSpace(s)); s == "" {
+		return Table, nil
+	}
+	for _, f := range Formats {
+		if s == string(f) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown output format %q (want table, json, csv or tsv)", s)
+}
+
+// Writer writes records in one format:
+//
+//   - Table aligns the cells under an upper-cased header, one line per
+//     record; a cell is cut to its first line. Nothing is printed for no
+//     records.
+//   - JSON writes each record's value on a line of its own (JSON Lines),
+//     in the order given, so output can be streamed into jq.
+//   - CSV and TSV start with a header of the column names. CSV quotes
+//     cells as RFC 4180 does, multi-line text included; TSV escapes tabs,
+//     newlines and backslashes as \t, \n and \\ so each record stays on one
+//     line.
+//
+// Flush must be called after the last record.
+type Writer struct {
+	format  Format
+	columns []string
+	out     io.Writer
+	tw      *tabwriter.Writer
+	csv     *csv.Writer
+	enc     *json.Encoder
+	header  bool
+}
+
+// New returns a Writer of records with the given columns to out.
+func New(out io.Writer, f Format, columns ...string) *Writer {
+	w := &Writer{format: f, columns: columns, out: out}
+	switch f {
+	case Table:
+		w.tw = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	case CSV:
+		w.csv = csv.NewWriter(out)
+	case JSON:
+		w.enc = json.NewEncoder(out)
+		w.enc.SetEscapeHTML(false)
+	}
+	return w
+}
+
+// Indent makes JSON values indented, one value spanning several lines;
+// for commands that print a single record.
+func (w *Writer) Indent(indent string) {
+	if w.enc != nil {
+		w.enc.SetIndent("", indent)
+	}
+}
+
+// Write emits one record: v is what JSON encodes and cells are the values
+// of the columns, in order, for the other formats.
+func (w *Writer) Write(v any, cells ...string) error {
+	if w.format == JSON {
+		return w.enc.Encode(v)
+	}
+	if len(cells) != len(w.columns) {
+		return fmt.Errorf("output: %d cells for %d columns", len(cells), len(w.columns))
+	}
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	return w.row(cells)
+}
+
+// Flush writes anything buffered, and the header of a CSV or TSV output
+// that had no records.
+func (w *Writer) Flush() error {
+	switch w.format {
+	case Table:
+		return w.tw.Flush()
+	case CSV, TSV:
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+		if w.csv != nil {
+			w.csv.Flush()
+			return w.csv.Error()
+		}
+	}
+	return nil
+}
+
+func (w *Writer) writeHeader() error {
+	if w.header {
+		return nil
+	}
+	w.header = true
+	names := w.columns
+	if w.format == Table {
+		names = make([]string, len(w.columns))
+		for i, c := range w.columns {
+			names[i] = strings.ToUpper(c)
+		}
+	}
+	return w.row(names)
+}
+
+func (w *Writer) row(cells []string) error {
+	switch w.format {
+	case Table:
+		out := make([]string, len(cells))
+		for i, c := range cells {
+			out[i] = tableCell(c)
+		}
+		_, err := fmt.Fprintln(w.tw, strings.Join(out, "\t"))
+		return err
+	case CSV:
+		return w.csv.Write(cells)
+	case TSV:
+		out := make([]string, len(cells))
+		for i, c := range cells {
+			out[i] = tsvEscaper.Replace(c)
+		}
+		_, err := fmt.Fprintln(w.out, strings.Join(out, "\t"))
+		return err
+	}
+	return fmt.Errorf("output: unknown format %q", w.format)
+}
+
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// tableCell cuts c to its first line, marking the cut, and turns tabs into
+// spaces so they can't split the column.
+func tableCell(c string) string {
+	if i := strings.IndexAny(c, "\r\n"); i >= 0 {
+		c = strings.TrimSpace(c[:i]) + " …"
+	}
+	return strings.ReplaceAll(c, "\t", " ")
+}
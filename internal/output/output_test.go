@@ -0,0 +1,61 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+type rec struct {
+	Name string `json:"name"`
+	Text string `json:"text"`
+}
+
+func render(t *testing.T, f Format, recs ...rec) string {
+	t.Helper()
+	var b bytes.Buffer
+	w := New(&b, f, "name", "text")
+	for _, r := range recs {
+		if err := w.Write(r, r.Name, r.Text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestWriter(t *testing.T) {
+	recs := []rec{{"a", "one line"}, {"bb", "two\nlines, \"quoted\"\tand tabbed <x@y>"}}
+	cases := map[Format]string{
+		Table: "NAME  TEXT\na     one line\nbb    two …\n",
+		JSON:  `{"name":"a","text":"one line"}` + "\n" + `{"name":"bb","text":"two\nlines, \"quoted\"\tand tabbed <x@y>"}` + "\n",
+		CSV:   "name,text\na,one line\nbb,\"two\nlines, \"\"quoted\"\"\tand tabbed <x@y>\"\n",
+		TSV:   "name\ttext\na\tone line\nbb\ttwo\\nlines, \"quoted\"\\tand tabbed <x@y>\n",
+	}
+	for f, want := range cases {
+		if got := render(t, f, recs...); got != want {
+			t.Errorf("%s:\n got %q\nwant %q", f, got, want)
+		}
+	}
+	// With no records a table prints nothing and CSV/TSV just the header.
+	for f, want := range map[Format]string{Table: "", JSON: "", CSV: "name,text\n", TSV: "name\ttext\n"} {
+		if got := render(t, f); got != want {
+			t.Errorf("%s with no records = %q, want %q", f, got, want)
+		}
+	}
+	if err := New(&bytes.Buffer{}, CSV, "a", "b").Write(nil, "only one"); err == nil {
+		t.Error("Write with too few cells succeeded")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"": Table, "JSON": JSON, " tsv ": TSV, "csv": CSV, "table": Table} {
+		if got, err := ParseFormat(in); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseFormat("yaml"); err == nil {
+		t.Error("ParseFormat(yaml) succeeded")
+	}
+}
@@ -0,0 +1,207 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+// Chart is a bucketed time series for the terminal: one row per day, or
+// per week for windows longer than a month.
+type Chart struct {
+	Title string `json:"title"`
+	// Series names the values of each point, e.g. created and closed.
+	Series []string `json:"series"`
+	// Step is the bucket length.
+	Step   time.Duration `json:"step"`
+	Points []Point       `json:"points"`
+}
+
+// Point is one bucket and a value per series.
+type Point struct {
+	Start  time.Time `json:"start"`
+	Values []int     `json:"values"`
+}
+
+// chartGlyphs draw each series' bars.
+var chartGlyphs = []byte{'#', '='}
+
+// buckets splits since..now into day or week buckets starting at midnight.
+func buckets(since, now time.Time) (starts []time.Time, step time.Duration) {
+	day := time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, since.Location())
+	n := 1
+	if now.Sub(day) > 31*24*time.Hour {
+		n = 7
+	}
+	for d := day; !d.After(now); d = d.AddDate(0, 0, n) {
+		starts = append(starts, d)
+	}
+	return starts, time.Duration(n) * 24 * time.Hour
+}
+
+// closedAt holds each task's close, approve and reopen events in order, to
+// tell whether it was closed at a given time.
+type closedAt map[string][]utask.Activity
+
+func closeEvents(events []utask.Activity) closedAt {
+	out := closedAt{}
+	for _, a := range events {
+		switch a.Kind {
+		case utask.ActivityClosed, utask.ActivityApproved, utask.ActivityReopened:
+			out[a.Task.ID] = append(out[a.Task.ID], a)
+		}
+	}
+	for _, evs := range out {
+		sort.SliceStable(evs, func(i, j int) bool { return evs[i].When.Before(evs[j].When) })
+	}
+	return out
+}
+
+// closed replays t's events up to at; a task without any keeps its
+// current state.
+func (c closedAt) closed(t utask.Task, at time.Time) bool {
+	evs := c[t.ID]
+	if len(evs) == 0 {
+		return t.Done
+	}
+	// Before the first recorded change the task was in the opposite state.
+	closed := evs[0].Kind == utask.ActivityReopened
+	for _, a := range evs {
+		if a.When.After(at) {
+			break
+		}
+		closed = a.Kind != utask.ActivityReopened
+	}
+	return closed
+}
+
+// Burndown charts how many tasks carrying tag (all tasks if empty) were
+// still open at the end of each bucket.
+func Burndown(tasks []utask.Task, events []utask.Activity, tag string, since, now time.Time) Chart {
+	starts, step := buckets(since, now)
+	title := "open tasks"
+	if tag != "" {
+		title += " tagged " + tag
+	}
+	ch := Chart{Title: title, Series: []string{"open"}, Step: step, Points: []Point{}}
+	ce := closeEvents(events)
+	for _, start := range starts {
+		end := start.Add(step)
+		if end.After(now) {
+			end = now
+		}
+		open := 0
+		for _, t := range tasks {
+			if tag != "" && !hasTag(t, tag) {
+				continue
+			}
+			created, err := time.Parse(time.RFC3339, t.Created)
+			if err != nil || created.After(end) || ce.closed(t, end) {
+				continue
+			}
+			open++
+		}
+		ch.Points = append(ch.Points, Point{Start: start, Values: []int{open}})
+	}
+	return ch
+}
+
+// CreatedVsClosed charts tasks created and tasks closed in each bucket.
+// Created counts come from the tasks that still exist; closes from the
+// events, once per task and bucket.
+func CreatedVsClosed(tasks []utask.Task, events []utask.Activity, since, now time.Time) Chart {
+	starts, step := buckets(since, now)
+	ch := Chart{Title: "created vs closed", Series: []string{"created", "closed"}, Step: step, Points: []Point{}}
+	// index finds the bucket at falls in: the last one starting at or before it.
+	index := func(at time.Time) int {
+		if at.Before(since) || at.After(now) {
+			return -1
+		}
+		return sort.Search(len(starts), func(i int) bool { return starts[i].After(at) }) - 1
+	}
+	for _, start := range starts {
+		ch.Points = append(ch.Points, Point{Start: start, Values: []int{0, 0}})
+	}
+	for _, t := range tasks {
+		if created, err := time.Parse(time.RFC3339, t.Created); err == nil {
+			if i := index(created); i >= 0 && i < len(ch.Points) {
+				ch.Points[i].Values[0]++
+			}
+		}
+	}
+	seen := map[string]bool{}
+	for _, a := range events {
+		if a.Kind != utask.ActivityClosed && a.Kind != utask.ActivityApproved {
+			continue
+		}
+		i := index(a.When)
+		if i < 0 || i >= len(ch.Points) {
+			continue
+		}
+		if key := fmt.Sprintf("%s/%d", a.Task.ID, i); !seen[key] {
+			seen[key] = true
+			ch.Points[i].Values[1]++
+		}
+	}
+	return ch
+}
+
+func hasTag(t utask.Task, tag string) bool {
+	for _, tt := range t.Tags {
+		if strings.EqualFold(tt, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Render draws the chart as horizontal bars scaled to width columns, one
+// line per series and bucket, followed by a legend.
+func (ch Chart) Render(w io.Writer, width int) error {
+	if width < 10 {
+		width = 10
+	}
+	max := 0
+	for _, p := range ch.Points {
+		for _, v := range p.Values {
+			if v > max {
+				max = v
+			}
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (per %s)\n", ch.Title, stepName(ch.Step))
+	for _, p := range ch.Points {
+		for i, v := range p.Values {
+			label := p.Start.Format("2006-01-02")
+			if i > 0 {
+				label = strings.Repeat(" ", len(label))
+			}
+			n := 0
+			if max > 0 {
+				n = (v*width + max - 1) / max
+			}
+			fmt.Fprintf(&b, "%s |%s %d\n", label, strings.Repeat(string(chartGlyphs[i%len(chartGlyphs)]), n), v)
+		}
+	}
+	if len(ch.Series) > 1 {
+		legend := make([]string, len(ch.Series))
+		for i, s := range ch.Series {
+			legend[i] = fmt.Sprintf("%c %s", chartGlyphs[i%len(chartGlyphs)], s)
+		}
+		fmt.Fprintf(&b, "%s\n", strings.Join(legend, "   "))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func stepName(step time.Duration) string {
+	if step >= 7*24*time.Hour {
+		return "week"
+	}
+	return "day"
+}
@@ -0,0 +1,62 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+func TestBurndown(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2026, 3, d, h, 0, 0, 0, time.UTC) }
+	tasks := []utask.Task{
+		{ID: "a", Tags: []string{"sprint-12"}, Created: day(1, 9).Format(time.RFC3339), Done: true},
+		{ID: "b", Tags: []string{"sprint-12"}, Created: day(1, 9).Format(time.RFC3339)},
+		{ID: "c", Tags: []string{"sprint-12"}, Created: day(2, 9).Format(time.RFC3339), Done: true},
+		{ID: "d", Tags: []string{"sprint-12"}, Created: day(1, 9).Format(time.RFC3339)},
+		{ID: "e", Created: day(1, 9).Format(time.RFC3339)},
+	}
+	events := []utask.Activity{
+		{Kind: utask.ActivityClosed, When: day(2, 10), Task: utask.Task{ID: "a"}},
+		{Kind: utask.ActivityApproved, When: day(3, 10), Task: utask.Task{ID: "c"}},
+		{Kind: utask.ActivityClosed, When: day(2, 11), Task: utask.Task{ID: "d"}},
+		{Kind: utask.ActivityReopened, When: day(3, 11), Task: utask.Task{ID: "d"}},
+	}
+	ch := Burndown(tasks, events, "sprint-12", day(1, 8), day(3, 12))
+	var got []int
+	for _, p := range ch.Points {
+		got = append(got, p.Values[0])
+	}
+	if want := []int{3, 2, 2}; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Fatalf("burndown = %v, want %v", got, want)
+	}
+
+	cc := CreatedVsClosed(tasks, events, day(1, 8), day(3, 12))
+	if len(cc.Points) != 3 || cc.Points[0].Values[0] != 4 || cc.Points[1].Values[0] != 1 || cc.Points[1].Values[1] != 2 || cc.Points[2].Values[1] != 1 {
+		t.Fatalf("created vs closed = %+v", cc.Points)
+	}
+	var buf bytes.Buffer
+	if err := cc.Render(&buf, 10); err != nil {
+		t.Fatal(err)
+	}
+	want := "created vs closed (per day)\n" +
+		"2026-03-01 |########## 4\n" +
+		"           | 0\n" +
+		"2026-03-02 |### 1\n" +
+		"           |===== 2\n" +
+		"2026-03-03 | 0\n" +
+		"           |=== 1\n" +
+		"# created   = closed\n"
+	if buf.String() != want {
+		t.Fatalf("render:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestBucketsWeekly(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	starts, step := buckets(now.AddDate(0, 0, -90), now)
+	if step != 7*24*time.Hour || len(starts) != 13 {
+		t.Fatalf("got %d buckets of %v", len(starts), step)
+	}
+}
@@ -0,0 +1,113 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+// dueSoon is how far ahead a digest lists upcoming due dates.
+const dueSoon = 7 * 24 * time.Hour
+
+// Digest is a backlog summary for a window ending now, as sent by scheduled
+// reports and printed by `ut report digest`.
+type Digest struct {
+	Since time.Time `json:"since"`
+	Now   time.Time `json:"now"`
+	Open  int       `json:"open"`
+	// Created and Closed count tasks created and closed in the window.
+	Created int `json:"created"`
+	Closed  int `json:"closed"`
+	// Review counts open tasks awaiting approval.
+	Review int `json:"review"`
+	// Overdue and DueSoon list open tasks past or within a week of their
+	// due date, soonest first.
+	Overdue []utask.Task `json:"overdue"`
+	DueSoon []utask.Task `json:"due_soon"`
+	Team    []TeamRow    `json:"team"`
+}
+
+// BuildDigest summarizes tasks and the window's close events (see Team).
+func BuildDigest(tasks []utask.Task, events []utask.Activity, since, now time.Time) Digest {
+	d := Digest{Since: since, Now: now, Overdue: []utask.Task{}, DueSoon: []utask.Task{}, Team: Team(tasks, events, since, now)}
+	for _, t := range tasks {
+		if created, err := time.Parse(time.RFC3339, t.Created); err == nil && created.After(since) && !created.After(now) {
+			d.Created++
+		}
+		if t.Done {
+			continue
+		}
+		d.Open++
+		if t.ReviewRequestedBy != "" {
+			d.Review++
+		}
+		switch due, ok := t.DueAt(now.Location()); {
+		case !ok:
+		case now.After(due):
+			d.Overdue = append(d.Overdue, t)
+		case due.Sub(now) <= dueSoon:
+			d.DueSoon = append(d.DueSoon, t)
+		}
+	}
+	for _, r := range d.Team {
+		d.Closed += r.Closed
+	}
+	byDue := func(ts []utask.Task) {
+		sort.SliceStable(ts, func(i, j int) bool {
+			a, _ := ts[i].DueAt(now.Location())
+			b, _ := ts[j].DueAt(now.Location())
+			return a.Before(b)
+		})
+	}
+	byDue(d.Overdue)
+	byDue(d.DueSoon)
+	return d
+}
+
+// Subject is a one-line title for the digest, e.g. an email subject.
+func (d Digest) Subject() string {
+	return fmt.Sprintf("ut summary: %d open, %d overdue, %d closed since %s", d.Open, len(d.Overdue), d.Closed, d.Since.Format("Jan 2"))
+}
+
+// Text renders the digest as plain text.
+func (d Digest) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s to %s\n", d.Since.Format("2006-01-02 15:04"), d.Now.Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, "%d open (%d overdue, %d awaiting review); %d created, %d closed\n", d.Open, len(d.Overdue), d.Review, d.Created, d.Closed)
+	list := func(title string, ts []utask.Task) {
+		if len(ts) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s:\n", title)
+		for _, t := range ts {
+			due, _ := t.DueAt(d.Now.Location())
+			line := fmt.Sprintf("  %s  %s (due %s", shortID(t.ID), t.Short(), due.Format("2006-01-02"))
+			if t.Assignee != "" {
+				line += ", " + t.Assignee
+			}
+			b.WriteString(line + ")\n")
+		}
+	}
+	list("Overdue", d.Overdue)
+	list("Due within a week", d.DueSoon)
+	if len(d.Team) > 0 {
+		b.WriteString("\nBy assignee:\n")
+		tw := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+		for _, r := range d.Team {
+			fmt.Fprintf(tw, "  %s\t%d open\t%d closed\t%d overdue\n", r.Assignee, r.Open, r.Closed, r.Overdue)
+		}
+		tw.Flush()
+	}
+	return b.String()
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
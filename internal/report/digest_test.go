@@ -0,0 +1,37 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+func TestBuildDigest(t *testing.T) {
+	now := time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)
+	since := now.AddDate(0, 0, -7)
+	tasks := []utask.Task{
+		{ID: "11111111aaaa", Text: "ship it\n\nDue: 2026-03-01", Assignee: "ada", Created: "2026-02-01T00:00:00Z"},
+		{ID: "22222222bbbb", Text: "plan\n\nDue: 2026-03-12", Created: "2026-03-04T00:00:00Z"},
+		{ID: "33333333cccc", Text: "later\n\nDue: 2026-06-01", ReviewRequestedBy: "bob", Created: "2026-03-05T00:00:00Z"},
+		{ID: "44444444dddd", Text: "done", Done: true, Assignee: "ada", Created: "2026-03-06T00:00:00Z"},
+	}
+	events := []utask.Activity{{Kind: utask.ActivityClosed, Task: tasks[3]}}
+	d := BuildDigest(tasks, events, since, now)
+	if d.Open != 3 || d.Created != 3 || d.Closed != 1 || d.Review != 1 {
+		t.Fatalf("unexpected counts %+v", d)
+	}
+	if len(d.Overdue) != 1 || d.Overdue[0].ID != "11111111aaaa" || len(d.DueSoon) != 1 || d.DueSoon[0].ID != "22222222bbbb" {
+		t.Fatalf("unexpected due lists %+v / %+v", d.Overdue, d.DueSoon)
+	}
+	text := d.Text()
+	for _, want := range []string{"3 open (1 overdue, 1 awaiting review); 3 created, 1 closed", "Overdue:\n  11111111  ship it (due 2026-03-01, ada)", "Due within a week:\n  22222222  plan (due 2026-03-12)", "By assignee:"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("digest text missing %q:\n%s", want, text)
+		}
+	}
+	if got := d.Subject(); got != "ut summary: 3 open, 1 overdue, 1 closed since Mar 2" {
+		t.Fatalf("Subject() = %q", got)
+	}
+}
@@ -0,0 +1,140 @@
+package report
+
+import (
+	"sort"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+// Flow is the throughput of a report window: tasks created and closed per
+// day, how long the closed ones took, and closes per tag.
+type Flow struct {
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+	// Tag limits the report to tasks carrying it, when set.
+	Tag     string    `json:"tag,omitempty"`
+	Created int       `json:"created"`
+	Closed  int       `json:"closed"`
+	Days    []FlowDay `json:"days"`
+	// AvgCycleSeconds is the mean time from create to close of the tasks
+	// closed in the window; 0 when none were.
+	AvgCycleSeconds int64     `json:"avg_cycle_seconds"`
+	Tags            []TagFlow `json:"tags"`
+}
+
+// FlowDay is one day of a Flow.
+type FlowDay struct {
+	Date    string `json:"date"`
+	Created int    `json:"created"`
+	Closed  int    `json:"closed"`
+}
+
+// TagFlow is one tag's share of a Flow.
+type TagFlow struct {
+	Tag     string `json:"tag"`
+	Created int    `json:"created"`
+	Closed  int    `json:"closed"`
+}
+
+// AvgCycleTime is AvgCycleSeconds as a duration.
+func (f Flow) AvgCycleTime() time.Duration { return time.Duration(f.AvgCycleSeconds) * time.Second }
+
+// BuildFlow counts the tasks created and closed each day from since to
+// now, in now's time zone. Created counts come from the tasks that still
+// exist; closes from the close and approve events, once per task, at its
+// last close in the window. A close's cycle time runs from the task's
+// Created to that event, so it needs the audit trail (or the close times
+// derived from each task's last write on profiles without one).
+func BuildFlow(tasks []utask.Task, events []utask.Activity, tag string, since, now time.Time) Flow {
+	f := Flow{Since: since, Until: now, Tag: tag, Days: []FlowDay{}, Tags: []TagFlow{}}
+	loc := now.Location()
+	first := time.Date(since.In(loc).Year(), since.In(loc).Month(), since.In(loc).Day(), 0, 0, 0, 0, loc)
+	day := map[string]int{}
+	for d := first; !d.After(now); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		day[date] = len(f.Days)
+		f.Days = append(f.Days, FlowDay{Date: date})
+	}
+	in := func(at time.Time) (int, bool) {
+		if at.Before(since) || at.After(now) {
+			return 0, false
+		}
+		i, ok := day[at.In(loc).Format("2006-01-02")]
+		return i, ok
+	}
+	tags := map[string]*TagFlow{}
+	tagRow := func(tag string) *TagFlow {
+		r, ok := tags[tag]
+		if !ok {
+			r = &TagFlow{Tag: tag}
+			tags[tag] = r
+		}
+		return r
+	}
+	for _, t := range tasks {
+		if tag != "" && !hasTag(t, tag) {
+			continue
+		}
+		created, err := time.Parse(time.RFC3339, t.Created)
+		if err != nil {
+			continue
+		}
+		i, ok := in(created)
+		if !ok {
+			continue
+		}
+		f.Created++
+		f.Days[i].Created++
+		for _, tt := range t.Tags {
+			tagRow(tt).Created++
+		}
+	}
+
+	last := map[string]utask.Activity{}
+	for _, a := range events {
+		if a.Kind != utask.ActivityClosed && a.Kind != utask.ActivityApproved {
+			continue
+		}
+		if tag != "" && !hasTag(a.Task, tag) {
+			continue
+		}
+		if _, ok := in(a.When); !ok {
+			continue
+		}
+		if prev, ok := last[a.Task.ID]; !ok || a.When.After(prev.When) {
+			last[a.Task.ID] = a
+		}
+	}
+	var cycle time.Duration
+	cycles := 0
+	for _, a := range last {
+		i, _ := in(a.When)
+		f.Closed++
+		f.Days[i].Closed++
+		for _, tt := range a.Task.Tags {
+			tagRow(tt).Closed++
+		}
+		if created, err := time.Parse(time.RFC3339, a.Task.Created); err == nil && a.When.After(created) {
+			cycle += a.When.Sub(created)
+			cycles++
+		}
+	}
+	if cycles > 0 {
+		f.AvgCycleSeconds = int64((cycle / time.Duration(cycles)).Round(time.Second) / time.Second)
+	}
+	for _, r := range tags {
+		f.Tags = append(f.Tags, *r)
+	}
+	sort.Slice(f.Tags, func(i, j int) bool {
+		a, b := f.Tags[i], f.Tags[j]
+		if a.Closed != b.Closed {
+			return a.Closed > b.Closed
+		}
+		if a.Created != b.Created {
+			return a.Created > b.Created
+		}
+		return a.Tag < b.Tag
+	})
+	return f
+}
@@ -0,0 +1,51 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+func TestBuildFlow(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2026, 3, d, h, 0, 0, 0, time.UTC) }
+	task := func(id string, created time.Time, tags ...string) utask.Task {
+		return utask.Task{ID: id, Tags: tags, Created: created.Format(time.RFC3339)}
+	}
+	a := task("a", day(1, 9), "api")
+	b := task("b", day(1, 9), "api", "bug")
+	c := task("c", day(2, 9), "web")
+	old := task("old", day(3, 9).AddDate(0, 0, -10), "api")
+	tasks := []utask.Task{a, b, c, old}
+	events := []utask.Activity{
+		{Kind: utask.ActivityClosed, When: day(1, 21), Task: a},
+		{Kind: utask.ActivityClosed, When: day(2, 9), Task: b},
+		// Closed, reopened and closed again: counted once, at the last close.
+		{Kind: utask.ActivityReopened, When: day(2, 10), Task: b},
+		{Kind: utask.ActivityApproved, When: day(3, 9), Task: b},
+		{Kind: utask.ActivityClosed, When: day(3, 9), Task: old},
+		{Kind: utask.ActivityUpdated, When: day(3, 10), Task: c},
+	}
+	f := BuildFlow(tasks, events, "", day(1, 8), day(3, 12))
+	if f.Created != 3 || f.Closed != 3 || len(f.Days) != 3 {
+		t.Fatalf("flow = %+v", f)
+	}
+	want := []FlowDay{{"2026-03-01", 2, 1}, {"2026-03-02", 1, 0}, {"2026-03-03", 0, 2}}
+	for i, d := range want {
+		if f.Days[i] != d {
+			t.Fatalf("day %d = %+v, want %+v", i, f.Days[i], d)
+		}
+	}
+	// a took 12h, b 48h and old 10 days.
+	if got, want := f.AvgCycleTime(), (12*time.Hour+48*time.Hour+240*time.Hour)/3; got != want {
+		t.Fatalf("average cycle time = %v, want %v", got, want)
+	}
+	if len(f.Tags) != 3 || f.Tags[0] != (TagFlow{"api", 2, 3}) || f.Tags[1] != (TagFlow{"bug", 1, 1}) || f.Tags[2] != (TagFlow{"web", 1, 0}) {
+		t.Fatalf("tags = %+v", f.Tags)
+	}
+
+	f = BuildFlow(tasks, events, "bug", day(1, 8), day(3, 12))
+	if f.Created != 1 || f.Closed != 1 || f.AvgCycleTime() != 48*time.Hour {
+		t.Fatalf("bug flow = %+v", f)
+	}
+}
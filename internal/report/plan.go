@@ -0,0 +1,186 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+const week = 7 * 24 * time.Hour
+
+// Plan is a capacity forecast: open-task estimates laid out over the weeks
+// up to a horizon, earliest due date first, against a weekly capacity.
+type Plan struct {
+	Now   time.Time `json:"now"`
+	Until time.Time `json:"until"`
+	// CapacityMinutes is the work available in a full week.
+	CapacityMinutes int        `json:"capacity_minutes"`
+	Weeks           []PlanWeek `json:"weeks"`
+	// AtRisk lists tasks that won't be done by their due date at this
+	// capacity, soonest due first.
+	AtRisk []PlanRisk `json:"at_risk"`
+	// BeyondMinutes is estimated work that doesn't fit before Until.
+	BeyondMinutes int `json:"beyond_minutes"`
+	// Unestimated counts open tasks without an estimate, which the plan
+	// can't account for.
+	Unestimated int `json:"unestimated"`
+}
+
+// PlanWeek is one week of the plan. The first week starts now and has
+// the remaining share of its capacity.
+type PlanWeek struct {
+	Start           time.Time `json:"start"`
+	CapacityMinutes int       `json:"capacity_minutes"`
+	// DueMinutes sums the estimates of tasks due this week (overdue tasks
+	// count in the first).
+	DueMinutes int `json:"due_minutes"`
+	// PlannedMinutes is the work scheduled into the week.
+	PlannedMinutes int `json:"planned_minutes"`
+	// Overcommitted is set when the work due by the end of the week is more
+	// than the capacity up to then.
+	Overcommitted bool `json:"overcommitted"`
+}
+
+// PlanRisk is a task forecast to miss its due date.
+type PlanRisk struct {
+	Task utask.Task `json:"task"`
+	Due  time.Time  `json:"due"`
+	// Finish is when the task would be done, zero if not before Until.
+	Finish time.Time `json:"finish"`
+}
+
+type planItem struct {
+	t      utask.Task
+	due    time.Time
+	hasDue bool
+}
+
+// BuildPlan forecasts the open tasks from now until the given time at
+// capacity per week. Tasks are worked on in due-date order, then by
+// priority; undated tasks fill what is left.
+func BuildPlan(tasks []utask.Task, now, until time.Time, capacity time.Duration) Plan {
+	capMin := int(capacity / time.Minute)
+	p := Plan{Now: now, Until: until, CapacityMinutes: capMin, Weeks: []PlanWeek{}, AtRisk: []PlanRisk{}}
+	// Weeks start on Monday; the first runs from now to the next Monday.
+	monday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monday = monday.AddDate(0, 0, -((int(monday.Weekday()) + 6) % 7))
+	var ends []time.Time
+	for start := now; start.Before(until); {
+		end := monday.AddDate(0, 0, 7*(len(ends)+1))
+		if end.After(until) {
+			end = until
+		}
+		p.Weeks = append(p.Weeks, PlanWeek{Start: start, CapacityMinutes: int(float64(capMin) * float64(end.Sub(start)) / float64(week))})
+		ends = append(ends, end)
+		start = end
+	}
+	weekOf := func(t time.Time) int {
+		return sort.Search(len(ends), func(i int) bool { return ends[i].After(t) })
+	}
+
+	var items []planItem
+	for _, t := range tasks {
+		if t.Done {
+			continue
+		}
+		if t.EstimateMinutes <= 0 {
+			p.Unestimated++
+			continue
+		}
+		due, ok := t.DueAt(now.Location())
+		items = append(items, planItem{t: t, due: due, hasDue: ok})
+		if ok {
+			if w := weekOf(due); w < len(p.Weeks) {
+				p.Weeks[w].DueMinutes += t.EstimateMinutes
+			}
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.hasDue != b.hasDue {
+			return a.hasDue
+		}
+		if a.hasDue && !a.due.Equal(b.due) {
+			return a.due.Before(b.due)
+		}
+		return a.t.Priority < b.t.Priority
+	})
+
+	dueSoFar, capSoFar := 0, 0
+	for i := range p.Weeks {
+		dueSoFar += p.Weeks[i].DueMinutes
+		capSoFar += p.Weeks[i].CapacityMinutes
+		p.Weeks[i].Overcommitted = dueSoFar > capSoFar
+	}
+
+	w, usedInWeek := 0, 0
+	for _, it := range items {
+		left := it.t.EstimateMinutes
+		var finish time.Time
+		for left > 0 && w < len(p.Weeks) {
+			wk := &p.Weeks[w]
+			take := min(left, wk.CapacityMinutes-usedInWeek)
+			wk.PlannedMinutes += take
+			usedInWeek += take
+			left -= take
+			if left == 0 {
+				// Work is spread evenly over the week's span.
+				span := ends[w].Sub(wk.Start)
+				finish = wk.Start.Add(time.Duration(float64(span) * float64(usedInWeek) / float64(max(wk.CapacityMinutes, 1))))
+			}
+			if usedInWeek >= wk.CapacityMinutes {
+				w++
+				usedInWeek = 0
+			}
+		}
+		p.BeyondMinutes += left
+		if it.hasDue && !it.due.After(until) && (left > 0 || finish.After(it.due)) {
+			p.AtRisk = append(p.AtRisk, PlanRisk{Task: it.t, Due: it.due, Finish: finish})
+		}
+	}
+	return p
+}
+
+// Text renders the plan as a weekly table followed by the tasks at risk.
+func (p Plan) Text() string {
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "week of\tcapacity\tdue\tplanned\t")
+	for _, w := range p.Weeks {
+		flag := ""
+		if w.Overcommitted {
+			flag = "overcommitted"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", w.Start.Format("2006-01-02"), hours(w.CapacityMinutes), hours(w.DueMinutes), hours(w.PlannedMinutes), flag)
+	}
+	tw.Flush()
+	if p.BeyondMinutes > 0 {
+		fmt.Fprintf(&b, "%s of estimated work doesn't fit before %s\n", hours(p.BeyondMinutes), p.Until.Format("2006-01-02"))
+	}
+	if len(p.AtRisk) > 0 {
+		b.WriteString("\nat risk:\n")
+		for _, r := range p.AtRisk {
+			finish := "not before " + p.Until.Format("2006-01-02")
+			if !r.Finish.IsZero() {
+				finish = "done ~" + r.Finish.Format("2006-01-02")
+			}
+			fmt.Fprintf(&b, "  %s  due %s, %s  %s\n", shortID(r.Task.ID), r.Due.Format("2006-01-02"), finish, r.Task.Short())
+		}
+	}
+	if p.Unestimated > 0 {
+		fmt.Fprintf(&b, "\n%d open tasks have no estimate and aren't planned\n", p.Unestimated)
+	}
+	return b.String()
+}
+
+// hours formats minutes of work as hours, e.g. "12h" or "7.5h".
+func hours(m int) string {
+	if m%60 == 0 {
+		return fmt.Sprintf("%dh", m/60)
+	}
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(m)/60), ".0") + "h"
+}
@@ -0,0 +1,44 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+func TestBuildPlan(t *testing.T) {
+	// Monday 9:00, three weeks of 10h.
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 3, 23, 0, 0, 0, 0, time.UTC)
+	tasks := []utask.Task{
+		{ID: "a", Text: "late\n\nDue: 2026-03-01", EstimateMinutes: 120},
+		{ID: "b", Text: "big\n\nDue: 2026-03-06", EstimateMinutes: 600},
+		{ID: "c", Text: "later\n\nDue: 2026-03-20", EstimateMinutes: 300},
+		{ID: "d", Text: "someday", EstimateMinutes: 900, Priority: 1},
+		{ID: "e", Text: "no estimate"},
+		{ID: "f", Text: "done", Done: true, EstimateMinutes: 60},
+	}
+	p := BuildPlan(tasks, now, until, 10*time.Hour)
+	if len(p.Weeks) != 3 {
+		t.Fatalf("weeks: %+v", p.Weeks)
+	}
+	w0 := p.Weeks[0]
+	if w0.CapacityMinutes != 567 || w0.DueMinutes != 720 || !w0.Overcommitted || w0.PlannedMinutes != 567 {
+		t.Fatalf("week 0: %+v", w0)
+	}
+	if p.Weeks[1].Overcommitted || p.Weeks[2].DueMinutes != 300 || p.Weeks[2].Overcommitted {
+		t.Fatalf("weeks: %+v", p.Weeks)
+	}
+	// a is overdue already and b can't be done by Friday; c fits.
+	if len(p.AtRisk) != 2 || p.AtRisk[0].Task.ID != "a" || p.AtRisk[1].Task.ID != "b" {
+		t.Fatalf("at risk: %+v", p.AtRisk)
+	}
+	if p.Unestimated != 1 || p.BeyondMinutes != 1920-1767 {
+		t.Fatalf("unestimated %d, beyond %d", p.Unestimated, p.BeyondMinutes)
+	}
+	if text := p.Text(); !strings.Contains(text, "overcommitted") || !strings.Contains(text, "2.5h of estimated work") {
+		t.Fatalf("text:\n%s", text)
+	}
+}
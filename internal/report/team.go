@@ -0,0 +1,127 @@
+// Package report aggregates tasks into summaries for sharing outside ut.
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+// Unassigned is the row name for tasks without an assignee.
+const Unassigned = "(unassigned)"
+
+// TeamRow is one assignee's workload.
+type TeamRow struct {
+	Assignee string `json:"assignee"`
+	// Open counts open tasks now.
+	Open int `json:"open"`
+	// Closed counts distinct tasks closed in the report window.
+	Closed int `json:"closed"`
+	// EstimateMinutes sums the estimates of open tasks.
+	EstimateMinutes int `json:"estimate_minutes"`
+	// Overdue counts open tasks past their due date.
+	Overdue int `json:"overdue"`
+	// WorkedMinutes sums the time logged on the assignee's tasks, by
+	// anyone, within the report window.
+	WorkedMinutes int `json:"worked_minutes"`
+}
+
+// Team builds per-assignee rows from the current tasks and the close
+// events and time worked of the report window, from since to now, sorted
+// by open count, then name. Assignees are grouped by exact value, so keep
+// identities consistent (see `ut mine`).
+func Team(tasks []utask.Task, events []utask.Activity, since, now time.Time) []TeamRow {
+	rows := map[string]*TeamRow{}
+	row := func(assignee string) *TeamRow {
+		if assignee == "" {
+			assignee = Unassigned
+		}
+		r, ok := rows[assignee]
+		if !ok {
+			r = &TeamRow{Assignee: assignee}
+			rows[assignee] = r
+		}
+		return r
+	}
+	for _, t := range tasks {
+		if worked := workedBetween(t, since, now); worked >= time.Minute {
+			row(t.Assignee).WorkedMinutes += int(worked / time.Minute)
+		}
+		if t.Done {
+			continue
+		}
+		r := row(t.Assignee)
+		r.Open++
+		r.EstimateMinutes += t.EstimateMinutes
+		if t.Overdue(now) {
+			r.Overdue++
+		}
+	}
+	closed := map[string]bool{}
+	for _, a := range events {
+		if a.Kind != utask.ActivityClosed && a.Kind != utask.ActivityApproved || closed[a.Task.ID] {
+			continue
+		}
+		closed[a.Task.ID] = true
+		row(a.Task.Assignee).Closed++
+	}
+	out := make([]TeamRow, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Open != out[j].Open {
+			return out[i].Open > out[j].Open
+		}
+		return out[i].Assignee < out[j].Assignee
+	})
+	return out
+}
+
+// workedBetween sums the part of the task's worked intervals that falls
+// between since and now.
+func workedBetween(t utask.Task, since, now time.Time) time.Duration {
+	var d time.Duration
+	for _, w := range t.Worked {
+		start, end := w.Start, w.End
+		if start.Before(since) {
+			start = since
+		}
+		if end.After(now) {
+			end = now
+		}
+		if end.After(start) {
+			d += end.Sub(start)
+		}
+	}
+	return d
+}
+
+// WriteTeamCSV writes rows with a header line.
+func WriteTeamCSV(w io.Writer, rows []TeamRow) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"assignee", "open", "closed", "estimate_minutes", "overdue", "worked_minutes"})
+	for _, r := range rows {
+		_ = cw.Write([]string{r.Assignee, strconv.Itoa(r.Open), strconv.Itoa(r.Closed), strconv.Itoa(r.EstimateMinutes), strconv.Itoa(r.Overdue), strconv.Itoa(r.WorkedMinutes)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// SprintStart returns the start of the sprint containing now, for sprints
+// of the given length beginning at anchor.
+func SprintStart(anchor time.Time, length time.Duration, now time.Time) (time.Time, error) {
+	if length <= 0 {
+		return time.Time{}, fmt.Errorf("sprint length must be positive")
+	}
+	n := now.Sub(anchor) / length
+	if now.Before(anchor) && now.Sub(anchor)%length != 0 {
+		n--
+	}
+	return anchor.Add(n * length), nil
+}
@@ -0,0 +1,68 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/iainlowe/utask/internal/utask"
+)
+
+func TestTeam(t *testing.T) {
+	now := time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC)
+	tasks := []utask.Task{
+		{ID: "1", Assignee: "ada", EstimateMinutes: 30, Text: "a\n\nDue: 2026-03-01"},
+		{ID: "2", Assignee: "ada", EstimateMinutes: 15},
+		{ID: "3", Assignee: "bob", Worked: []utask.WorkInterval{
+			{Start: now.Add(-8 * 24 * time.Hour), End: now.Add(-8*24*time.Hour + time.Hour)},
+			{Start: now.Add(-7*24*time.Hour - 30*time.Minute), End: now.Add(-7*24*time.Hour + 45*time.Minute)},
+		}},
+		{ID: "4", Assignee: "bob", Done: true, Worked: []utask.WorkInterval{{Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)}}},
+		{ID: "5"},
+	}
+	events := []utask.Activity{
+		{Kind: utask.ActivityClosed, Task: utask.Task{ID: "4", Assignee: "bob"}},
+		{Kind: utask.ActivityClosed, Task: utask.Task{ID: "4", Assignee: "bob"}},
+		{Kind: utask.ActivityApproved, Task: utask.Task{ID: "6", Assignee: "carol"}},
+		{Kind: utask.ActivityUpdated, Task: utask.Task{ID: "2", Assignee: "ada"}},
+	}
+	got := Team(tasks, events, now.Add(-7*24*time.Hour), now)
+	want := []TeamRow{
+		{Assignee: "ada", Open: 2, EstimateMinutes: 45, Overdue: 1},
+		{Assignee: Unassigned, Open: 1},
+		{Assignee: "bob", Open: 1, Closed: 1, WorkedMinutes: 105},
+		{Assignee: "carol", Closed: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("row %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	var buf bytes.Buffer
+	if err := WriteTeamCSV(&buf, got[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "assignee,open,closed,estimate_minutes,overdue,worked_minutes\nada,2,0,45,1,0\n" {
+		t.Fatalf("unexpected csv %q", buf.String())
+	}
+}
+
+func TestSprintStart(t *testing.T) {
+	anchor := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	length := 14 * 24 * time.Hour
+	cases := map[time.Time]time.Time{
+		time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC): anchor,
+		time.Date(2026, 1, 19, 0, 0, 0, 0, time.UTC): anchor.Add(length),
+		time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC):  time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC):  anchor.Add(-length),
+	}
+	for now, want := range cases {
+		got, err := SprintStart(anchor, length, now)
+		if err != nil || !got.Equal(want) {
+			t.Fatalf("SprintStart(%v) = %v, %v; want %v", now, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// A Thursday.
+	from := time.Date(2026, 3, 5, 9, 30, 20, 0, time.UTC)
+	cases := map[string]time.Time{
+		"0 8 * * mon":      time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC),
+		"*/15 * * * *":     time.Date(2026, 3, 5, 9, 45, 0, 0, time.UTC),
+		"30 9 * * *":       time.Date(2026, 3, 6, 9, 30, 0, 0, time.UTC),
+		"0 9-17/4 * * 1-5": time.Date(2026, 3, 5, 13, 0, 0, 0, time.UTC),
+		"@monthly":         time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 1 jan *":      time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		"0 12 13 * fri":    time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC),
+		"0 0 * * 7":        time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC),
+		"0 0 29 2 *":       time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		"5,35 10 5 3 *":    time.Date(2026, 3, 5, 10, 5, 0, 0, time.UTC),
+		"0 0 31 2 *":       {},
+		"59 23 31 12 *":    time.Date(2026, 12, 31, 23, 59, 0, 0, time.UTC),
+	}
+	for expr, want := range cases {
+		c, err := Parse(expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", expr, err)
+		}
+		if got := c.Next(from); !got.Equal(want) {
+			t.Errorf("%q: Next = %v, want %v", expr, got, want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "x * * * *"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded", expr)
+		}
+	}
+}
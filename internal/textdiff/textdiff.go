@@ -0,0 +1,119 @@
+// Package textdiff renders line diffs in unified format.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+type op struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// lineOps aligns a and b on a longest common subsequence.
+func lineOps(a, b []string) []op {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	ops := make([]op, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, op{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, op{'-', a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, op{'+', b[j]})
+	}
+	return ops
+}
+
+// Unified returns the diff from a to b with context lines around each
+// change, headed by the two names. Equal inputs yield "".
+func Unified(nameA, nameB string, a, b []string, context int) string {
+	ops := lineOps(a, b)
+	var out strings.Builder
+	for start := 0; start < len(ops); {
+		// Find the next change and extend the hunk while changes are
+		// within 2*context lines of each other.
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		lo := max(first-context, start)
+		hi := first
+		for k := first; k < len(ops); k++ {
+			if ops[k].kind != ' ' {
+				hi = k
+			} else if k-hi > 2*context {
+				break
+			}
+		}
+		hi = min(hi+context+1, len(ops))
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", nameA, nameB)
+		}
+		aLine, bLine := 1, 1
+		for _, o := range ops[:lo] {
+			if o.kind != '+' {
+				aLine++
+			}
+			if o.kind != '-' {
+				bLine++
+			}
+		}
+		aLen, bLen := 0, 0
+		for _, o := range ops[lo:hi] {
+			if o.kind != '+' {
+				aLen++
+			}
+			if o.kind != '-' {
+				bLen++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", span(aLine, aLen), span(bLine, bLen))
+		for _, o := range ops[lo:hi] {
+			fmt.Fprintf(&out, "%c%s\n", o.kind, o.line)
+		}
+		start = hi
+	}
+	return out.String()
+}
+
+// span formats a hunk range; an empty range names the line before it.
+func span(line, n int) string {
+	if n == 0 {
+		line--
+	}
+	if n == 1 {
+		return fmt.Sprint(line)
+	}
+	return fmt.Sprintf("%d,%d", line, n)
+}
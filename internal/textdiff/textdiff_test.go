@@ -0,0 +1,23 @@
+package textdiff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	a := strings.Split("a b c d e f g h i j", " ")
+	b := strings.Split("a b C d e f g h i j k", " ")
+	want := "--- old\n+++ new\n" +
+		"@@ -1,6 +1,6 @@\n a\n b\n-c\n+C\n d\n e\n f\n" +
+		"@@ -8,3 +8,4 @@\n h\n i\n j\n+k\n"
+	if got := Unified("old", "new", a, b, 3); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if got := Unified("old", "new", a, a, 3); got != "" {
+		t.Fatalf("equal inputs: %q", got)
+	}
+	if got := Unified("/dev/null", "new", nil, []string{"x"}, 3); got != "--- /dev/null\n+++ new\n@@ -0,0 +1 @@\n+x\n" {
+		t.Fatalf("from nothing: %q", got)
+	}
+}
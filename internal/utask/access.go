@@ -0,0 +1,103 @@
+package utask
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Role is what a caller may do with a Store. Roles are ordered: each one
+// may do everything the roles below it may.
+type Role int
+
+const (
+	// RoleReader may list, show and watch tasks.
+	RoleReader Role = iota + 1
+	// RoleContributor may also create, edit, close and reopen tasks and
+	// manage aliases.
+	RoleContributor
+	// RoleAdmin may also delete tasks and run maintenance: index rebuilds,
+	// compaction, resharding and migrations.
+	RoleAdmin
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleReader:
+		return "read-only"
+	case RoleContributor:
+		return "contributor"
+	case RoleAdmin:
+		return "admin"
+	}
+	return fmt.Sprintf("Role(%d)", int(r))
+}
+
+// ParseRole parses read-only (or reader), contributor or admin.
+func ParseRole(s string) (Role, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "read-only", "readonly", "reader", "read":
+		return RoleReader, nil
+	case "contributor":
+		return RoleContributor, nil
+	case "admin":
+		return RoleAdmin, nil
+	}
+	return 0, invalidf("invalid role %q (want read-only|contributor|admin)", s)
+}
+
+type roleKey struct{}
+
+// WithRole returns a context whose Store calls run as role, e.g. the role
+// an HTTP request's token maps to. It can only narrow the Store's own
+// Options.Role, never widen it.
+func WithRole(ctx context.Context, role Role) context.Context {
+	return context.WithValue(ctx, roleKey{}, role)
+}
+
+// Role returns the role Store calls made with ctx run as.
+func (s *Store) Role(ctx context.Context) Role {
+	role := s.opts.Role
+	if role == 0 {
+		role = RoleAdmin
+	}
+	if r, ok := ctx.Value(roleKey{}).(Role); ok && r < role {
+		role = r
+	}
+	return role
+}
+
+// authorize fails with ErrForbidden unless ctx's role is at least need.
+func (s *Store) authorize(ctx context.Context, op string, need Role) error {
+	if have := s.Role(ctx); have < need {
+		return fmt.Errorf("%s: %w: role %s, needs %s", op, ErrForbidden, have, need)
+	}
+	return nil
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a context whose Store calls act as who instead of
+// Options.Identity: writes are stamped with it, and it decides which private
+// tasks are visible. An empty who is anonymous and sees no private tasks.
+func WithIdentity(ctx context.Context, who string) context.Context {
+	return context.WithValue(ctx, identityKey{}, who)
+}
+
+// identity returns who Store calls made with ctx act as.
+func (s *Store) identity(ctx context.Context) string {
+	if who, ok := ctx.Value(identityKey{}).(string); ok {
+		return who
+	}
+	return s.opts.Identity
+}
+
+// canSee reports whether ctx may read t. Shared tasks are visible to
+// everyone; private ones only to their author and to admins.
+func (s *Store) canSee(ctx context.Context, t Task) bool {
+	if !t.Private || s.Role(ctx) >= RoleAdmin {
+		return true
+	}
+	who := s.identity(ctx)
+	return who != "" && t.CreatedBy != "" && SameIdentity(t.CreatedBy, who)
+}
@@ -0,0 +1,57 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseRole(t *testing.T) {
+	cases := map[string]Role{"read-only": RoleReader, "Reader": RoleReader, "contributor": RoleContributor, " admin ": RoleAdmin}
+	for in, want := range cases {
+		if got, err := ParseRole(in); err != nil || got != want {
+			t.Fatalf("ParseRole(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseRole("root"); !errors.Is(err, ErrValidation) {
+		t.Fatalf("ParseRole(root): got %v, want ErrValidation", err)
+	}
+}
+
+func TestRoleNarrowsOnly(t *testing.T) {
+	ctx := context.Background()
+	s := &Store{opts: Options{Role: RoleContributor}}
+	if got := s.Role(WithRole(ctx, RoleAdmin)); got != RoleContributor {
+		t.Fatalf("context widened role to %v", got)
+	}
+	if got := s.Role(WithRole(ctx, RoleReader)); got != RoleReader {
+		t.Fatalf("context role %v, want read-only", got)
+	}
+	if err := s.authorize(ctx, "delete", RoleAdmin); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("authorize: got %v, want ErrForbidden", err)
+	}
+	if got := (&Store{}).Role(ctx); got != RoleAdmin {
+		t.Fatalf("default role %v, want admin", got)
+	}
+}
+
+func TestCanSee(t *testing.T) {
+	ctx := context.Background()
+	mine := Task{Private: true, CreatedBy: "Ada <ada@example.org>"}
+	s := &Store{opts: Options{Role: RoleContributor, Identity: "Bob <bob@example.org>"}}
+	if !s.canSee(ctx, Task{CreatedBy: "Ada <ada@example.org>"}) {
+		t.Fatal("shared task hidden")
+	}
+	if s.canSee(ctx, mine) {
+		t.Fatal("private task visible to someone else")
+	}
+	if !s.canSee(WithIdentity(ctx, "ada@example.org"), mine) {
+		t.Fatal("private task hidden from its author")
+	}
+	if s.canSee(WithIdentity(ctx, ""), mine) {
+		t.Fatal("private task visible to anonymous caller")
+	}
+	if !(&Store{}).canSee(ctx, mine) {
+		t.Fatal("private task hidden from admin")
+	}
+}
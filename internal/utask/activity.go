@@ -0,0 +1,107 @@
+package utask
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// ActivityKind names a task lifecycle event. RecentActivity derives created
+// and closed events from stored state; the audit trail (Store.Audit) records
+// every kind as it happens.
+type ActivityKind string
+
+const (
+	ActivityCreated  ActivityKind = "created"
+	ActivityClosed   ActivityKind = "closed"
+	ActivityReopened ActivityKind = "reopened"
+	ActivityUpdated  ActivityKind = "updated"
+	ActivityAssigned ActivityKind = "assigned"
+	ActivityDeleted  ActivityKind = "deleted"
+	// ActivityReviewRequested is a close held for approval, and
+	// ActivityApproved the approval that closed the task.
+	ActivityReviewRequested ActivityKind = "review_requested"
+	ActivityApproved        ActivityKind = "approved"
+	ActivityDelegated       ActivityKind = "delegated"
+	ActivitySnoozed         ActivityKind = "snoozed"
+	// ActivityBlocked and ActivityUnblocked add and drop dependencies;
+	// Detail names the other task ("on <id>", or "all").
+	ActivityBlocked   ActivityKind = "blocked"
+	ActivityUnblocked ActivityKind = "unblocked"
+	// ActivityWorked is a recorded work interval; Detail is its length.
+	ActivityWorked ActivityKind = "worked"
+	// ActivityAnnotated is a note added to the task; Detail is its text.
+	ActivityAnnotated ActivityKind = "annotated"
+	// ActivityStatusChanged moves an open task between open, in-progress
+	// and blocked, and ActivityCancelled closes it without doing it; Detail
+	// is "from -> to".
+	ActivityStatusChanged ActivityKind = "status"
+	ActivityCancelled     ActivityKind = "cancelled"
+)
+
+// Activity is a single event for a task.
+type Activity struct {
+	Kind ActivityKind `json:"kind"`
+	When time.Time    `json:"when"`
+	// By is who caused the event, when known.
+	By string `json:"by,omitempty"`
+	// Detail qualifies the event, e.g. "bob -> ada" for a reassignment.
+	Detail string `json:"detail,omitempty"`
+	Task   Task   `json:"task"`
+}
+
+// RecentActivity returns created and closed events newer than since, newest
+// first, capped at limit (0 means no cap). Close times come from the task's
+// Closed stamp, or for tasks closed before it was kept, the KV timestamp of
+// its latest revision.
+func (s *Store) RecentActivity(ctx context.Context, since time.Time, limit int) ([]Activity, error) {
+	lister, err := s.tasksKV.ListKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer lister.Stop()
+	out := []Activity{}
+	for k := range lister.Keys() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		e, err := s.tasksKV.Get(ctx, k)
+		if err != nil {
+			continue
+		}
+		t, err := s.decodeTask(e.Value())
+		if err != nil {
+			continue
+		}
+		t.Revision = e.Revision()
+		if !s.canSee(ctx, t) {
+			continue
+		}
+		out = append(out, taskActivity(t, e.Created(), since)...)
+	}
+	sortActivity(out)
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// taskActivity derives the events for one task; modified is the time of its
+// latest stored revision.
+func taskActivity(t Task, modified, since time.Time) []Activity {
+	var out []Activity
+	if t.Closed != nil {
+		modified = t.Closed.Time
+	}
+	if created, err := time.Parse(time.RFC3339, t.Created); err == nil && created.After(since) {
+		out = append(out, Activity{Kind: ActivityCreated, When: created.UTC(), By: t.CreatedBy, Task: t})
+	}
+	if t.Done && modified.After(since) {
+		out = append(out, Activity{Kind: ActivityClosed, When: modified.UTC(), By: t.UpdatedBy, Task: t})
+	}
+	return out
+}
+
+func sortActivity(acts []Activity) {
+	sort.SliceStable(acts, func(i, j int) bool { return acts[i].When.After(acts[j].When) })
+}
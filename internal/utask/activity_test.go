@@ -0,0 +1,55 @@
+package utask
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTaskActivity(t *testing.T) {
+	since := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	modified := time.Date(2025, 8, 20, 0, 0, 0, 0, time.UTC)
+
+	open := Task{ID: "a", Created: "2025-08-10T00:00:00Z"}
+	if acts := taskActivity(open, modified, since); len(acts) != 1 || acts[0].Kind != ActivityCreated {
+		t.Fatalf("expected single created event, got %+v", acts)
+	}
+	closed := Task{ID: "b", Created: "2025-07-01T00:00:00Z", Done: true, CreatedBy: "ada", UpdatedBy: "bob"}
+	acts := taskActivity(closed, modified, since)
+	if len(acts) != 1 || acts[0].Kind != ActivityClosed || !acts[0].When.Equal(modified) || acts[0].By != "bob" {
+		t.Fatalf("expected only closed event inside window, got %+v", acts)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"7d":    7 * 24 * time.Hour,
+		"2w":    14 * 24 * time.Hour,
+		"36h":   36 * time.Hour,
+		"1d12h": 36 * time.Hour,
+	}
+	for in, want := range cases {
+		got, err := ParseDuration(in)
+		if err != nil || got != want {
+			t.Fatalf("ParseDuration(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseDuration("soon"); err == nil {
+		t.Fatalf("expected error for invalid duration")
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	cases := map[time.Duration]string{
+		20 * time.Second:              "<1m",
+		12 * time.Minute:              "12m",
+		83 * time.Minute:              "1h23m",
+		2 * time.Hour:                 "2h",
+		76*time.Hour + 10*time.Minute: "3d4h",
+		48 * time.Hour:                "2d",
+	}
+	for in, want := range cases {
+		if got := FormatDuration(in); got != want {
+			t.Fatalf("FormatDuration(%v) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Aliases are user-chosen names for tasks ("release", "q3-plan"), stored in
+// the meta bucket as one key per alias holding the task id.
+const aliasKeyPrefix = "alias."
+
+// aliasRe keeps alias names readable and usable as KV keys. Dots are left
+// out so an alias maps to exactly one key token.
+var aliasRe = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,63}$`)
+
+// maxDescribed bounds how many ambiguous candidates Resolve reads titles for.
+const maxDescribed = 10
+
+// Candidate describes one task matched by an ambiguous reference.
+type Candidate struct {
+	ID string `json:"id"`
+	// Short is the shortest prefix (at least 8 characters) that tells this
+	// candidate apart from the others.
+	Short string `json:"short"`
+	Title string `json:"title,omitempty"`
+}
+
+// newCandidates builds candidates for ids with distinguishing short ids.
+func newCandidates(ids []string) []Candidate {
+	ids = append([]string(nil), ids...)
+	sort.Strings(ids)
+	n := 8
+	for i := 1; i < len(ids); i++ {
+		n = max(n, commonPrefixLen(ids[i-1], ids[i])+1)
+	}
+	out := make([]Candidate, len(ids))
+	for i, id := range ids {
+		out[i] = Candidate{ID: id, Short: id[:min(n, len(id))]}
+	}
+	return out
+}
+
+func commonPrefixLen(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// normalizeAlias lowercases and checks an alias name.
+func normalizeAlias(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !aliasRe.MatchString(name) {
+		return "", invalidf("invalid alias %q: must match %s", name, aliasRe)
+	}
+	if _, ok := ParseNumber(name); ok {
+		return "", invalidf("invalid alias %q: task numbers are taken", name)
+	}
+	return name, nil
+}
+
+// SetAlias points name at task id, replacing any previous target.
+func (s *Store) SetAlias(ctx context.Context, name, id string) error {
+	if err := s.authorize(ctx, "set alias", RoleContributor); err != nil {
+		return err
+	}
+	name, err := normalizeAlias(name)
+	if err != nil {
+		return err
+	}
+	if _, _, err := s.GetTask(ctx, id); err != nil {
+		return err
+	}
+	if _, err := s.metaKV.Put(ctx, aliasKeyPrefix+name, []byte(id)); err != nil {
+		return fmt.Errorf("set alias %s: %w", name, err)
+	}
+	return nil
+}
+
+// DeleteAlias removes an alias.
+func (s *Store) DeleteAlias(ctx context.Context, name string) error {
+	if err := s.authorize(ctx, "delete alias", RoleContributor); err != nil {
+		return err
+	}
+	name, err := normalizeAlias(name)
+	if err != nil {
+		return err
+	}
+	if _, err := s.metaKV.Get(ctx, aliasKeyPrefix+name); err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return fmt.Errorf("alias %s: %w", name, ErrNotFound)
+		}
+		return err
+	}
+	return s.metaKV.Delete(ctx, aliasKeyPrefix+name)
+}
+
+// Aliases returns every alias and the task id it names.
+func (s *Store) Aliases(ctx context.Context) (map[string]string, error) {
+	lister, err := s.metaKV.ListKeysFiltered(ctx, aliasKeyPrefix+">")
+	if err != nil {
+		return nil, err
+	}
+	defer lister.Stop()
+	out := map[string]string{}
+	for k := range lister.Keys() {
+		e, err := s.metaKV.Get(ctx, k)
+		if err != nil {
+			continue
+		}
+		out[strings.TrimPrefix(k, aliasKeyPrefix)] = string(e.Value())
+	}
+	return out, ctx.Err()
+}
+
+// aliasTarget returns the task an alias names, if it exists and still points
+// at a live task.
+func (s *Store) aliasTarget(ctx context.Context, ref string) (string, bool) {
+	name, err := normalizeAlias(ref)
+	if err != nil {
+		return "", false
+	}
+	e, err := s.metaKV.Get(ctx, aliasKeyPrefix+name)
+	if err != nil {
+		return "", false
+	}
+	id := string(e.Value())
+	return id, s.hasTask(ctx, id)
+}
+
+// hasTask reports whether id is a stored task id.
+func (s *Store) hasTask(ctx context.Context, id string) bool {
+	key := s.taskKey(id)
+	if !validKVKey(key) {
+		return false
+	}
+	_, err := s.tasksKV.Get(ctx, key)
+	return err == nil
+}
+
+// describeCandidates fills in titles for the first maxDescribed candidates.
+func (s *Store) describeCandidates(ctx context.Context, cands []Candidate) {
+	for i := range cands[:min(len(cands), maxDescribed)] {
+		if t, _, err := s.GetTask(ctx, cands[i].ID); err == nil {
+			cands[i].Title = t.Short()
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package utask
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Annotation is a note added to a task after it was written, e.g. what was
+// tried or decided. Annotations only ever append, so editing the text
+// doesn't lose that history.
+type Annotation struct {
+	When time.Time `json:"when"`
+	By   string    `json:"by,omitempty"`
+	Text string    `json:"text"`
+}
+
+// AnnotateTask appends a note to a task. Like LogWork, a concurrent change
+// to the task is re-read and retried rather than reported as ErrConflict.
+func (s *Store) AnnotateTask(ctx context.Context, id, text string) (Task, error) {
+	if err := s.authorize(ctx, "annotate", RoleContributor); err != nil {
+		return Task{}, err
+	}
+	text = strings.TrimSpace(text)
+	if err := s.opts.Limits.validateText(text); err != nil {
+		return Task{}, err
+	}
+	a := Annotation{When: time.Now().UTC().Truncate(time.Second), By: s.identity(ctx), Text: text}
+	var t Task
+	err := retryCAS(ctx, "annotate "+id, func() error {
+		cur, rev, err := s.GetTask(ctx, id)
+		if err != nil {
+			return err
+		}
+		cur.Annotations = append(cur.Annotations, a)
+		cur.touch(a.By, a.When)
+		newRev, err := s.putTaskCAS(ctx, id, cur, rev)
+		if err != nil {
+			return err
+		}
+		cur.Revision = newRev
+		t = cur
+		return nil
+	})
+	if err != nil {
+		return Task{}, err
+	}
+	s.logWrite(ctx, "annotate", id, t.Revision)
+	s.audit(ctx, ActivityAnnotated, t, text)
+	return t, nil
+}
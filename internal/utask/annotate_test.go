@@ -0,0 +1,97 @@
+package utask
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnnotateTask(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	task, _, err := s.CreateTask(ctx, TaskInput{Text: "Fix flaky build"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AnnotateTask(ctx, task.ID, "  retried on a clean runner; still fails  "); err != nil {
+		t.Fatal(err)
+	}
+	text := "Fix flaky build on arm64"
+	if _, err := s.UpdateTask(ctx, task.ID, UpdateSet{Text: &text}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.AnnotateTask(ctx, task.ID, "pinned the toolchain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Annotations) != 2 || got.Annotations[0].Text != "retried on a clean runner; still fails" || got.Annotations[1].Text != "pinned the toolchain" {
+		t.Fatalf("annotations = %+v", got.Annotations)
+	}
+	if got.Annotations[0].By != "Ada <ada@example.org>" || got.Annotations[0].When.IsZero() {
+		t.Fatalf("first annotation = %+v", got.Annotations[0])
+	}
+	if _, err := s.AnnotateTask(ctx, task.ID, " "); !errors.Is(err, ErrValidation) {
+		t.Fatalf("empty annotation: %v", err)
+	}
+	events, err := s.Audit(ctx, AuditFilter{Task: task.ID})
+	if err != nil || events[len(events)-1].Kind != ActivityAnnotated || events[len(events)-1].Detail != "pinned the toolchain" {
+		t.Fatalf("audit = %+v, %v", events, err)
+	}
+}
+
+func TestAnnotateTaskSealed(t *testing.T) {
+	ctx := context.Background()
+	k, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := OpenSQLite(ctx, filepath.Join(t.TempDir(), "tasks.db"), "test", Options{Key: k})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	task, _, err := s.CreateTask(ctx, TaskInput{Text: "rotate the router login"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AnnotateTask(ctx, task.ID, "password is hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	e, err := s.tasksKV.Get(ctx, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := decodeTask(e.Value())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw.Annotations) != 1 || !strings.HasPrefix(raw.Annotations[0].Text, sealedPrefix) {
+		t.Fatalf("annotation stored in the clear: %+v", raw.Annotations)
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT data FROM audit`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(b, []byte("hunter2")) {
+			t.Fatalf("audit event in the clear: %s", b)
+		}
+	}
+
+	got, _, err := s.GetTask(ctx, task.ID)
+	if err != nil || got.Annotations[0].Text != "password is hunter2" {
+		t.Fatalf("GetTask = %+v, %v", got.Annotations, err)
+	}
+	events, err := s.Audit(ctx, AuditFilter{Task: task.ID})
+	if err != nil || events[len(events)-1].Detail != "password is hunter2" {
+		t.Fatalf("audit = %+v, %v", events, err)
+	}
+}
@@ -0,0 +1,51 @@
+package utask
+
+import "strings"
+
+// splitIdentity breaks "Name <email>" into its parts. A bare value with an
+// "@" is an email; anything else is a name.
+func splitIdentity(s string) (name, email string) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '<'); i >= 0 && strings.HasSuffix(s, ">") {
+		return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1 : len(s)-1])
+	}
+	if strings.Contains(s, "@") && !strings.ContainsAny(s, " \t") {
+		return "", s
+	}
+	return s, ""
+}
+
+// matchAssignee reports whether assignee and who name the same person.
+// Identities compare case-insensitively by name or by email, and a bare
+// name also matches an email's local part, so "ada", "ada@example.org" and
+// "Ada <ada@example.org>" all match "Ada <ada@example.org>".
+func matchAssignee(assignee, who string) bool {
+	an, ae := splitIdentity(assignee)
+	wn, we := splitIdentity(who)
+	switch {
+	case an != "" && strings.EqualFold(an, wn), ae != "" && strings.EqualFold(ae, we):
+		return true
+	case wn != "" && we == "" && ae != "":
+		local, _, _ := strings.Cut(ae, "@")
+		return strings.EqualFold(local, wn)
+	case an != "" && ae == "" && we != "":
+		local, _, _ := strings.Cut(we, "@")
+		return strings.EqualFold(local, an)
+	}
+	return false
+}
+
+// SameIdentity reports whether a and b name the same person, by the rules
+// assignee filters use.
+func SameIdentity(a, b string) bool { return matchAssignee(a, b) }
+
+// matchAssigneeFilter applies ListFilter's Assignee and Unassigned to t.
+func matchAssigneeFilter(t Task, f ListFilter) bool {
+	switch {
+	case f.Unassigned:
+		return t.Assignee == ""
+	case f.Assignee != "":
+		return t.Assignee != "" && matchAssignee(t.Assignee, f.Assignee)
+	}
+	return true
+}
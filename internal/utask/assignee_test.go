@@ -0,0 +1,37 @@
+package utask
+
+import "testing"
+
+func TestMatchAssignee(t *testing.T) {
+	ada := "Ada Lovelace <ada@example.org>"
+	for _, who := range []string{ada, "ada lovelace", "ADA@example.org", "ada", "<ada@example.org>"} {
+		if !matchAssignee(ada, who) {
+			t.Fatalf("%q should match %q", who, ada)
+		}
+	}
+	for _, who := range []string{"bob", "bob@example.org", "Lovelace"} {
+		if matchAssignee(ada, who) {
+			t.Fatalf("%q should not match %q", who, ada)
+		}
+	}
+	if !matchAssignee("ada", ada) {
+		t.Fatal("a bare name should match the identity's email local part")
+	}
+}
+
+func TestSnapshotSelectAssignee(t *testing.T) {
+	snap := &Snapshot{Tasks: map[string]Task{
+		"a": {ID: "a", Created: "1", Assignee: "Ada <ada@example.org>"},
+		"b": {ID: "b", Created: "2"},
+		"c": {ID: "c", Created: "3", Assignee: "bob", Done: true},
+	}}
+	if got := snap.Select(ListFilter{Assignee: "ada"}); len(got) != 1 || got[0].ID != "a" {
+		t.Fatalf("assignee filter: %+v", got)
+	}
+	if got := snap.Select(ListFilter{Unassigned: true}); len(got) != 1 || got[0].ID != "b" {
+		t.Fatalf("unassigned filter: %+v", got)
+	}
+	if got := snap.Select(ListFilter{Assignee: "bob", Status: StatusOpen}); len(got) != 0 {
+		t.Fatalf("status and assignee combine: %+v", got)
+	}
+}
@@ -0,0 +1,230 @@
+package utask
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// DefaultAuditRetention is how long audit events are kept when
+// Options.AuditRetention is zero.
+const DefaultAuditRetention = 90 * 24 * time.Hour
+
+// auditNames returns the stream and subject holding a profile's audit trail:
+// one Activity per task write, in order.
+func auditNames(ns string) (stream, subject string) {
+	return "utask_audit_" + ns, "utask.audit." + ns
+}
+
+// ensureAuditStream creates the audit stream on first use.
+func (s *Store) ensureAuditStream(ctx context.Context) error {
+	name, subject := auditNames(s.ns)
+	maxAge := s.opts.AuditRetention
+	if maxAge == 0 {
+		maxAge = DefaultAuditRetention
+	}
+	_, err := s.js.CreateStream(ctx, jetstream.StreamConfig{Name: name, Subjects: []string{subject}, MaxAge: maxAge})
+	if errors.Is(err, jetstream.ErrStreamNameAlreadyInUse) {
+		return nil
+	}
+	return err
+}
+
+// audit appends an event for t to the audit trail. The write it describes
+// has already happened, so failures are logged rather than returned.
+// t.Revision, when set, is the revision that write produced; it lets
+// TaskHistory find a task's versions.
+func (s *Store) audit(ctx context.Context, kind ActivityKind, t Task, detail string) {
+	sealed, err := sealTask(t, s.opts)
+	if err == nil {
+		// A note's text is its detail, so the detail is sealed with the task.
+		detail, err = s.opts.Key.seal(detail)
+	}
+	if err != nil {
+		s.log.WarnContext(ctx, "audit event not recorded", "op", string(kind), "task", t.ID, "err", err)
+		return
+	}
+	b, err := json.Marshal(Activity{Kind: kind, When: time.Now().UTC(), By: s.identity(ctx), Detail: detail, Task: sealed})
+	if err != nil {
+		return
+	}
+	if s.db != nil {
+		err = s.auditSQLite(ctx, b)
+	} else {
+		_, subject := auditNames(s.ns)
+		_, err = s.js.Publish(ctx, subject, b)
+		if errors.Is(err, jetstream.ErrNoStreamResponse) {
+			if err = s.ensureAuditStream(ctx); err == nil {
+				_, err = s.js.Publish(ctx, subject, b)
+			}
+		}
+	}
+	if err != nil {
+		s.log.WarnContext(ctx, "audit event not recorded", "op", string(kind), "task", t.ID, "err", err)
+	}
+}
+
+// auditUpdate records the events an update from before to after implies.
+func (s *Store) auditUpdate(ctx context.Context, before, after Task) {
+	for _, ev := range updateEvents(before, after) {
+		s.audit(ctx, ev.kind, after, ev.detail)
+	}
+}
+
+type updateEvent struct {
+	kind   ActivityKind
+	detail string
+}
+
+// updateEvents classifies a change: closes, reopens and reassignments get
+// their own kinds, and any other change (or none) is an update.
+func updateEvents(before, after Task) []updateEvent {
+	var out []updateEvent
+	if before.ReviewRequestedBy == "" && after.ReviewRequestedBy != "" {
+		out = append(out, updateEvent{kind: ActivityReviewRequested})
+	}
+	if before.Done != after.Done {
+		kind := ActivityReopened
+		if after.Done {
+			kind = ActivityClosed
+		}
+		out = append(out, updateEvent{kind: kind})
+	}
+	if before.Assignee != after.Assignee {
+		out = append(out, updateEvent{kind: ActivityAssigned, detail: assignDetail(before.Assignee, after.Assignee)})
+	}
+	if len(out) == 0 || before.Text != after.Text || before.Description != after.Description || before.Priority != after.Priority || before.Private != after.Private || before.Recur != after.Recur || !equalStrings(before.Tags, after.Tags) || !equalStrings(before.Contexts, after.Contexts) || !equalStrings(before.DependsOn, after.DependsOn) || !equalTimes(before.Due, after.Due) || !equalTimes(before.Scheduled, after.Scheduled) || !equalTimes(before.SnoozedUntil, after.SnoozedUntil) {
+		out = append(out, updateEvent{kind: ActivityUpdated})
+	}
+	return out
+}
+
+// assignDetail describes a reassignment, using "-" for nobody.
+func assignDetail(from, to string) string {
+	if from == "" {
+		from = "-"
+	}
+	if to == "" {
+		to = "-"
+	}
+	return from + " -> " + to
+}
+
+func equalTimes(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// AuditFilter selects events from the audit trail. Zero values match
+// everything.
+type AuditFilter struct {
+	Since time.Time
+	// User keeps events whose author matches, compared like assignees.
+	User string
+	// Task keeps the events of one task id.
+	Task string
+	// Limit keeps only the most recent events (0 = all).
+	Limit int
+}
+
+// Audit returns audit events in chronological order. Profiles that have not
+// recorded any events yet return none.
+func (s *Store) Audit(ctx context.Context, f AuditFilter) ([]Activity, error) {
+	out := []Activity{}
+	keep := func(b []byte) {
+		var a Activity
+		if json.Unmarshal(b, &a) != nil || f.Task != "" && a.Task.ID != f.Task {
+			return
+		}
+		if s.openTask(&a.Task) != nil {
+			return
+		}
+		detail, err := s.opts.Key.open(a.Detail)
+		if err != nil {
+			return
+		}
+		a.Detail = detail
+		if !s.canSee(ctx, a.Task) || f.User != "" && !matchAssignee(a.By, f.User) {
+			return
+		}
+		out = append(out, a)
+	}
+	var err error
+	if s.db != nil {
+		err = s.auditMessagesSQLite(ctx, f.Since, keep)
+	} else {
+		err = s.auditMessages(ctx, f.Since, keep)
+	}
+	if err != nil {
+		return out, err
+	}
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[len(out)-f.Limit:]
+	}
+	return out, nil
+}
+
+// auditMessages passes fn each audit stream message from since on.
+func (s *Store) auditMessages(ctx context.Context, since time.Time, fn func([]byte)) error {
+	name, _ := auditNames(s.ns)
+	stream, err := s.js.Stream(ctx, name)
+	if errors.Is(err, jetstream.ErrStreamNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("audit stream: %w", err)
+	}
+	cfg := jetstream.OrderedConsumerConfig{}
+	if !since.IsZero() {
+		cfg.DeliverPolicy = jetstream.DeliverByStartTimePolicy
+		cfg.OptStartTime = &since
+	}
+	cons, err := stream.OrderedConsumer(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("audit stream: %w", err)
+	}
+	info, err := cons.Info(ctx)
+	if err != nil {
+		return fmt.Errorf("audit stream: %w", err)
+	}
+	pending := info.NumPending
+	if pending == 0 {
+		return nil
+	}
+	msgs, err := cons.Messages()
+	if err != nil {
+		return fmt.Errorf("audit stream: %w", err)
+	}
+	defer msgs.Stop()
+	stop := context.AfterFunc(ctx, msgs.Stop)
+	defer stop()
+	for ; pending > 0; pending-- {
+		msg, err := msgs.Next()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		fn(msg.Data())
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package utask
+
+import "testing"
+
+func TestUpdateEvents(t *testing.T) {
+	base := Task{ID: "a", Text: "x", Tags: []string{"t"}}
+	kinds := func(evs []updateEvent) string {
+		out := ""
+		for _, ev := range evs {
+			out += string(ev.kind) + ";"
+		}
+		return out
+	}
+	closed := base
+	closed.Done = true
+	if got := kinds(updateEvents(base, closed)); got != "closed;" {
+		t.Fatalf("close: %s", got)
+	}
+	assigned := base
+	assigned.Assignee = "ada"
+	assigned.Text = "y"
+	evs := updateEvents(base, assigned)
+	if got := kinds(evs); got != "assigned;updated;" || evs[0].detail != "- -> ada" {
+		t.Fatalf("reassign and edit: %s %+v", got, evs)
+	}
+	if got := kinds(updateEvents(base, base)); got != "updated;" {
+		t.Fatalf("no-op update: %s", got)
+	}
+}
@@ -0,0 +1,142 @@
+package utask
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ExportJSONL writes every task to w, one JSON object per line (see
+// WriteJSONL), and returns how many tasks it wrote. With revisions, each
+// task's revisions that are still kept (see History) are written instead,
+// oldest first and its current one last, so ImportJSONL can recreate its
+// history.
+func (s *Store) ExportJSONL(ctx context.Context, w io.Writer, revisions bool) (int, error) {
+	n := 0
+	err := s.ForEach(ctx, func(t Task) error {
+		n++
+		if !revisions {
+			return WriteJSONL(w, t)
+		}
+		revs, err := s.History(ctx, t.ID)
+		if err != nil {
+			return fmt.Errorf("history of %s: %w", t.ID, err)
+		}
+		for _, r := range revs {
+			if r.Deleted || r.Revision == t.Revision {
+				continue
+			}
+			if err := WriteJSONL(w, r.Task); err != nil {
+				return err
+			}
+		}
+		return WriteJSONL(w, t)
+	})
+	return n, err
+}
+
+// ImportResult counts the tasks ImportJSONL created, changed and found
+// already up to date.
+type ImportResult struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+}
+
+// ImportJSONL upserts the tasks read from r (see ReadJSONL) by id. Lines
+// for the same task in a row are its revisions, oldest first, as
+// ExportJSONL writes them: each is written in turn, except those the
+// stored task already went through, so importing a file again changes
+// nothing. The tag index is rebuilt once at the end instead of being
+// updated per task. written, if set, sees every task written.
+func (s *Store) ImportJSONL(ctx context.Context, r io.Reader, written func(Task)) (ImportResult, error) {
+	var (
+		res ImportResult
+		run []Task
+	)
+	flush := func() error {
+		if len(run) == 0 {
+			return nil
+		}
+		err := s.importRevisions(ctx, run, &res, written)
+		run = run[:0]
+		return err
+	}
+	err := ReadJSONL(r, func(line int, t Task) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if len(run) > 0 && run[0].ID != t.ID {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		run = append(run, t)
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if res.Created+res.Updated > 0 {
+		if ierr := s.rebuildIndex(ctx); ierr != nil {
+			s.log.WarnContext(ctx, "tag index rebuild failed", "op", "import", "err", ierr)
+			err = errors.Join(err, indexError(ierr))
+		}
+	}
+	return res, err
+}
+
+// importRevisions writes the revisions of one task that come after the
+// last of them matching the stored task, or all of them if none does.
+func (s *Store) importRevisions(ctx context.Context, revs []Task, res *ImportResult, written func(Task)) error {
+	id := revs[0].ID
+	cur, _, err := s.getTask(ctx, id)
+	switch {
+	case errors.Is(err, ErrNotFound):
+	case err != nil:
+		return fmt.Errorf("task %s: %w", id, err)
+	default:
+		for i := len(revs) - 1; i >= 0; i-- {
+			if sameTask(cur, revs[i]) {
+				revs = revs[i+1:]
+				break
+			}
+		}
+		if len(revs) == 0 {
+			res.Unchanged++
+			return nil
+		}
+	}
+	created := false
+	for _, t := range revs {
+		isNew, err := s.putTask(ctx, t, false)
+		if err != nil {
+			return fmt.Errorf("task %s: %w", id, err)
+		}
+		created = created || isNew
+		if written != nil {
+			written(t)
+		}
+	}
+	if created {
+		res.Created++
+	} else {
+		res.Updated++
+	}
+	return nil
+}
+
+// sameTask reports whether a and b hold the same task, ignoring what
+// differs between profiles: the revision and the task number.
+func sameTask(a, b Task) bool {
+	enc := func(t Task) []byte {
+		t.Revision, t.Number, t.Schema = 0, 0, SchemaVersion
+		t.Tags = normalizeTags(t.Tags)
+		b, _ := json.Marshal(t)
+		return b
+	}
+	return bytes.Equal(enc(a), enc(b))
+}
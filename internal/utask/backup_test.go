@@ -0,0 +1,71 @@
+package utask
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExportImportJSONL(t *testing.T) {
+	ctx := context.Background()
+	src := openTestSQLite(t)
+	task, _, err := src.CreateTask(ctx, TaskInput{Text: "move me", Tags: []string{"ops"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := "move me\n\nto the new cluster"
+	if _, err := src.UpdateTask(ctx, task.ID, UpdateSet{Text: &text}); err != nil {
+		t.Fatal(err)
+	}
+	tags := []string{"ops", "infra"}
+	if _, err := src.UpdateTask(ctx, task.ID, UpdateSet{Tags: &tags}); err != nil {
+		t.Fatal(err)
+	}
+	export := func(revisions bool) *bytes.Buffer {
+		t.Helper()
+		var b bytes.Buffer
+		if n, err := src.ExportJSONL(ctx, &b, revisions); err != nil || n != 1 {
+			t.Fatalf("ExportJSONL = %d, %v", n, err)
+		}
+		return &b
+	}
+	if got := strings.Count(export(false).String(), "\n"); got != 1 {
+		t.Fatalf("plain export has %d lines", got)
+	}
+	full := export(true)
+	if got := strings.Count(full.String(), "\n"); got != 3 {
+		t.Fatalf("export with revisions has %d lines:\n%s", got, full)
+	}
+
+	dst := openTestSQLite(t)
+	var written int
+	res, err := dst.ImportJSONL(ctx, bytes.NewReader(full.Bytes()), func(Task) { written++ })
+	if err != nil || res != (ImportResult{Created: 1}) || written != 3 {
+		t.Fatalf("ImportJSONL = %+v, %v (%d written)", res, err, written)
+	}
+	want, _, _ := src.GetTask(ctx, task.ID)
+	if got, _, err := dst.GetTask(ctx, task.ID); err != nil || !sameTask(got, want) {
+		t.Fatalf("imported task = %+v, %v", got, err)
+	}
+	if revs, err := dst.History(ctx, task.ID); err != nil || len(revs) != 3 {
+		t.Fatalf("imported history = %d revisions, %v", len(revs), err)
+	}
+	if got := tagIDs(t, dst, "infra"); len(got) != 1 || got[0] != task.ID {
+		t.Fatalf("infra index = %v", got)
+	}
+
+	// Importing again changes nothing; a later export only adds what's new.
+	if res, err := dst.ImportJSONL(ctx, bytes.NewReader(full.Bytes()), nil); err != nil || res != (ImportResult{Unchanged: 1}) {
+		t.Fatalf("second ImportJSONL = %+v, %v", res, err)
+	}
+	if _, _, err := src.CloseTask(ctx, task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := dst.ImportJSONL(ctx, export(true), nil); err != nil || res != (ImportResult{Updated: 1}) {
+		t.Fatalf("ImportJSONL after close = %+v, %v", res, err)
+	}
+	if revs, err := dst.History(ctx, task.ID); err != nil || len(revs) != 4 || !revs[3].Task.Done {
+		t.Fatalf("history after close = %+v, %v", revs, err)
+	}
+}
@@ -0,0 +1,161 @@
+package utask
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+// synthProfile generates n deterministic tasks spread over m tags. About a
+// third carry a body and trailers so parsing benchmarks see realistic text.
+func synthProfile(n, m int) []Task {
+	r := rand.New(rand.NewSource(int64(n*31 + m)))
+	tasks := make([]Task, 0, n)
+	for i := 0; i < n; i++ {
+		text := fmt.Sprintf("Task %d: follow up on item %d", i, r.Intn(1000))
+		if i%3 == 0 {
+			text += "\n\nSome longer notes describing the work.\nAcross a couple of lines.\n\nDue: 2025-09-01\nRef: #" + fmt.Sprint(i)
+		}
+		tags := make([]string, 0, 3)
+		for j, k := 0, 1+r.Intn(3); j < k; j++ {
+			tags = append(tags, fmt.Sprintf("tag%d", r.Intn(m)))
+		}
+		t := Task{Text: text, Tags: normalizeTags(tags), Done: i%4 == 0, Priority: 1 + r.Intn(4), Created: "2025-01-01T00:00:00Z"}
+		t.ID = ContentID(t)
+		tasks = append(tasks, t)
+	}
+	return tasks
+}
+
+func synthSnapshot(n, m int) *Snapshot {
+	snap := &Snapshot{Profile: "bench", Revision: 1, Tasks: map[string]Task{}}
+	for _, t := range synthProfile(n, m) {
+		snap.Tasks[t.ID] = t
+	}
+	return snap
+}
+
+func BenchmarkNormalizeInput(b *testing.B) {
+	in := TaskInput{Text: "  Buy   milk  \n\nfrom the  corner shop", Tags: []string{"Errand", "home", "errand"}, Priority: 2}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = NormalizeInput(in)
+	}
+}
+
+func BenchmarkTaskParse(b *testing.B) {
+	tasks := synthProfile(1000, 20)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		t := tasks[i%len(tasks)]
+		_ = t.Short()
+		_ = t.Details()
+		_ = t.Trailers()
+	}
+}
+
+func BenchmarkSnapshotQuery(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		snap := synthSnapshot(n, 50)
+		b.Run(fmt.Sprintf("tasks=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = snap.Query("", []string{"tag1", "tag2"}, nil, StatusOpen)
+			}
+		})
+	}
+}
+
+func BenchmarkPlanTagSets(b *testing.B) {
+	acc := map[string][]string{}
+	for _, t := range synthProfile(10000, 50) {
+		indexTask(acc, t)
+	}
+	read := func(tag string) (map[string]struct{}, error) {
+		out := make(map[string]struct{}, len(acc[tag]))
+		for _, id := range acc[tag] {
+			out[id] = struct{}{}
+		}
+		return out, nil
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := planTagSets([]string{"tag3"}, []string{"tag1", "tag2"}, read); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkIndexTasks(b *testing.B) {
+	tasks := synthProfile(10000, 50)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		acc := map[string][]string{}
+		for _, t := range tasks {
+			indexTask(acc, t)
+		}
+	}
+}
+
+// benchStore opens a scratch profile on the server named by UTASK_BENCH_NATS,
+// seeding it with n synthetic tasks on first use. Store benchmarks are
+// skipped when the variable is unset.
+func benchStore(b *testing.B, n int) *Store {
+	url := os.Getenv("UTASK_BENCH_NATS")
+	if url == "" {
+		b.Skip("set UTASK_BENCH_NATS to run store benchmarks")
+	}
+	ctx := context.Background()
+	s, err := Open(ctx, url, fmt.Sprintf("bench%d", n))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(s.Close)
+	if keys, _ := s.tasksKV.Keys(ctx); len(keys) >= n {
+		return s
+	}
+	for _, t := range synthProfile(n, 50) {
+		if _, err := s.PutTask(ctx, t); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return s
+}
+
+func BenchmarkStoreList(b *testing.B) {
+	s := benchStore(b, 5000)
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.List(ctx, ListFilter{Status: StatusOpen}, ""); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStoreQuery(b *testing.B) {
+	s := benchStore(b, 5000)
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.Query(ctx, nil, []string{"tag1", "tag2"}, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStoreRebuildIndex(b *testing.B) {
+	s := benchStore(b, 5000)
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := s.RebuildIndex(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
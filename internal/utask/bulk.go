@@ -0,0 +1,197 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// BulkResult reports a bulk operation: how many tasks it changed, how many
+// were already as asked, and the ones it could not change. One failed task
+// doesn't stop the others.
+type BulkResult struct {
+	Changed   int           `json:"changed"`
+	Unchanged int           `json:"unchanged"`
+	Failed    []BulkFailure `json:"failed,omitempty"`
+}
+
+// BulkFailure is a task a bulk operation could not change, and why.
+type BulkFailure struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+func (r *BulkResult) fail(id string, err error) {
+	r.Failed = append(r.Failed, BulkFailure{ID: id, Error: err.Error()})
+}
+
+// CloseTasks closes each of ids, like CloseTask.
+func (s *Store) CloseTasks(ctx context.Context, ids []string) (BulkResult, error) {
+	if err := s.authorize(ctx, "set done", RoleContributor); err != nil {
+		return BulkResult{}, err
+	}
+	done := true
+	return s.updateTasks(ctx, "close", ids, func(t Task) (UpdateSet, bool) {
+		if t.Done || t.ReviewRequestedBy != "" {
+			return UpdateSet{}, false
+		}
+		return UpdateSet{Done: &done}, true
+	})
+}
+
+// TagTasks adds and removes tags on each of ids. A tag in both lists ends
+// up added.
+func (s *Store) TagTasks(ctx context.Context, ids []string, add, remove []string) (BulkResult, error) {
+	if err := s.authorize(ctx, "update", RoleContributor); err != nil {
+		return BulkResult{}, err
+	}
+	add, remove = normalizeTags(add), normalizeTags(remove)
+	if len(add) == 0 && len(remove) == 0 {
+		return BulkResult{}, invalidf("no tags to add or remove")
+	}
+	if err := s.opts.Limits.validateTags(add); err != nil {
+		return BulkResult{}, err
+	}
+	return s.updateTasks(ctx, "tag", ids, func(t Task) (UpdateSet, bool) {
+		tags, ok := retag(t.Tags, add, remove)
+		if !ok {
+			return UpdateSet{}, false
+		}
+		return UpdateSet{Tags: &tags}, true
+	})
+}
+
+// retag returns tags with remove dropped and add appended, and whether
+// that changed them. add and remove must be normalized.
+func retag(tags, add, remove []string) ([]string, bool) {
+	out := slices.DeleteFunc(slices.Clone(tags), func(tag string) bool {
+		return slices.Contains(remove, tag)
+	})
+	for _, tag := range add {
+		if !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	return out, !slices.Equal(out, tags)
+}
+
+// updateTasks applies the update edit returns to each task it reports a
+// change for, retrying lost races per task. Tag index changes are collected
+// and written once per tag at the end rather than once per task.
+func (s *Store) updateTasks(ctx context.Context, op string, ids []string, edit func(Task) (UpdateSet, bool)) (BulkResult, error) {
+	var (
+		res    BulkResult
+		tags   tagChanges
+		status statusChanges
+		undo   []UndoChange
+	)
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		var (
+			before, after Task
+			changed       bool
+		)
+		err := retryCAS(ctx, op+" "+id, func() error {
+			var (
+				rev uint64
+				err error
+			)
+			before, rev, err = s.GetTask(ctx, id)
+			if err != nil {
+				return err
+			}
+			set, ok := edit(before)
+			if changed = ok; !ok {
+				return nil
+			}
+			if after, err = s.applyUpdate(ctx, before, set); err != nil {
+				return err
+			}
+			after.Revision, err = s.putTaskCAS(ctx, id, after, rev)
+			return err
+		})
+		switch {
+		case err != nil:
+			res.fail(id, err)
+			continue
+		case !changed:
+			res.Unchanged++
+			continue
+		}
+		res.Changed++
+		s.logWrite(ctx, op, id, after.Revision)
+		s.auditUpdate(ctx, before, after)
+		if after.Done && !before.Done {
+			s.dropClaim(ctx, id)
+			s.recur(ctx, after)
+		}
+		tags.note(id, before.Tags, after.Tags)
+		status.note(id, &before, &after)
+		undo = append(undo, undoChange(&before, &after))
+	}
+	s.recordUndo(ctx, op, undo...)
+	return res, s.bulkReindex(ctx, op, tags, status)
+}
+
+// DeleteTasks moves each of ids to the trash, like DeleteTask.
+func (s *Store) DeleteTasks(ctx context.Context, ids []string) (BulkResult, error) {
+	if err := s.authorize(ctx, "delete", RoleAdmin); err != nil {
+		return BulkResult{}, err
+	}
+	var (
+		res    BulkResult
+		tags   tagChanges
+		status statusChanges
+		undo   []UndoChange
+	)
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		var t Task
+		err := retryCAS(ctx, "delete "+id, func() error {
+			var (
+				rev uint64
+				err error
+			)
+			if t, rev, err = s.GetTask(ctx, id); err != nil {
+				return err
+			}
+			if err := s.trash(ctx, t); err != nil {
+				return err
+			}
+			if err := deleteIf(ctx, s.tasksKV, s.taskKey(id), rev); err != nil {
+				s.untrash(ctx, id)
+				return casError("delete task "+id, err)
+			}
+			return nil
+		})
+		if err != nil {
+			res.fail(id, err)
+			continue
+		}
+		res.Changed++
+		s.logWrite(ctx, "delete", id, 0)
+		t.Revision = 0
+		s.audit(ctx, ActivityDeleted, t, "")
+		s.dropClaim(ctx, id)
+		s.dropNumber(ctx, t)
+		s.reindexTrailers(ctx, id, nil)
+		tags.note(id, t.Tags, nil)
+		status.note(id, &t, nil)
+		undo = append(undo, undoChange(&t, nil))
+	}
+	s.recordUndo(ctx, "delete", undo...)
+	return res, s.bulkReindex(ctx, "delete", tags, status)
+}
+
+// bulkReindex writes a bulk operation's tag and status index changes.
+func (s *Store) bulkReindex(ctx context.Context, op string, tags tagChanges, status statusChanges) error {
+	if err := errors.Join(s.applyTagChanges(ctx, tags), s.applyStatusChanges(ctx, status)); err != nil {
+		s.log.WarnContext(ctx, "index update failed", "op", op, "err", err)
+		return indexError(err)
+	}
+	return nil
+}
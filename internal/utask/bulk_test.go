@@ -0,0 +1,113 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// countingKV counts the writes made through it, per key.
+type countingKV struct {
+	jetstream.KeyValue
+	writes map[string]int
+}
+
+func (kv *countingKV) Create(ctx context.Context, key string, value []byte, opts ...jetstream.KVCreateOpt) (uint64, error) {
+	kv.writes[key]++
+	return kv.KeyValue.Create(ctx, key, value, opts...)
+}
+
+func (kv *countingKV) Update(ctx context.Context, key string, value []byte, rev uint64) (uint64, error) {
+	kv.writes[key]++
+	return kv.KeyValue.Update(ctx, key, value, rev)
+}
+
+func (kv *countingKV) DeleteIf(ctx context.Context, key string, rev uint64) error {
+	return deleteIf(ctx, kv.KeyValue, key, rev)
+}
+
+func tagIDs(t *testing.T, s *Store, tag string) []string {
+	t.Helper()
+	set, err := s.readTagIDs(context.Background(), tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestBulk(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	var ids []string
+	for _, text := range []string{"one", "two", "three"} {
+		task, _, err := s.CreateTask(ctx, TaskInput{Text: text, Tags: []string{"sprint", "junk"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, task.ID)
+	}
+	sort.Strings(ids)
+	if _, _, err := s.CloseTask(ctx, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each task's index entry is written once, never a shared value.
+	kv := &countingKV{KeyValue: s.tagsKV, writes: map[string]int{}}
+	s.tagsKV = kv
+	res, err := s.TagTasks(ctx, ids, []string{"Backlog"}, []string{"junk"})
+	if err != nil || res.Changed != 3 || res.Unchanged != 0 || len(res.Failed) != 0 {
+		t.Fatalf("TagTasks = %+v, %v", res, err)
+	}
+	for _, id := range ids {
+		if kv.writes[tagEntryKey("backlog", id)] != 1 {
+			t.Fatalf("tag index writes = %v", kv.writes)
+		}
+	}
+	if len(kv.writes) != len(ids) {
+		t.Fatalf("tag index writes = %v", kv.writes)
+	}
+	if got := tagIDs(t, s, "backlog"); !reflect.DeepEqual(got, ids) {
+		t.Fatalf("backlog index = %v", got)
+	}
+	if got := tagIDs(t, s, "junk"); len(got) != 0 {
+		t.Fatalf("junk index = %v", got)
+	}
+	if res, err := s.TagTasks(ctx, ids, []string{"backlog"}, nil); err != nil || res.Unchanged != 3 {
+		t.Fatalf("TagTasks again = %+v, %v", res, err)
+	}
+	if _, err := s.TagTasks(ctx, ids, nil, []string{" "}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("TagTasks with no tags err = %v", err)
+	}
+
+	missing := ids[2][:127] + "0"
+	res, err = s.CloseTasks(ctx, append(ids, missing))
+	if err != nil || res.Changed != 2 || res.Unchanged != 1 || len(res.Failed) != 1 || res.Failed[0].ID != missing {
+		t.Fatalf("CloseTasks = %+v, %v", res, err)
+	}
+	for _, id := range ids {
+		if got, _, err := s.GetTask(ctx, id); err != nil || !got.Done {
+			t.Fatalf("after CloseTasks: %+v, %v", got, err)
+		}
+	}
+
+	res, err = s.DeleteTasks(ctx, ids[:2])
+	if err != nil || res.Changed != 2 {
+		t.Fatalf("DeleteTasks = %+v, %v", res, err)
+	}
+	if got := tagIDs(t, s, "sprint"); !reflect.DeepEqual(got, ids[2:]) {
+		t.Fatalf("sprint index after delete = %v", got)
+	}
+	counts, err := s.ListTags(ctx)
+	if err != nil || counts["sprint"] != 1 || counts["backlog"] != 1 || counts["junk"] != 0 {
+		t.Fatalf("tag counts = %v, %v", counts, err)
+	}
+}
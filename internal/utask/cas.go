@@ -0,0 +1,58 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// CAS retry tuning for tag index writes. Backoff doubles from casBaseDelay
+// up to casMaxDelay, and each sleep is jittered so racing writers spread
+// out instead of colliding again in lockstep.
+const (
+	maxCASRetries = 8
+	casBaseDelay  = 2 * time.Millisecond
+	casMaxDelay   = 250 * time.Millisecond
+)
+
+// casBackoff returns the sleep before retry attempt (0-based), drawn
+// uniformly from [d/2, d) where d is the capped exponential delay.
+func casBackoff(attempt int) time.Duration {
+	d := casMaxDelay
+	if attempt < 16 {
+		d = min(casBaseDelay<<attempt, casMaxDelay)
+	}
+	return d/2 + rand.N(d/2)
+}
+
+// retryCAS runs fn until it succeeds, fails with an error other than
+// ErrConflict, or maxCASRetries attempts have all conflicted, in which case
+// the returned error wraps ErrConflict.
+func retryCAS(ctx context.Context, what string, fn func() error) error {
+	var err error
+	for attempt := 0; attempt < maxCASRetries; attempt++ {
+		if err = fn(); !errors.Is(err, ErrConflict) {
+			return err
+		}
+		t := time.NewTimer(casBackoff(attempt))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	return fmt.Errorf("%s: gave up after %d attempts: %w", what, maxCASRetries, err)
+}
+
+// retryTaskCAS runs a read-modify-write of a task with retryCAS, unless the
+// caller pinned the revision it expects (ifRev != 0): losing the race is
+// then the answer, so fn runs once and its ErrConflict is returned.
+func retryTaskCAS(ctx context.Context, what string, ifRev uint64, fn func() error) error {
+	if ifRev != 0 {
+		return fn()
+	}
+	return retryCAS(ctx, what, fn)
+}
@@ -0,0 +1,154 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func TestCASBackoffBounds(t *testing.T) {
+	for attempt := 0; attempt < 40; attempt++ {
+		d := casBackoff(attempt)
+		want := min(casBaseDelay<<min(attempt, 16), casMaxDelay)
+		if d < want/2 || d >= want {
+			t.Fatalf("attempt %d: backoff %v outside [%v, %v)", attempt, d, want/2, want)
+		}
+	}
+}
+
+func TestRetryCAS(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	err := retryCAS(ctx, "x", func() error {
+		if calls++; calls < 3 {
+			return ErrConflict
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("got err=%v after %d calls, want success on 3rd", err, calls)
+	}
+
+	calls = 0
+	err = retryCAS(ctx, "x", func() error { calls++; return ErrConflict })
+	if !errors.Is(err, ErrConflict) || calls != maxCASRetries {
+		t.Fatalf("got err=%v after %d calls, want ErrConflict after %d", err, calls, maxCASRetries)
+	}
+
+	boom := errors.New("boom")
+	if err := retryCAS(ctx, "x", func() error { return boom }); err != boom {
+		t.Fatalf("non-conflict error not returned as is: %v", err)
+	}
+
+	cctx, cancel := context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	err = retryCAS(cctx, "x", func() error { time.Sleep(time.Millisecond); return ErrConflict })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want context error, got %v", err)
+	}
+}
+
+func TestRetryTaskCAS(t *testing.T) {
+	calls := 0
+	err := retryTaskCAS(context.Background(), "x", 7, func() error { calls++; return ErrConflict })
+	if !errors.Is(err, ErrConflict) || calls != 1 {
+		t.Fatalf("pinned revision: err=%v after %d calls, want ErrConflict after 1", err, calls)
+	}
+}
+
+// racingKV lets another writer in just before the first write through it.
+type racingKV struct {
+	jetstream.KeyValue
+	race func()
+}
+
+func (kv *racingKV) before() {
+	if race := kv.race; race != nil {
+		kv.race = nil
+		race()
+	}
+}
+
+func (kv *racingKV) Put(ctx context.Context, key string, value []byte) (uint64, error) {
+	kv.before()
+	return kv.KeyValue.Put(ctx, key, value)
+}
+
+func (kv *racingKV) Update(ctx context.Context, key string, value []byte, rev uint64) (uint64, error) {
+	kv.before()
+	return kv.KeyValue.Update(ctx, key, value, rev)
+}
+
+// TestUpdateTaskRace checks that an update racing another is re-applied
+// on top of it instead of clobbering it, unless a revision is pinned.
+func TestUpdateTaskRace(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	task, _, err := s.CreateTask(ctx, TaskInput{Text: "race"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kv := &racingKV{KeyValue: s.tasksKV}
+	s.tasksKV = kv
+	race := func() {
+		p := 3
+		if _, err := s.UpdateTask(ctx, task.ID, UpdateSet{Priority: &p}); err != nil {
+			t.Error(err)
+		}
+	}
+
+	kv.race = race
+	who := "ada"
+	if _, err := s.UpdateTask(ctx, task.ID, UpdateSet{Assignee: &who}); err != nil {
+		t.Fatal(err)
+	}
+	got, rev, err := s.GetTask(ctx, task.ID)
+	if err != nil || got.Priority != 3 || got.Assignee != "ada" {
+		t.Fatalf("lost an update: %+v, %v", got, err)
+	}
+
+	kv.race = race
+	if _, _, err := s.CloseTaskIf(ctx, task.ID, rev); !errors.Is(err, ErrConflict) {
+		t.Fatalf("CloseTaskIf racing a write: %v", err)
+	}
+	kv.race = race
+	if _, _, err := s.CloseTask(ctx, task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, err := s.GetTask(ctx, task.ID); err != nil || !got.Done || got.Assignee != "ada" {
+		t.Fatalf("after close: %+v, %v", got, err)
+	}
+}
+
+func TestSetStatusRace(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	task, _, err := s.CreateTask(ctx, TaskInput{Text: "race"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kv := &racingKV{KeyValue: s.tasksKV}
+	s.tasksKV = kv
+	race := func() {
+		who := "ada"
+		if _, err := s.UpdateTask(ctx, task.ID, UpdateSet{Assignee: &who}); err != nil {
+			t.Error(err)
+		}
+	}
+
+	kv.race = race
+	if _, _, err := s.SetStatus(ctx, task.ID, StatusInProgress, 0); err != nil {
+		t.Fatal(err)
+	}
+	got, rev, err := s.GetTask(ctx, task.ID)
+	if err != nil || got.State() != StatusInProgress || got.Assignee != "ada" {
+		t.Fatalf("after a racing write: %+v, %v", got, err)
+	}
+	kv.race = race
+	if _, _, err := s.SetStatus(ctx, task.ID, StatusBlocked, rev); !errors.Is(err, ErrConflict) {
+		t.Fatalf("SetStatus pinned to a raced revision: %v", err)
+	}
+}
@@ -0,0 +1,302 @@
+package utask
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Claims record who is working on a task right now, stored in the meta
+// bucket as one key per task. A claim is a lease: it lapses at Expires
+// unless renewed, so a crashed worker doesn't hold a task forever.
+const claimKeyPrefix = "claim."
+
+// DefaultLease is how long a claim lasts when ClaimTask gets no lease.
+const DefaultLease = 2 * time.Hour
+
+// Claim is an active lease on a task. Since is when the holder started,
+// which doubles as the start of their time-tracking session.
+type Claim struct {
+	Task string `json:"task"`
+	By   string `json:"by"`
+	// Worker is the worker of By holding the claim (see WithWorker), if
+	// any.
+	Worker  string    `json:"worker,omitempty"`
+	Since   time.Time `json:"since"`
+	Expires time.Time `json:"expires"`
+}
+
+type workerKey struct{}
+
+// WithWorker returns ctx whose claims are taken, renewed and released for
+// worker, so one identity can run several queue workers that each hold
+// their own claims. Without it claims belong to the identity alone.
+func WithWorker(ctx context.Context, worker string) context.Context {
+	return context.WithValue(ctx, workerKey{}, worker)
+}
+
+func workerOf(ctx context.Context) string {
+	w, _ := ctx.Value(workerKey{}).(string)
+	return w
+}
+
+// heldBy reports whether the claim belongs to who's worker.
+func (c Claim) heldBy(who, worker string) bool {
+	return (SameIdentity(c.By, who) || c.By == who) && c.Worker == worker
+}
+
+// Holder names who holds the claim: By, and the worker when there is one.
+func (c Claim) Holder() string {
+	if c.Worker == "" {
+		return c.By
+	}
+	return fmt.Sprintf("%s (worker %s)", c.By, c.Worker)
+}
+
+// Active reports whether the lease is still held at now.
+func (c Claim) Active(now time.Time) bool { return now.Before(c.Expires) }
+
+// Elapsed is how long the holder has been working at now.
+func (c Claim) Elapsed(now time.Time) time.Duration { return now.Sub(c.Since) }
+
+// ClaimTask starts or renews the caller's claim on a task for lease (0 =
+// DefaultLease). Renewing keeps the original Since. It fails with
+// ErrConflict while someone else holds an active claim. An open or blocked
+// task moves to in-progress.
+func (s *Store) ClaimTask(ctx context.Context, id string, lease time.Duration) (Claim, error) {
+	if err := s.authorize(ctx, "claim", RoleContributor); err != nil {
+		return Claim{}, err
+	}
+	t, _, err := s.GetTask(ctx, id)
+	if err != nil {
+		return Claim{}, err
+	}
+	if lease <= 0 {
+		lease = DefaultLease
+	}
+	who, worker := s.identity(ctx), workerOf(ctx)
+	now := time.Now().UTC()
+	c := Claim{Task: id, By: who, Worker: worker, Since: now, Expires: now.Add(lease)}
+	key := claimKeyPrefix + id
+	e, err := s.metaKV.Get(ctx, key)
+	var rev uint64
+	switch {
+	case errors.Is(err, jetstream.ErrKeyNotFound):
+	case err != nil:
+		return Claim{}, err
+	default:
+		rev = e.Revision()
+		var cur Claim
+		if json.Unmarshal(e.Value(), &cur) == nil && cur.Active(now) {
+			if !cur.heldBy(who, worker) {
+				return Claim{}, fmt.Errorf("task %s is claimed by %s for another %s: %w", id, cur.Holder(), cur.Expires.Sub(now).Round(time.Minute), ErrConflict)
+			}
+			c.Since = cur.Since
+		}
+	}
+	b, _ := json.Marshal(c)
+	if rev == 0 {
+		_, err = s.metaKV.Create(ctx, key, b)
+	} else {
+		_, err = s.metaKV.Update(ctx, key, b, rev)
+	}
+	if err != nil {
+		return Claim{}, casError("claim task "+id, err)
+	}
+	s.logWrite(ctx, "claim", id, 0)
+	if st := t.State(); st == StatusOpen || st == StatusBlocked {
+		if _, _, err := s.SetStatus(ctx, id, StatusInProgress, 0); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+// ReleaseClaim ends the claim on a task and moves it from in-progress back
+// to open. Only its holder or an admin may release an active claim;
+// releasing an unclaimed task is a no-op.
+func (s *Store) ReleaseClaim(ctx context.Context, id string) error {
+	if err := s.authorize(ctx, "release", RoleContributor); err != nil {
+		return err
+	}
+	key := claimKeyPrefix + id
+	e, err := s.metaKV.Get(ctx, key)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var cur Claim
+	if json.Unmarshal(e.Value(), &cur) == nil && cur.Active(time.Now()) && s.Role(ctx) < RoleAdmin {
+		if !cur.heldBy(s.identity(ctx), workerOf(ctx)) {
+			return fmt.Errorf("release: %w: task %s is claimed by %s", ErrForbidden, id, cur.Holder())
+		}
+	}
+	if err := deleteIf(ctx, s.metaKV, key, e.Revision()); err != nil {
+		return casError("release claim "+id, err)
+	}
+	s.logWrite(ctx, "release", id, 0)
+	if t, _, err := s.GetTask(ctx, id); err == nil && t.State() == StatusInProgress {
+		if _, _, err := s.SetStatus(ctx, id, StatusOpen, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// heldClaim returns the claim on a task and whether the caller holds it.
+// A lapsed claim still counts, as it does for CompleteClaim.
+func (s *Store) heldClaim(ctx context.Context, id string) (Claim, bool, error) {
+	e, err := s.metaKV.Get(ctx, claimKeyPrefix+id)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return Claim{}, false, nil
+	}
+	if err != nil {
+		return Claim{}, false, err
+	}
+	var c Claim
+	if err := json.Unmarshal(e.Value(), &c); err != nil {
+		return Claim{}, false, fmt.Errorf("claim on %s: %w", id, err)
+	}
+	return c, c.heldBy(s.identity(ctx), workerOf(ctx)), nil
+}
+
+// ClaimNext claims the oldest open task matching f that nobody holds an
+// active claim on, as a queue worker would, and returns it in progress.
+// Blocked, snoozed and not-ready tasks are skipped. Workers racing for the
+// same task each end up with a different one: the claim is written with
+// compare-and-set, and the loser moves on to the next task. Lapsed claims
+// don't count, so a crashed worker's tasks come back once its lease runs
+// out. It fails with ErrNotFound when there is nothing to claim.
+func (s *Store) ClaimNext(ctx context.Context, f ListFilter, lease time.Duration) (Task, Claim, error) {
+	if err := s.authorize(ctx, "claim", RoleContributor); err != nil {
+		return Task{}, Claim{}, err
+	}
+	claims, err := s.Claims(ctx)
+	if err != nil {
+		return Task{}, Claim{}, err
+	}
+	taken := make(map[string]bool, len(claims))
+	for _, c := range claims {
+		taken[c.Task] = true
+	}
+	f.Status, f.Ready, f.HideSnoozed, f.Limit = StatusOpen, true, true, 0
+	it, err := s.ListIter(ctx, f)
+	if err != nil {
+		return Task{}, Claim{}, err
+	}
+	var queue []Task
+	for it.Next() {
+		if t := it.Task(); !taken[t.ID] && t.State() != StatusBlocked {
+			queue = append(queue, t)
+		}
+	}
+	it.Close()
+	if err := it.Err(); err != nil {
+		return Task{}, Claim{}, err
+	}
+	sort.Slice(queue, func(i, j int) bool {
+		if queue[i].Created != queue[j].Created {
+			return queue[i].Created < queue[j].Created
+		}
+		return queue[i].Number < queue[j].Number
+	})
+	for _, t := range queue {
+		c, err := s.ClaimTask(ctx, t.ID, lease)
+		if errors.Is(err, ErrConflict) || errors.Is(err, ErrNotFound) {
+			// Claimed or deleted since the listing.
+			continue
+		}
+		if err != nil {
+			return Task{}, Claim{}, err
+		}
+		if t, _, err = s.GetTask(ctx, t.ID); err != nil {
+			return Task{}, Claim{}, err
+		}
+		return t, c, nil
+	}
+	return Task{}, Claim{}, fmt.Errorf("no unclaimed open tasks: %w", ErrNotFound)
+}
+
+// CompleteClaim closes a task the caller claimed, which ends the claim. A
+// lapsed claim still counts until someone else claims the task. It fails
+// with ErrConflict when the caller holds no claim on the task, e.g.
+// because its lease ran out and another worker took over.
+func (s *Store) CompleteClaim(ctx context.Context, id string) (Task, error) {
+	if err := s.authorize(ctx, "complete", RoleContributor); err != nil {
+		return Task{}, err
+	}
+	e, err := s.metaKV.Get(ctx, claimKeyPrefix+id)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return Task{}, fmt.Errorf("complete: task %s is not claimed: %w", id, ErrConflict)
+	}
+	if err != nil {
+		return Task{}, err
+	}
+	var cur Claim
+	if err := json.Unmarshal(e.Value(), &cur); err != nil {
+		return Task{}, fmt.Errorf("claim on %s: %w", id, err)
+	}
+	if !cur.heldBy(s.identity(ctx), workerOf(ctx)) {
+		return Task{}, fmt.Errorf("complete: task %s is claimed by %s: %w", id, cur.Holder(), ErrConflict)
+	}
+	t, _, err := s.CloseTask(ctx, id)
+	return t, err
+}
+
+// Claims returns the active claims, longest running first.
+func (s *Store) Claims(ctx context.Context) ([]Claim, error) {
+	lister, err := s.metaKV.ListKeysFiltered(ctx, claimKeyPrefix+">")
+	if err != nil {
+		return nil, err
+	}
+	defer lister.Stop()
+	var all []Claim
+	for k := range lister.Keys() {
+		e, err := s.metaKV.Get(ctx, k)
+		if err != nil {
+			continue
+		}
+		var c Claim
+		if json.Unmarshal(e.Value(), &c) == nil {
+			all = append(all, c)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return activeClaims(all, time.Now()), nil
+}
+
+// activeClaims drops lapsed claims and orders the rest by Since.
+func activeClaims(all []Claim, now time.Time) []Claim {
+	out := []Claim{}
+	for _, c := range all {
+		if c.Active(now) {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Since.Equal(out[j].Since) {
+			return out[i].Since.Before(out[j].Since)
+		}
+		return out[i].Task < out[j].Task
+	})
+	return out
+}
+
+// dropClaim removes any claim on a task that has been closed or deleted.
+func (s *Store) dropClaim(ctx context.Context, id string) {
+	if _, err := s.metaKV.Get(ctx, claimKeyPrefix+id); err != nil {
+		return
+	}
+	if err := s.metaKV.Delete(ctx, claimKeyPrefix+id); err != nil {
+		s.log.WarnContext(ctx, "claim not released", "op", "release", "task", id, "err", err)
+	}
+}
@@ -0,0 +1,81 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestActiveClaims(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	claims := []Claim{
+		{Task: "b", Since: now.Add(-time.Hour), Expires: now.Add(time.Hour)},
+		{Task: "lapsed", Since: now.Add(-3 * time.Hour), Expires: now.Add(-time.Minute)},
+		{Task: "a", Since: now.Add(-2 * time.Hour), Expires: now.Add(time.Minute)},
+	}
+	got := activeClaims(claims, now)
+	if len(got) != 2 || got[0].Task != "a" || got[1].Task != "b" {
+		t.Fatalf("unexpected claims %+v", got)
+	}
+	if e := got[0].Elapsed(now); e != 2*time.Hour {
+		t.Fatalf("elapsed %v, want 2h", e)
+	}
+}
+
+func TestClaimNext(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	var ids []string
+	for _, text := range []string{"build a", "build b", "build c"} {
+		task, _, err := s.CreateTask(ctx, TaskInput{Text: text, Tags: []string{"build"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, task.ID)
+	}
+	if _, _, err := s.CreateTask(ctx, TaskInput{Text: "deploy", Tags: []string{"deploy"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.CloseTask(ctx, ids[2]); err != nil {
+		t.Fatal(err)
+	}
+	w1, w2 := WithWorker(ctx, "w1"), WithWorker(ctx, "w2")
+	f := ListFilter{Tag: "build"}
+
+	got, c, err := s.ClaimNext(w1, f, time.Hour)
+	if err != nil || got.ID != ids[0] || c.Worker != "w1" || got.State() != StatusInProgress {
+		t.Fatalf("first ClaimNext = %s %+v, %v", got.Text, c, err)
+	}
+	got, _, err = s.ClaimNext(w2, f, time.Millisecond)
+	if err != nil || got.ID != ids[1] {
+		t.Fatalf("second ClaimNext = %s, %v", got.Text, err)
+	}
+	if _, _, err := s.ClaimNext(w1, f, time.Hour); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("ClaimNext with every task claimed: %v, want ErrNotFound", err)
+	}
+
+	// w2's lease runs out, so its in-progress task goes to w1.
+	time.Sleep(5 * time.Millisecond)
+	if got, _, err = s.ClaimNext(w1, f, time.Hour); err != nil || got.ID != ids[1] {
+		t.Fatalf("ClaimNext after lapse = %s, %v", got.Text, err)
+	}
+	if _, err := s.CompleteClaim(w2, ids[1]); !errors.Is(err, ErrConflict) {
+		t.Fatalf("CompleteClaim by the lapsed worker: %v, want ErrConflict", err)
+	}
+	if err := s.ReleaseClaim(WithRole(w2, RoleContributor), ids[0]); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("ReleaseClaim of another worker's claim: %v, want ErrForbidden", err)
+	}
+	if done, err := s.CompleteClaim(w1, ids[1]); err != nil || !done.Done {
+		t.Fatalf("CompleteClaim = %+v, %v", done, err)
+	}
+	if _, err := s.CompleteClaim(w1, ids[1]); !errors.Is(err, ErrConflict) {
+		t.Fatalf("CompleteClaim of an unclaimed task: %v, want ErrConflict", err)
+	}
+	if err := s.ReleaseClaim(w1, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, err = s.ClaimNext(w2, f, time.Hour); err != nil || got.ID != ids[0] {
+		t.Fatalf("ClaimNext after release = %s, %v", got.Text, err)
+	}
+}
@@ -0,0 +1,66 @@
+package utask
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ClonedFromTrailer links a clone back to the task it was copied from.
+const ClonedFromTrailer = "Cloned-from"
+
+// historyTrailers record what happened to one task and aren't copied by
+// default: a clone hasn't been approved, delegated or scheduled yet.
+var historyTrailers = []string{ApprovedByTrailer, DelegatedToTrailer, ClonedFromTrailer, "Due"}
+
+// checkedItem matches a ticked Markdown checklist item.
+var checkedItem = regexp.MustCompile(`(?m)^(\s*[-*+] )\[[xX]\]`)
+
+// CloneInput builds a new task from t: its text (with title, when set,
+// replacing the first line) and details, checklist items unticked, the
+// trailers keep accepts (nil keeps all but approvals, delegations, earlier
+// clone links and due dates) and a Cloned-from trailer naming t. Tags, priority,
+// estimate, contexts and privacy are copied; the assignee is not.
+func CloneInput(t Task, title string, keep func(key string) bool) TaskInput {
+	return TaskInput{
+		Text:            appendTrailer(copyText(t, title, keep), ClonedFromTrailer, t.ID),
+		Details:         untick(t.Description),
+		Tags:            append([]string(nil), t.Tags...),
+		Priority:        t.Priority,
+		EstimateMinutes: t.EstimateMinutes,
+		Private:         t.Private,
+		Contexts:        append([]string(nil), t.Contexts...),
+	}
+}
+
+// copyText is the text of a copy of t, as described for CloneInput but
+// without the Cloned-from trailer.
+func copyText(t Task, title string, keep func(key string) bool) string {
+	if keep == nil {
+		keep = func(key string) bool {
+			for _, h := range historyTrailers {
+				if strings.EqualFold(key, h) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	if title = strings.TrimSpace(title); title == "" {
+		title = t.Short()
+	}
+	text := title
+	if d := t.textDetails(); d != "" {
+		text += "\n\n" + untick(d)
+	}
+	for _, tr := range t.Trailers() {
+		if keep(tr.Key) {
+			text = appendTrailer(text, tr.Key, tr.Value)
+		}
+	}
+	return text
+}
+
+// untick clears the ticks of a body's Markdown checklist items.
+func untick(body string) string {
+	return checkedItem.ReplaceAllString(body, "$1[ ]")
+}
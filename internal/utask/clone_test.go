@@ -0,0 +1,35 @@
+package utask
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCloneInput(t *testing.T) {
+	src := Task{
+		ID:       "abc123",
+		Text:     "Release 1.2\n\n- [x] tag\n- [ ] announce\n\nDue: 2026-03-01\nReviewed-by: bob\nApproved-by: carol",
+		Tags:     []string{"release", "sprint-11"},
+		Priority: 2, EstimateMinutes: 90, Assignee: "ada",
+	}
+	in := CloneInput(src, "", nil)
+	want := "Release 1.2\n\n- [ ] tag\n- [ ] announce\n\nReviewed-by: bob\nCloned-from: abc123"
+	if in.Text != want {
+		t.Fatalf("text:\n%s\nwant:\n%s", in.Text, want)
+	}
+	if in.Priority != 2 || in.EstimateMinutes != 90 || in.Assignee != "" || len(in.Tags) != 2 {
+		t.Fatalf("fields: %+v", in)
+	}
+	_, id := NormalizeInput(in)
+	if id == src.ID || id == ContentID(src) {
+		t.Fatal("clone must get its own id")
+	}
+
+	in = CloneInput(src, "Release 1.3", func(key string) bool { return key == "Due" })
+	if !strings.HasPrefix(in.Text, "Release 1.3\n") || !strings.HasSuffix(in.Text, "\n\nDue: 2026-03-01\nCloned-from: abc123") {
+		t.Fatalf("with title and kept Due:\n%s", in.Text)
+	}
+	if got := CloneInput(Task{ID: "x", Text: "just a title"}, "", nil).Text; got != "just a title\n\nCloned-from: x" {
+		t.Fatalf("plain: %q", got)
+	}
+}
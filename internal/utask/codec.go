@@ -0,0 +1,197 @@
+package utask
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Encoding selects how task values are written to the tasks bucket.
+// Reads always accept every encoding, so switching is safe at any time and
+// existing JSON values keep working.
+type Encoding string
+
+const (
+	EncodingJSON    Encoding = "json"
+	EncodingMsgpack Encoding = "msgpack"
+)
+
+// Compression selects the codec for task values above Options.CompressAbove.
+type Compression string
+
+const (
+	CompressionZstd Compression = "zstd"
+	CompressionGzip Compression = "gzip"
+)
+
+// Binary task values start with a schema-version byte. JSON values start
+// with '{' and carry no marker. Compressed values wrap an encoded value
+// (JSON or msgpack) behind their own marker.
+const (
+	valueMsgpackV1 byte = 0x01
+	valueZstd      byte = 0x02
+	valueGzip      byte = 0x03
+)
+
+// maxDecompressed bounds inflated values so a corrupt or hostile value can't
+// exhaust memory.
+const maxDecompressed = 64 << 20
+
+var (
+	zstdOnce sync.Once
+	zstdEnc  *zstd.Encoder
+	zstdDec  *zstd.Decoder
+)
+
+func zstdCodec() (*zstd.Encoder, *zstd.Decoder) {
+	zstdOnce.Do(func() {
+		zstdEnc, _ = zstd.NewWriter(nil)
+		zstdDec, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxDecompressed))
+	})
+	return zstdEnc, zstdDec
+}
+
+// ParseCompression validates a configured compression name ("" means zstd).
+func ParseCompression(s string) (Compression, error) {
+	switch Compression(s) {
+	case "", CompressionZstd:
+		return CompressionZstd, nil
+	case CompressionGzip:
+		return CompressionGzip, nil
+	}
+	return "", fmt.Errorf("invalid compression: %s (want zstd|gzip)", s)
+}
+
+// compressValue wraps an encoded value with the given codec's marker.
+func compressValue(b []byte, c Compression) ([]byte, error) {
+	if c == CompressionGzip {
+		var buf bytes.Buffer
+		buf.WriteByte(valueGzip)
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(b); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	enc, _ := zstdCodec()
+	return enc.EncodeAll(b, []byte{valueZstd}), nil
+}
+
+// decompressValue undoes compressValue; other values are returned as is.
+func decompressValue(b []byte) ([]byte, error) {
+	if len(b) == 0 {
+		return b, nil
+	}
+	switch b[0] {
+	case valueZstd:
+		_, dec := zstdCodec()
+		out, err := dec.DecodeAll(b[1:], nil)
+		if err != nil {
+			return nil, fmt.Errorf("decompress task: %w", err)
+		}
+		return out, nil
+	case valueGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(b[1:]))
+		if err != nil {
+			return nil, fmt.Errorf("decompress task: %w", err)
+		}
+		out, err := io.ReadAll(io.LimitReader(zr, maxDecompressed+1))
+		if err != nil {
+			return nil, fmt.Errorf("decompress task: %w", err)
+		}
+		if len(out) > maxDecompressed {
+			return nil, fmt.Errorf("decompress task: value exceeds %d bytes", maxDecompressed)
+		}
+		return out, nil
+	}
+	return b, nil
+}
+
+// ParseEncoding validates a configured encoding name ("" means JSON).
+func ParseEncoding(s string) (Encoding, error) {
+	switch Encoding(s) {
+	case "", EncodingJSON:
+		return EncodingJSON, nil
+	case EncodingMsgpack:
+		return EncodingMsgpack, nil
+	}
+	return "", fmt.Errorf("invalid encoding: %s (want json|msgpack)", s)
+}
+
+// encodeTask serializes t for storage per opts. Msgpack uses the JSON field
+// names so both encodings describe the same record; values longer than
+// opts.CompressAbove bytes are compressed. With opts.Key the text is sealed
+// first (see Key).
+func encodeTask(t Task, opts Options) ([]byte, error) {
+	t.Revision = 0 // read-side metadata, not part of the record
+	t.Schema = SchemaVersion
+	t.Status = t.State()
+	t, err := sealTask(t, opts)
+	if err != nil {
+		return nil, err
+	}
+	var b []byte
+	if opts.Encoding == EncodingMsgpack {
+		var buf bytes.Buffer
+		buf.WriteByte(valueMsgpackV1)
+		me := msgpack.NewEncoder(&buf)
+		me.SetCustomStructTag("json")
+		me.SetOmitEmpty(true)
+		if err := me.Encode(t); err != nil {
+			return nil, err
+		}
+		b = buf.Bytes()
+	} else if b, err = json.Marshal(t); err != nil {
+		return nil, err
+	}
+	if opts.CompressAbove > 0 && len(b) > opts.CompressAbove {
+		return compressValue(b, opts.Compression)
+	}
+	return b, nil
+}
+
+// decodeTask reads a stored task value in any supported encoding, compressed
+// or not, upgrading records written at older schema versions.
+func decodeTask(b []byte) (Task, error) {
+	t, _, err := decodeStoredTask(b)
+	return t, err
+}
+
+// decodeStoredTask is decodeTask that also reports the schema version the
+// value was written at.
+func decodeStoredTask(b []byte) (Task, int, error) {
+	b, err := decompressValue(b)
+	if err != nil {
+		return Task{}, 0, err
+	}
+	if len(b) == 0 || b[0] != valueMsgpackV1 {
+		return decodeJSONTask(b)
+	}
+	unmarshal := func(v any) error {
+		md := msgpack.NewDecoder(bytes.NewReader(b[1:]))
+		md.SetCustomStructTag("json")
+		return md.Decode(v)
+	}
+	var t Task
+	if err := unmarshal(&t); err != nil {
+		return Task{}, 0, fmt.Errorf("decode msgpack task: %w", err)
+	}
+	from := t.Schema
+	if from != SchemaVersion {
+		t, err = upgradeRecord(from, func(rec *map[string]any) error { return unmarshal(rec) })
+		if err != nil {
+			return Task{}, from, err
+		}
+	}
+	t.Schema = 0
+	return t, from, nil
+}
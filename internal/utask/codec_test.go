@@ -0,0 +1,54 @@
+package utask
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	task := Task{ID: "abc", Text: "Title\n\nBody", Tags: []string{"a", "b"}, Created: "2025-01-01T00:00:00Z", Priority: 2, Status: StatusOpen}
+	js, err := encodeTask(task, Options{})
+	if err != nil || js[0] != '{' {
+		t.Fatalf("json encode: %q %v", js, err)
+	}
+	mp, err := encodeTask(task, Options{Encoding: EncodingMsgpack})
+	if err != nil || mp[0] != valueMsgpackV1 {
+		t.Fatalf("msgpack encode: %v", err)
+	}
+	if len(mp) >= len(js) {
+		t.Fatalf("msgpack (%d bytes) should be smaller than json (%d)", len(mp), len(js))
+	}
+	for _, b := range [][]byte{js, mp} {
+		got, err := decodeTask(b)
+		if err != nil || !reflect.DeepEqual(got, task) {
+			t.Fatalf("decode %q: got %+v err=%v", b[:1], got, err)
+		}
+	}
+	if _, err := ParseEncoding("protobuf"); err == nil {
+		t.Fatal("unknown encoding should be rejected")
+	}
+}
+
+func TestCodecCompression(t *testing.T) {
+	task := Task{ID: "abc", Text: "Meeting notes\n\n" + strings.Repeat("the same log line over and over\n", 200)}
+	for _, c := range []Compression{CompressionZstd, CompressionGzip} {
+		for _, enc := range []Encoding{EncodingJSON, EncodingMsgpack} {
+			b, err := encodeTask(task, Options{Encoding: enc, CompressAbove: 1024, Compression: c})
+			if err != nil {
+				t.Fatalf("%s/%s encode: %v", c, enc, err)
+			}
+			if len(b) > 1024 {
+				t.Fatalf("%s/%s: expected compressed value, got %d bytes", c, enc, len(b))
+			}
+			got, err := decodeTask(b)
+			if err != nil || got.Text != task.Text {
+				t.Fatalf("%s/%s decode: err=%v", c, enc, err)
+			}
+		}
+	}
+	small, _ := encodeTask(Task{ID: "x", Text: "short"}, Options{CompressAbove: 1024})
+	if small[0] != '{' {
+		t.Fatalf("values under the threshold should stay plain JSON, got %q", small)
+	}
+}
@@ -0,0 +1,63 @@
+package utask
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestStoreSharedStateRace exercises the Store state read on every call
+// while it is being replaced; run with -race.
+func TestStoreSharedStateRace(t *testing.T) {
+	s := &Store{}
+	id := "ab12cd34"
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if k := s.taskKey(id); keyID(k) != id {
+					t.Errorf("taskKey(%q) = %q", id, k)
+					return
+				}
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if (i+j)%2 == 0 {
+					s.keyspace.Store(KeyspaceSharded)
+				} else {
+					s.keyspace.Store(KeyspaceFlat)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// TestCodecConcurrent checks the shared lazily-built zstd codec.
+func TestCodecConcurrent(t *testing.T) {
+	opts := Options{CompressAbove: 1, Compression: CompressionZstd, Encoding: EncodingMsgpack}
+	task := Task{ID: "ab", Text: "concurrent codec use", Tags: []string{"x"}, Created: "2024-01-01T00:00:00Z"}
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				b, err := encodeTask(task, opts)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				got, err := decodeTask(b)
+				if err != nil || got.Text != task.Text {
+					t.Errorf("round trip: %+v, %v", got, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,129 @@
+package utask
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Contexts are GTD-style places, tools or modes a task needs ("@home",
+// "@errands", "@deep-work"). Unlike tags they aren't indexed: they narrow
+// listings to what can be done where you are now (see ListFilter.Context
+// and the active context of `ut context set`).
+
+// ParseContext normalizes one context name: lower case with a leading "@".
+func ParseContext(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = "@" + strings.TrimLeft(s, "@")
+	if s == "@" || strings.ContainsAny(s, " \t\n,") {
+		return "", invalidf("invalid context %q (want e.g. @home)", s)
+	}
+	return s, nil
+}
+
+// NormalizeContexts parses each of in, dropping blanks and duplicates.
+func NormalizeContexts(in []string) ([]string, error) {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, s := range in {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		c, err := ParseContext(s)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[c] {
+			seen[c] = true
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// HasContext reports whether t carries context c (as given to ParseContext).
+func (t Task) HasContext(c string) bool {
+	c, err := ParseContext(c)
+	if err != nil {
+		return false
+	}
+	for _, tc := range t.Contexts {
+		if tc == c {
+			return true
+		}
+	}
+	return false
+}
+
+// matchContext applies ListFilter.Context.
+func matchContext(t Task, f ListFilter) bool {
+	return f.Context == "" || t.HasContext(f.Context)
+}
+
+// ContextCount is a context and how many open tasks carry it.
+type ContextCount struct {
+	Context string `json:"context"`
+	Open    int    `json:"open"`
+}
+
+// CountContexts tallies the contexts of open tasks, most used first.
+func CountContexts(tasks []Task) []ContextCount {
+	n := map[string]int{}
+	for _, t := range tasks {
+		if t.Done {
+			continue
+		}
+		for _, c := range t.Contexts {
+			n[c]++
+		}
+	}
+	out := make([]ContextCount, 0, len(n))
+	for c, k := range n {
+		out = append(out, ContextCount{Context: c, Open: k})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Open != out[j].Open {
+			return out[i].Open > out[j].Open
+		}
+		return out[i].Context < out[j].Context
+	})
+	return out
+}
+
+// ActiveContextPath returns the file holding a profile's active context on
+// this machine.
+func ActiveContextPath(profile string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".utask", "context", profile), nil
+}
+
+// LoadActiveContext reads the active context; none set yields "".
+func LoadActiveContext(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// SaveActiveContext records c as the active context; "" clears it.
+func SaveActiveContext(path, c string) error {
+	if c == "" {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(c+"\n"), 0o600)
+}
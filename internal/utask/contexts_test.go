@@ -0,0 +1,66 @@
+package utask
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeContexts(t *testing.T) {
+	got, err := NormalizeContexts([]string{"home", " @Home", "", "@deep-work", "@@errands"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"@home", "@deep-work", "@errands"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for _, bad := range []string{"@", "deep work", "a,b"} {
+		if _, err := ParseContext(bad); err == nil {
+			t.Errorf("ParseContext(%q) accepted", bad)
+		}
+	}
+}
+
+func TestSelectContext(t *testing.T) {
+	snap := &Snapshot{Tasks: map[string]Task{
+		"a": {ID: "a", Created: "1", Contexts: []string{"@home"}},
+		"b": {ID: "b", Created: "2", Contexts: []string{"@errands", "@home"}},
+		"c": {ID: "c", Created: "3", Contexts: []string{"@errands"}},
+		"d": {ID: "d", Created: "4"},
+		"e": {ID: "e", Created: "5", Done: true, Contexts: []string{"@home"}},
+	}}
+	var ids []string
+	for _, tk := range snap.Select(ListFilter{Context: "home", Status: StatusOpen}) {
+		ids = append(ids, tk.ID)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("got %v, want %v", ids, want)
+	}
+	tasks := make([]Task, 0, len(snap.Tasks))
+	for _, tk := range snap.Tasks {
+		tasks = append(tasks, tk)
+	}
+	want := []ContextCount{{"@errands", 2}, {"@home", 2}}
+	if got := CountContexts(tasks); !reflect.DeepEqual(got, want) {
+		t.Fatalf("CountContexts = %v, want %v", got, want)
+	}
+}
+
+func TestActiveContextFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "context", "default")
+	if c, err := LoadActiveContext(path); c != "" || err != nil {
+		t.Fatalf("unset: %q, %v", c, err)
+	}
+	if err := SaveActiveContext(path, "@home"); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := LoadActiveContext(path); c != "@home" {
+		t.Fatalf("got %q", c)
+	}
+	if err := SaveActiveContext(path, ""); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := LoadActiveContext(path); c != "" {
+		t.Fatalf("after clear: %q", c)
+	}
+}
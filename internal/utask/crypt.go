@@ -0,0 +1,201 @@
+package utask
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// Key is a profile encryption key. With Options.Key set, a task's text
+// (which carries its trailers), details and annotations are sealed with
+// NaCl secretbox before they are written to the tasks bucket or the audit
+// trail, and opened again as it is read, so the NATS server only ever sees
+// ciphertext. The trailer index keys trailers by a keyed hash instead of
+// their names and values (see indexMAC). Tags, assignee, dates and the
+// other structured fields stay in the clear: the tag index and server-side
+// filters need them.
+type Key [32]byte
+
+// sealedPrefix marks sealed text. Text without it is read as is, so a
+// profile can start using a key without rewriting its existing tasks.
+const sealedPrefix = "utask:sealed:v1:"
+
+// ErrSealed is returned when task text is sealed with a key this Store
+// doesn't have.
+var ErrSealed = errors.New("task text is encrypted with a different key")
+
+// GenerateKey returns a new random key.
+func GenerateKey() (*Key, error) {
+	var k Key
+	if _, err := rand.Read(k[:]); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// ParseKey decodes a base64 key as written by Key.String.
+func ParseKey(s string) (*Key, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(b) != len(Key{}) {
+		return nil, fmt.Errorf("invalid encryption key: want %d bytes of base64", len(Key{}))
+	}
+	var k Key
+	copy(k[:], b)
+	return &k, nil
+}
+
+// String encodes k as base64.
+func (k *Key) String() string { return base64.StdEncoding.EncodeToString(k[:]) }
+
+// KeyPath returns the default key file for a profile.
+func KeyPath(profile string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".utask", "keys", profile+".key"), nil
+}
+
+// LoadKey reads a key file. A missing file returns nil and no error.
+func LoadKey(path string) (*Key, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	k, err := ParseKey(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return k, nil
+}
+
+// SaveKey writes k to path, readable only by the owner. It refuses to
+// replace an existing key, which would strand every task sealed with it.
+func SaveKey(path string, k *Key) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(k.String() + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// indexMAC returns a hex HMAC-SHA256 of data under a subkey derived from k,
+// for index keys standing in for sealed text: without k they can't be read
+// back or matched against guesses.
+func (k *Key) indexMAC(data string) string {
+	sub := hmac.New(sha256.New, k[:])
+	sub.Write([]byte("utask index v1"))
+	mac := hmac.New(sha256.New, sub.Sum(nil))
+	mac.Write([]byte(data))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// seal encrypts text. Empty and already sealed text is returned as is.
+func (k *Key) seal(text string) (string, error) {
+	if k == nil || text == "" || strings.HasPrefix(text, sealedPrefix) {
+		return text, nil
+	}
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return "", err
+	}
+	box := secretbox.Seal(nonce[:], []byte(text), &nonce, (*[32]byte)(k))
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(box), nil
+}
+
+// open undoes seal; text that isn't sealed is returned as is.
+func (k *Key) open(text string) (string, error) {
+	if !strings.HasPrefix(text, sealedPrefix) {
+		return text, nil
+	}
+	if k == nil {
+		return "", fmt.Errorf("%w: configure the profile's key", ErrSealed)
+	}
+	box, err := base64.RawStdEncoding.DecodeString(text[len(sealedPrefix):])
+	if err != nil || len(box) < 24 {
+		return "", fmt.Errorf("decode sealed text: malformed value")
+	}
+	var nonce [24]byte
+	copy(nonce[:], box)
+	out, ok := secretbox.Open(nil, box[24:], &nonce, (*[32]byte)(k))
+	if !ok {
+		return "", ErrSealed
+	}
+	return string(out), nil
+}
+
+// sealTask returns t with its text, details and annotations sealed under
+// opts.Key. The annotations are copied, so t's own stay readable.
+func sealTask(t Task, opts Options) (Task, error) {
+	text, err := opts.Key.seal(t.Text)
+	if err != nil {
+		return Task{}, fmt.Errorf("encrypt task: %w", err)
+	}
+	t.Text = text
+	if t.Description != "" {
+		if t.Description, err = opts.Key.seal(t.Description); err != nil {
+			return Task{}, fmt.Errorf("encrypt task: %w", err)
+		}
+	}
+	if opts.Key != nil && len(t.Annotations) > 0 {
+		notes := make([]Annotation, len(t.Annotations))
+		for i, a := range t.Annotations {
+			if a.Text, err = opts.Key.seal(a.Text); err != nil {
+				return Task{}, fmt.Errorf("encrypt task: %w", err)
+			}
+			notes[i] = a
+		}
+		t.Annotations = notes
+	}
+	return t, nil
+}
+
+// openTask undoes sealTask on t in place.
+func (s *Store) openTask(t *Task) error {
+	text, err := s.opts.Key.open(t.Text)
+	if err != nil {
+		return err
+	}
+	details, err := s.opts.Key.open(t.Description)
+	if err != nil {
+		return err
+	}
+	for i := range t.Annotations {
+		if t.Annotations[i].Text, err = s.opts.Key.open(t.Annotations[i].Text); err != nil {
+			return err
+		}
+	}
+	t.Text, t.Description = text, details
+	return nil
+}
+
+// decodeTask is the package decodeTask that also opens sealed text.
+func (s *Store) decodeTask(b []byte) (Task, error) {
+	t, err := decodeTask(b)
+	if err != nil {
+		return Task{}, err
+	}
+	if err := s.openTask(&t); err != nil {
+		return Task{}, fmt.Errorf("task %s: %w", t.ID, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,84 @@
+package utask
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSealedTaskRoundTrip(t *testing.T) {
+	k, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := Task{ID: "abc", Text: "call the bank\n\nDue: 2026-03-01", Description: "ask about the bank fee", Tags: []string{"home"}}
+	for _, opts := range []Options{{Key: k}, {Key: k, Encoding: EncodingMsgpack, CompressAbove: 1}} {
+		b, err := encodeTask(task, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := decodeTask(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(raw.Text, sealedPrefix) || strings.Contains(raw.Text, "bank") {
+			t.Fatalf("text stored in the clear: %q", raw.Text)
+		}
+		if !strings.HasPrefix(raw.Description, sealedPrefix) {
+			t.Fatalf("details stored in the clear: %q", raw.Description)
+		}
+		if raw.Tags[0] != "home" {
+			t.Fatalf("tags should stay readable, got %v", raw.Tags)
+		}
+		got, err := (&Store{opts: opts}).decodeTask(b)
+		if err != nil || got.Text != task.Text || got.Description != task.Description {
+			t.Fatalf("decodeTask = %q, %q, %v", got.Text, got.Description, err)
+		}
+		if _, err := (&Store{}).decodeTask(b); !errors.Is(err, ErrSealed) {
+			t.Fatalf("without key: err = %v, want ErrSealed", err)
+		}
+		other, _ := GenerateKey()
+		if _, err := (&Store{opts: Options{Key: other}}).decodeTask(b); !errors.Is(err, ErrSealed) {
+			t.Fatalf("wrong key: err = %v, want ErrSealed", err)
+		}
+	}
+}
+
+func TestOpenPlainText(t *testing.T) {
+	k, _ := GenerateKey()
+	b, err := encodeTask(Task{ID: "abc", Text: "plain"}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := (&Store{opts: Options{Key: k}}).decodeTask(b)
+	if err != nil || got.Text != "plain" {
+		t.Fatalf("decodeTask = %q, %v", got.Text, err)
+	}
+	sealed, _ := k.seal("x")
+	if again, _ := k.seal(sealed); again != sealed {
+		t.Fatal("sealing sealed text should be a no-op")
+	}
+}
+
+func TestKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "default.key")
+	if k, err := LoadKey(path); k != nil || err != nil {
+		t.Fatalf("missing file: %v, %v", k, err)
+	}
+	k, _ := GenerateKey()
+	if err := SaveKey(path, k); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveKey(path, k); !os.IsExist(err) {
+		t.Fatalf("overwrite: err = %v", err)
+	}
+	got, err := LoadKey(path)
+	if err != nil || *got != *k {
+		t.Fatalf("LoadKey = %v, %v", got, err)
+	}
+	if _, err := ParseKey("c2hvcnQ="); err == nil {
+		t.Fatal("short key accepted")
+	}
+}
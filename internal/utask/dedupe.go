@@ -0,0 +1,51 @@
+package utask
+
+import (
+	"context"
+	"sort"
+)
+
+// Duplicates returns the groups of tasks the caller can see whose content
+// is identical: the same ContentID, so the same normalized text, tags,
+// priority, estimate and details. Such tasks come from IDRandom or
+// ForceNew creates, or from edits that turned one task into a copy of
+// another. Each group is oldest first, and groups are ordered by their
+// oldest task.
+func (s *Store) Duplicates(ctx context.Context) ([][]Task, error) {
+	it, err := s.ListIter(ctx, ListFilter{})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+	byContent := map[string][]Task{}
+	for it.Next() {
+		t := it.Task()
+		id := ContentID(t)
+		byContent[id] = append(byContent[id], t)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	out := [][]Task{}
+	for _, group := range byContent {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool { return olderTask(group[i], group[j]) })
+		out = append(out, group)
+	}
+	sort.Slice(out, func(i, j int) bool { return olderTask(out[i][0], out[j][0]) })
+	return out, nil
+}
+
+// olderTask orders tasks by creation time, then by number for tasks
+// created in the same second, then by id.
+func olderTask(a, b Task) bool {
+	if a.Created != b.Created {
+		return a.Created < b.Created
+	}
+	if a.Number != b.Number {
+		return a.Number < b.Number
+	}
+	return a.ID < b.ID
+}
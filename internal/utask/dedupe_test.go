@@ -0,0 +1,64 @@
+package utask
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestForceNewAndDuplicates(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	a, _, err := s.CreateTask(ctx, TaskInput{Text: "water plants", Tags: []string{"home"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, existed, err := s.CreateTask(ctx, TaskInput{Text: "water plants", Tags: []string{"home"}}); err != nil || !existed {
+		t.Fatalf("content-addressed re-create: existed = %v, %v", existed, err)
+	}
+	b, existed, err := s.CreateTask(ctx, TaskInput{Text: "water plants", Tags: []string{"home"}, ForceNew: true})
+	if err != nil || existed || b.ID == a.ID || !isTaskID(b.ID) {
+		t.Fatalf("ForceNew create = %s, existed = %v, %v", b.ID, existed, err)
+	}
+	if _, _, err := s.CreateTask(ctx, TaskInput{Text: "something else"}); err != nil {
+		t.Fatal(err)
+	}
+	groups, err := s.Duplicates(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || len(groups[0]) != 2 {
+		t.Fatalf("Duplicates = %v, want one pair", groups)
+	}
+	if got := map[string]bool{groups[0][0].ID: true, groups[0][1].ID: true}; !got[a.ID] || !got[b.ID] {
+		t.Fatalf("Duplicates = %v, want %s and %s", groups, a.ID, b.ID)
+	}
+}
+
+func TestRandomIDStrategy(t *testing.T) {
+	ctx := context.Background()
+	s, err := OpenSQLite(ctx, filepath.Join(t.TempDir(), "tasks.db"), "test", Options{Identity: "Ada <ada@example.org>", IDStrategy: IDRandom})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	a, _, err := s.CreateTask(ctx, TaskInput{Text: "standup"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, existed, err := s.CreateTask(ctx, TaskInput{Text: "standup"})
+	if err != nil || existed || a.ID == b.ID {
+		t.Fatalf("random ids: %s, %s, existed = %v, %v", a.ID, b.ID, existed, err)
+	}
+}
+
+func TestParseIDStrategy(t *testing.T) {
+	for in, want := range map[string]IDStrategy{"": IDContent, "content": IDContent, "random": IDRandom} {
+		if got, err := ParseIDStrategy(in); err != nil || got != want {
+			t.Errorf("ParseIDStrategy(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseIDStrategy("ulid"); err == nil {
+		t.Error("ParseIDStrategy accepted ulid")
+	}
+}
@@ -0,0 +1,75 @@
+package utask
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// DelegatedToTrailer is the trailer DelegateTask appends to a task's text.
+const DelegatedToTrailer = "Delegated-to"
+
+// Delegation records that a task was handed to someone else and its
+// delegator is waiting on them.
+type Delegation struct {
+	To    string    `json:"to"`
+	By    string    `json:"by,omitempty"`
+	Since time.Time `json:"since"`
+}
+
+// DelegateTask hands a task to another person: it appends a Delegated-to
+// trailer, assigns the task to them, and records the caller as waiting on
+// it until the task is closed. An empty to ends the wait and leaves the
+// trailer as history. A non-zero ifRev makes it fail with ErrConflict
+// unless the task is still at that revision.
+func (s *Store) DelegateTask(ctx context.Context, id, to string, ifRev uint64) (Task, error) {
+	if err := s.authorize(ctx, "delegate", RoleContributor); err != nil {
+		return Task{}, err
+	}
+	before, rev, err := s.GetTask(ctx, id)
+	if err != nil {
+		return Task{}, err
+	}
+	if err := checkRevision(id, ifRev, rev); err != nil {
+		return Task{}, err
+	}
+	to = strings.TrimSpace(to)
+	t := before
+	detail := "ended"
+	if to == "" {
+		if t.Delegation == nil {
+			return t, nil
+		}
+		t.Delegation = nil
+	} else {
+		if t.Delegation != nil && t.Delegation.To == to {
+			return t, nil
+		}
+		text := appendTrailer(t.Text, DelegatedToTrailer, to)
+		if err := s.opts.Limits.validateText(text); err != nil {
+			return Task{}, err
+		}
+		t.Text = text
+		t.Assignee = to
+		t.Delegation = &Delegation{To: to, By: s.identity(ctx), Since: time.Now().UTC()}
+		detail = "-> " + to
+	}
+	t.touch(s.identity(ctx), time.Now())
+	newRev, err := s.putTaskCAS(ctx, id, t, rev)
+	if err != nil {
+		return Task{}, err
+	}
+	t.Revision = newRev
+	s.logWrite(ctx, "delegate", id, newRev)
+	s.audit(ctx, ActivityDelegated, t, detail)
+	return t, nil
+}
+
+// matchDelegatedBy applies ListFilter.DelegatedBy: open tasks the given
+// identity handed to someone else.
+func matchDelegatedBy(t Task, f ListFilter) bool {
+	if f.DelegatedBy == "" {
+		return true
+	}
+	return !t.Done && t.Delegation != nil && matchAssignee(t.Delegation.By, f.DelegatedBy)
+}
@@ -0,0 +1,22 @@
+package utask
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatchDelegatedBy(t *testing.T) {
+	d := &Delegation{To: "bob", By: "Ada <ada@example.org>", Since: time.Now()}
+	snap := &Snapshot{Tasks: map[string]Task{
+		"open":   {ID: "open", Delegation: d},
+		"closed": {ID: "closed", Done: true, Delegation: d},
+		"mine":   {ID: "mine"},
+	}}
+	got := snap.Select(ListFilter{DelegatedBy: "ada"})
+	if len(got) != 1 || got[0].ID != "open" {
+		t.Fatalf("unexpected tasks %+v", got)
+	}
+	if n := len(snap.Select(ListFilter{DelegatedBy: "bob"})); n != 0 {
+		t.Fatalf("delegatee matched %d tasks", n)
+	}
+}
@@ -0,0 +1,160 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Dependencies: Task.DependsOn lists tasks that must be closed before a task
+// can be worked on. They don't affect the task id, every id must name an
+// existing task and the graph they form stays acyclic.
+
+// Ready reports whether t is open and none of its dependencies is still
+// open. closed says whether a dependency is closed; the callers treat
+// deleted dependencies as closed.
+func (t Task) Ready(closed func(id string) bool) bool {
+	if t.Done {
+		return false
+	}
+	for _, dep := range t.DependsOn {
+		if !closed(dep) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchReady applies ListFilter.Ready.
+func matchReady(t Task, f ListFilter, closed func(id string) bool) bool {
+	return !f.Ready || t.Ready(closed)
+}
+
+// resolveDeps resolves each of refs (ids, prefixes or aliases) for a new
+// task id, dropping duplicates. A new task can't close a cycle, since
+// nothing depends on it yet, but it can't depend on itself.
+func (s *Store) resolveDeps(ctx context.Context, id string, refs []string) ([]string, error) {
+	var out []string
+	seen := map[string]bool{}
+	for _, ref := range refs {
+		dep, _, err := s.Resolve(ctx, ref)
+		if err != nil {
+			return nil, invalidf("dependency %s: %v", ref, err)
+		}
+		if dep == id {
+			return nil, invalidf("task %s can't depend on itself", id)
+		}
+		if !seen[dep] {
+			seen[dep] = true
+			out = append(out, dep)
+		}
+	}
+	return out, nil
+}
+
+// checkCycle fails when id depending on on would close a cycle, i.e. when
+// on already depends on id, directly or through other tasks.
+func (s *Store) checkCycle(ctx context.Context, id, on string) error {
+	if id == on {
+		return invalidf("task %s can't depend on itself", id)
+	}
+	seen := map[string]bool{}
+	queue := []string{on}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		t, _, err := s.getTask(ctx, cur)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, dep := range t.DependsOn {
+			if dep == id {
+				return invalidf("task %s already depends on %s; blocking it would make a cycle", on, id)
+			}
+			if !seen[dep] {
+				seen[dep] = true
+				queue = append(queue, dep)
+			}
+		}
+	}
+	return nil
+}
+
+// BlockTask makes task id depend on task on: id isn't ready until on is
+// closed. Both must exist and the dependency must not close a cycle. A
+// non-zero ifRev makes it fail with ErrConflict unless id is still at that
+// revision.
+func (s *Store) BlockTask(ctx context.Context, id, on string, ifRev uint64) (Task, error) {
+	if err := s.authorize(ctx, "block", RoleContributor); err != nil {
+		return Task{}, err
+	}
+	t, rev, err := s.GetTask(ctx, id)
+	if err != nil {
+		return Task{}, err
+	}
+	if err := checkRevision(id, ifRev, rev); err != nil {
+		return Task{}, err
+	}
+	if _, _, err := s.GetTask(ctx, on); err != nil {
+		return Task{}, err
+	}
+	for _, dep := range t.DependsOn {
+		if dep == on {
+			return t, nil
+		}
+	}
+	if err := s.checkCycle(ctx, id, on); err != nil {
+		return Task{}, err
+	}
+	t.DependsOn = append(t.DependsOn, on)
+	t.touch(s.identity(ctx), time.Now())
+	newRev, err := s.putTaskCAS(ctx, id, t, rev)
+	if err != nil {
+		return Task{}, err
+	}
+	t.Revision = newRev
+	s.logWrite(ctx, "block", id, newRev)
+	s.audit(ctx, ActivityBlocked, t, "on "+on)
+	return t, nil
+}
+
+// UnblockTask drops the dependency of task id on task on, or all of its
+// dependencies when on is empty. ifRev is as for BlockTask.
+func (s *Store) UnblockTask(ctx context.Context, id, on string, ifRev uint64) (Task, error) {
+	if err := s.authorize(ctx, "unblock", RoleContributor); err != nil {
+		return Task{}, err
+	}
+	t, rev, err := s.GetTask(ctx, id)
+	if err != nil {
+		return Task{}, err
+	}
+	if err := checkRevision(id, ifRev, rev); err != nil {
+		return Task{}, err
+	}
+	var kept []string
+	for _, dep := range t.DependsOn {
+		if on != "" && dep != on {
+			kept = append(kept, dep)
+		}
+	}
+	if len(kept) == len(t.DependsOn) {
+		return t, nil
+	}
+	detail := "all"
+	if on != "" {
+		detail = "on " + on
+	}
+	t.DependsOn = kept
+	t.touch(s.identity(ctx), time.Now())
+	newRev, err := s.putTaskCAS(ctx, id, t, rev)
+	if err != nil {
+		return Task{}, err
+	}
+	t.Revision = newRev
+	s.logWrite(ctx, "unblock", id, newRev)
+	s.audit(ctx, ActivityUnblocked, t, detail)
+	return t, nil
+}
@@ -0,0 +1,76 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDependencies(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	design, _, err := s.CreateTask(ctx, TaskInput{Text: "Design"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	build, _, err := s.CreateTask(ctx, TaskInput{Text: "Build", DependsOn: []string{design.ID[:8], design.ID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(build.DependsOn) != 1 || build.DependsOn[0] != design.ID {
+		t.Fatalf("depends on %v", build.DependsOn)
+	}
+	if _, _, err := s.CreateTask(ctx, TaskInput{Text: "Ship", DependsOn: []string{"ffffffff"}}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("unknown dependency: %v", err)
+	}
+	ship, _, err := s.CreateTask(ctx, TaskInput{Text: "Ship"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.BlockTask(ctx, ship.ID, build.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.BlockTask(ctx, design.ID, ship.ID, 0); !errors.Is(err, ErrValidation) {
+		t.Fatalf("cycle: %v", err)
+	}
+
+	ready := func() []string {
+		it, err := s.ListIter(ctx, ListFilter{Ready: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer it.Close()
+		var ids []string
+		for it.Next() {
+			ids = append(ids, it.Task().ID)
+		}
+		return ids
+	}
+	if got := ready(); len(got) != 1 || got[0] != design.ID {
+		t.Fatalf("ready = %v, want only design", got)
+	}
+	if _, _, err := s.CloseTask(ctx, design.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := ready(); len(got) != 1 || got[0] != build.ID {
+		t.Fatalf("ready after closing design = %v, want only build", got)
+	}
+	if _, err := s.UnblockTask(ctx, ship.ID, "", 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := ready(); len(got) != 2 {
+		t.Fatalf("ready after unblocking ship = %v", got)
+	}
+}
+
+func TestSnapshotReady(t *testing.T) {
+	snap := &Snapshot{Tasks: map[string]Task{
+		"a": {ID: "a", Done: true},
+		"b": {ID: "b", DependsOn: []string{"a", "gone"}},
+		"c": {ID: "c", DependsOn: []string{"b"}},
+	}}
+	got := snap.Select(ListFilter{Ready: true})
+	if len(got) != 1 || got[0].ID != "b" {
+		t.Fatalf("ready = %v, want only b", got)
+	}
+}
@@ -0,0 +1,151 @@
+package utask
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// DueTrailer is the trailer that held a task's due date before Task.Due, as
+// an RFC 3339 time or a YYYY-MM-DD date. DueAt still reads it on tasks
+// without the field.
+const DueTrailer = "Due"
+
+// DueAt returns when the task is due: Task.Due, or the Due trailer of older
+// tasks. A bare date (a due time at midnight) is due at the end of that
+// day, in loc for a trailer.
+func (t Task) DueAt(loc *time.Location) (time.Time, bool) {
+	due, ok := t.dueStart(loc)
+	if ok && isDate(due) {
+		due = due.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return due, ok
+}
+
+// dueStart reads the due time like DueAt, but returns a bare date as the
+// start of the day, which FormatDue writes back as a bare date.
+func (t Task) dueStart(loc *time.Location) (time.Time, bool) {
+	if t.Due != nil {
+		return *t.Due, true
+	}
+	for _, tr := range t.Trailers() {
+		if !strings.EqualFold(tr.Key, DueTrailer) {
+			continue
+		}
+		v := strings.TrimSpace(tr.Value)
+		if ts, err := time.Parse(time.RFC3339, v); err == nil {
+			return ts, true
+		}
+		if d, err := time.ParseInLocation("2006-01-02", v, loc); err == nil {
+			return d, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Overdue reports whether t is open and past its due time at now.
+func (t Task) Overdue(now time.Time) bool {
+	due, ok := t.DueAt(now.Location())
+	return ok && !t.Done && now.After(due)
+}
+
+// FormatDue renders a due time: a time at midnight becomes a bare date (due
+// by the end of that day), anything else RFC 3339. The zero time yields "".
+func FormatDue(t time.Time) string {
+	switch {
+	case t.IsZero():
+		return ""
+	case isDate(t):
+		return t.Format("2006-01-02")
+	}
+	return t.Format(time.RFC3339)
+}
+
+// isDate reports whether t is at midnight, i.e. names a whole day.
+func isDate(t time.Time) bool {
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
+
+// SetTrailer replaces the key trailers in text with one holding value, or
+// just removes them when value is empty.
+func SetTrailer(text, key, value string) string {
+	text = strings.TrimRight(text, " \t\n")
+	if start, end, ok := trailerRegion(text); ok {
+		var kept []string
+		for _, line := range strings.Split(text[start:end], "\n") {
+			if tr, ok := parseTrailer(line); ok && strings.EqualFold(tr.Key, key) {
+				continue
+			}
+			kept = append(kept, line)
+		}
+		text = strings.TrimRight(text[:start], " \t\n")
+		if len(kept) > 0 {
+			text += "\n\n" + strings.Join(kept, "\n")
+		}
+	}
+	if value == "" {
+		return text
+	}
+	return appendTrailer(text, key, value)
+}
+
+// optionalTime stores t to the second, with the zero time as nil.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	u := t.UTC().Truncate(time.Second)
+	return &u
+}
+
+// dueTime stores a due time for Task.Due: to the second, in its own zone so
+// a bare date stays at midnight, with the zero time as nil.
+func dueTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	u := t.Truncate(time.Second)
+	return &u
+}
+
+// applyDates makes the due, scheduled and wait changes in set to t.
+func (set UpdateSet) applyDates(t *Task) {
+	if set.Due != nil {
+		t.Due = dueTime(*set.Due)
+		if _, _, ok := trailerRegion(t.Text); ok {
+			t.Text = SetTrailer(t.Text, DueTrailer, "")
+		}
+	}
+	if set.Scheduled != nil {
+		t.Scheduled = optionalTime(*set.Scheduled)
+	}
+	if set.Wait != nil {
+		t.SnoozedUntil = optionalTime(*set.Wait)
+	}
+}
+
+// matchDue applies ListFilter.DueBefore and Overdue at the current time.
+func matchDue(t Task, f ListFilter) bool {
+	if f.DueBefore.IsZero() && !f.Overdue {
+		return true
+	}
+	now := time.Now()
+	due, ok := t.DueAt(now.Location())
+	if !ok || !f.DueBefore.IsZero() && due.After(f.DueBefore) {
+		return false
+	}
+	return !f.Overdue || t.Overdue(now)
+}
+
+// SortByDue orders tasks soonest due first; tasks without a due date keep
+// their order after the rest.
+func SortByDue(tasks []Task, loc *time.Location) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, aok := tasks[i].DueAt(loc)
+		b, bok := tasks[j].DueAt(loc)
+		if aok != bok {
+			return aok
+		}
+		return aok && a.Before(b)
+	})
+}
@@ -0,0 +1,115 @@
+package utask
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDueAndOverdue(t *testing.T) {
+	now := time.Date(2026, 3, 5, 15, 0, 0, 0, time.UTC)
+	day := func(d int) *time.Time {
+		t := time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	at := time.Date(2026, 3, 5, 14, 0, 0, 0, time.UTC)
+	cases := []struct {
+		text    string
+		due     *time.Time
+		overdue bool
+	}{
+		{"Pay rent", day(5), false},
+		{"Pay rent", day(4), true},
+		{"Ship", &at, true},
+		{"Pay rent\n\nDue: 2026-03-05", nil, false},
+		{"Pay rent\n\nDue: 2026-03-04", nil, true},
+		{"Ship\n\ndue: 2026-03-05T14:00:00Z", nil, true},
+		{"Field wins\n\nDue: 2026-03-01", day(6), false},
+		{"No date", nil, false},
+	}
+	for _, c := range cases {
+		if got := (Task{Text: c.text, Due: c.due}).Overdue(now); got != c.overdue {
+			t.Fatalf("%q due %v: overdue %v, want %v", c.text, c.due, got, c.overdue)
+		}
+	}
+	if (Task{Text: "x\n\nDue: 2026-03-01", Done: true}).Overdue(now) {
+		t.Fatal("closed task reported overdue")
+	}
+}
+
+func TestSetTrailer(t *testing.T) {
+	text := "Pay rent\n\nDetails.\n\nDue: 2026-03-01\nApproved-by: Ada"
+	if got, want := SetTrailer(text, DueTrailer, "2026-04-01"), "Pay rent\n\nDetails.\n\nApproved-by: Ada\nDue: 2026-04-01"; got != want {
+		t.Fatalf("replace:\n%q\nwant\n%q", got, want)
+	}
+	if got, want := SetTrailer("Pay rent\n\nDue: 2026-03-01", DueTrailer, ""), "Pay rent"; got != want {
+		t.Fatalf("clear: %q, want %q", got, want)
+	}
+	if got, want := SetTrailer("Pay rent", DueTrailer, FormatDue(time.Date(2026, 3, 6, 17, 0, 0, 0, time.UTC))), "Pay rent\n\nDue: 2026-03-06T17:00:00Z"; got != want {
+		t.Fatalf("add: %q, want %q", got, want)
+	}
+}
+
+func TestDueFilterAndSort(t *testing.T) {
+	late := time.Date(2999, 1, 2, 0, 0, 0, 0, time.UTC)
+	tasks := []Task{
+		{ID: "none", Text: "Undated"},
+		{ID: "late", Text: "x", Due: &late},
+		{ID: "past", Text: "x\n\nDue: 2000-01-01"},
+		{ID: "soon", Text: "x\n\nDue: 2999-01-01"},
+	}
+	SortByDue(tasks, time.UTC)
+	var got []string
+	for _, task := range tasks {
+		got = append(got, task.ID)
+	}
+	if want := "past soon late none"; strings.Join(got, " ") != want {
+		t.Fatalf("sorted %v, want %s", got, want)
+	}
+	// Now past, soon, late, none.
+	f := ListFilter{DueBefore: time.Date(2999, 1, 2, 0, 0, 0, 0, time.Local)}
+	if !matchDue(tasks[0], f) || !matchDue(tasks[1], f) || matchDue(tasks[2], f) || matchDue(tasks[3], f) {
+		t.Fatal("DueBefore kept the wrong tasks")
+	}
+	overdue := ListFilter{Overdue: true}
+	if !matchDue(tasks[0], overdue) || matchDue(tasks[1], overdue) || matchDue(tasks[3], overdue) {
+		t.Fatal("Overdue kept the wrong tasks")
+	}
+}
+
+func TestDueField(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	due := time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)
+	task, _, err := s.CreateTask(ctx, TaskInput{Text: "Pay rent", Due: due})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Text != "Pay rent" || task.Due == nil || !task.Due.Equal(due) {
+		t.Fatalf("created %q due %v, want the date in Task.Due", task.Text, task.Due)
+	}
+	if _, id := NormalizeInput(TaskInput{Text: "Pay rent"}); task.ID != id {
+		t.Fatal("due date changed the task id")
+	}
+	if got, _ := task.DueAt(time.UTC); !got.Equal(due.AddDate(0, 0, 1).Add(-time.Nanosecond)) {
+		t.Fatalf("DueAt = %v, want the end of the day", got)
+	}
+
+	old, _, err := s.CreateTask(ctx, TaskInput{Text: "Old\n\nDue: 2026-03-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	later := time.Date(2026, 3, 9, 17, 0, 0, 0, time.UTC)
+	old, err = s.UpdateTask(ctx, old.ID, UpdateSet{Due: &later})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old.Text != "Old" || old.Due == nil || !old.Due.Equal(later) {
+		t.Fatalf("updated %q due %v, want the trailer moved to Task.Due", old.Text, old.Due)
+	}
+	var none time.Time
+	if old, err = s.UpdateTask(ctx, old.ID, UpdateSet{Due: &none}); err != nil || old.Due != nil {
+		t.Fatalf("cleared due = %v, %v", old.Due, err)
+	}
+}
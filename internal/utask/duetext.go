@@ -0,0 +1,270 @@
+package utask
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dueRule recognises one kind of date phrase. resolve gets the submatches
+// and today's midnight and returns the due day.
+type dueRule struct {
+	re      *regexp.Regexp
+	resolve func(m []string, today time.Time) (time.Time, bool)
+}
+
+const (
+	duePrep  = `(?:(?:by|due|before|until)\s+)?`
+	dueOn    = `(?:by|due|before|until|on)\s+`
+	weekdays = `monday|tuesday|wednesday|thursday|friday|saturday|sunday|mon|tues|tue|wed|thurs|thu|fri|sat|sun`
+	months   = `january|february|march|april|may|june|july|august|september|october|november|december|jan|feb|mar|apr|jun|jul|aug|sept|sep|oct|nov|dec`
+)
+
+// dueRules are tried against the first line of a task's text. Bare
+// weekdays and dates only count after a preposition ("by friday", "on
+// mar 3") so titles that merely mention them are left alone.
+var dueRules = []dueRule{
+	{regexp.MustCompile(`(?i)\b` + duePrep + `(today|tonight|tomorrow|tmrw)\b`), func(m []string, today time.Time) (time.Time, bool) {
+		if strings.EqualFold(m[1], "today") || strings.EqualFold(m[1], "tonight") {
+			return today, true
+		}
+		return today.AddDate(0, 0, 1), true
+	}},
+	{regexp.MustCompile(`(?i)\b` + duePrep + `next\s+(week|month|year)\b`), func(m []string, today time.Time) (time.Time, bool) {
+		switch strings.ToLower(m[1]) {
+		case "week":
+			return today.AddDate(0, 0, daysUntil(today, time.Monday)), true
+		case "month":
+			return time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, today.Location()), true
+		}
+		return time.Date(today.Year()+1, 1, 1, 0, 0, 0, 0, today.Location()), true
+	}},
+	{regexp.MustCompile(`(?i)\b` + duePrep + `(?:the\s+)?end\s+of\s+(?:the\s+)?(week|month)\b`), func(m []string, today time.Time) (time.Time, bool) {
+		if strings.EqualFold(m[1], "week") {
+			if today.Weekday() == time.Friday {
+				return today, true
+			}
+			return today.AddDate(0, 0, daysUntil(today, time.Friday)), true
+		}
+		return time.Date(today.Year(), today.Month()+1, 0, 0, 0, 0, 0, today.Location()), true
+	}},
+	{regexp.MustCompile(`(?i)\b` + duePrep + `in\s+(\d+|an?|one|two|three)\s+(day|week|month)s?\b`), func(m []string, today time.Time) (time.Time, bool) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			n = map[string]int{"a": 1, "an": 1, "one": 1, "two": 2, "three": 3}[strings.ToLower(m[1])]
+		}
+		switch strings.ToLower(m[2]) {
+		case "day":
+			return today.AddDate(0, 0, n), true
+		case "week":
+			return today.AddDate(0, 0, 7*n), true
+		}
+		return today.AddDate(0, n, 0), true
+	}},
+	{regexp.MustCompile(`(?i)\b` + duePrep + `next\s+(` + weekdays + `)\b`), func(m []string, today time.Time) (time.Time, bool) {
+		return today.AddDate(0, 0, daysUntil(today, weekday(m[1]))+7), true
+	}},
+	{regexp.MustCompile(`(?i)\b` + dueOn + `(?:this\s+)?(` + weekdays + `)\b`), func(m []string, today time.Time) (time.Time, bool) {
+		return today.AddDate(0, 0, daysUntil(today, weekday(m[1]))), true
+	}},
+	{regexp.MustCompile(`(?i)\b` + dueOn + `(` + months + `)\.?\s+(\d{1,2})(?:st|nd|rd|th)?\b`), func(m []string, today time.Time) (time.Time, bool) {
+		return nextDate(today, month(m[1]), m[2])
+	}},
+	{regexp.MustCompile(`(?i)\b` + dueOn + `(\d{1,2})(?:st|nd|rd|th)?\s+(` + months + `)\b`), func(m []string, today time.Time) (time.Time, bool) {
+		return nextDate(today, month(m[2]), m[1])
+	}},
+	{regexp.MustCompile(`(?i)\b` + dueOn + `(\d{4}-\d{2}-\d{2})\b`), func(m []string, today time.Time) (time.Time, bool) {
+		d, err := time.ParseInLocation("2006-01-02", m[1], today.Location())
+		return d, err == nil
+	}},
+}
+
+// ParseDueText finds the first date phrase such as "by friday", "next
+// month" or "in 3 days" in the first line of text. It returns the day it
+// names (midnight in now's location) and the byte range of the phrase.
+func ParseDueText(text string, now time.Time) (due time.Time, start, end int, ok bool) {
+	line, _, _ := strings.Cut(text, "\n")
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	start = -1
+	for _, r := range dueRules {
+		loc := r.re.FindStringSubmatchIndex(line)
+		if loc == nil || start >= 0 && loc[0] >= start {
+			continue
+		}
+		m := make([]string, len(loc)/2)
+		for i := range m {
+			if loc[2*i] >= 0 {
+				m[i] = line[loc[2*i]:loc[2*i+1]]
+			}
+		}
+		if d, dok := r.resolve(m, today); dok {
+			due, start, end = d, loc[0], loc[1]
+		}
+	}
+	return due, start, end, start >= 0
+}
+
+// CaptureDue finds a date phrase in text (see ParseDueText) and returns it
+// as a due date for Task.Due, removing the phrase from the title unless
+// keep is set. Text that already has a Due trailer or names no date is
+// returned as is.
+func CaptureDue(text string, now time.Time, keep bool) (string, time.Time, bool) {
+	if _, ok := (Task{Text: text}).DueAt(now.Location()); ok {
+		return text, time.Time{}, false
+	}
+	due, start, end, ok := ParseDueText(text, now)
+	if !ok {
+		return text, time.Time{}, false
+	}
+	if !keep {
+		line, rest, multi := strings.Cut(text, "\n")
+		line = strings.Join(strings.Fields(line[:start]+" "+line[end:]), " ")
+		line = strings.Trim(line, " ,;:-")
+		if line == "" {
+			return text, time.Time{}, false
+		}
+		text = line
+		if multi {
+			text += "\n" + rest
+		}
+	}
+	return text, due, true
+}
+
+// daysUntil counts days from today to the next wd, 1 to 7.
+func daysUntil(today time.Time, wd time.Weekday) int {
+	d := (int(wd) - int(today.Weekday()) + 7) % 7
+	if d == 0 {
+		d = 7
+	}
+	return d
+}
+
+func weekday(name string) time.Weekday {
+	switch strings.ToLower(name)[:3] {
+	case "mon":
+		return time.Monday
+	case "tue":
+		return time.Tuesday
+	case "wed":
+		return time.Wednesday
+	case "thu":
+		return time.Thursday
+	case "fri":
+		return time.Friday
+	case "sat":
+		return time.Saturday
+	}
+	return time.Sunday
+}
+
+func month(name string) time.Month {
+	p := strings.ToLower(name)[:3]
+	for m := time.January; m <= time.December; m++ {
+		if strings.ToLower(m.String())[:3] == p {
+			return m
+		}
+	}
+	return 0
+}
+
+// nextDate is the next month/day on or after today.
+func nextDate(today time.Time, m time.Month, day string) (time.Time, bool) {
+	dd, err := strconv.Atoi(day)
+	if err != nil || m == 0 || dd < 1 || dd > 31 {
+		return time.Time{}, false
+	}
+	d := time.Date(today.Year(), m, dd, 0, 0, 0, 0, today.Location())
+	if d.Month() != m {
+		return time.Time{}, false
+	}
+	if d.Before(today) {
+		d = d.AddDate(1, 0, 0)
+	}
+	return d, true
+}
+
+// timeOfDay matches a trailing clock time: "5pm", "9:30am", "17:00", "at
+// noon".
+var timeOfDay = regexp.MustCompile(`(?i)(?:^|\s+)(?:at\s+)?(noon|midnight|(\d{1,2})(?::(\d{2}))?\s*(am|pm)|(\d{1,2}):(\d{2}))$`)
+
+// ParseWhen reads a date for --due, --scheduled and --wait: RFC 3339,
+// YYYY-MM-DD, a duration from now ("3d", "4h"), or a phrase ParseDueText
+// knows ("tomorrow", "fri", "next week", "mar 3"), optionally followed by a
+// time ("fri 5pm", "tomorrow 9:30", "noon"). dateOnly is set when no time
+// was given; the result is then midnight of that day in now's location.
+func ParseWhen(s string, now time.Time) (when time.Time, dateOnly bool, err error) {
+	s = strings.TrimSpace(s)
+	if ts, err := time.Parse(time.RFC3339, s); err == nil {
+		return ts, false, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04"} {
+		if ts, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return ts, false, nil
+		}
+	}
+	if d, err := ParseDuration(s); err == nil && d > 0 {
+		return now.Add(d), false, nil
+	}
+	day, clock := s, -1
+	if m := timeOfDay.FindStringSubmatch(s); m != nil {
+		day = strings.TrimSpace(s[:len(s)-len(m[0])])
+		if clock = clockMinutes(m); clock < 0 {
+			return time.Time{}, false, invalidf("invalid time in %q", s)
+		}
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	date := today
+	switch {
+	case day == "" && clock >= 0:
+	case day == "":
+		return time.Time{}, false, invalidf("empty date")
+	default:
+		if d, err := time.ParseInLocation("2006-01-02", day, now.Location()); err == nil {
+			date = d
+			break
+		}
+		phrase := "by " + day
+		d, start, end, ok := ParseDueText(phrase, now)
+		if !ok || start != 0 || end != len(phrase) {
+			return time.Time{}, false, invalidf("unrecognized date %q (try tomorrow, fri 5pm, 2026-03-01 or 3d)", s)
+		}
+		date = d
+	}
+	if clock < 0 {
+		return date, true, nil
+	}
+	return date.Add(time.Duration(clock) * time.Minute), false, nil
+}
+
+// clockMinutes converts a timeOfDay match to minutes after midnight, or -1.
+func clockMinutes(m []string) int {
+	switch strings.ToLower(m[1]) {
+	case "noon":
+		return 12 * 60
+	case "midnight":
+		return 0
+	}
+	hs, ms, ampm := m[2], m[3], strings.ToLower(m[4])
+	if ampm == "" {
+		hs, ms = m[5], m[6]
+	}
+	hour, _ := strconv.Atoi(hs)
+	minute := 0
+	if ms != "" {
+		minute, _ = strconv.Atoi(ms)
+	}
+	switch {
+	case ampm != "" && (hour < 1 || hour > 12):
+		return -1
+	case ampm == "pm" && hour != 12:
+		hour += 12
+	case ampm == "am" && hour == 12:
+		hour = 0
+	}
+	if hour > 23 || minute > 59 {
+		return -1
+	}
+	return hour*60 + minute
+}
@@ -0,0 +1,93 @@
+package utask
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDueText(t *testing.T) {
+	// A Thursday.
+	now := time.Date(2026, 3, 5, 15, 30, 0, 0, time.UTC)
+	cases := map[string]string{
+		"call bob tomorrow":              "2026-03-06",
+		"ship it by Friday":              "2026-03-06",
+		"ship it by thursday":            "2026-03-12",
+		"plan next Friday":               "2026-03-13",
+		"review budget next month":       "2026-04-01",
+		"book venue next week":           "2026-03-09",
+		"tidy up by end of the month":    "2026-03-31",
+		"send report end of week":        "2026-03-06",
+		"renew passport in 2 weeks":      "2026-03-19",
+		"pay rent on Apr 1st":            "2026-04-01",
+		"file taxes by 15 January":       "2027-01-15",
+		"launch on 2026-06-01":           "2026-06-01",
+		"today: water plants":            "2026-03-05",
+		"ship it by friday, or tomorrow": "2026-03-06",
+	}
+	for text, want := range cases {
+		due, _, _, ok := ParseDueText(text, now)
+		if !ok || due.Format("2006-01-02") != want {
+			t.Errorf("ParseDueText(%q) = %v, %v; want %s", text, due, ok, want)
+		}
+	}
+	for _, text := range []string{"fix friday deploy script", "update may notes", "week planning", "a\n\nbody due tomorrow"} {
+		if due, _, _, ok := ParseDueText(text, now); ok {
+			t.Errorf("ParseDueText(%q) = %v, want no date", text, due)
+		}
+	}
+}
+
+func TestCaptureDue(t *testing.T) {
+	now := time.Date(2026, 3, 5, 15, 30, 0, 0, time.UTC)
+	cases := []struct {
+		text      string
+		keep      bool
+		want, due string
+	}{
+		{"ship it by Friday", false, "ship it", "2026-03-06"},
+		{"ship it by Friday", true, "ship it by Friday", "2026-03-06"},
+		{"call bob tomorrow about the lease\n\nnotes", false, "call bob about the lease\n\nnotes", "2026-03-06"},
+		{"ship it by Friday\n\nDue: 2026-04-01", false, "ship it by Friday\n\nDue: 2026-04-01", ""},
+		{"today: water plants", false, "water plants", "2026-03-05"},
+		{"tomorrow", false, "tomorrow", ""},
+		{"no dates here", false, "no dates here", ""},
+	}
+	for _, c := range cases {
+		got, due, _ := CaptureDue(c.text, now, c.keep)
+		if got != c.want || FormatDue(due) != c.due {
+			t.Errorf("CaptureDue(%q, keep=%v) = %q, %q; want %q, %q", c.text, c.keep, got, FormatDue(due), c.want, c.due)
+		}
+	}
+}
+
+func TestParseWhen(t *testing.T) {
+	// A Thursday.
+	now := time.Date(2026, 3, 5, 15, 30, 0, 0, time.UTC)
+	cases := []struct {
+		in       string
+		want     string
+		dateOnly bool
+	}{
+		{"tomorrow", "2026-03-06T00:00:00Z", true},
+		{"fri 5pm", "2026-03-06T17:00:00Z", false},
+		{"next week", "2026-03-09T00:00:00Z", true},
+		{"mar 10 9:30am", "2026-03-10T09:30:00Z", false},
+		{"2026-04-01", "2026-04-01T00:00:00Z", true},
+		{"2026-04-01 08:15", "2026-04-01T08:15:00Z", false},
+		{"noon", "2026-03-05T12:00:00Z", false},
+		{"tomorrow at 12am", "2026-03-06T00:00:00Z", false},
+		{"3d", "2026-03-08T15:30:00Z", false},
+		{"2026-03-07T10:00:00+01:00", "2026-03-07T10:00:00+01:00", false},
+	}
+	for _, c := range cases {
+		got, dateOnly, err := ParseWhen(c.in, now)
+		if err != nil || got.Format(time.RFC3339) != c.want || dateOnly != c.dateOnly {
+			t.Errorf("ParseWhen(%q) = %s, %v, %v; want %s, %v", c.in, got.Format(time.RFC3339), dateOnly, err, c.want, c.dateOnly)
+		}
+	}
+	for _, bad := range []string{"", "someday", "fri 13pm", "call bob tomorrow"} {
+		if _, _, err := ParseWhen(bad, now); err == nil {
+			t.Errorf("ParseWhen(%q) accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package utask
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDuration extends time.ParseDuration with day ("d") and week ("w")
+// units, as used by --since style flags: "7d", "2w", "36h", "1d12h".
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	var total time.Duration
+	for s != "" {
+		i := strings.IndexAny(s, "dw")
+		if i < 0 {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return total + d, nil
+		}
+		n, err := strconv.Atoi(s[:i])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		unit := 24 * time.Hour
+		if s[i] == 'w' {
+			unit *= 7
+		}
+		total += time.Duration(n) * unit
+		s = s[i+1:]
+	}
+	if total == 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return total, nil
+}
+
+// FormatDuration renders d compactly at the two largest units ParseDuration
+// knows, e.g. "3d4h", "1h23m", "12m", or "<1m" for anything shorter.
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days, d := d/(24*time.Hour), d%(24*time.Hour)
+	hours, mins := d/time.Hour, (d%time.Hour)/time.Minute
+	switch {
+	case days > 0 && hours > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case days > 0:
+		return fmt.Sprintf("%dd", days)
+	case hours > 0 && mins > 0:
+		return fmt.Sprintf("%dh%dm", hours, mins)
+	case hours > 0:
+		return fmt.Sprintf("%dh", hours)
+	case mins > 0:
+		return fmt.Sprintf("%dm", mins)
+	}
+	return "<1m"
+}
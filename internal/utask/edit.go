@@ -0,0 +1,76 @@
+package utask
+
+import (
+	"strings"
+)
+
+// editFence opens and closes the front matter of an edit buffer.
+const editFence = "---"
+
+// EditBuffer renders a task for editing in a text editor: a front-matter
+// block with its tags and trailers, one "Key: Value" per line, then its text
+// without the trailer block. ParseEditBuffer reads it back.
+func EditBuffer(t Task) string {
+	var b strings.Builder
+	b.WriteString(editFence + "\n")
+	b.WriteString("tags:")
+	if len(t.Tags) > 0 {
+		b.WriteString(" " + strings.Join(t.Tags, ", "))
+	}
+	b.WriteString("\n")
+	body := strings.TrimSpace(t.Text)
+	if start, _, ok := trailerRegion(body); ok && len(t.TrailerDrops()) == 0 {
+		for _, tr := range t.Trailers() {
+			b.WriteString(tr.Key + ": " + tr.Value + "\n")
+		}
+		body = strings.TrimSpace(body[:start])
+	}
+	b.WriteString(editFence + "\n")
+	b.WriteString(body + "\n")
+	return b.String()
+}
+
+// ParseEditBuffer reads an edited EditBuffer back into task text, ending
+// with the front matter's trailers as its trailer block, and tags. A
+// buffer without front matter is all text and leaves tags nil.
+func ParseEditBuffer(buf string) (text string, tags []string, err error) {
+	buf = strings.ReplaceAll(buf, "\r\n", "\n")
+	var trailers []string
+	if rest, ok := strings.CutPrefix(buf, editFence+"\n"); ok {
+		front, body, ok := strings.Cut(rest, "\n"+editFence+"\n")
+		if !ok {
+			if front, ok = strings.CutSuffix(rest, "\n"+editFence); !ok {
+				return "", nil, invalidf("front matter is not closed with a %s line", editFence)
+			}
+			body = ""
+		}
+		buf = body
+		tags = []string{}
+		for i, line := range strings.Split(front, "\n") {
+			if isBlank(line) {
+				continue
+			}
+			tr, ok := parseTrailer(strings.TrimSpace(line))
+			if !ok {
+				return "", nil, invalidf("front matter line %d: want Key: Value, got %q", i+2, line)
+			}
+			if strings.EqualFold(tr.Key, "tags") {
+				for _, tag := range strings.Split(tr.Value, ",") {
+					if tag = strings.TrimSpace(tag); tag != "" {
+						tags = append(tags, tag)
+					}
+				}
+				continue
+			}
+			trailers = append(trailers, tr.Key+": "+strings.TrimSpace(tr.Value))
+		}
+	}
+	text = strings.TrimSpace(buf)
+	if text == "" {
+		return "", nil, invalidf("task text is empty")
+	}
+	if len(trailers) > 0 {
+		text += "\n\n" + strings.Join(trailers, "\n")
+	}
+	return text, tags, nil
+}
@@ -0,0 +1,41 @@
+package utask
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEditBuffer(t *testing.T) {
+	task := Task{Text: "Call bank\n\nAsk about fees.\n\nDue: 2026-03-01\nCloned-from: abc", Tags: []string{"home", "money"}}
+	buf := EditBuffer(task)
+	want := "---\ntags: home, money\nDue: 2026-03-01\nCloned-from: abc\n---\nCall bank\n\nAsk about fees.\n"
+	if buf != want {
+		t.Fatalf("EditBuffer = %q", buf)
+	}
+	text, tags, err := ParseEditBuffer(buf)
+	if err != nil || text != task.Text || !reflect.DeepEqual(tags, task.Tags) {
+		t.Fatalf("round trip = %q, %q, %v", text, tags, err)
+	}
+
+	// A final paragraph that isn't all trailers stays in the text.
+	prose := Task{Text: "Call bank\n\nAsk about fees: the new ones."}
+	if got := EditBuffer(prose); got != "---\ntags:\n---\n"+prose.Text+"\n" {
+		t.Fatalf("EditBuffer(prose) = %q", got)
+	}
+}
+
+func TestParseEditBuffer(t *testing.T) {
+	text, tags, err := ParseEditBuffer("---\r\ntags: Work,  urgent ,\r\n\r\nDue: fri\r\n---\r\nShip it\r\n")
+	if err != nil || text != "Ship it\n\nDue: fri" || !reflect.DeepEqual(tags, []string{"Work", "urgent"}) {
+		t.Fatalf("got %q, %q, %v", text, tags, err)
+	}
+	if text, tags, err := ParseEditBuffer("just text\n"); err != nil || text != "just text" || tags != nil {
+		t.Fatalf("no front matter: %q, %q, %v", text, tags, err)
+	}
+	for _, buf := range []string{"---\ntags: a\nShip it\n", "---\nnot a trailer\n---\nShip it\n", "---\ntags: a\n---\n\n"} {
+		if _, _, err := ParseEditBuffer(buf); !errors.Is(err, ErrValidation) {
+			t.Errorf("ParseEditBuffer(%q) err = %v", buf, err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package utask
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Errors returned by the Store. Match them with errors.Is; the concrete
+// values may wrap more detail.
+var (
+	// ErrNotFound means no task has the given id or id prefix.
+	ErrNotFound = errors.New("not found")
+	// ErrAmbiguousPrefix means an id prefix matched several tasks; the
+	// error is an *AmbiguousError listing them.
+	ErrAmbiguousPrefix = errors.New("ambiguous prefix")
+	// ErrConflict means a compare-and-set write lost a race with another
+	// writer.
+	ErrConflict = errors.New("conflict")
+	// ErrValidation means the input was rejected before anything was written.
+	ErrValidation = errors.New("invalid input")
+	// ErrForbidden means the caller's Role does not allow the operation.
+	ErrForbidden = errors.New("forbidden")
+	// ErrUnavailable means the NATS server could not be reached.
+	ErrUnavailable = errors.New("nats unavailable")
+)
+
+// AmbiguousError reports the tasks matched by an ambiguous id prefix.
+type AmbiguousError struct {
+	Prefix     string
+	Candidates []Candidate
+}
+
+func (e *AmbiguousError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ambiguous prefix %q matches %d tasks: ", e.Prefix, len(e.Candidates))
+	for i, c := range e.Candidates {
+		if i == maxDescribed {
+			fmt.Fprintf(&b, ", and %d more", len(e.Candidates)-i)
+			break
+		}
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(c.Short)
+		if c.Title != "" {
+			fmt.Fprintf(&b, " (%s)", c.Title)
+		}
+	}
+	return b.String()
+}
+
+// Is makes errors.Is(err, ErrAmbiguousPrefix) match.
+func (e *AmbiguousError) Is(target error) bool { return target == ErrAmbiguousPrefix }
+
+// validationError keeps the message of the underlying error while matching
+// ErrValidation.
+type validationError struct{ err error }
+
+func (e *validationError) Error() string        { return e.err.Error() }
+func (e *validationError) Unwrap() error        { return e.err }
+func (e *validationError) Is(target error) bool { return target == ErrValidation }
+
+// invalidf formats a validation error.
+func invalidf(format string, args ...any) error {
+	return &validationError{fmt.Errorf(format, args...)}
+}
+
+// casError maps a KV revision mismatch to ErrConflict, keeping other errors.
+func casError(what string, err error) error {
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		return fmt.Errorf("%s: %w", what, ErrConflict)
+	}
+	return fmt.Errorf("%s: %w", what, err)
+}
@@ -0,0 +1,103 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// HealthCheck is the outcome of one probe in a Health report.
+type HealthCheck struct {
+	Name      string  `json:"name"`
+	OK        bool    `json:"ok"`
+	LatencyMS float64 `json:"latency_ms"`
+	Detail    string  `json:"detail,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Health reports whether the Store can serve requests end to end.
+type Health struct {
+	OK     bool          `json:"ok"`
+	Checks []HealthCheck `json:"checks"`
+}
+
+// Live reports whether the NATS connection is up, without a round trip. It
+// suits liveness probes, which should not fail on a slow server. A sqlite
+// Store checks its database instead.
+func (s *Store) Live() error {
+	if s.db != nil {
+		return s.db.Ping()
+	}
+	if st := s.nc.Status(); st != nats.CONNECTED {
+		return fmt.Errorf("nats connection %s", st)
+	}
+	return nil
+}
+
+// Health probes the NATS connection (or SQLite file), each bucket, and a KV
+// watcher, the path change streams rely on. Every probe runs even if an
+// earlier one fails.
+func (s *Store) Health(ctx context.Context) Health {
+	h := Health{OK: true}
+	probe := func(name string, fn func() (string, error)) {
+		start := time.Now()
+		detail, err := fn()
+		c := HealthCheck{Name: name, OK: err == nil, LatencyMS: float64(time.Since(start).Microseconds()) / 1000, Detail: detail}
+		if err != nil {
+			c.Error = err.Error()
+			h.OK = false
+		}
+		h.Checks = append(h.Checks, c)
+	}
+	if s.db != nil {
+		probe("sqlite", func() (string, error) { return s.dbPath, s.db.PingContext(ctx) })
+	} else {
+		probe("nats", s.probeNATS)
+	}
+	for _, kv := range []jetstream.KeyValue{s.tasksKV, s.tagsKV, s.metaKV} {
+		probe("bucket "+kv.Bucket(), func() (string, error) {
+			st, err := kv.Status(ctx)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%d values", st.Values()), nil
+		})
+	}
+	probe("watch", func() (string, error) { return "", s.probeWatch(ctx) })
+	return h
+}
+
+// probeWatch starts a watcher on one meta key and waits for its first
+// delivery, which arrives once the server has set up the consumer.
+func (s *Store) probeWatch(ctx context.Context) error {
+	w, err := s.metaKV.Watch(ctx, keyspaceKey, jetstream.MetaOnly())
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+	select {
+	case _, ok := <-w.Updates():
+		if !ok {
+			return errors.New("watcher closed before its first update")
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// probeNATS checks the connection with a round trip.
+func (s *Store) probeNATS() (string, error) {
+	if err := s.Live(); err != nil {
+		return "", err
+	}
+	rtt, err := s.nc.RTT()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s rtt %s", s.nc.ConnectedUrlRedacted(), rtt.Round(time.Microsecond)), nil
+}
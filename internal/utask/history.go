@@ -0,0 +1,221 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// DefaultTaskHistory is how many revisions of each task the tasks bucket
+// keeps when Options.TaskHistory is zero.
+const DefaultTaskHistory = 16
+
+// TaskHistory returns the versions of a task recorded in the audit trail,
+// oldest first: one per write, as the task stood after it. It reaches back
+// as far as Options.AuditRetention, past the revisions the tasks bucket
+// keeps (see History). Events recorded before revisions were kept carry
+// Revision 0.
+func (s *Store) TaskHistory(ctx context.Context, id string) ([]Activity, error) {
+	events, err := s.Audit(ctx, AuditFilter{Task: id})
+	if err != nil {
+		return nil, err
+	}
+	return versions(events), nil
+}
+
+// versions keeps one event per write: an update can log several events
+// (closed and assigned, say) for the same revision. Deletes change no
+// content and are dropped.
+func versions(events []Activity) []Activity {
+	out := []Activity{}
+	for _, a := range events {
+		if a.Kind == ActivityDeleted {
+			continue
+		}
+		if n := len(out); n > 0 && a.Task.Revision != 0 && out[n-1].Task.Revision == a.Task.Revision {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// FindRevision returns the version at revision rev.
+func FindRevision(history []Activity, rev uint64) (Task, error) {
+	for _, a := range history {
+		if a.Task.Revision == rev {
+			return a.Task, nil
+		}
+	}
+	return Task{}, fmt.Errorf("revision %d: %w (the audit trail may have expired it)", rev, ErrNotFound)
+}
+
+// TaskRevision is one stored version of a task.
+type TaskRevision struct {
+	Revision uint64    `json:"revision"`
+	When     time.Time `json:"when"`
+	// Deleted marks the revision that deleted the task; Task is then the
+	// version it deleted.
+	Deleted bool `json:"deleted,omitempty"`
+	Task    Task `json:"task"`
+}
+
+// History returns the stored revisions of a task, oldest first, deleted
+// tasks included. The tasks bucket keeps the last Options.TaskHistory
+// revisions; earlier ones come from the audit trail while it holds them.
+func (s *Store) History(ctx context.Context, id string) ([]TaskRevision, error) {
+	entries, err := s.tasksKV.History(ctx, s.taskKey(id))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		entries, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var kept []TaskRevision
+	for _, e := range entries {
+		r := TaskRevision{Revision: e.Revision(), When: e.Created(), Deleted: e.Operation() != jetstream.KeyValuePut}
+		if !r.Deleted {
+			if r.Task, err = s.decodeTask(e.Value()); err != nil {
+				return nil, fmt.Errorf("task %s revision %d: %w", id, e.Revision(), err)
+			}
+			r.Task.Revision = e.Revision()
+		}
+		kept = append(kept, r)
+	}
+	audited, err := s.TaskHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var out []TaskRevision
+	for _, a := range audited {
+		if len(kept) > 0 && (a.Task.Revision >= kept[0].Revision || a.Task.Revision == 0 && !a.When.Before(kept[0].When)) {
+			break
+		}
+		out = append(out, TaskRevision{Revision: a.Task.Revision, When: a.When, Task: a.Task})
+	}
+	out = append(out, kept...)
+	var last Task
+	for i := range out {
+		if out[i].Deleted {
+			out[i].Task = last
+		}
+		last = out[i].Task
+	}
+	if len(out) == 0 || !s.canSee(ctx, last) {
+		return nil, fmt.Errorf("task %s: %w", id, ErrNotFound)
+	}
+	return out, nil
+}
+
+// FieldChange is one field that differs between two versions of a task,
+// rendered as in RecordLines; an empty From or To means unset.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+}
+
+// Changes lists the fields that differ from a to b: text and details
+// first, then the RecordLines fields in their order.
+func Changes(a, b Task) []FieldChange {
+	var out []FieldChange
+	if a.Text != b.Text {
+		out = append(out, FieldChange{Field: "text", From: a.Text, To: b.Text})
+	}
+	if a.Description != b.Description {
+		out = append(out, FieldChange{Field: "details", From: a.Description, To: b.Description})
+	}
+	from, to := fieldValues(a), fieldValues(b)
+	for _, f := range fieldOrder {
+		if from[f] != to[f] {
+			out = append(out, FieldChange{Field: f, From: from[f], To: to[f]})
+		}
+	}
+	return out
+}
+
+// fieldValues maps each set field to its value, repeated fields
+// (annotations) joined by newlines.
+func fieldValues(t Task) map[string]string {
+	m := map[string]string{}
+	for _, f := range record(t) {
+		if v, ok := m[f[0]]; ok {
+			m[f[0]] = v + "\n" + f[1]
+		} else {
+			m[f[0]] = f[1]
+		}
+	}
+	return m
+}
+
+// RecordLines renders a task as lines for diffing: its text and details,
+// then one "field: value" line per set field in a fixed order.
+func RecordLines(t Task) []string {
+	lines := strings.Split(strings.TrimRight(t.Text, "\n"), "\n")
+	if t.Description != "" {
+		lines = append(lines, "")
+		lines = append(lines, strings.Split(t.Description, "\n")...)
+	}
+	lines = append(lines, "")
+	for _, f := range record(t) {
+		lines = append(lines, f[0]+": "+f[1])
+	}
+	return lines
+}
+
+// fieldOrder is the order record lists fields in.
+var fieldOrder = []string{"done", "status", "tags", "contexts", "depends_on", "recur", "priority", "estimate_minutes", "assignee", "private", "review_requested_by", "delegation", "snoozed_until", "scheduled", "worked", "annotation", "updated_by"}
+
+// record returns a task's set fields, besides its text, as name/value
+// pairs in fieldOrder.
+func record(t Task) [][2]string {
+	var fields [][2]string
+	field := func(name, value string) {
+		if value != "" {
+			fields = append(fields, [2]string{name, value})
+		}
+	}
+	field("done", fmt.Sprint(t.Done))
+	if t.Status != "" {
+		field("status", string(t.State()))
+	}
+	field("tags", strings.Join(t.Tags, ", "))
+	field("contexts", strings.Join(t.Contexts, " "))
+	field("depends_on", strings.Join(t.DependsOn, " "))
+	field("recur", t.Recur)
+	if t.Priority != 0 {
+		field("priority", fmt.Sprint(t.Priority))
+	}
+	if t.EstimateMinutes != 0 {
+		field("estimate_minutes", fmt.Sprint(t.EstimateMinutes))
+	}
+	field("assignee", t.Assignee)
+	if t.Private {
+		field("private", "true")
+	}
+	field("review_requested_by", t.ReviewRequestedBy)
+	if d := t.Delegation; d != nil {
+		field("delegation", fmt.Sprintf("%s by %s since %s", d.To, d.By, d.Since.Format(time.RFC3339)))
+	}
+	if t.SnoozedUntil != nil {
+		field("snoozed_until", t.SnoozedUntil.Format(time.RFC3339))
+	}
+	if t.Due != nil {
+		field("due", FormatDue(*t.Due))
+	}
+	if t.Scheduled != nil {
+		field("scheduled", t.Scheduled.Format(time.RFC3339))
+	}
+	if len(t.Worked) > 0 {
+		field("worked", fmt.Sprintf("%d intervals, %s", len(t.Worked), FormatDuration(t.TimeSpent())))
+	}
+	for _, a := range t.Annotations {
+		field("annotation", fmt.Sprintf("%s %s: %s", a.When.Format(time.RFC3339), a.By, strings.ReplaceAll(a.Text, "\n", " ")))
+	}
+	field("updated_by", t.UpdatedBy)
+	return fields
+}
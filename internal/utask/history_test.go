@@ -0,0 +1,82 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestVersions(t *testing.T) {
+	events := []Activity{
+		{Kind: ActivityCreated, Task: Task{ID: "a", Revision: 3}},
+		{Kind: ActivityClosed, Task: Task{ID: "a", Revision: 5, Done: true}},
+		{Kind: ActivityAssigned, Task: Task{ID: "a", Revision: 5, Done: true}},
+		{Kind: ActivityDeleted, Task: Task{ID: "a"}},
+	}
+	h := versions(events)
+	if len(h) != 2 || h[1].Kind != ActivityClosed {
+		t.Fatalf("versions = %+v", h)
+	}
+	if got, err := FindRevision(h, 5); err != nil || !got.Done {
+		t.Fatalf("FindRevision(5) = %+v, %v", got, err)
+	}
+	if _, err := FindRevision(h, 4); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("FindRevision(4) err = %v", err)
+	}
+}
+
+func TestRecordLines(t *testing.T) {
+	got := RecordLines(Task{Text: "call bank\n\nDue: 2026-03-01\n", Tags: []string{"home", "money"}, Priority: 2, Assignee: "ada"})
+	want := []string{"call bank", "", "Due: 2026-03-01", "", "done: false", "tags: home, money", "priority: 2", "assignee: ada"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	a := Task{Text: "call bank", Tags: []string{"home"}, Assignee: "ada"}
+	b := Task{Text: "call bank today", Tags: []string{"home", "money"}, Priority: 2, Status: StatusInProgress}
+	got := Changes(a, b)
+	want := []FieldChange{
+		{Field: "text", From: "call bank", To: "call bank today"},
+		{Field: "status", To: "in-progress"},
+		{Field: "tags", From: "home", To: "home, money"},
+		{Field: "priority", To: "2"},
+		{Field: "assignee", From: "ada"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Changes = %+v", got)
+	}
+	if got := Changes(a, a); len(got) != 0 {
+		t.Fatalf("Changes(a, a) = %+v", got)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	task, _, err := s.CreateTask(ctx, TaskInput{Text: "call bank"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := "call bank today"
+	updated, err := s.UpdateTask(ctx, task.ID, UpdateSet{Text: &text})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The revisions come from the tasks bucket, not the audit trail.
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM audit`); err != nil {
+		t.Fatal(err)
+	}
+	h, err := s.History(ctx, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h) != 2 || h[0].Revision != task.Revision || h[0].Task.Text != "call bank" || h[1].Revision != updated.Revision || h[1].Task.Text != text {
+		t.Fatalf("History = %+v", h)
+	}
+	if _, err := s.History(ctx, "nope"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("History(nope) err = %v", err)
+	}
+}
@@ -0,0 +1,370 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ListFilter selects tasks for ListIter. Tag is a single-tag filter; Any and
+// All behave like Query's ANY/ALL tag sets. Zero values match everything.
+// Limit (0 = unlimited) caps the number of tasks yielded after the status
+// and assignee filters, so no bodies are fetched past the last needed match.
+type ListFilter struct {
+	Tag    string
+	Any    []string
+	All    []string
+	Status Status
+	// Assignee keeps tasks assigned to this identity (see Task.Assignee);
+	// Unassigned keeps only tasks with no assignee.
+	Assignee   string
+	Unassigned bool
+	// DelegatedBy keeps open tasks this identity delegated to someone else.
+	DelegatedBy string
+	// Snoozed keeps only open tasks snoozed right now; HideSnoozed drops
+	// them instead.
+	Snoozed     bool
+	HideSnoozed bool
+	// Context keeps tasks carrying this context, e.g. "@home".
+	Context string
+	// DueBefore keeps tasks due at or before this time; Overdue keeps open
+	// tasks past their due time.
+	DueBefore time.Time
+	Overdue   bool
+	// Since and Until keep tasks last changed within them (see
+	// Task.Changed); either may be zero for an open end.
+	Since, Until time.Time
+	// Ready keeps open tasks whose dependencies are all closed.
+	Ready bool
+	// Recurring keeps open tasks with a recurrence rule.
+	Recurring bool
+	// Trailers keeps tasks carrying each of these trailers (see
+	// ParseTrailerFilter); an empty Value matches any value.
+	Trailers []Trailer
+	// Sort orders the tasks (see SortTasks), Reverse flips the order. A
+	// sorted listing reads every match before yielding the first; the
+	// empty key keeps the bucket's order and streams.
+	Sort    SortKey
+	Reverse bool
+	Limit   int
+}
+
+// TaskIterator yields tasks one at a time:
+//
+//	it, err := store.ListIter(ctx, filter)
+//	if err != nil { ... }
+//	defer it.Close()
+//	for it.Next() {
+//		t := it.Task()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type TaskIterator interface {
+	// Next advances to the next matching task, returning false when the
+	// listing is exhausted or an error occurred.
+	Next() bool
+	// Task returns the current task.
+	Task() Task
+	// Err returns the first error encountered, if any.
+	Err() error
+	// Close releases the underlying key lister.
+	Close() error
+	// Orphans returns the ids seen so far that the tag index lists but
+	// whose tasks no longer exist, and how many of them were removed from
+	// the index (Options.HealOrphans).
+	Orphans() (ids []string, healed int)
+}
+
+// ListIter returns an iterator over the tasks matching f. Task bodies are
+// fetched lazily, so memory stays flat regardless of profile size, unless
+// f.Sort asks for an order.
+func (s *Store) ListIter(ctx context.Context, f ListFilter) (TaskIterator, error) {
+	if f.Sort != "" {
+		return s.sortedIter(ctx, f)
+	}
+	anyTags, allTags := normalizeTags(f.Any), normalizeTags(f.All)
+	if tag := strings.ToLower(strings.TrimSpace(f.Tag)); tag != "" {
+		anyTags = append(anyTags, tag)
+	}
+	it := &taskIter{ctx: ctx, s: s, filter: f, limit: f.Limit}
+	var (
+		ids     []string
+		err     error
+		indexed bool
+	)
+	tagged := len(anyTags) > 0 || len(allTags) > 0
+	if !tagged && len(f.Trailers) > 0 {
+		if ids, indexed, err = s.trailerSetIDs(ctx, f.Trailers); err != nil {
+			return nil, err
+		}
+	}
+	if !tagged && !indexed && f.Status != "" {
+		if ids, indexed, err = s.statusIDs(ctx, f.Status); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case tagged:
+		ids, err = s.tagSetIDs(ctx, anyTags, allTags)
+		it.tags = append(append([]string{}, anyTags...), allTags...)
+	case indexed:
+		// Only tasks the trailer or status index lists are read.
+	case s.Keyspace() == KeyspaceSharded:
+		// Shards are listed in parallel; only ids are held in memory.
+		ids, err = s.taskIDs(ctx)
+	default:
+		lister, err := s.tasksKV.ListKeys(ctx)
+		if err != nil {
+			return nil, err
+		}
+		keys := lister.Keys()
+		it.next = func() (string, bool) {
+			k, ok := <-keys
+			return keyID(k), ok
+		}
+		it.stop = lister.Stop
+		return it, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	it.next = func() (string, bool) {
+		if len(ids) == 0 {
+			return "", false
+		}
+		id := ids[0]
+		ids = ids[1:]
+		return id, true
+	}
+	return it, nil
+}
+
+// tagSetIDs resolves ANY/ALL tag sets to task ids via the tag index.
+func (s *Store) tagSetIDs(ctx context.Context, anyTags, allTags []string) ([]string, error) {
+	return planTagSets(anyTags, allTags, func(tag string) (map[string]struct{}, error) {
+		return s.readTagIDs(ctx, tag)
+	})
+}
+
+// planTagSets combines tag index sets without touching the tasks bucket. ALL
+// sets are intersected smallest first; ANY sets are then unioned but only
+// for ids that survived the intersection. Reads stop as soon as the result
+// is known to be empty. The ids are returned sorted.
+func planTagSets(anyTags, allTags []string, read func(string) (map[string]struct{}, error)) ([]string, error) {
+	var result map[string]struct{}
+	if len(allTags) > 0 {
+		sets := make([]map[string]struct{}, 0, len(allTags))
+		for _, tag := range allTags {
+			ids, err := read(tag)
+			if err != nil {
+				return nil, err
+			}
+			if len(ids) == 0 {
+				return []string{}, nil
+			}
+			sets = append(sets, ids)
+		}
+		sort.Slice(sets, func(i, j int) bool { return len(sets[i]) < len(sets[j]) })
+		result = make(map[string]struct{}, len(sets[0]))
+		for id := range sets[0] {
+			result[id] = struct{}{}
+		}
+		for _, ids := range sets[1:] {
+			for id := range result {
+				if _, ok := ids[id]; !ok {
+					delete(result, id)
+				}
+			}
+			if len(result) == 0 {
+				return []string{}, nil
+			}
+		}
+	}
+	if len(anyTags) > 0 {
+		union := map[string]struct{}{}
+		for _, tag := range anyTags {
+			ids, err := read(tag)
+			if err != nil {
+				return nil, err
+			}
+			for id := range ids {
+				if result != nil {
+					if _, ok := result[id]; !ok {
+						continue
+					}
+				}
+				union[id] = struct{}{}
+			}
+			if result != nil && len(union) == len(result) {
+				break // every candidate already matched
+			}
+		}
+		result = union
+	}
+	out := make([]string, 0, len(result))
+	for id := range result {
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+type taskIter struct {
+	ctx    context.Context
+	s      *Store
+	filter ListFilter
+	limit  int
+	count  int
+	// tags are the index entries ids came from; empty for full scans.
+	tags    []string
+	orphans []string
+	healed  int
+	// deps caches whether dependencies are closed, for Ready.
+	deps map[string]bool
+	next func() (string, bool)
+	stop func() error
+	cur  Task
+	err  error
+	done bool
+}
+
+func (it *taskIter) Next() bool {
+	if it.done || (it.limit > 0 && it.count >= it.limit) {
+		return false
+	}
+	for {
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			it.done = true
+			return false
+		}
+		k, ok := it.next()
+		if !ok {
+			it.done = true
+			return false
+		}
+		if k == "" {
+			continue
+		}
+		t, _, err := it.s.getTask(it.ctx, k)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) && len(it.tags) > 0 {
+				it.orphan(k)
+			}
+			continue
+		}
+		if !it.s.canSee(it.ctx, t) || !matchStatus(t, it.filter.Status) || !matchAssigneeFilter(t, it.filter) || !matchDelegatedBy(t, it.filter) || !matchSnoozed(t, it.filter) || !matchContext(t, it.filter) || !matchDue(t, it.filter) || !matchChanged(t, it.filter) || !matchReady(t, it.filter, it.closed) || !matchRecurring(t, it.filter) || !matchTrailers(t, it.filter) {
+			continue
+		}
+		it.cur = t
+		it.count++
+		return true
+	}
+}
+
+// closed reports whether a dependency is closed, reading each one once.
+// Deleted dependencies count as closed.
+func (it *taskIter) closed(id string) bool {
+	if done, ok := it.deps[id]; ok {
+		return done
+	}
+	t, _, err := it.s.getTask(it.ctx, id)
+	done := errors.Is(err, ErrNotFound) || err == nil && t.Done
+	if it.deps == nil {
+		it.deps = map[string]bool{}
+	}
+	it.deps[id] = done
+	return done
+}
+
+func (it *taskIter) Task() Task { return it.cur }
+func (it *taskIter) Err() error { return it.err }
+
+func (it *taskIter) Close() error {
+	it.done = true
+	if it.stop != nil {
+		return it.stop()
+	}
+	return nil
+}
+
+// orphan records an index entry without a task and, when the Store heals
+// orphans, drops it from every tag the listing read.
+func (it *taskIter) orphan(id string) {
+	it.orphans = append(it.orphans, id)
+	if !it.s.opts.HealOrphans {
+		return
+	}
+	for _, tag := range it.tags {
+		if err := it.s.removeTagIDs(it.ctx, tag, []string{id}); err != nil {
+			it.s.log.WarnContext(it.ctx, "stale tag index entry not removed", "op", "heal", "task", id, "tag", tag, "err", err)
+			return
+		}
+	}
+	it.s.log.InfoContext(it.ctx, "removed stale tag index entry", "op", "heal", "task", id, "tags", it.tags)
+	it.healed++
+}
+
+func (it *taskIter) Orphans() ([]string, int) { return it.orphans, it.healed }
+
+// sortedIter reads every task f matches, sorts them and yields up to
+// f.Limit of them.
+func (s *Store) sortedIter(ctx context.Context, f ListFilter) (TaskIterator, error) {
+	key, limit := f.Sort, f.Limit
+	f.Sort, f.Limit = "", 0
+	inner, err := s.ListIter(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	defer inner.Close()
+	var tasks []Task
+	for inner.Next() {
+		tasks = append(tasks, inner.Task())
+	}
+	if err := inner.Err(); err != nil {
+		return nil, err
+	}
+	SortTasks(tasks, key, f.Reverse, time.Local)
+	if limit > 0 && len(tasks) > limit {
+		tasks = tasks[:limit]
+	}
+	it := &sliceIter{tasks: tasks}
+	it.orphans, it.healed = inner.Orphans()
+	return it, nil
+}
+
+// sliceIter is a TaskIterator over tasks already read.
+type sliceIter struct {
+	tasks   []Task
+	cur     Task
+	orphans []string
+	healed  int
+}
+
+func (it *sliceIter) Next() bool {
+	if len(it.tasks) == 0 {
+		return false
+	}
+	it.cur, it.tasks = it.tasks[0], it.tasks[1:]
+	return true
+}
+
+func (it *sliceIter) Task() Task               { return it.cur }
+func (it *sliceIter) Err() error               { return nil }
+func (it *sliceIter) Close() error             { it.tasks = nil; return nil }
+func (it *sliceIter) Orphans() ([]string, int) { return it.orphans, it.healed }
+
+// OrphanWarning summarizes TaskIterator.Orphans for users; it is empty when
+// the index was consistent.
+func OrphanWarning(ids []string, healed int) string {
+	switch {
+	case len(ids) == 0:
+		return ""
+	case healed == len(ids):
+		return fmt.Sprintf("removed %d stale tag index entries for deleted tasks", healed)
+	default:
+		return fmt.Sprintf("%d tag index entries point at deleted tasks (%d removed); run `ut rebuild-index`", len(ids), healed)
+	}
+}
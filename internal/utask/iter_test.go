@@ -0,0 +1,54 @@
+package utask
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPlanTagSets(t *testing.T) {
+	index := map[string][]string{
+		"work":   {"a", "b", "c", "d"},
+		"urgent": {"b", "c"},
+		"ops":    {"c", "e"},
+		"empty":  {},
+	}
+	var reads []string
+	read := func(tag string) (map[string]struct{}, error) {
+		reads = append(reads, tag)
+		out := map[string]struct{}{}
+		for _, id := range index[tag] {
+			out[id] = struct{}{}
+		}
+		return out, nil
+	}
+	check := func(anyTags, allTags []string, want string) {
+		t.Helper()
+		reads = nil
+		got, err := planTagSets(anyTags, allTags, read)
+		if err != nil || strings.Join(got, ",") != want {
+			t.Fatalf("any=%v all=%v: got %v err=%v, want %s", anyTags, allTags, got, err, want)
+		}
+	}
+
+	check(nil, []string{"work", "urgent"}, "b,c")
+	check([]string{"urgent", "ops"}, nil, "b,c,e")
+	check([]string{"ops"}, []string{"work"}, "c")
+
+	// An empty ALL set short-circuits every remaining read.
+	check([]string{"ops"}, []string{"empty", "work"}, "")
+	if len(reads) != 1 {
+		t.Fatalf("expected a single index read, got %v", reads)
+	}
+}
+
+func TestOrphanWarning(t *testing.T) {
+	if got := OrphanWarning(nil, 0); got != "" {
+		t.Fatalf("no orphans: got %q", got)
+	}
+	if got := OrphanWarning([]string{"a", "b"}, 2); !strings.Contains(got, "removed 2") {
+		t.Fatalf("healed: got %q", got)
+	}
+	if got := OrphanWarning([]string{"a", "b"}, 0); !strings.Contains(got, "2 tag index entries") || !strings.Contains(got, "rebuild-index") {
+		t.Fatalf("unhealed: got %q", got)
+	}
+}
@@ -0,0 +1,306 @@
+package utask
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// JournalOp names a queued offline change.
+type JournalOp string
+
+const (
+	JournalCreate JournalOp = "create"
+	JournalUpdate JournalOp = "update"
+	JournalClose  JournalOp = "close"
+	JournalReopen JournalOp = "reopen"
+	JournalDelete JournalOp = "delete"
+)
+
+// JournalEntry is a change made while NATS was unreachable, replayed by
+// Store.Replay on the next connection. BaseRevision is the revision of the
+// task in the local snapshot when the change was made, so edits that raced
+// with someone else's are reported as conflicts instead of overwriting.
+type JournalEntry struct {
+	Op           JournalOp  `json:"op"`
+	ID           string     `json:"id"`
+	BaseRevision uint64     `json:"base_revision,omitempty"`
+	Input        *TaskInput `json:"input,omitempty"`
+	Set          *UpdateSet `json:"set,omitempty"`
+	At           time.Time  `json:"at"`
+}
+
+// JournalPath returns the default offline journal for a profile.
+func JournalPath(profile string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".utask", "journal", profile+".jsonl"), nil
+}
+
+// AppendJournal adds e to the journal at path.
+func AppendJournal(path string, e JournalEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	b, _ := json.Marshal(e)
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadJournal reads the queued entries; a missing journal has none.
+func LoadJournal(path string) ([]JournalEntry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []JournalEntry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		var e JournalEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("journal %s line %d: %w", path, n, err)
+		}
+		out = append(out, e)
+	}
+	return out, sc.Err()
+}
+
+// SaveJournal replaces the journal with entries, removing it when empty.
+func SaveJournal(path string, entries []JournalEntry) error {
+	if len(entries) == 0 {
+		err := os.Remove(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.Remove(tmp); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for _, e := range entries {
+		if err := AppendJournal(tmp, e); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp, path)
+}
+
+// ReplayResult is the outcome of one replayed entry.
+type ReplayResult struct {
+	Entry JournalEntry
+	Task  Task
+	Err   error
+}
+
+// Replay applies journal entries in order. An entry the server rejects
+// (a conflict, a task gone or forbidden, invalid input) is reported in its
+// result and replay moves on; any other error, such as losing the
+// connection again, stops replay so the rest can be retried. It returns
+// the results for the entries it got through.
+func (s *Store) Replay(ctx context.Context, entries []JournalEntry) []ReplayResult {
+	// Later entries for a task were queued against the same base revision
+	// as earlier ones; once an earlier one lands, expect its revision.
+	rebased := map[string]uint64{}
+	var out []ReplayResult
+	for _, e := range entries {
+		ifRev := e.BaseRevision
+		if rev, ok := rebased[e.ID]; ok {
+			ifRev = rev
+		}
+		var (
+			t   Task
+			err error
+		)
+		switch e.Op {
+		case JournalCreate:
+			if e.Input == nil {
+				err = invalidf("create without input")
+				break
+			}
+			t, _, err = s.createTask(ctx, *e.Input, e.ID)
+		case JournalUpdate:
+			if e.Set == nil {
+				err = invalidf("update without fields")
+				break
+			}
+			set := *e.Set
+			set.IfRevision = ifRev
+			t, err = s.UpdateTask(ctx, e.ID, set)
+		case JournalClose:
+			t, _, err = s.CloseTaskIf(ctx, e.ID, ifRev)
+		case JournalReopen:
+			t, _, err = s.ReopenTaskIf(ctx, e.ID, ifRev)
+		case JournalDelete:
+			_, err = s.DeleteTaskIf(ctx, e.ID, ifRev)
+			t = Task{ID: e.ID}
+		default:
+			err = invalidf("unknown journal op %q", e.Op)
+		}
+		if err != nil && !rejected(err) {
+			return out
+		}
+		if err == nil {
+			rebased[e.ID] = t.Revision
+		}
+		out = append(out, ReplayResult{Entry: e, Task: t, Err: err})
+	}
+	return out
+}
+
+// rejected reports whether err is the server refusing a change, as opposed
+// to failing to reach it.
+func rejected(err error) bool {
+	for _, target := range []error{ErrConflict, ErrNotFound, ErrValidation, ErrForbidden} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve matches an id or unique id prefix against the snapshot's tasks.
+func (snap *Snapshot) Resolve(ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", invalidf("empty prefix")
+	}
+	keys := make([]string, 0, len(snap.Tasks))
+	for id := range snap.Tasks {
+		keys = append(keys, id)
+	}
+	return matchPrefix(keys, ref)
+}
+
+// Apply makes a queued change to the snapshot so offline reads show it,
+// returning the task as changed. The task keeps its Revision, which stays
+// the base for further offline edits. by is the caller's identity.
+func (snap *Snapshot) Apply(e JournalEntry, by string) (Task, error) {
+	if e.Op == JournalCreate {
+		if e.Input == nil {
+			return Task{}, invalidf("create without input")
+		}
+		c, id := NormalizeInput(*e.Input)
+		if e.Input.ForceNew {
+			id = RandomID()
+		}
+		if t, ok := snap.Tasks[id]; ok {
+			return t, nil
+		}
+		t := Task{ID: id, Text: c.Text, Description: c.Details, Status: StatusOpen, Tags: c.Tags, Priority: c.Priority, EstimateMinutes: c.EstimateMinutes,
+			Created: e.At.UTC().Format(time.RFC3339), Modified: stamp(e.At), CreatedBy: by, Assignee: strings.TrimSpace(e.Input.Assignee), Private: e.Input.Private,
+			Due: dueTime(e.Input.Due), Scheduled: optionalTime(e.Input.Scheduled), SnoozedUntil: optionalTime(e.Input.Wait)}
+		contexts, err := NormalizeContexts(e.Input.Contexts)
+		if err != nil {
+			return Task{}, err
+		}
+		if len(contexts) > 0 {
+			t.Contexts = contexts
+		}
+		for _, ref := range e.Input.DependsOn {
+			dep, err := snap.Resolve(ref)
+			if err != nil {
+				return Task{}, invalidf("dependency %s: %v", ref, err)
+			}
+			t.DependsOn = append(t.DependsOn, dep)
+		}
+		if t.Recur, err = normalizeRecur(e.Input.Recur); err != nil {
+			return Task{}, err
+		}
+		snap.Tasks[id] = t
+		return t, nil
+	}
+	t, ok := snap.Tasks[e.ID]
+	if !ok {
+		return Task{}, fmt.Errorf("task %s: %w", e.ID, ErrNotFound)
+	}
+	wasDone := t.Done
+	switch e.Op {
+	case JournalUpdate:
+		if e.Set == nil {
+			return Task{}, invalidf("update without fields")
+		}
+		if e.Set.Text != nil {
+			t.Text = strings.TrimSpace(*e.Set.Text)
+		}
+		if e.Set.Details != nil {
+			t.Description = strings.TrimSpace(*e.Set.Details)
+		}
+		if e.Set.Done != nil && *e.Set.Done != t.Done {
+			if *e.Set.Done {
+				t.setStatus(StatusDone)
+			} else {
+				t.setStatus(StatusOpen)
+			}
+		}
+		if e.Set.Tags != nil {
+			t.Tags = normalizeTags(*e.Set.Tags)
+		}
+		if e.Set.Priority != nil {
+			t.Priority = *e.Set.Priority
+		}
+		if e.Set.Assignee != nil {
+			t.Assignee = strings.TrimSpace(*e.Set.Assignee)
+		}
+		if e.Set.Private != nil {
+			t.Private = *e.Set.Private
+		}
+		if e.Set.Contexts != nil {
+			contexts, err := NormalizeContexts(*e.Set.Contexts)
+			if err != nil {
+				return Task{}, err
+			}
+			t.Contexts = nil
+			if len(contexts) > 0 {
+				t.Contexts = contexts
+			}
+		}
+		e.Set.applyDates(&t)
+		if e.Set.Recur != nil {
+			recur, err := normalizeRecur(*e.Set.Recur)
+			if err != nil {
+				return Task{}, err
+			}
+			t.Recur = recur
+		}
+	case JournalClose:
+		t.setStatus(StatusDone)
+	case JournalReopen:
+		t.setStatus(StatusOpen)
+	case JournalDelete:
+		delete(snap.Tasks, e.ID)
+		return t, nil
+	default:
+		return Task{}, invalidf("unknown journal op %q", e.Op)
+	}
+	t.touch(by, e.At)
+	if t.Done && !wasDone {
+		// Replayed closes happened when the entry was written, not now.
+		t.Closed = t.Modified
+	}
+	snap.Tasks[e.ID] = t
+	return t, nil
+}
@@ -0,0 +1,81 @@
+package utask
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJournalRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal", "p.jsonl")
+	if got, err := LoadJournal(path); err != nil || len(got) != 0 {
+		t.Fatalf("missing journal: %v, %v", got, err)
+	}
+	text := "new text"
+	entries := []JournalEntry{
+		{Op: JournalCreate, ID: "a", Input: &TaskInput{Text: "buy milk", Tags: []string{"errand"}}},
+		{Op: JournalUpdate, ID: "b", BaseRevision: 7, Set: &UpdateSet{Text: &text}},
+		{Op: JournalClose, ID: "b", BaseRevision: 7},
+	}
+	for _, e := range entries {
+		if err := AppendJournal(path, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := LoadJournal(path)
+	if err != nil || len(got) != 3 {
+		t.Fatalf("LoadJournal = %v, %v", got, err)
+	}
+	if got[0].Input.Text != "buy milk" || *got[1].Set.Text != text || got[2].BaseRevision != 7 {
+		t.Fatalf("unexpected entries %+v", got)
+	}
+	if err := SaveJournal(path, got[2:]); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := LoadJournal(path); len(got) != 1 || got[0].Op != JournalClose {
+		t.Fatalf("after SaveJournal: %+v", got)
+	}
+	if err := SaveJournal(path, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := LoadJournal(path); len(got) != 0 {
+		t.Fatalf("journal not removed: %+v", got)
+	}
+}
+
+func TestSnapshotApply(t *testing.T) {
+	snap := &Snapshot{Tasks: map[string]Task{"abc123": {ID: "abc123", Text: "old", Revision: 4}}}
+	at := time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC)
+	created, err := snap.Apply(JournalEntry{Op: JournalCreate, Input: &TaskInput{Text: "Buy milk", Tags: []string{"Errand"}}, At: at}, "ada")
+	if err != nil || created.ID == "" || created.CreatedBy != "ada" || created.Created != "2026-03-05T09:00:00Z" {
+		t.Fatalf("create: %+v, %v", created, err)
+	}
+	if _, id := NormalizeInput(TaskInput{Text: "Buy milk", Tags: []string{"Errand"}}); id != created.ID {
+		t.Fatalf("offline id %s differs from the server's %s", created.ID, id)
+	}
+	id, err := snap.Resolve("abc")
+	if err != nil || id != "abc123" {
+		t.Fatalf("Resolve = %q, %v", id, err)
+	}
+	text, tags := "new", []string{"A", "a", " b "}
+	got, err := snap.Apply(JournalEntry{Op: JournalUpdate, ID: id, Set: &UpdateSet{Text: &text, Tags: &tags}}, "bob")
+	if err != nil || got.Text != "new" || len(got.Tags) != 2 || got.Revision != 4 || got.UpdatedBy != "bob" {
+		t.Fatalf("update: %+v, %v", got, err)
+	}
+	if got, _ := snap.Apply(JournalEntry{Op: JournalClose, ID: id}, "bob"); !got.Done {
+		t.Fatal("close did not mark the task done")
+	}
+	if _, err := snap.Apply(JournalEntry{Op: JournalDelete, ID: id}, "bob"); err != nil || len(snap.Tasks) != 1 {
+		t.Fatalf("delete: %v, %d tasks left", err, len(snap.Tasks))
+	}
+	if _, err := snap.Apply(JournalEntry{Op: JournalReopen, ID: id}, "bob"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("reopen of a deleted task: %v", err)
+	}
+}
+
+func TestRejected(t *testing.T) {
+	if !rejected(checkRevision("a", 1, 2)) || !rejected(invalidf("x")) || rejected(ErrUnavailable) || rejected(errors.New("timeout")) {
+		t.Fatal("rejected misclassifies errors")
+	}
+}
@@ -0,0 +1,90 @@
+package utask
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxJSONLLine bounds a single JSONL record; large pasted notes fit comfortably.
+const maxJSONLLine = 16 << 20
+
+// ValidateTask checks that a task record is well-formed for storage.
+func ValidateTask(t Task) error {
+	if !isTaskID(t.ID) {
+		return invalidf("invalid id %q: want 128 hex chars", t.ID)
+	}
+	if strings.TrimSpace(t.Text) == "" {
+		return invalidf("task %s: empty text", t.ID)
+	}
+	if _, err := time.Parse(time.RFC3339, t.Created); err != nil {
+		return invalidf("task %s: invalid created %q: %w", t.ID, t.Created, err)
+	}
+	return nil
+}
+
+func isTaskID(s string) bool {
+	if len(s) != 128 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// WriteJSONL writes one task as a single JSON line.
+func WriteJSONL(w io.Writer, t Task) error {
+	t.Revision = 0 // revisions are per-profile, so exports omit them
+	t.Schema = SchemaVersion
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	_, err = w.Write(b)
+	return err
+}
+
+// ReadJSONL decodes one task per line from r and passes each to fn along with
+// its 1-based line number. Blank lines are skipped. Records without an id get
+// the deterministic id of their normalized content, and records without a
+// created timestamp are stamped with the current time. Every record is
+// validated before fn sees it.
+func ReadJSONL(r io.Reader, fn func(line int, t Task) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxJSONLLine)
+	n := 0
+	for sc.Scan() {
+		n++
+		raw := strings.TrimSpace(sc.Text())
+		if raw == "" {
+			continue
+		}
+		t, _, err := decodeJSONTask([]byte(raw))
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		t.Text = strings.TrimSpace(t.Text)
+		if t.ID == "" {
+			t.ID = ContentID(t)
+		}
+		if t.Created == "" {
+			t.Created = time.Now().UTC().Format(time.RFC3339)
+		}
+		if err := ValidateTask(t); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		if err := fn(n, t); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	return sc.Err()
+}
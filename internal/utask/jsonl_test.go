@@ -0,0 +1,46 @@
+package utask
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestJSONLRoundTrip(t *testing.T) {
+	_, id := NormalizeInput(TaskInput{Text: "Buy milk", Tags: []string{"errand"}})
+	in := Task{ID: id, Text: "Buy milk", Tags: []string{"errand"}, Created: "2025-08-26T13:40:00Z"}
+
+	var buf bytes.Buffer
+	if err := WriteJSONL(&buf, in); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	// Records without id/created are completed on read.
+	buf.WriteString("\n{\"text\":\"Walk dog\",\"tags\":[\"home\"]}\n")
+
+	var got []Task
+	err := ReadJSONL(&buf, func(line int, t Task) error {
+		got = append(got, t)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 tasks, got %d", len(got))
+	}
+	if got[0].ID != id || got[0].Text != "Buy milk" {
+		t.Fatalf("unexpected first task: %+v", got[0])
+	}
+	_, wantID := NormalizeInput(TaskInput{Text: "Walk dog", Tags: []string{"home"}})
+	if got[1].ID != wantID || got[1].Created == "" {
+		t.Fatalf("expected derived id and created, got %+v", got[1])
+	}
+}
+
+func TestReadJSONLRejectsInvalid(t *testing.T) {
+	in := strings.NewReader("{\"id\":\"abc\",\"text\":\"x\",\"created\":\"2025-08-26T13:40:00Z\"}\n")
+	err := ReadJSONL(in, func(int, Task) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Fatalf("expected line-numbered validation error, got %v", err)
+	}
+}
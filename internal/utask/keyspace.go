@@ -0,0 +1,218 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Keyspace selects how task ids map to keys in the tasks bucket. Flat keys
+// are the bare id. Sharded keys prefix the id with its first two hex digits
+// ("ab.<id>"), so prefix resolution lists a single shard server-side and
+// full scans fan out across shards in parallel.
+type Keyspace string
+
+const (
+	KeyspaceFlat    Keyspace = "flat"
+	KeyspaceSharded Keyspace = "sharded"
+)
+
+// keyspaceKey records a profile's layout in the meta bucket; absent means flat.
+const keyspaceKey = "keyspace"
+
+// scanWorkers bounds concurrent shard listings.
+const scanWorkers = 16
+
+// ParseKeyspace validates a configured keyspace name ("" means flat).
+func ParseKeyspace(s string) (Keyspace, error) {
+	switch Keyspace(s) {
+	case "", KeyspaceFlat:
+		return KeyspaceFlat, nil
+	case KeyspaceSharded:
+		return KeyspaceSharded, nil
+	}
+	return "", fmt.Errorf("invalid keyspace: %s (want flat|sharded)", s)
+}
+
+// keyFor returns the tasks bucket key for id under ks.
+func keyFor(ks Keyspace, id string) string {
+	if ks == KeyspaceSharded && len(id) > 2 {
+		return id[:2] + "." + id
+	}
+	return id
+}
+
+// keyID recovers the task id from a key in either layout. Ids are hex, so a
+// '.' at position 2 can only be a shard separator.
+func keyID(key string) string {
+	if len(key) > 3 && key[2] == '.' {
+		return key[3:]
+	}
+	return key
+}
+
+func (s *Store) taskKey(id string) string { return keyFor(s.Keyspace(), id) }
+
+// loadKeyspace reads the profile's recorded layout. A profile without one is
+// flat, except that an empty profile adopts want so new profiles can start
+// sharded.
+func (s *Store) loadKeyspace(ctx context.Context, want Keyspace) error {
+	e, err := s.metaKV.Get(ctx, keyspaceKey)
+	if err == nil {
+		ks, perr := ParseKeyspace(string(e.Value()))
+		if perr != nil {
+			return perr
+		}
+		s.keyspace.Store(ks)
+		return nil
+	}
+	if !errors.Is(err, jetstream.ErrKeyNotFound) {
+		return err
+	}
+	s.keyspace.Store(KeyspaceFlat)
+	if want != KeyspaceSharded {
+		return nil
+	}
+	st, err := s.tasksKV.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if st.Values() > 0 {
+		// Existing data stays put until `ut maintain --keyspace sharded`.
+		return nil
+	}
+	if _, err := s.metaKV.Put(ctx, keyspaceKey, []byte(KeyspaceSharded)); err != nil {
+		return err
+	}
+	s.keyspace.Store(KeyspaceSharded)
+	return nil
+}
+
+// Keyspace reports the profile's key layout.
+func (s *Store) Keyspace() Keyspace {
+	if ks, ok := s.keyspace.Load().(Keyspace); ok {
+		return ks
+	}
+	return KeyspaceFlat
+}
+
+// listKeys returns the keys matching a subject filter such as "ab.>".
+func (s *Store) listKeys(ctx context.Context, filter string) ([]string, error) {
+	w, err := s.tasksKV.Watch(ctx, filter, jetstream.MetaOnly(), jetstream.IgnoreDeletes())
+	if err != nil {
+		return nil, err
+	}
+	defer w.Stop()
+	var keys []string
+	for e := range w.Updates() {
+		if e == nil {
+			break
+		}
+		keys = append(keys, e.Key())
+	}
+	return keys, ctx.Err()
+}
+
+// taskIDs returns every task id. In the sharded layout each shard is listed
+// separately across scanWorkers goroutines.
+func (s *Store) taskIDs(ctx context.Context) ([]string, error) {
+	if s.Keyspace() != KeyspaceSharded {
+		keys, err := s.tasksKV.Keys(ctx)
+		if err != nil {
+			if errors.Is(err, jetstream.ErrNoKeysFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		for i, k := range keys {
+			keys[i] = keyID(k)
+		}
+		return keys, nil
+	}
+	shards := make(chan string)
+	go func() {
+		defer close(shards)
+		for i := 0; i < 256; i++ {
+			select {
+			case shards <- fmt.Sprintf("%02x.>", i):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var (
+		mu       sync.Mutex
+		ids      []string
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	for w := 0; w < scanWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for filter := range shards {
+				keys, err := s.listKeys(ctx, filter)
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				for _, k := range keys {
+					ids = append(ids, keyID(k))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return ids, firstErr
+}
+
+// Reshard moves every task to the target key layout and records it. This
+// Store switches layouts once every task has moved and other processes
+// switch when they reopen the profile, so run it while nothing else uses
+// the profile. Tasks are copied to their new key before the old key is
+// deleted, so an interrupted run is safe to repeat.
+func (s *Store) Reshard(ctx context.Context, target Keyspace) (int, error) {
+	if err := s.authorize(ctx, "reshard", RoleAdmin); err != nil {
+		return 0, err
+	}
+	keys, err := s.tasksKV.Keys(ctx)
+	if err != nil && !errors.Is(err, jetstream.ErrNoKeysFound) {
+		return 0, err
+	}
+	moved := 0
+	for _, k := range keys {
+		if err := ctx.Err(); err != nil {
+			return moved, err
+		}
+		nk := keyFor(target, keyID(k))
+		if nk == k {
+			continue
+		}
+		e, err := s.tasksKV.Get(ctx, k)
+		if err != nil {
+			continue
+		}
+		if _, err := s.tasksKV.Put(ctx, nk, e.Value()); err != nil {
+			return moved, fmt.Errorf("move %s: %w", k, err)
+		}
+		if err := s.tasksKV.Delete(ctx, k); err != nil {
+			return moved, fmt.Errorf("move %s: %w", k, err)
+		}
+		// Snapshots replay the stream by id, so the old key's delete would
+		// drop the moved task; writing it again after the delete keeps the
+		// last event for the id a put.
+		if _, err := s.tasksKV.Put(ctx, nk, e.Value()); err != nil {
+			return moved, fmt.Errorf("move %s: %w", k, err)
+		}
+		moved++
+	}
+	if _, err := s.metaKV.Put(ctx, keyspaceKey, []byte(target)); err != nil {
+		return moved, err
+	}
+	s.keyspace.Store(target)
+	return moved, nil
+}
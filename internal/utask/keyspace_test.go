@@ -0,0 +1,28 @@
+package utask
+
+import "testing"
+
+func TestKeyForRoundTrip(t *testing.T) {
+	id := "ab12cd"
+	if got := keyFor(KeyspaceFlat, id); got != id {
+		t.Fatalf("flat key = %q", got)
+	}
+	sharded := keyFor(KeyspaceSharded, id)
+	if sharded != "ab.ab12cd" {
+		t.Fatalf("sharded key = %q", sharded)
+	}
+	for _, k := range []string{id, sharded} {
+		if got := keyID(k); got != id {
+			t.Fatalf("keyID(%q) = %q, want %q", k, got, id)
+		}
+	}
+}
+
+func TestParseKeyspace(t *testing.T) {
+	if ks, err := ParseKeyspace(""); err != nil || ks != KeyspaceFlat {
+		t.Fatalf("empty keyspace = %q, %v", ks, err)
+	}
+	if _, err := ParseKeyspace("hashed"); err == nil {
+		t.Fatal("expected error for unknown keyspace")
+	}
+}
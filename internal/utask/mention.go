@@ -0,0 +1,31 @@
+package utask
+
+import (
+	"regexp"
+	"strings"
+)
+
+// mentionRe matches "@name" at the start of the text or after a character
+// that can't be part of a word or email address, so "ada@example.org" is
+// not a mention.
+var mentionRe = regexp.MustCompile(`(?:^|[^\w.@])@([A-Za-z][\w.-]*)`)
+
+// Mentions returns the distinct @names in text, lowercased, in order of
+// first appearance. Trailing dots (end of a sentence) are not part of a name.
+func Mentions(text string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, m := range mentionRe.FindAllStringSubmatch(text, -1) {
+		name := strings.ToLower(strings.TrimRight(m[1], ".-"))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		out = append(out, name)
+	}
+	return out
+}
+
+// Mentions returns the @names in the task's text, including its body and
+// trailers.
+func (t Task) Mentions() []string { return Mentions(t.Text) }
@@ -0,0 +1,20 @@
+package utask
+
+import "testing"
+
+func TestMentions(t *testing.T) {
+	cases := map[string][]string{
+		"ping @ada about it":                  {"ada"},
+		"@Bob and @ada, then @bob again":      {"bob", "ada"},
+		"mail ada@example.org":                nil,
+		"thanks @grace.hopper.":               {"grace.hopper"},
+		"(cc @ops-team)\n\nReviewed-by: @ada": {"ops-team", "ada"},
+		"@ @1x":                               nil,
+	}
+	for in, want := range cases {
+		got := Mentions(in)
+		if !equalStrings(got, want) {
+			t.Fatalf("Mentions(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
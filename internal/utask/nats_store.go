@@ -2,21 +2,112 @@ package utask
 
 import (
 	"context"
-	"encoding/json"
+	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 )
 
+// Store is a utask profile backed by NATS JetStream KV, or by a local
+// SQLite file standing in for it (see OpenSQLite). A Store is safe for
+// concurrent use by multiple goroutines: per-call state travels in the
+// context and arguments, configuration is fixed at Open, and writes that
+// race on the same key are serialized by KV compare-and-set.
 type Store struct {
 	nc      *nats.Conn
-	js      nats.JetStreamContext
-	tasksKV nats.KeyValue
-	tagsKV  nats.KeyValue
+	closed  chan struct{}
+	js      jetstream.JetStream
+	tasksKV jetstream.KeyValue
+	tagsKV  jetstream.KeyValue
+	metaKV  jetstream.KeyValue
+	syncKV  jetstream.KeyValue
+	undoKV  jetstream.KeyValue
+	trashKV jetstream.KeyValue
+	// trailersKV holds the trailer index (see trailerindex.go).
+	trailersKV jetstream.KeyValue
+	// db is set instead of nc and js for the sqlite driver.
+	db      *sql.DB
+	dbPath  string
 	ns      string
+	opts    Options
+	log     *slog.Logger
+	// keyspace holds the profile's Keyspace; Reshard swaps it while other
+	// goroutines may be reading it.
+	keyspace atomic.Value
+	// legacyTags is set while the tag index may still hold newline-joined
+	// values (see loadTagIndex).
+	legacyTags atomic.Bool
+	// writes counts successful task writes (see Writes).
+	writes atomic.Uint64
+}
+
+// Options tunes a Store. The zero value matches Open's defaults.
+type Options struct {
+	// Encoding for newly written task values (default JSON).
+	Encoding Encoding
+	// CompressAbove compresses encoded task values larger than this many
+	// bytes (0 = never) using Compression (default zstd).
+	CompressAbove int
+	Compression   Compression
+	// Timeout bounds each NATS operation whose context has no deadline
+	// (0 = the client default of 5s), so a hung server can't block forever.
+	Timeout time.Duration
+	// HealOrphans removes tag index entries whose task no longer exists
+	// when a listing comes across them.
+	HealOrphans bool
+	// Limits bounds text and tags accepted by CreateTask, UpdateTask and
+	// PutTask.
+	Limits Limits
+	// Keyspace is the key layout for a new, empty profile (default flat).
+	// Existing profiles keep their recorded layout; see Store.Reshard.
+	Keyspace Keyspace
+	// IDStrategy is how CreateTask picks ids (default IDContent).
+	IDStrategy IDStrategy
+	// Identity names the author of this Store's writes, e.g.
+	// "Ada <ada@example.org>". It is stamped on tasks as CreatedBy/UpdatedBy
+	// and logged with each write.
+	Identity string
+	// AuditRetention is how long audit events are kept (default
+	// DefaultAuditRetention). It applies when the audit stream is created.
+	AuditRetention time.Duration
+	// TaskHistory is how many revisions of each task the tasks bucket
+	// keeps, for History (default DefaultTaskHistory, at most 64). Open
+	// applies it to existing buckets too; OpenSQLite keeps as many.
+	TaskHistory int
+	// ReviewTags are tags whose tasks need a second identity's approval to
+	// close: closing them only requests review (see ApproveTask).
+	ReviewTags []string
+	// Role limits what this Store may do (default RoleAdmin). Calls can
+	// narrow it further with WithRole.
+	Role Role
+	// Key, when set, encrypts task text before it leaves this process (see
+	// Key). Every client of the profile needs the same key.
+	Key *Key
+	// UndoDepth is how many recent mutations Undo can revert (0 =
+	// DefaultUndoDepth, negative = none are recorded).
+	UndoDepth int
+	// ReconnectWait is the pause between attempts to reconnect after the
+	// connection drops (0 = the client default of 2s), and MaxReconnects
+	// how many are made before giving up (0 = the client default of 60,
+	// negative = forever).
+	ReconnectWait time.Duration
+	MaxReconnects int
+	// Retries is how many more times reads and plain puts are tried after
+	// a NATS timeout (0 = DefaultRetries, negative = never).
+	Retries int
+	// FailFast makes operations fail at once with an ErrUnavailable naming
+	// the server, profile and bucket while the connection is down, rather
+	// than waiting for a reconnect or a timeout, and turns Retries off.
+	FailFast bool
+	// Logger receives debug lines for writes and warnings for index
+	// problems, tagged with the profile (default slog.Default()).
+	Logger *slog.Logger
 }
 
 func bucketNames(ns string) (tasks, tags string) {
@@ -25,87 +116,299 @@ func bucketNames(ns string) (tasks, tags string) {
 	return fmt.Sprintf("utask_tasks_%s", ns), fmt.Sprintf("utask_tags_%s", ns)
 }
 
+// metaBucketName holds per-profile bookkeeping such as cached tag counts.
+func metaBucketName(ns string) string { return fmt.Sprintf("utask_meta_%s", ns) }
+
+// ensureKV binds to a KV bucket, creating it on first use. The bucket
+// keeps history revisions per key; 0 keeps only the latest.
+func ensureKV(ctx context.Context, js jetstream.JetStream, name string, history int) (jetstream.KeyValue, error) {
+	kv, err := js.KeyValue(ctx, name)
+	if errors.Is(err, jetstream.ErrBucketNotFound) {
+		kv, err = js.CreateKeyValue(ctx, jetstream.KeyValueConfig{Bucket: name, History: uint8(history)})
+	}
+	return kv, err
+}
+
+// setHistory makes an existing bucket keep history revisions per key, for
+// buckets created before tasks kept any or with another setting.
+func setHistory(ctx context.Context, js jetstream.JetStream, name string, history int) error {
+	st, err := js.Stream(ctx, "KV_"+name)
+	if err != nil {
+		return err
+	}
+	cfg := st.CachedInfo().Config
+	if cfg.MaxMsgsPerSubject == int64(history) {
+		return nil
+	}
+	cfg.MaxMsgsPerSubject = int64(history)
+	_, err = js.UpdateStream(ctx, cfg)
+	return err
+}
+
+// taskHistory is Options.TaskHistory with its default and limit applied.
+func (o Options) taskHistory() int {
+	switch {
+	case o.TaskHistory <= 0:
+		return DefaultTaskHistory
+	case o.TaskHistory > jetstream.KeyValueMaxHistory:
+		return jetstream.KeyValueMaxHistory
+	}
+	return o.TaskHistory
+}
+
 // Open connects to NATS, ensures KV buckets for the namespace, and returns a Store.
 func Open(ctx context.Context, url, namespace string) (*Store, error) {
+	return OpenWithOptions(ctx, url, namespace, Options{})
+}
+
+// OpenWithOptions is Open with explicit Options.
+func OpenWithOptions(ctx context.Context, url, namespace string, opts Options) (*Store, error) {
 	if namespace == "" {
 		namespace = "default"
 	}
-	nc, err := nats.Connect(url)
+	closed := make(chan struct{})
+	natsOpts := natsOptions(opts, opts.logger(namespace), closed)
+	var jsOpts []jetstream.JetStreamOpt
+	if opts.Timeout > 0 {
+		jsOpts = append(jsOpts, jetstream.WithDefaultTimeout(opts.Timeout))
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	tasksName, tagsName := bucketNames(namespace)
+	nc, err := nats.Connect(url, natsOpts...)
 	if err != nil {
-		return nil, fmt.Errorf("connect nats: %w", err)
+		return nil, unreachable(url, namespace, tasksName, err)
 	}
-	js, err := nc.JetStream()
+	js, err := jetstream.New(nc, jsOpts...)
 	if err != nil {
 		nc.Close()
 		return nil, fmt.Errorf("jetstream: %w", err)
 	}
-	tasksName, tagsName := bucketNames(namespace)
 
 	// Ensure KV buckets
-	tasksKV, err := js.KeyValue(tasksName)
-	if err != nil {
-		if errors.Is(err, nats.ErrBucketNotFound) {
-			tasksKV, err = js.CreateKeyValue(&nats.KeyValueConfig{Bucket: tasksName})
+	ensure := func(what, name string, history int) (jetstream.KeyValue, error) {
+		kv, err := ensureKV(ctx, js, name, history)
+		if transient(ctx, err) {
+			return nil, unreachable(url, namespace, name, err)
 		}
 		if err != nil {
-			nc.Close()
-			return nil, fmt.Errorf("ensure tasks bucket: %w", err)
+			return nil, fmt.Errorf("ensure %s bucket: %w", what, err)
 		}
+		return kv, nil
 	}
-	tagsKV, err := js.KeyValue(tagsName)
+	tasksKV, err := ensure("tasks", tasksName, opts.taskHistory())
 	if err != nil {
-		if errors.Is(err, nats.ErrBucketNotFound) {
-			tagsKV, err = js.CreateKeyValue(&nats.KeyValueConfig{Bucket: tagsName})
-		}
-		if err != nil {
-			nc.Close()
-			return nil, fmt.Errorf("ensure tags bucket: %w", err)
-		}
+		nc.Close()
+		return nil, err
+	}
+	tagsKV, err := ensure("tags", tagsName, 0)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	metaKV, err := ensure("meta", metaBucketName(namespace), 0)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	syncKV, err := ensure("sync", syncBucketName(namespace), 0)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	undoKV, err := ensure("undo", undoBucketName(namespace), 0)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	trashKV, err := ensure("trash", trashBucketName(namespace), 0)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	trailersKV, err := ensure("trailers", trailerBucketName(namespace), 0)
+	if err != nil {
+		nc.Close()
+		return nil, err
 	}
 
-	s := &Store{nc: nc, js: js, tasksKV: tasksKV, tagsKV: tagsKV, ns: namespace}
+	s := &Store{nc: nc, closed: closed, js: js, ns: namespace, opts: opts}
+	s.tasksKV, s.tagsKV = s.resilient(tasksKV, url), s.resilient(tagsKV, url)
+	s.metaKV, s.syncKV = s.resilient(metaKV, url), s.resilient(syncKV, url)
+	s.undoKV, s.trashKV = s.resilient(undoKV, url), s.resilient(trashKV, url)
+	s.trailersKV = s.resilient(trailersKV, url)
+	if err := s.init(ctx); err != nil {
+		nc.Close()
+		return nil, err
+	}
+	if err := setHistory(ctx, js, tasksName, opts.taskHistory()); err != nil {
+		// Users without stream admin rights still get a working Store.
+		s.log.WarnContext(ctx, "could not set task history", "bucket", tasksName, "err", err)
+	}
 	return s, nil
 }
 
-func (s *Store) Close() { s.nc.Drain(); s.nc.Close() }
+// logger is Options.Logger, or slog.Default(), tagged with the profile.
+func (o Options) logger(ns string) *slog.Logger {
+	if o.Logger == nil {
+		return slog.Default().With("profile", ns)
+	}
+	return o.Logger.With("profile", ns)
+}
+
+// init finishes a Store whose buckets are bound: its logger and keyspace.
+func (s *Store) init(ctx context.Context) error {
+	s.log = s.opts.logger(s.ns)
+	if err := s.loadKeyspace(ctx, s.opts.Keyspace); err != nil {
+		return fmt.Errorf("load keyspace: %w", err)
+	}
+	if err := s.loadTagIndex(ctx); err != nil {
+		return fmt.Errorf("load tag index: %w", err)
+	}
+	if err := s.initStatusIndex(ctx); err != nil {
+		return fmt.Errorf("init status index: %w", err)
+	}
+	if err := s.initTrailerIndex(ctx); err != nil {
+		return fmt.Errorf("init trailer index: %w", err)
+	}
+	return nil
+}
+
+// Profile returns the profile the Store was opened for.
+func (s *Store) Profile() string { return s.ns }
+
+// Writes returns how many task writes the Store has made since it was
+// opened, so a caller can tell whether a cached copy has fallen behind.
+func (s *Store) Writes() uint64 { return s.writes.Load() }
+
+// drainWait bounds how long Close waits for in-flight operations.
+const drainWait = 5 * time.Second
+
+// Close drains the connection, letting in-flight operations finish, and
+// waits up to drainWait for it to close. A sqlite Store closes its file.
+func (s *Store) Close() {
+	if s.db != nil {
+		s.db.Close()
+		return
+	}
+	if err := s.nc.Drain(); err != nil {
+		s.nc.Close()
+		return
+	}
+	select {
+	case <-s.closed:
+	case <-time.After(drainWait):
+		s.nc.Close()
+	}
+}
 
 // CreateTask creates a task idempotently. Returns the task and whether it already existed.
+// Under IDRandom, or with in.ForceNew, the task gets a random id and so is
+// always new.
 func (s *Store) CreateTask(ctx context.Context, in TaskInput) (Task, bool, error) {
-	c, id := NormalizeInput(in)
+	return s.createTask(ctx, in, "")
+}
+
+// createTask is CreateTask that uses id, when set, for a task that would
+// get a random one; Replay passes the id a queued create was shown with.
+func (s *Store) createTask(ctx context.Context, in TaskInput, id string) (Task, bool, error) {
+	if err := s.authorize(ctx, "create", RoleContributor); err != nil {
+		return Task{}, false, err
+	}
+	c, contentID := NormalizeInput(in)
+	switch {
+	case !in.ForceNew && s.opts.IDStrategy != IDRandom:
+		id = contentID
+	case !isTaskID(id):
+		id = RandomID()
+	}
+	if err := s.opts.Limits.Validate(c.Text, c.Tags); err != nil {
+		return Task{}, false, err
+	}
+	if err := s.opts.Limits.validateDetails(c.Details); err != nil {
+		return Task{}, false, err
+	}
+	contexts, err := NormalizeContexts(in.Contexts)
+	if err != nil {
+		return Task{}, false, err
+	}
+	deps, err := s.resolveDeps(ctx, id, in.DependsOn)
+	if err != nil {
+		return Task{}, false, err
+	}
+	recur, err := normalizeRecur(in.Recur)
+	if err != nil {
+		return Task{}, false, err
+	}
 	now := time.Now().UTC()
 	t := Task{
 		ID:              id,
 		Text:            c.Text,
-		Done:            false,
+		Description:     c.Details,
+		Status:          StatusOpen,
 		Created:         now.Format(time.RFC3339),
+		Modified:        stamp(now),
 		Tags:            c.Tags,
 		Priority:        c.Priority,
 		EstimateMinutes: c.EstimateMinutes,
+		CreatedBy:       s.identity(ctx),
+		Assignee:        strings.TrimSpace(in.Assignee),
+		Private:         in.Private,
+		Due:             dueTime(in.Due),
+		Scheduled:       optionalTime(in.Scheduled),
+		SnoozedUntil:    optionalTime(in.Wait),
+		DependsOn:       deps,
+		Recur:           recur,
+	}
+	if len(contexts) > 0 {
+		t.Contexts = contexts
+	}
+	if t.Number, err = s.nextNumber(ctx); err != nil {
+		return Task{}, false, err
+	}
+	b, err := encodeTask(t, s.opts)
+	if err != nil {
+		return Task{}, false, fmt.Errorf("encode task: %w", err)
 	}
-	b, _ := json.Marshal(t)
 
 	// Create only if not exists
-	if _, err := s.tasksKV.Create(id, b); err != nil {
-		if errors.Is(err, nats.ErrKeyExists) {
+	rev, err := s.tasksKV.Create(ctx, s.taskKey(id), b)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyExists) {
 			// Fetch existing
-			e, gerr := s.tasksKV.Get(id)
+			e, gerr := s.tasksKV.Get(ctx, s.taskKey(id))
 			if gerr != nil {
 				return Task{}, false, fmt.Errorf("get existing: %w", gerr)
 			}
-			var existing Task
-			if jerr := json.Unmarshal(e.Value(), &existing); jerr != nil {
+			existing, jerr := s.decodeTask(e.Value())
+			if jerr != nil {
 				return Task{}, false, fmt.Errorf("decode existing: %w", jerr)
 			}
+			existing.Revision = e.Revision()
+			if !s.canSee(ctx, existing) {
+				return Task{}, false, fmt.Errorf("create: %w: task %s exists and is private", ErrForbidden, id)
+			}
 			return existing, true, nil
 		}
 		return Task{}, false, fmt.Errorf("create task: %w", err)
 	}
+	t.Revision = rev
+	s.logWrite(ctx, "create", id, rev)
+	if _, err := s.claimNumber(ctx, t.Number, id); err != nil {
+		// The task keeps its number; `ut maintain` restores the index.
+		s.log.WarnContext(ctx, "task number index update failed", "task", id, "number", t.Number, "err", err)
+	}
+	s.audit(ctx, ActivityCreated, t, "")
+	s.recordUndo(ctx, "create", undoChange(nil, &t))
+	s.reindexTrailers(ctx, t.ID, &t)
 
 	// Update tag index
-	for _, tag := range t.Tags {
-		if err := s.appendTagID(tag, t.ID); err != nil {
-			return Task{}, false, err
-		}
+	if err := s.reindexTags(ctx, t.ID, nil, t.Tags); err != nil {
+		return t, false, err
+	}
+	if err := s.reindexStatus(ctx, t.ID, nil, &t); err != nil {
+		return t, false, err
 	}
 
     // Events removed
@@ -113,97 +416,119 @@ func (s *Store) CreateTask(ctx context.Context, in TaskInput) (Task, bool, error
 	return t, false, nil
 }
 
-func (s *Store) appendTagID(tag, id string) error {
-	// Try update existing with CAS
-	e, err := s.tagsKV.Get(tag)
-	if err != nil {
-		if errors.Is(err, nats.ErrKeyNotFound) {
-			// Create new
-			v := id
-			if _, err := s.tagsKV.Create(tag, []byte(v)); err != nil && !errors.Is(err, nats.ErrKeyExists) {
-				return fmt.Errorf("create tag index: %w", err)
-			}
-			if errors.Is(err, nats.ErrKeyExists) {
-				// Race: fall through to update path
-				return s.appendTagID(tag, id)
-			}
-			return nil
-		}
-		return fmt.Errorf("get tag index: %w", err)
-	}
-	// Parse existing
-	lines := strings.Split(string(e.Value()), "\n")
-	for _, line := range lines {
-		if strings.TrimSpace(line) == id {
-			return nil // already present
-		}
-	}
-	lines = append(lines, id)
-	newVal := strings.TrimSpace(strings.Join(lines, "\n"))
-	if _, err := s.tagsKV.Update(tag, []byte(newVal), e.Revision()); err != nil {
-		return fmt.Errorf("update tag index: %w", err)
+// GetTask reads a task. Private tasks the caller may not see are reported
+// as ErrNotFound.
+func (s *Store) GetTask(ctx context.Context, id string) (Task, uint64, error) {
+	t, rev, err := s.getTask(ctx, id)
+	if err == nil && !s.canSee(ctx, t) {
+		return Task{}, 0, fmt.Errorf("task %s: %w", id, ErrNotFound)
 	}
-	return nil
+	return t, rev, err
 }
 
-func (s *Store) removeTagID(tag, id string) error {
-	e, err := s.tagsKV.Get(tag)
+// getTask is GetTask without the visibility check.
+func (s *Store) getTask(ctx context.Context, id string) (Task, uint64, error) {
+	e, err := s.tasksKV.Get(ctx, s.taskKey(id))
 	if err != nil {
-		if errors.Is(err, nats.ErrKeyNotFound) {
-			return nil
-		}
-		return err
-	}
-	lines := strings.Split(string(e.Value()), "\n")
-	out := make([]string, 0, len(lines))
-	for _, line := range lines {
-		if strings.TrimSpace(line) == id || strings.TrimSpace(line) == "" {
-			continue
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return Task{}, 0, fmt.Errorf("task %s: %w", id, ErrNotFound)
 		}
-		out = append(out, strings.TrimSpace(line))
+		return Task{}, 0, err
 	}
-	newVal := strings.TrimSpace(strings.Join(out, "\n"))
-	if _, err := s.tagsKV.Update(tag, []byte(newVal), e.Revision()); err != nil {
-		return err
+	t, err := s.decodeTask(e.Value())
+	if err != nil {
+		return Task{}, 0, err
 	}
-	return nil
+	t.Revision = e.Revision()
+	return t, e.Revision(), nil
 }
 
-func (s *Store) GetTask(ctx context.Context, id string) (Task, uint64, error) {
-	e, err := s.tasksKV.Get(id)
+// putTaskCAS writes t over revision rev, the one it was read at, and
+// returns its new revision. If the task has changed since, nothing is
+// written and the error wraps ErrConflict.
+func (s *Store) putTaskCAS(ctx context.Context, id string, t Task, rev uint64) (uint64, error) {
+	b, err := encodeTask(t, s.opts)
 	if err != nil {
-		if errors.Is(err, nats.ErrKeyNotFound) {
-			return Task{}, 0, fmt.Errorf("not found")
-		}
-		return Task{}, 0, err
+		return 0, fmt.Errorf("encode task: %w", err)
 	}
-	var t Task
-	if err := json.Unmarshal(e.Value(), &t); err != nil {
-		return Task{}, 0, err
+	newRev, err := s.tasksKV.Update(ctx, s.taskKey(id), b, rev)
+	if err != nil {
+		return 0, casError("task "+id, err)
 	}
-	return t, e.Revision(), nil
+	s.reindexTrailers(ctx, id, &t)
+	return newRev, nil
 }
 
-func (s *Store) putTaskCAS(id string, t Task, rev uint64) error {
-	b, _ := json.Marshal(t)
-	if _, err := s.tasksKV.Put(id, b); err != nil {
-		return err
+// checkRevision rejects a write expecting revision want when the task is at
+// have. want == 0 accepts any revision.
+func checkRevision(id string, want, have uint64) error {
+	if want != 0 && want != have {
+		return fmt.Errorf("task %s is at revision %d, not %d; re-read it and retry: %w", id, have, want, ErrConflict)
 	}
 	return nil
 }
 
-// UpdateTask modifies fields and updates the tag index.
+// UpdateTask modifies fields and updates the tag index. A write that
+// races another is re-read and re-applied, unless set.IfRevision pins the
+// revision, in which case it fails with ErrConflict.
 func (s *Store) UpdateTask(ctx context.Context, id string, set UpdateSet) (Task, error) {
-	before, rev, err := s.GetTask(ctx, id)
+	if err := s.authorize(ctx, "update", RoleContributor); err != nil {
+		return Task{}, err
+	}
+	var before, after Task
+	err := retryTaskCAS(ctx, "update task "+id, set.IfRevision, func() error {
+		var (
+			rev uint64
+			err error
+		)
+		before, rev, err = s.GetTask(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkRevision(id, set.IfRevision, rev); err != nil {
+			return err
+		}
+		if after, err = s.applyUpdate(ctx, before, set); err != nil {
+			return err
+		}
+		after.Revision, err = s.putTaskCAS(ctx, id, after, rev)
+		return err
+	})
 	if err != nil {
 		return Task{}, err
 	}
+	s.logWrite(ctx, "update", id, after.Revision)
+	s.auditUpdate(ctx, before, after)
+	s.recordUndo(ctx, "update", undoChange(&before, &after))
+	if after.Done && !before.Done {
+		s.recur(ctx, after)
+	}
+	if err := s.reindexTags(ctx, id, before.Tags, after.Tags); err != nil {
+		return after, err
+	}
+	if err := s.reindexStatus(ctx, id, &before, &after); err != nil {
+		return after, err
+	}
+    // Events removed
+    return after, nil
+}
+
+// applyUpdate returns before with set applied, validated and stamped with
+// the caller's identity.
+func (s *Store) applyUpdate(ctx context.Context, before Task, set UpdateSet) (Task, error) {
 	after := before
 	if set.Text != nil {
 		after.Text = strings.TrimSpace(*set.Text)
 	}
-	if set.Done != nil {
-		after.Done = *set.Done
+	if set.Details != nil {
+		after.Description = strings.TrimSpace(*set.Details)
+	}
+	if set.Done != nil && *set.Done != after.Done {
+		if *set.Done {
+			after.setStatus(StatusDone)
+		} else {
+			after.setStatus(StatusOpen)
+		}
 	}
 	if set.Tags != nil {
 		// normalize tags
@@ -225,334 +550,561 @@ func (s *Store) UpdateTask(ctx context.Context, id string, set UpdateSet) (Task,
 	if set.Priority != nil {
 		after.Priority = *set.Priority
 	}
-	if err := s.putTaskCAS(id, after, rev); err != nil {
-		return Task{}, err
+	if set.Assignee != nil {
+		after.Assignee = strings.TrimSpace(*set.Assignee)
+	}
+	if set.Private != nil {
+		after.Private = *set.Private
+	}
+	if set.Contexts != nil {
+		contexts, err := NormalizeContexts(*set.Contexts)
+		if err != nil {
+			return Task{}, err
+		}
+		after.Contexts = nil
+		if len(contexts) > 0 {
+			after.Contexts = contexts
+		}
+	}
+	set.applyDates(&after)
+	if set.Recur != nil {
+		recur, err := normalizeRecur(*set.Recur)
+		if err != nil {
+			return Task{}, err
+		}
+		after.Recur = recur
+	}
+	switch {
+	case set.Done == nil:
+	case !after.Done:
+		after.ReviewRequestedBy = ""
+	case !before.Done:
+		s.requestReview(ctx, &after)
+	}
+	// Only the fields being changed are checked, so tasks stored before a
+	// limit was tightened stay editable.
+	if set.Text != nil {
+		if err := s.opts.Limits.validateText(after.Text); err != nil {
+			return Task{}, err
+		}
+	}
+	if set.Details != nil {
+		if err := s.opts.Limits.validateDetails(after.Description); err != nil {
+			return Task{}, err
+		}
+	}
+	if set.Tags != nil {
+		if err := s.opts.Limits.validateTags(after.Tags); err != nil {
+			return Task{}, err
+		}
+	}
+	after.touch(s.identity(ctx), time.Now())
+	return after, nil
+}
+
+// reindexTags applies the difference between two tag sets to the tag index.
+// Every change is attempted; failures (conflicts that outlived their
+// retries included) are joined into the returned error.
+func (s *Store) reindexTags(ctx context.Context, id string, before, after []string) error {
+	var c tagChanges
+	c.note(id, before, after)
+	if err := s.applyTagChanges(ctx, c); err != nil {
+		s.log.WarnContext(ctx, "tag index update failed", "op", "reindex", "task", id, "err", err)
+		return indexError(err)
+	}
+	return nil
+}
+
+// tagChanges collects tag index edits for any number of tasks, so each
+// tag's entry is written once however many of them change it.
+type tagChanges struct {
+	add, remove map[string][]string
+}
+
+// note records a task's tags changing from before to after.
+func (c *tagChanges) note(id string, before, after []string) {
+	if c.add == nil {
+		c.add, c.remove = map[string][]string{}, map[string][]string{}
 	}
-	// Tag diff
 	beforeSet := map[string]struct{}{}
 	afterSet := map[string]struct{}{}
-	for _, t := range before.Tags {
+	for _, t := range before {
 		beforeSet[t] = struct{}{}
 	}
-	for _, t := range after.Tags {
+	for _, t := range after {
 		afterSet[t] = struct{}{}
 	}
 	for t := range afterSet {
 		if _, ok := beforeSet[t]; !ok {
-			_ = s.appendTagID(t, id)
+			c.add[t] = append(c.add[t], id)
 		}
 	}
 	for t := range beforeSet {
 		if _, ok := afterSet[t]; !ok {
-			_ = s.removeTagID(t, id)
+			c.remove[t] = append(c.remove[t], id)
 		}
 	}
-    // Events removed
-    return after, nil
 }
 
-// DeleteTask removes a task and its tag references.
-func (s *Store) DeleteTask(ctx context.Context, id string) (string, error) {
-	t, _, err := s.GetTask(ctx, id)
-	if err != nil {
-		return "", err
+// applyTagChanges writes c to the tag index. Every change is attempted and
+// failures are joined into the returned error.
+func (s *Store) applyTagChanges(ctx context.Context, c tagChanges) error {
+	var errs []error
+	for t, ids := range c.add {
+		errs = append(errs, s.addTagIDs(ctx, t, ids))
 	}
-	if err := s.tasksKV.Delete(id); err != nil {
-		return "", err
+	for t, ids := range c.remove {
+		errs = append(errs, s.removeTagIDs(ctx, t, ids))
 	}
-	for _, tag := range t.Tags {
-		_ = s.removeTagID(tag, id)
+	return errors.Join(errs...)
+}
+
+// logWrite records a successful task write: it counts it for Writes and
+// logs it at debug level.
+func (s *Store) logWrite(ctx context.Context, op, id string, rev uint64) {
+	s.writes.Add(1)
+	s.log.DebugContext(ctx, "task written", "op", op, "task", id, "revision", rev, "by", s.identity(ctx))
+}
+
+// indexError explains a tag or status index failure after the task itself was
+// written: the task is saved and RebuildIndex repairs the index.
+func indexError(err error) error {
+	if err == nil {
+		return nil
 	}
-    // Events removed
-    return t.ID, nil
+	return fmt.Errorf("task saved but the index is stale (run `ut rebuild-index`): %w", err)
 }
 
-func (s *Store) CloseTask(ctx context.Context, id string) (Task, bool, error) {
-	t, rev, err := s.GetTask(ctx, id)
+// PutTask writes a complete task record, creating it or replacing the stored
+// value, and keeps the tag index in sync. It is the upsert path used by bulk
+// import. Returns true if the task did not exist before.
+func (s *Store) PutTask(ctx context.Context, t Task) (bool, error) {
+	return s.putTask(ctx, t, true)
+}
+
+// putTask is PutTask that leaves the tag index alone unless index is set;
+// a caller that skips it rebuilds the index afterwards.
+func (s *Store) putTask(ctx context.Context, t Task, index bool) (bool, error) {
+	if err := s.authorize(ctx, "put", RoleContributor); err != nil {
+		return false, err
+	}
+	if err := ValidateTask(t); err != nil {
+		return false, err
+	}
+	t.Tags = normalizeTags(t.Tags)
+	if err := s.opts.Limits.Validate(t.Text, t.Tags); err != nil {
+		return false, fmt.Errorf("task %s: %w", t.ID, err)
+	}
+	if err := s.opts.Limits.validateDetails(t.Description); err != nil {
+		return false, fmt.Errorf("task %s: %w", t.ID, err)
+	}
+	before, beforeRev, err := s.getTask(ctx, t.ID)
+	if err == nil && !s.canSee(ctx, before) {
+		return false, fmt.Errorf("put: %w: task %s is private", ErrForbidden, t.ID)
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return false, err
+	}
+	// A stored task keeps its number; others keep the one they bring if
+	// it is free.
+	if before.Number != 0 {
+		t.Number = before.Number
+	} else if nerr := s.numberTask(ctx, &t); nerr != nil {
+		return false, fmt.Errorf("number task %s: %w", t.ID, nerr)
+	}
 	if err != nil {
-		return Task{}, false, err
+		b, err := encodeTask(t, s.opts)
+		if err != nil {
+			return false, fmt.Errorf("encode task: %w", err)
+		}
+		rev, err := s.tasksKV.Create(ctx, s.taskKey(t.ID), b)
+		if err != nil {
+			return false, fmt.Errorf("create task: %w", err)
+		}
+		s.logWrite(ctx, "put", t.ID, rev)
+		t.Revision = rev
+		s.audit(ctx, ActivityCreated, t, "")
+		if !index {
+			return true, nil
+		}
+		s.reindexTrailers(ctx, t.ID, &t)
+		return true, errors.Join(s.reindexTags(ctx, t.ID, nil, t.Tags), s.reindexStatus(ctx, t.ID, nil, &t))
 	}
-	if t.Done {
-		return t, false, nil
+	rev, err := s.putTaskCAS(ctx, t.ID, t, beforeRev)
+	if err != nil {
+		return false, err
 	}
-	t.Done = true
-	if err := s.putTaskCAS(id, t, rev); err != nil {
-		return Task{}, false, err
+	s.logWrite(ctx, "put", t.ID, rev)
+	t.Revision = rev
+	s.auditUpdate(ctx, before, t)
+	if !index {
+		return false, nil
 	}
-    // Events removed
-    return t, true, nil
+	return false, errors.Join(s.reindexTags(ctx, t.ID, before.Tags, t.Tags), s.reindexStatus(ctx, t.ID, &before, &t))
 }
 
-func (s *Store) ReopenTask(ctx context.Context, id string) (Task, bool, error) {
+// DeleteTask moves a task to the trash and drops its tag references.
+func (s *Store) DeleteTask(ctx context.Context, id string) (string, error) {
+	return s.DeleteTaskIf(ctx, id, 0)
+}
+
+// DeleteTaskIf is DeleteTask that fails with ErrConflict unless the task is
+// at revision ifRev (0 = any).
+func (s *Store) DeleteTaskIf(ctx context.Context, id string, ifRev uint64) (string, error) {
+	if err := s.authorize(ctx, "delete", RoleAdmin); err != nil {
+		return "", err
+	}
 	t, rev, err := s.GetTask(ctx, id)
 	if err != nil {
-		return Task{}, false, err
+		return "", err
 	}
-	if !t.Done {
-		return t, false, nil
+	if err := checkRevision(id, ifRev, rev); err != nil {
+		return "", err
 	}
-	t.Done = false
-	if err := s.putTaskCAS(id, t, rev); err != nil {
-		return Task{}, false, err
+	if err := s.trash(ctx, t); err != nil {
+		return "", err
 	}
-    // Events removed
-    return t, true, nil
-}
-
-// List tasks; if tag is non-empty, list by tag index, else scan all keys.
-func (s *Store) List(ctx context.Context, tag string, statusFilter Status) ([]Task, error) {
-	out := []Task{}
-	if tag != "" {
-		e, err := s.tagsKV.Get(strings.ToLower(strings.TrimSpace(tag)))
-		if err != nil {
-			if errors.Is(err, nats.ErrKeyNotFound) {
-				return out, nil
-			}
-			return nil, err
-		}
-		ids := strings.Split(string(e.Value()), "\n")
-		for _, id := range ids {
-			id = strings.TrimSpace(id)
-			if id == "" {
-				continue
-			}
-			t, _, err := s.GetTask(ctx, id)
-			if err != nil {
-				continue
-			}
-			if statusFilter != "" {
-				if statusFilter == StatusOpen && t.Done {
-					continue
-				}
-				if statusFilter == StatusClosed && !t.Done {
-					continue
-				}
-			}
-			out = append(out, t)
-		}
-		return out, nil
+	if ifRev != 0 {
+		err = deleteIf(ctx, s.tasksKV, s.taskKey(id), ifRev)
+	} else {
+		err = s.tasksKV.Delete(ctx, s.taskKey(id))
 	}
-	// Scan all entries in tasks bucket
-	keys, err := s.tasksKV.Keys()
 	if err != nil {
-		return nil, err
+		s.untrash(ctx, id)
+		return "", casError("delete task "+id, err)
 	}
-	for _, k := range keys {
-		if k == "" {
-			continue
-		}
-		t, _, err := s.GetTask(ctx, k)
-		if err != nil {
-			continue
-		}
-		if statusFilter != "" {
-			if statusFilter == StatusOpen && t.Done {
-				continue
-			}
-			if statusFilter == StatusClosed && !t.Done {
-				continue
-			}
-		}
-		out = append(out, t)
+	s.logWrite(ctx, "delete", id, 0)
+	t.Revision = 0
+	s.audit(ctx, ActivityDeleted, t, "")
+	s.recordUndo(ctx, "delete", undoChange(&t, nil))
+	s.dropClaim(ctx, id)
+	s.dropNumber(ctx, t)
+	s.reindexTrailers(ctx, id, nil)
+	if err := s.reindexTags(ctx, id, t.Tags, nil); err != nil {
+		return t.ID, err
+	}
+	if err := s.reindexStatus(ctx, id, &t, nil); err != nil {
+		return t.ID, err
 	}
-	return out, nil
+    // Events removed
+    return t.ID, nil
 }
 
-// Query returns tasks matching ANY(allAny) union and ALL(allAll) intersection, with optional limit.
-func (s *Store) Query(ctx context.Context, any, all []string, limit int) ([]Task, error) {
-	norm := func(in []string) []string {
-		out := make([]string, 0, len(in))
-		seen := map[string]struct{}{}
-		for _, t := range in {
-			t = strings.ToLower(strings.TrimSpace(t))
-			if t == "" {
-				continue
-			}
-			if _, ok := seen[t]; ok {
-				continue
-			}
-			seen[t] = struct{}{}
-			out = append(out, t)
-		}
-		return out
-	}
-	any = norm(any)
-	all = norm(all)
+func (s *Store) CloseTask(ctx context.Context, id string) (Task, bool, error) {
+	return s.setDone(ctx, id, true, 0)
+}
+
+func (s *Store) ReopenTask(ctx context.Context, id string) (Task, bool, error) {
+	return s.setDone(ctx, id, false, 0)
+}
+
+// CloseTaskIf is CloseTask that fails with ErrConflict unless the task is at
+// revision ifRev (0 = any).
+func (s *Store) CloseTaskIf(ctx context.Context, id string, ifRev uint64) (Task, bool, error) {
+	return s.setDone(ctx, id, true, ifRev)
+}
+
+// ReopenTaskIf is ReopenTask with the same precondition as CloseTaskIf.
+func (s *Store) ReopenTaskIf(ctx context.Context, id string, ifRev uint64) (Task, bool, error) {
+	return s.setDone(ctx, id, false, ifRev)
+}
 
-	// Helper to fetch set of IDs for a tag
-	readTag := func(tag string) (map[string]struct{}, error) {
-		out := map[string]struct{}{}
-		e, err := s.tagsKV.Get(tag)
+// setDone sets the done flag, reporting whether it changed. Like
+// UpdateTask, a lost race is retried unless ifRev pins the revision.
+func (s *Store) setDone(ctx context.Context, id string, done bool, ifRev uint64) (Task, bool, error) {
+	if err := s.authorize(ctx, "set done", RoleContributor); err != nil {
+		return Task{}, false, err
+	}
+	var (
+		t, before Task
+		changed   bool
+		op        string
+		kind      ActivityKind
+	)
+	err := retryTaskCAS(ctx, "set done on "+id, ifRev, func() error {
+		var (
+			rev uint64
+			err error
+		)
+		t, rev, err = s.GetTask(ctx, id)
 		if err != nil {
-			if errors.Is(err, nats.ErrKeyNotFound) {
-				return out, nil
-			}
-			return nil, err
+			return err
 		}
-		for _, line := range strings.Split(string(e.Value()), "\n") {
-			id := strings.TrimSpace(line)
-			if id != "" {
-				out[id] = struct{}{}
-			}
+		if err := checkRevision(id, ifRev, rev); err != nil {
+			return err
 		}
-		return out, nil
-	}
-
-	union := map[string]struct{}{}
-	if len(any) == 0 {
-		// If ANY not provided, start union with all task IDs
-		keys, err := s.tasksKV.Keys()
-		if err != nil {
-			return nil, err
+		before = t
+		pending := t.ReviewRequestedBy != ""
+		if t.Done == done && !pending || done && pending {
+			changed = false
+			return nil
 		}
-		for _, k := range keys {
-			if k != "" {
-				union[k] = struct{}{}
-			}
+		changed = true
+		// Reopening also withdraws a pending review.
+		t.ReviewRequestedBy = ""
+		if done {
+			t.setStatus(StatusDone)
+		} else {
+			t.setStatus(StatusOpen)
 		}
-	} else {
-		for _, tag := range any {
-			ids, err := readTag(tag)
-			if err != nil {
-				return nil, err
-			}
-			for id := range ids {
-				union[id] = struct{}{}
+		op, kind = "reopen", ActivityReopened
+		if done {
+			op, kind = "close", ActivityClosed
+			if s.requestReview(ctx, &t) {
+				op, kind = "request review", ActivityReviewRequested
 			}
 		}
+		t.touch(s.identity(ctx), time.Now())
+		t.Revision, err = s.putTaskCAS(ctx, id, t, rev)
+		return err
+	})
+	if err != nil {
+		return Task{}, false, err
 	}
+	if !changed {
+		return t, false, nil
+	}
+	s.logWrite(ctx, op, id, t.Revision)
+	s.audit(ctx, kind, t, "")
+	s.recordUndo(ctx, op, undoChange(&before, &t))
+	if t.Done {
+		s.dropClaim(ctx, id)
+		s.recur(ctx, t)
+	}
+	if err := s.reindexStatus(ctx, id, &before, &t); err != nil {
+		return t, true, err
+	}
+    // Events removed
+    return t, true, nil
+}
 
-	// Apply ALL intersection
-	for _, tag := range all {
-		ids, err := readTag(tag)
-		if err != nil {
-			return nil, err
-		}
-		// intersect union with ids
-		for id := range union {
-			if _, ok := ids[id]; !ok {
-				delete(union, id)
-			}
+// ForEach streams every task in the tasks bucket to fn, one at a time, without
+// materializing the full listing. Iteration stops at the first error from fn.
+func (s *Store) ForEach(ctx context.Context, fn func(Task) error) error {
+	it, err := s.ListIter(ctx, ListFilter{})
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	for it.Next() {
+		if err := fn(it.Task()); err != nil {
+			return err
 		}
 	}
+	return it.Err()
+}
 
-	// Fetch tasks
+// Query returns tasks matching ANY(any) union and ALL(all) intersection, with optional limit.
+// Both empty lists every task. Sets are planned on the tag index (see ListIter)
+// so only matching task bodies are fetched.
+func (s *Store) Query(ctx context.Context, any, all []string, limit int) ([]Task, error) {
+	it, err := s.ListIter(ctx, ListFilter{Any: any, All: all, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
 	out := []Task{}
-	for id := range union {
-		t, _, err := s.GetTask(ctx, id)
-		if err != nil {
-			continue
-		}
-		out = append(out, t)
-		if limit > 0 && len(out) >= limit {
-			break
-		}
+	for it.Next() {
+		out = append(out, it.Task())
 	}
-	return out, nil
+	return out, it.Err()
 }
 
-// RebuildIndex scans all tasks and rewrites the tag index from scratch.
+// RebuildIndex scans all tasks and rewrites the tag, status and trailer
+// indexes from scratch.
 func (s *Store) RebuildIndex(ctx context.Context) error {
-	keys, err := s.tasksKV.Keys()
+	if err := s.authorize(ctx, "rebuild index", RoleAdmin); err != nil {
+		return err
+	}
+	return s.rebuildIndex(ctx)
+}
+
+// rebuildIndex is RebuildIndex without the role check, for writers that
+// skipped the index (see ImportJSONL).
+func (s *Store) rebuildIndex(ctx context.Context) error {
+	ids, err := s.taskIDs(ctx)
 	if err != nil {
 		return err
 	}
 	acc := map[string][]string{}
-	for _, k := range keys {
-		if k == "" {
+	trailers := map[string][]string{}
+	var open, closed []string
+	for _, id := range ids {
+		if id == "" {
 			continue
 		}
-		t, _, err := s.GetTask(ctx, k)
+		t, _, err := s.getTask(ctx, id)
 		if err != nil {
 			continue
 		}
-		for _, tag := range t.Tags {
-			tag = strings.ToLower(strings.TrimSpace(tag))
-			if tag == "" {
-				continue
-			}
-			acc[tag] = append(acc[tag], t.ID)
+		indexTask(acc, t)
+		trailers[t.ID] = trailerPairs(s.opts.Key, &t)
+		if t.Done {
+			closed = append(closed, t.ID)
+		} else {
+			open = append(open, t.ID)
 		}
 	}
-	// Delete old tags not present
-	oldKeys, err := s.tagsKV.Keys()
-	if err == nil {
-		for _, ok := range oldKeys {
-			if ok == "" {
-				continue
-			}
-			if _, present := acc[ok]; !present {
-				_ = s.tagsKV.Delete(ok)
-			}
-		}
+	if err := s.putStatusIndex(ctx, open, closed); err != nil {
+		return err
+	}
+	if err := s.rebuildTrailerIndex(ctx, trailers); err != nil {
+		return err
 	}
-	// Write new values
+	want := map[string]bool{}
+	counts := make(map[string]int, len(acc))
 	for tag, ids := range acc {
-		val := strings.Join(ids, "\n")
-		if _, err := s.tagsKV.Put(tag, []byte(val)); err != nil {
-			return fmt.Errorf("write tag %s: %w", tag, err)
+		for _, id := range ids {
+			want[tagEntryKey(tag, id)] = true
 		}
+		counts[tag] = len(ids)
+	}
+	keys, err := s.tagsKV.Keys(ctx)
+	if err != nil && !errors.Is(err, jetstream.ErrNoKeysFound) {
+		return err
+	}
+	have := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		have[k] = true
+	}
+	// Write missing entries, then drop stale ones and legacy values.
+	for k := range want {
+		if have[k] {
+			continue
+		}
+		if _, err := s.tagsKV.Put(ctx, k, nil); err != nil {
+			return fmt.Errorf("write tag index %s: %w", k, err)
+		}
+	}
+	for k := range have {
+		if !want[k] {
+			_ = s.tagsKV.Delete(ctx, k)
+		}
+	}
+	if err := s.markTagEntries(ctx); err != nil {
+		return fmt.Errorf("record tag index layout: %w", err)
+	}
+	return s.putTagCounts(ctx, counts)
+}
+
+// indexTask adds t's id under each of its normalized tags in acc.
+func indexTask(acc map[string][]string, t Task) {
+	for _, tag := range t.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		acc[tag] = append(acc[tag], t.ID)
 	}
-	return nil
 }
 
 // Events removed: no publish/subscribe helpers
 
-// Resolve implements Git-style prefix resolution. Returns full id and candidates on ambiguity.
-func (s *Store) Resolve(prefix string) (string, []string, error) {
-	prefix = strings.TrimSpace(prefix)
-	if prefix == "" {
-		return "", nil, fmt.Errorf("empty prefix")
+// Resolve maps a task reference to a full id. An exact id wins even when
+// other ids start with it, then a task number (T-142), then an alias (see
+// SetAlias), then Git-style prefix resolution. On ambiguity the error is an *AmbiguousError and its candidates,
+// with short ids and titles, are also returned.
+func (s *Store) Resolve(ctx context.Context, ref string) (string, []Candidate, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", nil, invalidf("empty prefix")
+	}
+	if s.hasTask(ctx, ref) {
+		return ref, nil, nil
+	}
+	if id, ok := s.numberTarget(ctx, ref); ok {
+		return id, nil, nil
+	}
+	if id, ok := s.aliasTarget(ctx, ref); ok {
+		return id, nil, nil
+	}
+	var (
+		keys []string
+		err  error
+	)
+	if s.Keyspace() == KeyspaceSharded && len(ref) >= 2 {
+		// Only the prefix's shard can hold a match.
+		keys, err = s.listKeys(ctx, strings.ToLower(ref[:2])+".>")
+		for i, k := range keys {
+			keys[i] = keyID(k)
+		}
+	} else {
+		keys, err = s.taskIDs(ctx)
 	}
-	// List keys via deprecated Keys(). Good enough for now.
-	keys, err := s.tasksKV.Keys()
 	if err != nil {
 		return "", nil, err
 	}
-	return matchPrefix(keys, prefix)
+	id, err := matchPrefix(keys, ref)
+	var amb *AmbiguousError
+	if errors.As(err, &amb) {
+		s.describeCandidates(ctx, amb.Candidates)
+		return "", amb.Candidates, amb
+	}
+	return id, nil, err
 }
 
-// matchPrefix applies Git-style prefix resolution on a list of full IDs.
-func matchPrefix(keys []string, prefix string) (string, []string, error) {
+// matchPrefix applies Git-style prefix resolution on a list of full IDs. An
+// id equal to prefix wins outright.
+func matchPrefix(keys []string, prefix string) (string, error) {
 	matches := []string{}
 	for _, k := range keys {
+		if k == prefix {
+			return k, nil
+		}
 		if strings.HasPrefix(k, prefix) {
 			matches = append(matches, k)
 		}
 	}
 	switch len(matches) {
 	case 0:
-		return "", nil, fmt.Errorf("not found")
+		return "", fmt.Errorf("prefix %q: %w", prefix, ErrNotFound)
 	case 1:
-		return matches[0], nil, nil
+		return matches[0], nil
 	default:
-		return "", matches, fmt.Errorf("ambiguous")
+		return "", &AmbiguousError{Prefix: prefix, Candidates: newCandidates(matches)}
 	}
 }
 
-// ListTags returns tag names with approximate counts based on index lines.
-func (s *Store) ListTags() (map[string]int, error) {
+// ListTags returns tag names with their task counts. Counts come from the
+// cached counts key maintained on every index change; without it (older
+// profiles) the index is scanned once and the cache seeded.
+func (s *Store) ListTags(ctx context.Context) (map[string]int, error) {
+	if counts, ok := s.cachedTagCounts(ctx); ok {
+		return counts, nil
+	}
+	counts, err := s.scanTagCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_ = s.putTagCounts(ctx, counts)
+	return counts, nil
+}
+
+// scanTagCounts counts ids by listing every tag index key.
+func (s *Store) scanTagCounts(ctx context.Context) (map[string]int, error) {
 	counts := map[string]int{}
-	keys, err := s.tagsKV.Keys()
+	keys, err := s.tagsKV.Keys(ctx)
 	if err != nil {
+		if errors.Is(err, jetstream.ErrNoKeysFound) {
+			return counts, nil
+		}
 		return nil, err
 	}
 	for _, k := range keys {
-		if k == "" {
+		if tag, _, ok := parseTagEntryKey(k); ok {
+			counts[tag]++
 			continue
 		}
-		e, err := s.tagsKV.Get(k)
+		if k == "" || isShardKey(k) || !s.legacyTags.Load() {
+			continue
+		}
+		ids, err := s.readLegacyTag(ctx, k)
 		if err != nil {
 			continue
 		}
-		lines := strings.Split(string(e.Value()), "\n")
-		n := 0
-		for _, l := range lines {
-			if strings.TrimSpace(l) != "" {
-				n++
-			}
+		if len(ids) > 0 {
+			counts[k] += len(ids)
 		}
-		counts[k] = n
 	}
 	return counts, nil
 }
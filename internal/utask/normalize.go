@@ -1,9 +1,11 @@
 package utask
 
 import (
+	"crypto/rand"
 	"crypto/sha512"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"regexp"
 	"sort"
 	"strings"
@@ -16,27 +18,18 @@ type canonical struct {
 	Tags            []string `json:"tags"`
 	Priority        int      `json:"priority"`
 	EstimateMinutes int      `json:"estimate_minutes"`
+	// Details is omitted when empty so ids of tasks without it are
+	// unchanged.
+	Details string `json:"details,omitempty"`
 }
 
 // NormalizeInput canonicalizes input for id derivation and returns the canonical
 // form plus the derived id. IDs are a deterministic sha512 of the canonical JSON.
 func NormalizeInput(in TaskInput) (canonical, string) {
-    text := strings.TrimSpace(in.Text)
+	text := strings.TrimSpace(in.Text)
 
 	// Normalize tags: lowercase, trim, drop empties, dedupe, sort
-	seen := map[string]struct{}{}
-	tags := make([]string, 0, len(in.Tags))
-	for _, t := range in.Tags {
-		t = strings.ToLower(strings.TrimSpace(t))
-		if t == "" {
-			continue
-		}
-		if _, ok := seen[t]; ok {
-			continue
-		}
-		seen[t] = struct{}{}
-		tags = append(tags, t)
-	}
+	tags := normalizeTags(in.Tags)
 	sort.Strings(tags)
 
 	c := canonical{
@@ -44,6 +37,7 @@ func NormalizeInput(in TaskInput) (canonical, string) {
 		Tags:            tags,
 		Priority:        in.Priority,
 		EstimateMinutes: in.EstimateMinutes,
+		Details:         strings.TrimSpace(in.Details),
 	}
 
 	// Deterministic JSON via struct field order
@@ -52,3 +46,68 @@ func NormalizeInput(in TaskInput) (canonical, string) {
 	id := hex.EncodeToString(sum[:])
 	return c, id
 }
+
+// IDStrategy is how CreateTask picks the id of a new task.
+type IDStrategy string
+
+const (
+	// IDContent derives the id from the task's content (NormalizeInput),
+	// so creating the same task twice returns the first one.
+	IDContent IDStrategy = "content"
+	// IDRandom gives every new task a random id, so similar tasks can be
+	// created again (see Store.Duplicates for finding them).
+	IDRandom IDStrategy = "random"
+)
+
+// ParseIDStrategy validates a configured id strategy ("" means content).
+func ParseIDStrategy(s string) (IDStrategy, error) {
+	switch IDStrategy(s) {
+	case "", IDContent:
+		return IDContent, nil
+	case IDRandom:
+		return IDRandom, nil
+	}
+	return "", fmt.Errorf("invalid id strategy: %s (want content|random)", s)
+}
+
+// RandomID returns a new random task id. It has the form of a content id,
+// 128 hex chars, so prefixes, sharded keys and imports treat both alike.
+func RandomID() string {
+	var b [sha512.Size]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("utask: read random id: %v", err))
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// ContentID returns the deterministic id CreateTask would assign to a task
+// with the same content.
+func ContentID(t Task) string {
+	_, id := NormalizeInput(TaskInput{
+		Text:            t.Text,
+		Tags:            t.Tags,
+		Priority:        t.Priority,
+		EstimateMinutes: t.EstimateMinutes,
+		Details:         t.Description,
+	})
+	return id
+}
+
+// normalizeTags lowercases and trims tags, dropping empties and duplicates
+// while preserving the input order.
+func normalizeTags(in []string) []string {
+	seen := map[string]struct{}{}
+	out := make([]string, 0, len(in))
+	for _, t := range in {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		out = append(out, t)
+	}
+	return out
+}
@@ -9,18 +9,26 @@ func TestNormalizeInput_DeterministicAndCanonical(t *testing.T) {
 	}
 	c1, id1 := NormalizeInput(in1)
 
-	if c1.Text != "Buy milk" {
+	// Only the ends are trimmed. Ids have always been derived from the
+	// text as written, so collapsing inner runs would re-key existing
+	// tasks and make re-imports create duplicates.
+	if c1.Text != "Buy   milk" {
 		t.Fatalf("text canonicalization failed: %q", c1.Text)
 	}
 	if len(c1.Tags) != 2 || c1.Tags[0] != "errand" || c1.Tags[1] != "shopping" {
 		t.Fatalf("tags canonicalization failed: %#v", c1.Tags)
 	}
-	// No extended/description field in canonical anymore
 
 	// Same semantic input in different order produces same ID
-	in2 := TaskInput{Text: "Buy milk", Tags: []string{"shopping", "errand"}}
+	in2 := TaskInput{Text: "Buy   milk", Tags: []string{"shopping", "errand"}}
 	_, id2 := NormalizeInput(in2)
 	if id1 != id2 {
 		t.Fatalf("expected deterministic id, got %q vs %q", id1, id2)
 	}
+
+	// Details only enter the id when given.
+	c3, id3 := NormalizeInput(TaskInput{Text: in2.Text, Tags: in2.Tags, Details: "  two litres\n"})
+	if c3.Details != "two litres" || id3 == id1 {
+		t.Fatalf("details: canonical %q, id unchanged: %v", c3.Details, id3 == id1)
+	}
 }
@@ -0,0 +1,202 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Task numbers are short references ("T-142") handed out in creation order,
+// so tasks can be named without their sha512 id. The meta bucket holds the
+// last number given out and one key per number holding its task id.
+const (
+	numberCounterKey = "seq.task"
+	numberKeyPrefix  = "number."
+	// NumberPrefix starts a task number reference.
+	NumberPrefix = "T-"
+)
+
+// numberRe matches a task number reference, in either case.
+var numberRe = regexp.MustCompile(`^[tT]-([1-9][0-9]{0,18})$`)
+
+// Ref returns how to refer to the task: its number, or its id if it has
+// none yet (see Store.NumberTasks).
+func (t Task) Ref() string {
+	if t.Number == 0 {
+		return t.ID
+	}
+	return NumberPrefix + strconv.FormatUint(t.Number, 10)
+}
+
+// ParseNumber reads a task number reference such as "T-142".
+func ParseNumber(ref string) (uint64, bool) {
+	m := numberRe.FindStringSubmatch(ref)
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(m[1], 10, 64)
+	return n, err == nil
+}
+
+func numberKey(n uint64) string { return numberKeyPrefix + strconv.FormatUint(n, 10) }
+
+// bumpCounter moves the task counter to next(current), where a missing
+// counter is 0, and returns the new value. Racing writers are retried.
+func (s *Store) bumpCounter(ctx context.Context, next func(uint64) uint64) (uint64, error) {
+	var n uint64
+	err := retryCAS(ctx, "task counter", func() error {
+		e, err := s.metaKV.Get(ctx, numberCounterKey)
+		var cur, rev uint64
+		switch {
+		case errors.Is(err, jetstream.ErrKeyNotFound):
+		case err != nil:
+			return err
+		default:
+			if cur, err = strconv.ParseUint(string(e.Value()), 10, 64); err != nil {
+				return fmt.Errorf("task counter: %w", err)
+			}
+			rev = e.Revision()
+		}
+		if n = next(cur); n == cur {
+			return nil
+		}
+		b := []byte(strconv.FormatUint(n, 10))
+		if rev == 0 {
+			_, err = s.metaKV.Create(ctx, numberCounterKey, b)
+		} else {
+			_, err = s.metaKV.Update(ctx, numberCounterKey, b, rev)
+		}
+		if err != nil {
+			return casError("task counter", err)
+		}
+		return nil
+	})
+	return n, err
+}
+
+// nextNumber hands out the next task number.
+func (s *Store) nextNumber(ctx context.Context) (uint64, error) {
+	return s.bumpCounter(ctx, func(cur uint64) uint64 { return cur + 1 })
+}
+
+// claimNumber points number n at task id unless another task has it,
+// reporting whether id now holds it.
+func (s *Store) claimNumber(ctx context.Context, n uint64, id string) (bool, error) {
+	_, err := s.metaKV.Create(ctx, numberKey(n), []byte(id))
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		e, gerr := s.metaKV.Get(ctx, numberKey(n))
+		if gerr != nil {
+			return false, gerr
+		}
+		return string(e.Value()) == id, nil
+	}
+	return err == nil, err
+}
+
+// numberTask gives t a number: the one it carries if no other task holds
+// it (an import from this profile), else the next one.
+func (s *Store) numberTask(ctx context.Context, t *Task) error {
+	if n := t.Number; n != 0 {
+		ok, err := s.claimNumber(ctx, n, t.ID)
+		if err != nil {
+			return err
+		}
+		if ok {
+			_, err = s.bumpCounter(ctx, func(cur uint64) uint64 { return max(cur, n) })
+			return err
+		}
+	}
+	n, err := s.nextNumber(ctx)
+	if err != nil {
+		return err
+	}
+	t.Number = n
+	_, err = s.claimNumber(ctx, n, t.ID)
+	return err
+}
+
+// dropNumber removes a deleted task's number from the index. Numbers are
+// never handed out again.
+func (s *Store) dropNumber(ctx context.Context, t Task) {
+	if t.Number == 0 {
+		return
+	}
+	if err := s.metaKV.Delete(ctx, numberKey(t.Number)); err != nil {
+		s.log.WarnContext(ctx, "task number index update failed", "task", t.ID, "number", t.Number, "err", err)
+	}
+}
+
+// numberTarget returns the task a number reference names, if it still
+// exists.
+func (s *Store) numberTarget(ctx context.Context, ref string) (string, bool) {
+	n, ok := ParseNumber(ref)
+	if !ok {
+		return "", false
+	}
+	e, err := s.metaKV.Get(ctx, numberKey(n))
+	if err != nil {
+		return "", false
+	}
+	id := string(e.Value())
+	return id, s.hasTask(ctx, id)
+}
+
+// NumberTasks numbers every task created before tasks were numbered, oldest
+// first, and restores missing number index entries. It returns how many
+// tasks it numbered.
+func (s *Store) NumberTasks(ctx context.Context) (int, error) {
+	if err := s.authorize(ctx, "number tasks", RoleAdmin); err != nil {
+		return 0, err
+	}
+	ids, err := s.taskIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var unnumbered []Task
+	for _, id := range ids {
+		t, _, err := s.getTask(ctx, id)
+		if err != nil {
+			continue
+		}
+		if t.Number == 0 {
+			unnumbered = append(unnumbered, t)
+		} else if _, err := s.claimNumber(ctx, t.Number, t.ID); err != nil {
+			return 0, fmt.Errorf("index task %s: %w", t.Ref(), err)
+		}
+	}
+	sort.SliceStable(unnumbered, func(i, j int) bool { return unnumbered[i].Created < unnumbered[j].Created })
+	numbered := 0
+	for _, t := range unnumbered {
+		if err := ctx.Err(); err != nil {
+			return numbered, err
+		}
+		// A retry keeps the number the lost attempt claimed.
+		var n uint64
+		err := retryCAS(ctx, "number task "+t.ID, func() error {
+			cur, rev, err := s.getTask(ctx, t.ID)
+			if err != nil || cur.Number != 0 {
+				return err
+			}
+			cur.Number = n
+			if err := s.numberTask(ctx, &cur); err != nil {
+				return err
+			}
+			n = cur.Number
+			_, err = s.putTaskCAS(ctx, cur.ID, cur, rev)
+			return err
+		})
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return numbered, err
+		}
+		numbered++
+	}
+	return numbered, nil
+}
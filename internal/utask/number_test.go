@@ -0,0 +1,88 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseNumber(t *testing.T) {
+	for ref, want := range map[string]uint64{"T-142": 142, "t-7": 7, "T-0": 0, "T142": 0, "T-": 0, "T-1x": 0} {
+		n, ok := ParseNumber(ref)
+		if n != want || ok != (want != 0) {
+			t.Errorf("ParseNumber(%q) = %d, %v", ref, n, ok)
+		}
+	}
+	if got := (Task{ID: "abc", Number: 3}).Ref(); got != "T-3" {
+		t.Errorf("Ref = %q", got)
+	}
+	if got := (Task{ID: "abc"}).Ref(); got != "abc" {
+		t.Errorf("unnumbered Ref = %q", got)
+	}
+}
+
+func TestTaskNumbers(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	a, _, err := s.CreateTask(ctx, TaskInput{Text: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _, err := s.CreateTask(ctx, TaskInput{Text: "second"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Number != 1 || b.Number != 2 {
+		t.Fatalf("numbers = %d, %d", a.Number, b.Number)
+	}
+	if id, _, err := s.Resolve(ctx, "t-2"); err != nil || id != b.ID {
+		t.Fatalf("Resolve(t-2) = %q, %v", id, err)
+	}
+	if err := s.SetAlias(ctx, "t-9", a.ID); !errors.Is(err, ErrValidation) {
+		t.Fatalf("SetAlias(t-9) err = %v", err)
+	}
+
+	// An import keeps a free number and moves the counter past it; a taken
+	// one is replaced.
+	hexID := func(c string) string { return strings.Repeat(c, 128) }
+	imported := Task{ID: hexID("c"), Text: "imported", Created: "2024-01-01T00:00:00Z", Number: 7}
+	if _, err := s.PutTask(ctx, imported); err != nil {
+		t.Fatal(err)
+	}
+	clash := Task{ID: hexID("b"), Text: "clash", Created: "2024-01-01T00:00:00Z", Number: 1}
+	if _, err := s.PutTask(ctx, clash); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, _ := s.GetTask(ctx, clash.ID); got.Number != 8 {
+		t.Fatalf("clashing import got number %d, want 8", got.Number)
+	}
+
+	// Tasks stored before numbering get one, oldest first.
+	for _, old := range []Task{{ID: hexID("e"), Text: "newer", Created: "2023-02-01T00:00:00Z"}, {ID: hexID("a"), Text: "older", Created: "2023-01-01T00:00:00Z"}} {
+		v, err := encodeTask(old, s.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.tasksKV.Put(ctx, s.taskKey(old.ID), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, err := s.NumberTasks(ctx); err != nil || n != 2 {
+		t.Fatalf("NumberTasks = %d, %v", n, err)
+	}
+	if id, _, err := s.Resolve(ctx, "T-9"); err != nil || id != hexID("a") {
+		t.Fatalf("Resolve(T-9) = %q, %v", id, err)
+	}
+
+	if _, err := s.DeleteTask(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Resolve(ctx, "T-1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Resolve(T-1) after delete err = %v", err)
+	}
+	c, _, err := s.CreateTask(ctx, TaskInput{Text: "third"})
+	if err != nil || c.Number != 11 {
+		t.Fatalf("number after delete = %d, %v", c.Number, err)
+	}
+}
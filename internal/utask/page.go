@@ -0,0 +1,98 @@
+package utask
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// Page is one page of a listing (see Store.List and PageOf).
+type Page struct {
+	Tasks []Task `json:"tasks"`
+	// Next is the cursor for the following page; empty on the last one.
+	Next string `json:"next,omitempty"`
+}
+
+// cursor is what a Page.Next token holds: the listing's order and the
+// position of the last task handed out.
+type cursor struct {
+	Sort    SortKey  `json:"s"`
+	Reverse bool     `json:"r,omitempty"`
+	After   position `json:"a"`
+}
+
+func (c cursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// parseCursor reads a Page.Next token for a listing ordered like f; the
+// empty token starts from the beginning.
+func parseCursor(tok string, f ListFilter) (*cursor, error) {
+	if tok == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(tok)
+	var c cursor
+	if err != nil || json.Unmarshal(b, &c) != nil || c.After.ID == "" {
+		return nil, invalidf("invalid cursor %q", tok)
+	}
+	if c.Sort != cmp.Or(f.Sort, DefaultSort) || c.Reverse != f.Reverse {
+		return nil, invalidf("cursor belongs to a listing sorted by %s; pass the same sort and filters for every page", c.Sort)
+	}
+	return &c, nil
+}
+
+// PageOf returns the page of tasks, already in f.Sort order (DefaultSort
+// when empty), that follows tok: up to f.Limit tasks (0 = all) after the
+// position tok records. tok is empty for the first page and the previous
+// Page.Next after that. Because the cursor holds a position rather than a
+// count, tasks created or deleted between calls don't shift later pages.
+func PageOf(tasks []Task, f ListFilter, tok string) (Page, error) {
+	c, err := parseCursor(tok, f)
+	if err != nil {
+		return Page{}, err
+	}
+	key := cmp.Or(f.Sort, DefaultSort)
+	page := Page{Tasks: []Task{}}
+	for _, t := range tasks {
+		if c != nil && positionOf(t, key, time.Local).compare(c.After, key, f.Reverse) <= 0 {
+			continue
+		}
+		if f.Limit > 0 && len(page.Tasks) == f.Limit {
+			last := page.Tasks[len(page.Tasks)-1]
+			page.Next = cursor{Sort: key, Reverse: f.Reverse, After: positionOf(last, key, time.Local)}.encode()
+			break
+		}
+		page.Tasks = append(page.Tasks, t)
+	}
+	return page, nil
+}
+
+// List returns a page of the tasks matching f, in f.Sort order
+// (DefaultSort when empty): up to f.Limit of them (0 = all) after the
+// cursor tok, as for PageOf. Every match is read to sort them.
+func (s *Store) List(ctx context.Context, f ListFilter, tok string) (Page, error) {
+	f.Sort = cmp.Or(f.Sort, DefaultSort)
+	if _, err := parseCursor(tok, f); err != nil {
+		return Page{}, err
+	}
+	limit := f.Limit
+	f.Limit = 0
+	it, err := s.ListIter(ctx, f)
+	if err != nil {
+		return Page{}, err
+	}
+	defer it.Close()
+	var tasks []Task
+	for it.Next() {
+		tasks = append(tasks, it.Task())
+	}
+	if err := it.Err(); err != nil {
+		return Page{}, err
+	}
+	f.Limit = limit
+	return PageOf(tasks, f, tok)
+}
@@ -0,0 +1,65 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStoreListPages(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	for i, text := range []string{"a", "b", "c", "d", "e"} {
+		if _, _, err := s.CreateTask(ctx, TaskInput{Text: text, Priority: []int{2, 4, 3, 4, 0}[i]}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got []string
+	f := ListFilter{Limit: 2}
+	cursor := ""
+	for pages := 0; ; pages++ {
+		page, err := s.List(ctx, f, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, task := range page.Tasks {
+			got = append(got, task.Text)
+		}
+		if pages == 0 {
+			// A task sorting before the cursor must not shift later pages.
+			if _, _, err := s.CreateTask(ctx, TaskInput{Text: "urgent", Priority: 1}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if page.Next == "" {
+			if pages != 2 {
+				t.Fatalf("got %d pages, want 3", pages+1)
+			}
+			break
+		}
+		cursor = page.Next
+	}
+	page, err := s.List(ctx, ListFilter{}, "")
+	if err != nil || page.Next != "" {
+		t.Fatalf("List without a limit = %+v, %v", page, err)
+	}
+	var want []string
+	for _, task := range page.Tasks[1:] {
+		want = append(want, task.Text)
+	}
+	if page.Tasks[0].Text != "urgent" || strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("pages = %v, want %v after urgent", got, want)
+	}
+
+	page, err = s.List(ctx, ListFilter{Limit: 2}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.List(ctx, ListFilter{Sort: SortText}, page.Next); !errors.Is(err, ErrValidation) {
+		t.Fatalf("cursor with another sort: %v, want ErrValidation", err)
+	}
+	if _, err := s.List(ctx, ListFilter{}, "not a cursor"); !errors.Is(err, ErrValidation) {
+		t.Fatalf("garbage cursor: %v, want ErrValidation", err)
+	}
+}
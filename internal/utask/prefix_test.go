@@ -1,19 +1,81 @@
 package utask
 
-import "testing"
+import (
+	"errors"
+	"strings"
+	"testing"
+)
 
 func TestMatchPrefix(t *testing.T) {
-	keys := []string{"abc12345deadbeef", "abc9ffff0000", "beadfeed"}
+	keys := []string{"abc12345deadbeef", "abc9ffff0000", "beadfeed", "beadfeed01"}
 
-	if _, _, err := matchPrefix(keys, "dead"); err == nil {
+	if _, err := matchPrefix(keys, "dead"); err == nil {
 		t.Fatalf("expected not found for unmatched prefix")
 	}
 
-	if full, cands, err := matchPrefix(keys, "bead"); err != nil || len(cands) != 0 || full != "beadfeed" {
-		t.Fatalf("expected unique match, got full=%q cands=%v err=%v", full, cands, err)
+	if full, err := matchPrefix(keys, "abc1"); err != nil || full != "abc12345deadbeef" {
+		t.Fatalf("expected unique match, got full=%q err=%v", full, err)
 	}
 
-	if _, cands, err := matchPrefix(keys, "abc"); err == nil || len(cands) < 2 {
-		t.Fatalf("expected ambiguous with candidates, got err=%v cands=%v", err, cands)
+	if full, err := matchPrefix(keys, "beadfeed"); err != nil || full != "beadfeed" {
+		t.Fatalf("expected exact match to win, got full=%q err=%v", full, err)
+	}
+
+	var amb *AmbiguousError
+	if _, err := matchPrefix(keys, "abc"); !errors.As(err, &amb) || len(amb.Candidates) != 2 {
+		t.Fatalf("expected ambiguous with candidates, got err=%v", err)
+	}
+}
+
+func TestNewCandidates(t *testing.T) {
+	cands := newCandidates([]string{"abcdef0123456789", "abcdef0199999999", "abcd0000ffffffff"})
+	want := []string{"abcd0000f", "abcdef012", "abcdef019"}
+	for i, c := range cands {
+		if c.Short != want[i] || !strings.HasPrefix(c.ID, c.Short) {
+			t.Fatalf("candidate %d: got %+v, want short %s", i, c, want[i])
+		}
+	}
+	cands[1].Title = "Buy milk"
+	msg := (&AmbiguousError{Prefix: "abcd", Candidates: cands}).Error()
+	if !strings.Contains(msg, "abcdef012 (Buy milk)") || !strings.Contains(msg, "3 tasks") {
+		t.Fatalf("message: %s", msg)
+	}
+}
+
+func TestNormalizeAlias(t *testing.T) {
+	if got, err := normalizeAlias(" Release "); err != nil || got != "release" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	for _, bad := range []string{"", "1st", "a.b", "has space"} {
+		if _, err := normalizeAlias(bad); !errors.Is(err, ErrValidation) {
+			t.Fatalf("%q: want ErrValidation, got %v", bad, err)
+		}
+	}
+}
+
+func TestMatchPrefixTypedErrors(t *testing.T) {
+	keys := []string{"abc1", "abc2"}
+	if _, err := matchPrefix(keys, "f"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("want ErrNotFound, got %v", err)
+	}
+	_, err := matchPrefix(keys, "abc")
+	var amb *AmbiguousError
+	if !errors.Is(err, ErrAmbiguousPrefix) || !errors.As(err, &amb) || len(amb.Candidates) != 2 {
+		t.Fatalf("want AmbiguousError with 2 candidates, got %v", err)
+	}
+	if err := ValidateTask(Task{ID: "x"}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("want ErrValidation, got %v", err)
+	}
+}
+
+func TestCheckRevision(t *testing.T) {
+	if err := checkRevision("a", 0, 9); err != nil {
+		t.Fatalf("zero expectation should accept any revision: %v", err)
+	}
+	if err := checkRevision("a", 9, 9); err != nil {
+		t.Fatalf("matching revision rejected: %v", err)
+	}
+	if err := checkRevision("a", 8, 9); !errors.Is(err, ErrConflict) {
+		t.Fatalf("want ErrConflict, got %v", err)
 	}
 }
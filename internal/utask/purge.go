@@ -0,0 +1,51 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Purge deletes the profile from the server (or SQLite file): its tasks,
+// tag index, meta (aliases, claims, cached counts), sync, undo, trash and
+// trailer index buckets and its audit stream, with all of their history.
+// Nothing can be recovered afterwards, and this Store must not be used
+// again. It returns the names of what it removed; what is already gone is
+// skipped, so an interrupted purge is safe to repeat.
+func (s *Store) Purge(ctx context.Context) ([]string, error) {
+	if err := s.authorize(ctx, "purge", RoleAdmin); err != nil {
+		return nil, err
+	}
+	if s.db != nil {
+		removed, err := s.purgeSQLite(ctx)
+		if err == nil {
+			s.log.InfoContext(ctx, "profile purged", "op", "purge", "removed", removed)
+		}
+		return removed, err
+	}
+	tasks, tags := bucketNames(s.ns)
+	removed := []string{}
+	for _, name := range []string{tasks, tags, metaBucketName(s.ns), syncBucketName(s.ns), undoBucketName(s.ns), trashBucketName(s.ns), trailerBucketName(s.ns)} {
+		err := s.js.DeleteKeyValue(ctx, name)
+		if errors.Is(err, jetstream.ErrBucketNotFound) {
+			continue
+		}
+		if err != nil {
+			return removed, fmt.Errorf("delete bucket %s: %w", name, err)
+		}
+		removed = append(removed, name)
+	}
+	stream, _ := auditNames(s.ns)
+	err := s.js.DeleteStream(ctx, stream)
+	switch {
+	case errors.Is(err, jetstream.ErrStreamNotFound):
+	case err != nil:
+		return removed, fmt.Errorf("delete stream %s: %w", stream, err)
+	default:
+		removed = append(removed, stream)
+	}
+	s.log.InfoContext(ctx, "profile purged", "op", "purge", "removed", removed)
+	return removed, nil
+}
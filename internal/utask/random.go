@@ -0,0 +1,66 @@
+package utask
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Urgency scores how pressing an open task is at now, for weighted picks:
+// higher priority (1 = highest) and a near or past due date raise it.
+// Every open task scores at least 1; an unset priority adds nothing, so it
+// weighs less than any set one, as it sorts after them.
+func (t Task) Urgency(now time.Time) float64 {
+	u := 1.0
+	if t.Priority > 0 {
+		u += 4 / float64(t.Priority)
+	}
+	if due, ok := t.DueAt(now.Location()); ok {
+		switch left := due.Sub(now); {
+		case left < 0:
+			u += 4
+		case left < 24*time.Hour:
+			u += 2
+		case left < 7*24*time.Hour:
+			u++
+		}
+	}
+	return u
+}
+
+// Pickable reports whether t is worth suggesting to work on at now: it is
+// open, not marked blocked, not snoozed and not waiting on a reviewer.
+// Open dependencies are the caller's to rule out (see ListFilter.Ready).
+func (t Task) Pickable(now time.Time) bool {
+	return !t.Done && t.State() != StatusBlocked && t.ReviewRequestedBy == "" && !t.Snoozed(now)
+}
+
+// PickRandom chooses one pickable task from tasks, uniformly or, when
+// weighted, in proportion to Urgency. It reports false if none qualify.
+func PickRandom(tasks []Task, weighted bool, now time.Time, r *rand.Rand) (Task, bool) {
+	var pool []Task
+	var weights []float64
+	total := 0.0
+	for _, t := range tasks {
+		if !t.Pickable(now) {
+			continue
+		}
+		w := 1.0
+		if weighted {
+			w = t.Urgency(now)
+		}
+		pool = append(pool, t)
+		weights = append(weights, w)
+		total += w
+	}
+	if len(pool) == 0 {
+		return Task{}, false
+	}
+	x := r.Float64() * total
+	for i, w := range weights {
+		if x < w {
+			return pool[i], true
+		}
+		x -= w
+	}
+	return pool[len(pool)-1], true
+}
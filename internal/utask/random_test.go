@@ -0,0 +1,49 @@
+package utask
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+func TestUrgency(t *testing.T) {
+	now := time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		task Task
+		want float64
+	}{
+		{Task{}, 1},
+		{Task{Priority: 1}, 5},
+		{Task{Priority: 4}, 2},
+		{Task{Text: "a\n\nDue: 2026-03-01"}, 5},
+		{Task{Priority: 2, Text: "a\n\nDue: 2026-03-01"}, 7},
+		{Task{Priority: 4, Text: "a\n\nDue: 2026-03-05"}, 4},
+		{Task{Priority: 4, Text: "a\n\nDue: 2026-03-09"}, 3},
+		{Task{Priority: 4, Text: "a\n\nDue: 2026-04-01"}, 2},
+	}
+	for _, c := range cases {
+		if got := c.task.Urgency(now); got != c.want {
+			t.Fatalf("Urgency(%+v) = %v, want %v", c.task, got, c.want)
+		}
+	}
+}
+
+func TestPickRandom(t *testing.T) {
+	now := time.Now()
+	r := rand.New(rand.NewPCG(1, 2))
+	if _, ok := PickRandom([]Task{{ID: "a", Done: true}, {ID: "b", ReviewRequestedBy: "ada"}, {ID: "c", Status: StatusBlocked}}, false, now, r); ok {
+		t.Fatal("picked an ineligible task")
+	}
+	tasks := []Task{{ID: "low", Priority: 4}, {ID: "high", Priority: 1, Text: "x\n\nDue: 2000-01-01"}, {ID: "done", Done: true}}
+	counts := map[string]int{}
+	for range 1000 {
+		got, ok := PickRandom(tasks, true, now, r)
+		if !ok {
+			t.Fatal("no pick")
+		}
+		counts[got.ID]++
+	}
+	if counts["done"] != 0 || counts["low"] == 0 || counts["high"] < 3*counts["low"] {
+		t.Fatalf("unexpected weighted spread %v", counts)
+	}
+}
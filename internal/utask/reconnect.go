@@ -0,0 +1,167 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// DefaultRetries is how many more times a NATS read is tried after timing
+// out, unless Options.Retries says otherwise.
+const DefaultRetries = 2
+
+// retryWait is the pause before the first retry; it doubles after each.
+const retryWait = 100 * time.Millisecond
+
+// retries is Options.Retries with its default applied; FailFast turns
+// retries off.
+func (o Options) retries() int {
+	switch {
+	case o.FailFast || o.Retries < 0:
+		return 0
+	case o.Retries == 0:
+		return DefaultRetries
+	}
+	return o.Retries
+}
+
+// natsOptions returns the connection options for opts: timeout, reconnect
+// policy, and handlers that log the connection dropping and coming back.
+func natsOptions(opts Options, log *slog.Logger, closed chan struct{}) []nats.Option {
+	out := []nats.Option{
+		nats.ClosedHandler(func(*nats.Conn) { close(closed) }),
+		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
+			if err != nil {
+				log.Warn("nats disconnected", "err", err)
+			}
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			log.Info("nats reconnected", "url", nc.ConnectedUrlRedacted())
+		}),
+	}
+	if opts.Timeout > 0 {
+		out = append(out, nats.Timeout(opts.Timeout))
+	}
+	if opts.ReconnectWait > 0 {
+		out = append(out, nats.ReconnectWait(opts.ReconnectWait))
+	}
+	if opts.MaxReconnects != 0 {
+		out = append(out, nats.MaxReconnects(opts.MaxReconnects))
+	}
+	if opts.FailFast {
+		// Writes made while disconnected fail instead of being buffered
+		// for the reconnect.
+		out = append(out, nats.ReconnectBufSize(-1))
+	}
+	return out
+}
+
+// unreachable reports that the server at servers could not be reached for
+// an operation on a profile's bucket. The error matches ErrUnavailable.
+func unreachable(servers, profile, bucket string, err error) error {
+	return fmt.Errorf("cannot reach NATS at %s (profile %s, bucket %s): %w: %w", redactURLs(servers), profile, bucket, ErrUnavailable, err)
+}
+
+// redactURLs masks passwords in a comma-separated list of server URLs.
+func redactURLs(servers string) string {
+	parts := strings.Split(servers, ",")
+	for i, p := range parts {
+		if u, err := url.Parse(strings.TrimSpace(p)); err == nil && u.User != nil {
+			parts[i] = u.Redacted()
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// transient reports whether err is a NATS timeout worth retrying: the
+// server didn't answer in time, but the caller's context is still live.
+func transient(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return errors.Is(err, nats.ErrTimeout) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// resilientKV wraps a NATS bucket. Reads and plain puts are retried after
+// a timeout; compare-and-set writes are not, as one that timed out may
+// still have landed, and their callers retry conflicts anyway. With
+// FailFast, every operation fails at once while the connection is down.
+type resilientKV struct {
+	jetstream.KeyValue
+	nc       *nats.Conn
+	servers  string
+	profile  string
+	retries  int
+	failFast bool
+}
+
+func (s *Store) resilient(kv jetstream.KeyValue, servers string) jetstream.KeyValue {
+	return &resilientKV{KeyValue: kv, nc: s.nc, servers: servers, profile: s.ns, retries: s.opts.retries(), failFast: s.opts.FailFast}
+}
+
+// kvDo runs op against kv, retrying it after timeouts if idempotent.
+func kvDo[T any](ctx context.Context, kv *resilientKV, idempotent bool, op func() (T, error)) (T, error) {
+	wait := retryWait
+	for attempt := 0; ; attempt++ {
+		if kv.failFast && !kv.nc.IsConnected() {
+			var zero T
+			return zero, unreachable(kv.servers, kv.profile, kv.Bucket(), fmt.Errorf("connection %s", kv.nc.Status()))
+		}
+		v, err := op()
+		if err == nil || !idempotent || attempt >= kv.retries || !transient(ctx, err) {
+			return v, err
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return v, err
+		case <-t.C:
+		}
+		wait *= 2
+	}
+}
+
+func (kv *resilientKV) Get(ctx context.Context, key string) (jetstream.KeyValueEntry, error) {
+	return kvDo(ctx, kv, true, func() (jetstream.KeyValueEntry, error) { return kv.KeyValue.Get(ctx, key) })
+}
+
+func (kv *resilientKV) Put(ctx context.Context, key string, value []byte) (uint64, error) {
+	return kvDo(ctx, kv, true, func() (uint64, error) { return kv.KeyValue.Put(ctx, key, value) })
+}
+
+func (kv *resilientKV) Create(ctx context.Context, key string, value []byte, opts ...jetstream.KVCreateOpt) (uint64, error) {
+	return kvDo(ctx, kv, false, func() (uint64, error) { return kv.KeyValue.Create(ctx, key, value, opts...) })
+}
+
+func (kv *resilientKV) Update(ctx context.Context, key string, value []byte, revision uint64) (uint64, error) {
+	return kvDo(ctx, kv, false, func() (uint64, error) { return kv.KeyValue.Update(ctx, key, value, revision) })
+}
+
+func (kv *resilientKV) Delete(ctx context.Context, key string, opts ...jetstream.KVDeleteOpt) error {
+	_, err := kvDo(ctx, kv, false, func() (struct{}, error) { return struct{}{}, kv.KeyValue.Delete(ctx, key, opts...) })
+	return err
+}
+
+func (kv *resilientKV) Keys(ctx context.Context, opts ...jetstream.WatchOpt) ([]string, error) {
+	return kvDo(ctx, kv, true, func() ([]string, error) { return kv.KeyValue.Keys(ctx, opts...) })
+}
+
+func (kv *resilientKV) ListKeys(ctx context.Context, opts ...jetstream.WatchOpt) (jetstream.KeyLister, error) {
+	return kvDo(ctx, kv, true, func() (jetstream.KeyLister, error) { return kv.KeyValue.ListKeys(ctx, opts...) })
+}
+
+func (kv *resilientKV) ListKeysFiltered(ctx context.Context, filters ...string) (jetstream.KeyLister, error) {
+	return kvDo(ctx, kv, true, func() (jetstream.KeyLister, error) { return kv.KeyValue.ListKeysFiltered(ctx, filters...) })
+}
+
+func (kv *resilientKV) Status(ctx context.Context) (jetstream.KeyValueStatus, error) {
+	return kvDo(ctx, kv, true, func() (jetstream.KeyValueStatus, error) { return kv.KeyValue.Status(ctx) })
+}
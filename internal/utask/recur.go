@@ -0,0 +1,273 @@
+package utask
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence is a parsed Task.Recur rule: every Interval days, weeks,
+// months or years, optionally on given weekdays, until a date or for a
+// number of instances.
+type Recurrence struct {
+	Freq     Freq
+	Interval int
+	// ByDay restricts a weekly rule to these days.
+	ByDay []time.Weekday
+	// Until ends the series: no instance is due after it.
+	Until time.Time
+	// Count is how many instances are left, this one included; 0 is
+	// unlimited.
+	Count int
+}
+
+// Freq is the unit of a Recurrence.
+type Freq string
+
+const (
+	Daily   Freq = "DAILY"
+	Weekly  Freq = "WEEKLY"
+	Monthly Freq = "MONTHLY"
+	Yearly  Freq = "YEARLY"
+)
+
+var (
+	recurEvery = regexp.MustCompile(`^every:(\d*)\s*(d|days?|w|weeks?|m|months?|y|years?)$`)
+	everyUnits = map[byte]Freq{'d': Daily, 'w': Weekly, 'm': Monthly, 'y': Yearly}
+	unitNames  = map[Freq]string{Daily: "d", Weekly: "w", Monthly: "m", Yearly: "y"}
+	recurNames = map[string]string{
+		"daily":    "FREQ=DAILY",
+		"weekly":   "FREQ=WEEKLY",
+		"monthly":  "FREQ=MONTHLY",
+		"yearly":   "FREQ=YEARLY",
+		"weekdays": "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+	}
+	rruleDays = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+)
+
+// ParseRecur reads a recurrence rule: "every:7d", "every:2w", "every:1m"
+// (months) or "every:1y"; daily, weekly, monthly, yearly or weekdays; or
+// an RRULE subset with FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL,
+// BYDAY (weekly only), UNTIL (YYYYMMDD) and COUNT, e.g.
+// "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH".
+func ParseRecur(s string) (Recurrence, error) {
+	s = strings.TrimSpace(s)
+	lower := strings.ToLower(s)
+	if m := recurEvery.FindStringSubmatch(lower); m != nil {
+		n := 1
+		if m[1] != "" {
+			n, _ = strconv.Atoi(m[1])
+		}
+		if n < 1 {
+			return Recurrence{}, invalidf("invalid recurrence %q: interval must be at least 1", s)
+		}
+		return Recurrence{Freq: everyUnits[m[2][0]], Interval: n}, nil
+	}
+	if rule, ok := recurNames[lower]; ok {
+		s = rule
+	}
+	r := Recurrence{Interval: 1}
+	for _, part := range strings.Split(strings.TrimPrefix(strings.ToUpper(s), "RRULE:"), ";") {
+		key, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return Recurrence{}, invalidf("invalid recurrence %q (want e.g. every:7d, weekly or FREQ=WEEKLY;BYDAY=MO)", s)
+		}
+		var err error
+		switch key {
+		case "FREQ":
+			switch f := Freq(val); f {
+			case Daily, Weekly, Monthly, Yearly:
+				r.Freq = f
+			default:
+				return Recurrence{}, invalidf("invalid recurrence %q: unsupported FREQ %s", s, val)
+			}
+		case "INTERVAL":
+			if r.Interval, err = strconv.Atoi(val); err != nil || r.Interval < 1 {
+				return Recurrence{}, invalidf("invalid recurrence %q: bad INTERVAL %s", s, val)
+			}
+		case "COUNT":
+			if r.Count, err = strconv.Atoi(val); err != nil || r.Count < 1 {
+				return Recurrence{}, invalidf("invalid recurrence %q: bad COUNT %s", s, val)
+			}
+		case "UNTIL":
+			if r.Until, err = time.ParseInLocation("20060102", val, time.Local); err != nil {
+				return Recurrence{}, invalidf("invalid recurrence %q: bad UNTIL %s (want YYYYMMDD)", s, val)
+			}
+			r.Until = r.Until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		case "BYDAY":
+			for _, d := range strings.Split(val, ",") {
+				i := indexOf(rruleDays, d)
+				if i < 0 {
+					return Recurrence{}, invalidf("invalid recurrence %q: bad BYDAY %s", s, d)
+				}
+				r.ByDay = append(r.ByDay, time.Weekday(i))
+			}
+		default:
+			return Recurrence{}, invalidf("invalid recurrence %q: unsupported %s", s, key)
+		}
+	}
+	switch {
+	case r.Freq == "":
+		return Recurrence{}, invalidf("invalid recurrence %q: FREQ is required", s)
+	case len(r.ByDay) > 0 && r.Freq != Weekly:
+		return Recurrence{}, invalidf("invalid recurrence %q: BYDAY needs FREQ=WEEKLY", s)
+	}
+	return r, nil
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// String renders r in the shortest form ParseRecur reads back: every:Nu
+// when it has no BYDAY, UNTIL or COUNT, an RRULE otherwise.
+func (r Recurrence) String() string {
+	if len(r.ByDay) == 0 && r.Until.IsZero() && r.Count == 0 {
+		return fmt.Sprintf("every:%d%s", r.Interval, unitNames[r.Freq])
+	}
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = rruleDays[d]
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first time after from that r falls on. Monthly and
+// yearly rules keep the day of month, moving to the month's last day when
+// it is shorter (Jan 31 is followed by Feb 28).
+func (r Recurrence) Next(from time.Time) time.Time {
+	switch r.Freq {
+	case Daily:
+		return from.AddDate(0, 0, r.Interval)
+	case Monthly:
+		return addMonths(from, r.Interval)
+	case Yearly:
+		return addMonths(from, 12*r.Interval)
+	}
+	if len(r.ByDay) == 0 {
+		return from.AddDate(0, 0, 7*r.Interval)
+	}
+	// Weeks start on Monday; only every Interval-th week from from's own
+	// counts.
+	monday := civilDay(from).AddDate(0, 0, -((int(from.Weekday()) + 6) % 7))
+	for d := 1; ; d++ {
+		next := from.AddDate(0, 0, d)
+		if week := int(civilDay(next).Sub(monday)/(24*time.Hour)) / 7; week%r.Interval != 0 {
+			continue
+		}
+		for _, wd := range r.ByDay {
+			if next.Weekday() == wd {
+				return next
+			}
+		}
+	}
+}
+
+// civilDay is t's calendar date at UTC midnight, so days can be counted
+// across daylight saving changes.
+func civilDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(t.Day(), last)-1)
+}
+
+// normalizeRecur validates a Task.Recur value and returns its canonical
+// form; "" stays "".
+func normalizeRecur(s string) (string, error) {
+	if strings.TrimSpace(s) == "" {
+		return "", nil
+	}
+	r, err := ParseRecur(s)
+	if err != nil {
+		return "", err
+	}
+	return r.String(), nil
+}
+
+// NextInstance builds the task that follows t in its series once t is
+// closed: the same text (checklists unticked, history trailers dropped)
+// due at the next time the rule falls on after t's due date, or after
+// today when t has none. Tags, priority, estimate, assignee, contexts and
+// privacy carry over. The due date isn't part of the id, so the instance
+// is created with ForceNew rather than colliding with t. It reports false
+// when t doesn't recur or its series has ended.
+func NextInstance(t Task, now time.Time) (TaskInput, bool) {
+	if t.Recur == "" {
+		return TaskInput{}, false
+	}
+	r, err := ParseRecur(t.Recur)
+	if err != nil || r.Count == 1 {
+		return TaskInput{}, false
+	}
+	from, ok := t.dueStart(now.Location())
+	if !ok {
+		from = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	}
+	next := r.Next(from)
+	if !r.Until.IsZero() && next.After(r.Until) {
+		return TaskInput{}, false
+	}
+	if r.Count > 0 {
+		r.Count--
+	}
+	return TaskInput{
+		Text:            copyText(t, "", nil),
+		Details:         untick(t.Description),
+		Tags:            append([]string(nil), t.Tags...),
+		Priority:        t.Priority,
+		EstimateMinutes: t.EstimateMinutes,
+		Assignee:        t.Assignee,
+		Private:         t.Private,
+		Contexts:        append([]string(nil), t.Contexts...),
+		Recur:           r.String(),
+		Due:             next,
+		ForceNew:        true,
+	}, true
+}
+
+// recur creates the next instance of a task that was just closed. The
+// close has already happened, so failures are only logged.
+func (s *Store) recur(ctx context.Context, t Task) {
+	in, ok := NextInstance(t, time.Now())
+	if !ok {
+		return
+	}
+	next, existed, err := s.CreateTask(ctx, in)
+	if err != nil {
+		s.log.WarnContext(ctx, "next recurring instance not created", "op", "recur", "task", t.ID, "err", err)
+		return
+	}
+	if !existed {
+		s.log.InfoContext(ctx, "created next recurring instance", "op", "recur", "task", t.ID, "next", next.ID)
+	}
+}
+
+// matchRecurring applies ListFilter.Recurring.
+func matchRecurring(t Task, f ListFilter) bool {
+	return !f.Recurring || !t.Done && t.Recur != ""
+}
@@ -0,0 +1,124 @@
+package utask
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseRecur(t *testing.T) {
+	cases := map[string]string{
+		"every:7d":                           "every:7d",
+		"every:2 weeks":                      "every:2w",
+		"every:month":                        "every:1m",
+		"weekly":                             "every:1w",
+		"weekdays":                           "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+		"RRULE:FREQ=YEARLY":                  "every:1y",
+		"freq=weekly;interval=2;byday=mo,th": "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH",
+		"FREQ=MONTHLY;COUNT=3":               "FREQ=MONTHLY;COUNT=3",
+		"FREQ=DAILY;UNTIL=20261231":          "FREQ=DAILY;UNTIL=20261231",
+	}
+	for in, want := range cases {
+		r, err := ParseRecur(in)
+		if err != nil {
+			t.Fatalf("ParseRecur(%q): %v", in, err)
+		}
+		if got := r.String(); got != want {
+			t.Errorf("ParseRecur(%q) = %s, want %s", in, got, want)
+		}
+	}
+	for _, in := range []string{"", "every:0d", "every:3x", "FREQ=HOURLY", "FREQ=DAILY;BYDAY=MO", "INTERVAL=2", "FREQ=WEEKLY;BYDAY=XX"} {
+		if _, err := ParseRecur(in); err == nil {
+			t.Errorf("ParseRecur(%q) succeeded", in)
+		}
+	}
+}
+
+func TestRecurNext(t *testing.T) {
+	// A Thursday.
+	from := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"every:3d":                        time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC),
+		"every:2w":                        time.Date(2026, 3, 19, 0, 0, 0, 0, time.UTC),
+		"weekdays":                        time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC),
+		"FREQ=WEEKLY;BYDAY=MO":            time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC),
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO": time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC),
+		"every:1y":                        time.Date(2027, 3, 5, 0, 0, 0, 0, time.UTC),
+	}
+	for rule, want := range cases {
+		r, err := ParseRecur(rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.Next(from); !got.Equal(want) {
+			t.Errorf("%s: Next = %v, want %v", rule, got, want)
+		}
+	}
+	monthly, _ := ParseRecur("monthly")
+	if got, want := monthly.Next(time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)), time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("monthly from Jan 31 = %v, want %v", got, want)
+	}
+}
+
+func TestNextInstance(t *testing.T) {
+	now := time.Date(2026, 3, 5, 15, 0, 0, 0, time.UTC)
+	task := Task{ID: "a", Text: "Water plants\n\n- [x] ferns\n\nDue: 2026-03-01\nApproved-by: Ada", Tags: []string{"home"}, Assignee: "Bob", Recur: "FREQ=WEEKLY;COUNT=2", Done: true}
+	in, ok := NextInstance(task, now)
+	if !ok {
+		t.Fatal("no next instance")
+	}
+	if want := "Water plants\n\n- [ ] ferns"; in.Text != want || FormatDue(in.Due) != "2026-03-08" || !in.ForceNew {
+		t.Fatalf("text %q due %s, want %q due 2026-03-08", in.Text, FormatDue(in.Due), want)
+	}
+	if in.Recur != "FREQ=WEEKLY;COUNT=1" || in.Assignee != "Bob" || in.Tags[0] != "home" {
+		t.Fatalf("next = %+v", in)
+	}
+	if _, ok := NextInstance(Task{Text: in.Text, Due: &in.Due, Recur: in.Recur}, now); ok {
+		t.Fatal("series continued past COUNT")
+	}
+	if _, ok := NextInstance(Task{Text: "x\n\nDue: 2026-12-31", Recur: "FREQ=DAILY;UNTIL=20261231"}, now.In(time.Local)); ok {
+		t.Fatal("series continued past UNTIL")
+	}
+	undated, _ := NextInstance(Task{Text: "Stretch", Recur: "every:1d"}, now)
+	if undated.Text != "Stretch" || FormatDue(undated.Due) != "2026-03-06" {
+		t.Fatalf("undated next = %q due %s", undated.Text, FormatDue(undated.Due))
+	}
+}
+
+func TestCloseRecurringTask(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	task, _, err := s.CreateTask(ctx, TaskInput{Text: "Pay rent", Due: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), Recur: "monthly"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Recur != "every:1m" {
+		t.Fatalf("recur stored as %q", task.Recur)
+	}
+	if _, _, err := s.CloseTask(ctx, task.ID); err != nil {
+		t.Fatal(err)
+	}
+	it, err := s.ListIter(ctx, ListFilter{Recurring: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	var open []Task
+	for it.Next() {
+		open = append(open, it.Task())
+	}
+	if len(open) != 1 || open[0].ID == task.ID || open[0].Due == nil || FormatDue(*open[0].Due) != "2026-04-01" {
+		t.Fatalf("recurring open tasks = %+v", open)
+	}
+	none := ""
+	if _, err := s.UpdateTask(ctx, open[0].ID, UpdateSet{Recur: &none}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.CloseTask(ctx, open[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	page, err := s.List(ctx, ListFilter{Status: StatusOpen}, "")
+	if err != nil || len(page.Tasks) != 0 {
+		t.Fatalf("open after stopping the series = %v, %v", page.Tasks, err)
+	}
+}
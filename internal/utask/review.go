@@ -0,0 +1,94 @@
+package utask
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ApprovedByTrailer is the trailer ApproveTask appends to a task's text.
+const ApprovedByTrailer = "Approved-by"
+
+// needsReview reports whether closing t needs a second identity's approval:
+// it carries one of Options.ReviewTags.
+func (s *Store) needsReview(t Task) bool {
+	for _, tag := range t.Tags {
+		for _, rt := range s.opts.ReviewTags {
+			if strings.EqualFold(tag, rt) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// requestReview turns a close of t into a review request: t stays open and
+// records who asked. It reports false when the close can go ahead.
+func (s *Store) requestReview(ctx context.Context, t *Task) bool {
+	if !s.needsReview(*t) {
+		return false
+	}
+	t.setStatus(StatusOpen)
+	if t.ReviewRequestedBy == "" {
+		t.ReviewRequestedBy = s.identity(ctx)
+		if t.ReviewRequestedBy == "" {
+			t.ReviewRequestedBy = "unknown"
+		}
+	}
+	return true
+}
+
+// ApproveTask closes a task awaiting review, appending an Approved-by
+// trailer with the caller's identity. The approver must be someone other
+// than whoever requested the review; otherwise it fails with ErrForbidden.
+// A non-zero ifRev makes it fail with ErrConflict unless the task is still
+// at that revision.
+func (s *Store) ApproveTask(ctx context.Context, id string, ifRev uint64) (Task, error) {
+	if err := s.authorize(ctx, "approve", RoleContributor); err != nil {
+		return Task{}, err
+	}
+	t, rev, err := s.GetTask(ctx, id)
+	if err != nil {
+		return Task{}, err
+	}
+	if err := checkRevision(id, ifRev, rev); err != nil {
+		return Task{}, err
+	}
+	if t.ReviewRequestedBy == "" {
+		return Task{}, invalidf("task %s is not awaiting review", id)
+	}
+	who := s.identity(ctx)
+	if who == "" {
+		return Task{}, fmt.Errorf("approve: %w: approving needs an identity", ErrForbidden)
+	}
+	if SameIdentity(who, t.ReviewRequestedBy) {
+		return Task{}, fmt.Errorf("approve: %w: %s requested the review; a second identity must approve", ErrForbidden, t.ReviewRequestedBy)
+	}
+	before := t
+	t.Text = appendTrailer(t.Text, ApprovedByTrailer, who)
+	t.setStatus(StatusDone)
+	t.ReviewRequestedBy = ""
+	t.touch(who, time.Now())
+	newRev, err := s.putTaskCAS(ctx, id, t, rev)
+	if err != nil {
+		return Task{}, err
+	}
+	t.Revision = newRev
+	s.logWrite(ctx, "approve", id, newRev)
+	s.audit(ctx, ActivityApproved, t, "")
+	s.dropClaim(ctx, id)
+	s.recur(ctx, t)
+	return t, s.reindexStatus(ctx, id, &before, &t)
+}
+
+// appendTrailer adds "key: value" to the trailer block at the end of text,
+// starting one after a blank line if text has none yet.
+func appendTrailer(text, key, value string) string {
+	text = strings.TrimRight(text, " \t\n")
+	line := key + ": " + value
+	if _, trs, ok := (Task{Text: text}).trailerBlock(); ok && len(trs) > 0 {
+		return text + "\n" + line
+	}
+	return text + "\n\n" + line
+}
@@ -0,0 +1,43 @@
+package utask
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAppendTrailer(t *testing.T) {
+	cases := map[string]string{
+		"Ship it":                             "Ship it\n\nApproved-by: Bob",
+		"Ship it\n\nnotes here\n":             "Ship it\n\nnotes here\n\nApproved-by: Bob",
+		"Ship it\n\nnotes\n\nTicket: OPS-1\n": "Ship it\n\nnotes\n\nTicket: OPS-1\nApproved-by: Bob",
+	}
+	for in, want := range cases {
+		got := appendTrailer(in, ApprovedByTrailer, "Bob")
+		if got != want {
+			t.Fatalf("appendTrailer(%q) = %q, want %q", in, got, want)
+		}
+		if trs := (Task{Text: got}).Trailers(); len(trs) == 0 || trs[len(trs)-1] != (Trailer{Key: "Approved-by", Value: "Bob"}) {
+			t.Fatalf("%q: trailer not parsed back: %v", got, trs)
+		}
+	}
+}
+
+func TestRequestReview(t *testing.T) {
+	ctx := WithIdentity(context.Background(), "Ada <ada@example.org>")
+	s := &Store{opts: Options{ReviewTags: []string{"release"}}}
+	plain := Task{Tags: []string{"docs"}, Done: true}
+	if s.requestReview(ctx, &plain) || !plain.Done {
+		t.Fatal("untagged task should close without review")
+	}
+	gated := Task{Tags: []string{"Release"}, Done: true}
+	if !s.requestReview(ctx, &gated) || gated.Done || gated.ReviewRequestedBy != "Ada <ada@example.org>" {
+		t.Fatalf("tagged task should await review, got %+v", gated)
+	}
+	if !matchStatus(gated, StatusReview) || !matchStatus(gated, StatusOpen) {
+		t.Fatal("pending task should match review and open")
+	}
+	evs := updateEvents(Task{Tags: gated.Tags}, gated)
+	if len(evs) != 1 || evs[0].kind != ActivityReviewRequested {
+		t.Fatalf("unexpected events %v", evs)
+	}
+}
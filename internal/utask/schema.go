@@ -0,0 +1,150 @@
+package utask
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// SchemaVersion is the task record layout written by this build. Stored
+// values and JSONL exports carry it in their "schema" field; records without
+// one predate versioning and are version 0.
+const SchemaVersion = 2
+
+// A migration upgrades a raw record by one schema version. Records are
+// generic maps so a migration can rename or reshape fields that Task no
+// longer has.
+type migration func(rec map[string]any) error
+
+// migrations[v] upgrades version v to v+1, so len(migrations) must equal
+// SchemaVersion. Append new steps; never edit released ones.
+var migrations = []migration{
+	// 0 → 1: the schema field itself; the layout is unchanged.
+	func(map[string]any) error { return nil },
+	// 1 → 2: the status field, open or done from the done flag.
+	func(rec map[string]any) error {
+		if _, ok := rec["status"]; !ok {
+			rec["status"] = string(StatusOpen)
+			if done, _ := rec["done"].(bool); done {
+				rec["status"] = string(StatusDone)
+			}
+		}
+		return nil
+	},
+}
+
+// upgradeRecord decodes a record stored at schema from into a current Task.
+// raw decodes the original value into the map it is given.
+func upgradeRecord(from int, raw func(rec *map[string]any) error) (Task, error) {
+	if from > SchemaVersion {
+		return Task{}, fmt.Errorf("task schema %d is newer than this build supports (%d); upgrade ut", from, SchemaVersion)
+	}
+	rec := map[string]any{}
+	if err := raw(&rec); err != nil {
+		return Task{}, err
+	}
+	for v := from; v < SchemaVersion; v++ {
+		if err := migrations[v](rec); err != nil {
+			return Task{}, fmt.Errorf("migrate task schema %d to %d: %w", v, v+1, err)
+		}
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return Task{}, err
+	}
+	var t Task
+	if err := json.Unmarshal(b, &t); err != nil {
+		return Task{}, fmt.Errorf("migrate task schema %d: %w", from, err)
+	}
+	return t, nil
+}
+
+// decodeJSONTask decodes a JSON record, migrating it if it is older than
+// SchemaVersion, and reports the version it was written at. The returned
+// task's Schema is cleared; writers stamp the current version.
+func decodeJSONTask(b []byte) (Task, int, error) {
+	var t Task
+	if err := json.Unmarshal(b, &t); err != nil {
+		return Task{}, 0, err
+	}
+	from := t.Schema
+	if from != SchemaVersion {
+		var err error
+		t, err = upgradeRecord(from, func(rec *map[string]any) error { return json.Unmarshal(b, rec) })
+		if err != nil {
+			return Task{}, from, err
+		}
+	}
+	t.Schema = 0
+	return t, from, nil
+}
+
+// MigrateReport summarizes a Migrate run.
+type MigrateReport struct {
+	Tasks    int `json:"tasks"`
+	Migrated int `json:"migrated"`
+	// Skipped counts tasks changed by another writer mid-run; those writes
+	// already used the current schema.
+	Skipped int `json:"skipped"`
+}
+
+// Migrate rewrites every task stored at an older schema in the current one.
+// Tasks are upgraded on read anyway, so this only makes the stored data
+// uniform before older readers are retired. Each rewrite is a compare-and-set
+// on the revision that was read, and the tag index is rebuilt afterwards in
+// case a migration changed tags.
+func (s *Store) Migrate(ctx context.Context) (MigrateReport, error) {
+	var rep MigrateReport
+	if err := s.authorize(ctx, "migrate", RoleAdmin); err != nil {
+		return rep, err
+	}
+	ids, err := s.taskIDs(ctx)
+	if err != nil {
+		return rep, err
+	}
+	var errs []error
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return rep, err
+		}
+		key := s.taskKey(id)
+		e, err := s.tasksKV.Get(ctx, key)
+		if err != nil {
+			if errors.Is(err, jetstream.ErrKeyNotFound) {
+				continue
+			}
+			return rep, err
+		}
+		rep.Tasks++
+		t, from, err := decodeStoredTask(e.Value())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("task %s: %w", id, err))
+			continue
+		}
+		if from == SchemaVersion {
+			continue
+		}
+		b, err := encodeTask(t, s.opts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("task %s: %w", id, err))
+			continue
+		}
+		if _, err := s.tasksKV.Update(ctx, key, b, e.Revision()); err != nil {
+			if errors.Is(err, jetstream.ErrKeyExists) {
+				rep.Skipped++
+				continue
+			}
+			return rep, fmt.Errorf("task %s: %w", id, err)
+		}
+		rep.Migrated++
+	}
+	if rep.Migrated > 0 {
+		if err := s.RebuildIndex(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("rebuild index: %w", err))
+		}
+	}
+	return rep, errors.Join(errs...)
+}
@@ -0,0 +1,33 @@
+package utask
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMigrationsCoverSchema(t *testing.T) {
+	if len(migrations) != SchemaVersion {
+		t.Fatalf("%d migrations for schema %d", len(migrations), SchemaVersion)
+	}
+}
+
+func TestDecodeUnversionedTask(t *testing.T) {
+	got, from, err := decodeStoredTask([]byte(`{"id":"abc","text":"old","tags":["a"],"created":"2024-01-01T00:00:00Z"}`))
+	if err != nil || from != 0 || got.Text != "old" || got.Schema != 0 {
+		t.Fatalf("got %+v from=%d err=%v", got, from, err)
+	}
+	b, err := encodeTask(got, Options{Encoding: EncodingMsgpack})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, from, err := decodeStoredTask(b); err != nil || from != SchemaVersion {
+		t.Fatalf("re-encoded: from=%d err=%v", from, err)
+	}
+}
+
+func TestDecodeNewerSchemaFails(t *testing.T) {
+	_, _, err := decodeStoredTask([]byte(`{"id":"abc","text":"x","schema":99}`))
+	if err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Fatalf("got %v", err)
+	}
+}
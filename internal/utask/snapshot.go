@@ -0,0 +1,244 @@
+package utask
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Snapshot is an on-disk copy of a profile's tasks used for instant reads.
+// Revision is the last tasks-bucket stream sequence applied, so SyncSnapshot
+// only has to replay newer revisions. The tag index is derived from Tasks.
+type Snapshot struct {
+	Profile  string          `json:"profile"`
+	Revision uint64          `json:"revision"`
+	Tasks    map[string]Task `json:"tasks"`
+}
+
+// SnapshotPath returns the default cache file for a profile.
+func SnapshotPath(profile string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".utask", "cache", profile+".json"), nil
+}
+
+// LoadSnapshot reads a snapshot; a missing file yields an empty snapshot.
+func LoadSnapshot(path, profile string) (*Snapshot, error) {
+	snap := &Snapshot{Profile: profile, Tasks: map[string]Task{}}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return snap, nil
+		}
+		return nil, fmt.Errorf("read snapshot: %w", err)
+	}
+	if err := json.Unmarshal(b, snap); err != nil || snap.Profile != profile {
+		// Corrupt or foreign cache: start over rather than fail the read.
+		return &Snapshot{Profile: profile, Tasks: map[string]Task{}}, nil
+	}
+	if snap.Tasks == nil {
+		snap.Tasks = map[string]Task{}
+	}
+	return snap, nil
+}
+
+// Save writes the snapshot atomically.
+func (snap *Snapshot) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".snapshot-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Empty reports whether the snapshot has never been synced.
+func (snap *Snapshot) Empty() bool { return snap.Revision == 0 }
+
+// Query filters the snapshot like List (tag) or Query (anyTags/allTags) and
+// then by status. Results are ordered by creation time.
+func (snap *Snapshot) Query(tag string, anyTags, allTags []string, sf Status) []Task {
+	return snap.Select(ListFilter{Tag: tag, Any: anyTags, All: allTags, Status: sf})
+}
+
+// Select is Query taking a ListFilter, ordered by f.Sort when it is set;
+// Limit is ignored.
+func (snap *Snapshot) Select(f ListFilter) []Task {
+	anyTags, allTags := normalizeTags(f.Any), normalizeTags(f.All)
+	if tag := strings.ToLower(strings.TrimSpace(f.Tag)); tag != "" && len(anyTags) == 0 && len(allTags) == 0 {
+		anyTags = []string{tag}
+	}
+	out := []Task{}
+	for _, t := range snap.Tasks {
+		if !matchStatus(t, f.Status) || !matchTags(t.Tags, anyTags, allTags) || !matchAssigneeFilter(t, f) || !matchDelegatedBy(t, f) || !matchSnoozed(t, f) || !matchContext(t, f) || !matchDue(t, f) || !matchChanged(t, f) || !matchReady(t, f, snap.closed) || !matchRecurring(t, f) || !matchTrailers(t, f) {
+			continue
+		}
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Created != out[j].Created {
+			return out[i].Created < out[j].Created
+		}
+		return out[i].ID < out[j].ID
+	})
+	if f.Sort != "" {
+		SortTasks(out, f.Sort, f.Reverse, time.Local)
+	}
+	return out
+}
+
+// closed reports whether the snapshot holds id closed, or not at all.
+func (snap *Snapshot) closed(id string) bool {
+	t, ok := snap.Tasks[id]
+	return !ok || t.Done
+}
+
+func matchStatus(t Task, sf Status) bool {
+	switch sf {
+	case StatusOpen:
+		return !t.Done
+	case StatusClosed:
+		return t.Done
+	case StatusReview:
+		return !t.Done && t.ReviewRequestedBy != ""
+	case "":
+		return true
+	}
+	return t.State() == sf
+}
+
+func matchTags(tags, anyTags, allTags []string) bool {
+	has := make(map[string]struct{}, len(tags))
+	for _, t := range tags {
+		has[t] = struct{}{}
+	}
+	if len(anyTags) > 0 {
+		found := false
+		for _, t := range anyTags {
+			if _, ok := has[t]; ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, t := range allTags {
+		if _, ok := has[t]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// SyncSnapshot replays tasks-bucket revisions newer than snap.Revision into
+// snap and returns how many were applied. An empty snapshot is filled from the
+// whole bucket history.
+func (s *Store) SyncSnapshot(ctx context.Context, snap *Snapshot) (int, error) {
+	if s.db != nil {
+		return s.syncSnapshotSQLite(ctx, snap)
+	}
+	bucket := s.tasksKV.Bucket()
+	stream, err := s.js.Stream(ctx, "KV_"+bucket)
+	if err != nil {
+		return 0, fmt.Errorf("stream info: %w", err)
+	}
+	info, err := stream.Info(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("stream info: %w", err)
+	}
+	last := info.State.LastSeq
+	if last <= snap.Revision {
+		return 0, nil
+	}
+	prefix := "$KV." + bucket + "."
+	cfg := jetstream.OrderedConsumerConfig{FilterSubjects: []string{prefix + ">"}}
+	if snap.Revision > 0 {
+		cfg.DeliverPolicy = jetstream.DeliverByStartSequencePolicy
+		cfg.OptStartSeq = snap.Revision + 1
+	}
+	cons, err := stream.OrderedConsumer(ctx, cfg)
+	if err != nil {
+		return 0, fmt.Errorf("subscribe: %w", err)
+	}
+	msgs, err := cons.Messages()
+	if err != nil {
+		return 0, fmt.Errorf("subscribe: %w", err)
+	}
+	defer msgs.Stop()
+	// Next has no context; stopping the iterator unblocks it on cancel.
+	stop := context.AfterFunc(ctx, msgs.Stop)
+	defer stop()
+	applied := 0
+	for {
+		msg, err := msgs.Next()
+		if err != nil {
+			if ctx.Err() != nil {
+				return applied, ctx.Err()
+			}
+			return applied, err
+		}
+		meta, err := msg.Metadata()
+		if err != nil {
+			return applied, err
+		}
+		op := jetstream.KeyValuePut
+		switch msg.Headers().Get("KV-Operation") {
+		case "DEL":
+			op = jetstream.KeyValueDelete
+		case "PURGE":
+			op = jetstream.KeyValuePurge
+		}
+		s.applyRevision(ctx, snap, strings.TrimPrefix(msg.Subject(), prefix), op, msg.Data(), meta.Sequence.Stream)
+		applied++
+		if snap.Revision >= last {
+			return applied, nil
+		}
+	}
+}
+
+// applyRevision records one tasks-bucket revision of key in snap.
+func (s *Store) applyRevision(ctx context.Context, snap *Snapshot, key string, op jetstream.KeyValueOp, data []byte, rev uint64) {
+	id := keyID(key)
+	if op != jetstream.KeyValuePut {
+		delete(snap.Tasks, id)
+	} else {
+		t, err := s.decodeTask(data)
+		switch {
+		case err != nil:
+		case !s.canSee(ctx, t):
+			// Private to someone else, possibly only since this revision.
+			delete(snap.Tasks, id)
+		default:
+			t.Revision = rev
+			snap.Tasks[id] = t
+		}
+	}
+	snap.Revision = rev
+}
@@ -0,0 +1,35 @@
+package utask
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshotQueryAndPersist(t *testing.T) {
+	snap := &Snapshot{Profile: "p", Revision: 7, Tasks: map[string]Task{
+		"a": {ID: "a", Tags: []string{"work", "urgent"}},
+		"b": {ID: "b", Tags: []string{"work"}, Done: true},
+		"c": {ID: "c", Tags: []string{"home"}},
+	}}
+	if got := snap.Query("work", nil, nil, StatusOpen); len(got) != 1 || got[0].ID != "a" {
+		t.Fatalf("tag+status filter: %+v", got)
+	}
+	if got := snap.Query("", []string{"HOME", "urgent"}, nil, ""); len(got) != 2 {
+		t.Fatalf("any filter: %+v", got)
+	}
+	if got := snap.Query("", nil, []string{"work", "urgent"}, ""); len(got) != 1 {
+		t.Fatalf("all filter: %+v", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "cache", "p.json")
+	if err := snap.Save(path); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	back, err := LoadSnapshot(path, "p")
+	if err != nil || back.Revision != 7 || len(back.Tasks) != 3 {
+		t.Fatalf("load: %+v err=%v", back, err)
+	}
+	if other, _ := LoadSnapshot(path, "q"); !other.Empty() {
+		t.Fatalf("snapshot of another profile must not be reused")
+	}
+}
@@ -0,0 +1,94 @@
+package utask
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Snoozed reports whether t is open and snoozed past now.
+func (t Task) Snoozed(now time.Time) bool {
+	return !t.Done && t.SnoozedUntil != nil && now.Before(*t.SnoozedUntil)
+}
+
+// SnoozeTask hides an open task from listings until the given time; a zero
+// until wakes it now. A non-zero ifRev makes it fail with ErrConflict unless
+// the task is still at that revision.
+func (s *Store) SnoozeTask(ctx context.Context, id string, until time.Time, ifRev uint64) (Task, error) {
+	if err := s.authorize(ctx, "snooze", RoleContributor); err != nil {
+		return Task{}, err
+	}
+	t, rev, err := s.GetTask(ctx, id)
+	if err != nil {
+		return Task{}, err
+	}
+	if err := checkRevision(id, ifRev, rev); err != nil {
+		return Task{}, err
+	}
+	detail := "woken"
+	if until.IsZero() {
+		if t.SnoozedUntil == nil {
+			return t, nil
+		}
+		t.SnoozedUntil = nil
+	} else {
+		if t.Done {
+			return Task{}, invalidf("task %s is closed", id)
+		}
+		u := until.UTC().Truncate(time.Second)
+		t.SnoozedUntil = &u
+		detail = "until " + u.Format(time.RFC3339)
+	}
+	t.touch(s.identity(ctx), time.Now())
+	newRev, err := s.putTaskCAS(ctx, id, t, rev)
+	if err != nil {
+		return Task{}, err
+	}
+	t.Revision = newRev
+	s.logWrite(ctx, "snooze", id, newRev)
+	s.audit(ctx, ActivitySnoozed, t, detail)
+	return t, nil
+}
+
+// matchSnoozed applies ListFilter.Snoozed and HideSnoozed at the current
+// time.
+func matchSnoozed(t Task, f ListFilter) bool {
+	if !f.Snoozed && !f.HideSnoozed {
+		return true
+	}
+	return t.Snoozed(time.Now()) == f.Snoozed
+}
+
+var snoozeMonths = regexp.MustCompile(`^(\d+)\s*(?:m|mo|months?)$`)
+
+// ParseSnooze turns a `ut snooze --until` value into a wake-up time:
+// tomorrow, next-week (Monday) and next-month (the 1st) wake at the start
+// of that day; "3m" or "3mo" is months from now, other durations such as
+// "4h", "2d" or "1w" go through ParseDuration; a YYYY-MM-DD date wakes at
+// its start in now's location.
+func ParseSnooze(s string, now time.Time) (time.Time, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch s {
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "next-week", "next week":
+		return today.AddDate(0, 0, daysUntil(today, time.Monday)), nil
+	case "next-month", "next month":
+		return time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, now.Location()), nil
+	}
+	if m := snoozeMonths.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return now.AddDate(0, n, 0), nil
+	}
+	if d, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return d, nil
+	}
+	d, err := ParseDuration(s)
+	if err != nil || d <= 0 {
+		return time.Time{}, invalidf("invalid snooze %q: use tomorrow, next-week, next-month, a duration like 4h, 2d, 1w or 1m (months), or YYYY-MM-DD", s)
+	}
+	return now.Add(d), nil
+}
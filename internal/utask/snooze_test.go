@@ -0,0 +1,60 @@
+package utask
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSnooze(t *testing.T) {
+	// A Thursday.
+	now := time.Date(2026, 3, 5, 15, 30, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"tomorrow":   time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC),
+		"next-week":  time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC),
+		"next-month": time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		"1m":         time.Date(2026, 4, 5, 15, 30, 0, 0, time.UTC),
+		"2mo":        time.Date(2026, 5, 5, 15, 30, 0, 0, time.UTC),
+		"4h":         now.Add(4 * time.Hour),
+		"1w":         now.AddDate(0, 0, 7),
+		"2026-03-20": time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC),
+	}
+	for in, want := range cases {
+		got, err := ParseSnooze(in, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseSnooze(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "later", "-2h"} {
+		if _, err := ParseSnooze(in, now); err == nil {
+			t.Errorf("ParseSnooze(%q) succeeded", in)
+		}
+	}
+}
+
+func TestMatchSnoozed(t *testing.T) {
+	later := time.Now().Add(time.Hour)
+	earlier := time.Now().Add(-time.Hour)
+	snoozed := Task{SnoozedUntil: &later}
+	woken := Task{SnoozedUntil: &earlier}
+	closed := Task{Done: true, SnoozedUntil: &later}
+	for _, c := range []struct {
+		t    Task
+		f    ListFilter
+		want bool
+	}{
+		{snoozed, ListFilter{}, true},
+		{snoozed, ListFilter{HideSnoozed: true}, false},
+		{snoozed, ListFilter{Snoozed: true}, true},
+		{woken, ListFilter{HideSnoozed: true}, true},
+		{woken, ListFilter{Snoozed: true}, false},
+		{closed, ListFilter{HideSnoozed: true}, true},
+		{Task{}, ListFilter{Snoozed: true}, false},
+	} {
+		if got := matchSnoozed(c.t, c.f); got != c.want {
+			t.Errorf("matchSnoozed(%+v, %+v) = %v, want %v", c.t, c.f, got, c.want)
+		}
+	}
+	if snoozed.Pickable(time.Now()) || !woken.Pickable(time.Now()) {
+		t.Fatal("snoozed tasks should not be pickable until they wake")
+	}
+}
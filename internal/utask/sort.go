@@ -0,0 +1,111 @@
+package utask
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SortKey orders a listing (see ListFilter.Sort and SortTasks).
+type SortKey string
+
+const (
+	// SortPriority puts the highest priority (1) first and tasks without
+	// one last, then the oldest.
+	SortPriority SortKey = "priority"
+	// SortCreated puts the oldest first.
+	SortCreated SortKey = "created"
+	// SortDue puts the soonest due first and undated tasks last.
+	SortDue SortKey = "due"
+	// SortText orders by text, ignoring case.
+	SortText SortKey = "text"
+)
+
+// DefaultSort is how ut list and the API order tasks unless told otherwise.
+const DefaultSort = SortPriority
+
+// ParseSortKey reads a sort key; empty is DefaultSort.
+func ParseSortKey(s string) (SortKey, error) {
+	switch k := SortKey(strings.ToLower(strings.TrimSpace(s))); k {
+	case "":
+		return DefaultSort, nil
+	case SortPriority, SortCreated, SortDue, SortText:
+		return k, nil
+	}
+	return "", invalidf("invalid sort %q (want priority, created, due or text)", s)
+}
+
+// SortTasks orders tasks by key, breaking ties by creation time and then
+// id so the order is total; reverse flips it. Due dates without a time are
+// read in loc.
+func SortTasks(tasks []Task, key SortKey, reverse bool, loc *time.Location) {
+	slices.SortFunc(tasks, func(a, b Task) int {
+		return positionOf(a, key, loc).compare(positionOf(b, key, loc), key, reverse)
+	})
+}
+
+// position is where a task falls in a listing sorted by a key: the fields
+// that key compares, then Created and ID.
+type position struct {
+	Priority int        `json:"p,omitempty"`
+	Due      *time.Time `json:"d,omitempty"`
+	Text     string     `json:"t,omitempty"`
+	Created  string     `json:"c"`
+	ID       string     `json:"i"`
+}
+
+func positionOf(t Task, key SortKey, loc *time.Location) position {
+	p := position{Created: t.Created, ID: t.ID}
+	switch key {
+	case SortPriority:
+		p.Priority = t.Priority
+	case SortDue:
+		if due, ok := t.DueAt(loc); ok {
+			p.Due = &due
+		}
+	case SortText:
+		p.Text = strings.ToLower(t.Text)
+	}
+	return p
+}
+
+func (a position) compare(b position, key SortKey, reverse bool) int {
+	c := 0
+	switch key {
+	case SortPriority:
+		c = comparePriority(a.Priority, b.Priority)
+	case SortDue:
+		switch {
+		case a.Due != nil && b.Due != nil:
+			c = a.Due.Compare(*b.Due)
+		case a.Due != nil:
+			c = -1
+		case b.Due != nil:
+			c = 1
+		}
+	case SortText:
+		c = cmp.Compare(a.Text, b.Text)
+	}
+	if c == 0 {
+		c = cmp.Or(cmp.Compare(a.Created, b.Created), cmp.Compare(a.ID, b.ID))
+	}
+	if reverse {
+		return -c
+	}
+	return c
+}
+
+// comparePriority orders priorities most urgent first: 1 before 2, and
+// unset (0) after any.
+func comparePriority(a, b int) int {
+	switch {
+	case a == b:
+		return 0
+	case a == 0:
+		return 1
+	case b == 0:
+		return -1
+	}
+	return cmp.Compare(a, b)
+}
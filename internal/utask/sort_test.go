@@ -0,0 +1,83 @@
+package utask
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSortTasks(t *testing.T) {
+	tasks := []Task{
+		{ID: "a", Text: "bravo\n\nDue: 2026-03-02", Priority: 1, Created: "2026-01-03T00:00:00Z"},
+		{ID: "b", Text: "Alpha", Priority: 3, Created: "2026-01-02T00:00:00Z"},
+		{ID: "c", Text: "charlie\n\nDue: 2026-03-01", Priority: 1, Created: "2026-01-01T00:00:00Z"},
+		{ID: "d", Text: "delta", Priority: 3, Created: "2026-01-02T00:00:00Z"},
+		{ID: "e", Text: "echo", Created: "2025-12-31T00:00:00Z"},
+	}
+	order := func() string {
+		var ids []string
+		for _, t := range tasks {
+			ids = append(ids, t.ID)
+		}
+		return strings.Join(ids, "")
+	}
+	cases := []struct {
+		key     SortKey
+		reverse bool
+		want    string
+	}{
+		{SortPriority, false, "cabde"},
+		{SortPriority, true, "edbac"},
+		{SortCreated, false, "ecbda"},
+		{SortDue, false, "caebd"},
+		{SortText, false, "bacde"},
+		{SortText, true, "edcab"},
+	}
+	for _, tc := range cases {
+		SortTasks(tasks, tc.key, tc.reverse, time.UTC)
+		if got := order(); got != tc.want {
+			t.Errorf("SortTasks(%s, reverse=%v) = %s, want %s", tc.key, tc.reverse, got, tc.want)
+		}
+	}
+	if k, err := ParseSortKey(""); err != nil || k != SortPriority {
+		t.Fatalf("ParseSortKey(\"\") = %q, %v", k, err)
+	}
+	if _, err := ParseSortKey("size"); err == nil {
+		t.Fatal("ParseSortKey accepted size")
+	}
+}
+
+func TestListIterSorted(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	for i, text := range []string{"high", "low", "mid"} {
+		if _, _, err := s.CreateTask(ctx, TaskInput{Text: text, Priority: []int{1, 4, 2}[i]}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	it, err := s.ListIter(ctx, ListFilter{Sort: SortPriority, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	var got []string
+	for it.Next() {
+		got = append(got, it.Task().Text)
+	}
+	if err := it.Err(); err != nil || strings.Join(got, ",") != "high,mid" {
+		t.Fatalf("sorted listing = %v, %v", got, err)
+	}
+
+	snap := &Snapshot{Tasks: map[string]Task{}}
+	if _, err := s.SyncSnapshot(ctx, snap); err != nil {
+		t.Fatal(err)
+	}
+	got = got[:0]
+	for _, t := range snap.Select(ListFilter{Sort: SortPriority, Reverse: true}) {
+		got = append(got, t.Text)
+	}
+	if strings.Join(got, ",") != "low,mid,high" {
+		t.Fatalf("sorted snapshot = %v", got)
+	}
+}
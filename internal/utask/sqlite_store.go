@@ -0,0 +1,666 @@
+package utask
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// SQLitePath returns the default database file for the sqlite driver. One
+// file holds every profile.
+func SQLitePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".utask", "tasks.db"), nil
+}
+
+// sqliteSchema mirrors what a profile keeps in JetStream: each bucket's
+// latest value per key (delete markers included, so watchers and snapshots
+// see deletes) with a per-bucket sequence standing in for the stream
+// sequence, the earlier revisions of buckets that keep history, and the
+// audit stream's messages.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS buckets (
+	name TEXT PRIMARY KEY,
+	seq  INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS kv (
+	bucket  TEXT NOT NULL,
+	key     TEXT NOT NULL,
+	seq     INTEGER NOT NULL,
+	op      INTEGER NOT NULL,
+	value   BLOB,
+	created INTEGER NOT NULL,
+	PRIMARY KEY (bucket, key)
+);
+CREATE INDEX IF NOT EXISTS kv_seq ON kv (bucket, seq);
+CREATE TABLE IF NOT EXISTS kv_history (
+	bucket  TEXT NOT NULL,
+	key     TEXT NOT NULL,
+	seq     INTEGER NOT NULL,
+	op      INTEGER NOT NULL,
+	value   BLOB,
+	created INTEGER NOT NULL,
+	PRIMARY KEY (bucket, seq)
+);
+CREATE INDEX IF NOT EXISTS kv_history_key ON kv_history (bucket, key, seq);
+CREATE TABLE IF NOT EXISTS audit (
+	seq    INTEGER PRIMARY KEY AUTOINCREMENT,
+	stream TEXT NOT NULL,
+	at     INTEGER NOT NULL,
+	data   BLOB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS audit_at ON audit (stream, at);
+`
+
+// OpenSQLite opens a profile stored in a local SQLite file, creating the
+// file and the profile's buckets on first use. The Store behaves as one
+// opened with OpenWithOptions, so every command works without a NATS
+// server; other processes using the same file see changes within
+// sqliteWatchPoll. Options.Timeout does not apply.
+func OpenSQLite(ctx context.Context, path, namespace string, opts Options) (*Store, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	// Immediate transactions take the write lock up front, so concurrent
+	// compare-and-set writers queue on the busy timeout instead of failing
+	// to upgrade a read lock.
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate")
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open sqlite %s: %w", path, err)
+	}
+	tasksName, tagsName := bucketNames(namespace)
+	kvs := make([]*sqliteKV, 0, 7)
+	for _, name := range []string{tasksName, tagsName, metaBucketName(namespace), syncBucketName(namespace), undoBucketName(namespace), trashBucketName(namespace), trailerBucketName(namespace)} {
+		if _, err := db.ExecContext(ctx, `INSERT OR IGNORE INTO buckets (name, seq) VALUES (?, 0)`, name); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("ensure bucket %s: %w", name, err)
+		}
+		kvs = append(kvs, &sqliteKV{db: db, bucket: name, history: 1})
+	}
+	kvs[0].history = opts.taskHistory()
+	s := &Store{db: db, dbPath: path, tasksKV: kvs[0], tagsKV: kvs[1], metaKV: kvs[2], syncKV: kvs[3], undoKV: kvs[4], trashKV: kvs[5], trailersKV: kvs[6], ns: namespace, opts: opts}
+	if err := s.init(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// auditSQLite appends an audit event, dropping events older than the
+// retention as the stream's MaxAge would.
+func (s *Store) auditSQLite(ctx context.Context, b []byte) error {
+	name, _ := auditNames(s.ns)
+	now := time.Now()
+	if _, err := s.db.ExecContext(ctx, `INSERT INTO audit (stream, at, data) VALUES (?, ?, ?)`, name, now.UnixNano(), b); err != nil {
+		return err
+	}
+	maxAge := s.opts.AuditRetention
+	if maxAge == 0 {
+		maxAge = DefaultAuditRetention
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM audit WHERE stream = ? AND at < ?`, name, now.Add(-maxAge).UnixNano())
+	return err
+}
+
+// auditMessagesSQLite is auditMessages for the sqlite driver.
+func (s *Store) auditMessagesSQLite(ctx context.Context, since time.Time, fn func([]byte)) error {
+	name, _ := auditNames(s.ns)
+	var from int64
+	if !since.IsZero() {
+		from = since.UnixNano()
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT data FROM audit WHERE stream = ? AND at >= ? ORDER BY seq`, name, from)
+	if err != nil {
+		return fmt.Errorf("audit stream: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return fmt.Errorf("audit stream: %w", err)
+		}
+		fn(b)
+	}
+	return rows.Err()
+}
+
+// syncSnapshotSQLite is SyncSnapshot for the sqlite driver: the tasks
+// table's sequence plays the part of the KV stream's.
+func (s *Store) syncSnapshotSQLite(ctx context.Context, snap *Snapshot) (int, error) {
+	kv := s.tasksKV.(*sqliteKV)
+	entries, err := kv.since(ctx, snap.Revision, nil, true)
+	if err != nil {
+		return 0, fmt.Errorf("read tasks: %w", err)
+	}
+	for _, e := range entries {
+		s.applyRevision(ctx, snap, e.key, e.op, e.value, e.rev)
+	}
+	return len(entries), nil
+}
+
+// purgeSQLite is Purge for the sqlite driver.
+func (s *Store) purgeSQLite(ctx context.Context) ([]string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	tasks, tags := bucketNames(s.ns)
+	removed := []string{}
+	for _, name := range []string{tasks, tags, metaBucketName(s.ns), syncBucketName(s.ns), undoBucketName(s.ns), trashBucketName(s.ns), trailerBucketName(s.ns)} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM kv WHERE bucket = ?`, name); err != nil {
+			return nil, fmt.Errorf("delete bucket %s: %w", name, err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM kv_history WHERE bucket = ?`, name); err != nil {
+			return nil, fmt.Errorf("delete bucket %s: %w", name, err)
+		}
+		res, err := tx.ExecContext(ctx, `DELETE FROM buckets WHERE name = ?`, name)
+		if err != nil {
+			return nil, fmt.Errorf("delete bucket %s: %w", name, err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			removed = append(removed, name)
+		}
+	}
+	stream, _ := auditNames(s.ns)
+	res, err := tx.ExecContext(ctx, `DELETE FROM audit WHERE stream = ?`, stream)
+	if err != nil {
+		return nil, fmt.Errorf("delete stream %s: %w", stream, err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		removed = append(removed, stream)
+	}
+	return removed, tx.Commit()
+}
+
+// sqliteWatchPoll is how often sqlite watchers look for new revisions.
+const sqliteWatchPoll = 500 * time.Millisecond
+
+// sqliteKV is a jetstream.KeyValue kept in the kv table, which holds the
+// latest revision of each key. With history above 1, the last history
+// revisions of each key are also kept in kv_history, as a bucket's
+// MaxMsgsPerSubject keeps them. Options passed to its methods are ignored
+// (jetstream keeps them opaque): watchers always skip deletes in their
+// initial values, and the compare-and-set delete and updates-only watch
+// the Store needs are reached through deleteIf and watchUpdates.
+type sqliteKV struct {
+	db      *sql.DB
+	bucket  string
+	history int
+}
+
+var _ jetstream.KeyValue = (*sqliteKV)(nil)
+
+type sqliteEntry struct {
+	bucket  string
+	key     string
+	value   []byte
+	rev     uint64
+	created time.Time
+	op      jetstream.KeyValueOp
+}
+
+func (e *sqliteEntry) Bucket() string                  { return e.bucket }
+func (e *sqliteEntry) Key() string                     { return e.key }
+func (e *sqliteEntry) Value() []byte                   { return e.value }
+func (e *sqliteEntry) Revision() uint64                { return e.rev }
+func (e *sqliteEntry) Created() time.Time              { return e.created }
+func (e *sqliteEntry) Delta() uint64                   { return 0 }
+func (e *sqliteEntry) Operation() jetstream.KeyValueOp { return e.op }
+
+// entry returns the latest revision of key, delete markers included.
+func (kv *sqliteKV) entry(ctx context.Context, key string) (*sqliteEntry, error) {
+	e := &sqliteEntry{bucket: kv.bucket, key: key}
+	var created int64
+	err := kv.db.QueryRowContext(ctx, `SELECT seq, op, value, created FROM kv WHERE bucket = ? AND key = ?`, kv.bucket, key).Scan(&e.rev, &e.op, &e.value, &created)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, jetstream.ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	e.created = time.Unix(0, created)
+	return e, nil
+}
+
+// write stores a revision of key after check, given the current revision
+// (0 if none) and whether it holds a value, accepts it.
+func (kv *sqliteKV) write(ctx context.Context, key string, op jetstream.KeyValueOp, value []byte, check func(rev uint64, live bool) error) (uint64, error) {
+	tx, err := kv.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	var (
+		rev uint64
+		cur jetstream.KeyValueOp
+	)
+	err = tx.QueryRowContext(ctx, `SELECT seq, op FROM kv WHERE bucket = ? AND key = ?`, kv.bucket, key).Scan(&rev, &cur)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+	if check != nil {
+		if err := check(rev, rev != 0 && cur == jetstream.KeyValuePut); err != nil {
+			return 0, err
+		}
+	}
+	var seq uint64
+	err = tx.QueryRowContext(ctx, `UPDATE buckets SET seq = seq + 1 WHERE name = ? RETURNING seq`, kv.bucket).Scan(&seq)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, jetstream.ErrBucketNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now().UnixNano()
+	_, err = tx.ExecContext(ctx, `INSERT INTO kv (bucket, key, seq, op, value, created) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (bucket, key) DO UPDATE SET seq = excluded.seq, op = excluded.op, value = excluded.value, created = excluded.created`,
+		kv.bucket, key, seq, op, value, now)
+	if err != nil {
+		return 0, err
+	}
+	if kv.history > 1 {
+		if err := kv.keepRevision(ctx, tx, key, seq, op, value, now); err != nil {
+			return 0, err
+		}
+	}
+	return seq, tx.Commit()
+}
+
+// keepRevision adds a revision of key to kv_history and drops the ones
+// past kv.history. A purge, as in JetStream, drops every earlier one.
+func (kv *sqliteKV) keepRevision(ctx context.Context, tx *sql.Tx, key string, seq uint64, op jetstream.KeyValueOp, value []byte, created int64) error {
+	if op == jetstream.KeyValuePurge {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM kv_history WHERE bucket = ? AND key = ?`, kv.bucket, key); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO kv_history (bucket, key, seq, op, value, created) VALUES (?, ?, ?, ?, ?, ?)`, kv.bucket, key, seq, op, value, created); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `DELETE FROM kv_history WHERE bucket = ? AND key = ? AND seq NOT IN
+		(SELECT seq FROM kv_history WHERE bucket = ? AND key = ? ORDER BY seq DESC LIMIT ?)`, kv.bucket, key, kv.bucket, key, kv.history)
+	return err
+}
+
+func (kv *sqliteKV) Get(ctx context.Context, key string) (jetstream.KeyValueEntry, error) {
+	e, err := kv.entry(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if e.op != jetstream.KeyValuePut {
+		return nil, jetstream.ErrKeyNotFound
+	}
+	return e, nil
+}
+
+func (kv *sqliteKV) GetRevision(ctx context.Context, key string, revision uint64) (jetstream.KeyValueEntry, error) {
+	entries, err := kv.History(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.Revision() == revision && e.Operation() == jetstream.KeyValuePut {
+			return e, nil
+		}
+	}
+	return nil, jetstream.ErrKeyNotFound
+}
+
+func (kv *sqliteKV) Put(ctx context.Context, key string, value []byte) (uint64, error) {
+	return kv.write(ctx, key, jetstream.KeyValuePut, value, nil)
+}
+
+func (kv *sqliteKV) PutString(ctx context.Context, key string, value string) (uint64, error) {
+	return kv.Put(ctx, key, []byte(value))
+}
+
+func (kv *sqliteKV) Create(ctx context.Context, key string, value []byte, _ ...jetstream.KVCreateOpt) (uint64, error) {
+	return kv.write(ctx, key, jetstream.KeyValuePut, value, func(_ uint64, live bool) error {
+		if live {
+			return jetstream.ErrKeyExists
+		}
+		return nil
+	})
+}
+
+func (kv *sqliteKV) Update(ctx context.Context, key string, value []byte, revision uint64) (uint64, error) {
+	return kv.write(ctx, key, jetstream.KeyValuePut, value, func(rev uint64, _ bool) error {
+		if rev != revision {
+			return jetstream.ErrKeyExists
+		}
+		return nil
+	})
+}
+
+// Delete deletes key. jetstream's delete options can't be read outside
+// that package, so rather than drop a LastRevision check Delete refuses
+// them all: conditional deletes go through deleteIf.
+func (kv *sqliteKV) Delete(ctx context.Context, key string, opts ...jetstream.KVDeleteOpt) error {
+	if len(opts) > 0 {
+		return errDeleteOpts
+	}
+	_, err := kv.write(ctx, key, jetstream.KeyValueDelete, nil, nil)
+	return err
+}
+
+// DeleteIf deletes key if its latest revision is rev, like Delete with
+// jetstream.LastRevision.
+func (kv *sqliteKV) DeleteIf(ctx context.Context, key string, rev uint64) error {
+	_, err := kv.write(ctx, key, jetstream.KeyValueDelete, nil, func(cur uint64, _ bool) error {
+		if cur != rev {
+			return jetstream.ErrKeyExists
+		}
+		return nil
+	})
+	return err
+}
+
+// Purge removes key and its history; like Delete it refuses options.
+func (kv *sqliteKV) Purge(ctx context.Context, key string, opts ...jetstream.KVDeleteOpt) error {
+	if len(opts) > 0 {
+		return errDeleteOpts
+	}
+	_, err := kv.write(ctx, key, jetstream.KeyValuePurge, nil, nil)
+	return err
+}
+
+// since returns the revisions after seq whose keys match one of filters
+// (all keys if none), oldest first.
+func (kv *sqliteKV) since(ctx context.Context, seq uint64, filters []string, deletes bool) ([]*sqliteEntry, error) {
+	rows, err := kv.db.QueryContext(ctx, `SELECT key, seq, op, value, created FROM kv WHERE bucket = ? AND seq > ? ORDER BY seq`, kv.bucket, seq)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*sqliteEntry
+	for rows.Next() {
+		e := &sqliteEntry{bucket: kv.bucket}
+		var created int64
+		if err := rows.Scan(&e.key, &e.rev, &e.op, &e.value, &created); err != nil {
+			return nil, err
+		}
+		if !deletes && e.op != jetstream.KeyValuePut || !matchAnySubject(filters, e.key) {
+			continue
+		}
+		e.created = time.Unix(0, created)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// matchAnySubject reports whether key matches one of the NATS subject
+// filters ("*" matches a token, a final ">" the rest); no filters match all.
+func matchAnySubject(filters []string, key string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, f := range filters {
+		if matchSubject(f, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSubject(filter, key string) bool {
+	ft, kt := strings.Split(filter, "."), strings.Split(key, ".")
+	for i, tok := range ft {
+		if tok == ">" {
+			return len(kt) > i
+		}
+		if i >= len(kt) || tok != "*" && tok != kt[i] {
+			return false
+		}
+	}
+	return len(ft) == len(kt)
+}
+
+type sqliteWatcher struct {
+	updates chan jetstream.KeyValueEntry
+	stop    context.CancelFunc
+}
+
+func (w *sqliteWatcher) Updates() <-chan jetstream.KeyValueEntry { return w.updates }
+func (w *sqliteWatcher) Stop() error                             { w.stop(); return nil }
+
+// watch delivers the current values matching filters, then a nil entry,
+// then new revisions as they are written; updatesOnly skips straight to
+// the new revisions, with no nil entry.
+func (kv *sqliteKV) watch(ctx context.Context, filters []string, updatesOnly bool) (jetstream.KeyWatcher, error) {
+	var last uint64
+	if err := kv.db.QueryRowContext(ctx, `SELECT seq FROM buckets WHERE name = ?`, kv.bucket).Scan(&last); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, jetstream.ErrBucketNotFound
+		}
+		return nil, err
+	}
+	var initial []*sqliteEntry
+	if !updatesOnly {
+		all, err := kv.since(ctx, 0, filters, false)
+		if err != nil {
+			return nil, err
+		}
+		// Revisions after last arrive as updates.
+		for _, e := range all {
+			if e.rev <= last {
+				initial = append(initial, e)
+			}
+		}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	w := &sqliteWatcher{updates: make(chan jetstream.KeyValueEntry, 256), stop: cancel}
+	send := func(e jetstream.KeyValueEntry) bool {
+		select {
+		case w.updates <- e:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	go func() {
+		defer close(w.updates)
+		for _, e := range initial {
+			if !send(e) {
+				return
+			}
+		}
+		if !updatesOnly && !send(nil) {
+			return
+		}
+		tick := time.NewTicker(sqliteWatchPoll)
+		defer tick.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C:
+			}
+			entries, err := kv.since(ctx, last, filters, true)
+			if err != nil {
+				continue
+			}
+			for _, e := range entries {
+				if !send(e) {
+					return
+				}
+				last = e.rev
+			}
+		}
+	}()
+	return w, nil
+}
+
+func (kv *sqliteKV) Watch(ctx context.Context, keys string, _ ...jetstream.WatchOpt) (jetstream.KeyWatcher, error) {
+	return kv.watch(ctx, []string{keys}, false)
+}
+
+func (kv *sqliteKV) WatchAll(ctx context.Context, _ ...jetstream.WatchOpt) (jetstream.KeyWatcher, error) {
+	return kv.watch(ctx, nil, false)
+}
+
+func (kv *sqliteKV) WatchFiltered(ctx context.Context, keys []string, _ ...jetstream.WatchOpt) (jetstream.KeyWatcher, error) {
+	return kv.watch(ctx, keys, false)
+}
+
+func (kv *sqliteKV) keys(ctx context.Context, filters []string) ([]string, error) {
+	entries, err := kv.since(ctx, 0, filters, false)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(entries))
+	for i, e := range entries {
+		keys[i] = e.key
+	}
+	return keys, nil
+}
+
+func (kv *sqliteKV) Keys(ctx context.Context, _ ...jetstream.WatchOpt) ([]string, error) {
+	keys, err := kv.keys(ctx, nil)
+	if err == nil && len(keys) == 0 {
+		return nil, jetstream.ErrNoKeysFound
+	}
+	return keys, err
+}
+
+type sqliteLister struct{ keys chan string }
+
+func (l *sqliteLister) Keys() <-chan string { return l.keys }
+func (l *sqliteLister) Stop() error         { return nil }
+
+// lister hands out keys already read, so Stop has nothing to release.
+func lister(keys []string) jetstream.KeyLister {
+	l := &sqliteLister{keys: make(chan string, len(keys))}
+	for _, k := range keys {
+		l.keys <- k
+	}
+	close(l.keys)
+	return l
+}
+
+func (kv *sqliteKV) ListKeys(ctx context.Context, _ ...jetstream.WatchOpt) (jetstream.KeyLister, error) {
+	keys, err := kv.keys(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return lister(keys), nil
+}
+
+func (kv *sqliteKV) ListKeysFiltered(ctx context.Context, filters ...string) (jetstream.KeyLister, error) {
+	keys, err := kv.keys(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	return lister(keys), nil
+}
+
+// History returns the revisions kept of key, oldest first: up to
+// kv.history of them, or only the latest for keys last written before
+// kv_history existed.
+func (kv *sqliteKV) History(ctx context.Context, key string, _ ...jetstream.WatchOpt) ([]jetstream.KeyValueEntry, error) {
+	var out []jetstream.KeyValueEntry
+	if kv.history > 1 {
+		rows, err := kv.db.QueryContext(ctx, `SELECT seq, op, value, created FROM kv_history WHERE bucket = ? AND key = ? ORDER BY seq`, kv.bucket, key)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			e := &sqliteEntry{bucket: kv.bucket, key: key}
+			var created int64
+			if err := rows.Scan(&e.rev, &e.op, &e.value, &created); err != nil {
+				return nil, err
+			}
+			e.created = time.Unix(0, created)
+			out = append(out, e)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	e, err := kv.entry(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 || out[len(out)-1].Revision() != e.rev {
+		out = []jetstream.KeyValueEntry{e}
+	}
+	return out, nil
+}
+
+func (kv *sqliteKV) Bucket() string { return kv.bucket }
+
+func (kv *sqliteKV) PurgeDeletes(ctx context.Context, _ ...jetstream.KVPurgeOpt) error {
+	_, err := kv.db.ExecContext(ctx, `DELETE FROM kv_history WHERE bucket = ? AND key IN (SELECT key FROM kv WHERE bucket = ? AND op != ?)`, kv.bucket, kv.bucket, jetstream.KeyValuePut)
+	if err != nil {
+		return err
+	}
+	_, err = kv.db.ExecContext(ctx, `DELETE FROM kv WHERE bucket = ? AND op != ?`, kv.bucket, jetstream.KeyValuePut)
+	return err
+}
+
+type sqliteStatus struct {
+	bucket  string
+	values  uint64
+	bytes   uint64
+	history int64
+}
+
+func (st sqliteStatus) Bucket() string                { return st.bucket }
+func (st sqliteStatus) Values() uint64                { return st.values }
+func (st sqliteStatus) History() int64                { return st.history }
+func (st sqliteStatus) TTL() time.Duration            { return 0 }
+func (st sqliteStatus) BackingStore() string          { return "SQLite" }
+func (st sqliteStatus) Bytes() uint64                 { return st.bytes }
+func (st sqliteStatus) IsCompressed() bool            { return false }
+func (st sqliteStatus) LimitMarkerTTL() time.Duration { return 0 }
+
+func (kv *sqliteKV) Status(ctx context.Context) (jetstream.KeyValueStatus, error) {
+	st := sqliteStatus{bucket: kv.bucket, history: int64(kv.history)}
+	err := kv.db.QueryRowContext(ctx, `SELECT COUNT(*), COALESCE(SUM(LENGTH(value)), 0) FROM kv WHERE bucket = ?`, kv.bucket).Scan(&st.values, &st.bytes)
+	return st, err
+}
+
+// errDeleteOpts is returned by the sqlite driver's Delete and Purge when
+// given options it can't honor.
+var errDeleteOpts = errors.New("sqlite: delete options are not supported (use deleteIf)")
+
+// revisionDeleter is a KeyValue taking a delete's expected revision
+// directly: the sqlite driver, and wrappers around it.
+type revisionDeleter interface {
+	DeleteIf(ctx context.Context, key string, rev uint64) error
+}
+
+// deleteIf deletes key from kv if its latest revision is rev.
+func deleteIf(ctx context.Context, kv jetstream.KeyValue, key string, rev uint64) error {
+	if kv, ok := kv.(revisionDeleter); ok {
+		return kv.DeleteIf(ctx, key, rev)
+	}
+	return kv.Delete(ctx, key, jetstream.LastRevision(rev))
+}
+
+// watchUpdates watches kv for revisions written from now on.
+func watchUpdates(ctx context.Context, kv jetstream.KeyValue) (jetstream.KeyWatcher, error) {
+	if kv, ok := kv.(*sqliteKV); ok {
+		return kv.watch(ctx, nil, true)
+	}
+	return kv.WatchAll(ctx, jetstream.UpdatesOnly())
+}
@@ -0,0 +1,250 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func openTestSQLite(t *testing.T) *Store {
+	t.Helper()
+	s, err := OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "tasks.db"), "test", Options{Identity: "Ada <ada@example.org>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestMatchSubject(t *testing.T) {
+	cases := []struct {
+		filter, key string
+		want        bool
+	}{
+		{"ab.>", "ab.abcdef", true},
+		{"ab.>", "ab", false},
+		{"ab.>", "cd.abcdef", false},
+		{"*.x", "ab.x", true},
+		{"*.x", "ab.x.y", false},
+		{"keyspace", "keyspace", true},
+		{">", "anything.at.all", true},
+	}
+	for _, c := range cases {
+		if got := matchSubject(c.filter, c.key); got != c.want {
+			t.Errorf("matchSubject(%q, %q) = %v", c.filter, c.key, got)
+		}
+	}
+}
+
+func TestSQLiteKV(t *testing.T) {
+	ctx := context.Background()
+	// The meta bucket starts with the status index; the sync one is empty.
+	kv := openTestSQLite(t).syncKV
+	rev, err := kv.Create(ctx, "k", []byte("v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kv.Create(ctx, "k", []byte("again")); !errors.Is(err, jetstream.ErrKeyExists) {
+		t.Fatalf("create existing: %v", err)
+	}
+	if _, err := kv.Update(ctx, "k", []byte("v2"), rev+1); !errors.Is(err, jetstream.ErrKeyExists) {
+		t.Fatalf("update at wrong revision: %v", err)
+	}
+	rev2, err := kv.Update(ctx, "k", []byte("v2"), rev)
+	if err != nil || rev2 <= rev {
+		t.Fatalf("update: rev %d, %v", rev2, err)
+	}
+	if err := deleteIf(ctx, kv, "k", rev); !errors.Is(err, jetstream.ErrKeyExists) {
+		t.Fatalf("delete at stale revision: %v", err)
+	}
+	if err := deleteIf(ctx, kv, "k", rev2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kv.Get(ctx, "k"); !errors.Is(err, jetstream.ErrKeyNotFound) {
+		t.Fatalf("get deleted: %v", err)
+	}
+	if _, err := kv.Keys(ctx); !errors.Is(err, jetstream.ErrNoKeysFound) {
+		t.Fatalf("keys of empty bucket: %v", err)
+	}
+	if _, err := kv.Create(ctx, "k", []byte("v3")); err != nil {
+		t.Fatalf("create over delete marker: %v", err)
+	}
+}
+
+func TestSQLiteDeleteStaleRevision(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	task, _, err := s.CreateTask(ctx, TaskInput{Text: "keep me"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.tasksKV.Delete(ctx, s.taskKey(task.ID), jetstream.LastRevision(task.Revision+1)); !errors.Is(err, errDeleteOpts) {
+		t.Fatalf("delete with LastRevision: %v", err)
+	}
+	// Someone edits the task after the transaction read it.
+	err = s.Txn(ctx, func(tx *Tx) error {
+		if err := tx.Delete(task.ID); err != nil {
+			return err
+		}
+		text := "edited meanwhile"
+		_, err := s.UpdateTask(ctx, task.ID, UpdateSet{Text: &text})
+		return err
+	})
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("txn delete of an edited task: %v", err)
+	}
+	if got, _, err := s.GetTask(ctx, task.ID); err != nil || got.Text != "edited meanwhile" {
+		t.Fatalf("edited task = %+v, %v", got, err)
+	}
+}
+
+func TestSQLiteKVHistory(t *testing.T) {
+	ctx := context.Background()
+	s, err := OpenSQLite(ctx, filepath.Join(t.TempDir(), "tasks.db"), "test", Options{TaskHistory: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	kv := s.tasksKV
+	var revs []uint64
+	for _, v := range []string{"v1", "v2", "v3"} {
+		rev, err := kv.Put(ctx, "k", []byte(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		revs = append(revs, rev)
+	}
+	h, err := kv.History(ctx, "k")
+	if err != nil || len(h) != 2 || string(h[0].Value()) != "v2" || string(h[1].Value()) != "v3" {
+		t.Fatalf("History = %v, %v", h, err)
+	}
+	if e, err := kv.GetRevision(ctx, "k", revs[1]); err != nil || string(e.Value()) != "v2" {
+		t.Fatalf("GetRevision(kept) = %v, %v", e, err)
+	}
+	if _, err := kv.GetRevision(ctx, "k", revs[0]); !errors.Is(err, jetstream.ErrKeyNotFound) {
+		t.Fatalf("GetRevision(dropped): %v", err)
+	}
+	if st, err := kv.Status(ctx); err != nil || st.History() != 2 {
+		t.Fatalf("Status = %v, %v", st, err)
+	}
+	if err := kv.Purge(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if h, err := kv.History(ctx, "k"); err != nil || len(h) != 1 || h[0].Operation() != jetstream.KeyValuePurge {
+		t.Fatalf("History after purge = %v, %v", h, err)
+	}
+	// Buckets without history keep only the latest revision.
+	if _, err := s.syncKV.Put(ctx, "k", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.syncKV.Put(ctx, "k", []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	if h, err := s.syncKV.History(ctx, "k"); err != nil || len(h) != 1 || string(h[0].Value()) != "b" {
+		t.Fatalf("sync History = %v, %v", h, err)
+	}
+}
+
+func TestSQLiteWatchUpdates(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	kv := openTestSQLite(t).metaKV
+	if _, err := kv.Put(ctx, "before", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	w, err := watchUpdates(ctx, kv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	if _, err := kv.Put(ctx, "after", []byte("y")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-w.Updates():
+		if e == nil || e.Key() != "after" {
+			t.Fatalf("first update = %v", e)
+		}
+	case <-ctx.Done():
+		t.Fatal("no update")
+	}
+}
+
+func TestSQLiteStore(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	milk, _, err := s.CreateTask(ctx, TaskInput{Text: "Buy milk", Tags: []string{"home"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.CreateTask(ctx, TaskInput{Text: "Write report", Tags: []string{"work"}}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Query(ctx, []string{"home"}, nil, 0)
+	if err != nil || len(got) != 1 || got[0].ID != milk.ID {
+		t.Fatalf("query home = %v, %v", got, err)
+	}
+	if id, _, err := s.Resolve(ctx, milk.ID[:6]); err != nil || id != milk.ID {
+		t.Fatalf("resolve = %q, %v", id, err)
+	}
+	if _, _, err := s.CloseTask(ctx, milk.ID); err != nil {
+		t.Fatal(err)
+	}
+	events, err := s.Audit(ctx, AuditFilter{Task: milk.ID})
+	if err != nil || len(events) != 2 || events[1].Kind != ActivityClosed {
+		t.Fatalf("audit = %+v, %v", events, err)
+	}
+
+	snap := &Snapshot{Tasks: map[string]Task{}}
+	if n, err := s.SyncSnapshot(ctx, snap); err != nil || n == 0 || len(snap.Tasks) != 2 || !snap.Tasks[milk.ID].Done {
+		t.Fatalf("sync: %d applied, %v, %+v", n, err, snap.Tasks)
+	}
+	if _, err := s.DeleteTask(ctx, milk.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SyncSnapshot(ctx, snap); err != nil || len(snap.Tasks) != 1 {
+		t.Fatalf("sync after delete: %v, %d tasks", err, len(snap.Tasks))
+	}
+
+	removed, err := s.Purge(ctx)
+	if err != nil || len(removed) != 8 {
+		t.Fatalf("purge removed %v, %v", removed, err)
+	}
+}
+
+func TestTaskDescription(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	task, _, err := s.CreateTask(ctx, TaskInput{Text: "Plan trip", Details: "- [x] book flights\n- [ ] hotel\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Text != "Plan trip" || task.Description != "- [x] book flights\n- [ ] hotel" {
+		t.Fatalf("created %q / %q", task.Text, task.Description)
+	}
+	got, _, err := s.GetTask(ctx, task.ID)
+	if err != nil || got.Details() != task.Description || got.Short() != "Plan trip" {
+		t.Fatalf("get = %+v, %v", got, err)
+	}
+	if clone := CloneInput(got, "", nil); clone.Details != "- [ ] book flights\n- [ ] hotel" {
+		t.Fatalf("clone details = %q", clone.Details)
+	}
+
+	empty := ""
+	after, err := s.UpdateTask(ctx, task.ID, UpdateSet{Details: &empty})
+	if err != nil || after.Description != "" {
+		t.Fatalf("clear details = %q, %v", after.Description, err)
+	}
+	if c := Changes(got, after); len(c) == 0 || c[0].Field != "details" || c[0].To != "" {
+		t.Fatalf("changes = %+v", c)
+	}
+	big := strings.Repeat("x", DefaultMaxTextLen+1)
+	if _, err := s.UpdateTask(ctx, task.ID, UpdateSet{Details: &big}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("oversized details: err = %v, want ErrValidation", err)
+	}
+}
@@ -0,0 +1,137 @@
+package utask
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Task states beyond open, stored in Task.Status. In-progress and blocked
+// tasks are still open (Done is false); done and cancelled tasks are
+// closed (Done is true).
+const (
+	StatusInProgress Status = "in-progress"
+	StatusBlocked    Status = "blocked"
+	StatusDone       Status = "done"
+	StatusCancelled  Status = "cancelled"
+)
+
+// closed reports whether st is a closed state.
+func (st Status) closed() bool { return st == StatusDone || st == StatusCancelled }
+
+// State returns the task's status. Done wins when the two disagree, so
+// records and writers that only know Done (older builds, importers) read
+// as done or open.
+func (t Task) State() Status {
+	if t.Status == "" || t.Done != t.Status.closed() {
+		if t.Done {
+			return StatusDone
+		}
+		return StatusOpen
+	}
+	return t.Status
+}
+
+// setStatus moves t to st, keeping Done in step and stamping Closed when
+// it closes (clearing it when it reopens).
+func (t *Task) setStatus(st Status) {
+	was := t.Done
+	t.Status, t.Done = st, st.closed()
+	switch {
+	case t.Done && !was:
+		t.Closed = stamp(time.Now())
+	case !t.Done:
+		t.Closed = nil
+	}
+}
+
+// ParseStatus reads a status filter: a task state (open, in-progress,
+// blocked, done, cancelled), closed for done or cancelled, or review. open
+// selects every open state.
+func ParseStatus(s string) (Status, error) {
+	switch st := Status(strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "_", "-")); st {
+	case StatusOpen, StatusInProgress, StatusBlocked, StatusDone, StatusCancelled, StatusClosed, StatusReview:
+		return st, nil
+	case "canceled":
+		return StatusCancelled, nil
+	}
+	return "", invalidf("invalid status %q (want open, in-progress, blocked, done, cancelled, closed or review)", s)
+}
+
+// SetStatus moves a task to st, reporting whether it changed. Open tasks
+// move freely between open, in-progress and blocked, and can be done or
+// cancelled; done and cancelled tasks must be reopened (st open) first.
+// Marking a task done is CloseTaskIf, review included. Cancelling drops any
+// claim and, like closing, starts the next instance of a recurring task.
+// A non-zero ifRev makes it fail with ErrConflict unless the task is still
+// at that revision; otherwise a lost race is retried, as in UpdateTask.
+func (s *Store) SetStatus(ctx context.Context, id string, st Status, ifRev uint64) (Task, bool, error) {
+	switch st {
+	case StatusDone:
+		return s.setDone(ctx, id, true, ifRev)
+	case StatusOpen, StatusInProgress, StatusBlocked, StatusCancelled:
+	default:
+		return Task{}, false, invalidf("invalid task status %q", st)
+	}
+	if err := s.authorize(ctx, "set status", RoleContributor); err != nil {
+		return Task{}, false, err
+	}
+	var (
+		t, before Task
+		from      Status
+		changed   bool
+	)
+	err := retryTaskCAS(ctx, "set status of "+id, ifRev, func() error {
+		var (
+			rev uint64
+			err error
+		)
+		t, rev, err = s.GetTask(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkRevision(id, ifRev, rev); err != nil {
+			return err
+		}
+		if from = t.State(); from == st {
+			changed = false
+			return nil
+		}
+		if from.closed() && st != StatusOpen {
+			return invalidf("task %s is %s; reopen it first", id, from)
+		}
+		changed = true
+		if st == StatusCancelled || from.closed() {
+			t.ReviewRequestedBy = ""
+		}
+		before = t
+		t.setStatus(st)
+		t.touch(s.identity(ctx), time.Now())
+		t.Revision, err = s.putTaskCAS(ctx, id, t, rev)
+		return err
+	})
+	if err != nil {
+		return Task{}, false, err
+	}
+	if !changed {
+		return t, false, nil
+	}
+	kind := ActivityStatusChanged
+	switch {
+	case st == StatusCancelled:
+		kind = ActivityCancelled
+	case from.closed():
+		kind = ActivityReopened
+	}
+	s.logWrite(ctx, "set status", id, t.Revision)
+	s.audit(ctx, kind, t, string(from)+" -> "+string(st))
+	s.recordUndo(ctx, "set status", undoChange(&before, &t))
+	if st == StatusCancelled {
+		s.dropClaim(ctx, id)
+		s.recur(ctx, t)
+	}
+	if err := s.reindexStatus(ctx, id, &before, &t); err != nil {
+		return t, true, err
+	}
+	return t, true, nil
+}
@@ -0,0 +1,108 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTaskState(t *testing.T) {
+	cases := []struct {
+		task Task
+		want Status
+	}{
+		{Task{}, StatusOpen},
+		{Task{Done: true}, StatusDone},
+		{Task{Status: StatusBlocked}, StatusBlocked},
+		{Task{Status: StatusCancelled, Done: true}, StatusCancelled},
+		// A writer that only knows Done reopened or closed the task.
+		{Task{Status: StatusCancelled}, StatusOpen},
+		{Task{Status: StatusInProgress, Done: true}, StatusDone},
+	}
+	for _, c := range cases {
+		if got := c.task.State(); got != c.want {
+			t.Errorf("%+v: State = %s, want %s", c.task, got, c.want)
+		}
+	}
+	if st, err := ParseStatus("In_Progress"); err != nil || st != StatusInProgress {
+		t.Fatalf("ParseStatus = %q, %v", st, err)
+	}
+	if _, err := ParseStatus("later"); !errors.Is(err, ErrValidation) {
+		t.Fatalf("ParseStatus(later): %v", err)
+	}
+}
+
+func TestDecodeMigratesStatus(t *testing.T) {
+	got, from, err := decodeStoredTask([]byte(`{"id":"abc","text":"old","done":true,"schema":1}`))
+	if err != nil || from != 1 || got.Status != StatusDone {
+		t.Fatalf("got %+v from=%d err=%v", got, from, err)
+	}
+}
+
+func TestSetStatus(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	task, _, err := s.CreateTask(ctx, TaskInput{Text: "Write docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ClaimTask(ctx, task.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, _ := s.GetTask(ctx, task.ID); got.Status != StatusInProgress {
+		t.Fatalf("after claim: %s", got.Status)
+	}
+	if err := s.ReleaseClaim(ctx, task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, _ := s.GetTask(ctx, task.ID); got.Status != StatusOpen {
+		t.Fatalf("after release: %s", got.Status)
+	}
+	got, changed, err := s.SetStatus(ctx, task.ID, StatusCancelled, 0)
+	if err != nil || !changed || !got.Done || got.Status != StatusCancelled {
+		t.Fatalf("cancel: %+v changed=%v err=%v", got, changed, err)
+	}
+	if _, _, err := s.SetStatus(ctx, task.ID, StatusInProgress, 0); !errors.Is(err, ErrValidation) {
+		t.Fatalf("start a cancelled task: %v", err)
+	}
+	if got, _, err := s.ReopenTask(ctx, task.ID); err != nil || got.Status != StatusOpen || got.Done {
+		t.Fatalf("reopen: %+v, %v", got, err)
+	}
+	if _, _, err := s.SetStatus(ctx, task.ID, StatusBlocked, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, err := s.CloseTask(ctx, task.ID); err != nil || got.Status != StatusDone {
+		t.Fatalf("close blocked task: %+v, %v", got, err)
+	}
+	events, err := s.Audit(ctx, AuditFilter{Task: task.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []ActivityKind
+	for _, e := range events {
+		kinds = append(kinds, e.Kind)
+	}
+	want := []ActivityKind{ActivityCreated, ActivityStatusChanged, ActivityStatusChanged, ActivityCancelled, ActivityReopened, ActivityStatusChanged, ActivityClosed}
+	if len(kinds) != len(want) {
+		t.Fatalf("audit kinds %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("audit kinds %v, want %v", kinds, want)
+		}
+	}
+}
+
+func TestSelectStatus(t *testing.T) {
+	snap := &Snapshot{Tasks: map[string]Task{
+		"a": {ID: "a"},
+		"b": {ID: "b", Status: StatusBlocked},
+		"c": {ID: "c", Status: StatusCancelled, Done: true},
+		"d": {ID: "d", Done: true},
+	}}
+	for st, want := range map[Status]int{StatusOpen: 2, StatusBlocked: 1, StatusCancelled: 1, StatusDone: 1, StatusClosed: 2, StatusInProgress: 0} {
+		if got := len(snap.Select(ListFilter{Status: st})); got != want {
+			t.Errorf("status %s selected %d tasks, want %d", st, got, want)
+		}
+	}
+}
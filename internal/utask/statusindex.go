@@ -0,0 +1,180 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// The status index lists the ids of open and of closed tasks under two keys
+// in the meta bucket, one id per line like a tag index entry, so a listing
+// filtered by status reads only the tasks it can match. Writers only edit
+// keys that exist: a profile from before the index has neither key and is
+// scanned until RebuildIndex writes them, while an empty profile starts
+// with both (see initStatusIndex).
+const (
+	statusOpenKey   = "status_open"
+	statusClosedKey = "status_closed"
+)
+
+// statusIndexKey returns the index key holding t, or "" for no task.
+func statusIndexKey(t *Task) string {
+	switch {
+	case t == nil:
+		return ""
+	case t.Done:
+		return statusClosedKey
+	}
+	return statusOpenKey
+}
+
+// initStatusIndex gives a profile without any tasks an empty status index.
+func (s *Store) initStatusIndex(ctx context.Context) error {
+	if _, err := s.metaKV.Get(ctx, statusOpenKey); !errors.Is(err, jetstream.ErrKeyNotFound) {
+		return err
+	}
+	st, err := s.tasksKV.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if st.Values() > 0 {
+		// Existing tasks are indexed by `ut rebuild-index`.
+		return nil
+	}
+	for _, key := range []string{statusClosedKey, statusOpenKey} {
+		if _, err := s.metaKV.Create(ctx, key, nil); err != nil && !errors.Is(err, jetstream.ErrKeyExists) {
+			return err
+		}
+	}
+	return nil
+}
+
+// statusIDs returns the ids the status index lists for tasks that may be in
+// status sf, sorted, and whether the index exists.
+func (s *Store) statusIDs(ctx context.Context, sf Status) ([]string, bool, error) {
+	key := statusOpenKey
+	switch sf {
+	case StatusDone, StatusCancelled, StatusClosed:
+		key = statusClosedKey
+	}
+	e, err := s.metaKV.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	ids, _ := parseTagValue(e.Value())
+	sort.Strings(ids)
+	return ids, true, nil
+}
+
+// statusChanges collects status index edits for any number of tasks, so
+// each key is written once however many of them move.
+type statusChanges struct {
+	add, remove map[string][]string
+}
+
+// note records a task moving from before to after; either is nil when the
+// task did not or no longer exists.
+func (c *statusChanges) note(id string, before, after *Task) {
+	from, to := statusIndexKey(before), statusIndexKey(after)
+	if from == to {
+		return
+	}
+	if c.add == nil {
+		c.add, c.remove = map[string][]string{}, map[string][]string{}
+	}
+	if to != "" {
+		c.add[to] = append(c.add[to], id)
+	}
+	if from != "" {
+		c.remove[from] = append(c.remove[from], id)
+	}
+}
+
+// applyStatusChanges writes c to the status index, additions first so a
+// moving task is never missing from both keys.
+func (s *Store) applyStatusChanges(ctx context.Context, c statusChanges) error {
+	var errs []error
+	for key, ids := range c.add {
+		errs = append(errs, s.editStatusIndex(ctx, key, ids, nil))
+	}
+	for key, ids := range c.remove {
+		errs = append(errs, s.editStatusIndex(ctx, key, nil, ids))
+	}
+	return errors.Join(errs...)
+}
+
+// reindexStatus moves id between the status index keys when a write opened,
+// closed, created or deleted it.
+func (s *Store) reindexStatus(ctx context.Context, id string, before, after *Task) error {
+	var c statusChanges
+	c.note(id, before, after)
+	if err := s.applyStatusChanges(ctx, c); err != nil {
+		s.log.WarnContext(ctx, "status index update failed", "op", "reindex", "task", id, "err", err)
+		return indexError(err)
+	}
+	return nil
+}
+
+// editStatusIndex adds and removes ids under key, retrying when another
+// writer updates it concurrently. It is a no-op until the key exists.
+func (s *Store) editStatusIndex(ctx context.Context, key string, add, remove []string) error {
+	return retryCAS(ctx, "update status index "+key, func() error {
+		e, err := s.metaKV.Get(ctx, key)
+		if err != nil {
+			if errors.Is(err, jetstream.ErrKeyNotFound) {
+				return nil
+			}
+			return fmt.Errorf("get status index: %w", err)
+		}
+		ids, _ := parseTagValue(e.Value())
+		present := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			present[id] = true
+		}
+		changed := false
+		for _, id := range add {
+			if !present[id] {
+				present[id] = true
+				ids = append(ids, id)
+				changed = true
+			}
+		}
+		drop := map[string]bool{}
+		for _, id := range remove {
+			if present[id] {
+				drop[id] = true
+			}
+		}
+		if !changed && len(drop) == 0 {
+			return nil
+		}
+		out := ids[:0]
+		for _, id := range ids {
+			if !drop[id] {
+				out = append(out, id)
+			}
+		}
+		if _, err := s.metaKV.Update(ctx, key, []byte(strings.Join(out, "\n")), e.Revision()); err != nil {
+			return casError("update status index", err)
+		}
+		return nil
+	})
+}
+
+// putStatusIndex replaces the status index wholesale.
+func (s *Store) putStatusIndex(ctx context.Context, open, closed []string) error {
+	if _, err := s.metaKV.Put(ctx, statusClosedKey, []byte(strings.Join(closed, "\n"))); err != nil {
+		return fmt.Errorf("write status index: %w", err)
+	}
+	if _, err := s.metaKV.Put(ctx, statusOpenKey, []byte(strings.Join(open, "\n"))); err != nil {
+		return fmt.Errorf("write status index: %w", err)
+	}
+	return nil
+}
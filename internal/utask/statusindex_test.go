@@ -0,0 +1,106 @@
+package utask
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestStatusIndex(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	var ids []string
+	for _, text := range []string{"a", "b", "c", "d", "e"} {
+		task, _, err := s.CreateTask(ctx, TaskInput{Text: text})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, task.ID)
+	}
+	if _, _, err := s.CloseTask(ctx, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.SetStatus(ctx, ids[1], StatusCancelled, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.DeleteTask(ctx, ids[2]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CloseTasks(ctx, ids[3:4]); err != nil {
+		t.Fatal(err)
+	}
+	check := func(sf Status, want ...string) {
+		t.Helper()
+		got, ok, err := s.statusIDs(ctx, sf)
+		slices.Sort(want)
+		if err != nil || !ok || !slices.Equal(got, want) {
+			t.Fatalf("statusIDs(%s) = %v, %v, %v; want %v", sf, got, ok, err, want)
+		}
+	}
+	check(StatusOpen, ids[4])
+	check(StatusClosed, ids[0], ids[1], ids[3])
+	if _, _, err := s.ReopenTask(ctx, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	check(StatusOpen, ids[0], ids[4])
+	check(StatusDone, ids[1], ids[3])
+
+	// Listings read only what the index lists.
+	if err := s.editStatusIndex(ctx, statusOpenKey, nil, ids[4:]); err != nil {
+		t.Fatal(err)
+	}
+	if got := listIDs(t, s, ListFilter{Status: StatusOpen}); !slices.Equal(got, ids[:1]) {
+		t.Fatalf("open tasks = %v, want %v", got, ids[:1])
+	}
+	if err := s.RebuildIndex(ctx); err != nil {
+		t.Fatal(err)
+	}
+	check(StatusOpen, ids[0], ids[4])
+}
+
+func TestStatusIndexMissing(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	// A profile from before the index has neither key.
+	for _, key := range []string{statusOpenKey, statusClosedKey} {
+		if err := s.metaKV.Delete(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a, _, err := s.CreateTask(ctx, TaskInput{Text: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.CloseTask(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := s.statusIDs(ctx, StatusClosed); ok || err != nil {
+		t.Fatalf("writers created the status index: %v, %v", ok, err)
+	}
+	if got := listIDs(t, s, ListFilter{Status: StatusClosed}); !slices.Equal(got, []string{a.ID}) {
+		t.Fatalf("closed tasks without an index = %v", got)
+	}
+	if err := s.RebuildIndex(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok, err := s.statusIDs(ctx, StatusClosed); !ok || err != nil || !slices.Equal(got, []string{a.ID}) {
+		t.Fatalf("statusIDs after rebuild = %v, %v, %v", got, ok, err)
+	}
+}
+
+func listIDs(t *testing.T, s *Store, f ListFilter) []string {
+	t.Helper()
+	it, err := s.ListIter(context.Background(), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Task().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
@@ -0,0 +1,109 @@
+package utask
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Count returns how many tasks match f, without returning them. Sort and
+// Limit are ignored.
+func (s *Store) Count(ctx context.Context, f ListFilter) (int, error) {
+	f.Sort, f.Reverse, f.Limit = "", false, 0
+	it, err := s.ListIter(ctx, f)
+	if err != nil {
+		return 0, err
+	}
+	defer it.Close()
+	n := 0
+	for it.Next() {
+		n++
+	}
+	return n, it.Err()
+}
+
+// Summary is an overview of a profile's tasks (see Store.Summarize).
+type Summary struct {
+	Open    int `json:"open"`
+	Closed  int `json:"closed"`
+	Overdue int `json:"overdue"`
+	// Tags breaks the totals down by tag, most open tasks first.
+	Tags []TagSummary `json:"tags"`
+	// Since starts the window CreatedSince and ClosedSince count in.
+	Since        time.Time `json:"since"`
+	CreatedSince int       `json:"created_since"`
+	ClosedSince  int       `json:"closed_since"`
+}
+
+// TagSummary is one tag's share of a Summary.
+type TagSummary struct {
+	Tag    string `json:"tag"`
+	Open   int    `json:"open"`
+	Closed int    `json:"closed"`
+}
+
+// Summarize totals the tasks the caller can see in one pass over the
+// tasks bucket: open and closed, per tag, overdue at now, and created and
+// closed since since. As with RecentActivity, a closed task counts as
+// closed when its latest revision was written.
+func (s *Store) Summarize(ctx context.Context, since, now time.Time) (Summary, error) {
+	sum := Summary{Since: since, Tags: []TagSummary{}}
+	lister, err := s.tasksKV.ListKeys(ctx)
+	if err != nil {
+		return sum, err
+	}
+	defer lister.Stop()
+	tags := map[string]*TagSummary{}
+	for k := range lister.Keys() {
+		if err := ctx.Err(); err != nil {
+			return sum, err
+		}
+		e, err := s.tasksKV.Get(ctx, k)
+		if err != nil {
+			continue
+		}
+		t, err := s.decodeTask(e.Value())
+		if err != nil || !s.canSee(ctx, t) {
+			continue
+		}
+		for _, a := range taskActivity(t, e.Created(), since) {
+			switch a.Kind {
+			case ActivityCreated:
+				sum.CreatedSince++
+			case ActivityClosed:
+				sum.ClosedSince++
+			}
+		}
+		if t.Overdue(now) {
+			sum.Overdue++
+		}
+		if t.Done {
+			sum.Closed++
+		} else {
+			sum.Open++
+		}
+		for _, tag := range t.Tags {
+			ts := tags[tag]
+			if ts == nil {
+				ts = &TagSummary{Tag: tag}
+				tags[tag] = ts
+			}
+			if t.Done {
+				ts.Closed++
+			} else {
+				ts.Open++
+			}
+		}
+	}
+	for _, ts := range tags {
+		sum.Tags = append(sum.Tags, *ts)
+	}
+	sort.Slice(sum.Tags, func(i, j int) bool {
+		a, b := sum.Tags[i], sum.Tags[j]
+		if a.Open != b.Open {
+			return a.Open > b.Open
+		}
+		return a.Tag < b.Tag
+	})
+	return sum, nil
+}
@@ -0,0 +1,56 @@
+package utask
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCountAndSummarize(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	now := time.Now()
+	if _, _, err := s.CreateTask(ctx, TaskInput{Text: "a", Tags: []string{"work"}, Due: now.Add(-48 * time.Hour).Truncate(time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+	b, _, err := s.CreateTask(ctx, TaskInput{Text: "b", Tags: []string{"work", "home"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.CreateTask(ctx, TaskInput{Text: "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.CloseTask(ctx, b.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		f    ListFilter
+		want int
+	}{
+		{ListFilter{}, 3},
+		{ListFilter{Status: StatusOpen}, 2},
+		{ListFilter{Tag: "work"}, 2},
+		{ListFilter{Tag: "work", Status: StatusOpen, Limit: 1}, 1},
+		{ListFilter{Overdue: true}, 1},
+	} {
+		if n, err := s.Count(ctx, tc.f); err != nil || n != tc.want {
+			t.Errorf("Count(%+v) = %d, %v; want %d", tc.f, n, err, tc.want)
+		}
+	}
+
+	sum, err := s.Summarize(ctx, now.Add(-7*24*time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Open != 2 || sum.Closed != 1 || sum.Overdue != 1 || sum.CreatedSince != 3 || sum.ClosedSince != 1 {
+		t.Fatalf("Summarize = %+v", sum)
+	}
+	want := []TagSummary{{Tag: "work", Open: 1, Closed: 1}, {Tag: "home", Closed: 1}}
+	if len(sum.Tags) != len(want) || sum.Tags[0] != want[0] || sum.Tags[1] != want[1] {
+		t.Fatalf("Summarize tags = %+v, want %+v", sum.Tags, want)
+	}
+	if sum, err := s.Summarize(ctx, now.Add(time.Minute), now); err != nil || sum.CreatedSince != 0 || sum.ClosedSince != 0 {
+		t.Fatalf("Summarize from later = %+v, %v", sum, err)
+	}
+}
@@ -0,0 +1,87 @@
+package utask
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// syncBucketName holds the state of syncs with other systems, such as
+// GitHub issues (see SyncLinks).
+func syncBucketName(ns string) string { return fmt.Sprintf("utask_sync_%s", ns) }
+
+// syncKeyRe matches what sync sources and items may contain: KV key
+// characters. Items also can't hold the dot that ends a source.
+var syncKeyRe = regexp.MustCompile(`^[-/_=.a-zA-Z0-9]+$`)
+
+// SyncLink ties a task to an item in another system and records where the
+// two were when they were last in step.
+type SyncLink struct {
+	Task string `json:"task"`
+	// Updated is the item's last-modified time as the other system last
+	// reported it.
+	Updated time.Time `json:"updated"`
+	// Revision is the task's revision when it last matched the item.
+	Revision uint64 `json:"revision"`
+}
+
+func syncKey(source, item string) (string, error) {
+	if !syncKeyRe.MatchString(source) || !syncKeyRe.MatchString(item) || strings.Contains(item, ".") {
+		return "", invalidf("sync key %q/%q: want letters, digits and -/_= (no dots in the item)", source, item)
+	}
+	return source + "." + item, nil
+}
+
+// SyncLinks returns the links of a sync source, e.g. "github.owner/repo",
+// by item.
+func (s *Store) SyncLinks(ctx context.Context, source string) (map[string]SyncLink, error) {
+	if _, err := syncKey(source, "x"); err != nil {
+		return nil, err
+	}
+	keys, err := s.syncKV.Keys(ctx)
+	if err != nil && !errors.Is(err, jetstream.ErrNoKeysFound) {
+		return nil, fmt.Errorf("list sync links: %w", err)
+	}
+	out := map[string]SyncLink{}
+	for _, k := range keys {
+		i := strings.LastIndexByte(k, '.')
+		if i < 0 || k[:i] != source {
+			continue
+		}
+		e, err := s.syncKV.Get(ctx, k)
+		if err != nil {
+			continue
+		}
+		var l SyncLink
+		if err := json.Unmarshal(e.Value(), &l); err != nil {
+			return nil, fmt.Errorf("sync link %s: %w", k, err)
+		}
+		out[k[i+1:]] = l
+	}
+	return out, nil
+}
+
+// PutSyncLink records the link of one item of a sync source.
+func (s *Store) PutSyncLink(ctx context.Context, source, item string, l SyncLink) error {
+	if err := s.authorize(ctx, "sync", RoleContributor); err != nil {
+		return err
+	}
+	key, err := syncKey(source, item)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	if _, err := s.syncKV.Put(ctx, key, b); err != nil {
+		return fmt.Errorf("put sync link %s: %w", key, err)
+	}
+	return nil
+}
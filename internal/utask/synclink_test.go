@@ -0,0 +1,29 @@
+package utask
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSyncLinks(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	if links, err := s.SyncLinks(ctx, "github.o/r"); err != nil || len(links) != 0 {
+		t.Fatalf("SyncLinks on empty bucket = %v, %v", links, err)
+	}
+	want := SyncLink{Task: "abc", Updated: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Revision: 3}
+	if err := s.PutSyncLink(ctx, "github.o/r", "7", want); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.PutSyncLink(ctx, "github.o/other", "7", SyncLink{Task: "def"}); err != nil {
+		t.Fatal(err)
+	}
+	links, err := s.SyncLinks(ctx, "github.o/r")
+	if err != nil || len(links) != 1 || !links["7"].Updated.Equal(want.Updated) || links["7"].Task != "abc" || links["7"].Revision != 3 {
+		t.Fatalf("SyncLinks = %v, %v", links, err)
+	}
+	if err := s.PutSyncLink(ctx, "github.o/r", "7.1", want); err == nil {
+		t.Fatal("PutSyncLink accepted an item with a dot")
+	}
+}
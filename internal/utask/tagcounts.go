@@ -0,0 +1,67 @@
+package utask
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// tagCountsKey holds a JSON object of tag -> task count in the meta bucket.
+// It is adjusted on every index change so ListTags needs a single read;
+// RebuildIndex rewrites it from the tasks.
+const tagCountsKey = "tag_counts"
+
+// applyTagDeltas adds deltas to counts, dropping tags that reach zero.
+func applyTagDeltas(counts, deltas map[string]int) {
+	for tag, d := range deltas {
+		if n := counts[tag] + d; n > 0 {
+			counts[tag] = n
+		} else {
+			delete(counts, tag)
+		}
+	}
+}
+
+func (s *Store) cachedTagCounts(ctx context.Context) (map[string]int, bool) {
+	e, err := s.metaKV.Get(ctx, tagCountsKey)
+	if err != nil {
+		return nil, false
+	}
+	counts := map[string]int{}
+	if err := json.Unmarshal(e.Value(), &counts); err != nil {
+		return nil, false
+	}
+	return counts, true
+}
+
+// bumpTagCounts applies deltas to the cached counts. It is a no-op until the
+// counts key exists, so the first ListTags scan seeds it from the index.
+// Losing every CAS retry only leaves the counts approximate until the next
+// rebuild or compaction, so errors are dropped.
+func (s *Store) bumpTagCounts(ctx context.Context, deltas map[string]int) {
+	_ = retryCAS(ctx, "update tag counts", func() error {
+		e, err := s.metaKV.Get(ctx, tagCountsKey)
+		if err != nil {
+			return nil
+		}
+		counts := map[string]int{}
+		if err := json.Unmarshal(e.Value(), &counts); err != nil {
+			return nil
+		}
+		applyTagDeltas(counts, deltas)
+		b, _ := json.Marshal(counts)
+		if _, err := s.metaKV.Update(ctx, tagCountsKey, b, e.Revision()); err != nil {
+			return casError("update tag counts", err)
+		}
+		return nil
+	})
+}
+
+// putTagCounts replaces the cached counts wholesale.
+func (s *Store) putTagCounts(ctx context.Context, counts map[string]int) error {
+	b, err := json.Marshal(counts)
+	if err != nil {
+		return err
+	}
+	_, err = s.metaKV.Put(ctx, tagCountsKey, b)
+	return err
+}
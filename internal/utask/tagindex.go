@@ -0,0 +1,380 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// The tag index has one key per tagged task in the tags bucket,
+// tag.<tag>.<id>, with an empty value. A tag's tasks are listed with the
+// key filter tag.<tag>.* (tags may contain dots, ids never do), so tagging
+// or untagging a task writes its own key and never contends with writers
+// of other tasks, and no value grows with the tag.
+//
+// Profiles from before kept every id of a tag in one newline-joined value
+// under the bare tag name, with overflow shards under <tag>=1..N-1 behind a
+// "#shards N" header. Until CompactTagIndex or RebuildIndex moves them to
+// entry keys and records tagIndexKey, reads merge both layouts and removals
+// edit both.
+const (
+	tagKeyPrefix = "tag."
+	// tagIndexKey in the meta bucket records that a profile's tag index
+	// uses entry keys only.
+	tagIndexKey     = "tag_index"
+	tagIndexEntries = "entries"
+	shardHeader     = "#shards "
+	shardSep        = "="
+)
+
+func tagEntryKey(tag, id string) string { return tagKeyPrefix + tag + "." + id }
+
+// parseTagEntryKey splits an entry key into its tag and task id; anything
+// else in the tags bucket is a legacy key.
+func parseTagEntryKey(key string) (tag, id string, ok bool) {
+	rest, ok := strings.CutPrefix(key, tagKeyPrefix)
+	i := strings.LastIndex(rest, ".")
+	if !ok || i <= 0 || !isTaskID(rest[i+1:]) {
+		return "", "", false
+	}
+	return rest[:i], rest[i+1:], true
+}
+
+func shardKey(tag string, i int) string { return tag + shardSep + strconv.Itoa(i) }
+
+// isShardKey reports whether a legacy tags bucket key is an overflow shard.
+func isShardKey(key string) bool {
+	i := strings.LastIndex(key, shardSep)
+	if i < 0 {
+		return false
+	}
+	_, err := strconv.Atoi(key[i+1:])
+	return err == nil
+}
+
+// parseTagValue splits a newline-joined id list into its ids (in stored
+// order, blanks dropped) and the number of shards declared by its header
+// (1 when unsharded).
+func parseTagValue(v []byte) (ids []string, shards int) {
+	shards = 1
+	for _, line := range strings.Split(string(v), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, shardHeader):
+			if n, err := strconv.Atoi(strings.TrimPrefix(line, shardHeader)); err == nil && n > 1 {
+				shards = n
+			}
+		default:
+			ids = append(ids, line)
+		}
+	}
+	return ids, shards
+}
+
+// loadTagIndex reads whether the profile's tag index is entry keys only. A
+// profile with an empty tags bucket starts that way.
+func (s *Store) loadTagIndex(ctx context.Context) error {
+	e, err := s.metaKV.Get(ctx, tagIndexKey)
+	if err == nil {
+		s.legacyTags.Store(string(e.Value()) != tagIndexEntries)
+		return nil
+	}
+	if !errors.Is(err, jetstream.ErrKeyNotFound) {
+		return err
+	}
+	st, err := s.tagsKV.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if st.Values() > 0 {
+		s.legacyTags.Store(true)
+		return nil
+	}
+	return s.markTagEntries(ctx)
+}
+
+// markTagEntries records that the tag index uses entry keys only.
+func (s *Store) markTagEntries(ctx context.Context) error {
+	if _, err := s.metaKV.Put(ctx, tagIndexKey, []byte(tagIndexEntries)); err != nil {
+		return err
+	}
+	s.legacyTags.Store(false)
+	return nil
+}
+
+// readTagIDs returns the set of task ids the index lists under tag.
+func (s *Store) readTagIDs(ctx context.Context, tag string) (map[string]struct{}, error) {
+	lister, err := s.tagsKV.ListKeysFiltered(ctx, tagKeyPrefix+tag+".*")
+	if err != nil {
+		return nil, err
+	}
+	defer lister.Stop()
+	out := map[string]struct{}{}
+	for k := range lister.Keys() {
+		if _, id, ok := parseTagEntryKey(k); ok {
+			out[id] = struct{}{}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !s.legacyTags.Load() {
+		return out, nil
+	}
+	ids, err := s.readLegacyTag(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		out[id] = struct{}{}
+	}
+	return out, nil
+}
+
+// readLegacyTag returns the ids in a tag's newline-joined value and its
+// shards.
+func (s *Store) readLegacyTag(ctx context.Context, tag string) ([]string, error) {
+	e, err := s.tagsKV.Get(ctx, tag)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	ids, shards := parseTagValue(e.Value())
+	more, err := s.readShards(ctx, tag, shards)
+	return append(ids, more...), err
+}
+
+// readShards returns the ids stored in a legacy tag's overflow shards
+// 1..n-1.
+func (s *Store) readShards(ctx context.Context, tag string, n int) ([]string, error) {
+	var ids []string
+	for i := 1; i < n; i++ {
+		e, err := s.tagsKV.Get(ctx, shardKey(tag, i))
+		if err != nil {
+			if errors.Is(err, jetstream.ErrKeyNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		more, _ := parseTagValue(e.Value())
+		ids = append(ids, more...)
+	}
+	return ids, nil
+}
+
+// addTagIDs writes an entry key for each of ids under tag.
+func (s *Store) addTagIDs(ctx context.Context, tag string, ids []string) error {
+	added := 0
+	var errs []error
+	for _, id := range ids {
+		_, err := s.tagsKV.Create(ctx, tagEntryKey(tag, id), nil)
+		switch {
+		case err == nil:
+			added++
+		case !errors.Is(err, jetstream.ErrKeyExists):
+			errs = append(errs, fmt.Errorf("add %s to tag %s: %w", id, tag, err))
+		}
+	}
+	if added > 0 {
+		s.bumpTagCounts(ctx, map[string]int{tag: added})
+	}
+	return errors.Join(errs...)
+}
+
+// removeTagIDs deletes the entry keys of ids under tag, and drops them from
+// a legacy value that still lists them.
+func (s *Store) removeTagIDs(ctx context.Context, tag string, ids []string) error {
+	removed := 0
+	var errs []error
+	for _, id := range ids {
+		key := tagEntryKey(tag, id)
+		e, err := s.tagsKV.Get(ctx, key)
+		if err != nil {
+			if !errors.Is(err, jetstream.ErrKeyNotFound) {
+				errs = append(errs, fmt.Errorf("remove %s from tag %s: %w", id, tag, err))
+			}
+			continue
+		}
+		if err := deleteIf(ctx, s.tagsKV, key, e.Revision()); err != nil {
+			// Someone else removed or re-added it meanwhile.
+			continue
+		}
+		removed++
+	}
+	if s.legacyTags.Load() {
+		n, err := s.removeLegacyTagIDs(ctx, tag, ids)
+		errs = append(errs, err)
+		removed += n
+	}
+	if removed > 0 {
+		s.bumpTagCounts(ctx, map[string]int{tag: -removed})
+	}
+	return errors.Join(errs...)
+}
+
+// removeLegacyTagIDs drops ids from a tag's legacy value and its shards,
+// returning how many were there.
+func (s *Store) removeLegacyTagIDs(ctx context.Context, tag string, ids []string) (int, error) {
+	e, err := s.tagsKV.Get(ctx, tag)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	_, shards := parseTagValue(e.Value())
+	left := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		left[id] = true
+	}
+	removed := 0
+	for i := 0; i < shards && len(left) > 0; i++ {
+		key := tag
+		if i > 0 {
+			key = shardKey(tag, i)
+		}
+		var found []string
+		err := retryCAS(ctx, "update tag index "+key, func() error {
+			var err error
+			found, err = s.removeShardIDs(ctx, key, left)
+			return err
+		})
+		if err != nil {
+			return removed, err
+		}
+		for _, id := range found {
+			delete(left, id)
+		}
+		removed += len(found)
+	}
+	return removed, nil
+}
+
+// removeShardIDs drops the ids in drop from a single legacy key, keeping
+// any header. It returns the ones that were present.
+func (s *Store) removeShardIDs(ctx context.Context, key string, drop map[string]bool) ([]string, error) {
+	e, err := s.tagsKV.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	lines := strings.Split(string(e.Value()), "\n")
+	out := make([]string, 0, len(lines))
+	var found []string
+	seen := map[string]bool{}
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if drop[line] {
+			if !seen[line] {
+				seen[line] = true
+				found = append(found, line)
+			}
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		out = append(out, line)
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+	newVal := strings.TrimSpace(strings.Join(out, "\n"))
+	if _, err := s.tagsKV.Update(ctx, key, []byte(newVal), e.Revision()); err != nil {
+		return nil, casError("update tag index", err)
+	}
+	return found, nil
+}
+
+// TagCompaction summarizes a CompactTagIndex run.
+type TagCompaction struct {
+	Migrated int `json:"migrated"` // legacy tags moved to entry keys
+	Entries  int `json:"entries"`  // entry keys written for them
+}
+
+// CompactTagIndex moves tags still stored as one newline-joined value to
+// entry keys, then clears the delete markers removed entries leave behind.
+// Run it with every client on a build that reads entry keys: older ones no
+// longer see migrated tags.
+func (s *Store) CompactTagIndex(ctx context.Context) (TagCompaction, error) {
+	var rep TagCompaction
+	if err := s.authorize(ctx, "compact", RoleAdmin); err != nil {
+		return rep, err
+	}
+	if s.legacyTags.Load() {
+		keys, err := s.tagsKV.Keys(ctx)
+		if err != nil && !errors.Is(err, jetstream.ErrNoKeysFound) {
+			return rep, err
+		}
+		for _, tag := range keys {
+			if _, _, ok := parseTagEntryKey(tag); ok || isShardKey(tag) {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return rep, err
+			}
+			n, err := s.migrateTag(ctx, tag)
+			if err != nil {
+				return rep, fmt.Errorf("migrate tag %s: %w", tag, err)
+			}
+			rep.Migrated++
+			rep.Entries += n
+		}
+		if err := s.markTagEntries(ctx); err != nil {
+			return rep, fmt.Errorf("record tag index layout: %w", err)
+		}
+	}
+	if err := s.tagsKV.PurgeDeletes(ctx); err != nil {
+		return rep, fmt.Errorf("purge delete markers: %w", err)
+	}
+	return rep, nil
+}
+
+// migrateTag writes entry keys for the ids in a legacy tag value, then
+// deletes the value and its shards, starting over if an older client
+// changes the value meanwhile. It returns how many ids it moved.
+func (s *Store) migrateTag(ctx context.Context, tag string) (int, error) {
+	moved := 0
+	err := retryCAS(ctx, "migrate tag "+tag, func() error {
+		e, err := s.tagsKV.Get(ctx, tag)
+		if err != nil {
+			if errors.Is(err, jetstream.ErrKeyNotFound) {
+				return nil
+			}
+			return err
+		}
+		ids, shards := parseTagValue(e.Value())
+		more, err := s.readShards(ctx, tag, shards)
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]bool, len(ids)+len(more))
+		for _, id := range append(ids, more...) {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if _, err := s.tagsKV.Put(ctx, tagEntryKey(tag, id), nil); err != nil {
+				return err
+			}
+		}
+		if err := deleteIf(ctx, s.tagsKV, tag, e.Revision()); err != nil {
+			return casError("delete legacy tag "+tag, err)
+		}
+		for i := 1; i < shards; i++ {
+			_ = s.tagsKV.Delete(ctx, shardKey(tag, i))
+		}
+		moved = len(seen)
+		return nil
+	})
+	return moved, err
+}
@@ -0,0 +1,98 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func TestParseTagKeys(t *testing.T) {
+	id := strings.Repeat("ab", 64)
+	if tag, got, ok := parseTagEntryKey(tagEntryKey("team.ops", id)); !ok || tag != "team.ops" || got != id {
+		t.Fatalf("parseTagEntryKey = %q, %q, %v", tag, got, ok)
+	}
+	for _, key := range []string{"work", "tag.work", "tag." + id, "tag.work.abc", "work=1"} {
+		if _, _, ok := parseTagEntryKey(key); ok {
+			t.Fatalf("parseTagEntryKey(%q) took a legacy key for an entry", key)
+		}
+	}
+	ids, shards := parseTagValue([]byte("#shards 3\na\n\nb\n c \n"))
+	if shards != 3 || strings.Join(ids, ",") != "a,b,c" {
+		t.Fatalf("parseTagValue: got %v shards=%d", ids, shards)
+	}
+	if !isShardKey(shardKey("work", 2)) || isShardKey("work") || isShardKey("a=b") {
+		t.Fatal("isShardKey misclassified keys")
+	}
+}
+
+func TestMigrateLegacyTagIndex(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	var ids []string
+	for _, text := range []string{"one", "two", "three"} {
+		task, _, err := s.CreateTask(ctx, TaskInput{Text: text})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, task.ID)
+	}
+	sort.Strings(ids)
+	// Lay the index out as older builds did: one sharded value per tag.
+	if err := s.metaKV.Delete(ctx, tagIndexKey); err != nil {
+		t.Fatal(err)
+	}
+	s.legacyTags.Store(true)
+	for key, val := range map[string]string{"work": "#shards 2\n" + ids[0] + "\n" + ids[1], "work=1": ids[2]} {
+		if _, err := s.tagsKV.Put(ctx, key, []byte(val)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := tagIDs(t, s, "work"); !reflect.DeepEqual(got, ids) {
+		t.Fatalf("legacy work = %v", got)
+	}
+	// Removals reach the legacy value; additions go to entry keys.
+	if err := s.removeTagIDs(ctx, "work", ids[2:]); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.addTagIDs(ctx, "home", ids[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if got := tagIDs(t, s, "work"); !reflect.DeepEqual(got, ids[:2]) {
+		t.Fatalf("work after removing one = %v", got)
+	}
+
+	rep, err := s.CompactTagIndex(ctx)
+	if err != nil || rep.Migrated != 1 || rep.Entries != 2 {
+		t.Fatalf("CompactTagIndex = %+v, %v", rep, err)
+	}
+	if s.legacyTags.Load() {
+		t.Fatal("still reading legacy values after migrating")
+	}
+	for _, key := range []string{"work", "work=1"} {
+		if _, err := s.tagsKV.Get(ctx, key); !errors.Is(err, jetstream.ErrKeyNotFound) {
+			t.Fatalf("legacy key %s left behind: %v", key, err)
+		}
+	}
+	if got := tagIDs(t, s, "work"); !reflect.DeepEqual(got, ids[:2]) {
+		t.Fatalf("work after migrating = %v", got)
+	}
+	if got := tagIDs(t, s, "home"); !reflect.DeepEqual(got, ids[:1]) {
+		t.Fatalf("home after migrating = %v", got)
+	}
+	if rep, err := s.CompactTagIndex(ctx); err != nil || rep.Migrated != 0 {
+		t.Fatalf("CompactTagIndex again = %+v, %v", rep, err)
+	}
+}
+
+func TestApplyTagDeltas(t *testing.T) {
+	counts := map[string]int{"work": 2, "home": 1}
+	applyTagDeltas(counts, map[string]int{"work": 1, "home": -1, "new": 1, "gone": -1})
+	if len(counts) != 2 || counts["work"] != 3 || counts["new"] != 1 {
+		t.Fatalf("unexpected counts: %v", counts)
+	}
+}
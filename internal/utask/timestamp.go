@@ -0,0 +1,82 @@
+package utask
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Timestamp is a time that encodes as RFC 3339 in UTC to the second, the
+// form Task.Created has always used. It decodes any RFC 3339 time, and ""
+// or null as the zero time. In msgpack records it is a native timestamp.
+type Timestamp struct{ time.Time }
+
+// stamp returns at as a Timestamp, to the second.
+func stamp(at time.Time) *Timestamp {
+	return &Timestamp{at.UTC().Truncate(time.Second)}
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte(`""`), nil
+	}
+	return json.Marshal(t.UTC().Format(time.RFC3339))
+}
+
+func (t *Timestamp) UnmarshalJSON(b []byte) error {
+	var s string
+	if string(b) != "null" {
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+	}
+	if s == "" {
+		*t = Timestamp{}
+		return nil
+	}
+	v, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return err
+	}
+	t.Time = v.UTC()
+	return nil
+}
+
+func (t Timestamp) EncodeMsgpack(enc *msgpack.Encoder) error { return enc.EncodeTime(t.UTC()) }
+
+func (t *Timestamp) DecodeMsgpack(dec *msgpack.Decoder) error {
+	v, err := dec.DecodeTime()
+	t.Time = v.UTC()
+	return err
+}
+
+// touch records that by changed the task at at.
+func (t *Task) touch(by string, at time.Time) {
+	t.UpdatedBy = by
+	t.Modified = stamp(at)
+}
+
+// Changed is when the task last changed: Closed for a closed task, else
+// Modified, else (for tasks stored before either was kept) Created. It is
+// the time ListFilter.Since and Until test, so `ut list --status closed
+// --since 7d` lists what was closed this week.
+func (t Task) Changed() time.Time {
+	switch {
+	case t.Done && t.Closed != nil:
+		return t.Closed.Time
+	case t.Modified != nil:
+		return t.Modified.Time
+	}
+	created, _ := time.Parse(time.RFC3339, t.Created)
+	return created
+}
+
+// matchChanged applies ListFilter.Since and Until.
+func matchChanged(t Task, f ListFilter) bool {
+	if f.Since.IsZero() && f.Until.IsZero() {
+		return true
+	}
+	at := t.Changed()
+	return (f.Since.IsZero() || !at.Before(f.Since)) && (f.Until.IsZero() || !at.After(f.Until))
+}
@@ -0,0 +1,102 @@
+package utask
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimestampCodec(t *testing.T) {
+	at := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+	task := Task{ID: "abc", Text: "ship it", Created: "2025-03-01T09:00:00Z", Status: StatusDone, Done: true, Modified: stamp(at), Closed: stamp(at)}
+	js, err := json.Marshal(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(js, &fields); err != nil || fields["closed"] != "2025-03-01T09:30:00Z" {
+		t.Fatalf("closed = %v, %v", fields["closed"], err)
+	}
+	for _, opts := range []Options{{}, {Encoding: EncodingMsgpack}} {
+		b, err := encodeTask(task, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := decodeTask(b)
+		if err != nil || !reflect.DeepEqual(got, task) {
+			t.Fatalf("%s: got %+v, %v", opts.Encoding, got, err)
+		}
+	}
+
+	for _, in := range []string{`""`, `null`} {
+		var ts Timestamp
+		if err := json.Unmarshal([]byte(in), &ts); err != nil || !ts.IsZero() {
+			t.Fatalf("%s: %v, %v", in, ts, err)
+		}
+	}
+	var ts Timestamp
+	if err := json.Unmarshal([]byte(`"2025-03-01T10:30:00+01:00"`), &ts); err != nil || !ts.Equal(at) {
+		t.Fatalf("offset time = %v, %v", ts, err)
+	}
+	if err := json.Unmarshal([]byte(`"yesterday"`), &ts); err == nil {
+		t.Fatal("a time that isn't RFC 3339 was accepted")
+	}
+}
+
+func TestClosedModified(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	task, _, err := s.CreateTask(ctx, TaskInput{Text: "write changelog"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Modified == nil || task.Closed != nil {
+		t.Fatalf("new task: modified %v, closed %v", task.Modified, task.Closed)
+	}
+	closed, _, err := s.CloseTask(ctx, task.ID)
+	if err != nil || closed.Closed == nil || closed.Closed.Before(task.Modified.Time) {
+		t.Fatalf("closed task: %+v, %v", closed, err)
+	}
+	reopened, _, err := s.ReopenTask(ctx, task.ID)
+	if err != nil || reopened.Closed != nil || reopened.Modified == nil {
+		t.Fatalf("reopened task: %+v, %v", reopened, err)
+	}
+
+	// Since and Until select on Changed; an old task, stored before
+	// either stamp was kept, falls back to Created.
+	old := Task{ID: "old", Text: "old", Created: "2020-01-01T00:00:00Z"}
+	if !old.Changed().Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Changed = %v", old.Changed())
+	}
+	week := time.Now().AddDate(0, 0, -7)
+	cases := []struct {
+		task Task
+		f    ListFilter
+		want bool
+	}{
+		{reopened, ListFilter{Since: week}, true},
+		{reopened, ListFilter{Until: week}, false},
+		{old, ListFilter{Since: week}, false},
+		{old, ListFilter{Until: week}, true},
+		{old, ListFilter{}, true},
+	}
+	for _, c := range cases {
+		if got := matchChanged(c.task, c.f); got != c.want {
+			t.Errorf("%s since %v until %v = %v", c.task.ID, c.f.Since, c.f.Until, got)
+		}
+	}
+	it, err := s.ListIter(ctx, ListFilter{Since: week})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if err := it.Err(); err != nil || n != 1 {
+		t.Fatalf("listed %d since a week ago, %v", n, err)
+	}
+}
@@ -0,0 +1,80 @@
+package utask
+
+import (
+	"context"
+	"strings"
+)
+
+// ParseTrailerLine reads one "Key: Value" trailer, as `ut trailer add`
+// takes it.
+func ParseTrailerLine(s string) (Trailer, error) {
+	tr, ok := parseTrailer(strings.TrimSpace(s))
+	if !ok || strings.TrimSpace(tr.Value) == "" {
+		return Trailer{}, invalidf("invalid trailer %q (want Key: Value, e.g. Refs: #123)", s)
+	}
+	tr.Value = strings.TrimSpace(tr.Value)
+	return tr, nil
+}
+
+// AddTrailer appends tr to the trailer block at the end of the task's
+// text, separating a new block from the body with a blank line.
+func (s *Store) AddTrailer(ctx context.Context, id string, tr Trailer) (Task, error) {
+	if !isValidKey(tr.Key) || strings.TrimSpace(tr.Value) == "" {
+		return Task{}, invalidf("invalid trailer %q", tr.Key+": "+tr.Value)
+	}
+	t, _, err := s.editTrailers(ctx, "trailer add", id, func(text string) string {
+		return appendTrailer(text, tr.Key, strings.TrimSpace(tr.Value))
+	})
+	return t, err
+}
+
+// RemoveTrailer drops every trailer named key (compared case-insensitively)
+// from the task's trailer block, and the block itself once it is empty.
+// changed is false when the task had none.
+func (s *Store) RemoveTrailer(ctx context.Context, id, key string) (Task, bool, error) {
+	if !isValidKey(key) {
+		return Task{}, false, invalidf("invalid trailer key %q", key)
+	}
+	return s.editTrailers(ctx, "trailer remove", id, func(text string) string {
+		if _, _, ok := trailerRegion(text); !ok {
+			return text
+		}
+		return SetTrailer(text, key, "")
+	})
+}
+
+// editTrailers rewrites a task's text with edit, retrying lost races with
+// the edit re-applied to the new text.
+func (s *Store) editTrailers(ctx context.Context, op, id string, edit func(string) string) (Task, bool, error) {
+	if err := s.authorize(ctx, op, RoleContributor); err != nil {
+		return Task{}, false, err
+	}
+	var before, after Task
+	changed := false
+	err := retryCAS(ctx, op+" "+id, func() error {
+		var (
+			rev uint64
+			err error
+		)
+		if before, rev, err = s.GetTask(ctx, id); err != nil {
+			return err
+		}
+		text := edit(before.Text)
+		if changed = text != before.Text; !changed {
+			after = before
+			return nil
+		}
+		if after, err = s.applyUpdate(ctx, before, UpdateSet{Text: &text}); err != nil {
+			return err
+		}
+		after.Revision, err = s.putTaskCAS(ctx, id, after, rev)
+		return err
+	})
+	if err != nil || !changed {
+		return after, false, err
+	}
+	s.logWrite(ctx, op, id, after.Revision)
+	s.auditUpdate(ctx, before, after)
+	s.recordUndo(ctx, op, undoChange(&before, &after))
+	return after, true, nil
+}
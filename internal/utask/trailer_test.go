@@ -0,0 +1,51 @@
+package utask
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAddRemoveTrailer(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	task, _, err := s.CreateTask(ctx, TaskInput{Text: "fix login\nbody line"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		add, rm string
+		want    string
+	}{
+		{add: "Refs: #123", want: "fix login\nbody line\n\nRefs: #123"},
+		{add: "Reviewed-by:  bob ", want: "fix login\nbody line\n\nRefs: #123\nReviewed-by: bob"},
+		{rm: "refs", want: "fix login\nbody line\n\nReviewed-by: bob"},
+		{rm: "Reviewed-by", want: "fix login\nbody line"},
+	}
+	for _, st := range steps {
+		if st.add != "" {
+			tr, err := ParseTrailerLine(st.add)
+			if err != nil {
+				t.Fatal(err)
+			}
+			task, err = s.AddTrailer(ctx, task.ID, tr)
+		} else {
+			task, _, err = s.RemoveTrailer(ctx, task.ID, st.rm)
+		}
+		if err != nil || task.Text != st.want {
+			t.Fatalf("add %q rm %q: text = %q, %v; want %q", st.add, st.rm, task.Text, err, st.want)
+		}
+	}
+	if _, changed, err := s.RemoveTrailer(ctx, task.ID, "Refs"); err != nil || changed {
+		t.Fatalf("removing a missing trailer: changed = %v, %v", changed, err)
+	}
+
+	if _, err := s.AddTrailer(ctx, task.ID, Trailer{Key: "Ticket", Value: "OPS-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.QueryTrailers(ctx, "ticket", "ops-1"); err != nil || len(got) != 1 {
+		t.Fatalf("added trailer not indexed: %v, %v", got, err)
+	}
+	if _, err := ParseTrailerLine("no colon"); err == nil {
+		t.Fatal("ParseTrailerLine accepted a line without a key")
+	}
+}
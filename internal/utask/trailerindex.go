@@ -0,0 +1,286 @@
+package utask
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// The trailer index maps trailers to the tasks carrying them, one empty
+// entry per task under e.<key>.<value hash>.<id> in the trailers bucket,
+// with keys lowercased and values hashed lowercased so any value makes a
+// valid KV key. Under Options.Key both are keyed hashes instead, since
+// trailers are sealed with the text. t.<id> lists the key.hash pairs a
+// task is indexed under, so a write only needs the new task to drop its
+// stale entries. Like the status index it is only read once the indexed
+// key exists: empty profiles start with it, and existing ones are scanned
+// until RebuildIndex writes it. The indexed key's value tells which key
+// the index was built under, so adding or changing Options.Key falls back
+// to scanning until the index is rebuilt.
+const (
+	trailerEntryPrefix = "e."
+	trailerTaskPrefix  = "t."
+	trailerIndexedKey  = "indexed"
+)
+
+func trailerBucketName(ns string) string { return fmt.Sprintf("utask_trailers_%s", ns) }
+
+// trailerPair is the key.hash part of an index entry under k (nil for a
+// profile without encryption); value "" leaves the hash as a wildcard.
+func trailerPair(k *Key, key, value string) string {
+	key = strings.ToLower(strings.TrimSpace(key))
+	value = strings.ToLower(strings.TrimSpace(value))
+	name := key
+	if k != nil {
+		name = k.indexMAC("trailer\x00" + key)
+	}
+	switch {
+	case value == "":
+		return name + ".*"
+	case k != nil:
+		return name + "." + k.indexMAC("trailer\x00"+key+"\x00"+value)
+	}
+	sum := sha256.Sum256([]byte(value))
+	return name + "." + hex.EncodeToString(sum[:8])
+}
+
+// trailerIndexMark is the indexed key's value for an index built under k.
+func trailerIndexMark(k *Key) []byte {
+	if k == nil {
+		return nil
+	}
+	return []byte(k.indexMAC("indexed"))
+}
+
+// trailerPairs returns the sorted, distinct pairs t is indexed under with
+// key k.
+func trailerPairs(k *Key, t *Task) []string {
+	if t == nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	var out []string
+	for _, tr := range t.Trailers() {
+		if strings.TrimSpace(tr.Value) == "" {
+			continue
+		}
+		if p := trailerPair(k, tr.Key, tr.Value); !seen[p] {
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// initTrailerIndex marks the trailer index of a profile without any tasks
+// as complete.
+func (s *Store) initTrailerIndex(ctx context.Context) error {
+	if _, err := s.trailersKV.Get(ctx, trailerIndexedKey); !errors.Is(err, jetstream.ErrKeyNotFound) {
+		return err
+	}
+	st, err := s.tasksKV.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if st.Values() > 0 {
+		// Existing tasks are indexed by `ut rebuild-index`.
+		return nil
+	}
+	if _, err := s.trailersKV.Create(ctx, trailerIndexedKey, trailerIndexMark(s.opts.Key)); err != nil && !errors.Is(err, jetstream.ErrKeyExists) {
+		return err
+	}
+	return nil
+}
+
+// reindexTrailers brings id's trailer index entries in line with t (nil
+// when the task is gone). The task write has already happened, so failures
+// are only logged; RebuildIndex repairs the index.
+func (s *Store) reindexTrailers(ctx context.Context, id string, t *Task) {
+	if err := s.editTrailerIndex(ctx, id, trailerPairs(s.opts.Key, t)); err != nil {
+		s.log.WarnContext(ctx, "trailer index update failed", "task", id, "err", err)
+	}
+}
+
+func (s *Store) editTrailerIndex(ctx context.Context, id string, want []string) error {
+	var have []string
+	e, err := s.trailersKV.Get(ctx, trailerTaskPrefix+id)
+	switch {
+	case err == nil:
+		have = strings.Fields(string(e.Value()))
+	case !errors.Is(err, jetstream.ErrKeyNotFound):
+		return err
+	}
+	if len(have) == 0 && len(want) == 0 {
+		return nil
+	}
+	var errs []error
+	for _, p := range want {
+		if !slices.Contains(have, p) {
+			if _, err := s.trailersKV.Put(ctx, trailerEntryPrefix+p+"."+id, nil); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	for _, p := range have {
+		if !slices.Contains(want, p) {
+			if err := s.trailersKV.Delete(ctx, trailerEntryPrefix+p+"."+id); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(want) == 0 {
+		errs = append(errs, s.trailersKV.Delete(ctx, trailerTaskPrefix+id))
+	} else if _, err := s.trailersKV.Put(ctx, trailerTaskPrefix+id, []byte(strings.Join(want, "\n"))); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// trailerSetIDs returns the sorted ids of tasks the index lists under all
+// of trs, and whether the index is complete enough to rely on: it must
+// also have been built under the Store's key.
+func (s *Store) trailerSetIDs(ctx context.Context, trs []Trailer) ([]string, bool, error) {
+	e, err := s.trailersKV.Get(ctx, trailerIndexedKey)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if !bytes.Equal(e.Value(), trailerIndexMark(s.opts.Key)) {
+		return nil, false, nil
+	}
+	var result map[string]struct{}
+	for _, tr := range trs {
+		ids, err := s.readTrailerIDs(ctx, tr)
+		if err != nil {
+			return nil, false, err
+		}
+		if result != nil {
+			for id := range result {
+				if _, ok := ids[id]; !ok {
+					delete(result, id)
+				}
+			}
+		} else {
+			result = ids
+		}
+		if len(result) == 0 {
+			break
+		}
+	}
+	out := make([]string, 0, len(result))
+	for id := range result {
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out, true, nil
+}
+
+// readTrailerIDs returns the ids the index lists under tr.
+func (s *Store) readTrailerIDs(ctx context.Context, tr Trailer) (map[string]struct{}, error) {
+	lister, err := s.trailersKV.ListKeysFiltered(ctx, trailerEntryPrefix+trailerPair(s.opts.Key, tr.Key, tr.Value)+".*")
+	if err != nil {
+		return nil, err
+	}
+	defer lister.Stop()
+	out := map[string]struct{}{}
+	for k := range lister.Keys() {
+		if i := strings.LastIndexByte(k, '.'); i >= 0 {
+			out[k[i+1:]] = struct{}{}
+		}
+	}
+	return out, ctx.Err()
+}
+
+// rebuildTrailerIndex rewrites the trailer index from tasks, id to the
+// pairs it carries, and marks it complete.
+func (s *Store) rebuildTrailerIndex(ctx context.Context, tasks map[string][]string) error {
+	want := map[string][]byte{}
+	for id, pairs := range tasks {
+		if len(pairs) == 0 {
+			continue
+		}
+		want[trailerTaskPrefix+id] = []byte(strings.Join(pairs, "\n"))
+		for _, p := range pairs {
+			want[trailerEntryPrefix+p+"."+id] = nil
+		}
+	}
+	keys, err := s.trailersKV.Keys(ctx)
+	if err != nil && !errors.Is(err, jetstream.ErrNoKeysFound) {
+		return err
+	}
+	for k, v := range want {
+		if _, err := s.trailersKV.Put(ctx, k, v); err != nil {
+			return fmt.Errorf("write trailer index %s: %w", k, err)
+		}
+	}
+	for _, k := range keys {
+		if _, ok := want[k]; !ok && k != trailerIndexedKey {
+			_ = s.trailersKV.Delete(ctx, k)
+		}
+	}
+	if _, err := s.trailersKV.Put(ctx, trailerIndexedKey, trailerIndexMark(s.opts.Key)); err != nil {
+		return fmt.Errorf("record trailer index: %w", err)
+	}
+	return nil
+}
+
+// ParseTrailerFilter reads a trailer filter, Key=Value or a bare Key for
+// any value, as `ut list --trailer` takes it.
+func ParseTrailerFilter(s string) (Trailer, error) {
+	k, v, _ := strings.Cut(s, "=")
+	k = strings.TrimSpace(k)
+	if !isValidKey(k) {
+		return Trailer{}, invalidf("invalid trailer filter %q (want Key=Value, e.g. Reviewed-by=bob)", s)
+	}
+	return Trailer{Key: k, Value: strings.TrimSpace(v)}, nil
+}
+
+// matchTrailers applies ListFilter.Trailers: the task carries each one,
+// keys and values compared case-insensitively and an empty value matching
+// any.
+func matchTrailers(t Task, f ListFilter) bool {
+	if len(f.Trailers) == 0 {
+		return true
+	}
+	have := t.Trailers()
+	for _, want := range f.Trailers {
+		found := false
+		for _, tr := range have {
+			if strings.EqualFold(tr.Key, want.Key) && (want.Value == "" && strings.TrimSpace(tr.Value) != "" || strings.EqualFold(strings.TrimSpace(tr.Value), strings.TrimSpace(want.Value))) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// QueryTrailers returns the tasks carrying the trailer key with value
+// (compared case-insensitively; "" matches any value), found through the
+// trailer index.
+func (s *Store) QueryTrailers(ctx context.Context, key, value string) ([]Task, error) {
+	it, err := s.ListIter(ctx, ListFilter{Trailers: []Trailer{{Key: key, Value: value}}})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+	out := []Task{}
+	for it.Next() {
+		out = append(out, it.Task())
+	}
+	return out, it.Err()
+}
@@ -0,0 +1,141 @@
+package utask
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQueryTrailers(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	a, _, err := s.CreateTask(ctx, TaskInput{Text: "ship it\n\nReviewed-by: Bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _, err := s.CreateTask(ctx, TaskInput{Text: "fix it\n\nReviewed-by: alice\nTicket: OPS-7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.CreateTask(ctx, TaskInput{Text: "plain"}); err != nil {
+		t.Fatal(err)
+	}
+	ids := func(key, value string) []string {
+		t.Helper()
+		got, err := s.QueryTrailers(ctx, key, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := []string{}
+		for _, task := range got {
+			out = append(out, task.ID)
+		}
+		return out
+	}
+	if got := ids("reviewed-by", "bob"); len(got) != 1 || got[0] != a.ID {
+		t.Fatalf("Reviewed-by=bob = %v, want %s", got, a.ID)
+	}
+	if got := ids("Reviewed-by", ""); len(got) != 2 {
+		t.Fatalf("any Reviewed-by = %v, want 2 tasks", got)
+	}
+
+	text := "fix it\n\nTicket: OPS-7"
+	if _, err := s.UpdateTask(ctx, b.ID, UpdateSet{Text: &text}); err != nil {
+		t.Fatal(err)
+	}
+	if got := ids("Reviewed-by", "alice"); len(got) != 0 {
+		t.Fatalf("removed trailer still found: %v", got)
+	}
+	it, err := s.ListIter(ctx, ListFilter{Trailers: []Trailer{{Key: "Ticket", Value: "ops-7"}}, Status: StatusOpen})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for it.Next() {
+		n++
+	}
+	it.Close()
+	if n != 1 {
+		t.Fatalf("list --trailer Ticket=ops-7 = %d tasks, want 1", n)
+	}
+	if _, err := s.DeleteTask(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := ids("Reviewed-by", "bob"); len(got) != 0 {
+		t.Fatalf("deleted task still found: %v", got)
+	}
+
+	// A profile from before the index is scanned until it is rebuilt.
+	for _, k := range []string{trailerIndexedKey, trailerTaskPrefix + b.ID, trailerEntryPrefix + trailerPair(nil, "Ticket", "OPS-7") + "." + b.ID} {
+		if err := s.trailersKV.Delete(ctx, k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := ids("Ticket", "OPS-7"); len(got) != 1 {
+		t.Fatalf("unindexed profile: %v", got)
+	}
+	if err := s.RebuildIndex(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok, err := s.trailerSetIDs(ctx, []Trailer{{Key: "ticket", Value: "OPS-7"}}); err != nil || !ok || len(got) != 1 || got[0] != b.ID {
+		t.Fatalf("rebuilt index = %v, %v, %v", got, ok, err)
+	}
+}
+
+func TestTrailerIndexSealed(t *testing.T) {
+	ctx := context.Background()
+	k, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "tasks.db")
+	s, err := OpenSQLite(ctx, path, "test", Options{Key: k})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	task, _, err := s.CreateTask(ctx, TaskInput{Text: "ship it\n\nReviewed-by: Bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := s.trailersKV.Keys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := trailerPair(nil, "Reviewed-by", "bob")
+	for _, key := range keys {
+		if strings.Contains(key, "reviewed-by") || strings.Contains(key, plain[strings.IndexByte(plain, '.')+1:]) {
+			t.Fatalf("trailer index key %q gives the trailer away", key)
+		}
+	}
+	if got, ok, err := s.trailerSetIDs(ctx, []Trailer{{Key: "reviewed-by", Value: "BOB"}}); err != nil || !ok || len(got) != 1 || got[0] != task.ID {
+		t.Fatalf("keyed index = %v, %v, %v", got, ok, err)
+	}
+
+	// Under another key the index isn't relied on, and listings scan.
+	other, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, err := OpenSQLite(ctx, path, "test", Options{Key: other})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s2.Close()
+	if _, ok, err := s2.trailerSetIDs(ctx, []Trailer{{Key: "Reviewed-by"}}); err != nil || ok {
+		t.Fatalf("index built under another key relied on: %v, %v", ok, err)
+	}
+}
+
+func TestParseTrailerFilter(t *testing.T) {
+	if tr, err := ParseTrailerFilter("Reviewed-by = Bob <bob@example.com>"); err != nil || tr.Key != "Reviewed-by" || tr.Value != "Bob <bob@example.com>" {
+		t.Fatalf("ParseTrailerFilter = %+v, %v", tr, err)
+	}
+	if tr, err := ParseTrailerFilter("Ticket"); err != nil || tr.Value != "" {
+		t.Fatalf("bare key = %+v, %v", tr, err)
+	}
+	if _, err := ParseTrailerFilter("not a key=x"); err == nil {
+		t.Fatal("invalid key accepted")
+	}
+}
@@ -0,0 +1,191 @@
+package utask
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// trashBucketName holds a profile's deleted tasks, keyed by task ID, until
+// they are restored or purged. Deleting a task moves it here, so the tasks
+// bucket and its indexes only ever hold live tasks.
+func trashBucketName(ns string) string { return fmt.Sprintf("utask_trash_%s", ns) }
+
+// TrashedTask is a deleted task as kept in the trash.
+type TrashedTask struct {
+	Task      Task      `json:"task"`
+	DeletedAt time.Time `json:"deleted_at"`
+	DeletedBy string    `json:"deleted_by,omitempty"`
+}
+
+// trash keeps a copy of t before it is deleted.
+func (s *Store) trash(ctx context.Context, t Task) error {
+	t.Revision = 0
+	sealed, err := sealTask(t, s.opts)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(TrashedTask{Task: sealed, DeletedAt: time.Now().UTC(), DeletedBy: s.identity(ctx)})
+	if err != nil {
+		return err
+	}
+	if _, err := s.trashKV.Put(ctx, t.ID, b); err != nil {
+		return fmt.Errorf("move task %s to the trash: %w", t.ID, err)
+	}
+	return nil
+}
+
+// untrash drops a task's trash entry once it is live again (or, after a
+// failed delete, was never gone).
+func (s *Store) untrash(ctx context.Context, id string) {
+	if err := s.trashKV.Delete(ctx, id); err != nil && !errors.Is(err, jetstream.ErrKeyNotFound) {
+		s.log.WarnContext(ctx, "trash entry not removed", "task", id, "err", err)
+	}
+}
+
+// trashed reads one trash entry.
+func (s *Store) trashed(ctx context.Context, id string) (TrashedTask, error) {
+	e, err := s.trashKV.Get(ctx, id)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return TrashedTask{}, fmt.Errorf("task %s is not in the trash: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return TrashedTask{}, fmt.Errorf("read trash: %w", err)
+	}
+	var tt TrashedTask
+	if err := json.Unmarshal(e.Value(), &tt); err != nil {
+		return TrashedTask{}, fmt.Errorf("trash entry %s: %w", id, err)
+	}
+	if err := s.openTask(&tt.Task); err != nil {
+		return TrashedTask{}, fmt.Errorf("trash entry %s: %w", id, err)
+	}
+	return tt, nil
+}
+
+// ListTrash returns the deleted tasks the caller can see, most recently
+// deleted first.
+func (s *Store) ListTrash(ctx context.Context) ([]TrashedTask, error) {
+	if err := s.authorize(ctx, "list trash", RoleReader); err != nil {
+		return nil, err
+	}
+	keys, err := s.trashKV.Keys(ctx)
+	if errors.Is(err, jetstream.ErrNoKeysFound) {
+		return []TrashedTask{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list trash: %w", err)
+	}
+	out := make([]TrashedTask, 0, len(keys))
+	for _, k := range keys {
+		tt, err := s.trashed(ctx, k)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if s.canSee(ctx, tt.Task) {
+			out = append(out, tt)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].DeletedAt.After(out[j].DeletedAt) })
+	return out, nil
+}
+
+// resolveTrashed finds the trashed task ref names: its ID, a unique ID
+// prefix or the T-<n> number it had.
+func (s *Store) resolveTrashed(ctx context.Context, ref string) (TrashedTask, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return TrashedTask{}, invalidf("empty task reference")
+	}
+	if tt, err := s.trashed(ctx, ref); !errors.Is(err, ErrNotFound) {
+		return tt, err
+	}
+	all, err := s.ListTrash(ctx)
+	if err != nil {
+		return TrashedTask{}, err
+	}
+	var (
+		match TrashedTask
+		ids   []string
+	)
+	for _, tt := range all {
+		if strings.HasPrefix(tt.Task.ID, strings.ToLower(ref)) || strings.EqualFold(tt.Task.Ref(), ref) {
+			match = tt
+			ids = append(ids, tt.Task.ID)
+		}
+	}
+	switch len(ids) {
+	case 0:
+		return TrashedTask{}, fmt.Errorf("no task %s in the trash: %w", ref, ErrNotFound)
+	case 1:
+		return match, nil
+	}
+	return TrashedTask{}, &AmbiguousError{Prefix: ref, Candidates: newCandidates(ids)}
+}
+
+// RestoreTask brings a task back from the trash, by ID, unique ID prefix
+// or former T-<n> number. It keeps its number if still free and fails
+// with ErrConflict if a task with its ID exists again.
+func (s *Store) RestoreTask(ctx context.Context, ref string) (Task, error) {
+	if err := s.authorize(ctx, "restore", RoleContributor); err != nil {
+		return Task{}, err
+	}
+	tt, err := s.resolveTrashed(ctx, ref)
+	if err != nil {
+		return Task{}, err
+	}
+	t, err := s.restore(ctx, tt.Task)
+	if err != nil {
+		return Task{}, err
+	}
+	s.recordUndo(ctx, "restore", undoChange(nil, &t))
+	return t, nil
+}
+
+// restore puts a deleted task back and drops it from the trash.
+func (s *Store) restore(ctx context.Context, t Task) (Task, error) {
+	if _, _, err := s.getTask(ctx, t.ID); err == nil {
+		return Task{}, fmt.Errorf("task %s exists again; not restoring it: %w", t.Ref(), ErrConflict)
+	} else if !errors.Is(err, ErrNotFound) {
+		return Task{}, err
+	}
+	if _, err := s.putTask(ctx, t, true); err != nil {
+		return Task{}, err
+	}
+	s.untrash(ctx, t.ID)
+	got, _, err := s.getTask(ctx, t.ID)
+	return got, err
+}
+
+// PurgeTrash permanently deletes tasks trashed longer than olderThan ago
+// (0 = all of them) and returns how many it removed.
+func (s *Store) PurgeTrash(ctx context.Context, olderThan time.Duration) (int, error) {
+	if err := s.authorize(ctx, "purge trash", RoleAdmin); err != nil {
+		return 0, err
+	}
+	all, err := s.ListTrash(ctx)
+	if err != nil {
+		return 0, err
+	}
+	cutoff := time.Now().Add(-olderThan)
+	n := 0
+	for _, tt := range all {
+		if olderThan > 0 && tt.DeletedAt.After(cutoff) {
+			continue
+		}
+		if err := s.trashKV.Delete(ctx, tt.Task.ID); err != nil && !errors.Is(err, jetstream.ErrKeyNotFound) {
+			return n, fmt.Errorf("purge task %s: %w", tt.Task.ID, err)
+		}
+		n++
+	}
+	s.log.DebugContext(ctx, "trash purged", "op", "purge", "tasks", n)
+	return n, nil
+}
@@ -0,0 +1,77 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTrash(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	a, _, err := s.CreateTask(ctx, TaskInput{Text: "a", Tags: []string{"x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _, err := s.CreateTask(ctx, TaskInput{Text: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.DeleteTask(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if ids := listIDs(t, s, ListFilter{Any: []string{"x"}}); len(ids) != 0 {
+		t.Fatalf("tag x still lists %v after the delete", ids)
+	}
+	trash, err := s.ListTrash(ctx)
+	if err != nil || len(trash) != 1 || trash[0].Task.ID != a.ID || trash[0].Task.Text != "a" || trash[0].DeletedAt.IsZero() {
+		t.Fatalf("ListTrash = %+v, %v", trash, err)
+	}
+
+	got, err := s.RestoreTask(ctx, a.Ref())
+	if err != nil || got.ID != a.ID || got.Number != a.Number {
+		t.Fatalf("RestoreTask(%s) = %+v, %v", a.Ref(), got, err)
+	}
+	if ids := listIDs(t, s, ListFilter{Any: []string{"x"}}); !slices.Equal(ids, []string{a.ID}) {
+		t.Fatalf("tag x lists %v after the restore", ids)
+	}
+	if trash, _ := s.ListTrash(ctx); len(trash) != 0 {
+		t.Fatalf("restored task left in the trash: %+v", trash)
+	}
+	if _, err := s.RestoreTask(ctx, a.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("second restore = %v, want ErrNotFound", err)
+	}
+
+	if _, err := s.DeleteTasks(ctx, []string{a.ID, b.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := s.PurgeTrash(ctx, time.Hour); err != nil || n != 0 {
+		t.Fatalf("PurgeTrash(1h) = %d, %v; want nothing that recent", n, err)
+	}
+	if n, err := s.PurgeTrash(ctx, 0); err != nil || n != 2 {
+		t.Fatalf("PurgeTrash(0) = %d, %v; want 2", n, err)
+	}
+	if _, err := s.RestoreTask(ctx, b.ID[:12]); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("restore after purge = %v, want ErrNotFound", err)
+	}
+}
+
+func TestUndoDeleteEmptiesTrash(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	a, _, err := s.CreateTask(ctx, TaskInput{Text: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.DeleteTask(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Undo(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if trash, err := s.ListTrash(ctx); err != nil || len(trash) != 0 {
+		t.Fatalf("ListTrash after undoing the delete = %+v, %v", trash, err)
+	}
+}
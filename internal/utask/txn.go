@@ -0,0 +1,293 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Tx stages changes to several tasks for Store.Txn, which writes all of
+// them or none. Reads through a Tx see the changes it has staged.
+type Tx struct {
+	s     *Store
+	ctx   context.Context
+	tasks map[string]*txTask
+	// order is the ids in the order the transaction first touched them,
+	// which is the order they are written in.
+	order []string
+}
+
+// txTask is one task in a transaction: as read, and as staged.
+type txTask struct {
+	before Task
+	rev    uint64
+	// after is the staged task, nil once deleted.
+	after *Task
+	dirty bool
+}
+
+// load reads a task into the transaction, once.
+func (tx *Tx) load(id string) (*txTask, error) {
+	if t, ok := tx.tasks[id]; ok {
+		return t, nil
+	}
+	before, rev, err := tx.s.GetTask(tx.ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	after := before
+	t := &txTask{before: before, rev: rev, after: &after}
+	tx.tasks[id] = t
+	tx.order = append(tx.order, id)
+	return t, nil
+}
+
+// live is load for a task the transaction must not have deleted.
+func (tx *Tx) live(id string) (*txTask, error) {
+	t, err := tx.load(id)
+	if err != nil {
+		return nil, err
+	}
+	if t.after == nil {
+		return nil, fmt.Errorf("task %s is deleted in this transaction: %w", id, ErrNotFound)
+	}
+	return t, nil
+}
+
+// Get returns the task as the transaction would leave it.
+func (tx *Tx) Get(id string) (Task, error) {
+	t, err := tx.live(id)
+	if err != nil {
+		return Task{}, err
+	}
+	return *t.after, nil
+}
+
+// Update stages set on a task, like UpdateTask. set.IfRevision is checked
+// against the revision the transaction read the task at.
+func (tx *Tx) Update(id string, set UpdateSet) (Task, error) {
+	if err := tx.s.authorize(tx.ctx, "update", RoleContributor); err != nil {
+		return Task{}, err
+	}
+	t, err := tx.live(id)
+	if err != nil {
+		return Task{}, err
+	}
+	if err := checkRevision(id, set.IfRevision, t.rev); err != nil {
+		return Task{}, err
+	}
+	return tx.stage(t, set)
+}
+
+// Close stages closing a task, like CloseTasks, and reports whether it
+// was still open.
+func (tx *Tx) Close(id string) (bool, error) {
+	if err := tx.s.authorize(tx.ctx, "set done", RoleContributor); err != nil {
+		return false, err
+	}
+	t, err := tx.live(id)
+	if err != nil {
+		return false, err
+	}
+	if t.after.Done || t.after.ReviewRequestedBy != "" {
+		return false, nil
+	}
+	done := true
+	_, err = tx.stage(t, UpdateSet{Done: &done})
+	return err == nil, err
+}
+
+// Tag stages adding and removing tags on a task, like TagTasks, and
+// reports whether that changes them.
+func (tx *Tx) Tag(id string, add, remove []string) (bool, error) {
+	if err := tx.s.authorize(tx.ctx, "update", RoleContributor); err != nil {
+		return false, err
+	}
+	add, remove = normalizeTags(add), normalizeTags(remove)
+	if err := tx.s.opts.Limits.validateTags(add); err != nil {
+		return false, err
+	}
+	t, err := tx.live(id)
+	if err != nil {
+		return false, err
+	}
+	tags, ok := retag(t.after.Tags, add, remove)
+	if !ok {
+		return false, nil
+	}
+	_, err = tx.stage(t, UpdateSet{Tags: &tags})
+	return err == nil, err
+}
+
+// Delete stages moving a task to the trash, like DeleteTask.
+func (tx *Tx) Delete(id string) error {
+	if err := tx.s.authorize(tx.ctx, "delete", RoleAdmin); err != nil {
+		return err
+	}
+	t, err := tx.live(id)
+	if err != nil {
+		return err
+	}
+	t.after, t.dirty = nil, true
+	return nil
+}
+
+func (tx *Tx) stage(t *txTask, set UpdateSet) (Task, error) {
+	after, err := tx.s.applyUpdate(tx.ctx, *t.after, set)
+	if err != nil {
+		return Task{}, err
+	}
+	t.after, t.dirty = &after, true
+	return after, nil
+}
+
+// Txn runs fn to stage changes to any number of tasks, then writes them
+// in the order fn first touched each task, every write a compare-and-set
+// against the revision the task was read at. If a write fails, the ones
+// already made are put back before anything is indexed, audited or
+// recorded for undo, so a failure leaves neither tasks nor indexes half
+// changed. A conflict runs fn again against fresh reads, so fn may run
+// more than once and should do nothing but stage changes; an error from
+// fn itself discards them. Once every write has landed the changes are
+// indexed and recorded as one undo entry.
+//
+// A task changed by someone else between the failed write and putting
+// back one of ours can't be put back; it keeps our change, is indexed as
+// such, and the error says so.
+func (s *Store) Txn(ctx context.Context, fn func(tx *Tx) error) error {
+	var (
+		tx                      *Tx
+		fnErr, commitErr, rbErr error
+		written, kept           []*txTask
+	)
+	err := retryCAS(ctx, "transaction", func() error {
+		tx = &Tx{s: s, ctx: ctx, tasks: map[string]*txTask{}}
+		if fnErr = fn(tx); fnErr != nil {
+			return nil
+		}
+		if written, commitErr = tx.commit(); commitErr == nil {
+			return nil
+		}
+		if kept, rbErr = tx.rollback(written); rbErr != nil {
+			// Some writes stuck, so running fn again could apply them twice.
+			return nil
+		}
+		return commitErr
+	})
+	switch {
+	case fnErr != nil:
+		return fnErr
+	case rbErr != nil:
+		return errors.Join(commitErr, fmt.Errorf("transaction partly applied: %w", rbErr), tx.finish(kept))
+	case err != nil:
+		return err
+	}
+	return tx.finish(written)
+}
+
+// commit writes the staged tasks, stopping at the first failure, and
+// returns the ones it wrote.
+func (tx *Tx) commit() ([]*txTask, error) {
+	var written []*txTask
+	for _, id := range tx.order {
+		t := tx.tasks[id]
+		if !t.dirty {
+			continue
+		}
+		if err := tx.ctx.Err(); err != nil {
+			return written, err
+		}
+		if err := tx.write(id, t); err != nil {
+			return written, err
+		}
+		written = append(written, t)
+	}
+	return written, nil
+}
+
+func (tx *Tx) write(id string, t *txTask) error {
+	s, ctx := tx.s, tx.ctx
+	if t.after != nil {
+		rev, err := s.putTaskCAS(ctx, id, *t.after, t.rev)
+		t.after.Revision = rev
+		return err
+	}
+	if err := s.trash(ctx, t.before); err != nil {
+		return err
+	}
+	if err := deleteIf(ctx, s.tasksKV, s.taskKey(id), t.rev); err != nil {
+		s.untrash(ctx, id)
+		return casError("delete task "+id, err)
+	}
+	return nil
+}
+
+// rollback puts back written tasks, newest write first, and returns the
+// ones it could not.
+func (tx *Tx) rollback(written []*txTask) ([]*txTask, error) {
+	s, ctx := tx.s, tx.ctx
+	var (
+		kept []*txTask
+		errs []error
+	)
+	for i := len(written) - 1; i >= 0; i-- {
+		t := written[i]
+		id := t.before.ID
+		var err error
+		if t.after != nil {
+			_, err = s.putTaskCAS(ctx, id, t.before, t.after.Revision)
+		} else {
+			var b []byte
+			if b, err = encodeTask(t.before, s.opts); err == nil {
+				if _, err = s.tasksKV.Create(ctx, s.taskKey(id), b); err == nil {
+					s.reindexTrailers(ctx, id, &t.before)
+					s.untrash(ctx, id)
+				}
+			}
+		}
+		if err != nil {
+			s.log.WarnContext(ctx, "transaction write not rolled back", "op", "txn", "task", id, "err", err)
+			kept = append(kept, t)
+			errs = append(errs, fmt.Errorf("task %s: %w", id, err))
+		}
+	}
+	return kept, errors.Join(errs...)
+}
+
+// finish does what follows a write for each written task: logging,
+// audit, claims and recurrence, one undo entry and the index changes.
+func (tx *Tx) finish(written []*txTask) error {
+	s, ctx := tx.s, tx.ctx
+	var (
+		tags   tagChanges
+		status statusChanges
+		undo   []UndoChange
+	)
+	for _, t := range written {
+		before, after := t.before, t.after
+		id := before.ID
+		if after == nil {
+			s.logWrite(ctx, "delete", id, 0)
+			before.Revision = 0
+			s.audit(ctx, ActivityDeleted, before, "")
+			s.dropClaim(ctx, id)
+			s.dropNumber(ctx, before)
+			s.reindexTrailers(ctx, id, nil)
+			tags.note(id, before.Tags, nil)
+			status.note(id, &before, nil)
+			undo = append(undo, undoChange(&before, nil))
+			continue
+		}
+		s.logWrite(ctx, "txn", id, after.Revision)
+		s.auditUpdate(ctx, before, *after)
+		if after.Done && !before.Done {
+			s.dropClaim(ctx, id)
+			s.recur(ctx, *after)
+		}
+		tags.note(id, before.Tags, after.Tags)
+		status.note(id, &before, after)
+		undo = append(undo, undoChange(&before, after))
+	}
+	s.recordUndo(ctx, "txn", undo...)
+	return s.bulkReindex(ctx, "txn", tags, status)
+}
@@ -0,0 +1,145 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// failingKV fails every update of one key with a non-conflict error.
+type failingKV struct {
+	jetstream.KeyValue
+	key string
+}
+
+func (kv *failingKV) Update(ctx context.Context, key string, value []byte, rev uint64) (uint64, error) {
+	if key == kv.key {
+		return 0, errors.New("disk full")
+	}
+	return kv.KeyValue.Update(ctx, key, value, rev)
+}
+
+func TestTxn(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	var ids []string
+	for _, text := range []string{"release 1.0", "write notes", "tag build"} {
+		task, _, err := s.CreateTask(ctx, TaskInput{Text: text, Tags: []string{"release"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, task.ID)
+	}
+
+	runs := 0
+	err := s.Txn(ctx, func(tx *Tx) error {
+		runs++
+		for _, id := range ids {
+			if _, err := tx.Close(id); err != nil {
+				return err
+			}
+			if _, err := tx.Tag(id, []string{"shipped"}, []string{"release"}); err != nil {
+				return err
+			}
+		}
+		if runs == 1 {
+			// Another writer gets to the last task first; the whole
+			// transaction is rolled back and run again.
+			text := "tag the build"
+			if _, err := s.UpdateTask(ctx, ids[2], UpdateSet{Text: &text}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil || runs != 2 {
+		t.Fatalf("Txn = %v after %d runs", err, runs)
+	}
+	for _, id := range ids {
+		task, _, err := s.GetTask(ctx, id)
+		if err != nil || !task.Done || !reflect.DeepEqual(task.Tags, []string{"shipped"}) {
+			t.Fatalf("task %s = %+v, %v", id, task, err)
+		}
+	}
+	if got := tagIDs(t, s, "shipped"); len(got) != 3 {
+		t.Fatalf("shipped index = %v", got)
+	}
+	if got := tagIDs(t, s, "release"); len(got) != 0 {
+		t.Fatalf("release index = %v", got)
+	}
+	entry, err := s.Undo(ctx)
+	if err != nil || entry.Op != "txn" || len(entry.Changes) != 3 {
+		t.Fatalf("Undo = %+v, %v", entry, err)
+	}
+	if got := tagIDs(t, s, "release"); len(got) != 3 {
+		t.Fatalf("release index after undo = %v", got)
+	}
+}
+
+func TestTxnRollback(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	a, _, err := s.CreateTask(ctx, TaskInput{Text: "parent", Tags: []string{"epic"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _, err := s.CreateTask(ctx, TaskInput{Text: "child one", Tags: []string{"epic"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _, err := s.CreateTask(ctx, TaskInput{Text: "child two", Tags: []string{"epic"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An error from fn writes nothing.
+	boom := errors.New("boom")
+	if err := s.Txn(ctx, func(tx *Tx) error {
+		if _, err := tx.Close(a.ID); err != nil {
+			return err
+		}
+		return boom
+	}); !errors.Is(err, boom) {
+		t.Fatalf("Txn = %v, want boom", err)
+	}
+	if task, _, _ := s.GetTask(ctx, a.ID); task.Done {
+		t.Fatal("a failed transaction closed its task")
+	}
+
+	// A failed write puts back the ones before it, deletes included.
+	s.tasksKV = &failingKV{KeyValue: s.tasksKV, key: s.taskKey(c.ID)}
+	err = s.Txn(ctx, func(tx *Tx) error {
+		if _, err := tx.Tag(a.ID, []string{"done"}, []string{"epic"}); err != nil {
+			return err
+		}
+		if err := tx.Delete(b.ID); err != nil {
+			return err
+		}
+		_, err := tx.Close(c.ID)
+		return err
+	})
+	if err == nil {
+		t.Fatal("Txn succeeded past a failing write")
+	}
+	if task, _, err := s.GetTask(ctx, a.ID); err != nil || !reflect.DeepEqual(task.Tags, []string{"epic"}) {
+		t.Fatalf("a after rollback = %+v, %v", task, err)
+	}
+	if _, _, err := s.GetTask(ctx, b.ID); err != nil {
+		t.Fatalf("deleted task not put back: %v", err)
+	}
+	if trash, err := s.ListTrash(ctx); err != nil || len(trash) != 0 {
+		t.Fatalf("trash after rollback = %v, %v", trash, err)
+	}
+	if got := tagIDs(t, s, "epic"); len(got) != 3 {
+		t.Fatalf("epic index = %v", got)
+	}
+	if got := tagIDs(t, s, "done"); len(got) != 0 {
+		t.Fatalf("done index = %v", got)
+	}
+	if hist, err := s.UndoHistory(ctx); err != nil || hist[0].Op == "txn" {
+		t.Fatalf("rolled back transaction recorded for undo: %+v, %v", hist, err)
+	}
+}
@@ -1,45 +1,150 @@
 package utask
 
-import "strings"
+import (
+	"strings"
+	"time"
+)
 
-// Status is kept for filtering semantics in the CLI.
+// Status is a task's state (Task.Status, see status.go) and a ListFilter
+// selection: StatusOpen selects every open state, StatusClosed done and
+// cancelled tasks, and StatusReview open tasks awaiting approval.
 type Status string
 
 const (
 	StatusOpen   Status = "open"
 	StatusClosed Status = "closed"
+	// StatusReview selects open tasks awaiting approval to close.
+	StatusReview Status = "review"
 )
 
 // Task matches the spec fields, with optional extended metadata.
 type Task struct {
-	ID              string   `json:"id"`
-	Text            string   `json:"text"`
+	ID   string `json:"id"`
+	Text string `json:"text"`
+	// Description is the task's body when it was given apart from the
+	// title (TaskInput.Details); older tasks keep it in Text. Task.Details
+	// reads either.
+	Description     string   `json:"details,omitempty"`
 	Done            bool     `json:"done"`
 	Tags            []string `json:"tags"`
 	Created         string   `json:"created"`
 	Priority        int      `json:"priority,omitempty"`
 	EstimateMinutes int      `json:"estimate_minutes,omitempty"`
+	// Status is the task's state (see Task.State and Store.SetStatus).
+	// Done mirrors it for older readers: true when done or cancelled.
+	Status Status `json:"status,omitempty"`
+	// CreatedBy and UpdatedBy name who created the task and who last
+	// changed it (Options.Identity), when known.
+	CreatedBy string `json:"created_by,omitempty"`
+	UpdatedBy string `json:"updated_by,omitempty"`
+	// Modified is when the task last changed (see Task.touch), Closed
+	// when it was last done or cancelled; reopening clears Closed. Tasks
+	// stored before these were kept get them on their next change.
+	Modified *Timestamp `json:"modified,omitempty"`
+	Closed   *Timestamp `json:"closed,omitempty"`
+	// Assignee is who should do the task, as an identity like CreatedBy.
+	Assignee string `json:"assignee,omitempty"`
+	// Private tasks are only visible to their author (CreatedBy) and to
+	// admins; see Store.canSee.
+	Private bool `json:"private,omitempty"`
+	// ReviewRequestedBy is set while a close awaits approval by a second
+	// identity (see Store.ApproveTask); the task stays open meanwhile.
+	ReviewRequestedBy string `json:"review_requested_by,omitempty"`
+	// Delegation is set while the task's delegator waits on someone else
+	// (see Store.DelegateTask).
+	Delegation *Delegation `json:"delegation,omitempty"`
+	// SnoozedUntil hides an open task from default listings until then
+	// (see Store.SnoozeTask).
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
+	// Due is when the task must be done; at midnight it names a whole day
+	// (see Task.DueAt). Scheduled is when work on it is planned to start.
+	Due       *time.Time `json:"due,omitempty"`
+	Scheduled *time.Time `json:"scheduled,omitempty"`
+	// Worked lists the intervals spent on the task (see Store.LogWork).
+	Worked []WorkInterval `json:"worked,omitempty"`
+	// Annotations are notes added over time, oldest first (see
+	// Store.AnnotateTask).
+	Annotations []Annotation `json:"annotations,omitempty"`
+	// Contexts are where the task can be done, e.g. "@home" (see
+	// ParseContext).
+	Contexts []string `json:"contexts,omitempty"`
+	// DependsOn lists the ids of tasks that must be closed before this one
+	// is ready (see Store.BlockTask and Task.Ready).
+	DependsOn []string `json:"depends_on,omitempty"`
+	// Recur repeats the task: closing it creates the next instance (see
+	// ParseRecur and NextInstance).
+	Recur string `json:"recur,omitempty"`
+	// Number is the task's short reference, shown as T-<n> (see Task.Ref).
+	// It is handed out on create and does not affect the task id.
+	Number uint64 `json:"number,omitempty"`
+	// Revision is the KV revision the task was read at. It is filled in by
+	// the Store and never stored in the task value.
+	Revision uint64 `json:"revision,omitempty"`
+	// Schema is the record layout version (see SchemaVersion). It is set
+	// when a task is written out and cleared again when it is read back.
+	Schema int `json:"schema,omitempty"`
 }
 
 type TaskInput struct {
-	Text            string
+	Text string
+	// Details is an optional body kept apart from Text, so consumers
+	// needn't split title from body. It is part of the id when set.
+	Details         string
 	Tags            []string
 	Priority        int
 	EstimateMinutes int
+	// Assignee is stored as given and does not affect the task id.
+	Assignee string
+	// Private hides the task from everyone but its author and admins.
+	Private bool
+	// Contexts, like Assignee, don't affect the task id.
+	Contexts []string
+	// DependsOn names tasks (ids, prefixes or aliases) the new task waits
+	// on; like Contexts they don't affect the id.
+	DependsOn []string
+	// Recur is a recurrence rule for ParseRecur; it doesn't affect the id.
+	Recur string
+	// ForceNew gives the task a random id instead of its content id, so
+	// it is created even if a task with the same content exists.
+	ForceNew bool
+	// Due and Scheduled set Task.Due and Task.Scheduled; Wait hides the
+	// task until then, as SnoozedUntil. None of them affect the id.
+	Due       time.Time
+	Scheduled time.Time
+	Wait      time.Time
 }
 
 // UpdateSet describes allowed fields to modify in UpdateTask.
 type UpdateSet struct {
-	Text     *string
+	Text *string
+	// Details replaces Task.Description; an empty string clears it.
+	Details  *string
 	Done     *bool
 	Tags     *[]string
 	Priority *int
+	// Assignee reassigns the task; an empty string unassigns it.
+	Assignee *string
+	// Private changes the task's visibility.
+	Private *bool
+	// Contexts replaces the task's contexts; an empty slice clears them.
+	Contexts *[]string
+	// Due, Scheduled and Wait change Task.Due (dropping an older Due
+	// trailer), Task.Scheduled and Task.SnoozedUntil; the zero time clears
+	// them.
+	Due       *time.Time
+	Scheduled *time.Time
+	Wait      *time.Time
+	// Recur replaces the recurrence rule; an empty string stops the series.
+	Recur *string
+	// IfRevision, when non-zero, rejects the update with ErrConflict unless
+	// the task is still at this revision.
+	IfRevision uint64
 }
 
 // Trailer represents a parsed Git-like trailer "Key: Value".
 type Trailer struct {
-    Key   string
-    Value string
+	Key   string
+	Value string
 }
 
 // Short returns the first line of the task text, trimmed.
@@ -51,136 +156,111 @@ func (t Task) Short() string {
 	return trimSpace(s)
 }
 
-// Details returns the text following the first line, with leading/trailing
-// blank lines trimmed.
+// Details returns the task's Description or, for tasks that keep their body
+// in Text, the text following the first line, with leading/trailing blank
+// lines trimmed.
 func (t Task) Details() string {
-    lines := splitLines(t.Text)
-    if len(lines) <= 1 {
-        return ""
-    }
-    end := len(lines)
-    // Identify trailer block separated by at least one blank line
-    if drops, trs, ok := t.trailerBlock(); ok && (len(trs) > 0 || len(drops) > 0) {
-        // Cut body before the trailer block start
-        _, start := t.trailerRegionBounds()
-        end = start
-    }
-    body := joinLines(lines[1:end])
-    return trimBlankLines(body)
+	if t.Description != "" {
+		return t.Description
+	}
+	return t.textDetails()
+}
+
+// textDetails is the body Details parses out of Text.
+func (t Task) textDetails() string {
+	s := t.Text
+	nl := indexNL(s)
+	if nl < 0 {
+		return ""
+	}
+	end := len(s)
+	if start, _, ok := trailerRegion(s); ok {
+		// The body stops at the newline before the trailer block.
+		end = start - 1
+	}
+	if end <= nl {
+		return ""
+	}
+	return trimBlankLines(s[nl+1 : end])
 }
 
 // Trailers parses Git-like trailers from the end of the message.
 // It returns trailers in display order (top-to-bottom of the trailer block).
 func (t Task) Trailers() []Trailer {
-    _, trailers, _ := t.trailerBlock()
-    return trailers
+	_, trailers, _ := t.trailerBlock()
+	return trailers
 }
 
 // TrailerDrops returns the raw lines inside the trailer block that do not
 // conform to the trailer "Key: Value" format and are therefore dropped.
 func (t Task) TrailerDrops() []string {
-    drops, _, _ := t.trailerBlock()
-    return drops
+	drops, _, _ := t.trailerBlock()
+	return drops
 }
 
 // --- helpers (kept local to avoid extra deps) ---
+//
+// Parsing runs per task on every listing, so the helpers below work on byte
+// offsets into the original text and return substrings rather than
+// allocating line slices.
 
-func indexNL(s string) int {
-	for i := 0; i < len(s); i++ {
-		if s[i] == '\n' {
-			return i
-		}
-	}
-	return -1
-}
+func indexNL(s string) int { return strings.IndexByte(s, '\n') }
 
 func trimSpace(s string) string { return strings.TrimSpace(s) }
 
-func splitLines(s string) []string {
-	out := []string{}
-	start := 0
-	for i := 0; i < len(s); i++ {
-		if s[i] == '\n' {
-			out = append(out, s[start:i])
-			start = i + 1
-		}
-	}
-	out = append(out, s[start:])
-	return out
+func isBlank(s string) bool { return strings.TrimSpace(s) == "" }
+
+// lineBefore returns the start offset of the line ending at end (a '\n'
+// offset or len(s)).
+func lineBefore(s string, end int) (start int) {
+	return strings.LastIndexByte(s[:end], '\n') + 1
 }
 
+// trimBlankLines drops leading and trailing blank lines from s.
 func trimBlankLines(s string) string {
-	lines := splitLines(s)
-	// trim leading blanks
 	start := 0
-	for start < len(lines) && trimSpace(lines[start]) == "" {
-		start++
-	}
-	// trim trailing blanks
-	end := len(lines)
-	for end > start && trimSpace(lines[end-1]) == "" {
-		end--
-	}
-	if start >= end {
-		return ""
-	}
-	// join with \n
-	n := 0
-	for i := start; i < end; i++ {
-		n += len(lines[i])
-		if i+1 < end {
-			n++
+	for start < len(s) {
+		i := strings.IndexByte(s[start:], '\n')
+		if i < 0 {
+			if isBlank(s[start:]) {
+				return ""
+			}
+			break
+		}
+		if !isBlank(s[start : start+i]) {
+			break
 		}
+		start += i + 1
 	}
-	b := make([]byte, 0, n)
-	for i := start; i < end; i++ {
-		b = append(b, lines[i]...)
-		if i+1 < end {
-			b = append(b, '\n')
+	end := len(s)
+	for end > start {
+		ls := lineBefore(s, end)
+		if ls < start {
+			ls = start
+		}
+		if !isBlank(s[ls:end]) {
+			break
 		}
+		if ls == start {
+			return ""
+		}
+		end = ls - 1
 	}
-	return string(b)
-}
-
-func joinLines(lines []string) string {
-    if len(lines) == 0 {
-        return ""
-    }
-    n := 0
-    for i := 0; i < len(lines); i++ {
-        n += len(lines[i])
-        if i+1 < len(lines) {
-            n++
-        }
-    }
-    b := make([]byte, 0, n)
-    for i := 0; i < len(lines); i++ {
-        b = append(b, lines[i]...)
-        if i+1 < len(lines) {
-            b = append(b, '\n')
-        }
-    }
-    return string(b)
+	return s[start:end]
 }
 
 func parseTrailer(s string) (Trailer, bool) {
 	// Minimal parse: Key: Value, Key matches [A-Za-z0-9-]+
-	// Find colon
-	for i := 0; i < len(s); i++ {
-		if s[i] == ':' {
-			key := s[:i]
-			if !isValidKey(key) {
-				return Trailer{}, false
-			}
-			// skip spaces after colon
-			j := i + 1
-			for j < len(s) && (s[j] == ' ' || s[j] == '\t') {
-				j++
-			}
-			return Trailer{Key: key, Value: s[j:]}, true
-		}
+	i := strings.IndexByte(s, ':')
+	if i < 0 || !isValidKey(s[:i]) {
+		return Trailer{}, false
+	}
+	// skip spaces after colon
+	j := i + 1
+	for j < len(s) && (s[j] == ' ' || s[j] == '\t') {
+		j++
 	}
-	return Trailer{}, false
+	return Trailer{Key: s[:i], Value: s[j:]}, true
 }
 
 func isValidKey(s string) bool {
@@ -197,60 +277,61 @@ func isValidKey(s string) bool {
 	return true
 }
 
-// trailerRegionBounds returns (endIndexExclusive, startIndexInclusive) for the
-// trailer region, if present, otherwise (len(lines), len(lines)).
-func (t Task) trailerRegionBounds() (end, start int) {
-    lines := splitLines(t.Text)
-    n := len(lines)
-    // Skip trailing blanks
-    endIdx := n - 1
-    for endIdx >= 0 && trimSpace(lines[endIdx]) == "" {
-        endIdx--
-    }
-    if endIdx < 0 {
-        return n, n
-    }
-    // Look upwards for the separating blank line(s)
-    k := endIdx
-    foundBlank := false
-    for k >= 0 {
-        if trimSpace(lines[k]) == "" {
-            foundBlank = true
-            break
-        }
-        k--
-    }
-    if !foundBlank || k == endIdx {
-        // No separating blank line between body and tail block
-        return n, n
-    }
-    // Trailer region starts after the last contiguous blank(s)
-    startIdx := k + 1
-    // Move startIdx forward if multiple blanks
-    for startIdx < n && trimSpace(lines[startIdx]) == "" {
-        startIdx++
-    }
-    return endIdx + 1, startIdx
+// trailerRegion locates the trailer block: the last paragraph of s, ignoring
+// trailing blank lines, when it is separated from the text above by a blank
+// line. start is the offset of its first line and end the offset just past
+// its last non-blank line.
+func trailerRegion(s string) (start, end int, ok bool) {
+	// Skip trailing blank lines.
+	end = len(s)
+	for {
+		ls := lineBefore(s, end)
+		if !isBlank(s[ls:end]) {
+			break
+		}
+		if ls == 0 {
+			return 0, 0, false
+		}
+		end = ls - 1
+	}
+	// Walk up to the nearest blank line.
+	pos := end
+	for {
+		ls := lineBefore(s, pos)
+		if isBlank(s[ls:pos]) {
+			return pos + 1, end, true
+		}
+		if ls == 0 {
+			return 0, 0, false
+		}
+		pos = ls - 1
+	}
 }
 
 // trailerBlock returns (drops, trailers, ok) where ok=true if a trailer region
 // is present (separated by blank lines). Drops are lines in the region that do
 // not match the trailer format and will be excluded from Details().
 func (t Task) trailerBlock() (drops []string, trailers []Trailer, ok bool) {
-    lines := splitLines(t.Text)
-    end, start := t.trailerRegionBounds()
-    if start >= end {
-        return nil, nil, false
-    }
-    drops = []string{}
-    trailers = []Trailer{}
-    for i := start; i < end; i++ {
-        line := lines[i]
-        if kv, ok := parseTrailer(line); ok {
-            trailers = append(trailers, kv)
-        } else if trimSpace(line) != "" { // ignore extra blanks in region
-            drops = append(drops, line)
-        }
-    }
-    return drops, trailers, true
+	s := t.Text
+	start, end, ok := trailerRegion(s)
+	if !ok {
+		return nil, nil, false
+	}
+	drops = []string{}
+	trailers = []Trailer{}
+	for start <= end {
+		line := s[start:end]
+		next := end + 1
+		if i := strings.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i]
+			next = start + i + 1
+		}
+		if kv, ok := parseTrailer(line); ok {
+			trailers = append(trailers, kv)
+		} else if !isBlank(line) { // ignore extra blanks in region
+			drops = append(drops, line)
+		}
+		start = next
+	}
+	return drops, trailers, true
 }
@@ -28,5 +28,29 @@ func TestDetailsExcludesTrailers(t *testing.T) {
     if trs[1].Key != "Reviewed-by" || !strings.Contains(trs[1].Value, "bob@example.com") {
         t.Fatalf("unexpected second trailer: %+v", trs[1])
     }
+
+    task.Description = "Kept apart"
+    if got := task.Details(); got != "Kept apart" {
+        t.Fatalf("Details() with a description = %q", got)
+    }
 }
 
+
+func TestParseEdgeCases(t *testing.T) {
+    cases := []struct {
+        text, short, details string
+        trailers             int
+    }{
+        {"", "", "", 0},
+        {"Only title", "Only title", "", 0},
+        {"Title\n\n\nBody\n\n", "Title", "", 0},
+        {"Title\nBody\n\nDue: fri\n\n", "Title", "Body", 1},
+        {"  Title  \n\n  \nBody a\n\nBody b\n\nRef: x", "Title", "Body a\n\nBody b", 1},
+    }
+    for _, c := range cases {
+        task := Task{Text: c.text}
+        if task.Short() != c.short || task.Details() != c.details || len(task.Trailers()) != c.trailers {
+            t.Fatalf("%q: got short=%q details=%q trailers=%v", c.text, task.Short(), task.Details(), task.Trailers())
+        }
+    }
+}
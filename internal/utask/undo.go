@@ -0,0 +1,393 @@
+package utask
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// DefaultUndoDepth is how many mutations a profile can undo when
+// Options.UndoDepth is zero.
+const DefaultUndoDepth = 20
+
+// The undo bucket is a ring buffer of the last UndoDepth mutations: head
+// holds the sequence number of the latest, and entry n lives under
+// u.<n mod depth>, so each new entry overwrites the oldest. Writers bump
+// head before filling the slot, and readers ignore a slot whose entry
+// carries another sequence number.
+const undoHeadKey = "head"
+
+// undoBucketName holds a profile's undo entries.
+func undoBucketName(ns string) string { return fmt.Sprintf("utask_undo_%s", ns) }
+
+func undoSlotKey(seq uint64, depth int) string {
+	return "u." + strconv.FormatUint(seq%uint64(depth), 10)
+}
+
+// undoDepth is Options.UndoDepth with its default applied; 0 means off.
+func (o Options) undoDepth() int {
+	switch {
+	case o.UndoDepth < 0:
+		return 0
+	case o.UndoDepth == 0:
+		return DefaultUndoDepth
+	}
+	return o.UndoDepth
+}
+
+// UndoEntry is one recorded mutation: the tasks it changed, as they were
+// before it.
+type UndoEntry struct {
+	Seq     uint64       `json:"seq"`
+	Op      string       `json:"op"`
+	By      string       `json:"by,omitempty"`
+	At      time.Time    `json:"at"`
+	Changes []UndoChange `json:"changes"`
+	// Undone is set once Undo has reverted the entry.
+	Undone bool `json:"undone,omitempty"`
+}
+
+// UndoChange is one task's part of an UndoEntry.
+type UndoChange struct {
+	ID     string `json:"id"`
+	Number uint64 `json:"number,omitempty"`
+	// Before is the task as it was; nil when the mutation created it.
+	Before *Task `json:"before,omitempty"`
+	// Revision is the one the mutation left the task at; 0 when it
+	// deleted the task.
+	Revision uint64 `json:"revision,omitempty"`
+}
+
+type noUndoKey struct{}
+
+// Ref returns how to refer to the task, as Task.Ref does.
+func (c UndoChange) Ref() string { return Task{ID: c.ID, Number: c.Number}.Ref() }
+
+// withoutUndo returns ctx whose writes record no undo entry, for Undo's own
+// writes.
+func withoutUndo(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noUndoKey{}, true)
+}
+
+// undoChange describes a task going from before to after; before is nil
+// when it was created and after when it was deleted.
+func undoChange(before, after *Task) UndoChange {
+	var c UndoChange
+	if after != nil {
+		c.ID, c.Number, c.Revision = after.ID, after.Number, after.Revision
+	}
+	if before != nil {
+		b := *before
+		b.Revision = 0
+		c.ID, c.Number, c.Before = b.ID, b.Number, &b
+	}
+	return c
+}
+
+// recordUndo appends an entry for op to the undo ring. The mutation has
+// already happened, so failures are logged rather than returned.
+func (s *Store) recordUndo(ctx context.Context, op string, changes ...UndoChange) {
+	depth := s.opts.undoDepth()
+	if depth == 0 || len(changes) == 0 || ctx.Value(noUndoKey{}) != nil {
+		return
+	}
+	entry := UndoEntry{Op: op, By: s.identity(ctx), At: time.Now().UTC(), Changes: make([]UndoChange, len(changes))}
+	for i, c := range changes {
+		if c.Before != nil {
+			sealed, err := sealTask(*c.Before, s.opts)
+			if err != nil {
+				s.log.WarnContext(ctx, "undo entry not recorded", "op", op, "err", err)
+				return
+			}
+			c.Before = &sealed
+		}
+		entry.Changes[i] = c
+	}
+	err := retryCAS(ctx, "record undo", func() error {
+		seq, rev, err := s.undoHead(ctx)
+		if err != nil {
+			return err
+		}
+		entry.Seq = seq + 1
+		head := []byte(strconv.FormatUint(entry.Seq, 10))
+		if rev == 0 {
+			_, err = s.undoKV.Create(ctx, undoHeadKey, head)
+		} else {
+			_, err = s.undoKV.Update(ctx, undoHeadKey, head, rev)
+		}
+		if err != nil {
+			return casError("undo head", err)
+		}
+		return nil
+	})
+	if err == nil {
+		var b []byte
+		if b, err = json.Marshal(entry); err == nil {
+			_, err = s.undoKV.Put(ctx, undoSlotKey(entry.Seq, depth), b)
+		}
+	}
+	if err != nil {
+		s.log.WarnContext(ctx, "undo entry not recorded", "op", op, "err", err)
+	}
+}
+
+// undoHead returns the sequence number of the latest entry (0 for none) and
+// the revision of the head key (0 when it doesn't exist yet).
+func (s *Store) undoHead(ctx context.Context) (uint64, uint64, error) {
+	e, err := s.undoKV.Get(ctx, undoHeadKey)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("read undo head: %w", err)
+	}
+	seq, err := strconv.ParseUint(string(e.Value()), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("read undo head: %w", err)
+	}
+	return seq, e.Revision(), nil
+}
+
+// latestUndo returns the newest entry not yet undone and its slot's
+// revision.
+func (s *Store) latestUndo(ctx context.Context) (UndoEntry, uint64, error) {
+	depth := s.opts.undoDepth()
+	if depth == 0 {
+		return UndoEntry{}, 0, fmt.Errorf("undo is turned off (storage.undo_depth): %w", ErrNotFound)
+	}
+	head, _, err := s.undoHead(ctx)
+	if err != nil {
+		return UndoEntry{}, 0, err
+	}
+	for seq := head; seq > 0 && head-seq < uint64(depth); seq-- {
+		e, err := s.undoKV.Get(ctx, undoSlotKey(seq, depth))
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			break
+		}
+		if err != nil {
+			return UndoEntry{}, 0, fmt.Errorf("read undo entry: %w", err)
+		}
+		var entry UndoEntry
+		if err := json.Unmarshal(e.Value(), &entry); err != nil {
+			return UndoEntry{}, 0, fmt.Errorf("read undo entry %d: %w", seq, err)
+		}
+		if entry.Seq != seq {
+			break
+		}
+		if !entry.Undone {
+			return entry, e.Revision(), nil
+		}
+	}
+	return UndoEntry{}, 0, fmt.Errorf("nothing to undo: %w", ErrNotFound)
+}
+
+// UndoHistory returns the entries Undo can still revert, newest first.
+func (s *Store) UndoHistory(ctx context.Context) ([]UndoEntry, error) {
+	depth := s.opts.undoDepth()
+	head, _, err := s.undoHead(ctx)
+	if err != nil || depth == 0 {
+		return nil, err
+	}
+	out := []UndoEntry{}
+	for seq := head; seq > 0 && head-seq < uint64(depth); seq-- {
+		e, err := s.undoKV.Get(ctx, undoSlotKey(seq, depth))
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read undo entry: %w", err)
+		}
+		var entry UndoEntry
+		if err := json.Unmarshal(e.Value(), &entry); err != nil || entry.Seq != seq {
+			break
+		}
+		if entry.Undone {
+			continue
+		}
+		if err := s.openUndo(&entry); err != nil {
+			return nil, err
+		}
+		out = append(out, entry)
+	}
+	return out, nil
+}
+
+// openUndo opens the sealed text of an entry's tasks.
+func (s *Store) openUndo(entry *UndoEntry) error {
+	for _, c := range entry.Changes {
+		if c.Before == nil {
+			continue
+		}
+		if err := s.openTask(c.Before); err != nil {
+			return fmt.Errorf("undo entry %d: task %s: %w", entry.Seq, c.Ref(), err)
+		}
+	}
+	return nil
+}
+
+// Undo reverts the latest mutation not yet undone: it deletes tasks the
+// mutation created, restores ones it deleted and puts back the previous
+// version of ones it changed. A task changed again since is left alone and
+// reported with ErrConflict. When no task could be reverted the entry stays
+// available; otherwise it is marked undone and the next Undo goes further
+// back. Undo itself records no entry.
+func (s *Store) Undo(ctx context.Context) (UndoEntry, error) {
+	if err := s.authorize(ctx, "undo", RoleContributor); err != nil {
+		return UndoEntry{}, err
+	}
+	var entry UndoEntry
+	err := retryCAS(ctx, "undo", func() error {
+		var (
+			rev uint64
+			err error
+		)
+		if entry, rev, err = s.latestUndo(ctx); err != nil {
+			return err
+		}
+		entry.Undone = true
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if _, err := s.undoKV.Update(ctx, undoSlotKey(entry.Seq, s.opts.undoDepth()), b, rev); err != nil {
+			return casError("undo entry", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return UndoEntry{}, err
+	}
+	if err := s.openUndo(&entry); err != nil {
+		return entry, err
+	}
+	ctx = withoutUndo(ctx)
+	var errs []error
+	revs := map[string]uint64{}
+	for i := len(entry.Changes) - 1; i >= 0; i-- {
+		c := entry.Changes[i]
+		rev, err := s.revert(ctx, c)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if err == nil || rev != 0 {
+			// A stale index still leaves the task reverted.
+			revs[c.ID] = rev
+		}
+	}
+	if len(revs) == 0 && len(errs) > 0 {
+		s.unmarkUndone(ctx, entry)
+	}
+	s.log.DebugContext(ctx, "undid "+entry.Op, "op", "undo", "seq", entry.Seq, "tasks", len(revs))
+	s.rebaseUndo(ctx, entry.Seq, revs)
+	return entry, errors.Join(errs...)
+}
+
+// rebaseUndo points the entries below seq at the revisions undoing it left
+// tasks at, so undoing further back finds each task as it expects. Only
+// the latest earlier entry for a task needs it.
+func (s *Store) rebaseUndo(ctx context.Context, seq uint64, revs map[string]uint64) {
+	depth := s.opts.undoDepth()
+	head, _, err := s.undoHead(ctx)
+	if err != nil {
+		return
+	}
+	for n := seq - 1; n > 0 && head-n < uint64(depth) && len(revs) > 0; n-- {
+		key := undoSlotKey(n, depth)
+		e, err := s.undoKV.Get(ctx, key)
+		if err != nil {
+			return
+		}
+		var entry UndoEntry
+		if err := json.Unmarshal(e.Value(), &entry); err != nil || entry.Seq != n {
+			return
+		}
+		if entry.Undone {
+			continue
+		}
+		changed := false
+		for i, c := range entry.Changes {
+			if rev, ok := revs[c.ID]; ok {
+				entry.Changes[i].Revision = rev
+				delete(revs, c.ID)
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		b, err := json.Marshal(entry)
+		if err == nil {
+			_, err = s.undoKV.Update(ctx, key, b, e.Revision())
+		}
+		if err != nil {
+			s.log.WarnContext(ctx, "undo entry not updated", "op", "undo", "seq", n, "err", err)
+		}
+	}
+}
+
+// unmarkUndone makes an entry Undo couldn't apply available again.
+func (s *Store) unmarkUndone(ctx context.Context, entry UndoEntry) {
+	entry.Undone = false
+	for _, c := range entry.Changes {
+		if c.Before == nil {
+			continue
+		}
+		sealed, err := sealTask(*c.Before, s.opts)
+		if err != nil {
+			return
+		}
+		*c.Before = sealed
+	}
+	if b, err := json.Marshal(entry); err == nil {
+		_, _ = s.undoKV.Put(ctx, undoSlotKey(entry.Seq, s.opts.undoDepth()), b)
+	}
+}
+
+// revert undoes one task's change, returning the revision it leaves the
+// task at (0 when deleted).
+func (s *Store) revert(ctx context.Context, c UndoChange) (uint64, error) {
+	switch {
+	case c.Before == nil:
+		_, err := s.DeleteTaskIf(ctx, c.ID, c.Revision)
+		if errors.Is(err, ErrConflict) {
+			return 0, fmt.Errorf("task %s changed since it was created; not deleting it: %w", c.Ref(), ErrConflict)
+		}
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return 0, err
+		}
+		return 0, nil
+	case c.Revision == 0:
+		t, err := s.restore(ctx, *c.Before)
+		return t.Revision, err
+	}
+	return s.restoreTask(ctx, c)
+}
+
+// restoreTask puts back the version of a task before a change, provided
+// nothing changed it since.
+func (s *Store) restoreTask(ctx context.Context, c UndoChange) (uint64, error) {
+	cur, rev, err := s.GetTask(ctx, c.ID)
+	if err != nil {
+		return 0, err
+	}
+	if rev != c.Revision {
+		return 0, fmt.Errorf("task %s changed since (revision %d, not %d); not reverting it: %w", cur.Ref(), rev, c.Revision, ErrConflict)
+	}
+	t := *c.Before
+	t.Number = cur.Number
+	t.touch(s.identity(ctx), time.Now())
+	if t.Revision, err = s.putTaskCAS(ctx, c.ID, t, rev); err != nil {
+		return 0, err
+	}
+	s.logWrite(ctx, "undo", c.ID, t.Revision)
+	s.auditUpdate(ctx, cur, t)
+	if t.Done && !cur.Done {
+		s.dropClaim(ctx, c.ID)
+	}
+	return t.Revision, errors.Join(s.reindexTags(ctx, c.ID, cur.Tags, t.Tags), s.reindexStatus(ctx, c.ID, &cur, &t))
+}
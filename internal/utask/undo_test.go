@@ -0,0 +1,117 @@
+package utask
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestUndo(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	a, _, err := s.CreateTask(ctx, TaskInput{Text: "a", Tags: []string{"x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _, err := s.CreateTask(ctx, TaskInput{Text: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := "a, edited"
+	if _, err := s.UpdateTask(ctx, a.ID, UpdateSet{Text: &text, Tags: &[]string{"y"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.CloseTask(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.DeleteTasks(ctx, []string{a.ID, b.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	undo := func(op string) {
+		t.Helper()
+		e, err := s.Undo(ctx)
+		if err != nil || e.Op != op {
+			t.Fatalf("Undo = %s, %v; want %s", e.Op, err, op)
+		}
+	}
+	undo("delete")
+	if got := listIDs(t, s, ListFilter{}); len(got) != 2 {
+		t.Fatalf("after undoing the delete: %d tasks, want 2", len(got))
+	}
+	undo("close")
+	got, _, err := s.GetTask(ctx, a.ID)
+	if err != nil || got.Done || got.Text != text || got.Number != a.Number {
+		t.Fatalf("after undoing the close: %+v, %v", got, err)
+	}
+	undo("update")
+	if got, _, _ = s.GetTask(ctx, a.ID); got.Text != "a" || !slices.Equal(got.Tags, []string{"x"}) {
+		t.Fatalf("after undoing the update: %+v", got)
+	}
+	if ids := listIDs(t, s, ListFilter{Any: []string{"x"}}); !slices.Equal(ids, []string{a.ID}) {
+		t.Fatalf("tag x lists %v", ids)
+	}
+	undo("create")
+	if _, _, err := s.GetTask(ctx, b.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("undone create left task b: %v", err)
+	}
+	undo("create")
+	if _, err := s.Undo(ctx); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Undo with nothing left = %v", err)
+	}
+}
+
+func TestUndoConflict(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	a, _, err := s.CreateTask(ctx, TaskInput{Text: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.CloseTask(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	// A change made without an undo entry, as by an older client.
+	text := "edited elsewhere"
+	if _, err := s.UpdateTask(withoutUndo(ctx), a.ID, UpdateSet{Text: &text}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Undo(ctx); !errors.Is(err, ErrConflict) {
+		t.Fatalf("Undo over a later change = %v, want ErrConflict", err)
+	}
+	// The entry is still there to retry.
+	if h, err := s.UndoHistory(ctx); err != nil || len(h) != 2 || h[0].Op != "close" {
+		t.Fatalf("UndoHistory = %+v, %v", h, err)
+	}
+}
+
+func TestUndoRing(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	s.opts.UndoDepth = 3
+	a, _, err := s.CreateTask(ctx, TaskInput{Text: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []int{2, 3, 4, 5} {
+		if _, err := s.UpdateTask(ctx, a.ID, UpdateSet{Priority: &p}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h, err := s.UndoHistory(ctx)
+	if err != nil || len(h) != 3 {
+		t.Fatalf("UndoHistory = %d entries, %v; want the last 3", len(h), err)
+	}
+	for range 3 {
+		if _, err := s.Undo(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.Undo(ctx); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Undo past the ring = %v", err)
+	}
+	if got, _, _ := s.GetTask(ctx, a.ID); got.Priority != 2 {
+		t.Fatalf("priority = %d, want 2", got.Priority)
+	}
+}
@@ -0,0 +1,126 @@
+package utask
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Default input limits. Text is bounded well below the NATS max payload so
+// encoded tasks always fit in one KV value.
+const (
+	DefaultMaxTextLen = 64 << 10
+	DefaultMaxTags    = 32
+	DefaultMaxTagLen  = 64
+)
+
+// DefaultTagPattern admits lowercase letters, digits and "_-./" after a
+// leading letter or digit.
+const DefaultTagPattern = `^[a-z0-9][a-z0-9_./-]*$`
+
+var defaultTagRe = regexp.MustCompile(DefaultTagPattern)
+
+// Limits bounds task input accepted by the Store. Zero fields use the
+// defaults above.
+type Limits struct {
+	MaxTextLen int // bytes of task text
+	MaxTags    int
+	MaxTagLen  int // bytes per tag
+	// TagPattern restricts normalized tag names. Tags must also be valid KV
+	// keys whatever the pattern allows.
+	TagPattern *regexp.Regexp
+}
+
+// ParseTagPattern compiles a configured tag pattern ("" means the default).
+func ParseTagPattern(s string) (*regexp.Regexp, error) {
+	if s == "" {
+		return defaultTagRe, nil
+	}
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag pattern: %w", err)
+	}
+	return re, nil
+}
+
+// Validate checks normalized text and tags against l.
+func (l Limits) Validate(text string, tags []string) error {
+	if err := l.validateText(text); err != nil {
+		return err
+	}
+	return l.validateTags(tags)
+}
+
+func (l Limits) validateText(text string) error {
+	if strings.TrimSpace(text) == "" {
+		return invalidf("empty text")
+	}
+	if maxText := orDefault(l.MaxTextLen, DefaultMaxTextLen); len(text) > maxText {
+		return invalidf("text is %d bytes; the limit is %d", len(text), maxText)
+	}
+	return nil
+}
+
+// validateDetails checks an optional body, which shares the text limit.
+func (l Limits) validateDetails(details string) error {
+	if maxText := orDefault(l.MaxTextLen, DefaultMaxTextLen); len(details) > maxText {
+		return invalidf("details are %d bytes; the limit is %d", len(details), maxText)
+	}
+	return nil
+}
+
+func (l Limits) validateTags(tags []string) error {
+	if maxTags := orDefault(l.MaxTags, DefaultMaxTags); len(tags) > maxTags {
+		return invalidf("%d tags; the limit is %d", len(tags), maxTags)
+	}
+	for _, tag := range tags {
+		if err := l.validateTag(tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l Limits) validateTag(tag string) error {
+	if maxLen := orDefault(l.MaxTagLen, DefaultMaxTagLen); len(tag) > maxLen {
+		return invalidf("tag %q is %d bytes; the limit is %d", tag, len(tag), maxLen)
+	}
+	re := l.TagPattern
+	if re == nil {
+		re = defaultTagRe
+	}
+	if !re.MatchString(tag) {
+		return invalidf("invalid tag %q: must match %s", tag, re)
+	}
+	// Tags are part of tags bucket keys, and "=" separated the overflow
+	// shards of the old layout.
+	if !validKVKey(tag) || strings.Contains(tag, shardSep) {
+		return invalidf("invalid tag %q: not usable as a KV key", tag)
+	}
+	return nil
+}
+
+// validKVKey reports whether s is a legal NATS KV key: characters from
+// [-/_=.a-zA-Z0-9], with no empty dot-separated token.
+func validKVKey(s string) bool {
+	if s == "" || s[0] == '.' || s[len(s)-1] == '.' || strings.Contains(s, "..") {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '/', c == '_', c == '=', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func orDefault(n, def int) int {
+	if n > 0 {
+		return n
+	}
+	return def
+}
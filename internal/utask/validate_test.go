@@ -0,0 +1,44 @@
+package utask
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLimitsValidate(t *testing.T) {
+	var l Limits
+	if err := l.Validate("buy milk", []string{"home", "errands/weekly", "p1"}); err != nil {
+		t.Fatalf("valid input rejected: %v", err)
+	}
+	bad := []struct {
+		text string
+		tags []string
+	}{
+		{"   ", nil},
+		{strings.Repeat("x", DefaultMaxTextLen+1), nil},
+		{"t", []string{"two words"}},
+		{"t", []string{"café"}},
+		{"t", []string{"a..b"}},
+		{"t", []string{strings.Repeat("a", DefaultMaxTagLen+1)}},
+		{"t", make([]string, DefaultMaxTags+1)},
+	}
+	for _, c := range bad {
+		if err := l.Validate(c.text, c.tags); !errors.Is(err, ErrValidation) {
+			t.Fatalf("Validate(%.20q, %q) = %v, want ErrValidation", c.text, c.tags, err)
+		}
+	}
+}
+
+func TestLimitsTagPatternKeepsKVSafety(t *testing.T) {
+	l := Limits{TagPattern: regexp.MustCompile(`.*`)}
+	for _, tag := range []string{"a=1", ".a", "a b"} {
+		if err := l.Validate("t", []string{tag}); err == nil {
+			t.Fatalf("tag %q accepted", tag)
+		}
+	}
+	if err := l.Validate("t", []string{"_x"}); err != nil {
+		t.Fatalf("custom pattern not applied: %v", err)
+	}
+}
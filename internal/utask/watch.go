@@ -0,0 +1,174 @@
+package utask
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// EventOp identifies the kind of change observed on the tasks bucket.
+type EventOp string
+
+const (
+	EventPut    EventOp = "put"
+	EventDelete EventOp = "delete"
+)
+
+// Event is a single change to a task as observed by the KV watcher.
+type Event struct {
+	Op EventOp `json:"op"`
+	// Kind is what the change did: created, updated, closed, cancelled,
+	// reopened or deleted.
+	Kind     ActivityKind `json:"kind"`
+	ID       string       `json:"id"`
+	Revision uint64       `json:"revision"`
+	Time     time.Time    `json:"time"`
+	// Task is the new revision, or for a delete the last one the watcher
+	// saw, when it saw one.
+	Task *Task `json:"task,omitempty"`
+}
+
+// WatchFilter narrows Watch. The zero value passes every change.
+type WatchFilter struct {
+	// Tag passes changes to tasks carrying the tag, the change that removes
+	// it, and deletes of tasks that carried it.
+	Tag string
+}
+
+// Watch streams changes to the tasks bucket until ctx is cancelled. Only
+// updates made after the call are delivered. The returned channel is closed
+// when the watcher stops.
+//
+// A task's first change seen by the watcher is classified from its new
+// revision alone: created if the task was created after the call, closed
+// or cancelled if it is now closed, updated otherwise. A Tag filter reads
+// the tagged tasks up front, so their changes are classified exactly.
+func (s *Store) Watch(ctx context.Context, f WatchFilter) (<-chan Event, error) {
+	since := time.Now().UTC().Truncate(time.Second)
+	w, err := watchUpdates(ctx, s.tasksKV)
+	if err != nil {
+		return nil, err
+	}
+	// seen holds the last revision the watcher knows of each task.
+	seen := map[string]Task{}
+	tag := strings.ToLower(strings.TrimSpace(f.Tag))
+	if tag != "" {
+		it, err := s.ListIter(ctx, ListFilter{Tag: tag})
+		if err != nil {
+			w.Stop()
+			return nil, err
+		}
+		for it.Next() {
+			t := it.Task()
+			seen[t.ID] = t
+		}
+		err = it.Err()
+		it.Close()
+		if err != nil {
+			w.Stop()
+			return nil, err
+		}
+	}
+	out := make(chan Event, 64)
+	go func() {
+		defer close(out)
+		defer w.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-w.Updates():
+				if !ok {
+					return
+				}
+				if e == nil {
+					continue
+				}
+				id := keyID(e.Key())
+				prev, known := seen[id]
+				if known && prev.Revision >= e.Revision() {
+					// Already read by the Tag preload.
+					continue
+				}
+				ev := Event{ID: id, Revision: e.Revision(), Time: e.Created().UTC()}
+				switch e.Operation() {
+				case jetstream.KeyValueDelete, jetstream.KeyValuePurge:
+					ev.Op, ev.Kind = EventDelete, ActivityDeleted
+					delete(seen, id)
+					if known {
+						if !s.canSee(ctx, prev) {
+							continue
+						}
+						ev.Task = &prev
+					} else if tag != "" {
+						continue
+					}
+				default:
+					ev.Op = EventPut
+					t, err := s.decodeTask(e.Value())
+					if err != nil {
+						if tag != "" {
+							continue
+						}
+						ev.Kind = ActivityUpdated
+						break
+					}
+					t.Revision = e.Revision()
+					ev.Kind = eventKind(t, prev, known, since)
+					if tag != "" && !hasTag(t, tag) {
+						delete(seen, id)
+						if !known {
+							continue
+						}
+					} else {
+						seen[id] = t
+					}
+					if !s.canSee(ctx, t) {
+						continue
+					}
+					ev.Task = &t
+				}
+				select {
+				case out <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// eventKind classifies the change that produced t. prev is the task's
+// previous revision when known.
+func eventKind(t, prev Task, known bool, since time.Time) ActivityKind {
+	now := t.State()
+	switch {
+	case !known && createdSince(t, since):
+		return ActivityCreated
+	case now.closed() && (!known || !prev.State().closed()):
+		if now == StatusCancelled {
+			return ActivityCancelled
+		}
+		return ActivityClosed
+	case known && prev.State().closed() && !now.closed():
+		return ActivityReopened
+	}
+	return ActivityUpdated
+}
+
+func createdSince(t Task, since time.Time) bool {
+	created, err := time.Parse(time.RFC3339, t.Created)
+	return err == nil && !created.Before(since)
+}
+
+func hasTag(t Task, tag string) bool {
+	for _, have := range t.Tags {
+		if have == tag {
+			return true
+		}
+	}
+	return false
+}
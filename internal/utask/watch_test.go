@@ -0,0 +1,78 @@
+package utask
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWatchTagFilter(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := openTestSQLite(t)
+	rent, _, err := s.CreateTask(ctx, TaskInput{Text: "Pay rent", Tags: []string{"home"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, _, err := s.CreateTask(ctx, TaskInput{Text: "Write report", Tags: []string{"work"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := s.Watch(ctx, WatchFilter{Tag: "Home"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The sqlite watcher polls and sees only a key's latest revision, so
+	// wait for each event before the next change.
+	next := func(kind ActivityKind, id string) {
+		t.Helper()
+		select {
+		case ev := <-events:
+			if ev.Kind != kind || ev.ID != id || ev.Task == nil || ev.Task.ID != id {
+				t.Fatalf("event %+v, want %s of %s", ev, kind, id[:8])
+			}
+		case <-ctx.Done():
+			t.Fatalf("no %s event", kind)
+		}
+	}
+	if _, _, err := s.CloseTask(ctx, report.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.CloseTask(ctx, rent.ID); err != nil {
+		t.Fatal(err)
+	}
+	next(ActivityClosed, rent.ID)
+	lawn, _, err := s.CreateTask(ctx, TaskInput{Text: "Mow the lawn", Tags: []string{"home"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next(ActivityCreated, lawn.ID)
+	if _, _, err := s.ReopenTask(ctx, rent.ID); err != nil {
+		t.Fatal(err)
+	}
+	next(ActivityReopened, rent.ID)
+	if _, err := s.DeleteTaskIf(ctx, lawn.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	next(ActivityDeleted, lawn.ID)
+}
+
+func TestEventKind(t *testing.T) {
+	since := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	older := Task{Created: "2026-02-01T00:00:00Z"}
+	newer := Task{Created: "2026-03-01T12:00:00Z"}
+	cancelled := older
+	cancelled.setStatus(StatusCancelled)
+	if k := eventKind(newer, Task{}, false, since); k != ActivityCreated {
+		t.Errorf("new task: %s", k)
+	}
+	if k := eventKind(older, Task{}, false, since); k != ActivityUpdated {
+		t.Errorf("unseen open task: %s", k)
+	}
+	if k := eventKind(cancelled, older, true, since); k != ActivityCancelled {
+		t.Errorf("cancelled task: %s", k)
+	}
+	if k := eventKind(older, cancelled, true, since); k != ActivityReopened {
+		t.Errorf("reopened task: %s", k)
+	}
+}
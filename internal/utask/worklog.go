@@ -0,0 +1,105 @@
+package utask
+
+import (
+	"context"
+	"time"
+)
+
+// WorkInterval is a span someone spent working on a task, e.g. one
+// pomodoro from `ut pomo`.
+type WorkInterval struct {
+	By    string    `json:"by,omitempty"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Duration is the length of the interval.
+func (w WorkInterval) Duration() time.Duration { return w.End.Sub(w.Start) }
+
+// TimeSpent sums the task's recorded work intervals.
+func (t Task) TimeSpent() time.Duration {
+	var d time.Duration
+	for _, w := range t.Worked {
+		d += w.Duration()
+	}
+	return d
+}
+
+// LogWork records that the caller worked on a task from start to end.
+// Intervals only ever append, so a concurrent change to the task is
+// re-read and retried rather than reported as ErrConflict.
+func (s *Store) LogWork(ctx context.Context, id string, start, end time.Time) (Task, error) {
+	if err := s.authorize(ctx, "log work", RoleContributor); err != nil {
+		return Task{}, err
+	}
+	w := WorkInterval{By: s.identity(ctx), Start: start.UTC().Truncate(time.Second), End: end.UTC().Truncate(time.Second)}
+	if !w.End.After(w.Start) {
+		return Task{}, invalidf("work interval must end after it starts")
+	}
+	var t Task
+	err := retryCAS(ctx, "log work on "+id, func() error {
+		cur, rev, err := s.GetTask(ctx, id)
+		if err != nil {
+			return err
+		}
+		cur.Worked = append(cur.Worked, w)
+		cur.touch(s.identity(ctx), time.Now())
+		newRev, err := s.putTaskCAS(ctx, id, cur, rev)
+		if err != nil {
+			return err
+		}
+		cur.Revision = newRev
+		t = cur
+		return nil
+	})
+	if err != nil {
+		return Task{}, err
+	}
+	s.logWrite(ctx, "log_work", id, t.Revision)
+	s.audit(ctx, ActivityWorked, t, FormatDuration(w.Duration()))
+	return t, nil
+}
+
+// StopWork ends the caller's claim on a task, as ReleaseClaim does, and
+// logs the time since the claim started (Claim.Since) as worked on it, so
+// `ut start` and `ut stop` make a timer. The interval is zero when the
+// caller held no claim or it ran under a second; nothing is logged then.
+func (s *Store) StopWork(ctx context.Context, id string) (Task, WorkInterval, error) {
+	if err := s.authorize(ctx, "log work", RoleContributor); err != nil {
+		return Task{}, WorkInterval{}, err
+	}
+	c, held, err := s.heldClaim(ctx, id)
+	if err != nil {
+		return Task{}, WorkInterval{}, err
+	}
+	if err := s.ReleaseClaim(ctx, id); err != nil {
+		return Task{}, WorkInterval{}, err
+	}
+	now := time.Now().UTC()
+	if !held || now.Sub(c.Since) < time.Second {
+		t, _, err := s.GetTask(ctx, id)
+		return t, WorkInterval{}, err
+	}
+	t, err := s.LogWork(ctx, id, c.Since, now)
+	if err != nil {
+		return Task{}, WorkInterval{}, err
+	}
+	return t, t.Worked[len(t.Worked)-1], nil
+}
+
+// Timers returns the caller's active claims, the timers `ut active` shows,
+// longest running first.
+func (s *Store) Timers(ctx context.Context) ([]Claim, error) {
+	all, err := s.Claims(ctx)
+	if err != nil {
+		return nil, err
+	}
+	who, worker := s.identity(ctx), workerOf(ctx)
+	out := []Claim{}
+	for _, c := range all {
+		if c.heldBy(who, worker) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,66 @@
+package utask
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimeSpent(t *testing.T) {
+	start := time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC)
+	task := Task{Worked: []WorkInterval{
+		{Start: start, End: start.Add(25 * time.Minute)},
+		{Start: start.Add(30 * time.Minute), End: start.Add(40 * time.Minute)},
+	}}
+	if got := task.TimeSpent(); got != 35*time.Minute {
+		t.Fatalf("TimeSpent() = %v", got)
+	}
+	if got := (Task{}).TimeSpent(); got != 0 {
+		t.Fatalf("empty TimeSpent() = %v", got)
+	}
+}
+
+func TestStopWork(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	task, _, err := s.CreateTask(ctx, TaskInput{Text: "profile the importer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.ClaimTask(ctx, task.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timers, err := s.Timers(ctx); err != nil || len(timers) != 1 || timers[0].Task != task.ID {
+		t.Fatalf("Timers = %v, %v", timers, err)
+	}
+	if timers, err := s.Timers(WithWorker(ctx, "w1")); err != nil || len(timers) != 0 {
+		t.Fatalf("another worker's Timers = %v, %v", timers, err)
+	}
+
+	// Backdate the claim so the timer has run for 90 minutes.
+	c.Since = c.Since.Add(-90 * time.Minute)
+	b, _ := json.Marshal(c)
+	if _, err := s.metaKV.Put(ctx, claimKeyPrefix+task.ID, b); err != nil {
+		t.Fatal(err)
+	}
+	got, w, err := s.StopWork(ctx, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := w.Duration(); d < 90*time.Minute || d > 91*time.Minute || got.TimeSpent() != d {
+		t.Fatalf("StopWork logged %v, task has %v", d, got.TimeSpent())
+	}
+	if got.State() != StatusOpen {
+		t.Fatalf("state after stop = %s", got.State())
+	}
+	if timers, err := s.Timers(ctx); err != nil || len(timers) != 0 {
+		t.Fatalf("Timers after stop = %v, %v", timers, err)
+	}
+
+	// Without a running timer nothing is logged.
+	if got, w, err := s.StopWork(ctx, task.ID); err != nil || !w.End.IsZero() || len(got.Worked) != 1 {
+		t.Fatalf("second StopWork = %v, %+v, %v", got.Worked, w, err)
+	}
+}